@@ -0,0 +1,5 @@
+// Package pombumpv1 is the gRPC API of pombump, generated from
+// pombump.proto. The server is in pkg/rpc, and `pombump serve` runs it.
+package pombumpv1
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative pombump.proto
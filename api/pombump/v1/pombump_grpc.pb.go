@@ -0,0 +1,250 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: pombump.proto
+
+package pombumpv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PombumpService_Analyze_FullMethodName       = "/pombump.v1.PombumpService/Analyze"
+	PombumpService_Patch_FullMethodName         = "/pombump.v1.PombumpService/Patch"
+	PombumpService_AnalyzeStream_FullMethodName = "/pombump.v1.PombumpService/AnalyzeStream"
+	PombumpService_PatchStream_FullMethodName   = "/pombump.v1.PombumpService/PatchStream"
+)
+
+// PombumpServiceClient is the client API for PombumpService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PombumpService analyzes and patches POMs, as the analyze command and the
+// root command of the pombump CLI do. The POMs are either read from the
+// filesystem of the server, or sent along with the request.
+type PombumpServiceClient interface {
+	// Analyze analyzes a POM and, given patches, recommends how to apply
+	// them: which dependencies to bump directly and which properties to
+	// update.
+	Analyze(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*AnalyzeResponse, error)
+	// Patch applies patches and property patches, as they are, to a POM and
+	// returns the patched POM.
+	Patch(ctx context.Context, in *PatchRequest, opts ...grpc.CallOption) (*PatchResponse, error)
+	// AnalyzeStream analyzes every POM sent, for batch and monorepo
+	// operations. A response is sent for each request, in the same order,
+	// and a POM failing to be analyzed does not end the stream.
+	AnalyzeStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[AnalyzeRequest, AnalyzeResponse], error)
+	// PatchStream patches every POM sent, the same way.
+	PatchStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[PatchRequest, PatchResponse], error)
+}
+
+type pombumpServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPombumpServiceClient(cc grpc.ClientConnInterface) PombumpServiceClient {
+	return &pombumpServiceClient{cc}
+}
+
+func (c *pombumpServiceClient) Analyze(ctx context.Context, in *AnalyzeRequest, opts ...grpc.CallOption) (*AnalyzeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AnalyzeResponse)
+	err := c.cc.Invoke(ctx, PombumpService_Analyze_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pombumpServiceClient) Patch(ctx context.Context, in *PatchRequest, opts ...grpc.CallOption) (*PatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PatchResponse)
+	err := c.cc.Invoke(ctx, PombumpService_Patch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pombumpServiceClient) AnalyzeStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[AnalyzeRequest, AnalyzeResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PombumpService_ServiceDesc.Streams[0], PombumpService_AnalyzeStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AnalyzeRequest, AnalyzeResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PombumpService_AnalyzeStreamClient = grpc.BidiStreamingClient[AnalyzeRequest, AnalyzeResponse]
+
+func (c *pombumpServiceClient) PatchStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[PatchRequest, PatchResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PombumpService_ServiceDesc.Streams[1], PombumpService_PatchStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[PatchRequest, PatchResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PombumpService_PatchStreamClient = grpc.BidiStreamingClient[PatchRequest, PatchResponse]
+
+// PombumpServiceServer is the server API for PombumpService service.
+// All implementations must embed UnimplementedPombumpServiceServer
+// for forward compatibility.
+//
+// PombumpService analyzes and patches POMs, as the analyze command and the
+// root command of the pombump CLI do. The POMs are either read from the
+// filesystem of the server, or sent along with the request.
+type PombumpServiceServer interface {
+	// Analyze analyzes a POM and, given patches, recommends how to apply
+	// them: which dependencies to bump directly and which properties to
+	// update.
+	Analyze(context.Context, *AnalyzeRequest) (*AnalyzeResponse, error)
+	// Patch applies patches and property patches, as they are, to a POM and
+	// returns the patched POM.
+	Patch(context.Context, *PatchRequest) (*PatchResponse, error)
+	// AnalyzeStream analyzes every POM sent, for batch and monorepo
+	// operations. A response is sent for each request, in the same order,
+	// and a POM failing to be analyzed does not end the stream.
+	AnalyzeStream(grpc.BidiStreamingServer[AnalyzeRequest, AnalyzeResponse]) error
+	// PatchStream patches every POM sent, the same way.
+	PatchStream(grpc.BidiStreamingServer[PatchRequest, PatchResponse]) error
+	mustEmbedUnimplementedPombumpServiceServer()
+}
+
+// UnimplementedPombumpServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPombumpServiceServer struct{}
+
+func (UnimplementedPombumpServiceServer) Analyze(context.Context, *AnalyzeRequest) (*AnalyzeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Analyze not implemented")
+}
+func (UnimplementedPombumpServiceServer) Patch(context.Context, *PatchRequest) (*PatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Patch not implemented")
+}
+func (UnimplementedPombumpServiceServer) AnalyzeStream(grpc.BidiStreamingServer[AnalyzeRequest, AnalyzeResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method AnalyzeStream not implemented")
+}
+func (UnimplementedPombumpServiceServer) PatchStream(grpc.BidiStreamingServer[PatchRequest, PatchResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method PatchStream not implemented")
+}
+func (UnimplementedPombumpServiceServer) mustEmbedUnimplementedPombumpServiceServer() {}
+func (UnimplementedPombumpServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafePombumpServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PombumpServiceServer will
+// result in compilation errors.
+type UnsafePombumpServiceServer interface {
+	mustEmbedUnimplementedPombumpServiceServer()
+}
+
+func RegisterPombumpServiceServer(s grpc.ServiceRegistrar, srv PombumpServiceServer) {
+	// If the following call pancis, it indicates UnimplementedPombumpServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PombumpService_ServiceDesc, srv)
+}
+
+func _PombumpService_Analyze_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnalyzeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PombumpServiceServer).Analyze(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PombumpService_Analyze_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PombumpServiceServer).Analyze(ctx, req.(*AnalyzeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PombumpService_Patch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PombumpServiceServer).Patch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PombumpService_Patch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PombumpServiceServer).Patch(ctx, req.(*PatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PombumpService_AnalyzeStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PombumpServiceServer).AnalyzeStream(&grpc.GenericServerStream[AnalyzeRequest, AnalyzeResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PombumpService_AnalyzeStreamServer = grpc.BidiStreamingServer[AnalyzeRequest, AnalyzeResponse]
+
+func _PombumpService_PatchStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PombumpServiceServer).PatchStream(&grpc.GenericServerStream[PatchRequest, PatchResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PombumpService_PatchStreamServer = grpc.BidiStreamingServer[PatchRequest, PatchResponse]
+
+// PombumpService_ServiceDesc is the grpc.ServiceDesc for PombumpService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PombumpService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pombump.v1.PombumpService",
+	HandlerType: (*PombumpServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Analyze",
+			Handler:    _PombumpService_Analyze_Handler,
+		},
+		{
+			MethodName: "Patch",
+			Handler:    _PombumpService_Patch_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "AnalyzeStream",
+			Handler:       _PombumpService_AnalyzeStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "PatchStream",
+			Handler:       _PombumpService_PatchStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "pombump.proto",
+}
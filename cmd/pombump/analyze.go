@@ -1,10 +1,17 @@
 package pombump
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
 
-	"github.com/chainguard-dev/gopom"
+	"github.com/chainguard-dev/clog"
 	"github.com/chainguard-dev/pombump/pkg"
 	"github.com/ghodss/yaml"
 	"github.com/spf13/cobra"
@@ -17,16 +24,60 @@ type analyzeCLIFlags struct {
 	outputDeps       string
 	outputProperties string
 	searchProperties bool
+	fromGrype        string
+	fromTrivy        string
+	osvCacheDir      string
+	repository       string
+	exclude          []string
+	include          []string
+	verifyVersions   bool
+	force            bool
+	syncMismatches   bool
+	allModules       bool
+	groupByAdvisory  bool
+	resolveBOMs      bool
+	resolveLicenses  bool
+	overrideBOMs     bool
+	bomPatterns      string
+	strategy         string
+	conflictPolicy   string
+	failOn           []string
+	effectivePOM     bool
+	jobs             int
+	streamAbove      int64
+	lenient          bool
+	strategyRules    string
+	pins             []string
+	skipScopes       []string
+	optionalPolicy   string
+	outputTemplate   string
+	baseline         string
+	saveBaseline     string
+}
+
+// recommendations is everything analyze recommends for a set of patches.
+type recommendations struct {
+	directPatches   []pkg.Patch
+	propertyPatches map[string]string
+	parentDelta     *pkg.ParentDelta
+	bomBumps        []pkg.BOMBump
+	conflicts       []pkg.VersionConflict
+	unfixable       []pkg.UnfixableIssue
+	suppressed      []pkg.SuppressedIssue
+	skipped         []pkg.SkippedPatch
+	candidates      []pkg.CandidateChoice
 }
 
 var analyzeFlags analyzeCLIFlags
 
 func AnalyzeCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "analyze <pom-file>",
+		Use:   "analyze <pom-file>...",
 		Short: "Analyze a POM file to understand dependency structure",
 		Long: `Analyze a POM file to understand how dependencies are defined.
 This command helps determine whether to use direct dependency patches or property updates.
+A Gradle build script (build.gradle or build.gradle.kts) is analyzed the same way, its
+variables and gradle.properties standing in for the POM properties.
 
 Examples:
   # Analyze a POM and show report
@@ -43,84 +94,506 @@ Examples:
     --output-deps pombump-deps.yaml \
     --output-properties pombump-properties.yaml
     
+  # Fetch the imported BOMs to know which versions they manage
+  pombump analyze pom.xml --resolve-boms --patches "io.netty@netty-handler@4.1.94.Final"
+
+  # Let Maven resolve the inherited versions, e.g. without access to the remote repository
+  pombump analyze pom.xml --effective-pom --patches "io.netty@netty-handler@4.1.94.Final"
+
+  # Analyze a Gradle build
+  pombump analyze build.gradle.kts --patches "io.netty@netty-handler@4.1.94.Final"
+
+  # Analyze every module of a multi-module project as JSON
+  pombump analyze pom.xml --all-modules --output json
+
+  # Analyze every POM under the current directory, one JSON record per file
+  pombump analyze '**/pom.xml' --output ndjson --jobs 4
+
+  # Analyze what can be recovered of a POM that is not well-formed XML
+  pombump analyze pom.xml --lenient
+
   # Search for properties in entire project tree
-  pombump analyze pom.xml --search-properties --patches "org.assertj@assertj-core@3.25.0"`,
-		Args: cobra.ExactArgs(1),
+  pombump analyze pom.xml --search-properties --patches "org.assertj@assertj-core@3.25.0"
+
+  # Search for properties, but also look at a POM in an otherwise ignored directory
+  pombump analyze pom.xml --search-properties --include "build/bom/pom.xml"
+
+  # Bump to the newest release, or the newest release of the current major.minor
+  pombump analyze pom.xml --patches "io.netty@netty-handler@latest org.slf4j@slf4j-api@latest-patch"
+
+  # Only recommend versions that are actually published
+  pombump analyze pom.xml --verify-versions --patches "io.netty@netty-handler@4.1.94.Final"
+
+  # Pick the first published of several acceptable versions, or the lowest release above a minimum
+  pombump analyze pom.xml --patches "io.netty@netty-handler@4.1.118.Final|4.1.119.Final org.slf4j@slf4j-api@>=2.0.10"
+
+  # Let OSV figure out the version that fixes an advisory
+  pombump analyze pom.xml --patches "io.netty@netty-handler@CVE-2023-34462"
+
+  # Never bump shared properties, patch each dependency with a literal version
+  pombump analyze pom.xml --strategy prefer-direct --patches "io.netty@netty-handler@4.1.94.Final"
+
+  # Fail the CI job when the patches conflict or a requested version is not published
+  pombump analyze pom.xml --verify-versions --fail-on conflicts,unfixable --patch-file pombump-deps.yaml
+
+  # Tell which BOM manages dependencies whose BOM is not named *-bom
+  pombump analyze pom.xml --bom-patterns boms.yaml
+
+  # Report each patch, passing if already satisfied, and each warning as JUnit XML
+  pombump analyze pom.xml --patch-file pombump-deps.yaml --output junit > pombump-junit.xml
+
+  # Use the fixed versions from a Grype scan (grype -o json) as patches
+  pombump analyze pom.xml --from-grype scan.json
+
+  # Use the fixed versions from a Trivy scan (trivy -f json) as patches
+  pombump analyze pom.xml --from-trivy report.json
+
+  # Only report what changed since the last scan, and save this one for the next
+  pombump analyze pom.xml --from-grype scan.json --baseline last.json --save-baseline last.json
+
+  # Also write one patch file per advisory, plus an index of them
+  pombump analyze pom.xml --from-grype scan.json --group-by-advisory \
+    --output-deps pombump-deps.yaml \
+    --output-properties pombump-properties.yaml`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Analyze the project (with property search if requested)
 			var analysis *pkg.AnalysisResult
 			var err error
 			
-			if analyzeFlags.searchProperties {
+			if analyzeFlags.allModules && analyzeFlags.searchProperties {
+				return fmt.Errorf("use either --all-modules or --search-properties")
+			}
+			if analyzeFlags.allModules && analyzeFlags.effectivePOM {
+				return fmt.Errorf("use either --all-modules or --effective-pom")
+			}
+			if analyzeFlags.groupByAdvisory && analyzeFlags.outputDeps == "" && analyzeFlags.outputProperties == "" {
+				return fmt.Errorf("--group-by-advisory requires --output-deps or --output-properties")
+			}
+
+			strategy, err := pkg.ParseStrategy(analyzeFlags.strategy)
+			if err != nil {
+				return err
+			}
+			conflictPolicy, err := pkg.ParseConflictPolicy(analyzeFlags.conflictPolicy)
+			if err != nil {
+				return err
+			}
+			if err := validateFailOn(analyzeFlags.failOn); err != nil {
+				return err
+			}
+			var outputTemplate *template.Template
+			if analyzeFlags.outputTemplate != "" {
+				if analyzeFlags.outputFormat != "human" {
+					return fmt.Errorf("use either --output or --output-template")
+				}
+				if outputTemplate, err = pkg.LoadOutputTemplate(analyzeFlags.outputTemplate); err != nil {
+					return err
+				}
+			}
+			var baseline *pkg.AnalysisOutput
+			if analyzeFlags.baseline != "" {
+				if !slices.Contains([]string{"human", "json", "yaml"}, analyzeFlags.outputFormat) || outputTemplate != nil {
+					return fmt.Errorf("--baseline reports the changes as human, json or yaml output")
+				}
+				if baseline, err = pkg.ReadAnalysisOutput(analyzeFlags.baseline); err != nil {
+					return err
+				}
+			}
+			skipScopes, err := pkg.ParseScopes(analyzeFlags.skipScopes)
+			if err != nil {
+				return err
+			}
+			analyzeOpts := []pkg.AnalyzeOption{pkg.WithJobs(analyzeFlags.jobs), pkg.WithStreamingThreshold(analyzeFlags.streamAbove), pkg.WithSettings(mavenSettings), pkg.WithSkippedScopes(skipScopes), pkg.WithPositions()}
+			if analyzeFlags.lenient {
+				analyzeOpts = append(analyzeOpts, pkg.WithLenientParsing())
+			}
+			if analyzeFlags.bomPatterns != "" {
+				patterns, err := pkg.LoadBOMPatterns(analyzeFlags.bomPatterns)
+				if err != nil {
+					return err
+				}
+				analyzeOpts = append(analyzeOpts, pkg.WithBOMPatterns(patterns))
+			}
+
+			// Several files, or a glob even if it matches one, are
+			// analyzed together.
+			paths, err := pkg.ExpandGlobs(args, pkg.NewPathFilter(analyzeFlags.exclude, analyzeFlags.include))
+			if err != nil {
+				return err
+			}
+			batch := len(paths) > 1 || pkg.IsGlob(args[0])
+			if batch && (analyzeFlags.allModules || analyzeFlags.searchProperties || analyzeFlags.effectivePOM) {
+				return fmt.Errorf("--all-modules, --search-properties and --effective-pom analyze a single POM")
+			}
+			if analyzeFlags.outputFormat == "diff" && (batch || analyzeFlags.allModules || !hasAnalyzePatches()) {
+				return fmt.Errorf("--output diff shows the changes patches make to a single POM")
+			}
+			if analyzeFlags.outputFormat == "ndjson" {
+				if !batch {
+					return fmt.Errorf("--output ndjson is for analyzing several files")
+				}
+				if analyzeFlags.outputDeps != "" || analyzeFlags.outputProperties != "" || analyzeFlags.groupByAdvisory {
+					return fmt.Errorf("--output-deps, --output-properties and --group-by-advisory can not be used with --output ndjson")
+				}
+				return outputNDJSON(cmd, pkg.AnalyzeFiles(cmd.Context(), paths, analyzeFlags.jobs, analyzeOpts...), strategy, conflictPolicy)
+			}
+
+			gradle := !batch && pkg.IsGradleBuild(args[0])
+			if gradle && (analyzeFlags.allModules || analyzeFlags.searchProperties || analyzeFlags.effectivePOM) {
+				return fmt.Errorf("--all-modules, --search-properties and --effective-pom are not supported for Gradle builds")
+			}
+
+			if batch {
+				analysis, err = pkg.MergeFileAnalyses(cmd.Context(), pkg.AnalyzeFiles(cmd.Context(), paths, analyzeFlags.jobs, analyzeOpts...))
+				if err != nil {
+					return err
+				}
+			} else if gradle {
+				build, err := pkg.LoadGradleBuild(args[0])
+				if err != nil {
+					return err
+				}
+				analysis = pkg.AnalyzeGradle(cmd.Context(), build)
+			} else if analyzeFlags.allModules {
+				// Merge the analysis of every module of the reactor
+				modules, err := pkg.DiscoverModules(cmd.Context(), args[0], pkg.WithJobs(analyzeFlags.jobs))
+				if err != nil {
+					return fmt.Errorf("failed to discover modules: %w", err)
+				}
+				analysis, err = pkg.AnalyzeReactor(cmd.Context(), modules, pkg.WithJobs(analyzeFlags.jobs), pkg.WithSkippedScopes(skipScopes), pkg.WithPositions())
+				if err != nil {
+					return fmt.Errorf("failed to analyze project: %w", err)
+				}
+			} else if analyzeFlags.searchProperties {
 				// Use enhanced analysis that searches for properties
-				analysis, err = pkg.AnalyzeProjectPath(cmd.Context(), args[0])
+				filter := pkg.NewPathFilter(analyzeFlags.exclude, analyzeFlags.include)
+				analysis, err = pkg.AnalyzeProjectPathWithFilter(cmd.Context(), args[0], filter, analyzeOpts...)
 				if err != nil {
 					return fmt.Errorf("failed to analyze project: %w", err)
 				}
+				if err := analysis.ValidateSchema(args[0]); err != nil {
+					return err
+				}
 			} else {
 				// Use basic analysis (single file only)
-				parsedPom, err := gopom.Parse(args[0])
+				parsedPom, err := pkg.ParseAnalysisPOM(cmd.Context(), args[0], analyzeOpts...)
 				if err != nil {
 					return fmt.Errorf("failed to parse POM file: %w", err)
 				}
 				
-				analysis, err = pkg.AnalyzeProject(cmd.Context(), parsedPom)
+				analysis, err = pkg.AnalyzeProject(cmd.Context(), parsedPom, analyzeOpts...)
 				if err != nil {
 					return fmt.Errorf("failed to analyze project: %w", err)
 				}
+				if err := analysis.LocateDeclarations(args[0]); err != nil {
+					return err
+				}
+				if err := analysis.ValidateSchema(args[0]); err != nil {
+					return err
+				}
+			}
+			analysis.Suppress(suppressions)
+
+			if analyzeFlags.resolveBOMs || analyzeFlags.overrideBOMs || strategy == pkg.StrategyPreferBOM {
+				analysis.ResolveBOMs(cmd.Context(), newRepository(analyzeFlags.repository))
+			}
+			if analyzeFlags.effectivePOM {
+				// Maven may reach what pombump can not, e.g. through
+				// mirrors configured in its settings or its local
+				// repository.
+				effective, err := pkg.EffectivePOM(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+				analysis.ApplyEffectivePOM(cmd.Context(), effective)
+			}
+			if analyzeFlags.resolveLicenses {
+				analysis.ResolveLicenses(cmd.Context(), newRepository(analyzeFlags.repository))
+			}
+
+			// Renovate and the graph take over from here, patches or
+			// not.
+			if analyzeFlags.outputFormat == "renovate" {
+				output, err := analysis.RenovateConfig().JSON()
+				if err != nil {
+					return fmt.Errorf("failed to render Renovate configuration: %w", err)
+				}
+				fmt.Println(string(output))
+				return failOn(cmd, analysis, recommendations{})
+			}
+			if analyzeFlags.outputFormat == "dot" {
+				fmt.Print(analysis.DOT())
+				return failOn(cmd, analysis, recommendations{})
 			}
 
 			// If patches are provided, analyze them
-			if analyzeFlags.patches != "" || analyzeFlags.patchFile != "" {
-				patches, err := pkg.ParsePatches(cmd.Context(), analyzeFlags.patchFile, analyzeFlags.patches)
+			if hasAnalyzePatches() {
+				patches, err := parseAnalyzePatches(cmd.Context())
+				if err != nil {
+					return err
+				}
+				recs, patches, strategyOpts, err := recommend(cmd.Context(), analysis, patches, strategy, conflictPolicy)
 				if err != nil {
-					return fmt.Errorf("failed to parse patches: %w", err)
+					return err
 				}
+				directPatches, propertyPatches := recs.directPatches, recs.propertyPatches
 
-				directPatches, propertyPatches := pkg.PatchStrategy(cmd.Context(), analysis, patches)
+				// Bumping the parent can change a lot more than one line,
+				// so resolve both parent versions and report the delta.
+				// Gradle builds have no parent, nor a batch of files a
+				// single one.
+				if !gradle && !batch {
+					parsedPom, err := pkg.ParseAnalysisPOM(cmd.Context(), args[0], analyzeOpts...)
+					if err != nil {
+						return fmt.Errorf("failed to parse POM file: %w", err)
+					}
+					if parentPatch, found := pkg.FindParentPatch(parsedPom, patches); found {
+						recs.parentDelta, err = pkg.ParentBumpDelta(cmd.Context(), newRepository(analyzeFlags.repository), parsedPom, parentPatch.Version)
+						if err != nil {
+							clog.FromContext(cmd.Context()).Warnf("Unable to compute the effect of the parent bump: %v", err)
+						}
+					}
+				}
 
 				// Output recommendations
-				if analyzeFlags.outputFormat == "yaml" {
-					outputYAML(directPatches, propertyPatches)
+				if baseline != nil {
+					if err := outputBaselineChanges(baseline, analysisOutput(paths, analysis, recs)); err != nil {
+						return err
+					}
+				} else if outputTemplate != nil {
+					if err := pkg.RenderOutput(os.Stdout, outputTemplate, analysisOutput(paths, analysis, recs)); err != nil {
+						return err
+					}
+				} else if analyzeFlags.outputFormat == "diff" {
+					if gradle {
+						return fmt.Errorf("--output diff is not supported for Gradle builds")
+					}
+					if err := outputDiff(cmd.Context(), args[0], directPatches, propertyPatches); err != nil {
+						return err
+					}
+				} else if analyzeFlags.outputFormat == "junit" {
+					if err := outputJUnit(pkg.NewJUnitReport(strings.Join(paths, ", "), analysis, patches, recs.unfixable, recs.skipped)); err != nil {
+						return err
+					}
+				} else if analyzeFlags.outputFormat == "yaml" {
+					outputYAML(analysis, recs)
+				} else if analyzeFlags.outputFormat == "json" {
+					if err := outputJSON(analysisOutput(paths, analysis, recs)); err != nil {
+						return err
+					}
 				} else {
-					outputAnalysisReport(analysis, directPatches, propertyPatches)
+					outputAnalysisReport(analysis, recs)
 				}
 
 				// Write files if requested
 				if analyzeFlags.outputDeps != "" && len(directPatches) > 0 {
-					if err := writeDepsFile(analyzeFlags.outputDeps, directPatches); err != nil {
+					if err := writeDepsFile(cmd.Context(), analyzeFlags.outputDeps, directPatches); err != nil {
 						return fmt.Errorf("failed to write deps file: %w", err)
 					}
 					fmt.Printf("\nWrote %d patches to %s\n", len(directPatches), analyzeFlags.outputDeps)
 				}
 
 				if analyzeFlags.outputProperties != "" && len(propertyPatches) > 0 {
-					if err := writePropertiesFile(analyzeFlags.outputProperties, propertyPatches); err != nil {
+					if err := writePropertiesFile(cmd.Context(), analyzeFlags.outputProperties, propertyPatches); err != nil {
 						return fmt.Errorf("failed to write properties file: %w", err)
 					}
 					fmt.Printf("Wrote %d properties to %s\n", len(propertyPatches), analyzeFlags.outputProperties)
 				}
+
+				if analyzeFlags.groupByAdvisory {
+					if err := writeAdvisoryFiles(cmd.Context(), analysis, patches, strategyOpts); err != nil {
+						return fmt.Errorf("failed to write advisory files: %w", err)
+					}
+				}
+				if err := saveBaseline(paths, analysis, recs); err != nil {
+					return err
+				}
+				return failOn(cmd, analysis, recs)
+			} else if baseline != nil {
+				if err := outputBaselineChanges(baseline, analysisOutput(paths, analysis, recommendations{})); err != nil {
+					return err
+				}
+			} else if outputTemplate != nil {
+				if err := pkg.RenderOutput(os.Stdout, outputTemplate, analysisOutput(paths, analysis, recommendations{})); err != nil {
+					return err
+				}
+			} else if analyzeFlags.outputFormat == "junit" {
+				if err := outputJUnit(pkg.NewJUnitReport(strings.Join(paths, ", "), analysis, nil, nil, nil)); err != nil {
+					return err
+				}
+			} else if analyzeFlags.outputFormat == "json" && !analyzeFlags.allModules && !batch {
+				if err := outputJSON(analysisOutput(paths, analysis, recommendations{})); err != nil {
+					return err
+				}
+			} else if (analyzeFlags.allModules || batch) && analyzeFlags.outputFormat != "human" {
+				if err := outputAggregateReport(analysis.AggregateReport(), analyzeFlags.outputFormat); err != nil {
+					return err
+				}
 			} else {
 				// Just output the analysis report
 				fmt.Println(analysis.AnalysisReport())
 			}
 
-			return nil
+			if err := saveBaseline(paths, analysis, recommendations{}); err != nil {
+				return err
+			}
+			return failOn(cmd, analysis, recommendations{})
 		},
 	}
 
 	flagSet := cmd.Flags()
-	flagSet.StringVar(&analyzeFlags.patches, "patches", "", "Space-separated list of patches to analyze (groupID@artifactID@version)")
+	flagSet.StringVar(&analyzeFlags.patches, "patches", "", "Space-separated list of patches to analyze (groupID@artifactID@version or pkg:maven/groupID/artifactID@version)")
 	flagSet.StringVar(&analyzeFlags.patchFile, "patch-file", "", "File containing patches to analyze")
-	flagSet.StringVar(&analyzeFlags.outputFormat, "output", "human", "Output format: human or yaml")
-	flagSet.StringVar(&analyzeFlags.outputDeps, "output-deps", "", "Write recommended dependency patches to this file")
-	flagSet.StringVar(&analyzeFlags.outputProperties, "output-properties", "", "Write recommended property patches to this file")
+	flagSet.StringVar(&analyzeFlags.outputFormat, "output", "human", "Output format: human, yaml, diff (the changes to the POM as a unified diff, given patches), renovate (packageRules and customManagers for renovate.json), dot (a Graphviz graph of the properties and BOMs controlling each version), json (the analysis and recommendations, see pombump schema analysis; the aggregate report with --all-modules or several files and no patches), junit (a JUnit XML report, a test case per patch, passing if already satisfied, and per warning), and ndjson, one record per file, with several files")
+	flagSet.StringVar(&analyzeFlags.baseline, "baseline", "", "Analysis written by --save-baseline or --output json to compare with: only report the issues new and resolved since, and the BOMs imported, dropped or bumped")
+	flagSet.StringVar(&analyzeFlags.saveBaseline, "save-baseline", "", "Also write the analysis and recommendations to this file (JSON if it ends in .json, YAML otherwise), for a later run to compare with --baseline")
+	flagSet.StringVar(&analyzeFlags.outputTemplate, "output-template", "", "Go text/template file to render the analysis and recommendations through instead (see AnalysisOutput), e.g. for a Slack message or a commit body")
+	flagSet.IntVar(&analyzeFlags.jobs, "jobs", 0, "How many POM files to parse and analyze at once: given several files or a glob, with --all-modules, or in the --search-properties search (defaults to the number of CPUs)")
+	flagSet.Int64Var(&analyzeFlags.streamAbove, "stream-above", pkg.DefaultStreamingThreshold, "Size in bytes above which a POM is streamed rather than read whole, to bound memory on generated POMs (negative never streams)")
+	flagSet.BoolVar(&analyzeFlags.lenient, "lenient", false, "Analyze what can be recovered of a POM that is not well-formed XML, with a malformed-pom warning, instead of failing")
+	flagSet.BoolVar(&analyzeFlags.allModules, "all-modules", false, "Analyze every module of the reactor and report them together")
+	flagSet.StringVar(&analyzeFlags.outputDeps, "output-deps", "", "Write recommended dependency patches to this file (JSON if it ends in .json, YAML otherwise)")
+	flagSet.StringVar(&analyzeFlags.outputProperties, "output-properties", "", "Write recommended property patches to this file (JSON if it ends in .json, YAML otherwise)")
+	flagSet.BoolVar(&analyzeFlags.resolveBOMs, "resolve-boms", false, "Fetch the imported BOMs from the repository to report which versions they manage")
+	flagSet.BoolVar(&analyzeFlags.resolveLicenses, "resolve-licenses", false, "Fetch the POM of every dependency from the repository to report its licenses (with --resolve-boms for the versions BOMs manage)")
+	flagSet.BoolVar(&analyzeFlags.overrideBOMs, "override-boms", false, "Pin the fixed version of artifacts managed by an imported BOM with a dependencyManagement entry ahead of it (implies --resolve-boms)")
+	flagSet.BoolVar(&analyzeFlags.effectivePOM, "effective-pom", false, "Run \"mvn help:effective-pom\" (mvn must be on the PATH) to learn the inherited properties and managed versions pombump can not resolve itself")
+	flagSet.StringVar(&analyzeFlags.bomPatterns, "bom-patterns", "", "YAML file of known BOM coordinates and group-to-BOM mappings, to tell which BOM manages a dependency without resolving them")
+	flagSet.StringVar(&analyzeFlags.strategy, "strategy", string(pkg.StrategyAuto), "How to patch dependencies: auto (the heuristics), prefer-property, prefer-direct or prefer-bom (implies resolving the BOMs)")
+	flagSet.StringVar(&analyzeFlags.strategyRules, "strategy-rules", "", "YAML or JSON file of rules forbidding some bumps (e.g. major bumps of guava), whose patches are dropped")
+	flagSet.StringVar(&analyzeFlags.optionalPolicy, "optional-dependencies", string(pkg.OptionalPolicyInclude), "How to treat the patches of <optional> dependencies: include them, skip them, or deprioritize them (patched after the others, never bumping a property they share with required dependencies)")
+	flagSet.StringSliceVar(&analyzeFlags.skipScopes, "skip-scopes", nil, "Scopes of the dependencies to leave out of the analysis, the recommendations and the issue counts (e.g. test,provided)")
+	flagSet.StringSliceVar(&analyzeFlags.pins, "pin", nil, "Dependencies never to patch, as groupId:artifactId, or never to patch past a version, as groupId:artifactId@version (* matches any part)")
+	flagSet.StringVar(&analyzeFlags.conflictPolicy, "conflict-policy", string(pkg.ConflictPolicyHighest), "What to do when patches request different versions for one artifact or one shared property: highest, lowest-fix (the lowest version satisfying every advisory) or fail")
+	flagSet.StringSliceVar(&analyzeFlags.failOn, "fail-on", nil, "Exit nonzero when the output has issues (exit 2: any recommended update), conflicts (3), unfixable (4) warnings (5) or snapshots (6); the first condition listed that is found sets the exit code")
+	flagSet.BoolVar(&analyzeFlags.groupByAdvisory, "group-by-advisory", false, "Also write the patches of each advisory (from --from-grype, --from-trivy or an advisory ID) to their own files, plus an index file")
 	flagSet.BoolVar(&analyzeFlags.searchProperties, "search-properties", false, "Search for properties in nearby POM files")
+	flagSet.StringSliceVar(&analyzeFlags.exclude, "exclude", nil, "Additional patterns to skip during property search (trailing / matches directories)")
+	flagSet.StringSliceVar(&analyzeFlags.include, "include", nil, "Patterns to search even if excluded (e.g. a POM in target/ or build/)")
+	flagSet.StringVar(&analyzeFlags.fromGrype, "from-grype", "", "Grype JSON report to read patches from (fixed versions of vulnerable Maven artifacts)")
+	flagSet.StringVar(&analyzeFlags.repository, "repository", pkg.DefaultRepositoryURL, "Maven repository to resolve parent POMs and @latest versions from")
+	flagSet.BoolVar(&analyzeFlags.verifyVersions, "verify-versions", false, "Check that every requested version is published in the repository before recommending it")
+	flagSet.BoolVar(&analyzeFlags.force, "force", false, "Patch dependencies and properties to the version or value already in effect too, instead of skipping them")
+	flagSet.BoolVar(&analyzeFlags.syncMismatches, "sync-mismatches", false, "When a dependency is declared differently in dependencies and dependencyManagement, update both and not just the winning one")
+	flagSet.StringVar(&analyzeFlags.osvCacheDir, "osv-cache-dir", "", "Directory to cache OSV and GitHub advisory lookups in (defaults to the user cache directory)")
+	flagSet.StringVar(&analyzeFlags.fromTrivy, "from-trivy", "", "Trivy JSON report to read patches from (fixed versions of vulnerable Java packages)")
 
 	return cmd
 }
 
-func outputAnalysisReport(analysis *pkg.AnalysisResult, directPatches []pkg.Patch, propertyPatches map[string]string) {
+// outputJUnit prints the JUnit XML report.
+func outputJUnit(report *pkg.JUnitReport) error {
+	out, err := report.XML()
+	if err != nil {
+		return fmt.Errorf("failed to render JUnit report: %w", err)
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// failOn checks the --fail-on conditions. Failing on one is not a usage
+// error, so the usage is not printed.
+func failOn(cmd *cobra.Command, analysis *pkg.AnalysisResult, recs recommendations) error {
+	if err := checkFailOn(analyzeFlags.failOn, analysis, recs); err != nil {
+		cmd.SilenceUsage = true
+		return err
+	}
+	return nil
+}
+
+// hasAnalyzePatches tells whether any patches were given to analyze.
+func hasAnalyzePatches() bool {
+	return analyzeFlags.patches != "" || analyzeFlags.patchFile != "" || analyzeFlags.fromGrype != "" || analyzeFlags.fromTrivy != ""
+}
+
+// parseAnalyzePatches reads the patches from the flags and scanner reports.
+func parseAnalyzePatches(ctx context.Context) ([]pkg.Patch, error) {
+	patches, err := pkg.ParsePatches(ctx, analyzeFlags.patchFile, analyzeFlags.patches)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse patches: %w", err)
+	}
+
+	if analyzeFlags.fromGrype != "" {
+		grypePatches, err := pkg.PatchesFromGrype(ctx, analyzeFlags.fromGrype)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read grype report: %w", err)
+		}
+		patches = append(patches, grypePatches...)
+	}
+
+	if analyzeFlags.fromTrivy != "" {
+		trivyPatches, err := pkg.PatchesFromTrivy(ctx, analyzeFlags.fromTrivy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read trivy report: %w", err)
+		}
+		patches = append(patches, trivyPatches...)
+	}
+	return patches, nil
+}
+
+// recommend works out the recommendations for patches against analysis. It
+// also returns the patches with their versions resolved and conflicts
+// settled, and the options PatchStrategy was called with.
+func recommend(ctx context.Context, analysis *pkg.AnalysisResult, patches []pkg.Patch, strategy pkg.Strategy, conflictPolicy pkg.ConflictPolicy) (recommendations, []pkg.Patch, []pkg.PatchStrategyOption, error) {
+	optionalPolicy, err := pkg.ParseOptionalPolicy(analyzeFlags.optionalPolicy)
+	if err != nil {
+		return recommendations{}, nil, nil, err
+	}
+	strategyOpts := []pkg.PatchStrategyOption{pkg.WithStrategy(strategy), pkg.WithOptionalPolicy(optionalPolicy)}
+	if analyzeFlags.syncMismatches {
+		strategyOpts = append(strategyOpts, pkg.WithMismatchSync())
+	}
+	if analyzeFlags.overrideBOMs {
+		strategyOpts = append(strategyOpts, pkg.WithBOMOverrides())
+	}
+	if analyzeFlags.strategyRules != "" {
+		rules, err := pkg.LoadStrategyRules(analyzeFlags.strategyRules)
+		if err != nil {
+			return recommendations{}, nil, nil, err
+		}
+		strategyOpts = append(strategyOpts, pkg.WithStrategyHooks(rules))
+	}
+	if len(analyzeFlags.pins) > 0 {
+		pins, err := pkg.ParsePins(analyzeFlags.pins)
+		if err != nil {
+			return recommendations{}, nil, nil, err
+		}
+		strategyOpts = append(strategyOpts, pkg.WithStrategyHooks(pins))
+	}
+	analyzerOpts := []pkg.AnalyzerOption{
+		pkg.WithRepository(newRepository(analyzeFlags.repository)),
+		pkg.WithOSVResolver(newOSVResolver(analyzeFlags.osvCacheDir)),
+		pkg.WithGHSAResolver(newGHSAResolver(analyzeFlags.osvCacheDir)),
+		pkg.WithConflictPolicy(conflictPolicy),
+		pkg.WithPatchStrategyOptions(strategyOpts...),
+	}
+	if analyzeFlags.verifyVersions {
+		analyzerOpts = append(analyzerOpts, pkg.WithVersionVerification())
+	}
+	if analyzeFlags.force {
+		analyzerOpts = append(analyzerOpts, pkg.WithForce())
+	}
+
+	rec, err := pkg.NewAnalyzer(analyzerOpts...).Recommend(ctx, analysis, patches)
+	if err != nil {
+		return recommendations{}, nil, nil, err
+	}
+	recs := recommendations{
+		directPatches:   rec.DirectPatches,
+		propertyPatches: rec.PropertyPatches,
+		bomBumps:        rec.BOMBumps,
+		conflicts:       rec.Conflicts,
+		unfixable:       rec.Unfixable,
+		suppressed:      rec.Suppressed,
+		skipped:         rec.Skipped,
+		candidates:      rec.Candidates,
+	}
+	return recs, rec.Patches, strategyOpts, nil
+}
+
+func outputAnalysisReport(analysis *pkg.AnalysisResult, recs recommendations) {
+	directPatches, propertyPatches := recs.directPatches, recs.propertyPatches
 	fmt.Println("")
 	fmt.Println("Patch Recommendations")
 	fmt.Println("=====================")
@@ -129,7 +602,8 @@ func outputAnalysisReport(analysis *pkg.AnalysisResult, directPatches []pkg.Patc
 	if len(propertyPatches) > 0 {
 		fmt.Println("Property Updates:")
 		fmt.Println("-----------------")
-		for prop, version := range propertyPatches {
+		for _, patch := range pkg.SortedPropertyPatches(propertyPatches) {
+			prop, version := patch.Property, patch.Value
 			currentValue := analysis.Properties[prop]
 			if currentValue != "" {
 				fmt.Printf("  %s: %s -> %s\n", prop, currentValue, version)
@@ -137,12 +611,19 @@ func outputAnalysisReport(analysis *pkg.AnalysisResult, directPatches []pkg.Patc
 				fmt.Printf("  %s: (new) -> %s\n", prop, version)
 			}
 
-			// Show affected dependencies
-			affected := analysis.GetAffectedDependencies(prop)
+			// Show affected dependencies, with the effective version of
+			// composite ones like 1.0-${build.qualifier}
+			affected := analysis.PropertyImpact(prop, version)
 			if len(affected) > 0 {
 				fmt.Printf("    Affects %d dependencies:\n", len(affected))
 				for _, dep := range affected {
-					fmt.Printf("      - %s:%s\n", dep.GroupID, dep.ArtifactID)
+					if dep.Version == "${"+prop+"}" {
+						fmt.Printf("      - %s:%s\n", dep.GroupID, dep.ArtifactID)
+					} else if len(dep.PropertyChain) > 0 {
+						fmt.Printf("      - %s:%s (via %s: %s -> %s)\n", dep.GroupID, dep.ArtifactID, strings.Join(dep.PropertyChain, " -> "), dep.Before, dep.After)
+					} else {
+						fmt.Printf("      - %s:%s (%s: %s -> %s)\n", dep.GroupID, dep.ArtifactID, dep.Version, dep.Before, dep.After)
+					}
 				}
 			}
 		}
@@ -154,9 +635,37 @@ func outputAnalysisReport(analysis *pkg.AnalysisResult, directPatches []pkg.Patc
 		fmt.Println("--------------------------")
 		for _, patch := range directPatches {
 			depKey := fmt.Sprintf("%s:%s", patch.GroupID, patch.ArtifactID)
-			if dep, exists := analysis.Dependencies[depKey]; exists {
+			dep, exists := analysis.Dependencies[depKey]
+			if patch.Operation == pkg.PatchOperationOverride {
+				current := "(new)"
+				if managed, ok := analysis.ManagedVersion(patch.GroupID, patch.ArtifactID); ok {
+					current = managed.String()
+				}
+				fmt.Printf("  %s:%s: %s -> %s (override ahead of the BOMs)\n",
+					patch.GroupID, patch.ArtifactID, current, patch.Version)
+			} else if patch.Operation == pkg.PatchOperationImport {
+				position := patch.Position
+				if position == "" {
+					position = pkg.PositionFirst
+				}
+				fmt.Printf("  %s:%s: import BOM %s (%s)\n",
+					patch.GroupID, patch.ArtifactID, patch.Version, position)
+			} else if patch.Operation == pkg.PatchOperationReorder {
+				position := patch.Position
+				if position == "" {
+					position = pkg.PositionFirst
+				}
+				fmt.Printf("  %s:%s: move BOM (%s)\n",
+					patch.GroupID, patch.ArtifactID, position)
+			} else if exists && dep.ManagedBy != nil {
+				fmt.Printf("  %s:%s: managed by %s -> %s\n",
+					patch.GroupID, patch.ArtifactID, dep.ManagedBy, patch.Version)
+			} else if exists {
 				fmt.Printf("  %s:%s: %s -> %s\n",
 					patch.GroupID, patch.ArtifactID, dep.Version, patch.Version)
+			} else if managed, ok := analysis.ManagedVersion(patch.GroupID, patch.ArtifactID); ok {
+				fmt.Printf("  %s:%s: managed by %s -> %s\n",
+					patch.GroupID, patch.ArtifactID, managed, patch.Version)
 			} else {
 				fmt.Printf("  %s:%s: (new) -> %s\n",
 					patch.GroupID, patch.ArtifactID, patch.Version)
@@ -164,33 +673,362 @@ func outputAnalysisReport(analysis *pkg.AnalysisResult, directPatches []pkg.Patc
 		}
 	}
 
+	if mismatches, _ := analysis.Suppressions().Mismatches(analysis.VersionMismatches); len(mismatches) > 0 {
+		fmt.Println()
+		fmt.Println("Version Mismatches (dependencies wins over dependencyManagement):")
+		fmt.Println("------------------------------------------------------------------")
+		for _, m := range mismatches {
+			fmt.Printf("  %s\n", pkg.FormatIssue(m.Code, fmt.Sprintf("%s:%s: %s vs %s", m.GroupID, m.ArtifactID, m.DependencyVersion, m.ManagedVersion)))
+			fmt.Printf("    %s\n", analysis.ExplainMismatch(m))
+		}
+	}
+
+	if recs.parentDelta != nil {
+		outputParentDelta(recs.parentDelta)
+	}
+
+	for _, bump := range recs.bomBumps {
+		outputBOMBump(bump)
+	}
+
+	if len(recs.conflicts) > 0 {
+		fmt.Println()
+		fmt.Println("Version Conflicts:")
+		fmt.Println("------------------")
+		for _, c := range recs.conflicts {
+			fmt.Printf("  %s\n", pkg.FormatIssue(c.Code, c.String()))
+		}
+	}
+
+	if len(recs.candidates) > 0 {
+		fmt.Println()
+		fmt.Println("Chosen Candidate Versions:")
+		fmt.Println("--------------------------")
+		for _, c := range recs.candidates {
+			fmt.Printf("  %s:%s: %s from %q (%s)\n", c.GroupID, c.ArtifactID, c.Chosen, c.Requested, c.Reason)
+		}
+	}
+
+	if len(recs.unfixable) > 0 {
+		fmt.Println()
+		fmt.Println("Unfixable:")
+		fmt.Println("----------")
+		for _, issue := range recs.unfixable {
+			fmt.Printf("  %s\n", pkg.FormatIssue(issue.Code, fmt.Sprintf("%s:%s %s: %s", issue.GroupID, issue.ArtifactID, issue.Version, issue.Reason)))
+		}
+	}
+
+	if len(recs.skipped) > 0 {
+		fmt.Println()
+		fmt.Println("Skipped:")
+		fmt.Println("--------")
+		for _, s := range recs.skipped {
+			fmt.Printf("  %s %s -> %s: %s\n", s.Name, s.Current, s.Version, s.Reason)
+		}
+	}
+
+	if suppressed := suppressedIssues(analysis, recs); len(suppressed) > 0 {
+		fmt.Println()
+		fmt.Println("Suppressed:")
+		fmt.Println("-----------")
+		for _, issue := range suppressed {
+			fmt.Printf("  %s\n", pkg.FormatSuppressed(issue))
+		}
+	}
+
 	fmt.Printf("\nSummary: %d property updates, %d direct dependency updates\n",
 		len(propertyPatches), len(directPatches))
 }
 
-func outputYAML(directPatches []pkg.Patch, propertyPatches map[string]string) {
+func outputParentDelta(delta *pkg.ParentDelta) {
+	fmt.Println()
+	fmt.Printf("Parent Bump %s:%s: %s -> %s\n", delta.GroupID, delta.ArtifactID, delta.OldVersion, delta.NewVersion)
+	fmt.Println("--------------------------------------")
+	if len(delta.Properties) == 0 && len(delta.ManagedDependencies) == 0 {
+		fmt.Println("  No inherited properties or managed versions change")
+		return
+	}
+	printChanges := func(title string, changes []pkg.VersionChange) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Printf("  %s (%d changed):\n", title, len(changes))
+		for _, c := range changes {
+			switch {
+			case c.Old == "":
+				fmt.Printf("    %s: (new) -> %s\n", c.Name, c.New)
+			case c.New == "":
+				fmt.Printf("    %s: %s -> (removed)\n", c.Name, c.Old)
+			default:
+				fmt.Printf("    %s: %s -> %s\n", c.Name, c.Old, c.New)
+			}
+		}
+	}
+	printChanges("Inherited properties", delta.Properties)
+	printChanges("Managed dependency versions", delta.ManagedDependencies)
+}
+
+func outputBOMBump(bump pkg.BOMBump) {
+	fmt.Println()
+	fmt.Printf("BOM Bump %s:%s: %s -> %s\n", bump.GroupID, bump.ArtifactID, bump.OldVersion, bump.NewVersion)
+	fmt.Println("--------------------------------------")
+	for _, warning := range bump.Warnings {
+		fmt.Printf("  Warning [%s] (%s): downgrades %d pinned artifacts:\n", warning.Code, warning.Kind, len(warning.Artifacts))
+		for _, c := range warning.Artifacts {
+			fmt.Printf("    %s: %s -> %s\n", c.Name, c.Old, c.New)
+		}
+	}
+	if len(bump.Satisfied) == 0 && len(bump.BelowFix) == 0 {
+		fmt.Println("  Manages none of the requested artifacts")
+		return
+	}
+	if len(bump.Satisfied) > 0 {
+		fmt.Printf("  Satisfied (%d):\n", len(bump.Satisfied))
+		for _, r := range bump.Satisfied {
+			fmt.Printf("    %s:%s: %s (requested %s)\n", r.GroupID, r.ArtifactID, r.Managed, r.Requested)
+		}
+	}
+	if len(bump.BelowFix) > 0 {
+		fmt.Printf("  Still below the fix (%d):\n", len(bump.BelowFix))
+		for _, r := range bump.BelowFix {
+			managed := r.Managed
+			if managed == "" {
+				managed = "no longer managed"
+			}
+			fmt.Printf("    %s:%s: %s (requested %s)\n", r.GroupID, r.ArtifactID, managed, r.Requested)
+		}
+	}
+}
+
+// analysisOutput is what --output-template renders, and --output json
+// prints.
+func analysisOutput(paths []string, analysis *pkg.AnalysisResult, recs recommendations) pkg.AnalysisOutput {
+	return pkg.AnalysisOutput{
+		POMs:               paths,
+		Analysis:           analysis,
+		Report:             analysis.AggregateReport(),
+		Patches:            pkg.WithPurls(recs.directPatches),
+		Properties:         pkg.SortedPropertyPatches(recs.propertyPatches),
+		Parent:             recs.parentDelta,
+		BOMs:               recs.bomBumps,
+		ImportedBOMs:       analysis.BOMs(),
+		Conflicts:          recs.conflicts,
+		Unfixable:          recs.unfixable,
+		Candidates:         recs.candidates,
+		Licenses:           analysis.Licenses(),
+		DependencyLicenses: analysis.DependencyLicenses(),
+		Warnings:           analysis.Warnings(),
+		Suppressed:         suppressedIssues(analysis, recs),
+		Skipped:            recs.skipped,
+	}
+}
+
+// suppressedIssues returns the suppressed warnings of the analysis and the
+// suppressed issues and patches of its recommendations.
+func suppressedIssues(analysis *pkg.AnalysisResult, recs recommendations) []pkg.SuppressedIssue {
+	return append(analysis.SuppressedIssues(), recs.suppressed...)
+}
+
+// saveBaseline writes the analysis and its recommendations to the
+// --save-baseline file, if any.
+func saveBaseline(paths []string, analysis *pkg.AnalysisResult, recs recommendations) error {
+	if analyzeFlags.saveBaseline == "" {
+		return nil
+	}
+	if err := pkg.WriteAnalysisOutput(analyzeFlags.saveBaseline, analysisOutput(paths, analysis, recs)); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// outputBaselineChanges prints what changed in output since baseline, in
+// the --output format.
+func outputBaselineChanges(baseline *pkg.AnalysisOutput, output pkg.AnalysisOutput) error {
+	changes := pkg.CompareAnalysisOutputs(baseline, &output)
+	switch analyzeFlags.outputFormat {
+	case "json", "yaml":
+		var data []byte
+		var err error
+		if analyzeFlags.outputFormat == "json" {
+			data, err = json.MarshalIndent(changes, "", "  ")
+		} else {
+			data, err = yaml.Marshal(changes)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to render changes: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	title := fmt.Sprintf("Changes Since Baseline (%s)", strings.Join(changes.Baseline, ", "))
+	fmt.Println(title)
+	fmt.Println(strings.Repeat("=", len(title)))
+	if changes.Empty() {
+		fmt.Println("No new or resolved issues, and no BOM changes")
+		return nil
+	}
+	printIssues := func(title string, issues pkg.AnalysisIssues) {
+		if issues.Count() == 0 {
+			return
+		}
+		fmt.Printf("\n%s (%d):\n", title, issues.Count())
+		for _, p := range issues.Patches {
+			fmt.Printf("  - update %s:%s to %s\n", p.GroupID, p.ArtifactID, p.Version)
+		}
+		for _, p := range issues.Properties {
+			fmt.Printf("  - update property %s to %s\n", p.Property, p.Value)
+		}
+		for _, w := range issues.Warnings {
+			fmt.Printf("  - [%s] %s\n", w.Code, w.Message)
+		}
+		for _, c := range issues.Conflicts {
+			fmt.Printf("  - [%s] %s is requested at %s\n", c.Code, c.Group, strings.Join(c.Versions, ", "))
+		}
+		for _, u := range issues.Unfixable {
+			fmt.Printf("  - [%s] %s:%s:%s %s\n", u.Code, u.GroupID, u.ArtifactID, u.Version, u.Reason)
+		}
+	}
+	printIssues("New Issues", changes.New)
+	printIssues("Resolved Issues", changes.Resolved)
+	if len(changes.BOMs) > 0 {
+		fmt.Printf("\nBOMs (%d changed):\n", len(changes.BOMs))
+		for _, c := range changes.BOMs {
+			switch c.Change {
+			case pkg.ChangeAdded:
+				fmt.Printf("  - %s: (new) -> %s\n", c.Name, c.New)
+			case pkg.ChangeRemoved:
+				fmt.Printf("  - %s: %s -> (removed)\n", c.Name, c.Old)
+			default:
+				fmt.Printf("  - %s: %s -> %s\n", c.Name, c.Old, c.New)
+			}
+		}
+	}
+	return nil
+}
+
+// outputJSON prints output as JSON, as described by `pombump schema
+// analysis`.
+func outputJSON(output pkg.AnalysisOutput) error {
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to render analysis: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func outputYAML(analysis *pkg.AnalysisResult, recs recommendations) {
+	directPatches, propertyPatches := recs.directPatches, recs.propertyPatches
 	result := map[string]interface{}{}
 
+	if warnings := analysis.Warnings(); len(warnings) > 0 {
+		result["warnings"] = warnings
+	}
+
+	if suppressed := suppressedIssues(analysis, recs); len(suppressed) > 0 {
+		result["suppressed"] = suppressed
+	}
+
+	if recs.parentDelta != nil {
+		result["parent"] = recs.parentDelta
+	}
+
+	if len(recs.bomBumps) > 0 {
+		result["boms"] = recs.bomBumps
+	}
+
+	if len(recs.unfixable) > 0 {
+		result["unfixable"] = recs.unfixable
+	}
+
+	if len(recs.skipped) > 0 {
+		result["skipped"] = recs.skipped
+	}
+
+	if len(recs.conflicts) > 0 {
+		result["conflicts"] = recs.conflicts
+	}
+
+	if len(recs.candidates) > 0 {
+		result["candidates"] = recs.candidates
+	}
+
 	if len(directPatches) > 0 {
-		result["patches"] = directPatches
+		result["patches"] = pkg.WithPurls(directPatches)
 	}
 
 	if len(propertyPatches) > 0 {
-		props := []pkg.PropertyPatch{}
-		for k, v := range propertyPatches {
-			props = append(props, pkg.PropertyPatch{
-				Property: k,
-				Value:    v,
-			})
-		}
-		result["properties"] = props
+		result["properties"] = pkg.SortedPropertyPatches(propertyPatches)
 	}
 
 	output, _ := yaml.Marshal(result)
 	fmt.Println(string(output))
 }
 
-func writeDepsFile(filename string, patches []pkg.Patch) error {
+// outputDiff prints the unified diff of what patching the POM at path with
+// patches and propertyPatches changes.
+func outputDiff(ctx context.Context, path string, patches []pkg.Patch, propertyPatches map[string]string) error {
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read POM file: %w", err)
+	}
+	project, err := pkg.ParsePOMFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to parse POM file: %w", err)
+	}
+	patched, err := pkg.PatchProject(ctx, project, patches, propertyPatches)
+	if err != nil {
+		return fmt.Errorf("failed to patch POM file: %w", err)
+	}
+	after, err := marshalPOM(ctx, patched, path)
+	if err != nil {
+		return fmt.Errorf("failed to marshal POM file: %w", err)
+	}
+	diff, err := pkg.UnifiedDiff(path, before, after)
+	if err != nil {
+		return err
+	}
+	fmt.Print(diff)
+	return nil
+}
+
+func outputAggregateReport(report *pkg.AggregateReport, format string) error {
+	var output []byte
+	var err error
+	switch format {
+	case "json":
+		output, err = json.MarshalIndent(report, "", "  ")
+	case "yaml":
+		output, err = yaml.Marshal(report)
+	default:
+		return fmt.Errorf("unsupported output format %q, use human, yaml or json", format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	fmt.Println(string(output))
+	return nil
+}
+
+// marshalOutputFile marshals v for filename, as JSON if its name ends in
+// .json and as YAML otherwise, the way it is read back.
+func marshalOutputFile(filename string, v any) ([]byte, error) {
+	if strings.HasSuffix(filename, ".json") {
+		return json.MarshalIndent(v, "", "  ")
+	}
+	return yaml.Marshal(v)
+}
+
+func writeDepsFile(ctx context.Context, filename string, patches []pkg.Patch) error {
+	// Hold the lock across the read-modify-write so that concurrent runs
+	// appending to the same file do not lose updates.
+	release, err := pkg.LockFile(ctx, filename)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// Read existing file if it exists
 	var existingList pkg.PatchList
 	if data, err := os.ReadFile(filename); err == nil {
@@ -213,21 +1051,98 @@ func writeDepsFile(filename string, patches []pkg.Patch) error {
 		patchMap[key] = patch // This will update if exists, or add if new
 	}
 
-	// Convert map back to slice
+	// Convert map back to slice, in a stable order
 	var finalPatches []pkg.Patch
-	for _, patch := range patchMap {
-		finalPatches = append(finalPatches, patch)
+	for _, key := range slices.Sorted(maps.Keys(patchMap)) {
+		finalPatches = append(finalPatches, patchMap[key])
 	}
 
-	finalList := pkg.PatchList{Patches: finalPatches}
-	data, err := yaml.Marshal(finalList)
+	finalList := pkg.PatchList{Patches: pkg.WithPurls(finalPatches)}
+	data, err := marshalOutputFile(filename, finalList)
 	if err != nil {
 		return err
 	}
 	return os.WriteFile(filename, data, 0644)
 }
 
-func writePropertiesFile(filename string, properties map[string]string) error {
+// writeAdvisoryFiles writes the recommendations for each advisory the patches
+// fix to their own files next to --output-deps and --output-properties, e.g.
+// pombump-deps-CVE-2024-1234.yaml, and an index of them, so that the fix for
+// a single advisory can be cherry-picked or reverted.
+func writeAdvisoryFiles(ctx context.Context, analysis *pkg.AnalysisResult, patches []pkg.Patch, opts []pkg.PatchStrategyOption) error {
+	groups, unattributed := pkg.GroupByAdvisory(patches)
+	index := pkg.AdvisoryIndex{Advisories: []pkg.AdvisoryIndexEntry{}}
+	for _, group := range groups {
+		directPatches, propertyPatches := pkg.PatchStrategy(ctx, analysis, group.Patches, opts...)
+		entry := pkg.AdvisoryIndexEntry{Advisory: group.Advisory, Artifacts: []string{}}
+		for _, p := range group.Patches {
+			entry.Artifacts = append(entry.Artifacts, fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID))
+		}
+		if analyzeFlags.outputDeps != "" && len(directPatches) > 0 {
+			file := pkg.AdvisoryFile(analyzeFlags.outputDeps, group.Advisory)
+			if err := writeDepsFile(ctx, file, directPatches); err != nil {
+				return err
+			}
+			entry.DepsFile = filepath.Base(file)
+		}
+		if analyzeFlags.outputProperties != "" && len(propertyPatches) > 0 {
+			file := pkg.AdvisoryFile(analyzeFlags.outputProperties, group.Advisory)
+			if err := writePropertiesFile(ctx, file, propertyPatches); err != nil {
+				return err
+			}
+			entry.PropertiesFile = filepath.Base(file)
+		}
+		index.Advisories = append(index.Advisories, entry)
+	}
+	for _, p := range unattributed {
+		index.Unattributed = append(index.Unattributed, fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID))
+	}
+
+	base := analyzeFlags.outputDeps
+	if base == "" {
+		base = analyzeFlags.outputProperties
+	}
+	indexFile := pkg.AdvisoryFile(base, "index")
+	if err := writeAdvisoryIndex(ctx, indexFile, index); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote the patches of %d advisories, see %s\n", len(groups), indexFile)
+	return nil
+}
+
+// writeAdvisoryIndex merges index into the one in filename, if any, so that
+// runs for other advisories do not drop each other's entries.
+func writeAdvisoryIndex(ctx context.Context, filename string, index pkg.AdvisoryIndex) error {
+	release, err := pkg.LockFile(ctx, filename)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	existing := pkg.AdvisoryIndex{Advisories: []pkg.AdvisoryIndexEntry{}}
+	if data, err := os.ReadFile(filename); err == nil {
+		if err := yaml.Unmarshal(data, &existing); err != nil {
+			// If unmarshal fails, start fresh
+			existing = pkg.AdvisoryIndex{Advisories: []pkg.AdvisoryIndexEntry{}}
+		}
+	}
+	existing.Merge(index)
+	data, err := marshalOutputFile(filename, existing)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+func writePropertiesFile(ctx context.Context, filename string, properties map[string]string) error {
+	// Hold the lock across the read-modify-write so that concurrent runs
+	// appending to the same file do not lose updates.
+	release, err := pkg.LockFile(ctx, filename)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// Read existing file if it exists
 	var existingList pkg.PropertyList
 	if data, err := os.ReadFile(filename); err == nil {
@@ -248,17 +1163,8 @@ func writePropertiesFile(filename string, properties map[string]string) error {
 		propMap[k] = v // This will update if exists, or add if new
 	}
 
-	// Convert map back to slice
-	var finalProperties []pkg.PropertyPatch
-	for k, v := range propMap {
-		finalProperties = append(finalProperties, pkg.PropertyPatch{
-			Property: k,
-			Value:    v,
-		})
-	}
-
-	finalList := pkg.PropertyList{Properties: finalProperties}
-	data, err := yaml.Marshal(finalList)
+	finalList := pkg.PropertyList{Properties: pkg.SortedPropertyPatches(propMap)}
+	data, err := marshalOutputFile(filename, finalList)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,200 @@
+package pombump
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/spf13/cobra"
+)
+
+type applyCLIFlags struct {
+	quarantine string
+	yes        bool
+	inPlace    bool
+	backup     bool
+}
+
+var applyFlags applyCLIFlags
+
+func ApplyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply <plan-file> | <pom-file> --quarantine <quarantine-file>",
+		Short: "Apply a plan or a quarantine plan after review",
+		Long: `Apply a plan written by pombump plan, or the risky changes that were written to
+a quarantine plan by pombump --quarantine or pombump ci --quarantine. The
+changes are listed and only applied once confirmed.
+
+A plan is applied to the POM it was made for, and only if that POM did not
+change since and patching it makes exactly the planned changes.
+
+Examples:
+  # Review and confirm a plan interactively, printing the patched POM
+  pombump apply plan.json
+
+  # Review and confirm quarantined changes interactively
+  pombump apply pom.xml --quarantine quarantine.yaml
+
+  # Confirm up front, patching the POM in place
+  pombump apply pom.xml --quarantine quarantine.yaml --yes --in-place`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if applyFlags.backup && !applyFlags.inPlace {
+				return fmt.Errorf("--backup needs --in-place")
+			}
+			if applyFlags.quarantine == "" {
+				return applyPlan(cmd, args[0])
+			}
+			plan, err := pkg.ReadQuarantinePlan(applyFlags.quarantine)
+			if err != nil {
+				return err
+			}
+			if plan.Empty() {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Nothing quarantined in %s\n", applyFlags.quarantine)
+				return nil
+			}
+
+			out := cmd.ErrOrStderr()
+			fmt.Fprintf(out, "Quarantined changes in %s:\n", applyFlags.quarantine)
+			for _, p := range plan.Patches {
+				fmt.Fprintf(out, "  %s:%s: %s -> %s (%s)\n", p.GroupID, p.ArtifactID, orNew(p.Current), p.Version, p.Reason)
+			}
+			for _, p := range plan.Properties {
+				fmt.Fprintf(out, "  %s: %s -> %s (%s)\n", p.Property, orNew(p.Current), p.Value, p.Reason)
+			}
+			if !applyFlags.yes && !confirm(cmd.InOrStdin(), out) {
+				return fmt.Errorf("quarantined changes were not confirmed")
+			}
+
+			if applyFlags.inPlace {
+				release, err := lockFiles(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+				defer release()
+			}
+			parsedPom, err := pkg.ParsePOMFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to parse the pom file: %w", err)
+			}
+			patches, properties := plan.Changes()
+			analysis, err := pkg.AnalyzeProject(cmd.Context(), parsedPom, pkg.WithoutBOMDetection())
+			if err != nil {
+				return fmt.Errorf("failed to analyze project: %w", err)
+			}
+			if patches, err = unappliedPatches(cmd.Context(), args[0], patches, analysis.CurrentVersions()); err != nil {
+				return err
+			}
+			newPom, err := pkg.PatchProject(cmd.Context(), parsedPom, patches, properties)
+			if err != nil {
+				return fmt.Errorf("failed to patch the pom file: %w", err)
+			}
+			data, err := marshalPOM(cmd.Context(), newPom, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to marshal the pom file: %w", err)
+			}
+			if applyFlags.inPlace {
+				if err := writePOM(cmd.Context(), args[0], data, applyFlags.backup); err != nil {
+					return err
+				}
+				return recordPatches(cmd.Context(), args[0], patches, properties)
+			}
+			printPOM(cmd.OutOrStdout(), data)
+			return nil
+		},
+	}
+
+	flagSet := cmd.Flags()
+	flagSet.StringVar(&applyFlags.quarantine, "quarantine", "", "Quarantine plan file to apply to the POM file, instead of a plan")
+	flagSet.BoolVar(&applyFlags.yes, "yes", false, "Apply without asking for confirmation")
+	flagSet.BoolVar(&applyFlags.inPlace, "in-place", false, "Overwrite the POM file instead of printing the patched one")
+	flagSet.BoolVar(&applyFlags.backup, "backup", false, "With --in-place, keep the original as <file>"+pkg.BackupSuffix)
+
+	return cmd
+}
+
+// applyPlan applies the plan written by pombump plan to planFile, once
+// confirmed.
+func applyPlan(cmd *cobra.Command, planFile string) error {
+	plan, err := pkg.ReadPlan(planFile)
+	if err != nil {
+		return err
+	}
+	out := cmd.ErrOrStderr()
+	printPlan(out, plan)
+	if plan.Empty() {
+		return nil
+	}
+	if !applyFlags.yes && !confirm(cmd.InOrStdin(), out) {
+		return fmt.Errorf("planned changes were not confirmed")
+	}
+
+	if applyFlags.inPlace {
+		release, err := lockFiles(cmd.Context(), plan.POM)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	data, err := os.ReadFile(plan.POM)
+	if err != nil {
+		return fmt.Errorf("failed to read POM file: %w", err)
+	}
+	// A plan is applied whole, or not at all.
+	project, err := pkg.ParsePOMFile(plan.POM)
+	if err != nil {
+		return fmt.Errorf("failed to parse the pom file: %w", err)
+	}
+	analysis, err := pkg.AnalyzeProject(cmd.Context(), project, pkg.WithoutBOMDetection())
+	if err != nil {
+		return fmt.Errorf("failed to analyze project: %w", err)
+	}
+	unapplied, err := unappliedPatches(cmd.Context(), plan.POM, plan.Patches, analysis.CurrentVersions())
+	if err != nil {
+		return err
+	}
+	if len(unapplied) < len(plan.Patches) {
+		return fmt.Errorf("%s records patches of %s as already applied, make a new plan", patchLockFile, planFile)
+	}
+	newPom, err := plan.Apply(cmd.Context(), data)
+	if err != nil {
+		return fmt.Errorf("failed to apply %s: %w", planFile, err)
+	}
+	patched, err := marshalPOM(cmd.Context(), newPom, plan.POM)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the pom file: %w", err)
+	}
+	if applyFlags.inPlace {
+		if err := writePOM(cmd.Context(), plan.POM, patched, applyFlags.backup); err != nil {
+			return err
+		}
+		properties := map[string]string{}
+		for _, p := range plan.Properties {
+			properties[p.Property] = p.Value
+		}
+		return recordPatches(cmd.Context(), plan.POM, plan.Patches, properties)
+	}
+	printPOM(cmd.OutOrStdout(), patched)
+	return nil
+}
+
+// confirm asks for a yes/no answer on in, defaulting to no.
+func confirm(in io.Reader, out io.Writer) bool {
+	fmt.Fprint(out, "Apply these changes? [y/N] ")
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func orNew(version string) string {
+	if version == "" {
+		return "(new)"
+	}
+	return version
+}
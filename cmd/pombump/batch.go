@@ -0,0 +1,73 @@
+package pombump
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/spf13/cobra"
+)
+
+// fileRecord is the NDJSON record of one file analyzed by analyze.
+type fileRecord struct {
+	pkg.ModuleAnalysis
+	DirectPatches   []pkg.Patch       `json:"directPatches,omitempty"`
+	PropertyPatches map[string]string `json:"propertyPatches,omitempty"`
+	Error           string            `json:"error,omitempty"`
+}
+
+// outputNDJSON prints a record per file, with the recommendations for the
+// patches if any. The files that could not be analyzed have their error
+// recorded, and fail the command once every record is printed, as do the
+// --fail-on conditions found in any file (the first one sets the exit code).
+func outputNDJSON(cmd *cobra.Command, files []pkg.FileAnalysis, strategy pkg.Strategy, conflictPolicy pkg.ConflictPolicy) error {
+	ctx := cmd.Context()
+	var patches []pkg.Patch
+	if hasAnalyzePatches() {
+		var err error
+		if patches, err = parseAnalyzePatches(ctx); err != nil {
+			return err
+		}
+	}
+
+	encoder := json.NewEncoder(cmd.OutOrStdout())
+	failed := 0
+	var failOnErr error
+	for _, file := range files {
+		record := fileRecord{ModuleAnalysis: pkg.ModuleAnalysis{Path: filepath.ToSlash(file.Path)}}
+		recs := recommendations{}
+		err := file.Err
+		if err == nil {
+			file.Result.Suppress(suppressions)
+			record.ModuleAnalysis = file.Result.ModuleAnalysis(record.Path)
+			if analyzeFlags.resolveBOMs || analyzeFlags.overrideBOMs || strategy == pkg.StrategyPreferBOM {
+				file.Result.ResolveBOMs(ctx, newRepository(analyzeFlags.repository))
+			}
+			if patches != nil {
+				recs, _, _, err = recommend(ctx, file.Result, patches, strategy, conflictPolicy)
+			}
+		}
+		if err != nil {
+			failed++
+			record.Error = err.Error()
+		} else {
+			record.DirectPatches, record.PropertyPatches = pkg.WithPurls(recs.directPatches), recs.propertyPatches
+			if failOnErr == nil {
+				failOnErr = checkFailOn(analyzeFlags.failOn, file.Result, recs)
+			}
+		}
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to write record: %w", err)
+		}
+	}
+
+	if failed > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("failed to analyze %d of %d files", failed, len(files))
+	}
+	if failOnErr != nil {
+		cmd.SilenceUsage = true
+	}
+	return failOnErr
+}
@@ -0,0 +1,150 @@
+package pombump
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+)
+
+type checkCLIFlags struct {
+	dependencies   string
+	patchFile      string
+	properties     string
+	propertiesFile string
+	outputFormat   string
+}
+
+var checkFlags checkCLIFlags
+
+func CheckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check <pom-file>",
+		Short: "Check that a POM already satisfies the patches",
+		Long: `Check that the POM already has at least the requested version of every
+patched dependency and property, and report pass or fail for each one. The
+command fails if any of them is not satisfied, so it can assert in a build
+pipeline that the patches were applied.
+
+Patches without a version, such as removals, are not checked.
+
+Examples:
+  # Check the POM after applying the patches
+  pombump check pom.xml --patch-file pombump-deps.yaml --properties-file pombump-properties.yaml
+
+  # Check a single dependency
+  pombump check pom.xml --dependencies "io.netty@netty-handler@4.1.118.Final"
+
+  # Report the checks as JUnit XML for a CI dashboard
+  pombump check pom.xml --patch-file pombump-deps.yaml --output junit > pombump-junit.xml`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if checkFlags.patchFile != "" && checkFlags.dependencies != "" {
+				return fmt.Errorf("use either --dependencies or --patch-file")
+			}
+			parsed, err := pkg.ParsePatches(cmd.Context(), checkFlags.patchFile, checkFlags.dependencies)
+			if err != nil {
+				return fmt.Errorf("failed to parse patches: %w", err)
+			}
+			propertyPatches, err := pkg.ParseProperties(cmd.Context(), checkFlags.propertiesFile, checkFlags.properties)
+			if err != nil {
+				return fmt.Errorf("failed to parse properties: %w", err)
+			}
+			patches := []pkg.Patch{}
+			for _, p := range parsed {
+				if p.Version == "" {
+					clog.FromContext(cmd.Context()).Infof("Not checking %s:%s, the patch has no version", p.GroupID, p.ArtifactID)
+					continue
+				}
+				patches = append(patches, p)
+			}
+			if len(patches) == 0 && len(propertyPatches) == 0 {
+				return fmt.Errorf("nothing to check, use --dependencies/--patch-file or --properties/--properties-file")
+			}
+
+			parsedPom, err := pkg.ParsePOMFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to parse POM file: %w", err)
+			}
+			results, err := pkg.CheckPatches(cmd.Context(), parsedPom, patches, propertyPatches)
+			if err != nil {
+				return fmt.Errorf("failed to check patches: %w", err)
+			}
+
+			switch checkFlags.outputFormat {
+			case "yaml", "json":
+				var out []byte
+				if checkFlags.outputFormat == "json" {
+					out, err = json.MarshalIndent(map[string][]pkg.CheckResult{"results": results}, "", "  ")
+				} else {
+					out, err = yaml.Marshal(map[string][]pkg.CheckResult{"results": results})
+				}
+				if err != nil {
+					return fmt.Errorf("failed to marshal results: %w", err)
+				}
+				fmt.Println(string(out))
+			case "junit":
+				if err := outputJUnit(pkg.CheckJUnitReport(args[0], results)); err != nil {
+					return err
+				}
+			case "human":
+				outputCheckReport(results)
+			default:
+				return fmt.Errorf("unsupported output format %q, use human, yaml, json or junit", checkFlags.outputFormat)
+			}
+
+			failed := 0
+			for _, r := range results {
+				if !r.Satisfied {
+					failed++
+				}
+			}
+			if failed > 0 {
+				// Not a usage error.
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d of %d patches are not satisfied", failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	flagSet := cmd.Flags()
+	flagSet.StringVar(&checkFlags.dependencies, "dependencies", "", "Space-separated list of patches to check (groupID@artifactID@version or pkg:maven/groupID/artifactID@version)")
+	flagSet.StringVar(&checkFlags.patchFile, "patch-file", "", "File containing the patches to check")
+	flagSet.StringVar(&checkFlags.properties, "properties", "", "Space-separated list of property patches to check (property@value)")
+	flagSet.StringVar(&checkFlags.propertiesFile, "properties-file", "", "File containing the property patches to check")
+	flagSet.StringVar(&checkFlags.outputFormat, "output", "human", "Output format: human, yaml, json or junit (a JUnit XML report, a test case per patch)")
+
+	return cmd
+}
+
+func outputCheckReport(results []pkg.CheckResult) {
+	fmt.Println("Check Report")
+	fmt.Println("============")
+	printCheckResults(results)
+	passed := 0
+	for _, r := range results {
+		if r.Satisfied {
+			passed++
+		}
+	}
+	fmt.Printf("\nSummary: %d passed, %d failed\n", passed, len(results)-passed)
+}
+
+// printCheckResults prints PASS or FAIL for each result.
+func printCheckResults(results []pkg.CheckResult) {
+	for _, r := range results {
+		status := fmt.Sprintf("FAIL [%s]", r.Code)
+		if r.Satisfied {
+			status = "PASS"
+		}
+		actual := r.Actual
+		if actual == "" {
+			actual = "(not found)"
+		}
+		fmt.Printf("  %s %s: %s (requested %s)\n", status, r.Name, actual, r.Requested)
+	}
+}
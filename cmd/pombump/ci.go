@@ -0,0 +1,374 @@
+package pombump
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+)
+
+// Files written to the output directory of `pombump ci`.
+const (
+	ciReportJSON     = "report.json"
+	ciReportSARIF    = "report.sarif"
+	ciReportMarkdown = "report.md"
+	ciPatchedPOM     = "pom.xml"
+	ciDepsFile       = "pombump-deps.yaml"
+	ciPropertiesFile = "pombump-properties.yaml"
+	ciDefaultOutput  = "pombump-out"
+)
+
+type ciCLIFlags struct {
+	patches        string
+	patchFile      string
+	properties     string
+	propertiesFile string
+	fromGrype      string
+	fromTrivy      string
+	osvCacheDir    string
+	nvd            bool
+	repository     string
+	outputDir      string
+	inPlace        bool
+	backup         bool
+	verifyVersions bool
+	force          bool
+	syncMismatches bool
+	overrideBOMs   bool
+	strategy       string
+	conflictPolicy string
+	quarantine     string
+	strategyRules  string
+	pins           []string
+	skipScopes     []string
+	optionalPolicy string
+}
+
+var ciFlags ciCLIFlags
+
+func CICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ci <pom-file>",
+		Short: "Analyze, patch and verify a POM file in one go",
+		Long: `Analyze a POM file, plan how to apply the given patches, apply them and verify
+that the patched POM has the requested versions. Meant to run in CI.
+
+The output directory contains:
+  pom.xml                   the patched POM
+  pombump-deps.yaml         the dependency patches that were applied
+  pombump-properties.yaml   the property patches that were applied
+  report.json               the full report
+  report.sarif              the report as SARIF, for code scanning UIs
+  report.md                 the report as markdown, for PR comments and job summaries
+
+The command fails if any patch could not be applied or verified.
+
+Examples:
+  # Patch from a Grype scan, writing everything to ./pombump-out
+  pombump ci pom.xml --from-grype scan.json
+
+  # Only apply low-risk changes, leave the rest for "pombump apply --quarantine"
+  pombump ci pom.xml --from-grype scan.json --quarantine quarantine.yaml
+
+  # Patch explicit versions, writing the patched POM back in place
+  pombump ci pom.xml --patches "io.netty@netty-handler@4.1.94.Final" --in-place --output-dir out`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			log := clog.FromContext(ctx)
+			pomFile := args[0]
+
+			if ciFlags.patches == "" && ciFlags.patchFile == "" && ciFlags.fromGrype == "" && ciFlags.fromTrivy == "" &&
+				ciFlags.properties == "" && ciFlags.propertiesFile == "" {
+				return fmt.Errorf("nothing to do, use --patches/--patch-file/--from-grype/--from-trivy or --properties/--properties-file")
+			}
+			if ciFlags.backup && !ciFlags.inPlace {
+				return fmt.Errorf("--backup needs --in-place")
+			}
+
+			strategy, err := pkg.ParseStrategy(ciFlags.strategy)
+			if err != nil {
+				return err
+			}
+			conflictPolicy, err := pkg.ParseConflictPolicy(ciFlags.conflictPolicy)
+			if err != nil {
+				return err
+			}
+			patches, err := ciPatches(ctx)
+			if err != nil {
+				return err
+			}
+			explicitProperties, err := pkg.ParseProperties(ctx, ciFlags.propertiesFile, ciFlags.properties)
+			if err != nil {
+				return fmt.Errorf("failed to parse properties: %w", err)
+			}
+
+			if ciFlags.inPlace {
+				release, err := lockFiles(ctx, pomFile)
+				if err != nil {
+					return err
+				}
+				defer release()
+			}
+
+			// Analyze
+			parsedPom, err := pkg.ParsePOMFile(pomFile)
+			if err != nil {
+				return fmt.Errorf("failed to parse POM file: %w", err)
+			}
+			skipScopes, err := pkg.ParseScopes(ciFlags.skipScopes)
+			if err != nil {
+				return err
+			}
+			analysis, err := pkg.AnalyzeProject(ctx, parsedPom, pkg.WithSettings(mavenSettings), pkg.WithSkippedScopes(skipScopes))
+			if err != nil {
+				return fmt.Errorf("failed to analyze project: %w", err)
+			}
+			analysis.Suppress(suppressions)
+			if ciFlags.overrideBOMs || strategy == pkg.StrategyPreferBOM {
+				analysis.ResolveBOMs(ctx, newRepository(ciFlags.repository))
+			}
+
+			// Plan
+			patches, suppressed := suppressions.Patches(patches)
+			patches, skipped := analysis.SkipScopedPatches(patches)
+			patches, candidates, err := resolvePatchVersions(ctx, patches, analysis.CurrentVersions(), ciFlags.osvCacheDir, ciFlags.repository)
+			if err != nil {
+				return err
+			}
+			if patches, err = unappliedPatches(ctx, pomFile, patches, analysis.CurrentVersions()); err != nil {
+				return err
+			}
+			if !ciFlags.force {
+				var effective, effectiveProperties []pkg.SkippedPatch
+				patches, effective = analysis.SkipEffectivePatches(patches)
+				explicitProperties, effectiveProperties = analysis.SkipEffectiveProperties(explicitProperties)
+				skipped = append(skipped, append(effective, effectiveProperties...)...)
+			}
+			var advisories []pkg.CVEDetails
+			if ciFlags.nvd {
+				advisories = pkg.LookupCVEs(ctx, newNVDClient(ciFlags.osvCacheDir), patches)
+			}
+			var unfixable []pkg.UnfixableIssue
+			if ciFlags.verifyVersions {
+				patches, unfixable, err = pkg.VerifyVersions(ctx, newRepository(ciFlags.repository), patches)
+				if err != nil {
+					return err
+				}
+				var suppressedUnfixable []pkg.SuppressedIssue
+				unfixable, suppressedUnfixable = suppressions.Unfixable(unfixable)
+				suppressed = append(suppressed, suppressedUnfixable...)
+			}
+			patches, _, err = pkg.ResolveVersionConflicts(ctx, analysis, patches, conflictPolicy)
+			if err != nil {
+				return err
+			}
+			optionalPolicy, err := pkg.ParseOptionalPolicy(ciFlags.optionalPolicy)
+			if err != nil {
+				return err
+			}
+			strategyOpts := []pkg.PatchStrategyOption{pkg.WithStrategy(strategy), pkg.WithOptionalPolicy(optionalPolicy)}
+			if ciFlags.syncMismatches {
+				strategyOpts = append(strategyOpts, pkg.WithMismatchSync())
+			}
+			if ciFlags.overrideBOMs {
+				strategyOpts = append(strategyOpts, pkg.WithBOMOverrides())
+			}
+			if ciFlags.strategyRules != "" {
+				rules, err := pkg.LoadStrategyRules(ciFlags.strategyRules)
+				if err != nil {
+					return err
+				}
+				strategyOpts = append(strategyOpts, pkg.WithStrategyHooks(rules))
+			}
+			if len(ciFlags.pins) > 0 {
+				pins, err := pkg.ParsePins(ciFlags.pins)
+				if err != nil {
+					return err
+				}
+				strategyOpts = append(strategyOpts, pkg.WithStrategyHooks(pins))
+			}
+			strategyOpts = append(strategyOpts, pkg.WithSkippedPatches(&skipped))
+			directPatches, propertyPatches := pkg.PatchStrategy(ctx, analysis, patches, strategyOpts...)
+			for k, v := range explicitProperties {
+				propertyPatches[k] = v
+			}
+
+			// Risky changes wait for a human, see `pombump apply --quarantine`.
+			var quarantined *pkg.QuarantinePlan
+			if ciFlags.quarantine != "" {
+				directPatches, propertyPatches, quarantined = pkg.QuarantineRisky(ctx, analysis, parsedPom, directPatches, propertyPatches)
+				if !quarantined.Empty() {
+					if err := pkg.WriteQuarantinePlan(ciFlags.quarantine, quarantined); err != nil {
+						return fmt.Errorf("failed to write quarantine plan: %w", err)
+					}
+					log.Infof("Quarantined %d patches and %d properties to %s", len(quarantined.Patches), len(quarantined.Properties), ciFlags.quarantine)
+				}
+			}
+
+			// Apply
+			patchedPom, err := pkg.PatchProject(ctx, parsedPom, directPatches, propertyPatches)
+			if err != nil {
+				return fmt.Errorf("failed to patch the pom file: %w", err)
+			}
+			out, err := marshalPOM(ctx, patchedPom, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to marshal the pom file: %w", err)
+			}
+			if err := os.MkdirAll(ciFlags.outputDir, 0755); err != nil {
+				return fmt.Errorf("failed to create output directory: %w", err)
+			}
+			patchedFile := filepath.Join(ciFlags.outputDir, ciPatchedPOM)
+			if err := os.WriteFile(patchedFile, out, 0644); err != nil {
+				return fmt.Errorf("failed to write patched POM: %w", err)
+			}
+			if ciFlags.inPlace {
+				if err := writePOM(ctx, pomFile, out, ciFlags.backup); err != nil {
+					return fmt.Errorf("failed to write %s: %w", pomFile, err)
+				}
+			}
+
+			// Verify, against what actually ended up on disk.
+			reparsed, err := pkg.ParsePOMFile(patchedFile)
+			if err != nil {
+				return fmt.Errorf("failed to parse patched POM: %w", err)
+			}
+			verification, err := pkg.CheckPatches(ctx, reparsed, directPatches, propertyPatches)
+			if err != nil {
+				return fmt.Errorf("failed to verify patched POM: %w", err)
+			}
+
+			report := pkg.NewCIReport(pomFile, analysis, directPatches, propertyPatches, unfixable, verification)
+			report.Quarantined = quarantined
+			report.Candidates = candidates
+			report.Advisories = advisories
+			report.Suppressed = append(report.Suppressed, suppressed...)
+			report.Skipped = skipped
+			if err := writeCIOutputs(ciFlags.outputDir, report); err != nil {
+				return err
+			}
+			log.Infof("Wrote patched POM and reports to %s", ciFlags.outputDir)
+
+			if !report.Passed {
+				return fmt.Errorf("%d patches were not applied, see %s", countFailures(report), filepath.Join(ciFlags.outputDir, ciReportMarkdown))
+			}
+			if ciFlags.inPlace {
+				return recordPatches(ctx, pomFile, directPatches, propertyPatches)
+			}
+			return nil
+		},
+	}
+
+	flagSet := cmd.Flags()
+	flagSet.StringVar(&ciFlags.patches, "patches", "", "Space-separated list of patches to apply (groupID@artifactID@version or pkg:maven/groupID/artifactID@version)")
+	flagSet.StringVar(&ciFlags.patchFile, "patch-file", "", "File containing patches to apply")
+	flagSet.StringVar(&ciFlags.properties, "properties", "", "A space-separated list of properties to update in form property@value")
+	flagSet.StringVar(&ciFlags.propertiesFile, "properties-file", "", "The input file to read properties from")
+	flagSet.StringVar(&ciFlags.fromGrype, "from-grype", "", "Grype JSON report to read patches from (fixed versions of vulnerable Maven artifacts)")
+	flagSet.StringVar(&ciFlags.fromTrivy, "from-trivy", "", "Trivy JSON report to read patches from (fixed versions of vulnerable Java packages)")
+	flagSet.StringVar(&ciFlags.osvCacheDir, "osv-cache-dir", "", "Directory to cache OSV, GitHub advisory and NVD lookups in (defaults to the user cache directory)")
+	flagSet.BoolVar(&ciFlags.nvd, "nvd", false, "Look up the CVSS score, vector and publication date of the CVEs patched in the NVD, for the reports ($NVD_API_KEY raises its rate limit)")
+	flagSet.StringVar(&ciFlags.repository, "repository", pkg.DefaultRepositoryURL, "Maven repository to look up @latest versions in and verify versions against")
+	flagSet.BoolVar(&ciFlags.verifyVersions, "verify-versions", false, "Check that every requested version is published in the repository before applying it")
+	flagSet.BoolVar(&ciFlags.force, "force", false, "Patch dependencies and properties to the version or value already in effect too, instead of skipping them")
+	flagSet.BoolVar(&ciFlags.syncMismatches, "sync-mismatches", false, "When a dependency is declared differently in dependencies and dependencyManagement, update both and not just the winning one")
+	flagSet.BoolVar(&ciFlags.overrideBOMs, "override-boms", false, "Pin the fixed version of artifacts managed by an imported BOM with a dependencyManagement entry ahead of it")
+	flagSet.StringVar(&ciFlags.strategy, "strategy", string(pkg.StrategyAuto), "How to patch dependencies: auto (the heuristics), prefer-property, prefer-direct or prefer-bom (implies resolving the BOMs)")
+	flagSet.StringVar(&ciFlags.strategyRules, "strategy-rules", "", "YAML or JSON file of rules forbidding some bumps (e.g. major bumps of guava), whose patches are dropped")
+	flagSet.StringVar(&ciFlags.optionalPolicy, "optional-dependencies", string(pkg.OptionalPolicyInclude), "How to treat the patches of <optional> dependencies: include them, skip them, or deprioritize them (patched after the others, never bumping a property they share with required dependencies)")
+	flagSet.StringSliceVar(&ciFlags.skipScopes, "skip-scopes", nil, "Scopes of the dependencies to leave out of the analysis, the recommendations and the issue counts (e.g. test,provided)")
+	flagSet.StringSliceVar(&ciFlags.pins, "pin", nil, "Dependencies never to patch, as groupId:artifactId, or never to patch past a version, as groupId:artifactId@version (* matches any part)")
+	flagSet.StringVar(&ciFlags.conflictPolicy, "conflict-policy", string(pkg.ConflictPolicyHighest), "What to do when patches request different versions for one artifact or one shared property: highest, lowest-fix (the lowest version satisfying every advisory) or fail")
+	flagSet.StringVar(&ciFlags.quarantine, "quarantine", "", "Write major bumps, BOM introductions and parent changes to this plan file instead of applying them")
+	flagSet.StringVar(&ciFlags.outputDir, "output-dir", ciDefaultOutput, "Directory to write the patched POM and the reports to")
+	flagSet.BoolVar(&ciFlags.inPlace, "in-place", false, "Also overwrite the input POM file with the patched one")
+	flagSet.BoolVar(&ciFlags.backup, "backup", false, "With --in-place, keep the original as <file>"+pkg.BackupSuffix)
+
+	return cmd
+}
+
+// ciPatches collects the patches from all the sources given on the command
+// line.
+func ciPatches(ctx context.Context) ([]pkg.Patch, error) {
+	return collectPatches(ctx, ciFlags.patchFile, ciFlags.patches, ciFlags.fromGrype, ciFlags.fromTrivy)
+}
+
+// collectPatches reads the patches from a patch file or list, and from
+// Grype and Trivy reports, any of which may be empty.
+func collectPatches(ctx context.Context, patchFile, patchList, fromGrype, fromTrivy string) ([]pkg.Patch, error) {
+	if patchFile != "" && patchList != "" {
+		return nil, fmt.Errorf("use either --patches or --patch-file")
+	}
+	patches, err := pkg.ParsePatches(ctx, patchFile, patchList)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse patches: %w", err)
+	}
+	if fromGrype != "" {
+		grypePatches, err := pkg.PatchesFromGrype(ctx, fromGrype)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read grype report: %w", err)
+		}
+		patches = append(patches, grypePatches...)
+	}
+	if fromTrivy != "" {
+		trivyPatches, err := pkg.PatchesFromTrivy(ctx, fromTrivy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read trivy report: %w", err)
+		}
+		patches = append(patches, trivyPatches...)
+	}
+	return patches, nil
+}
+
+// writeCIOutputs writes the reports and the applied patch files to dir.
+func writeCIOutputs(dir string, report *pkg.CIReport) error {
+	jsonReport, err := report.JSON()
+	if err != nil {
+		return fmt.Errorf("failed to render JSON report: %w", err)
+	}
+	sarifReport, err := report.SARIF()
+	if err != nil {
+		return fmt.Errorf("failed to render SARIF report: %w", err)
+	}
+	deps, err := yaml.Marshal(pkg.PatchList{Patches: report.DirectPatches})
+	if err != nil {
+		return fmt.Errorf("failed to render dependency patches: %w", err)
+	}
+	props, err := yaml.Marshal(pkg.PropertyList{Properties: report.PropertyPatches})
+	if err != nil {
+		return fmt.Errorf("failed to render property patches: %w", err)
+	}
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{ciReportJSON, jsonReport},
+		{ciReportSARIF, sarifReport},
+		{ciReportMarkdown, []byte(report.Markdown())},
+		{ciDepsFile, deps},
+		{ciPropertiesFile, props},
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, f.name), f.data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+// countFailures returns the number of patches that were not applied.
+func countFailures(report *pkg.CIReport) int {
+	failures := len(report.Unfixable)
+	for _, v := range report.Verification {
+		if !v.Satisfied {
+			failures++
+		}
+	}
+	return failures
+}
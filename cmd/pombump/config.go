@@ -0,0 +1,74 @@
+package pombump
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// configFile is the --config flag: the project configuration file to use
+// instead of the .pombump.yaml found from the POM.
+var configFile string
+
+// applyConfig sets the flags of cmd not given on the command line from the
+// user and project configuration files, the project one being looked up
+// from the directory of the first argument.
+func applyConfig(cmd *cobra.Command, args []string) error {
+	dir := "."
+	if len(args) > 0 {
+		dir = filepath.Dir(args[0])
+	}
+	config, err := pkg.LoadConfigs(dir, configFile)
+	if err != nil {
+		return err
+	}
+	if len(config.Files) == 0 {
+		return nil
+	}
+	if err := config.Validate(commandFlags(cmd.Root())); err != nil {
+		return err
+	}
+
+	flags := cmd.Flags()
+	values := config.FlagsFor(cmd.Name(), func(name string) bool { return flags.Lookup(name) != nil })
+	for name, value := range values {
+		flag := flags.Lookup(name)
+		if flag.Changed {
+			continue
+		}
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			err = slice.Replace(value)
+		} else if len(value) != 1 {
+			err = fmt.Errorf("expected a single value, got %d", len(value))
+		} else {
+			err = flag.Value.Set(value[0])
+		}
+		if err != nil {
+			return fmt.Errorf("%s: invalid value for --%s: %w", strings.Join(config.Files, ", "), name, err)
+		}
+	}
+	return nil
+}
+
+// commandFlags returns the names of the flags of cmd and of every command
+// under it, by command name.
+func commandFlags(cmd *cobra.Command) map[string][]string {
+	names := map[string][]string{}
+	var walk func(*cobra.Command)
+	walk = func(c *cobra.Command) {
+		for _, flags := range []*pflag.FlagSet{c.Flags(), c.InheritedFlags()} {
+			flags.VisitAll(func(flag *pflag.Flag) {
+				names[c.Name()] = append(names[c.Name()], flag.Name)
+			})
+		}
+		for _, child := range c.Commands() {
+			walk(child)
+		}
+	}
+	walk(cmd)
+	return names
+}
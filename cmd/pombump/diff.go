@@ -0,0 +1,108 @@
+package pombump
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+)
+
+type diffCLIFlags struct {
+	outputFormat string
+}
+
+var diffFlags diffCLIFlags
+
+func DiffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <old-pom-file> <new-pom-file>",
+		Short: "Compare the dependencies, properties and BOMs of two POMs",
+		Long: `Compare two POM files, say those of two upstream releases, and report the
+dependencies, properties, imported BOMs and parent added, removed or changed,
+to review what an upstream release changed before rebasing patches onto it.
+
+Versions are compared as they are in effect, with properties resolved, so a
+dependency whose version comes from a bumped property is reported as changed.
+
+Examples:
+  # What changed between two releases
+  pombump diff zipkin-3.4.0.pom zipkin-3.5.0.pom
+
+  # The same, for a script
+  pombump diff old/pom.xml pom.xml --output json`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			before, err := analyzePOM(cmd.Context(), args[0], false, nil, nil)
+			if err != nil {
+				return err
+			}
+			after, err := analyzePOM(cmd.Context(), args[1], false, nil, nil)
+			if err != nil {
+				return err
+			}
+			diff := pkg.DiffPOMs(before, after)
+
+			switch diffFlags.outputFormat {
+			case "yaml", "json":
+				var out []byte
+				if diffFlags.outputFormat == "json" {
+					out, err = json.MarshalIndent(diff, "", "  ")
+				} else {
+					out, err = yaml.Marshal(diff)
+				}
+				if err != nil {
+					return fmt.Errorf("failed to marshal diff: %w", err)
+				}
+				fmt.Println(string(out))
+			case "human":
+				outputPOMDiff(diff)
+			default:
+				return fmt.Errorf("unsupported output format %q, use human, yaml or json", diffFlags.outputFormat)
+			}
+			return nil
+		},
+	}
+
+	flagSet := cmd.Flags()
+	flagSet.StringVar(&diffFlags.outputFormat, "output", "human", "Output format: human, yaml or json")
+
+	return cmd
+}
+
+func outputPOMDiff(diff *pkg.POMDiff) {
+	fmt.Printf("POM Diff %s -> %s\n", diff.Old, diff.New)
+	fmt.Println("--------------------------------------")
+	if diff.Empty() {
+		fmt.Println("  No dependencies, properties, BOMs or parent changed")
+		return
+	}
+	// A dependency declared without a version is managed elsewhere.
+	printChanges := func(title string, changes []pkg.POMChange, empty string) {
+		if len(changes) == 0 {
+			return
+		}
+		version := func(v string) string {
+			if v == "" {
+				return empty
+			}
+			return v
+		}
+		fmt.Printf("  %s (%d changed):\n", title, len(changes))
+		for _, c := range changes {
+			switch c.Change {
+			case pkg.ChangeAdded:
+				fmt.Printf("    %s: (new) -> %s\n", c.Name, version(c.New))
+			case pkg.ChangeRemoved:
+				fmt.Printf("    %s: %s -> (removed)\n", c.Name, version(c.Old))
+			default:
+				fmt.Printf("    %s: %s -> %s\n", c.Name, version(c.Old), version(c.New))
+			}
+		}
+	}
+	printChanges("Parent", diff.Parent, "(none)")
+	printChanges("Dependencies", diff.Dependencies, "(managed)")
+	printChanges("Properties", diff.Properties, "(empty)")
+	printChanges("BOMs", diff.BOMs, "(none)")
+}
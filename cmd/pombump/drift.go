@@ -0,0 +1,177 @@
+package pombump
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+)
+
+type driftCLIFlags struct {
+	dependencies   string
+	patchFile      string
+	properties     string
+	propertiesFile string
+	upstream       string
+	upstreamURL    string
+	repository     string
+	outputFormat   string
+}
+
+var driftFlags driftCLIFlags
+
+func DriftCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drift <pom-file>",
+		Short: "Find local overrides that upstream has caught up with",
+		Long: `Compare the local pombump overrides with the newest upstream release POM.
+Every patch and property override is checked against what upstream declares,
+and the ones where upstream is at or past the override are reported as
+redundant, so they can be dropped.
+
+By default the upstream is the newest release of the groupId:artifactId of
+the given POM in the repository.
+
+Examples:
+  # Check which patches are no longer needed
+  pombump drift pom.xml --patch-file pombump-deps.yaml --properties-file pombump-properties.yaml
+
+  # Compare against a specific upstream release
+  pombump drift pom.xml --patch-file pombump-deps.yaml --upstream io.zipkin:zipkin-parent:3.4.0
+
+  # Compare against a POM from the upstream source repository
+  pombump drift pom.xml --patch-file pombump-deps.yaml \
+    --upstream-url https://raw.githubusercontent.com/openzipkin/zipkin/master/pom.xml`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if driftFlags.upstream != "" && driftFlags.upstreamURL != "" {
+				return fmt.Errorf("use either --upstream or --upstream-url")
+			}
+
+			patches, err := pkg.ParsePatches(cmd.Context(), driftFlags.patchFile, driftFlags.dependencies)
+			if err != nil {
+				return fmt.Errorf("failed to parse patches: %w", err)
+			}
+			propertyPatches, err := pkg.ParseProperties(cmd.Context(), driftFlags.propertiesFile, driftFlags.properties)
+			if err != nil {
+				return fmt.Errorf("failed to parse properties: %w", err)
+			}
+			if len(patches) == 0 && len(propertyPatches) == 0 {
+				return fmt.Errorf("no overrides to check, use --dependencies/--patch-file or --properties/--properties-file")
+			}
+
+			repo := newRepository(driftFlags.repository)
+			var upstream *gopom.Project
+			if driftFlags.upstreamURL != "" {
+				upstream, err = repo.FetchPOMFromURL(cmd.Context(), driftFlags.upstreamURL)
+			} else {
+				var groupID, artifactID, version string
+				groupID, artifactID, version, err = upstreamCoordinates(args[0], driftFlags.upstream)
+				if err != nil {
+					return err
+				}
+				upstream, err = pkg.FetchUpstreamPOM(cmd.Context(), repo, groupID, artifactID, version)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to fetch upstream POM: %w", err)
+			}
+
+			report, err := pkg.CompareWithUpstream(cmd.Context(), upstream, patches, propertyPatches)
+			if err != nil {
+				return fmt.Errorf("failed to compare with upstream: %w", err)
+			}
+
+			if driftFlags.outputFormat == "yaml" {
+				out, err := yaml.Marshal(report)
+				if err != nil {
+					return fmt.Errorf("failed to marshal report: %w", err)
+				}
+				fmt.Println(string(out))
+				return nil
+			}
+			outputDriftReport(report)
+			return nil
+		},
+	}
+
+	flagSet := cmd.Flags()
+	flagSet.StringVar(&driftFlags.dependencies, "dependencies", "", "Space-separated list of dependency overrides (groupID@artifactID@version or pkg:maven/groupID/artifactID@version)")
+	flagSet.StringVar(&driftFlags.patchFile, "patch-file", "", "File containing the dependency overrides")
+	flagSet.StringVar(&driftFlags.properties, "properties", "", "Space-separated list of property overrides (property@value)")
+	flagSet.StringVar(&driftFlags.propertiesFile, "properties-file", "", "File containing the property overrides")
+	flagSet.StringVar(&driftFlags.upstream, "upstream", "", "Upstream coordinates groupId:artifactId[:version] or pkg:maven/groupId/artifactId[@version] (defaults to the newest release of the local POM)")
+	flagSet.StringVar(&driftFlags.upstreamURL, "upstream-url", "", "URL of the upstream POM to compare with")
+	flagSet.StringVar(&driftFlags.repository, "repository", pkg.DefaultRepositoryURL, "Maven repository to fetch upstream releases from")
+	flagSet.StringVar(&driftFlags.outputFormat, "output", "human", "Output format: human or yaml")
+
+	return cmd
+}
+
+// upstreamCoordinates figures out which upstream release to compare with,
+// either from the --upstream flag or from the local POM.
+func upstreamCoordinates(pomFile, upstream string) (string, string, string, error) {
+	if pkg.IsPurl(upstream) {
+		p, err := pkg.ParsePurl(upstream)
+		if err != nil {
+			return "", "", "", err
+		}
+		return p.GroupID, p.ArtifactID, p.Version, nil
+	}
+	if upstream != "" {
+		parts := strings.Split(upstream, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return "", "", "", fmt.Errorf("invalid upstream %q, use groupId:artifactId[:version]", upstream)
+		}
+		if len(parts) == 2 {
+			return parts[0], parts[1], "", nil
+		}
+		return parts[0], parts[1], parts[2], nil
+	}
+
+	project, err := pkg.ParsePOMFile(pomFile)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to parse POM file: %w", err)
+	}
+	groupID := project.GroupID
+	if groupID == "" && project.Parent != nil {
+		groupID = project.Parent.GroupID
+	}
+	if groupID == "" || project.ArtifactID == "" {
+		return "", "", "", fmt.Errorf("%s has no groupId/artifactId, use --upstream", pomFile)
+	}
+	return groupID, project.ArtifactID, "", nil
+}
+
+func outputDriftReport(report *pkg.DriftReport) {
+	fmt.Println("Upstream Drift Report")
+	fmt.Println("=====================")
+	fmt.Printf("Upstream: %s\n\n", report.Upstream)
+
+	printEntries := func(title string, entries []pkg.DriftEntry) {
+		if len(entries) == 0 {
+			return
+		}
+		fmt.Println(title)
+		fmt.Println(strings.Repeat("-", len(title)))
+		for _, e := range entries {
+			upstream := e.Upstream
+			if upstream == "" {
+				upstream = "(not declared)"
+			}
+			status := "still needed"
+			if e.Redundant {
+				status = "REDUNDANT"
+			}
+			fmt.Printf("  %s: override %s, upstream %s - %s\n", e.Name, e.Override, upstream, status)
+		}
+		fmt.Println()
+	}
+	printEntries("Dependency Overrides:", report.Dependencies)
+	printEntries("Property Overrides:", report.Properties)
+
+	fmt.Printf("Summary: %d of %d overrides are redundant\n",
+		report.Redundant(), len(report.Dependencies)+len(report.Properties))
+}
@@ -0,0 +1,123 @@
+package pombump
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+)
+
+type explainCLIFlags struct {
+	searchProperties bool
+	exclude          []string
+	include          []string
+	resolve          bool
+	effectivePOM     bool
+	repository       string
+	outputFormat     string
+}
+
+var explainFlags explainCLIFlags
+
+func ExplainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "explain <pom-file> <groupId:artifactId>",
+		Short: "Explain where the version of a dependency comes from",
+		Long: `Explain where the version of a dependency comes from: a literal version, a
+property (and the file defining it), the dependencyManagement of the POM, an
+imported BOM or the parent, and so what to patch to change it.
+
+The parent and the BOMs are only known to manage a version with --resolve, or
+--effective-pom.
+
+Examples:
+  # Where does the version of netty-handler come from?
+  pombump explain pom.xml io.netty:netty-handler
+
+  # Including properties of the other POMs of the project, and what the
+  # parent and BOMs manage
+  pombump explain pom.xml io.netty:netty-handler --search-properties --resolve`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			parts := strings.Split(args[1], ":")
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				return fmt.Errorf("invalid dependency %q, use groupId:artifactId", args[1])
+			}
+
+			analysis, err := analyzePOM(cmd.Context(), args[0], explainFlags.searchProperties, explainFlags.exclude, explainFlags.include)
+			if err != nil {
+				return err
+			}
+			if explainFlags.resolve {
+				analysis.ResolveBOMs(cmd.Context(), newRepository(explainFlags.repository))
+			}
+			if explainFlags.effectivePOM {
+				effective, err := pkg.EffectivePOM(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+				analysis.ApplyEffectivePOM(cmd.Context(), effective)
+			}
+
+			provenance, err := analysis.Explain(parts[0], parts[1])
+			if err != nil {
+				return err
+			}
+			switch explainFlags.outputFormat {
+			case "yaml", "json":
+				var out []byte
+				if explainFlags.outputFormat == "json" {
+					out, err = json.MarshalIndent(provenance, "", "  ")
+				} else {
+					out, err = yaml.Marshal(provenance)
+				}
+				if err != nil {
+					return fmt.Errorf("failed to marshal explanation: %w", err)
+				}
+				fmt.Println(string(out))
+			case "human":
+				fmt.Print(provenance.String())
+			default:
+				return fmt.Errorf("unsupported output format %q, use human, yaml or json", explainFlags.outputFormat)
+			}
+			return nil
+		},
+	}
+
+	flagSet := cmd.Flags()
+	flagSet.BoolVar(&explainFlags.searchProperties, "search-properties", false, "Search for properties in nearby POM files")
+	flagSet.StringSliceVar(&explainFlags.exclude, "exclude", nil, "Additional patterns to skip during property search (trailing / matches directories)")
+	flagSet.StringSliceVar(&explainFlags.include, "include", nil, "Patterns to search even if excluded (e.g. a POM in target/ or build/)")
+	flagSet.BoolVar(&explainFlags.resolve, "resolve", false, "Resolve the BOMs and the parent from the repository to know what they manage")
+	flagSet.BoolVar(&explainFlags.effectivePOM, "effective-pom", false, "Run \"mvn help:effective-pom\" (mvn must be on the PATH) to learn the managed versions pombump can not resolve itself")
+	flagSet.StringVar(&explainFlags.repository, "repository", pkg.DefaultRepositoryURL, "Maven repository to resolve from")
+	flagSet.StringVar(&explainFlags.outputFormat, "output", "human", "Output format: human, yaml or json")
+
+	return cmd
+}
+
+// analyzePOM analyzes the POM at path, with the properties of the nearby
+// POMs not excluded if searchProperties is set.
+func analyzePOM(ctx context.Context, path string, searchProperties bool, exclude, include []string) (*pkg.AnalysisResult, error) {
+	opts := []pkg.AnalyzeOption{pkg.WithSettings(mavenSettings)}
+	if searchProperties {
+		analysis, err := pkg.AnalyzeProjectPathWithFilter(ctx, path, pkg.NewPathFilter(exclude, include), opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze project: %w", err)
+		}
+		return analysis, nil
+	}
+	parsedPom, err := pkg.ParseAnalysisPOM(ctx, path, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse POM file: %w", err)
+	}
+	analysis, err := pkg.AnalyzeProject(ctx, parsedPom, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze project: %w", err)
+	}
+	return analysis, nil
+}
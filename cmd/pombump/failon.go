@@ -0,0 +1,108 @@
+package pombump
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chainguard-dev/pombump/pkg"
+)
+
+// ExitError is an error that sets the exit code of the process, so that CI
+// pipelines can tell what made a command fail.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// The conditions analyze --fail-on can gate on, and the exit code of each.
+// Other failures exit with 1.
+const (
+	// failOnIssues is any recommended property or dependency update.
+	failOnIssues = "issues"
+	// failOnConflicts is patches requesting different versions for one
+	// artifact or one shared property.
+	failOnConflicts = "conflicts"
+	// failOnUnfixable is requested versions that are not published.
+	failOnUnfixable = "unfixable"
+	// failOnWarnings is BOM bumps downgrading pinned artifacts, BOM
+	// versions shadowed by an earlier BOM, POMs breaking the schema and
+	// malformed POMs analyzed leniently.
+	failOnWarnings = "warnings"
+	// failOnSnapshots is dependencies and properties resolving to SNAPSHOT
+	// versions.
+	failOnSnapshots = "snapshots"
+)
+
+var failOnExitCodes = map[string]int{
+	failOnIssues:    2,
+	failOnConflicts: 3,
+	failOnUnfixable: 4,
+	failOnWarnings:  5,
+	failOnSnapshots: 6,
+}
+
+// validateFailOn checks that every condition is known.
+func validateFailOn(conditions []string) error {
+	for _, condition := range conditions {
+		if _, ok := failOnExitCodes[condition]; !ok {
+			return fmt.Errorf("unknown --fail-on condition %q, use %s, %s, %s, %s or %s", condition, failOnIssues, failOnConflicts, failOnUnfixable, failOnWarnings, failOnSnapshots)
+		}
+	}
+	return nil
+}
+
+// checkFailOn returns an ExitError for the first of conditions found in the
+// analysis and its recommendations, or nil.
+func checkFailOn(conditions []string, analysis *pkg.AnalysisResult, recs recommendations) error {
+	for _, condition := range conditions {
+		found := []string{}
+		switch condition {
+		case failOnIssues:
+			for _, p := range recs.directPatches {
+				found = append(found, fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID))
+			}
+			for property := range recs.propertyPatches {
+				found = append(found, property)
+			}
+		case failOnConflicts:
+			for _, c := range recs.conflicts {
+				found = append(found, c.Group)
+			}
+		case failOnUnfixable:
+			for _, issue := range recs.unfixable {
+				found = append(found, fmt.Sprintf("%s:%s", issue.GroupID, issue.ArtifactID))
+			}
+		case failOnWarnings:
+			for _, bump := range recs.bomBumps {
+				for _, warning := range bump.Warnings {
+					found = append(found, pkg.FormatIssue(warning.Code, warning.Message))
+				}
+			}
+			warnings := append(analysis.ShadowedBOMVersions(), analysis.SchemaViolations()...)
+			for _, warning := range append(warnings, analysis.MalformedPOMs()...) {
+				found = append(found, pkg.FormatIssue(warning.Code, warning.Message))
+			}
+		case failOnSnapshots:
+			for _, warning := range analysis.SnapshotVersions() {
+				found = append(found, warning.Artifacts[0].Name)
+			}
+		}
+		if len(found) > 0 {
+			sort.Strings(found)
+			return &ExitError{
+				Code: failOnExitCodes[condition],
+				Err:  fmt.Errorf("failing on %s: %d found (%s)", condition, len(found), strings.Join(found, "; ")),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package pombump
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/pombump/pkg"
+)
+
+// patchGradle patches the Gradle build script at path and prints it, or
+// what was applied with --output json or yaml. A
+// property defined in gradle.properties is patched in that file, in place,
+// unless stdout is set, in which case it is an error.
+func patchGradle(ctx context.Context, path string, patches []pkg.Patch, propertyPatches map[string]string, stdout bool) error {
+	if !stdout {
+		release, err := lockFiles(ctx, filepath.Join(filepath.Dir(path), "gradle.properties"))
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	build, err := pkg.LoadGradleBuild(path)
+	if err != nil {
+		return err
+	}
+	analysis := pkg.AnalyzeGradle(ctx, build)
+	patches, _, err = resolvePatchVersions(ctx, patches, analysis.CurrentVersions(), rootFlags.osvCacheDir, rootFlags.repository)
+	if err != nil {
+		return err
+	}
+
+	patched, err := pkg.PatchGradle(ctx, build, patches, propertyPatches)
+	if err != nil {
+		return fmt.Errorf("failed to patch the build script: %w", err)
+	}
+	if !bytes.Equal(patched.Properties, build.Properties) {
+		if stdout {
+			return fmt.Errorf("%s would be patched too, which can not be printed with --stdout", patched.PropertiesPath)
+		}
+		if err := pkg.WriteFileAtomic(patched.PropertiesPath, patched.Properties, rootFlags.backup); err != nil {
+			return fmt.Errorf("failed to write %s: %w", patched.PropertiesPath, err)
+		}
+		clog.FromContext(ctx).Infof("Patched %s", patched.PropertiesPath)
+	}
+	if rootFlags.output == "human" {
+		fmt.Println(string(patched.Script))
+		return nil
+	}
+	file := pkg.NewFilePatchReport(path, analysis, pkg.AnalyzeGradle(ctx, patched), patches, propertyPatches, nil)
+	file.Patched = string(patched.Script)
+	report := &pkg.PatchReport{}
+	report.Add(file)
+	return printPatchReport(report, rootFlags.output)
+}
@@ -0,0 +1,153 @@
+package pombump
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+)
+
+// patchLockFile is the --lock-file flag: the lock recording the patches
+// applied in place.
+var patchLockFile string
+
+// unappliedPatches drops the patches the lock records as applied to pom,
+// given current, the versions it uses.
+func unappliedPatches(ctx context.Context, pom string, patches []pkg.Patch, current map[string]string) ([]pkg.Patch, error) {
+	if patchLockFile == "" {
+		return patches, nil
+	}
+	lock, err := pkg.ReadPatchLock(patchLockFile)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return patches, nil
+	case err != nil:
+		return nil, err
+	}
+	patches, applied := lock.Unapplied(pom, patches, current)
+	for _, e := range applied {
+		clog.FromContext(ctx).Infof("%s %s was already applied on %s", e.Name(), e.Version, e.AppliedAt.Format(time.DateOnly))
+	}
+	return patches, nil
+}
+
+// recordPatches records in the lock that patches and propertyPatches were
+// applied to pom.
+func recordPatches(ctx context.Context, pom string, patches []pkg.Patch, propertyPatches map[string]string) error {
+	if patchLockFile == "" {
+		return nil
+	}
+	at, err := pkg.PatchTime()
+	if err != nil {
+		return err
+	}
+	return pkg.UpdatePatchLock(ctx, patchLockFile, func(lock *pkg.PatchLock) {
+		lock.Record(pom, patches, propertyPatches, at)
+	})
+}
+
+// forgetPatches drops from the lock the patches recorded as applied to pom
+// that project, pom as restored by pombump rollback, no longer has.
+func forgetPatches(ctx context.Context, pom string, project *gopom.Project) error {
+	if patchLockFile == "" {
+		return nil
+	}
+	analysis, err := pkg.AnalyzeProject(ctx, project, pkg.WithoutBOMDetection())
+	if err != nil {
+		return fmt.Errorf("failed to analyze the restored %s: %w", pom, err)
+	}
+	current := analysis.CurrentVersions()
+	if project.Properties != nil {
+		for name, value := range project.Properties.Entries {
+			current[name] = value
+		}
+	}
+	return pkg.UpdatePatchLock(ctx, patchLockFile, func(lock *pkg.PatchLock) {
+		if dropped := lock.Drop(pom, current); dropped > 0 {
+			clog.FromContext(ctx).Infof("Dropped %d patches of %s from %s", dropped, pom, patchLockFile)
+		}
+	})
+}
+
+type historyCLIFlags struct {
+	pom    string
+	output string
+}
+
+var historyFlags historyCLIFlags
+
+func HistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history [<groupId:artifactId>|<property>|<advisory>]",
+		Short: "List the patches recorded as applied in the lock file",
+		Long: `List the patches that were applied in place and recorded in the lock file given
+by --lock-file (` + pkg.PatchLockFileName + ` by default), oldest first, optionally
+only those of a dependency, a property or an advisory.
+
+Examples:
+  # Everything applied to pom.xml
+  pombump history --pom pom.xml
+
+  # When was CVE-2023-34462 fixed, and how
+  pombump history CVE-2023-34462 --output json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file := patchLockFile
+			if file == "" {
+				file = pkg.PatchLockFileName
+			}
+			lock, err := pkg.ReadPatchLock(file)
+			if err != nil {
+				return err
+			}
+			query := ""
+			if len(args) > 0 {
+				query = args[0]
+			}
+			entries := lock.History(historyFlags.pom, query)
+
+			switch historyFlags.output {
+			case "json":
+				out, err := json.MarshalIndent(entries, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal history: %w", err)
+				}
+				fmt.Println(string(out))
+			case "yaml":
+				out, err := yaml.Marshal(entries)
+				if err != nil {
+					return fmt.Errorf("failed to marshal history: %w", err)
+				}
+				fmt.Print(string(out))
+			default:
+				if len(entries) == 0 {
+					fmt.Println("No patches recorded")
+					return nil
+				}
+				for _, e := range entries {
+					line := fmt.Sprintf("%s  %s  %s -> %s", e.AppliedAt.Format(time.RFC3339), e.POM, e.Name(), e.Version)
+					if len(e.Advisories) > 0 {
+						line += " (" + strings.Join(e.Advisories, ", ") + ")"
+					}
+					fmt.Println(line)
+				}
+			}
+			return nil
+		},
+	}
+
+	flagSet := cmd.Flags()
+	flagSet.StringVar(&historyFlags.pom, "pom", "", "Only list the patches applied to this POM file")
+	flagSet.StringVar(&historyFlags.output, "output", "human", "Output format (human, yaml, json)")
+
+	return cmd
+}
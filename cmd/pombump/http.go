@@ -0,0 +1,72 @@
+package pombump
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/chainguard-dev/pombump/pkg"
+)
+
+type httpCLIFlags struct {
+	timeout  time.Duration
+	retries  int
+	cacheDir string
+	cacheTTL time.Duration
+}
+
+// httpFlags are the persistent flags configuring the HTTP client of every
+// remote lookup.
+var httpFlags httpCLIFlags
+
+// applyHTTPFlags sets the options of the HTTP clients from httpFlags.
+func applyHTTPFlags() {
+	pkg.SetHTTPOptions(pkg.HTTPOptions{
+		Timeout:  httpFlags.timeout,
+		Retries:  httpFlags.retries,
+		CacheDir: httpFlags.cacheDir,
+	})
+}
+
+// metadataCache returns the cache of the repository metadata, under
+// --cache-dir if set, or nil if --cache-ttl disables caching.
+func metadataCache() *pkg.MetadataCache {
+	if httpFlags.cacheTTL <= 0 {
+		return nil
+	}
+	dir := ""
+	if httpFlags.cacheDir != "" {
+		dir = filepath.Join(httpFlags.cacheDir, "metadata")
+	}
+	return pkg.NewMetadataCache(dir, httpFlags.cacheTTL)
+}
+
+// newOSVResolver returns an OSV resolver caching in cacheDir for --cache-ttl.
+func newOSVResolver(cacheDir string) *pkg.OSVResolver {
+	resolver := pkg.NewOSVResolver(cacheDir)
+	resolver.CacheTTL = httpFlags.cacheTTL
+	if httpFlags.cacheTTL <= 0 {
+		resolver.CacheDir = ""
+	}
+	return resolver
+}
+
+// newGHSAResolver returns a GitHub advisory resolver caching in cacheDir for
+// --cache-ttl.
+func newGHSAResolver(cacheDir string) *pkg.GHSAResolver {
+	resolver := pkg.NewGHSAResolver(cacheDir)
+	resolver.CacheTTL = httpFlags.cacheTTL
+	if httpFlags.cacheTTL <= 0 {
+		resolver.CacheDir = ""
+	}
+	return resolver
+}
+
+// newNVDClient returns an NVD client caching in cacheDir for --cache-ttl.
+func newNVDClient(cacheDir string) *pkg.NVDClient {
+	client := pkg.NewNVDClient(cacheDir)
+	client.CacheTTL = httpFlags.cacheTTL
+	if httpFlags.cacheTTL <= 0 {
+		client.CacheDir = ""
+	}
+	return client
+}
@@ -0,0 +1,173 @@
+package pombump
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/spf13/cobra"
+)
+
+type planCLIFlags struct {
+	patches        string
+	patchFile      string
+	properties     string
+	propertiesFile string
+	fromGrype      string
+	fromTrivy      string
+	osvCacheDir    string
+	repository     string
+	strategy       string
+	conflictPolicy string
+	skipScopes     []string
+	force          bool
+	planFile       string
+}
+
+var planFlags planCLIFlags
+
+func PlanCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plan <pom-file>",
+		Short: "Plan the changes patching a POM file would make, without making them",
+		Long: `Work out how the given patches would be applied to a POM file and write every
+change to its XML to a plan file, to be reviewed and then applied with
+"pombump apply <plan-file>". The POM file is left untouched.
+
+The plan is JSON, or YAML when --plan-file does not end in .json. Applying it
+fails if the POM changed since it was planned.
+
+Examples:
+  # Plan the fixes of a Grype scan, review plan.json, then apply it
+  pombump plan pom.xml --from-grype scan.json --plan-file plan.json
+  pombump apply plan.json --yes --in-place
+
+  # Print the plan for explicit patches
+  pombump plan pom.xml --patches "io.netty@netty-handler@4.1.94.Final"`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			pomFile := args[0]
+
+			if planFlags.patches == "" && planFlags.patchFile == "" && planFlags.fromGrype == "" && planFlags.fromTrivy == "" &&
+				planFlags.properties == "" && planFlags.propertiesFile == "" {
+				return fmt.Errorf("nothing to plan, use --patches/--patch-file/--from-grype/--from-trivy or --properties/--properties-file")
+			}
+			strategy, err := pkg.ParseStrategy(planFlags.strategy)
+			if err != nil {
+				return err
+			}
+			conflictPolicy, err := pkg.ParseConflictPolicy(planFlags.conflictPolicy)
+			if err != nil {
+				return err
+			}
+			patches, err := collectPatches(ctx, planFlags.patchFile, planFlags.patches, planFlags.fromGrype, planFlags.fromTrivy)
+			if err != nil {
+				return err
+			}
+			explicitProperties, err := pkg.ParseProperties(ctx, planFlags.propertiesFile, planFlags.properties)
+			if err != nil {
+				return fmt.Errorf("failed to parse properties: %w", err)
+			}
+
+			data, err := os.ReadFile(pomFile)
+			if err != nil {
+				return fmt.Errorf("failed to read POM file: %w", err)
+			}
+			parsedPom, err := pkg.ParsePOMFile(pomFile)
+			if err != nil {
+				return fmt.Errorf("failed to parse POM file: %w", err)
+			}
+			skipScopes, err := pkg.ParseScopes(planFlags.skipScopes)
+			if err != nil {
+				return err
+			}
+			analysis, err := pkg.AnalyzeProject(ctx, parsedPom, pkg.WithSettings(mavenSettings), pkg.WithSkippedScopes(skipScopes))
+			if err != nil {
+				return fmt.Errorf("failed to analyze project: %w", err)
+			}
+			analysis.Suppress(suppressions)
+			if strategy == pkg.StrategyPreferBOM {
+				analysis.ResolveBOMs(ctx, newRepository(planFlags.repository))
+			}
+
+			patches, _ = suppressions.Patches(patches)
+			patches, skipped := analysis.SkipScopedPatches(patches)
+			patches, _, err = resolvePatchVersions(ctx, patches, analysis.CurrentVersions(), planFlags.osvCacheDir, planFlags.repository)
+			if err != nil {
+				return err
+			}
+			if !planFlags.force {
+				var effective, effectiveProperties []pkg.SkippedPatch
+				patches, effective = analysis.SkipEffectivePatches(patches)
+				explicitProperties, effectiveProperties = analysis.SkipEffectiveProperties(explicitProperties)
+				skipped = append(skipped, append(effective, effectiveProperties...)...)
+			}
+			patches, _, err = pkg.ResolveVersionConflicts(ctx, analysis, patches, conflictPolicy)
+			if err != nil {
+				return err
+			}
+			directPatches, propertyPatches := pkg.PatchStrategy(ctx, analysis, patches, pkg.WithStrategy(strategy), pkg.WithSkippedPatches(&skipped))
+			for k, v := range explicitProperties {
+				propertyPatches[k] = v
+			}
+
+			plan, err := pkg.NewPlan(ctx, pomFile, data, directPatches, propertyPatches)
+			if err != nil {
+				return fmt.Errorf("failed to plan: %w", err)
+			}
+			out := cmd.ErrOrStderr()
+			printPlan(out, plan)
+			for _, s := range skipped {
+				fmt.Fprintf(out, "Skipped %s %s: %s\n", s.Name, s.Version, s.Reason)
+			}
+			if planFlags.planFile != "" {
+				if err := pkg.WritePlan(planFlags.planFile, plan); err != nil {
+					return fmt.Errorf("failed to write plan: %w", err)
+				}
+				fmt.Fprintf(out, "Wrote plan to %s, apply it with: pombump apply %s\n", planFlags.planFile, planFlags.planFile)
+				return nil
+			}
+			rendered, err := pkg.MarshalPlan(plan, true)
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(rendered))
+			return nil
+		},
+	}
+
+	flagSet := cmd.Flags()
+	flagSet.StringVar(&planFlags.patches, "patches", "", "Space-separated list of patches to plan (groupID@artifactID@version or pkg:maven/groupID/artifactID@version)")
+	flagSet.StringVar(&planFlags.patchFile, "patch-file", "", "File containing patches to plan")
+	flagSet.StringVar(&planFlags.properties, "properties", "", "A space-separated list of properties to update in form property@value")
+	flagSet.StringVar(&planFlags.propertiesFile, "properties-file", "", "The input file to read properties from")
+	flagSet.StringVar(&planFlags.fromGrype, "from-grype", "", "Grype JSON report to read patches from (fixed versions of vulnerable Maven artifacts)")
+	flagSet.StringVar(&planFlags.fromTrivy, "from-trivy", "", "Trivy JSON report to read patches from (fixed versions of vulnerable Java packages)")
+	flagSet.StringVar(&planFlags.osvCacheDir, "osv-cache-dir", "", "Directory to cache OSV and GitHub advisory lookups in (defaults to the user cache directory)")
+	flagSet.StringVar(&planFlags.repository, "repository", pkg.DefaultRepositoryURL, "Maven repository to look up @latest versions in")
+	flagSet.StringVar(&planFlags.strategy, "strategy", string(pkg.StrategyAuto), "How to patch dependencies: auto (the heuristics), prefer-property, prefer-direct or prefer-bom (implies resolving the BOMs)")
+	flagSet.StringVar(&planFlags.conflictPolicy, "conflict-policy", string(pkg.ConflictPolicyHighest), "What to do when patches request different versions for one artifact or one shared property: highest, lowest-fix (the lowest version satisfying every advisory) or fail")
+	flagSet.BoolVar(&planFlags.force, "force", false, "Patch dependencies and properties to the version or value already in effect too, instead of skipping them")
+	flagSet.StringSliceVar(&planFlags.skipScopes, "skip-scopes", nil, "Scopes of the dependencies to leave unpatched (e.g. test,provided)")
+	flagSet.StringVar(&planFlags.planFile, "plan-file", "", "File to write the plan to instead of printing it, as JSON if it ends in .json and as YAML otherwise")
+
+	return cmd
+}
+
+// printPlan lists the mutations of plan for humans.
+func printPlan(out io.Writer, plan *pkg.Plan) {
+	if plan.Empty() {
+		fmt.Fprintf(out, "No changes planned for %s\n", plan.POM)
+		return
+	}
+	fmt.Fprintf(out, "Planned changes to %s:\n", plan.POM)
+	for _, m := range plan.Mutations {
+		if m.Position != nil {
+			fmt.Fprintf(out, "  %s (line %d)\n", m, m.Position.Line)
+			continue
+		}
+		fmt.Fprintf(out, "  %s\n", m)
+	}
+}
@@ -0,0 +1,408 @@
+package pombump
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/spf13/cobra"
+)
+
+type prCLIFlags struct {
+	patches        string
+	patchFile      string
+	properties     string
+	propertiesFile string
+	fromGrype      string
+	fromTrivy      string
+	osvCacheDir    string
+	repository     string
+	verifyVersions bool
+	force          bool
+	syncMismatches bool
+	strategy       string
+	strategyRules  string
+	pins           []string
+	skipScopes     []string
+	optionalPolicy string
+	conflictPolicy string
+	remote         string
+	base           string
+	branch         string
+	title          string
+	draft          bool
+}
+
+var prFlags prCLIFlags
+
+func PRCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pr <pom-file>",
+		Short: "Patch a POM file on a new branch and open a GitHub pull request",
+		Long: `Analyze a POM file and apply the given patches as "pombump ci" does, then commit the
+patched POM to a new branch, push it and open a pull request on GitHub with the
+report and the analysis in its body.
+
+The POM must be in a git checkout of a GitHub repository, whose remote (see
+--remote) tells the repository to open the pull request on. The branch is pushed
+with the credentials git is configured with, and the pull request is opened with
+the token in $GITHUB_TOKEN (or $GH_TOKEN), which needs to be allowed to create
+pull requests. $GITHUB_API_URL points at GitHub Enterprise Server instead of
+github.com.
+
+The branch starts from HEAD, so a --base other than the current branch must be
+where HEAD is, on the remote, as in a CI checkout of it. The POM must not have
+uncommitted changes. Whether it succeeds or not, the command checks out the
+branch or commit it started from again, and unless the branch was pushed,
+deletes it.
+
+Examples:
+  # Fix what a Grype scan found, in a pull request against the current branch
+  GITHUB_TOKEN=... pombump pr pom.xml --from-grype scan.json
+
+  # Open a draft pull request against main, from a branch of our choosing
+  GITHUB_TOKEN=... pombump pr pom.xml --patches "io.netty@netty-handler@4.1.118.Final" \
+    --base main --branch bump-netty --draft`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			log := clog.FromContext(ctx)
+			pomFile := args[0]
+
+			if prFlags.patches == "" && prFlags.patchFile == "" && prFlags.fromGrype == "" && prFlags.fromTrivy == "" &&
+				prFlags.properties == "" && prFlags.propertiesFile == "" {
+				return fmt.Errorf("nothing to do, use --patches/--patch-file/--from-grype/--from-trivy or --properties/--properties-file")
+			}
+			token := os.Getenv("GITHUB_TOKEN")
+			if token == "" {
+				token = os.Getenv("GH_TOKEN")
+			}
+			if token == "" {
+				return fmt.Errorf("set $GITHUB_TOKEN (or $GH_TOKEN) to a token allowed to create pull requests")
+			}
+
+			// Find out where the pull request goes before changing anything.
+			git := gitRepository{dir: filepath.Dir(pomFile)}
+			remoteURL, err := git.run(ctx, "remote", "get-url", prFlags.remote)
+			if err != nil {
+				return err
+			}
+			owner, name, err := pkg.ParseGitHubRemote(remoteURL)
+			if err != nil {
+				return err
+			}
+			current, err := git.run(ctx, "rev-parse", "--abbrev-ref", "HEAD")
+			if err != nil {
+				return err
+			}
+			head, err := git.run(ctx, "rev-parse", "HEAD")
+			if err != nil {
+				return err
+			}
+			base := prFlags.base
+			if base == "" {
+				if current == "HEAD" {
+					return fmt.Errorf("HEAD is detached, use --base to say which branch to open the pull request against")
+				}
+				base = current
+			}
+			// The branch starts from HEAD, which must be the base, or the pull
+			// request would carry whatever else HEAD has.
+			if base != current {
+				baseCommit, err := git.run(ctx, "rev-parse", "--verify", prFlags.remote+"/"+base+"^{commit}")
+				if err != nil {
+					return err
+				}
+				if baseCommit != head {
+					return fmt.Errorf("HEAD is not %s/%s, check out %s to open a pull request against it", prFlags.remote, base, base)
+				}
+			}
+			// The commit would carry uncommitted edits of the POM, lost with
+			// the branch if it is not pushed.
+			if _, err := git.run(ctx, "diff", "--quiet", "HEAD", "--", filepath.Base(pomFile)); err != nil {
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+					return fmt.Errorf("%s has uncommitted changes, commit or stash them first", pomFile)
+				}
+				return err
+			}
+			release, err := lockFiles(ctx, pomFile)
+			if err != nil {
+				return err
+			}
+			defer release()
+
+			// Plan the patches.
+			analyzer, err := prAnalyzer()
+			if err != nil {
+				return err
+			}
+			patches, err := collectPatches(ctx, prFlags.patchFile, prFlags.patches, prFlags.fromGrype, prFlags.fromTrivy)
+			if err != nil {
+				return err
+			}
+			explicitProperties, err := pkg.ParseProperties(ctx, prFlags.propertiesFile, prFlags.properties)
+			if err != nil {
+				return fmt.Errorf("failed to parse properties: %w", err)
+			}
+			analysis, err := analyzer.Analyze(ctx, pomFile)
+			if err != nil {
+				return err
+			}
+			rec, err := analyzer.Recommend(ctx, analysis, patches)
+			if err != nil {
+				return err
+			}
+			for k, v := range explicitProperties {
+				rec.PropertyPatches[k] = v
+			}
+
+			// Apply them, and check that they did change the POM.
+			before, err := os.ReadFile(pomFile)
+			if err != nil {
+				return fmt.Errorf("failed to read POM file: %w", err)
+			}
+			after, err := analyzer.Patch(ctx, pomFile, rec.DirectPatches, rec.PropertyPatches)
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(before, after) {
+				log.Infof("%s is already up to date, not opening a pull request", pomFile)
+				return nil
+			}
+
+			branch := prFlags.branch
+			if branch == "" {
+				branch = prBranch(rec.DirectPatches, rec.PropertyPatches)
+			}
+			if _, err := git.run(ctx, "checkout", "-b", branch); err != nil {
+				return err
+			}
+			// Leave the checkout as we found it, whatever happens, even
+			// once ctx is canceled.
+			committed, pushed := false, false
+			defer func() {
+				ctx := context.WithoutCancel(ctx)
+				if !committed {
+					if err := pkg.WriteFileAtomic(pomFile, before, false); err != nil {
+						log.Warnf("Unable to restore %s: %v", pomFile, err)
+					}
+				}
+				original := current
+				if current == "HEAD" {
+					original = head
+				}
+				if _, err := git.run(ctx, "checkout", original); err != nil {
+					log.Warnf("Unable to switch back to %s: %v", original, err)
+					return
+				}
+				if !pushed {
+					if _, err := git.run(ctx, "branch", "-D", branch); err != nil {
+						log.Warnf("Unable to delete %s: %v", branch, err)
+					}
+				}
+			}()
+			if err := pkg.WriteFileAtomic(pomFile, after, false); err != nil {
+				return fmt.Errorf("failed to write %s: %w", pomFile, err)
+			}
+			report, err := prReport(ctx, pomFile, analysis, rec)
+			if err != nil {
+				return err
+			}
+			title := prFlags.title
+			if title == "" {
+				title = prTitle(pomFile, rec.DirectPatches, rec.PropertyPatches)
+			}
+			// Only the POM, whatever else is staged.
+			if _, err := git.run(ctx, "commit", "-m", title, "--", filepath.Base(pomFile)); err != nil {
+				return err
+			}
+			committed = true
+			log.Infof("Pushing %s to %s", branch, prFlags.remote)
+			if _, err := git.run(ctx, "push", "--set-upstream", prFlags.remote, branch); err != nil {
+				return err
+			}
+			pushed = true
+
+			created, err := pkg.NewGitHubClient(token).CreatePullRequest(ctx, owner, name, pkg.PullRequest{
+				Title: title,
+				Body:  prBody(report, analysis),
+				Head:  branch,
+				Base:  base,
+				Draft: prFlags.draft,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Println(created.URL)
+			return nil
+		},
+	}
+
+	flagSet := cmd.Flags()
+	flagSet.StringVar(&prFlags.patches, "patches", "", "Space-separated list of patches to apply (groupID@artifactID@version or pkg:maven/groupID/artifactID@version)")
+	flagSet.StringVar(&prFlags.patchFile, "patch-file", "", "File containing patches to apply")
+	flagSet.StringVar(&prFlags.properties, "properties", "", "A space-separated list of properties to update in form property@value")
+	flagSet.StringVar(&prFlags.propertiesFile, "properties-file", "", "The input file to read properties from")
+	flagSet.StringVar(&prFlags.fromGrype, "from-grype", "", "Grype JSON report to read patches from (fixed versions of vulnerable Maven artifacts)")
+	flagSet.StringVar(&prFlags.fromTrivy, "from-trivy", "", "Trivy JSON report to read patches from (fixed versions of vulnerable Java packages)")
+	flagSet.StringVar(&prFlags.osvCacheDir, "osv-cache-dir", "", "Directory to cache OSV and GitHub advisory lookups in (defaults to the user cache directory)")
+	flagSet.StringVar(&prFlags.repository, "repository", pkg.DefaultRepositoryURL, "Maven repository to look up @latest versions in and verify versions against")
+	flagSet.BoolVar(&prFlags.verifyVersions, "verify-versions", false, "Check that every requested version is published in the repository before applying it")
+	flagSet.BoolVar(&prFlags.force, "force", false, "Patch dependencies and properties to the version or value already in effect too, instead of skipping them")
+	flagSet.BoolVar(&prFlags.syncMismatches, "sync-mismatches", false, "When a dependency is declared differently in dependencies and dependencyManagement, update both and not just the winning one")
+	flagSet.StringVar(&prFlags.strategy, "strategy", string(pkg.StrategyAuto), "How to patch dependencies: auto (the heuristics), prefer-property, prefer-direct or prefer-bom (implies resolving the BOMs)")
+	flagSet.StringVar(&prFlags.strategyRules, "strategy-rules", "", "YAML or JSON file of rules forbidding some bumps (e.g. major bumps of guava), whose patches are dropped")
+	flagSet.StringVar(&prFlags.optionalPolicy, "optional-dependencies", string(pkg.OptionalPolicyInclude), "How to treat the patches of <optional> dependencies: include them, skip them, or deprioritize them (patched after the others, never bumping a property they share with required dependencies)")
+	flagSet.StringSliceVar(&prFlags.skipScopes, "skip-scopes", nil, "Scopes of the dependencies to leave out of the analysis, the recommendations and the issue counts (e.g. test,provided)")
+	flagSet.StringSliceVar(&prFlags.pins, "pin", nil, "Dependencies never to patch, as groupId:artifactId, or never to patch past a version, as groupId:artifactId@version (* matches any part)")
+	flagSet.StringVar(&prFlags.conflictPolicy, "conflict-policy", string(pkg.ConflictPolicyHighest), "What to do when patches request different versions for one artifact or one shared property: highest, lowest-fix (the lowest version satisfying every advisory) or fail")
+	flagSet.StringVar(&prFlags.remote, "remote", "origin", "Git remote to push the branch to, whose GitHub repository the pull request is opened on")
+	flagSet.StringVar(&prFlags.base, "base", "", "Branch to open the pull request against (defaults to the current branch), which HEAD must be at")
+	flagSet.StringVar(&prFlags.branch, "branch", "", "Branch to commit the patched POM to (defaults to pombump/ and a hash of the changes, so that the same changes are not proposed twice)")
+	flagSet.StringVar(&prFlags.title, "title", "", "Title of the pull request and message of the commit (defaults to a summary of the changes)")
+	flagSet.BoolVar(&prFlags.draft, "draft", false, "Open the pull request as a draft")
+
+	return cmd
+}
+
+// prAnalyzer is the Analyzer configured by the pr flags.
+func prAnalyzer() (*pkg.Analyzer, error) {
+	strategy, err := pkg.ParseStrategy(prFlags.strategy)
+	if err != nil {
+		return nil, err
+	}
+	conflictPolicy, err := pkg.ParseConflictPolicy(prFlags.conflictPolicy)
+	if err != nil {
+		return nil, err
+	}
+	optionalPolicy, err := pkg.ParseOptionalPolicy(prFlags.optionalPolicy)
+	if err != nil {
+		return nil, err
+	}
+	strategyOpts := []pkg.PatchStrategyOption{pkg.WithStrategy(strategy), pkg.WithOptionalPolicy(optionalPolicy)}
+	if prFlags.syncMismatches {
+		strategyOpts = append(strategyOpts, pkg.WithMismatchSync())
+	}
+	if prFlags.strategyRules != "" {
+		rules, err := pkg.LoadStrategyRules(prFlags.strategyRules)
+		if err != nil {
+			return nil, err
+		}
+		strategyOpts = append(strategyOpts, pkg.WithStrategyHooks(rules))
+	}
+	if len(prFlags.pins) > 0 {
+		pins, err := pkg.ParsePins(prFlags.pins)
+		if err != nil {
+			return nil, err
+		}
+		strategyOpts = append(strategyOpts, pkg.WithStrategyHooks(pins))
+	}
+	opts := []pkg.AnalyzerOption{
+		pkg.WithRepository(newRepository(prFlags.repository)),
+		pkg.WithOSVResolver(newOSVResolver(prFlags.osvCacheDir)),
+		pkg.WithGHSAResolver(newGHSAResolver(prFlags.osvCacheDir)),
+		pkg.WithConflictPolicy(conflictPolicy),
+		pkg.WithPatchStrategyOptions(strategyOpts...),
+		pkg.WithSuppressions(suppressions),
+	}
+	skipScopes, err := pkg.ParseScopes(prFlags.skipScopes)
+	if err != nil {
+		return nil, err
+	}
+	if len(skipScopes) > 0 {
+		opts = append(opts, pkg.WithAnalyzeOptions(pkg.WithSkippedScopes(skipScopes)))
+	}
+	if strategy == pkg.StrategyPreferBOM {
+		opts = append(opts, pkg.WithResolvedBOMs())
+	}
+	if prFlags.verifyVersions {
+		opts = append(opts, pkg.WithVersionVerification())
+	}
+	if prFlags.force {
+		opts = append(opts, pkg.WithForce())
+	}
+	return pkg.NewAnalyzer(opts...), nil
+}
+
+// prReport is the report of the patched POM, verified against what was
+// written.
+func prReport(ctx context.Context, pomFile string, analysis *pkg.AnalysisResult, rec *pkg.Recommendation) (*pkg.CIReport, error) {
+	patched, err := pkg.ParsePOMFile(pomFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse patched POM: %w", err)
+	}
+	verification, err := pkg.CheckPatches(ctx, patched, rec.DirectPatches, rec.PropertyPatches)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify patched POM: %w", err)
+	}
+	report := pkg.NewCIReport(pomFile, analysis, rec.DirectPatches, rec.PropertyPatches, rec.Unfixable, verification)
+	report.Candidates = rec.Candidates
+	report.Skipped = rec.Skipped
+	return report, nil
+}
+
+// prBody is the body of the pull request: the report, then the analysis of
+// the POM before the changes, folded.
+func prBody(report *pkg.CIReport, analysis *pkg.AnalysisResult) string {
+	var body strings.Builder
+	body.WriteString(report.Markdown())
+	body.WriteString("\n<details>\n<summary>Analysis</summary>\n\n```\n")
+	body.WriteString(strings.TrimSpace(analysis.AnalysisReport()))
+	body.WriteString("\n```\n\n</details>\n")
+	return body.String()
+}
+
+// prTitle summarizes the changes, naming the one dependency or property
+// changed if there is only one.
+func prTitle(pomFile string, patches []pkg.Patch, propertyPatches map[string]string) string {
+	if len(patches)+len(propertyPatches) == 1 {
+		for _, p := range patches {
+			return fmt.Sprintf("Bump %s:%s to %s", p.GroupID, p.ArtifactID, p.Version)
+		}
+		for name, value := range propertyPatches {
+			return fmt.Sprintf("Bump %s to %s", name, value)
+		}
+	}
+	return fmt.Sprintf("Bump %d dependencies of %s", len(patches)+len(propertyPatches), pomFile)
+}
+
+// prBranch names the branch after a hash of the changes, so that proposing
+// the same changes again fails to push rather than opening another pull
+// request.
+func prBranch(patches []pkg.Patch, propertyPatches map[string]string) string {
+	h := sha256.New()
+	for _, p := range patches {
+		fmt.Fprintf(h, "%s:%s:%s:%s\n", p.GroupID, p.ArtifactID, p.Version, p.Operation)
+	}
+	for _, p := range pkg.SortedPropertyPatches(propertyPatches) {
+		fmt.Fprintf(h, "%s=%s\n", p.Property, p.Value)
+	}
+	return "pombump/" + hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// gitRepository runs git in the checkout containing dir.
+type gitRepository struct {
+	dir string
+}
+
+// run runs git with args and returns its trimmed output.
+func (g gitRepository) run(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	command := exec.CommandContext(ctx, "git", args...)
+	command.Dir = g.dir
+	command.Stdout, command.Stderr = &stdout, &stderr
+	if err := command.Run(); err != nil {
+		return "", fmt.Errorf("failed to run git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
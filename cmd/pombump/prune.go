@@ -0,0 +1,118 @@
+package pombump
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+)
+
+type pruneCLIFlags struct {
+	remove       bool
+	inPlace      bool
+	backup       bool
+	keep         []string
+	outputFormat string
+}
+
+var pruneFlags pruneCLIFlags
+
+func PrunePropertiesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prune-properties <pom-file>",
+		Short: "Find and remove properties nothing references",
+		Long: `List the properties a POM defines that are referenced by no dependency,
+plugin or other part of the POM, nor by the POMs of its modules. Properties
+Maven and common plugins read implicitly (maven.*, project.*, ...) are never
+listed. With --remove they are deleted from the POM.
+
+Examples:
+  # List unused properties
+  pombump prune-properties pom.xml
+
+  # Remove them, except one used by resource filtering
+  pombump prune-properties pom.xml --remove --in-place --keep banner.text`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if pruneFlags.inPlace && !pruneFlags.remove {
+				return fmt.Errorf("--in-place needs --remove")
+			}
+			if pruneFlags.backup && !pruneFlags.inPlace {
+				return fmt.Errorf("--backup needs --in-place")
+			}
+			if pruneFlags.inPlace {
+				release, err := lockFiles(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+				defer release()
+			}
+			parsedPom, err := pkg.ParsePOMFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to parse the pom file: %w", err)
+			}
+
+			// Modules may use the properties they inherit.
+			modules, err := pkg.DiscoverModules(cmd.Context(), args[0])
+			if err != nil {
+				return fmt.Errorf("failed to discover modules: %w", err)
+			}
+			others := []*gopom.Project{}
+			for _, m := range modules[1:] {
+				others = append(others, m.Project)
+			}
+
+			unused, err := pkg.UnusedProperties(cmd.Context(), parsedPom, others, pruneFlags.keep)
+			if err != nil {
+				return fmt.Errorf("failed to find unused properties: %w", err)
+			}
+
+			if !pruneFlags.remove {
+				if pruneFlags.outputFormat == "yaml" {
+					out, err := yaml.Marshal(map[string][]string{"unused": unused})
+					if err != nil {
+						return fmt.Errorf("failed to marshal unused properties: %w", err)
+					}
+					fmt.Println(string(out))
+					return nil
+				}
+				if len(unused) == 0 {
+					fmt.Println("No unused properties")
+					return nil
+				}
+				fmt.Println("Unused Properties:")
+				fmt.Println("------------------")
+				for _, name := range unused {
+					fmt.Printf("  %s = %s\n", name, parsedPom.Properties.Entries[name])
+				}
+				return nil
+			}
+
+			if len(unused) > 0 {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Removing %d unused properties: %s\n", len(unused), strings.Join(unused, ", "))
+			}
+			pkg.RemoveProperties(parsedPom, unused)
+			data, err := marshalPOM(cmd.Context(), parsedPom, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to marshal the pom file: %w", err)
+			}
+			if pruneFlags.inPlace {
+				return writePOM(cmd.Context(), args[0], data, pruneFlags.backup)
+			}
+			printPOM(cmd.OutOrStdout(), data)
+			return nil
+		},
+	}
+
+	flagSet := cmd.Flags()
+	flagSet.BoolVar(&pruneFlags.remove, "remove", false, "Remove the unused properties, printing the patched POM")
+	flagSet.BoolVar(&pruneFlags.inPlace, "in-place", false, "With --remove, overwrite the POM file instead of printing the patched one")
+	flagSet.BoolVar(&pruneFlags.backup, "backup", false, "With --in-place, keep the original as <file>"+pkg.BackupSuffix)
+	flagSet.StringSliceVar(&pruneFlags.keep, "keep", nil, "Properties to keep even if unused (comma-separated or repeated)")
+	flagSet.StringVar(&pruneFlags.outputFormat, "output", "human", "Output format: human or yaml")
+
+	return cmd
+}
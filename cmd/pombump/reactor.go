@@ -0,0 +1,120 @@
+package pombump
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/chainguard-dev/pombump/pkg"
+)
+
+// patchReactor patches every module of the reactor rooted at rootPOM in
+// place, and prints what was applied to each module, as a report with
+// --output json or yaml.
+func patchReactor(ctx context.Context, rootPOM string, patches []pkg.Patch, propertyPatches map[string]string) error {
+	modules, err := pkg.DiscoverModules(ctx, rootPOM)
+	if err != nil {
+		return err
+	}
+	// Lock every module, and read them again now that none can change.
+	rootDir := filepath.Dir(rootPOM)
+	poms := []string{}
+	for _, m := range modules {
+		poms = append(poms, filepath.Join(rootDir, filepath.FromSlash(m.Path)))
+	}
+	release, err := lockFiles(ctx, poms...)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if modules, err = pkg.DiscoverModules(ctx, rootPOM); err != nil {
+		return err
+	}
+
+	// Resolve symbolic versions against what the whole reactor uses.
+	current := map[string]string{}
+	analyses := map[string]*pkg.AnalysisResult{}
+	for _, m := range modules {
+		analysis, err := pkg.AnalyzeProject(ctx, m.Project, pkg.WithoutBOMDetection())
+		if err != nil {
+			return fmt.Errorf("failed to analyze %s: %w", m.Path, err)
+		}
+		analyses[m.Path] = analysis
+		for k, v := range analysis.CurrentVersions() {
+			if _, exists := current[k]; !exists {
+				current[k] = v
+			}
+		}
+	}
+	patches, _, err = resolvePatchVersions(ctx, patches, current, rootFlags.osvCacheDir, rootFlags.repository)
+	if err != nil {
+		return err
+	}
+	// Drop what the lock records as applied to a module and not undone
+	// there since.
+	for _, m := range modules {
+		pom := filepath.Join(rootDir, filepath.FromSlash(m.Path))
+		if patches, err = unappliedPatches(ctx, pom, patches, analyses[m.Path].CurrentVersions()); err != nil {
+			return err
+		}
+	}
+
+	results, err := pkg.PatchReactor(ctx, modules, patches, propertyPatches)
+	if err != nil {
+		return err
+	}
+
+	report := &pkg.PatchReport{}
+	for _, r := range results {
+		out, err := pkg.MarshalPOM(r.Project, r.Maven4)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", r.Path, err)
+		}
+		pom := filepath.Join(rootDir, filepath.FromSlash(r.Path))
+		original, err := os.ReadFile(pom)
+		if err != nil {
+			return fmt.Errorf("failed reading %s: %w", r.Path, err)
+		}
+		out, err = pkg.RestorePOMFormat(original, out)
+		if err != nil {
+			return fmt.Errorf("failed to format %s: %w", r.Path, err)
+		}
+		if err := pkg.CheckPOMSchema(original, out); err != nil {
+			return fmt.Errorf("failed to patch %s: %w", r.Path, err)
+		}
+		if err := writePOM(ctx, pom, out, rootFlags.backup); err != nil {
+			return fmt.Errorf("failed to write %s: %w", r.Path, err)
+		}
+		if err := recordPatches(ctx, pom, r.Patches, r.Properties); err != nil {
+			return err
+		}
+
+		if rootFlags.output != "human" {
+			patched, err := pkg.AnalyzeProject(ctx, r.Project, pkg.WithoutBOMDetection())
+			if err != nil {
+				return fmt.Errorf("failed to analyze the patched %s: %w", r.Path, err)
+			}
+			report.Add(pkg.NewFilePatchReport(r.Path, analyses[r.Path], patched, r.Patches, r.Properties, nil))
+			continue
+		}
+		fmt.Printf("%s:\n", r.Path)
+		for _, p := range r.Patches {
+			fmt.Printf("  %s:%s -> %s\n", p.GroupID, p.ArtifactID, p.Version)
+		}
+		names := make([]string, 0, len(r.Properties))
+		for name := range r.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("  %s -> %s\n", name, r.Properties[name])
+		}
+	}
+	if rootFlags.output != "human" {
+		return printPatchReport(report, rootFlags.output)
+	}
+	fmt.Printf("Patched %d of %d modules\n", len(results), len(modules))
+	return nil
+}
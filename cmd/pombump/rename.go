@@ -0,0 +1,70 @@
+package pombump
+
+import (
+	"fmt"
+
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/spf13/cobra"
+)
+
+type renameCLIFlags struct {
+	inPlace bool
+	backup  bool
+}
+
+var renameFlags renameCLIFlags
+
+func RenamePropertyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rename-property <pom-file> <old-name> <new-name>",
+		Short: "Rename a property and all its references",
+		Long: `Rename a property throughout a POM: its definition, in the project and
+profile properties, and every ${old-name} reference. Useful to consolidate
+inconsistent property names before automated bumping.
+
+Examples:
+  # Print the POM with netty.version renamed
+  pombump rename-property pom.xml version.netty netty.version
+
+  # Rename in place
+  pombump rename-property pom.xml version.netty netty.version --in-place`,
+		Args: cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if renameFlags.backup && !renameFlags.inPlace {
+				return fmt.Errorf("--backup needs --in-place")
+			}
+			if renameFlags.inPlace {
+				release, err := lockFiles(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+				defer release()
+			}
+			parsedPom, err := pkg.ParsePOMFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to parse the pom file: %w", err)
+			}
+			renamed, err := pkg.RenameProperty(cmd.Context(), parsedPom, args[1], args[2])
+			if err != nil {
+				return fmt.Errorf("failed to rename property: %w", err)
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Renamed %s to %s, %d references\n", args[1], args[2], renamed)
+
+			data, err := marshalPOM(cmd.Context(), parsedPom, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to marshal the pom file: %w", err)
+			}
+			if renameFlags.inPlace {
+				return writePOM(cmd.Context(), args[0], data, renameFlags.backup)
+			}
+			printPOM(cmd.OutOrStdout(), data)
+			return nil
+		},
+	}
+
+	flagSet := cmd.Flags()
+	flagSet.BoolVar(&renameFlags.inPlace, "in-place", false, "Overwrite the POM file instead of printing the renamed one")
+	flagSet.BoolVar(&renameFlags.backup, "backup", false, "With --in-place, keep the original as <file>"+pkg.BackupSuffix)
+
+	return cmd
+}
@@ -0,0 +1,16 @@
+package pombump
+
+import (
+	"context"
+
+	"github.com/chainguard-dev/pombump/pkg"
+)
+
+// resolvePatchVersions turns the symbolic versions a patch may carry
+// (advisory identifiers, @latest, @latest-patch, candidate lists and
+// minimums) into actual versions, and returns which candidates were picked.
+// current maps groupId:artifactId to the version currently in use.
+func resolvePatchVersions(ctx context.Context, patches []pkg.Patch, current map[string]string, osvCacheDir, repository string) ([]pkg.Patch, []pkg.CandidateChoice, error) {
+	analyzer := pkg.NewAnalyzer(pkg.WithRepository(newRepository(repository)), pkg.WithOSVResolver(newOSVResolver(osvCacheDir)), pkg.WithGHSAResolver(newGHSAResolver(osvCacheDir)))
+	return analyzer.ResolveVersions(ctx, patches, current)
+}
@@ -0,0 +1,175 @@
+package pombump
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/spf13/cobra"
+)
+
+// stateFile is the --state-file flag: the file to record the POMs written
+// in place to, for pombump rollback.
+var stateFile string
+
+// lockFiles locks the files at paths, in the same order whatever the order of
+// paths so that two runs cannot each wait for the other, for the read, patch
+// and write of files patched in place. The returned function releases them.
+func lockFiles(ctx context.Context, paths ...string) (func(), error) {
+	sorted := append([]string{}, paths...)
+	sort.Strings(sorted)
+	var releases []func()
+	release := func() {
+		for i := len(releases) - 1; i >= 0; i-- {
+			releases[i]()
+		}
+	}
+	for i, path := range sorted {
+		if i > 0 && path == sorted[i-1] {
+			continue
+		}
+		r, err := pkg.LockFile(ctx, path)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		releases = append(releases, r)
+	}
+	return release, nil
+}
+
+// writePOM writes data over the POM at path, like pkg.WriteFileAtomic, and
+// records the changes in stateFile, if given.
+func writePOM(ctx context.Context, path string, data []byte, backup bool) error {
+	if stateFile == "" {
+		return pkg.WriteFileAtomic(path, data, backup)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return pkg.UpdateRollbackState(ctx, stateFile, func(state *pkg.RollbackState) error {
+		if err := state.Record(path, before, data); err != nil {
+			return fmt.Errorf("failed to record the changes to %s: %w", path, err)
+		}
+		return pkg.WriteFileAtomic(path, data, backup)
+	})
+}
+
+// lockRollbackState reads the state in file and locks the POMs it records,
+// then file, the same order writePOM locks them in, so that rollback and a
+// command patching in place cannot each wait for the other. The returned
+// function releases them.
+func lockRollbackState(ctx context.Context, file string) (*pkg.RollbackState, func(), error) {
+	state, err := pkg.ReadRollbackState(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	for {
+		poms := state.POMs()
+		releasePOMs, err := lockFiles(ctx, poms...)
+		if err != nil {
+			return nil, nil, err
+		}
+		unlock, err := pkg.LockFile(ctx, file)
+		if err != nil {
+			releasePOMs()
+			return nil, nil, err
+		}
+		release := func() {
+			unlock()
+			releasePOMs()
+		}
+		// Another run may have recorded a POM before file was locked,
+		// start over with it locked too.
+		if state, err = pkg.ReadRollbackState(file); err != nil {
+			release()
+			return nil, nil, err
+		}
+		if !slices.ContainsFunc(state.POMs(), func(pom string) bool { return !slices.Contains(poms, pom) }) {
+			return state, release, nil
+		}
+		release()
+	}
+}
+
+func RollbackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback <state-file>",
+		Short: "Restore the POM files patched in place to what they were",
+		Long: `Undo the changes recorded in a state file by the commands writing POM files in
+place with --state-file, restoring the versions, properties and dependencies
+they replaced without relying on version control. Run it from the directory
+the POM files were patched from.
+
+Nothing is restored if any POM file changed since it was patched. Each POM
+file restored is dropped from the state file, which is removed once every POM
+file is restored. With --lock-file, the patches the
+lock file records that the restored POM files no longer have are dropped from
+it, so that pombump history does not list them and they are applied again.
+
+Examples:
+  # Patch in CI, and undo it if the build breaks
+  pombump ci pom.xml --from-grype scan.json --in-place --state-file pombump-state.json
+  mvn verify || pombump rollback pombump-state.json
+
+  # The same, keeping the lock file in step
+  pombump ci pom.xml --from-grype scan.json --in-place --state-file pombump-state.json --lock-file pombump.lock
+  mvn verify || pombump rollback pombump-state.json --lock-file pombump.lock`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			state, release, err := lockRollbackState(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			defer release()
+
+			// Every POM is restored before any is written, so that one
+			// that can not be does not leave the others half done.
+			poms := state.POMs()
+			projects := make([]*gopom.Project, len(poms))
+			restored := make([][]byte, len(poms))
+			for i, pom := range poms {
+				data, err := os.ReadFile(pom)
+				if err != nil {
+					return fmt.Errorf("failed to read POM file: %w", err)
+				}
+				if projects[i], err = state.Restore(pom, data); err != nil {
+					return err
+				}
+				if restored[i], err = marshalPOM(ctx, projects[i], pom); err != nil {
+					return fmt.Errorf("failed to marshal the pom file: %w", err)
+				}
+			}
+
+			// A POM written is dropped from the state straight away, so
+			// that running rollback again after a failure restores the
+			// others.
+			for i, pom := range poms {
+				if err := pkg.WriteFileAtomic(pom, restored[i], false); err != nil {
+					return err
+				}
+				state.Forget(pom)
+				if len(state.Changes) == 0 {
+					err = os.Remove(args[0])
+				} else {
+					err = pkg.WriteRollbackState(args[0], state)
+				}
+				if err != nil {
+					return fmt.Errorf("failed to update %s: %w", args[0], err)
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Restored %s\n", pom)
+				if err := forgetPatches(ctx, pom, projects[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	return cmd
+}
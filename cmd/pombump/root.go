@@ -1,14 +1,23 @@
 package pombump
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"slices"
+	"time"
 
 	"chainguard.dev/apko/pkg/log"
 	charmlog "github.com/charmbracelet/log"
 
+	"github.com/chainguard-dev/clog"
 	"github.com/chainguard-dev/gopom"
 	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/ghodss/yaml"
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/release-utils/version"
 )
@@ -18,6 +27,16 @@ type rootCLIFlags struct {
 	properties     string
 	patchFile      string
 	propertiesFile string
+	fromGrype      string
+	fromTrivy      string
+	osvCacheDir    string
+	repository     string
+	recursive      bool
+	quarantine     string
+	stdout         bool
+	backup         bool
+	force          bool
+	output         string
 }
 
 var rootFlags rootCLIFlags
@@ -31,6 +50,20 @@ func New() *cobra.Command {
 		Short: "pombump cli",
 		Args:  cobra.ExactArgs(1),
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// The configuration files can set the log flags too.
+			if err := applyConfig(cmd, args); err != nil {
+				return err
+			}
+			applyHTTPFlags()
+			settings, err := pkg.LoadSettings(settingsFile)
+			if err != nil {
+				return err
+			}
+			mavenSettings = settings
+			if err := loadSuppressions(args); err != nil {
+				return err
+			}
+
 			out, err := log.Writer(logPolicy)
 			if err != nil {
 				return fmt.Errorf("failed to create log writer: %w", err)
@@ -44,8 +77,9 @@ func New() *cobra.Command {
 		// has an action associated with it:
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if rootFlags.dependencies == "" && rootFlags.properties == "" &&
-				rootFlags.patchFile == "" && rootFlags.propertiesFile == "" {
-				return fmt.Errorf("no dependencies or properties provides, use --dependencies/--patch-file or --properties/properties-file")
+				rootFlags.patchFile == "" && rootFlags.propertiesFile == "" &&
+				rootFlags.fromGrype == "" && rootFlags.fromTrivy == "" {
+				return fmt.Errorf("no dependencies or properties provides, use --dependencies/--patch-file/--from-grype/--from-trivy or --properties/properties-file")
 			}
 
 			if rootFlags.patchFile != "" && rootFlags.dependencies != "" {
@@ -60,41 +94,225 @@ func New() *cobra.Command {
 				return fmt.Errorf("failed to parse patches: %w", err)
 			}
 
+			if rootFlags.fromGrype != "" {
+				grypePatches, err := pkg.PatchesFromGrype(cmd.Context(), rootFlags.fromGrype)
+				if err != nil {
+					return fmt.Errorf("failed to read grype report: %w", err)
+				}
+				patches = append(patches, grypePatches...)
+			}
+			if rootFlags.fromTrivy != "" {
+				trivyPatches, err := pkg.PatchesFromTrivy(cmd.Context(), rootFlags.fromTrivy)
+				if err != nil {
+					return fmt.Errorf("failed to read trivy report: %w", err)
+				}
+				patches = append(patches, trivyPatches...)
+			}
+
 			propertiesPatches, err := pkg.ParseProperties(cmd.Context(), rootFlags.propertiesFile, rootFlags.properties)
 			if err != nil {
 				return fmt.Errorf("failed to parse properties: %w", err)
 			}
 
-			parsedPom, err := gopom.Parse(args[0])
+			if !slices.Contains([]string{"human", "json", "yaml"}, rootFlags.output) {
+				return fmt.Errorf("unsupported output format %q, use human, json or yaml", rootFlags.output)
+			}
+			if rootFlags.backup && rootFlags.stdout {
+				return fmt.Errorf("--stdout writes nothing in place, there is nothing to --backup")
+			}
+			if rootFlags.backup && !rootFlags.recursive && !pkg.IsGradleBuild(args[0]) {
+				return fmt.Errorf("--backup needs --recursive or a Gradle build, a single POM is printed, not written in place")
+			}
+			if !rootFlags.recursive && slices.ContainsFunc(patches, func(p pkg.Patch) bool { return p.Module != "" }) {
+				return fmt.Errorf("some patches target a module, use --recursive to apply them to the modules of the reactor")
+			}
+
+			if pkg.IsGradleBuild(args[0]) {
+				if rootFlags.recursive || rootFlags.quarantine != "" {
+					return fmt.Errorf("--recursive and --quarantine are not supported for Gradle builds")
+				}
+				return patchGradle(cmd.Context(), args[0], patches, propertiesPatches, rootFlags.stdout)
+			}
+
+			if rootFlags.recursive {
+				if rootFlags.stdout {
+					return fmt.Errorf("--recursive patches the modules in place, it can not be used with --stdout")
+				}
+				return patchReactor(cmd.Context(), args[0], patches, propertiesPatches)
+			}
+
+			parsedPom, err := pkg.ParsePOMFile(args[0])
 			if err != nil {
 				return fmt.Errorf("failed to parse the pom file: %w", err)
 			}
 
+			// Patches can name an advisory or a version keyword instead of
+			// a version, resolve those to actual versions.
+			analysis, err := pkg.AnalyzeProject(cmd.Context(), parsedPom, pkg.WithSettings(mavenSettings))
+			if err != nil {
+				return fmt.Errorf("failed to analyze the pom file: %w", err)
+			}
+			patches, _, err = resolvePatchVersions(cmd.Context(), patches, analysis.CurrentVersions(), rootFlags.osvCacheDir, rootFlags.repository)
+			if err != nil {
+				return err
+			}
+			var skipped []pkg.SkippedPatch
+			if !rootFlags.force {
+				var effective, effectiveProperties []pkg.SkippedPatch
+				patches, effective = analysis.SkipEffectivePatches(patches)
+				propertiesPatches, effectiveProperties = analysis.SkipEffectiveProperties(propertiesPatches)
+				skipped = append(effective, effectiveProperties...)
+				for _, s := range skipped {
+					clog.FromContext(cmd.Context()).Infof("Skipping %s %s: %s", s.Name, s.Version, s.Reason)
+				}
+			}
+
+			if rootFlags.quarantine != "" {
+				var quarantined *pkg.QuarantinePlan
+				patches, propertiesPatches, quarantined = pkg.QuarantineRisky(cmd.Context(), analysis, parsedPom, patches, propertiesPatches)
+				if !quarantined.Empty() {
+					if err := pkg.WriteQuarantinePlan(rootFlags.quarantine, quarantined); err != nil {
+						return fmt.Errorf("failed to write quarantine plan: %w", err)
+					}
+				}
+				skipped = append(skipped, quarantined.Skipped()...)
+			}
+
 			newPom, err := pkg.PatchProject(cmd.Context(), parsedPom, patches, propertiesPatches)
 			if err != nil {
 				return fmt.Errorf("failed to patch the pom file: %w", err)
 			}
 
-			out, err := newPom.Marshal()
+			out, err := marshalPOM(cmd.Context(), newPom, args[0])
 			if err != nil {
 				return fmt.Errorf("failed to marshal the pom file: %w", err)
 			}
-			fmt.Println(string(out))
-			return nil
+			if rootFlags.output == "human" {
+				printPOM(cmd.OutOrStdout(), out)
+				return nil
+			}
+			patched, err := pkg.AnalyzeProject(cmd.Context(), newPom, pkg.WithSettings(mavenSettings))
+			if err != nil {
+				return fmt.Errorf("failed to analyze the patched pom file: %w", err)
+			}
+			file := pkg.NewFilePatchReport(args[0], analysis, patched, patches, propertiesPatches, skipped)
+			// The report is UTF-8, whatever the encoding of the POM.
+			text, err := pkg.DecodePOM(out)
+			if err != nil {
+				return err
+			}
+			file.Patched = string(text)
+			report := &pkg.PatchReport{}
+			report.Add(file)
+			return printPatchReport(report, rootFlags.output)
 		},
 	}
 	cmd.PersistentFlags().StringSliceVar(&logPolicy, "log-policy", []string{"builtin:stderr"}, "log policy (e.g. builtin:stderr, /tmp/log/foo)")
 	cmd.PersistentFlags().Var(&level, "log-level", "log level (e.g. debug, info, warn, error)")
+	cmd.PersistentFlags().StringVar(&settingsFile, "settings", "", "Maven settings.xml with the mirrors, proxies, credentials and active profile properties to use (defaults to ~/.m2/settings.xml, if any)")
+	cmd.PersistentFlags().BoolVar(&insecure, "insecure", false, "Only warn when a POM or metadata fetched from a repository does not match its published SHA-256 or SHA-1 checksum, instead of failing")
+	cmd.PersistentFlags().DurationVar(&httpFlags.timeout, "http-timeout", 30*time.Second, "Timeout of every remote request, retries included")
+	cmd.PersistentFlags().IntVar(&httpFlags.retries, "http-retries", 3, "How many times to retry a remote lookup failing with a network error, a 429 or a 5xx, backing off exponentially (0 to never retry)")
+	cmd.PersistentFlags().StringVar(&httpFlags.cacheDir, "cache-dir", "", "Directory to cache remote responses in, revalidated with their ETag or Last-Modified, and the repository metadata (without it, responses are not cached and the metadata is cached in the user cache directory)")
+	cmd.PersistentFlags().DurationVar(&httpFlags.cacheTTL, "cache-ttl", pkg.DefaultCacheTTL, "How long the version lists, POMs and advisories looked up are cached for (0 to not cache them)")
+	cmd.PersistentFlags().StringSliceVar(&suppress, "suppress", nil, "Codes (e.g. POMBUMP-W004) or names (e.g. snapshot) of the warnings and issues to leave out of the output and --fail-on, see the README for the list")
+	cmd.PersistentFlags().StringVar(&ignoreFile, "ignore-file", "", "File listing the warning codes, groupId:artifactId coordinates and advisories to suppress, one per line (defaults to the "+pkg.IgnoreFileName+" in the directory of the POM or a parent, up to the repository root)")
+	cmd.PersistentFlags().StringVar(&stateFile, "state-file", "", "Record the changes to the POM files written in place to this file (JSON if it ends in .json, YAML otherwise), for pombump rollback to undo them")
+	cmd.PersistentFlags().StringVar(&patchLockFile, "lock-file", "", "Record the patches applied in place in this lock file (e.g. "+pkg.PatchLockFileName+"), with their advisories and when, and do not apply those it records again")
+	cmd.PersistentFlags().StringVar(&configFile, "config", "", "Project configuration file setting default flags (defaults to the "+pkg.ConfigFileName+" in the directory of the POM or a parent, up to the repository root; ~/.config/pombump/config.yaml applies under it)")
 
 	cmd.AddCommand(version.WithFont("starwars"))
 	cmd.AddCommand(AnalyzeCmd())
+	cmd.AddCommand(DriftCmd())
+	cmd.AddCommand(DiffCmd())
+	cmd.AddCommand(CICmd())
+	cmd.AddCommand(CheckCmd())
+	cmd.AddCommand(VerifyCmd())
+	cmd.AddCommand(PlanCmd())
+	cmd.AddCommand(ApplyCmd())
+	cmd.AddCommand(RollbackCmd())
+	cmd.AddCommand(HistoryCmd())
+	cmd.AddCommand(PrunePropertiesCmd())
+	cmd.AddCommand(RenamePropertyCmd())
+	cmd.AddCommand(PRCmd())
+	cmd.AddCommand(TreeCmd())
+	cmd.AddCommand(ExplainCmd())
+	cmd.AddCommand(WhichPropertyCmd())
+	cmd.AddCommand(ServeCmd())
+	cmd.AddCommand(SchemaCmd())
 
 	cmd.DisableAutoGenTag = true
 
 	flagSet := cmd.Flags()
-	flagSet.StringVar(&rootFlags.dependencies, "dependencies", "", "A space-separated list of dependencies to update in form groupID@artifactID@version")
+	flagSet.StringVar(&rootFlags.dependencies, "dependencies", "", "A space-separated list of dependencies to update in form groupID@artifactID@version[@scope[@type[@classifier]]] or pkg:maven/groupID/artifactID@version")
 	flagSet.StringVar(&rootFlags.properties, "properties", "", "A space-separated list of properties to update in form property@value")
-	flagSet.StringVar(&rootFlags.patchFile, "patch-file", "", "The input file to read patches from")
-	flagSet.StringVar(&rootFlags.propertiesFile, "properties-file", "", "The input file to read properties from")
+	flagSet.StringVar(&rootFlags.patchFile, "patch-file", "", "The input file to read patches from (YAML, or JSON if it ends in .json)")
+	flagSet.StringVar(&rootFlags.propertiesFile, "properties-file", "", "The input file to read properties from (YAML, or JSON if it ends in .json)")
+	flagSet.StringVar(&rootFlags.repository, "repository", pkg.DefaultRepositoryURL, "Maven repository to look up @latest and @latest-patch versions in")
+	flagSet.StringVar(&rootFlags.osvCacheDir, "osv-cache-dir", "", "Directory to cache OSV and GitHub advisory lookups in (defaults to the user cache directory)")
+	flagSet.BoolVar(&rootFlags.recursive, "recursive", false, "Patch every module of the reactor in place, each patch going to the module that declares it")
+	flagSet.BoolVar(&rootFlags.force, "force", false, "Patch dependencies and properties to the version or value already in effect too, instead of skipping them")
+	flagSet.StringVar(&rootFlags.quarantine, "quarantine", "", "Write major bumps, BOM introductions and parent changes to this plan file instead of applying them")
+	flagSet.StringVar(&rootFlags.fromGrype, "from-grype", "", "Grype JSON report to read patches from (fixed versions of vulnerable Maven artifacts)")
+	flagSet.StringVar(&rootFlags.fromTrivy, "from-trivy", "", "Trivy JSON report to read patches from (fixed versions of vulnerable Java packages)")
+	flagSet.BoolVar(&rootFlags.stdout, "stdout", false, "Fail rather than write anything in place (the gradle.properties of a Gradle build, the modules with --recursive); the patched file is printed to stdout either way")
+	flagSet.BoolVar(&rootFlags.backup, "backup", false, "Keep the original of every file written in place as <file>"+pkg.BackupSuffix+" (the modules with --recursive, the gradle.properties of a Gradle build)")
+	flagSet.StringVar(&rootFlags.output, "output", "human", "Output format (human, yaml, json): human prints the patched file, yaml and json what was applied, skipped and failed, with the patched file")
 	return cmd
 }
+
+// marshalPOM marshals project, patched from the POM at path, keeping the
+// Maven 4 additions of that POM which gopom does not model, and its format,
+// see pkg.RestorePOMFormat. It fails if the result breaks the Maven 4.0.0
+// schema, see pkg.CheckPOMSchema.
+func marshalPOM(ctx context.Context, project *gopom.Project, path string) ([]byte, error) {
+	maven4, err := pkg.ReadMaven4Model(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	out, err := pkg.MarshalPOM(project, maven4)
+	if err != nil {
+		return nil, err
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading file: %w", err)
+	}
+	out, err = pkg.RestorePOMFormat(original, out)
+	if err != nil {
+		return nil, err
+	}
+	if err := pkg.CheckPOMSchema(original, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// printPOM prints data, a POM from marshalPOM, to w, ending it with a
+// newline only if its own format does not.
+func printPOM(w io.Writer, data []byte) {
+	fmt.Fprint(w, string(data))
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		fmt.Fprintln(w)
+	}
+}
+
+// printPatchReport prints report as JSON or YAML, following format. The
+// JSON is not HTML escaped, to keep the patched file readable.
+func printPatchReport(report *pkg.PatchReport, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to marshal patch report: %w", err)
+		}
+		return nil
+	}
+	out, err := yaml.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal patch report: %w", err)
+	}
+	fmt.Print(string(out))
+	return nil
+}
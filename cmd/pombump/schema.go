@@ -0,0 +1,67 @@
+package pombump
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/spf13/cobra"
+)
+
+type schemaCLIFlags struct {
+	list bool
+}
+
+var schemaFlags schemaCLIFlags
+
+func SchemaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema [name]",
+		Short: "Print the JSON Schema of an output or patch file format",
+		Long: `Print the JSON Schema (draft 2020-12) of one of the formats pombump writes
+or reads, to validate documents against or generate code from:
+
+  analysis    the output of analyze --output json (and of --output-template)
+  report      the output of analyze --output json with --all-modules or
+              several POMs and no patches
+  patches     the patch files of --patch-file and --output-deps
+  properties  the properties files of --properties-file and --output-properties
+  quarantine  the plan files of --quarantine
+  patch-report
+              the output of pombump --output json
+  changes     the output of analyze --baseline --output json
+
+The schemas of the patch files apply to their YAML form too.
+
+Examples:
+  # Print the schema of analyze --output json
+  pombump schema
+
+  # Validate a patch file
+  pombump schema patches > patches.schema.json
+  check-jsonschema --schemafile patches.schema.json pombump-deps.yaml`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: pkg.SchemaNames(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if schemaFlags.list {
+				fmt.Fprintln(cmd.OutOrStdout(), strings.Join(pkg.SchemaNames(), "\n"))
+				return nil
+			}
+			name := "analysis"
+			if len(args) > 0 {
+				name = args[0]
+			}
+			schema, err := pkg.Schema(name)
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(schema)
+			return err
+		},
+	}
+
+	flagSet := cmd.Flags()
+	flagSet.BoolVar(&schemaFlags.list, "list", false, "List the names of the schemas instead")
+
+	return cmd
+}
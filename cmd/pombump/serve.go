@@ -0,0 +1,103 @@
+package pombump
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/chainguard-dev/clog"
+	pombumpv1 "github.com/chainguard-dev/pombump/api/pombump/v1"
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/chainguard-dev/pombump/pkg/rpc"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+type serveCLIFlags struct {
+	listen      string
+	root        string
+	repository  string
+	osvCacheDir string
+	resolveBOMs bool
+}
+
+var serveFlags serveCLIFlags
+
+func ServeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the analyze and patch APIs over gRPC",
+		Long: `Serve the gRPC API defined in api/pombump/v1/pombump.proto, to analyze and
+patch POMs from other programs without shelling out. The POMs are either read
+from the filesystem of the server or sent with the requests, and the
+streaming methods handle many POMs, e.g. the modules of a monorepo, over a
+single call.
+
+Only the POMs under --root, the current directory by default, are read by
+path; paths resolving outside of it, through symbolic links too, are refused.
+Pass --root "" to only accept POMs sent with the requests.
+
+The server supports reflection, so that tools like grpcurl can list and call
+its methods. It is neither encrypted nor authenticated, so it warns when
+listening on other than a loopback address. It runs until interrupted.
+
+Examples:
+  pombump serve --listen localhost:50051
+  grpcurl -plaintext -d '{"path": "pom.xml"}' localhost:50051 pombump.v1.PombumpService/Analyze`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			opts := []pkg.AnalyzerOption{
+				pkg.WithRepository(newRepository(serveFlags.repository)),
+				pkg.WithOSVResolver(newOSVResolver(serveFlags.osvCacheDir)),
+				pkg.WithGHSAResolver(newGHSAResolver(serveFlags.osvCacheDir)),
+				pkg.WithAnalyzeOptions(pkg.WithSettings(mavenSettings)),
+				pkg.WithSuppressions(suppressions),
+			}
+			if serveFlags.resolveBOMs {
+				opts = append(opts, pkg.WithResolvedBOMs())
+			}
+
+			listener, err := net.Listen("tcp", serveFlags.listen)
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s: %w", serveFlags.listen, err)
+			}
+			if !isLoopback(listener.Addr()) {
+				clog.FromContext(ctx).Warnf("Listening on %s, which is not a loopback address, without encryption or authentication", listener.Addr())
+			}
+			var serverOpts []rpc.ServerOption
+			if serveFlags.root != "" {
+				serverOpts = append(serverOpts, rpc.WithRoot(serveFlags.root))
+			}
+			server := grpc.NewServer()
+			pombumpv1.RegisterPombumpServiceServer(server, rpc.NewServer(pkg.NewAnalyzer(opts...), serverOpts...))
+			reflection.Register(server)
+
+			go func() {
+				<-ctx.Done()
+				server.GracefulStop()
+			}()
+			clog.FromContext(ctx).Infof("Serving the pombump API on %s", listener.Addr())
+			if err := server.Serve(listener); err != nil {
+				return fmt.Errorf("failed to serve: %w", err)
+			}
+			return nil
+		},
+	}
+
+	flagSet := cmd.Flags()
+	flagSet.StringVar(&serveFlags.listen, "listen", "localhost:50051", "Address to listen on")
+	flagSet.StringVar(&serveFlags.root, "root", ".", "Directory to read the POMs requested by path from, empty to only accept POMs sent with the requests")
+	flagSet.StringVar(&serveFlags.repository, "repository", pkg.DefaultRepositoryURL, "Maven repository to resolve versions, BOMs and parents from")
+	flagSet.StringVar(&serveFlags.osvCacheDir, "osv-cache-dir", "", "Directory to cache OSV and GitHub advisory lookups in (defaults to the user cache directory)")
+	flagSet.BoolVar(&serveFlags.resolveBOMs, "resolve-boms", false, "Resolve the imported BOMs of every POM analyzed")
+
+	return cmd
+}
+
+// isLoopback reports whether addr, a listener address, only accepts
+// connections from the local host.
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
@@ -0,0 +1,27 @@
+package pombump
+
+import (
+	"github.com/chainguard-dev/pombump/pkg"
+)
+
+// settingsFile is the --settings flag: the Maven settings.xml to use instead
+// of ~/.m2/settings.xml.
+var settingsFile string
+
+// insecure is the --insecure flag: only warn when a file fetched from a
+// repository does not match its checksum.
+var insecure bool
+
+// mavenSettings are the settings read from settingsFile before any command
+// runs.
+var mavenSettings *pkg.Settings
+
+// newRepository returns a client for the repository at url, reached through
+// the mirrors, proxies and credentials of the Maven settings, and caching
+// what it fetches.
+func newRepository(url string) *pkg.MavenRepository {
+	repo := mavenSettings.Repository(url)
+	repo.Insecure = insecure
+	repo.Cache = metadataCache()
+	return repo
+}
@@ -0,0 +1,50 @@
+package pombump
+
+import (
+	"path/filepath"
+
+	"github.com/chainguard-dev/pombump/pkg"
+)
+
+// suppress is the --suppress flag: the codes, or names, of the warnings and
+// issues to leave out of the output and of --fail-on.
+var suppress []string
+
+// ignoreFile is the --ignore-file flag: the ignore file to use instead of
+// the .pombump-ignore found from the POM.
+var ignoreFile string
+
+// suppressions are what suppress and the ignore file suppress, read before
+// any command runs.
+var suppressions pkg.Suppressions
+
+// loadSuppressions sets suppressions from suppress and the ignore file,
+// ignoreFile or else the one found from the directory of the first
+// argument.
+func loadSuppressions(args []string) error {
+	parsed, err := pkg.ParseSuppressions(suppress)
+	if err != nil {
+		return err
+	}
+	path := ignoreFile
+	if path == "" {
+		dir := "."
+		if len(args) > 0 {
+			dir = filepath.Dir(args[0])
+		}
+		if path, err = pkg.FindIgnoreFile(dir); err != nil {
+			return err
+		}
+	}
+	if path != "" {
+		ignored, err := pkg.LoadIgnoreFile(path)
+		if err != nil {
+			return err
+		}
+		for entry := range ignored {
+			parsed[entry] = true
+		}
+	}
+	suppressions = parsed
+	return nil
+}
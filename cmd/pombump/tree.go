@@ -0,0 +1,93 @@
+package pombump
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+)
+
+type treeCLIFlags struct {
+	resolve      bool
+	depth        int
+	repository   string
+	outputFormat string
+}
+
+var treeFlags treeCLIFlags
+
+func TreeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tree <pom-file>",
+		Short: "Print the dependency tree of a POM",
+		Long: `Print the dependencies and dependencyManagement of a POM as a tree, like
+mvn dependency:tree, with what controls the version of each one: the property
+it uses, or the dependencyManagement, BOM or parent managing it.
+
+With --resolve, the imported BOMs and the parent are resolved from the
+repository to know what they manage, and so are the transitive dependencies.
+Those take the version the project manages, if it does, as Maven would.
+
+Examples:
+  # What the POM declares
+  pombump tree pom.xml
+
+  # With the transitive dependencies, two levels deep
+  pombump tree pom.xml --resolve --depth 2`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if treeFlags.depth < 0 {
+				return fmt.Errorf("--depth must not be negative")
+			}
+			if treeFlags.depth > 0 && !treeFlags.resolve {
+				return fmt.Errorf("--depth needs --resolve")
+			}
+			parsedPom, err := pkg.ParseAnalysisPOM(cmd.Context(), args[0])
+			if err != nil {
+				return fmt.Errorf("failed to parse POM file: %w", err)
+			}
+			analysis, err := pkg.AnalyzeProject(cmd.Context(), parsedPom, pkg.WithSettings(mavenSettings))
+			if err != nil {
+				return fmt.Errorf("failed to analyze project: %w", err)
+			}
+			var repo *pkg.MavenRepository
+			if treeFlags.resolve {
+				repo = newRepository(treeFlags.repository)
+				analysis.ResolveBOMs(cmd.Context(), repo)
+			}
+			tree, err := analysis.DependencyTree(cmd.Context(), repo, treeFlags.depth)
+			if err != nil {
+				return err
+			}
+
+			switch treeFlags.outputFormat {
+			case "yaml", "json":
+				var out []byte
+				if treeFlags.outputFormat == "json" {
+					out, err = json.MarshalIndent(tree, "", "  ")
+				} else {
+					out, err = yaml.Marshal(tree)
+				}
+				if err != nil {
+					return fmt.Errorf("failed to marshal tree: %w", err)
+				}
+				fmt.Println(string(out))
+			case "human":
+				fmt.Print(tree.String())
+			default:
+				return fmt.Errorf("unsupported output format %q, use human, yaml or json", treeFlags.outputFormat)
+			}
+			return nil
+		},
+	}
+
+	flagSet := cmd.Flags()
+	flagSet.BoolVar(&treeFlags.resolve, "resolve", false, "Resolve the BOMs, the parent and the transitive dependencies from the repository")
+	flagSet.IntVar(&treeFlags.depth, "depth", 0, "With --resolve, how many levels of transitive dependencies to resolve (0 for all)")
+	flagSet.StringVar(&treeFlags.repository, "repository", pkg.DefaultRepositoryURL, "Maven repository to resolve from")
+	flagSet.StringVar(&treeFlags.outputFormat, "output", "human", "Output format: human, yaml or json")
+
+	return cmd
+}
@@ -0,0 +1,193 @@
+package pombump
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+)
+
+type verifyCLIFlags struct {
+	dependencies   string
+	patchFile      string
+	properties     string
+	propertiesFile string
+	resolveBOMs    bool
+	repository     string
+	mvn            bool
+	outputFormat   string
+}
+
+var verifyFlags verifyCLIFlags
+
+// verifyReport is the outcome of `pombump verify`.
+type verifyReport struct {
+	Schema   []pkg.SchemaViolation `json:"schema" yaml:"schema"`
+	Versions []pkg.CheckResult     `json:"versions" yaml:"versions"`
+	// Maven is the output of mvn validate when it failed.
+	Maven  string `json:"maven,omitempty" yaml:"maven,omitempty"`
+	Passed bool   `json:"passed" yaml:"passed"`
+}
+
+func VerifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify <pom-file>",
+		Short: "Validate a patched POM file",
+		Long: `Validate a POM after patching it: reparse it, check it against the Maven
+4.0.0 schema, and check that it has at least the requested version of every
+patched dependency and property, declared directly, through a property or,
+with --resolve-boms, managed by an imported BOM. With --mvn, also run
+"mvn -q validate" on it. The command fails if any check does.
+
+Examples:
+  # Verify the POM after applying the patches
+  pombump verify pom.xml --patch-file pombump-deps.yaml --properties-file pombump-properties.yaml
+
+  # Also accept versions managed by BOMs, and let Maven validate the POM
+  pombump verify pom.xml --patch-file pombump-deps.yaml --resolve-boms --mvn`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if verifyFlags.patchFile != "" && verifyFlags.dependencies != "" {
+				return fmt.Errorf("use either --dependencies or --patch-file")
+			}
+			parsed, err := pkg.ParsePatches(ctx, verifyFlags.patchFile, verifyFlags.dependencies)
+			if err != nil {
+				return fmt.Errorf("failed to parse patches: %w", err)
+			}
+			propertyPatches, err := pkg.ParseProperties(ctx, verifyFlags.propertiesFile, verifyFlags.properties)
+			if err != nil {
+				return fmt.Errorf("failed to parse properties: %w", err)
+			}
+			patches := []pkg.Patch{}
+			for _, p := range parsed {
+				if p.Version != "" {
+					patches = append(patches, p)
+				}
+			}
+
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed reading file: %w", err)
+			}
+			report := verifyReport{}
+			report.Schema, err = pkg.ValidatePOMSchema(data)
+			if err != nil {
+				return err
+			}
+			parsedPom, err := pkg.ParsePOMFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to parse POM file: %w", err)
+			}
+			var checkOpts []pkg.CheckOption
+			if verifyFlags.resolveBOMs {
+				checkOpts = append(checkOpts, pkg.WithBOMResolution(newRepository(verifyFlags.repository)))
+			}
+			report.Versions, err = pkg.CheckPatches(ctx, parsedPom, patches, propertyPatches, checkOpts...)
+			if err != nil {
+				return fmt.Errorf("failed to check patches: %w", err)
+			}
+			report.Passed = len(report.Schema) == 0
+			for _, r := range report.Versions {
+				report.Passed = report.Passed && r.Satisfied
+			}
+			if verifyFlags.mvn {
+				if out, err := mavenValidate(ctx, args[0]); err != nil {
+					report.Maven = strings.TrimSpace(fmt.Sprintf("%v\n%s", err, out))
+					report.Passed = false
+				}
+			}
+
+			if verifyFlags.outputFormat == "yaml" {
+				out, err := yaml.Marshal(report)
+				if err != nil {
+					return fmt.Errorf("failed to marshal report: %w", err)
+				}
+				fmt.Println(string(out))
+			} else {
+				outputVerifyReport(report)
+			}
+			if !report.Passed {
+				// Not a usage error.
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%s did not pass verification", args[0])
+			}
+			return nil
+		},
+	}
+
+	flagSet := cmd.Flags()
+	flagSet.StringVar(&verifyFlags.dependencies, "dependencies", "", "Space-separated list of patches to verify (groupID@artifactID@version or pkg:maven/groupID/artifactID@version)")
+	flagSet.StringVar(&verifyFlags.patchFile, "patch-file", "", "File containing the patches to verify")
+	flagSet.StringVar(&verifyFlags.properties, "properties", "", "Space-separated list of property patches to verify (property@value)")
+	flagSet.StringVar(&verifyFlags.propertiesFile, "properties-file", "", "File containing the property patches to verify")
+	flagSet.BoolVar(&verifyFlags.resolveBOMs, "resolve-boms", false, "Fetch the imported BOMs from the repository, so that versions they manage count")
+	flagSet.StringVar(&verifyFlags.repository, "repository", pkg.DefaultRepositoryURL, "Maven repository to resolve BOMs from")
+	flagSet.BoolVar(&verifyFlags.mvn, "mvn", false, "Also run \"mvn -q validate\" on the POM (mvn must be on the PATH)")
+	flagSet.StringVar(&verifyFlags.outputFormat, "output", "human", "Output format: human or yaml")
+
+	return cmd
+}
+
+// mavenValidate runs mvn -q validate on the POM at path, in its directory,
+// and returns the output of a failed run.
+func mavenValidate(ctx context.Context, path string) (string, error) {
+	mvn, err := exec.LookPath("mvn")
+	if err != nil {
+		return "", fmt.Errorf("failed to find mvn: %w", err)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	clog.FromContext(ctx).Infof("Running mvn -q validate on %s", abs)
+	var out bytes.Buffer
+	command := exec.CommandContext(ctx, mvn, "-q", "-f", abs, "validate")
+	command.Dir = filepath.Dir(abs)
+	command.Stdout, command.Stderr = &out, &out
+	if err := command.Run(); err != nil {
+		return out.String(), fmt.Errorf("mvn validate failed: %w", err)
+	}
+	return "", nil
+}
+
+func outputVerifyReport(report verifyReport) {
+	fmt.Println("Verify Report")
+	fmt.Println("=============")
+	fmt.Println()
+	fmt.Println("Schema:")
+	if len(report.Schema) == 0 {
+		fmt.Println("  PASS")
+	}
+	for _, v := range report.Schema {
+		fmt.Printf("  FAIL %s\n", v)
+	}
+	if len(report.Versions) > 0 {
+		fmt.Println()
+		fmt.Println("Versions:")
+		printCheckResults(report.Versions)
+	}
+	if verifyFlags.mvn {
+		fmt.Println()
+		fmt.Println("Maven:")
+		if report.Maven == "" {
+			fmt.Println("  PASS")
+		} else {
+			fmt.Printf("  FAIL %s\n", report.Maven)
+		}
+	}
+	fmt.Println()
+	if report.Passed {
+		fmt.Println("Result: passed")
+	} else {
+		fmt.Println("Result: failed")
+	}
+}
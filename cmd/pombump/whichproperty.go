@@ -0,0 +1,74 @@
+package pombump
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+)
+
+type whichPropertyCLIFlags struct {
+	searchProperties bool
+	exclude          []string
+	include          []string
+	outputFormat     string
+}
+
+var whichPropertyFlags whichPropertyCLIFlags
+
+func WhichPropertyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "which-property <pom-file> <groupId:artifactId|property>",
+		Short: "Find the property controlling a dependency, or the dependencies a property controls",
+		Long: `Given a groupId:artifactId, print the property controlling its version, following
+properties defined as other properties to the one holding the value, and the
+other dependencies bumping it changes too. Given a property, print every
+dependency whose version it controls.
+
+Examples:
+  # Which property sets the version of netty-handler?
+  pombump which-property pom.xml io.netty:netty-handler
+
+  # What does bumping netty.version change?
+  pombump which-property pom.xml netty.version --output json`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			analysis, err := analyzePOM(cmd.Context(), args[0], whichPropertyFlags.searchProperties, whichPropertyFlags.exclude, whichPropertyFlags.include)
+			if err != nil {
+				return err
+			}
+			lookup, err := analysis.WhichProperty(args[1])
+			if err != nil {
+				return err
+			}
+
+			switch whichPropertyFlags.outputFormat {
+			case "yaml", "json":
+				var out []byte
+				if whichPropertyFlags.outputFormat == "json" {
+					out, err = json.MarshalIndent(lookup, "", "  ")
+				} else {
+					out, err = yaml.Marshal(lookup)
+				}
+				if err != nil {
+					return fmt.Errorf("failed to marshal lookup: %w", err)
+				}
+				fmt.Println(string(out))
+			case "human":
+				fmt.Print(lookup.String())
+			default:
+				return fmt.Errorf("unsupported output format %q, use human, yaml or json", whichPropertyFlags.outputFormat)
+			}
+			return nil
+		},
+	}
+
+	flagSet := cmd.Flags()
+	flagSet.BoolVar(&whichPropertyFlags.searchProperties, "search-properties", false, "Search for properties in nearby POM files")
+	flagSet.StringSliceVar(&whichPropertyFlags.exclude, "exclude", nil, "Additional patterns to skip during property search (trailing / matches directories)")
+	flagSet.StringSliceVar(&whichPropertyFlags.include, "include", nil, "Patterns to search even if excluded (e.g. a POM in target/ or build/)")
+	flagSet.StringVar(&whichPropertyFlags.outputFormat, "output", "human", "Output format: human, yaml or json")
+
+	return cmd
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
 	"os/signal"
@@ -14,6 +15,12 @@ func main() {
 	defer done()
 
 	if err := pombump.New().ExecuteContext(ctx); err != nil {
+		var exitErr *pombump.ExitError
+		if errors.As(err, &exitErr) {
+			log.Printf("error during command execution: %v", err)
+			done()
+			os.Exit(exitErr.Code)
+		}
 		log.Fatalf("error during command execution: %v", err)
 	}
 }
@@ -0,0 +1,78 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchStrategyAdd(t *testing.T) {
+	analysis, err := AnalyzeProject(context.Background(), &gopom.Project{
+		Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.94.Final")),
+	})
+	require.NoError(t, err)
+	add := Patch{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Classifier: "tests", Operation: PatchOperationAdd}
+	direct, props := PatchStrategy(context.Background(), analysis, []Patch{add})
+	assert.Equal(t, []Patch{add}, direct)
+	assert.Empty(t, props)
+}
+
+func TestParsePatchesOperation(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{{
+		name: "add",
+		content: `patches:
+  - groupId: io.netty
+    artifactId: netty-codec-http2
+    version: 4.1.100.Final
+    classifier: linux-x86_64
+    operation: add
+`,
+	}, {
+		name: "add without version",
+		content: `patches:
+  - groupId: io.netty
+    artifactId: netty-codec-http2
+    operation: add
+`,
+		wantErr: true,
+	}, {
+		name: "unknown operation",
+		content: `patches:
+  - groupId: io.netty
+    artifactId: netty-codec-http2
+    version: 4.1.100.Final
+    operation: replace
+`,
+		wantErr: true,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "patches.yaml")
+			require.NoError(t, os.WriteFile(file, []byte(tc.content), 0644))
+			got, err := ParsePatches(context.Background(), file, "")
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []Patch{{
+				GroupID:    "io.netty",
+				ArtifactID: "netty-codec-http2",
+				Version:    "4.1.100.Final",
+				Scope:      defaultScope,
+				Type:       defaultType,
+				Classifier: "linux-x86_64",
+				Operation:  PatchOperationAdd,
+			}}, got)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package pkg
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// AdvisoryGroup is the patches remediating a single advisory.
+type AdvisoryGroup struct {
+	Advisory string
+	Patches  []Patch
+}
+
+// GroupByAdvisory groups patches by the advisories they fix, sorted by
+// advisory. A patch fixing several advisories is in each of their groups,
+// since each of them needs it. Patches without advisories are returned
+// separately, in their original order.
+func GroupByAdvisory(patches []Patch) ([]AdvisoryGroup, []Patch) {
+	byAdvisory := map[string][]Patch{}
+	unattributed := []Patch{}
+	for _, p := range patches {
+		if len(p.Advisories) == 0 {
+			unattributed = append(unattributed, p)
+			continue
+		}
+		for _, advisory := range p.Advisories {
+			byAdvisory[advisory] = append(byAdvisory[advisory], p)
+		}
+	}
+
+	advisories := make([]string, 0, len(byAdvisory))
+	for advisory := range byAdvisory {
+		advisories = append(advisories, advisory)
+	}
+	sort.Strings(advisories)
+	groups := make([]AdvisoryGroup, 0, len(advisories))
+	for _, advisory := range advisories {
+		groups = append(groups, AdvisoryGroup{Advisory: advisory, Patches: byAdvisory[advisory]})
+	}
+	return groups, unattributed
+}
+
+// AdvisoryFile returns the per-advisory variant of file, e.g.
+// pombump-deps-CVE-2024-1234.yaml for pombump-deps.yaml. Advisory IDs come
+// from scanner reports, anything but letters, digits, dots, dashes and
+// underscores in them is replaced so that they cannot point elsewhere.
+func AdvisoryFile(file, advisory string) string {
+	ext := filepath.Ext(file)
+	advisory = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, advisory)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(file, ext), advisory, ext)
+}
+
+// AdvisoryIndexEntry points to the patch files of a single advisory.
+type AdvisoryIndexEntry struct {
+	Advisory       string   `json:"advisory" yaml:"advisory"`
+	DepsFile       string   `json:"depsFile,omitempty" yaml:"depsFile,omitempty"`
+	PropertiesFile string   `json:"propertiesFile,omitempty" yaml:"propertiesFile,omitempty"`
+	Artifacts      []string `json:"artifacts" yaml:"artifacts"`
+}
+
+// AdvisoryIndex lists the per-advisory patch files written for a plan, and
+// the artifacts patched without any advisory.
+type AdvisoryIndex struct {
+	Advisories   []AdvisoryIndexEntry `json:"advisories" yaml:"advisories"`
+	Unattributed []string             `json:"unattributed,omitempty" yaml:"unattributed,omitempty"`
+}
+
+// Merge adds the entries of other to the index, replacing those of the same
+// advisory, so that runs for different advisories add up. The advisories
+// stay sorted.
+func (index *AdvisoryIndex) Merge(other AdvisoryIndex) {
+	for _, entry := range other.Advisories {
+		i := slices.IndexFunc(index.Advisories, func(e AdvisoryIndexEntry) bool {
+			return e.Advisory == entry.Advisory
+		})
+		if i < 0 {
+			index.Advisories = append(index.Advisories, entry)
+			continue
+		}
+		index.Advisories[i] = entry
+	}
+	sort.Slice(index.Advisories, func(i, j int) bool {
+		return index.Advisories[i].Advisory < index.Advisories[j].Advisory
+	})
+	for _, artifact := range other.Unattributed {
+		if !slices.Contains(index.Unattributed, artifact) {
+			index.Unattributed = append(index.Unattributed, artifact)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupByAdvisory(t *testing.T) {
+	netty := Patch{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Advisories: []string{"CVE-2023-44487", "CVE-2023-34462"}}
+	codec := Patch{GroupID: "io.netty", ArtifactID: "netty-codec-http2", Version: "4.1.100.Final", Advisories: []string{"CVE-2023-44487"}}
+	json := Patch{GroupID: "org.json", ArtifactID: "json", Version: "20231013"}
+
+	groups, unattributed := GroupByAdvisory([]Patch{netty, json, codec})
+	want := []AdvisoryGroup{
+		{Advisory: "CVE-2023-34462", Patches: []Patch{netty}},
+		{Advisory: "CVE-2023-44487", Patches: []Patch{netty, codec}},
+	}
+	if diff := cmp.Diff(want, groups); diff != "" {
+		t.Errorf("GroupByAdvisory() (-want +got):\n%s", diff)
+	}
+	assert.Equal(t, []Patch{json}, unattributed)
+}
+
+func TestAdvisoryFile(t *testing.T) {
+	assert.Equal(t, "out/pombump-deps-CVE-2024-1234.yaml", AdvisoryFile("out/pombump-deps.yaml", "CVE-2024-1234"))
+	assert.Equal(t, "pombump-properties-index.yaml", AdvisoryFile("pombump-properties.yaml", "index"))
+	assert.Equal(t, "deps-GHSA-jjjh-jjxp-wpff", AdvisoryFile("deps", "GHSA-jjjh-jjxp-wpff"))
+	assert.Equal(t, "out/deps-.._.._etc_passwd.yaml", AdvisoryFile("out/deps.yaml", "../../etc/passwd"))
+}
+
+func TestAdvisoryIndexMerge(t *testing.T) {
+	index := AdvisoryIndex{
+		Advisories:   []AdvisoryIndexEntry{{Advisory: "CVE-2023-44487", DepsFile: "deps-CVE-2023-44487.yaml", Artifacts: []string{"io.netty:netty-codec-http2"}}},
+		Unattributed: []string{"org.json:json"},
+	}
+	index.Merge(AdvisoryIndex{
+		Advisories: []AdvisoryIndexEntry{
+			{Advisory: "CVE-2023-44487", DepsFile: "deps-CVE-2023-44487.yaml", Artifacts: []string{"io.netty:netty-handler"}},
+			{Advisory: "CVE-2023-34462", PropertiesFile: "properties-CVE-2023-34462.yaml", Artifacts: []string{"io.netty:netty-handler"}},
+		},
+		Unattributed: []string{"org.json:json", "org.yaml:snakeyaml"},
+	})
+	assert.Equal(t, AdvisoryIndex{
+		Advisories: []AdvisoryIndexEntry{
+			{Advisory: "CVE-2023-34462", PropertiesFile: "properties-CVE-2023-34462.yaml", Artifacts: []string{"io.netty:netty-handler"}},
+			{Advisory: "CVE-2023-44487", DepsFile: "deps-CVE-2023-44487.yaml", Artifacts: []string{"io.netty:netty-handler"}},
+		},
+		Unattributed: []string{"org.json:json", "org.yaml:snakeyaml"},
+	}, index)
+}
@@ -0,0 +1,175 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// AnalyzeReactor analyzes every module of a reactor and merges the results
+// into one AnalysisResult, keeping the per-module results in its Modules
+// field. Dependencies declaring a version win over those that do not, then
+// the first module (in the order of modules) wins, as do properties. The
+// modules are analyzed concurrently, see WithJobs; the other options are
+// passed on to AnalyzeProject.
+func AnalyzeReactor(ctx context.Context, modules []Module, opts ...AnalyzeOption) (*AnalysisResult, error) {
+	options := &analyzeOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	paths := make([]string, len(modules))
+	analyses := make([]*AnalysisResult, len(modules))
+	errs := make([]error, len(modules))
+	forEach(options.jobs, len(modules), func(i int) {
+		paths[i] = modules[i].Path
+		analyses[i], errs[i] = AnalyzeProject(ctx, modules[i].Project, opts...)
+		if errs[i] == nil && options.positions && modules[i].file != "" {
+			errs[i] = analyses[i].locateDeclarations(modules[i].file, modules[i].Path)
+		}
+	})
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze %s: %w", paths[i], err)
+		}
+	}
+
+	merged := mergeAnalyses(ctx, paths, analyses)
+	clog.FromContext(ctx).Infof("Reactor analysis complete: %d modules, %d dependencies, %d properties",
+		len(modules), len(merged.Dependencies), len(merged.Properties))
+	return merged, nil
+}
+
+// mergeAnalyses merges the analyses of the files at paths, in order, as
+// AnalyzeReactor does the modules.
+func mergeAnalyses(ctx context.Context, paths []string, analyses []*AnalysisResult) *AnalysisResult {
+	merged := &AnalysisResult{
+		Dependencies:        make(map[string]*DependencyInfo),
+		PropertyUsageCounts: make(map[string]int),
+		Properties:          make(map[string]string),
+		Modules:             make(map[string]*AnalysisResult, len(analyses)),
+	}
+	// Everything is merged eagerly, there is no single project to compute
+	// the skipped passes from.
+	merged.depsOnce.Do(func() {})
+	merged.boms = []BOMInfo{}
+	merged.bomsOnce.Do(func() {})
+
+	for i, analysis := range analyses {
+		merged.Modules[paths[i]] = analysis
+
+		for key, dep := range analysis.Dependencies {
+			if existing, exists := merged.Dependencies[key]; !exists || (existing.Version == "" && dep.Version != "") {
+				merged.Dependencies[key] = dep
+			}
+		}
+		for name, count := range analysis.PropertyUsageCounts {
+			merged.PropertyUsageCounts[name] += count
+		}
+		mergeProperties(ctx, merged.Properties, analysis.Properties, paths[i])
+		merged.boms = append(merged.boms, analysis.BOMs()...)
+		merged.VersionMismatches = append(merged.VersionMismatches, analysis.VersionMismatches...)
+		merged.schemaViolations = append(merged.schemaViolations, analysis.schemaViolations...)
+		merged.malformed = append(merged.malformed, analysis.malformed...)
+		merged.CIFriendly = mergeCIFriendlyVersions(merged.CIFriendly, analysis.CIFriendly)
+		for _, dep := range analysis.SkippedDependencies {
+			merged.addSkippedDependency(dep)
+		}
+	}
+	merged.pruneSkippedDependencies()
+	return merged
+}
+
+// ModuleAnalysis is the breakdown of a single module in an AggregateReport.
+type ModuleAnalysis struct {
+	Path                        string            `json:"path" yaml:"path"`
+	Dependencies                []*DependencyInfo `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+	DependenciesUsingProperties int               `json:"dependenciesUsingProperties" yaml:"dependenciesUsingProperties"`
+	Properties                  map[string]string `json:"properties,omitempty" yaml:"properties,omitempty"`
+	BOMs                        []BOMInfo         `json:"boms,omitempty" yaml:"boms,omitempty"`
+}
+
+// AggregateReport is the serializable form of a reactor analysis.
+type AggregateReport struct {
+	Dependencies                int              `json:"dependencies" yaml:"dependencies"`
+	DependenciesUsingProperties int              `json:"dependenciesUsingProperties" yaml:"dependenciesUsingProperties"`
+	Properties                  int              `json:"properties" yaml:"properties"`
+	BOMs                        int              `json:"boms" yaml:"boms"`
+	Modules                     []ModuleAnalysis `json:"modules" yaml:"modules"`
+	// Warnings are the undefined properties, shadowed BOM versions and
+	// SNAPSHOT versions of the whole analysis.
+	Warnings []Warning `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	// Suppressed are the warnings of the whole analysis that are
+	// suppressed.
+	Suppressed []SuppressedIssue `json:"suppressed,omitempty" yaml:"suppressed,omitempty"`
+}
+
+// AggregateReport summarizes the analysis with a breakdown per module.
+// Modules are sorted by path; a result not produced by AnalyzeReactor has
+// no module breakdown.
+func (result *AnalysisResult) AggregateReport() *AggregateReport {
+	result.ensureDependencies()
+	report := &AggregateReport{
+		Dependencies:                len(result.Dependencies),
+		DependenciesUsingProperties: countPropertiesUsage(result),
+		Properties:                  len(result.Properties),
+		BOMs:                        len(result.BOMs()),
+		Modules:                     []ModuleAnalysis{},
+		Warnings:                    result.Warnings(),
+		Suppressed:                  result.SuppressedIssues(),
+	}
+	for _, path := range result.modulePaths() {
+		report.Modules = append(report.Modules, result.Modules[path].ModuleAnalysis(path))
+	}
+	return report
+}
+
+// ModuleAnalysis summarizes the analysis of the file at path, as a module
+// of an AggregateReport.
+func (result *AnalysisResult) ModuleAnalysis(path string) ModuleAnalysis {
+	result.ensureDependencies()
+	deps := make([]*DependencyInfo, 0, len(result.Dependencies))
+	for _, dep := range result.Dependencies {
+		deps = append(deps, dep)
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		return deps[i].GroupID+":"+deps[i].ArtifactID < deps[j].GroupID+":"+deps[j].ArtifactID
+	})
+	return ModuleAnalysis{
+		Path:                        path,
+		Dependencies:                deps,
+		DependenciesUsingProperties: countPropertiesUsage(result),
+		Properties:                  result.Properties,
+		BOMs:                        result.BOMs(),
+	}
+}
+
+// modulePaths returns the paths of the modules, sorted.
+func (result *AnalysisResult) modulePaths() []string {
+	paths := make([]string, 0, len(result.Modules))
+	for path := range result.Modules {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// moduleReport is the per-module breakdown in AnalysisReport, or empty if
+// the result is not a reactor analysis.
+func (result *AnalysisResult) moduleReport() string {
+	if len(result.Modules) == 0 {
+		return ""
+	}
+	var report strings.Builder
+	report.WriteString("Modules:\n")
+	report.WriteString("--------\n")
+	for _, path := range result.modulePaths() {
+		module := result.Modules[path]
+		report.WriteString(fmt.Sprintf("  %s: %d dependencies (%d using properties), %d properties, %d BOMs\n",
+			path, len(module.Dependencies), countPropertiesUsage(module), len(module.Properties), len(module.BOMs())))
+	}
+	report.WriteString("\n")
+	return report.String()
+}
@@ -6,71 +6,382 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/chainguard-dev/clog"
 	"github.com/chainguard-dev/gopom"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // DependencyInfo contains information about how a dependency is defined
 type DependencyInfo struct {
-	GroupID            string
-	ArtifactID         string
-	Version            string
-	UsesProperty       bool
-	PropertyName       string
-	PropertyUsageCount int
+	GroupID            string `json:"groupId" yaml:"groupId"`
+	ArtifactID         string `json:"artifactId" yaml:"artifactId"`
+	Version            string `json:"version,omitempty" yaml:"version,omitempty"`
+	UsesProperty       bool   `json:"usesProperty" yaml:"usesProperty"`
+	PropertyName       string `json:"propertyName,omitempty" yaml:"propertyName,omitempty"`
+	PropertyUsageCount int    `json:"-" yaml:"-"`
+	// ReferencedProperties lists the properties a composite version such as
+	// 1.0-${build.qualifier} embeds. Such a version is not patched through
+	// the property, but does change when the property does.
+	ReferencedProperties []string `json:"referencedProperties,omitempty" yaml:"referencedProperties,omitempty"`
+	// VersionRange is set when the version, or the property it uses, is a
+	// range like [1.2,2.0). Patches narrow the range rather than replace it.
+	VersionRange bool `json:"versionRange,omitempty" yaml:"versionRange,omitempty"`
+	// PropertyChain is set when the property the version uses refers to
+	// other properties, e.g. [a.version b.version] for a.version defined
+	// as ${b.version}. The last one holds the value, and is the one bumped.
+	PropertyChain []string `json:"propertyChain,omitempty" yaml:"propertyChain,omitempty"`
+	// ManagedBy tells where the version of a dependency declared without
+	// one comes from. Parents and BOMs are only known once resolved.
+	ManagedBy *Management `json:"managedBy,omitempty" yaml:"managedBy,omitempty"`
+	// Purl is the package URL of the dependency, with its resolved version
+	// if known.
+	Purl string `json:"purl,omitempty" yaml:"purl,omitempty"`
+	// Licenses are the licenses the POM of the dependency declares, once
+	// resolved by ResolveLicenses.
+	Licenses []License `json:"licenses,omitempty" yaml:"licenses,omitempty"`
+	// Optional is set for a dependency declared <optional>true</optional>,
+	// see OptionalPolicy.
+	Optional bool `json:"optional,omitempty" yaml:"optional,omitempty"`
+	// Position is where the entry analyzed is declared, once located by
+	// LocateDeclarations. It is always known for Gradle builds.
+	Position *SourcePosition `json:"position,omitempty" yaml:"position,omitempty"`
+
+	depType    string
+	classifier string
 }
 
 // AnalysisResult contains the analysis of a POM project
 type AnalysisResult struct {
 	// Dependencies maps groupId:artifactId to dependency info
-	Dependencies map[string]*DependencyInfo
+	Dependencies map[string]*DependencyInfo `json:"dependencies" yaml:"dependencies"`
 	// PropertyUsageCounts tracks how many times each property is used
-	PropertyUsageCounts map[string]int
+	PropertyUsageCounts map[string]int `json:"propertyUsageCounts" yaml:"propertyUsageCounts"`
 	// Properties contains the actual property values from the POM
-	Properties map[string]string
+	Properties map[string]string `json:"properties" yaml:"properties"`
+	// VersionMismatches lists the dependencies declared differently in
+	// dependencies and dependencyManagement.
+	VersionMismatches []VersionMismatch `json:"versionMismatches,omitempty" yaml:"versionMismatches,omitempty"`
+	// SkippedPOMs lists the files and directories (relative to the project
+	// root) that were ignored by the PathFilter during the property search.
+	SkippedPOMs []string `json:"skippedPoms,omitempty" yaml:"skippedPoms,omitempty"`
+	// SkippedDependencies lists the dependencies left out of the analysis
+	// because of their scope, see WithSkippedScopes.
+	SkippedDependencies []SkippedDependency `json:"skippedDependencies,omitempty" yaml:"skippedDependencies,omitempty"`
+	// CIFriendly describes the CI friendly versions the project uses, if
+	// any.
+	CIFriendly *CIFriendlyVersions `json:"ciFriendly,omitempty" yaml:"ciFriendly,omitempty"`
+	// PropertyPositions maps the properties the POM defines to where they
+	// are declared, once located by LocateDeclarations.
+	PropertyPositions map[string]SourcePosition `json:"propertyPositions,omitempty" yaml:"propertyPositions,omitempty"`
+	// Modules maps the path of each module to its own analysis, when the
+	// result is the merged analysis of a reactor from AnalyzeReactor.
+	Modules map[string]*AnalysisResult `json:"modules,omitempty" yaml:"modules,omitempty"`
+
+	// project is kept around so that passes skipped by an AnalyzeOption can
+	// be computed lazily.
+	project  *gopom.Project
+	depsOnce sync.Once
+	bomsOnce sync.Once
+	boms     []BOMInfo
+	// versionless are the dependencies declared without a version, by
+	// groupId:artifactId, and parentManaged what the parent manages once
+	// resolved.
+	versionless   map[string]gopom.Dependency
+	parent        *Management
+	parentManaged map[string]string
+	bomPatterns   *BOMPatterns
+	skippedScopes []string
+	// effectiveManaged is what the effective POM manages, once applied by
+	// ApplyEffectivePOM.
+	effectiveManaged map[string]string
+	// suppressions are the codes of the warnings to leave out, see
+	// Suppress.
+	suppressions Suppressions
+	// propertySources are the files defining the properties not defined by
+	// the project itself, see PropertySource.
+	propertySources map[string]string
+	// schemaViolations are the warnings of ValidateSchema.
+	schemaViolations []Warning
+	// malformed are the warnings about the POMs recovered by
+	// WithLenientParsing.
+	malformed []Warning
+}
+
+// BOMInfo describes a BOM imported in dependencyManagement.
+type BOMInfo struct {
+	GroupID      string `json:"groupId" yaml:"groupId"`
+	ArtifactID   string `json:"artifactId" yaml:"artifactId"`
+	Version      string `json:"version" yaml:"version"`
+	UsesProperty bool   `json:"usesProperty" yaml:"usesProperty"`
+	PropertyName string `json:"propertyName,omitempty" yaml:"propertyName,omitempty"`
+	// Managed maps the groupId:artifactId of every artifact the BOM manages
+	// to its version. It is only set by ResolveBOMs.
+	Managed map[string]string `json:"managed,omitempty" yaml:"managed,omitempty"`
+	// Position is where the BOM is imported, once located by
+	// LocateDeclarations.
+	Position *SourcePosition `json:"position,omitempty" yaml:"position,omitempty"`
+}
+
+// AnalyzeOption configures which passes AnalyzeProject runs eagerly.
+type AnalyzeOption func(*analyzeOptions)
+
+type analyzeOptions struct {
+	skipDependencies   bool
+	skipBOMs           bool
+	bomPatterns        *BOMPatterns
+	jobs               int
+	streamingThreshold int64
+	settings           *Settings
+	skippedScopes      []string
+	positions          bool
+	lenient            *lenientParsing
+}
+
+// WithoutDependencyIndex skips indexing dependencies and their property
+// usage. The index is built by IndexDependencies, or on first use by the
+// AnalysisResult methods that need it; Dependencies and PropertyUsageCounts
+// stay empty until then.
+func WithoutDependencyIndex() AnalyzeOption {
+	return func(o *analyzeOptions) {
+		o.skipDependencies = true
+	}
+}
+
+// WithoutBOMDetection skips detecting imported BOMs. They are detected on
+// the first call to BOMs.
+func WithoutBOMDetection() AnalyzeOption {
+	return func(o *analyzeOptions) {
+		o.skipBOMs = true
+	}
 }
 
-// AnalyzeProject analyzes a POM project to understand how dependencies are defined
-func AnalyzeProject(ctx context.Context, project *gopom.Project) (*AnalysisResult, error) {
+// AnalyzeProject analyzes a POM project to understand how dependencies are defined.
+// By default all passes are run, use AnalyzeOption to skip the ones that
+// are not needed.
+func AnalyzeProject(ctx context.Context, project *gopom.Project, opts ...AnalyzeOption) (_ *AnalysisResult, err error) {
+	ctx, end := startSpan(ctx, OperationAnalyze)
+	defer func() { end(err) }()
 	log := clog.FromContext(ctx)
 
 	if project == nil {
 		return nil, fmt.Errorf("project is nil")
 	}
 
+	options := &analyzeOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	result := &AnalysisResult{
 		Dependencies:        make(map[string]*DependencyInfo),
 		PropertyUsageCounts: make(map[string]int),
 		Properties:          make(map[string]string),
+		project:             project,
+		bomPatterns:         options.bomPatterns,
+		skippedScopes:       options.skippedScopes,
+	}
+	if options.lenient != nil {
+		result.malformed = options.lenient.warnings(project)
 	}
 
 	// Extract existing properties
 	result.Properties = extractPropertiesFromProject(project)
+	result.CIFriendly = ciFriendlyVersions(project)
+	if options.settings != nil {
+		result.recordPropertySources(options.settings.Properties(), nil)
+		mergeProperties(ctx, result.Properties, options.settings.Properties(), "settings.xml")
+	}
+
+	if !options.skipDependencies {
+		result.IndexDependencies(ctx)
+		log.Infof("Analysis complete: found %d dependencies, %d using properties",
+			len(result.Dependencies), countPropertiesUsage(result))
+	}
+
+	if !options.skipBOMs {
+		log.Debugf("Found %d imported BOMs", len(result.BOMs()))
+	}
+
+	return result, nil
+}
+
+// IndexDependencies indexes the dependencies of the project and their
+// property usage, unless that has been done already. AnalyzeProject does it
+// unless given WithoutDependencyIndex.
+func (result *AnalysisResult) IndexDependencies(ctx context.Context) {
+	result.depsOnce.Do(func() {
+		result.indexDependencies(ctx)
+	})
+}
+
+// ensureDependencies indexes the dependencies on first use by the methods
+// needing them, which take no context: indexing only logs, through the
+// default logger then.
+func (result *AnalysisResult) ensureDependencies() {
+	result.IndexDependencies(context.Background())
+}
+
+// indexDependencies indexes the dependencies of the project.
+func (result *AnalysisResult) indexDependencies(ctx context.Context) {
+	project := result.project
+	if project == nil {
+		return
+	}
+
+	// The entry indexed for each groupId:artifactId. When several types
+	// or classifiers of an artifact are declared, the main jar is the
+	// one indexed.
+	indexed := map[string]gopom.Dependency{}
+	result.versionless = map[string]gopom.Dependency{}
+	// Modules often declare each other as ${project.groupId}, keys
+	// are built from the resolved coordinates.
+	props := coordinateProperties(project)
+	skip := func(dep gopom.Dependency) {
+		for _, name := range propertyReferences(dep.Version) {
+			result.PropertyUsageCounts[name]++
+		}
+	}
 
 	// Analyze regular dependencies
 	if project.Dependencies != nil {
 		for _, dep := range *project.Dependencies {
+			dep = resolveCoordinates(dep, props)
+			key := fmt.Sprintf("%s:%s", dep.GroupID, dep.ArtifactID)
+			if result.skipsScope(dep) {
+				result.skipDependency(dep)
+				continue
+			}
+			if existing, exists := indexed[key]; exists && isDefaultArtifact(existing) && !isDefaultArtifact(dep) {
+				skip(dep)
+				continue
+			}
 			analyzeDependency(ctx, dep, result)
+			indexed[key] = dep
+			if dep.Version == "" {
+				result.versionless[key] = dep
+			} else {
+				delete(result.versionless, key)
+			}
 		}
 	}
 
-	// Analyze dependency management section
+	// Analyze dependency management section. A version declared in
+	// dependencies wins over the managed one, so that is the one kept.
+	// Only the same type and classifier can disagree on the version.
 	if project.DependencyManagement != nil && project.DependencyManagement.Dependencies != nil {
 		for _, dep := range *project.DependencyManagement.Dependencies {
+			dep = resolveCoordinates(dep, props)
+			key := fmt.Sprintf("%s:%s", dep.GroupID, dep.ArtifactID)
+			if _, skipped := result.isSkippedDependency(key); skipped || result.skipsScope(dep) && dep.Scope != "" {
+				result.skipDependency(dep)
+				continue
+			}
+			declared, exists := result.Dependencies[key]
+			if exists && declared.Version != "" && dep.Version != "" {
+				if dependencyKey(indexed[key]) == dependencyKey(dep) {
+					result.recordMismatch(declared, dep)
+				}
+				skip(dep)
+				continue
+			}
+			analyzeDependency(ctx, dep, result)
+			indexed[key] = dep
+		}
+	}
+
+	// Analyze plugin dependencies, unless the project itself already
+	// declares the same artifact.
+	for _, plugin := range pluginDependencyLists(project) {
+		for _, dep := range *plugin.Dependencies {
+			dep = resolveCoordinates(dep, props)
+			key := fmt.Sprintf("%s:%s", dep.GroupID, dep.ArtifactID)
+			if _, exists := indexed[key]; exists {
+				skip(dep)
+				continue
+			}
 			analyzeDependency(ctx, dep, result)
+			indexed[key] = dep
 		}
 	}
 
-	log.Infof("Analysis complete: found %d dependencies, %d using properties",
-		len(result.Dependencies), countPropertiesUsage(result))
+	result.attributeVersionless()
+	result.pruneSkippedDependencies()
+}
 
-	return result, nil
+// BOMs returns the BOMs imported in the dependencyManagement section of the
+// project, detecting them on first use.
+func (result *AnalysisResult) BOMs() []BOMInfo {
+	result.bomsOnce.Do(func() {
+		result.boms = []BOMInfo{}
+		if result.project == nil || result.project.DependencyManagement == nil || result.project.DependencyManagement.Dependencies == nil {
+			return
+		}
+		props := coordinateProperties(result.project)
+		for _, dep := range *result.project.DependencyManagement.Dependencies {
+			if dep.Scope != "import" || dep.Type != "pom" {
+				continue
+			}
+			dep = resolveCoordinates(dep, props)
+			bom := BOMInfo{GroupID: dep.GroupID, ArtifactID: dep.ArtifactID, Version: dep.Version}
+			if name, ok := propertyReference(dep.Version); ok {
+				bom.UsesProperty = true
+				bom.PropertyName = name
+			}
+			result.boms = append(result.boms, bom)
+		}
+	})
+	return result.boms
+}
+
+// propertyReference returns the property name if version is a pure property
+// reference like ${netty.version}.
+func propertyReference(version string) (string, bool) {
+	if strings.HasPrefix(version, "${") && strings.HasSuffix(version, "}") {
+		name := strings.TrimSuffix(strings.TrimPrefix(version, "${"), "}")
+		// ${a}-${b} is composite, not a reference to a property "a}-${b".
+		if !strings.ContainsAny(name, "${}") {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// propertyReferences returns the names of all the properties version refers
+// to, pure or embedded in a composite version like 1.0-${build.qualifier},
+// in order of appearance and without duplicates.
+func propertyReferences(version string) []string {
+	names := []string{}
+	rest := version
+	for {
+		start := strings.Index(rest, "${")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			break
+		}
+		if name := rest[start+2 : start+end]; !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+		rest = rest[start+end+1:]
+	}
+	return names
 }
 
 // AnalyzeProjectPath analyzes a POM file and searches for properties in nearby POM files
-func AnalyzeProjectPath(ctx context.Context, pomPath string) (*AnalysisResult, error) {
+func AnalyzeProjectPath(ctx context.Context, pomPath string, opts ...AnalyzeOption) (*AnalysisResult, error) {
+	return AnalyzeProjectPathWithFilter(ctx, pomPath, NewPathFilter(nil, nil), opts...)
+}
+
+// AnalyzeProjectPathWithFilter is like AnalyzeProjectPath, but uses the given
+// filter to decide which nearby POM files are searched for properties.
+func AnalyzeProjectPathWithFilter(ctx context.Context, pomPath string, filter *PathFilter, opts ...AnalyzeOption) (*AnalysisResult, error) {
 	log := clog.FromContext(ctx)
 	
 	// Get absolute path for consistency
@@ -82,12 +393,18 @@ func AnalyzeProjectPath(ctx context.Context, pomPath string) (*AnalysisResult, e
 	log.Debugf("Analyzing POM with property search: %s", absPomPath)
 	
 	// First analyze the main POM
-	project, err := gopom.Parse(absPomPath)
+	project, err := ParseAnalysisPOM(ctx, absPomPath, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse POM file: %w", err)
 	}
 	
-	result, err := AnalyzeProject(ctx, project)
+	// The properties of the settings only fill in what the nearby POMs do
+	// not define either, so they are merged last.
+	options := &analyzeOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	result, err := AnalyzeProject(ctx, project, append(opts, WithSettings(nil))...)
 	if err != nil {
 		return nil, err
 	}
@@ -97,12 +414,27 @@ func AnalyzeProjectPath(ctx context.Context, pomPath string) (*AnalysisResult, e
 	
 	// Search for additional properties in nearby POMs
 	dir := filepath.Dir(absPomPath)
-	additionalProps := searchForProperties(ctx, dir, absPomPath)
+	additionalProps, sources, skipped := searchForProperties(ctx, dir, absPomPath, filter, opts...)
+	result.SkippedPOMs = skipped
 	
 	log.Debugf("Property search found %d additional properties", len(additionalProps))
 	
 	// Merge additional properties
+	result.recordPropertySources(additionalProps, sources)
 	mergeProperties(ctx, result.Properties, additionalProps, "nearby POM")
+	if options.settings != nil {
+		result.recordPropertySources(options.settings.Properties(), nil)
+		mergeProperties(ctx, result.Properties, options.settings.Properties(), "settings.xml")
+	}
+	// The merged properties may complete property chains.
+	for _, info := range result.Dependencies {
+		result.resolveDependencyProperties(ctx, info)
+	}
+	if options.positions {
+		if err := result.locateDeclarations(absPomPath, pomPath); err != nil {
+			return nil, err
+		}
+	}
 	
 	log.Infof("Total after merge: %d properties, %d dependencies", 
 		len(result.Properties), len(result.Dependencies))
@@ -120,11 +452,13 @@ func analyzeDependency(ctx context.Context, dep gopom.Dependency, result *Analys
 		GroupID:    dep.GroupID,
 		ArtifactID: dep.ArtifactID,
 		Version:    dep.Version,
+		Optional:   strings.EqualFold(strings.TrimSpace(dep.Optional), "true"),
+		depType:    dep.Type,
+		classifier: dep.Classifier,
 	}
 
 	// Check if version uses a property reference
-	if strings.HasPrefix(dep.Version, "${") && strings.HasSuffix(dep.Version, "}") {
-		propertyName := strings.TrimSuffix(strings.TrimPrefix(dep.Version, "${"), "}")
+	if propertyName, ok := propertyReference(dep.Version); ok {
 		info.UsesProperty = true
 		info.PropertyName = propertyName
 		result.PropertyUsageCounts[propertyName]++
@@ -132,7 +466,14 @@ func analyzeDependency(ctx context.Context, dep gopom.Dependency, result *Analys
 
 		log.Debugf("Dependency %s uses property %s (total usage: %d)",
 			depKey, propertyName, info.PropertyUsageCount)
+	} else if names := propertyReferences(dep.Version); len(names) > 0 {
+		info.ReferencedProperties = names
+		for _, name := range names {
+			result.PropertyUsageCounts[name]++
+		}
+		log.Debugf("Dependency %s has composite version %s", depKey, dep.Version)
 	}
+	result.resolveDependencyProperties(ctx, info)
 
 	result.Dependencies[depKey] = info
 }
@@ -150,6 +491,7 @@ func countPropertiesUsage(result *AnalysisResult) int {
 
 // ShouldUseProperty determines if a specific dependency should be updated via property
 func (result *AnalysisResult) ShouldUseProperty(groupID, artifactID string) (bool, string) {
+	result.ensureDependencies()
 	depKey := fmt.Sprintf("%s:%s", groupID, artifactID)
 
 	if info, exists := result.Dependencies[depKey]; exists {
@@ -164,8 +506,23 @@ func (result *AnalysisResult) ShouldUseProperty(groupID, artifactID string) (boo
 
 // PatchStrategy recommends whether to use properties or direct patches
 // Returns direct patches and property patches separately
-func PatchStrategy(ctx context.Context, result *AnalysisResult, patches []Patch) ([]Patch, map[string]string) {
+func PatchStrategy(ctx context.Context, result *AnalysisResult, patches []Patch, opts ...PatchStrategyOption) ([]Patch, map[string]string) {
+	ctx, end := startSpan(ctx, OperationPlan, attribute.Int("pombump.patches", len(patches)))
+	defer end(nil)
 	log := clog.FromContext(ctx)
+	result.ensureDependencies()
+
+	options := &patchStrategyOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.skipped != nil {
+		ctx = context.WithValue(ctx, skippedPatchesKey{}, options.skipped)
+	}
+	for _, hook := range options.hooks {
+		patches = hook.BeforeStrategy(ctx, result, patches)
+	}
+	patches = result.applyOptionalPolicy(ctx, patches, options.optionalPolicy)
 
 	log.Debugf("Determining patch strategy for %d patches", len(patches))
 	log.Debugf("Available properties: %d, Dependencies: %d", len(result.Properties), len(result.Dependencies))
@@ -177,17 +534,83 @@ func PatchStrategy(ctx context.Context, result *AnalysisResult, patches []Patch)
 	for _, patch := range patches {
 		depKey := fmt.Sprintf("%s:%s", patch.GroupID, patch.ArtifactID)
 		useProperty, propertyName := result.ShouldUseProperty(patch.GroupID, patch.ArtifactID)
+		if useProperty && propertyName != "" {
+			propertyName = result.bumpedProperty(ctx, propertyName)
+		}
 		
 		log.Debugf("Checking patch for %s version %s", depKey, patch.Version)
 
-		if useProperty && propertyName != "" {
+		patch = narrowVersionRange(ctx, result, patch, useProperty, propertyName)
+		if options.strategy == StrategyPreferDirect {
+			useProperty = false
+		}
+		if options.optionalPolicy == OptionalPolicyDeprioritize && useProperty && result.isOptional(depKey) && result.sharedWithRequired(propertyName) {
+			// Only the dependencies that are not optional, planned
+			// first, may bump the property.
+			if existing, bumped := propertyPatches[propertyName]; !bumped || compareVersions(patch.Version, existing) > 0 {
+				log.Infof("Not bumping property %s for the optional dependency %s, it is shared", propertyName, depKey)
+				useProperty = false
+			}
+		}
+		if useProperty && IsCIFriendlyProperty(propertyName) {
+			// The property is the version of the build itself, the
+			// dependency is a module of it.
+			log.Infof("Not bumping the CI friendly property %s for %s, patching it directly", propertyName, depKey)
+			useProperty = false
+		}
+
+		if patch.Operation == PatchOperationAdd {
+			// Declares a dependency of its own, whatever the existing
+			// ones use.
+			directPatches = append(directPatches, patch)
+			log.Infof("Will add %s:%s %s", patch.GroupID, patch.ArtifactID, patch.Version)
+		} else if patch.Operation == PatchOperationRemove {
+			directPatches = append(directPatches, patch)
+			log.Infof("Will remove %s:%s", patch.GroupID, patch.ArtifactID)
+		} else if patch.Operation == PatchOperationOverride {
+			directPatches = append(directPatches, patch)
+			log.Infof("Will override %s:%s with %s", patch.GroupID, patch.ArtifactID, patch.Version)
+		} else if patch.Operation == PatchOperationImport {
+			directPatches = append(directPatches, patch)
+			log.Infof("Will import BOM %s:%s %s", patch.GroupID, patch.ArtifactID, patch.Version)
+		} else if patch.Operation == PatchOperationReorder {
+			directPatches = append(directPatches, patch)
+			log.Infof("Will move BOM %s:%s", patch.GroupID, patch.ArtifactID)
+		} else if bom, ok := result.trackingBOM(patch); ok && patch.Version != "" && (options.strategy == StrategyPreferBOM || options.strategy == StrategyPreferProperty && bom.UsesProperty) {
+			// The BOM brings the whole family of artifacts along.
+			if bom.UsesProperty {
+				bomProperty := result.bumpedProperty(ctx, bom.PropertyName)
+				if existingVersion, exists := propertyPatches[bomProperty]; exists && existingVersion != patch.Version {
+					log.Warnf("Property %s already set to %s, requested %s for %s:%s",
+						bomProperty, existingVersion, patch.Version, patch.GroupID, patch.ArtifactID)
+				} else if !exists {
+					propertyPatches[bomProperty] = patch.Version
+					log.Infof("Will update property %s of BOM %s:%s to %s for %s:%s", bomProperty, bom.GroupID, bom.ArtifactID, patch.Version, patch.GroupID, patch.ArtifactID)
+				}
+			} else if !slices.ContainsFunc(directPatches, func(p Patch) bool { return p.GroupID == bom.GroupID && p.ArtifactID == bom.ArtifactID }) {
+				directPatches = append(directPatches, Patch{GroupID: bom.GroupID, ArtifactID: bom.ArtifactID, Version: patch.Version, Scope: "import", Type: "pom", Advisories: patch.Advisories})
+				log.Infof("Will bump BOM %s:%s to %s for %s:%s", bom.GroupID, bom.ArtifactID, patch.Version, patch.GroupID, patch.ArtifactID)
+			}
+		} else if managed, ok := result.managedByBOM(patch); ok && (options.overrideBOMs || options.strategy == StrategyPreferBOM) && patch.Version != "" {
+			patch.Operation = PatchOperationOverride
+			directPatches = append(directPatches, patch)
+			log.Infof("Will override %s:%s %s managed by BOM %s:%s with %s", patch.GroupID, patch.ArtifactID, managed.Version, managed.GroupID, managed.ArtifactID, patch.Version)
+		} else if useProperty && propertyName != "" {
 			log.Debugf("  -> Dependency %s uses property ${%s}", depKey, propertyName)
 			
 			// Check if we already have this property
-			if existingVersion, exists := propertyPatches[propertyName]; exists {
-				log.Warnf("Property %s already set to %s, requested %s for %s:%s",
-					propertyName, existingVersion, patch.Version, patch.GroupID, patch.ArtifactID)
-				// Compare versions and use the newer one
+			if patch.Version == "" {
+				log.Debugf("  -> Only patching the exclusions of %s", depKey)
+			} else if existingVersion, exists := propertyPatches[propertyName]; exists {
+				// Compare versions and use the newer one, see
+				// ResolveVersionConflicts for other policies.
+				if existingVersion != patch.Version {
+					log.Warnf("Property %s already set to %s, requested %s for %s:%s",
+						propertyName, existingVersion, patch.Version, patch.GroupID, patch.ArtifactID)
+				}
+				if compareVersions(patch.Version, existingVersion) > 0 {
+					propertyPatches[propertyName] = patch.Version
+				}
 			} else {
 				propertyPatches[propertyName] = patch.Version
 				
@@ -199,11 +622,48 @@ func PatchStrategy(ctx context.Context, result *AnalysisResult, patches []Patch)
 					missingProperties = append(missingProperties, propertyName)
 				}
 			}
+			// Exclusions are not in the property, they still need the
+			// dependency patched, keeping its version.
+			if len(patch.Exclusions) > 0 {
+				exclusionsOnly := patch
+				exclusionsOnly.Version = ""
+				directPatches = append(directPatches, exclusionsOnly)
+				log.Infof("Will patch the exclusions of %s:%s", patch.GroupID, patch.ArtifactID)
+			}
+		} else if values, ok := result.compositePropertyPatches(ctx, result.Dependencies[depKey], patch.Version); ok && options.strategy != StrategyPreferDirect {
+			// Only the properties of a composite version like
+			// ${major}.${minor}.0 change, its literals stay.
+			composite := result.Dependencies[depKey].Version
+			for _, name := range sortedKeys(values) {
+				if existingVersion, exists := propertyPatches[name]; exists && existingVersion != values[name] {
+					log.Warnf("Property %s already set to %s, requested %s for %s:%s",
+						name, existingVersion, values[name], patch.GroupID, patch.ArtifactID)
+					continue
+				}
+				propertyPatches[name] = values[name]
+				log.Infof("Will update property %s from %s to %s for the composite version %s of %s", name, result.Properties[name], values[name], composite, depKey)
+			}
+			if len(patch.Exclusions) > 0 {
+				exclusionsOnly := patch
+				exclusionsOnly.Version = ""
+				directPatches = append(directPatches, exclusionsOnly)
+				log.Infof("Will patch the exclusions of %s:%s", patch.GroupID, patch.ArtifactID)
+			}
 		} else {
-			if _, exists := result.Dependencies[depKey]; exists {
+			if dep, exists := result.Dependencies[depKey]; exists && len(dep.ReferencedProperties) > 0 && patch.Version != "" {
+				if rewritten, ok := rewriteLiterals(dep.Version, patch.Version, result.Properties); ok {
+					log.Infof("Will rewrite the composite version %s of %s as %s", dep.Version, depKey, rewritten)
+				} else {
+					log.Warnf("The composite version %s of %s can not express %s through %s, replacing it", dep.Version, depKey, patch.Version, strings.Join(dep.ReferencedProperties, ", "))
+				}
+			} else if _, exists := result.Dependencies[depKey]; exists {
 				log.Debugf("  -> Dependency %s found but doesn't use properties", depKey)
 			} else {
-				log.Debugf("  -> Dependency %s not found in POM (may be from BOM or new)", depKey)
+				if bom, ok := result.BOMForGroup(patch.GroupID); ok {
+					log.Debugf("  -> Dependency %s not found in POM (may be from BOM %s:%s)", depKey, bom.GroupID, bom.ArtifactID)
+				} else {
+					log.Debugf("  -> Dependency %s not found in POM (may be from BOM or new)", depKey)
+				}
 			}
 			directPatches = append(directPatches, patch)
 			log.Infof("Will directly patch %s:%s to %s", patch.GroupID, patch.ArtifactID, patch.Version)
@@ -215,17 +675,51 @@ func PatchStrategy(ctx context.Context, result *AnalysisResult, patches []Patch)
 		log.Warnf("These properties may be defined in an external parent POM or imported dependency")
 	}
 
+	directPatches = normalizeMismatches(ctx, result, patches, directPatches, propertyPatches, options.syncMismatches)
+	for _, hook := range options.hooks {
+		directPatches, propertyPatches = hook.AfterStrategy(ctx, result, directPatches, propertyPatches)
+	}
+
 	log.Infof("Strategy: %d direct patches, %d property updates", len(directPatches), len(propertyPatches))
 
 	return directPatches, propertyPatches
 }
 
+// CurrentVersions returns the version of every dependency keyed by
+// groupId:artifactId, with property references resolved where possible and
+// the managed version of dependencies declared without one, when known.
+func (result *AnalysisResult) CurrentVersions() map[string]string {
+	result.ensureDependencies()
+	versions := make(map[string]string, len(result.Dependencies))
+	for key, dep := range result.Dependencies {
+		if version := result.dependencyVersion(dep); version != "" {
+			versions[key] = version
+		}
+	}
+	return versions
+}
+
+// dependencyVersion returns the version dep resolves to, through its
+// properties or what manages it, empty if unknown.
+func (result *AnalysisResult) dependencyVersion(dep *DependencyInfo) string {
+	version := dep.Version
+	if dep.UsesProperty {
+		version = interpolate(result.Properties[dep.PropertyName], result.Properties)
+	} else if len(dep.ReferencedProperties) > 0 {
+		version = interpolate(dep.Version, result.Properties)
+	} else if version == "" && dep.ManagedBy != nil {
+		version = dep.ManagedBy.Version
+	}
+	return version
+}
+
 // GetAffectedDependencies returns all dependencies that would be affected by updating a property
 func (result *AnalysisResult) GetAffectedDependencies(propertyName string) []*DependencyInfo {
+	result.ensureDependencies()
 	affected := []*DependencyInfo{}
 
 	for _, dep := range result.Dependencies {
-		if dep.UsesProperty && dep.PropertyName == propertyName {
+		if dep.UsesProperty && dep.PropertyName == propertyName || slices.Contains(dep.ReferencedProperties, propertyName) || slices.Contains(dep.PropertyChain, propertyName) {
 			affected = append(affected, dep)
 		}
 	}
@@ -233,12 +727,55 @@ func (result *AnalysisResult) GetAffectedDependencies(propertyName string) []*De
 	return affected
 }
 
+// DependencyImpact is how updating a property changes the effective version
+// of a dependency.
+type DependencyImpact struct {
+	GroupID    string `json:"groupId" yaml:"groupId"`
+	ArtifactID string `json:"artifactId" yaml:"artifactId"`
+	// Version is the version as declared, e.g. 1.0-${build.qualifier}.
+	Version string `json:"version" yaml:"version"`
+	Before  string `json:"before" yaml:"before"`
+	After   string `json:"after" yaml:"after"`
+	// PropertyChain is the chain of properties from the version to the
+	// property, if the version refers to it through other properties.
+	PropertyChain []string `json:"propertyChain,omitempty" yaml:"propertyChain,omitempty"`
+}
+
+// PropertyImpact returns the effective version of every dependency affected
+// by setting propertyName to value, before and after, sorted by
+// groupId:artifactId. Composite versions are included.
+func (result *AnalysisResult) PropertyImpact(propertyName, value string) []DependencyImpact {
+	after := make(map[string]string, len(result.Properties)+1)
+	for name, v := range result.Properties {
+		after[name] = v
+	}
+	after[propertyName] = value
+
+	impacts := []DependencyImpact{}
+	for _, dep := range result.GetAffectedDependencies(propertyName) {
+		impacts = append(impacts, DependencyImpact{
+			GroupID:    dep.GroupID,
+			ArtifactID: dep.ArtifactID,
+			Version:    dep.Version,
+			Before:        interpolate(dep.Version, result.Properties),
+			After:         interpolate(dep.Version, after),
+			PropertyChain: dep.PropertyChain,
+		})
+	}
+	sort.Slice(impacts, func(i, j int) bool {
+		return impacts[i].GroupID+":"+impacts[i].ArtifactID < impacts[j].GroupID+":"+impacts[j].ArtifactID
+	})
+	return impacts
+}
+
 // AnalysisReport generates a human-readable report of the analysis
 func (result *AnalysisResult) AnalysisReport() string {
+	result.ensureDependencies()
 	var report strings.Builder
 
 	report.WriteString("POM Analysis Report\n")
 	report.WriteString("===================\n\n")
+	report.WriteString(result.malformedReport())
 
 	report.WriteString(fmt.Sprintf("Total dependencies: %d\n", len(result.Dependencies)))
 	report.WriteString(fmt.Sprintf("Dependencies using properties: %d\n", countPropertiesUsage(result)))
@@ -247,10 +784,19 @@ func (result *AnalysisResult) AnalysisReport() string {
 	if len(result.PropertyUsageCounts) > 0 {
 		report.WriteString("Property Usage:\n")
 		report.WriteString("---------------\n")
-		for prop, count := range result.PropertyUsageCounts {
+		undefined := map[string]string{}
+		for _, warning := range result.UndefinedProperties() {
+			undefined[warning.Artifacts[0].Name] = warning.Code
+		}
+		for _, prop := range sortedKeys(result.PropertyUsageCounts) {
+			count := result.PropertyUsageCounts[prop]
 			currentValue := result.Properties[prop]
 			if currentValue != "" {
 				report.WriteString(fmt.Sprintf("  %s = %s (used by %d dependencies)\n", prop, currentValue, count))
+			} else if IsCIFriendlyProperty(prop) {
+				report.WriteString(fmt.Sprintf("  %s (used by %d dependencies) - CI friendly, set with -D%s\n", prop, count, prop))
+			} else if code, ok := undefined[prop]; ok {
+				report.WriteString(fmt.Sprintf("  %s\n", FormatIssue(code, fmt.Sprintf("%s (used by %d dependencies) - NOT DEFINED", prop, count))))
 			} else {
 				report.WriteString(fmt.Sprintf("  %s (used by %d dependencies) - NOT DEFINED\n", prop, count))
 			}
@@ -258,10 +804,50 @@ func (result *AnalysisResult) AnalysisReport() string {
 		report.WriteString("\n")
 	}
 
+	if boms := result.BOMs(); len(boms) > 0 {
+		report.WriteString("Imported BOMs:\n")
+		report.WriteString("--------------\n")
+		for _, bom := range boms {
+			if bom.Managed != nil {
+				report.WriteString(fmt.Sprintf("  %s:%s:%s (manages %d artifacts)\n", bom.GroupID, bom.ArtifactID, bom.Version, len(bom.Managed)))
+			} else {
+				report.WriteString(fmt.Sprintf("  %s:%s:%s\n", bom.GroupID, bom.ArtifactID, bom.Version))
+			}
+		}
+		// Earlier imports win, later ones may manage versions that never
+		// apply.
+		for _, warning := range result.ShadowedBOMVersions() {
+			report.WriteString(fmt.Sprintf("  Warning: %s\n", FormatIssue(warning.Code, warning.Message)))
+		}
+		report.WriteString("\n")
+	}
+
+	report.WriteString(result.ciFriendlyReport())
+	report.WriteString(result.versionlessReport())
+	report.WriteString(result.snapshotReport())
+	report.WriteString(result.schemaReport())
+	report.WriteString(result.licenseReport())
+	report.WriteString(result.moduleReport())
+	report.WriteString(result.mismatchReport())
+	report.WriteString(result.suppressedReport())
+	report.WriteString(result.skippedScopeReport())
+	report.WriteString(result.optionalReport())
+
+	if len(result.SkippedPOMs) > 0 {
+		report.WriteString("Skipped During Property Search (use --include to opt in):\n")
+		report.WriteString("---------------------------------------------------------\n")
+		for _, path := range result.SkippedPOMs {
+			report.WriteString(fmt.Sprintf("  %s\n", path))
+		}
+		report.WriteString("\n")
+	}
+
+	report.WriteString(result.compositeReport())
+
 	// List dependencies that use properties
 	depsWithProps := []*DependencyInfo{}
-	for _, dep := range result.Dependencies {
-		if dep.UsesProperty {
+	for _, key := range sortedKeys(result.Dependencies) {
+		if dep := result.Dependencies[key]; dep.UsesProperty {
 			depsWithProps = append(depsWithProps, dep)
 		}
 	}
@@ -270,20 +856,37 @@ func (result *AnalysisResult) AnalysisReport() string {
 		report.WriteString("Dependencies Using Properties:\n")
 		report.WriteString("-------------------------------\n")
 		for _, dep := range depsWithProps {
-			report.WriteString(fmt.Sprintf("  %s:%s -> ${%s}\n",
-				dep.GroupID, dep.ArtifactID, dep.PropertyName))
+			chain := []string{dep.PropertyName}
+			if len(dep.PropertyChain) > 0 {
+				chain = dep.PropertyChain
+			}
+			optional := ""
+			if dep.Optional {
+				optional = " (optional)"
+			}
+			report.WriteString(fmt.Sprintf("  %s:%s -> ${%s}%s\n",
+				dep.GroupID, dep.ArtifactID, strings.Join(chain, "} -> ${"), optional))
 		}
 	}
 
 	return report.String()
 }
 
-// searchForProperties recursively searches for all properties in the project
-func searchForProperties(ctx context.Context, startDir string, excludePath string) map[string]string {
+// searchForProperties recursively searches for all properties in the project.
+// It also returns the POM defining each one, relative to the project root,
+// and the paths that were skipped because of the filter.
+func searchForProperties(ctx context.Context, startDir string, excludePath string, filter *PathFilter, opts ...AnalyzeOption) (map[string]string, map[string]string, []string) {
+	options := &analyzeOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
 	log := clog.FromContext(ctx)
 	properties := make(map[string]string)
+	sources := make(map[string]string)
+	skipped := []string{}
 	pomFilesChecked := 0
 	pomFilesSkipped := 0
+	candidates := []string{}
 	
 	// First, find the project root (go up until we find the topmost pom.xml)
 	projectRoot := findProjectRoot(startDir)
@@ -296,9 +899,16 @@ func searchForProperties(ctx context.Context, startDir string, excludePath strin
 			return nil // Skip errors, continue walking
 		}
 		
-		// Skip hidden directories and common non-source directories
+		relPath, _ := filepath.Rel(projectRoot, path)
+		
+		// Skip hidden directories and generated or vendored directories
 		if info.IsDir() {
-			if isSkippableDirectory(info.Name()) {
+			if path != projectRoot && isSkippableDirectory(info.Name()) {
+				return filepath.SkipDir
+			}
+			if path != projectRoot && filter.skipDir(relPath) {
+				log.Debugf("Skipping excluded directory: %s", relPath)
+				skipped = append(skipped, filepath.ToSlash(relPath)+"/")
 				return filepath.SkipDir
 			}
 			return nil
@@ -309,6 +919,12 @@ func searchForProperties(ctx context.Context, startDir string, excludePath strin
 			return nil
 		}
 		
+		if filter.skipFile(relPath) {
+			log.Debugf("Skipping excluded file: %s", relPath)
+			skipped = append(skipped, filepath.ToSlash(relPath))
+			return nil
+		}
+		
 		// Skip the file we're already analyzing
 		if absPath, _ := filepath.Abs(path); absPath == excludePath {
 			log.Debugf("Skipping excluded file: %s", path)
@@ -316,42 +932,53 @@ func searchForProperties(ctx context.Context, startDir string, excludePath strin
 			return nil
 		}
 		
-		// Try to parse as POM
-		project, err := gopom.Parse(path)
+		candidates = append(candidates, path)
+		return nil
+	})
+
+	if err != nil {
+		log.Warnf("Error walking project tree: %v", err)
+	}
+
+	// Parsing is what takes time, so the candidates are parsed
+	// concurrently. Their properties are merged in the order of the walk,
+	// so that the first definition still wins.
+	found := make([]map[string]string, len(candidates))
+	forEach(options.jobs, len(candidates), func(i int) {
+		project, err := ParseAnalysisPOM(ctx, candidates[i], opts...)
 		if err != nil {
 			// Not a valid POM, skip
-			log.Debugf("Not a valid POM (skipping): %s", path)
-			return nil
+			log.Debugf("Not a valid POM (skipping): %s", candidates[i])
+			return
+		}
+		found[i] = extractPropertiesFromProject(project)
+	})
+	for i, pomProperties := range found {
+		if pomProperties == nil {
+			continue
 		}
-		
 		pomFilesChecked++
-		log.Debugf("Checking POM file %d: %s", pomFilesChecked, path)
-		
+		relPath, _ := filepath.Rel(projectRoot, candidates[i])
+		log.Debugf("Checking POM file %d: %s", pomFilesChecked, candidates[i])
+
 		// Extract properties if they exist
-		pomProperties := extractPropertiesFromProject(project)
 		for k, v := range pomProperties {
 			if _, exists := properties[k]; !exists {
 				properties[k] = v
-				relPath, _ := filepath.Rel(projectRoot, path)
+				sources[k] = filepath.ToSlash(relPath)
 				log.Infof("Found property %s = %s in %s", k, v, relPath)
 			}
 		}
-		
-		return nil
-	})
-	
-	if err != nil {
-		log.Warnf("Error walking project tree: %v", err)
 	}
-	
-	log.Infof("Property search complete: checked %d POM files, skipped %d, found %d unique properties", 
-		pomFilesChecked, pomFilesSkipped, len(properties))
+
+	log.Infof("Property search complete: checked %d POM files, skipped %d, excluded %d paths, found %d unique properties", 
+		pomFilesChecked, pomFilesSkipped, len(skipped), len(properties))
 	
 	if log.Enabled(context.Background(), slog.LevelDebug) {
 		log.Debugf("Properties found: %v", properties)
 	}
 	
-	return properties
+	return properties, sources, skipped
 }
 
 // findProjectRoot finds the root of the Maven project by looking for the topmost pom.xml
@@ -394,6 +1021,7 @@ func FindPropertyLocation(ctx context.Context, startDir string, propertyName str
 	log := clog.FromContext(ctx)
 	
 	projectRoot := findProjectRoot(startDir)
+	filter := NewPathFilter(nil, nil)
 	log.Debugf("Searching for property %s starting from project root: %s", propertyName, projectRoot)
 	
 	var foundPath string
@@ -406,20 +1034,22 @@ func FindPropertyLocation(ctx context.Context, startDir string, propertyName str
 			return nil
 		}
 		
-		// Skip hidden directories and common non-source directories
+		relPath, _ := filepath.Rel(projectRoot, path)
+		
+		// Skip hidden directories and generated or vendored directories
 		if info.IsDir() {
-			if isSkippableDirectory(info.Name()) {
+			if path != projectRoot && (isSkippableDirectory(info.Name()) || filter.skipDir(relPath)) {
 				return filepath.SkipDir
 			}
 			return nil
 		}
 		
 		// Only process XML files (POMs can have any name)
-		if !strings.HasSuffix(info.Name(), ".xml") {
+		if !strings.HasSuffix(info.Name(), ".xml") || filter.skipFile(relPath) {
 			return nil
 		}
 		
-		project, err := gopom.Parse(path)
+		project, err := parsePOM(ctx, path)
 		if err != nil {
 			return nil
 		}
@@ -430,7 +1060,6 @@ func FindPropertyLocation(ctx context.Context, startDir string, propertyName str
 		if value, exists := pomProperties[propertyName]; exists {
 			foundPath = path
 			foundValue = value
-			relPath, _ := filepath.Rel(projectRoot, path)
 			log.Infof("Found property %s = %s in %s", propertyName, value, relPath)
 			return filepath.SkipDir // Stop searching
 		}
@@ -464,14 +1093,10 @@ func mergeProperties(ctx context.Context, target, source map[string]string, sour
 	}
 }
 
-// isSkippableDirectory checks if a directory should be skipped during traversal
+// isSkippableDirectory checks if a directory should always be skipped during
+// traversal. Generated and vendored directories are handled by PathFilter.
 func isSkippableDirectory(name string) bool {
-	return strings.HasPrefix(name, ".") || 
-		name == "target" || 
-		name == "node_modules" ||
-		name == "build" ||
-		name == "dist" ||
-		name == "out"
+	return strings.HasPrefix(name, ".")
 }
 
 // extractPropertiesFromProject extracts properties from a parsed POM project
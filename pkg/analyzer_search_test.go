@@ -352,7 +352,7 @@ func TestSearchForPropertiesSkipsHiddenAndBuildDirs(t *testing.T) {
 		0644))
 	
 	ctx := context.Background()
-	props := searchForProperties(ctx, tmpDir, "")
+	props, _, _ := searchForProperties(ctx, tmpDir, "", NewPathFilter(nil, nil))
 	
 	// Should only find the property from the valid directory
 	assert.Equal(t, "valid", props["test.property"])
@@ -411,4 +411,72 @@ func TestAnalyzeProjectPathWithNonPomXMLFiles(t *testing.T) {
 	
 	// Should not have properties from non-POM XML files
 	assert.NotContains(t, result.Properties, "setting")
-}
\ No newline at end of file
+}
+
+func TestSearchForPropertiesExcludeAndInclude(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	pomContent := `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+    <properties>
+        <%s.property>value</%s.property>
+    </properties>
+</project>`
+
+	files := map[string]string{
+		"pom.xml":                       "root",
+		"dependency-reduced-pom.xml":    "reduced",
+		"module/.flattened-pom.xml":     "flattened",
+		"build/pom.xml":                 "build",
+		"build/bom/pom.xml":             "bom",
+		"generated/pom.xml":             "generated",
+		"module/target/classes/pom.xml": "target",
+	}
+	for path, name := range files {
+		full := filepath.Join(tmpDir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(fmt.Sprintf(pomContent, name, name)), 0644))
+	}
+
+	ctx := context.Background()
+
+	t.Run("defaults plus extra exclude", func(t *testing.T) {
+		props, _, skipped := searchForProperties(ctx, tmpDir, "", NewPathFilter([]string{"generated/"}, nil))
+		assert.Equal(t, map[string]string{"root.property": "value"}, props)
+		assert.ElementsMatch(t, []string{
+			"build/",
+			"dependency-reduced-pom.xml",
+			"generated/",
+			"module/.flattened-pom.xml",
+			"module/target/",
+		}, skipped)
+	})
+
+	t.Run("include opts back in", func(t *testing.T) {
+		props, _, skipped := searchForProperties(ctx, tmpDir, "", NewPathFilter(nil, []string{"build/bom/pom.xml", "dependency-reduced-pom.xml"}))
+		assert.Contains(t, props, "bom.property")
+		assert.Contains(t, props, "reduced.property")
+		assert.Contains(t, props, "generated.property")
+		assert.NotContains(t, props, "build.property")
+		assert.Contains(t, skipped, "build/pom.xml")
+	})
+}
+
+func TestSearchForPropertiesFirstDefinitionWins(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "pom.xml"), []byte("<project><artifactId>root</artifactId></project>"), 0644))
+	// Defined in many POMs, the first in the order of the walk wins however
+	// many are parsed at once.
+	for i := range 20 {
+		dir := filepath.Join(tmpDir, fmt.Sprintf("module-%02d", i))
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "pom.xml"), []byte(fmt.Sprintf(
+			"<project><properties><shared.version>%d</shared.version><module-%02d.version>1</module-%02d.version></properties></project>", i, i, i)), 0644))
+	}
+
+	for _, jobs := range []int{1, 8} {
+		props, _, _ := searchForProperties(context.Background(), tmpDir, "", NewPathFilter(nil, nil), WithJobs(jobs))
+		assert.Equal(t, "0", props["shared.version"], "jobs %d", jobs)
+		assert.Len(t, props, 21, "jobs %d", jobs)
+	}
+}
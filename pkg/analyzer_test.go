@@ -303,3 +303,119 @@ func TestGetAffectedDependencies(t *testing.T) {
 	affectedNone := result.GetAffectedDependencies("non.existent")
 	assert.Len(t, affectedNone, 0)
 }
+
+func TestAnalyzeProjectOptions(t *testing.T) {
+	project := &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{"netty.version": "4.1.94.Final"}},
+		DependencyManagement: &gopom.DependencyManagement{
+			Dependencies: &[]gopom.Dependency{
+				{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "${netty.version}", Type: "pom", Scope: "import"},
+				{GroupID: "com.fasterxml.jackson", ArtifactID: "jackson-bom", Version: "2.15.2", Type: "pom", Scope: "import"},
+				{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.9"},
+			},
+		},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "${netty.version}"},
+		},
+	}
+
+	result, err := AnalyzeProject(context.Background(), project, WithoutDependencyIndex(), WithoutBOMDetection())
+	require.NoError(t, err)
+
+	// Skipped passes are not computed up front...
+	assert.Empty(t, result.Dependencies)
+	assert.Empty(t, result.PropertyUsageCounts)
+	assert.Equal(t, "4.1.94.Final", result.Properties["netty.version"])
+
+	// ...but on first use.
+	assert.Equal(t, []BOMInfo{
+		{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "${netty.version}", UsesProperty: true, PropertyName: "netty.version"},
+		{GroupID: "com.fasterxml.jackson", ArtifactID: "jackson-bom", Version: "2.15.2"},
+	}, result.BOMs())
+	useProperty, property := result.ShouldUseProperty("io.netty", "netty-handler")
+	assert.True(t, useProperty)
+	assert.Equal(t, "netty.version", property)
+	assert.Len(t, result.Dependencies, 4)
+	assert.Equal(t, 2, result.PropertyUsageCounts["netty.version"])
+
+	// Or when asked for.
+	result, err = AnalyzeProject(context.Background(), project, WithoutDependencyIndex())
+	require.NoError(t, err)
+	assert.Empty(t, result.Dependencies)
+	result.IndexDependencies(context.Background())
+	assert.Len(t, result.Dependencies, 4)
+	assert.Equal(t, 2, result.PropertyUsageCounts["netty.version"])
+}
+
+func TestCompositeVersions(t *testing.T) {
+	project := &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{
+			"netty.version":   "4.1.94",
+			"build.qualifier": "Final",
+		}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "${netty.version}.${build.qualifier}"},
+			{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "${netty.version}"},
+			{GroupID: "org.example", ArtifactID: "tool", Version: "1.0-${build.qualifier}"},
+		},
+	}
+	result, err := AnalyzeProject(context.Background(), project)
+	require.NoError(t, err)
+
+	handler := result.Dependencies["io.netty:netty-handler"]
+	assert.False(t, handler.UsesProperty)
+	assert.Equal(t, []string{"netty.version", "build.qualifier"}, handler.ReferencedProperties)
+	assert.Equal(t, map[string]int{"netty.version": 2, "build.qualifier": 2}, result.PropertyUsageCounts)
+	assert.Equal(t, 1, countPropertiesUsage(result))
+
+	assert.Equal(t, map[string]string{
+		"io.netty:netty-handler": "4.1.94.Final",
+		"io.netty:netty-codec":   "4.1.94",
+		"org.example:tool":       "1.0-Final",
+	}, result.CurrentVersions())
+
+	assert.Equal(t, []DependencyImpact{
+		{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "${netty.version}", Before: "4.1.94", After: "4.1.118"},
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "${netty.version}.${build.qualifier}", Before: "4.1.94.Final", After: "4.1.118.Final"},
+	}, result.PropertyImpact("netty.version", "4.1.118"))
+
+	// Composite versions are not routed through their properties.
+	direct, props := PatchStrategy(context.Background(), result, []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final"},
+	})
+	assert.Empty(t, props)
+	assert.Len(t, direct, 1)
+}
+
+func TestPropertyReference(t *testing.T) {
+	for version, want := range map[string]string{
+		"${netty.version}":       "netty.version",
+		"${a}-${b}":              "",
+		"1.0-${build.qualifier}": "",
+		"4.1.94.Final":           "",
+	} {
+		got, _ := propertyReference(version)
+		assert.Equal(t, want, got, version)
+	}
+	assert.Equal(t, []string{"a", "b"}, propertyReferences("${a}-${b}-${a}"))
+	assert.Empty(t, propertyReferences("4.1.94.Final"))
+}
+
+func TestAnalysisReportOrder(t *testing.T) {
+	deps := []gopom.Dependency{}
+	properties := map[string]string{}
+	for _, name := range []string{"e", "b", "d", "a", "c"} {
+		deps = append(deps, gopom.Dependency{GroupID: "g", ArtifactID: name, Version: "${" + name + ".version}"})
+		properties[name+".version"] = "1.0"
+	}
+	result, err := AnalyzeProject(context.Background(), &gopom.Project{
+		Properties:   &gopom.Properties{Entries: properties},
+		Dependencies: &deps,
+	})
+	require.NoError(t, err)
+
+	// Reproducible, whatever the order of the maps.
+	report := result.AnalysisReport()
+	assert.Contains(t, report, "Property Usage:\n---------------\n  a.version = 1.0 (used by 1 dependencies)\n  b.version = 1.0 (used by 1 dependencies)\n  c.version = 1.0 (used by 1 dependencies)\n  d.version = 1.0 (used by 1 dependencies)\n  e.version = 1.0 (used by 1 dependencies)\n")
+	assert.Contains(t, report, "Dependencies Using Properties:\n-------------------------------\n  g:a -> ${a.version}\n  g:b -> ${b.version}\n  g:c -> ${c.version}\n  g:d -> ${d.version}\n  g:e -> ${e.version}\n")
+}
@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// AnalysisIssues are the issues of an analysis: the updates recommended for
+// the patches, and the warnings, conflicts and unfixable patches.
+type AnalysisIssues struct {
+	Patches    []Patch           `json:"patches" yaml:"patches"`
+	Properties []PropertyPatch   `json:"properties" yaml:"properties"`
+	Warnings   []Warning         `json:"warnings" yaml:"warnings"`
+	Conflicts  []VersionConflict `json:"conflicts" yaml:"conflicts"`
+	Unfixable  []UnfixableIssue  `json:"unfixable" yaml:"unfixable"`
+}
+
+// Count returns the number of issues.
+func (i AnalysisIssues) Count() int {
+	return len(i.Patches) + len(i.Properties) + len(i.Warnings) + len(i.Conflicts) + len(i.Unfixable)
+}
+
+// AnalysisChanges is what changed in an analysis since a baseline, an
+// earlier AnalysisOutput of the same POMs.
+type AnalysisChanges struct {
+	// Baseline are the POMs of the baseline.
+	Baseline []string `json:"baseline" yaml:"baseline"`
+	// New are the issues the baseline does not have, and Resolved those
+	// of the baseline which are gone.
+	New      AnalysisIssues `json:"new" yaml:"new"`
+	Resolved AnalysisIssues `json:"resolved" yaml:"resolved"`
+	// BOMs are the imported BOMs added, removed or changed.
+	BOMs []POMChange `json:"boms" yaml:"boms"`
+}
+
+// Empty reports whether nothing changed since the baseline.
+func (c *AnalysisChanges) Empty() bool {
+	return c.New.Count() == 0 && c.Resolved.Count() == 0 && len(c.BOMs) == 0
+}
+
+// CompareAnalysisOutputs reports what changed from baseline to current. An
+// update is the same if it bumps the same artifact, or property, to the same
+// version, a warning if it has the same code and message.
+func CompareAnalysisOutputs(baseline, current *AnalysisOutput) *AnalysisChanges {
+	changes := &AnalysisChanges{Baseline: baseline.POMs}
+	changes.New.Patches, changes.Resolved.Patches = compareIssues(baseline.Patches, current.Patches, func(p Patch) string {
+		return fmt.Sprintf("%s:%s:%s", p.GroupID, p.ArtifactID, p.Version)
+	})
+	changes.New.Properties, changes.Resolved.Properties = compareIssues(baseline.Properties, current.Properties, func(p PropertyPatch) string {
+		return p.Property + "=" + p.Value
+	})
+	changes.New.Warnings, changes.Resolved.Warnings = compareIssues(baseline.Warnings, current.Warnings, func(w Warning) string {
+		return w.Code + " " + w.Message
+	})
+	changes.New.Conflicts, changes.Resolved.Conflicts = compareIssues(baseline.Conflicts, current.Conflicts, func(c VersionConflict) string {
+		return c.Group + " " + strings.Join(c.Versions, ",")
+	})
+	changes.New.Unfixable, changes.Resolved.Unfixable = compareIssues(baseline.Unfixable, current.Unfixable, func(u UnfixableIssue) string {
+		return fmt.Sprintf("%s %s:%s:%s", u.Code, u.GroupID, u.ArtifactID, u.Version)
+	})
+	changes.BOMs = diffEntries(importedBOMVersions(baseline), importedBOMVersions(current))
+	return changes
+}
+
+// compareIssues returns the issues of current not in baseline, and those of
+// baseline not in current, telling them apart by key.
+func compareIssues[T any](baseline, current []T, key func(T) string) ([]T, []T) {
+	missing := func(from, in []T) []T {
+		keys := map[string]bool{}
+		for _, issue := range in {
+			keys[key(issue)] = true
+		}
+		out := []T{}
+		for _, issue := range from {
+			if !keys[key(issue)] {
+				out = append(out, issue)
+			}
+		}
+		return out
+	}
+	return missing(current, baseline), missing(baseline, current)
+}
+
+// importedBOMVersions maps the BOMs output imports to their version, with
+// the properties resolved.
+func importedBOMVersions(output *AnalysisOutput) map[string]string {
+	var properties map[string]string
+	if output.Analysis != nil {
+		properties = output.Analysis.Properties
+	}
+	versions := map[string]string{}
+	for _, bom := range output.ImportedBOMs {
+		versions[fmt.Sprintf("%s:%s", bom.GroupID, bom.ArtifactID)] = interpolate(bom.Version, properties)
+	}
+	return versions
+}
+
+// WriteAnalysisOutput writes output to file, to compare later analyses with,
+// as JSON if its name ends in .json and as YAML otherwise.
+func WriteAnalysisOutput(file string, output AnalysisOutput) error {
+	var data []byte
+	var err error
+	if strings.HasSuffix(file, ".json") {
+		data, err = json.MarshalIndent(output, "", "  ")
+	} else {
+		data, err = yaml.Marshal(output)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal analysis: %w", err)
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// ReadAnalysisOutput reads an AnalysisOutput, as written by
+// WriteAnalysisOutput or analyze --output json.
+func ReadAnalysisOutput(file string) (*AnalysisOutput, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading file: %w", err)
+	}
+	var output AnalysisOutput
+	if err := yaml.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("failed to parse analysis: %w", err)
+	}
+	return &output, nil
+}
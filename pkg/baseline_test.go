@@ -0,0 +1,60 @@
+package pkg
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareAnalysisOutputs(t *testing.T) {
+	project, err := parsePOMData([]byte(analyzerPOM))
+	require.NoError(t, err)
+	analysis, err := AnalyzeProject(context.Background(), project)
+	require.NoError(t, err)
+
+	baseline := AnalysisOutput{
+		POMs:     []string{"pom.xml"},
+		Analysis: analysis,
+		Patches: []Patch{
+			{GroupID: "org.yaml", ArtifactID: "snakeyaml", Version: "2.2"},
+			{GroupID: "com.google.guava", ArtifactID: "guava", Version: "33.0.0-jre"},
+		},
+		Properties: []PropertyPatch{{Property: "netty.version", Value: "4.1.100.Final"}},
+		Warnings:   []Warning{{Code: CodeSnapshot, Kind: "snapshot", Message: "app uses 1.0-SNAPSHOT"}},
+	}
+	for _, file := range []string{"baseline.json", "baseline.yaml"} {
+		path := filepath.Join(t.TempDir(), file)
+		require.NoError(t, WriteAnalysisOutput(path, baseline))
+		read, err := ReadAnalysisOutput(path)
+		require.NoError(t, err)
+		assert.Equal(t, baseline.Patches, read.Patches)
+		assert.Equal(t, analysis.Properties, read.Analysis.Properties)
+	}
+
+	current := AnalysisOutput{
+		POMs:     []string{"pom.xml"},
+		Analysis: analysis,
+		Patches: []Patch{
+			{GroupID: "org.yaml", ArtifactID: "snakeyaml", Version: "2.2"},
+			{GroupID: "com.google.guava", ArtifactID: "guava", Version: "33.1.0-jre"},
+		},
+		Properties:   []PropertyPatch{{Property: "netty.version", Value: "4.1.100.Final"}},
+		Unfixable:    []UnfixableIssue{{Code: CodeVersionNotPublished, GroupID: "io.netty", ArtifactID: "netty-codec", Version: "9.9.9", Reason: "not published"}},
+		ImportedBOMs: analysis.BOMs(),
+	}
+	changes := CompareAnalysisOutputs(&baseline, &current)
+	assert.Equal(t, []string{"pom.xml"}, changes.Baseline)
+	assert.Equal(t, []Patch{{GroupID: "com.google.guava", ArtifactID: "guava", Version: "33.1.0-jre"}}, changes.New.Patches)
+	assert.Equal(t, current.Unfixable, changes.New.Unfixable)
+	assert.Empty(t, changes.New.Properties)
+	assert.Equal(t, 2, changes.New.Count())
+	assert.Equal(t, []Patch{{GroupID: "com.google.guava", ArtifactID: "guava", Version: "33.0.0-jre"}}, changes.Resolved.Patches)
+	assert.Equal(t, baseline.Warnings, changes.Resolved.Warnings)
+	assert.Equal(t, []POMChange{{Name: "io.netty:netty-bom", Change: ChangeAdded, New: "4.1.94.Final"}}, changes.BOMs)
+	assert.False(t, changes.Empty())
+
+	assert.True(t, CompareAnalysisOutputs(&current, &current).Empty())
+}
@@ -0,0 +1,234 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// IsGlob reports whether path is a glob pattern rather than a file, see
+// ExpandGlobs.
+func IsGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// ExpandGlobs expands the glob patterns among paths to the files they match,
+// sorted, leaving the other paths as they are. Besides the filepath.Match
+// syntax, a ** path element matches any number of directories, so that
+// **/pom.xml is every pom.xml under the current directory. Directories the
+// filter skips are not searched, and a pattern matching no file is an error.
+// A file given twice is only returned once.
+func ExpandGlobs(paths []string, filter *PathFilter) ([]string, error) {
+	expanded := []string{}
+	seen := map[string]bool{}
+	add := func(path string) {
+		if !seen[filepath.Clean(path)] {
+			seen[filepath.Clean(path)] = true
+			expanded = append(expanded, path)
+		}
+	}
+	for _, path := range paths {
+		if !IsGlob(path) {
+			add(path)
+			continue
+		}
+		matches, err := expandGlob(path, filter)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no file matches %s", path)
+		}
+		for _, match := range matches {
+			add(match)
+		}
+	}
+	return expanded, nil
+}
+
+// expandGlob walks the directory the pattern starts with, its longest prefix
+// without a wildcard, for the files matching the pattern.
+func expandGlob(pattern string, filter *PathFilter) ([]string, error) {
+	elements := strings.Split(filepath.ToSlash(pattern), "/")
+	fixed := 0
+	for fixed < len(elements)-1 && !IsGlob(elements[fixed]) {
+		fixed++
+	}
+	root := strings.Join(elements[:fixed], "/")
+	if root == "" && fixed > 0 {
+		root = "/"
+	}
+	walkRoot := filepath.FromSlash(root)
+	if walkRoot == "" {
+		walkRoot = "."
+	}
+	for _, element := range elements[fixed:] {
+		if _, err := filepath.Match(element, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+	}
+
+	matches := []string{}
+	err := filepath.WalkDir(walkRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == walkRoot {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		rel, err := filepath.Rel(walkRoot, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if d.IsDir() {
+			if filter.skipDir(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !filter.skipFile(rel) && matchPathGlob(elements[fixed:], strings.Split(filepath.ToSlash(rel), "/")) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand %s: %w", pattern, err)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// matchPathGlob matches the elements of a path against those of a pattern,
+// where ** matches any number of them.
+func matchPathGlob(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchPathGlob(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	matched, err := filepath.Match(pattern[0], path[0])
+	return err == nil && matched && matchPathGlob(pattern[1:], path[1:])
+}
+
+// FileAnalysis is the analysis of one of the files given to AnalyzeFiles.
+type FileAnalysis struct {
+	Path   string
+	Result *AnalysisResult
+	// Err is why the file could not be analyzed, in which case Result is
+	// nil.
+	Err error
+}
+
+// AnalyzeFiles analyzes each of the POMs (or Gradle build scripts) at paths
+// on its own, running up to jobs analyses at once, or one per CPU if jobs is
+// not positive. A file failing does not stop the others. The analyses are
+// returned in the order of paths.
+func AnalyzeFiles(ctx context.Context, paths []string, jobs int, opts ...AnalyzeOption) []FileAnalysis {
+	results := make([]FileAnalysis, len(paths))
+	forEach(jobs, len(paths), func(i int) {
+		results[i] = FileAnalysis{Path: paths[i]}
+		results[i].Result, results[i].Err = analyzeFile(ctx, paths[i], opts...)
+		if results[i].Err != nil {
+			clog.FromContext(ctx).Warnf("Failed to analyze %s: %v", paths[i], results[i].Err)
+		}
+	})
+	return results
+}
+
+// MergeFileAnalyses merges the analyses into one AnalysisResult as
+// AnalyzeReactor does the modules of a reactor, each file being a module. It
+// fails if any file could not be analyzed.
+func MergeFileAnalyses(ctx context.Context, files []FileAnalysis) (*AnalysisResult, error) {
+	paths := make([]string, 0, len(files))
+	analyses := make([]*AnalysisResult, 0, len(files))
+	for _, file := range files {
+		if file.Err != nil {
+			return nil, fmt.Errorf("failed to analyze %s: %w", file.Path, file.Err)
+		}
+		paths = append(paths, filepath.ToSlash(file.Path))
+		analyses = append(analyses, file.Result)
+	}
+	return mergeAnalyses(ctx, paths, analyses), nil
+}
+
+// analyzeFile analyzes the POM or Gradle build script at path.
+func analyzeFile(ctx context.Context, path string, opts ...AnalyzeOption) (*AnalysisResult, error) {
+	if IsGradleBuild(path) {
+		build, err := LoadGradleBuild(path)
+		if err != nil {
+			return nil, err
+		}
+		return AnalyzeGradle(ctx, build), nil
+	}
+	project, err := ParseAnalysisPOM(ctx, path, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse POM file: %w", err)
+	}
+	result, err := AnalyzeProject(ctx, project, opts...)
+	if err != nil {
+		return nil, err
+	}
+	options := &analyzeOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.positions {
+		if err := result.LocateDeclarations(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := result.ValidateSchema(path); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// WithJobs bounds how many POMs are parsed or analyzed at once by
+// AnalyzeProjectPath's property search, DiscoverModules and AnalyzeReactor.
+// By default, or if jobs is not positive, it is one per CPU.
+func WithJobs(jobs int) AnalyzeOption {
+	return func(o *analyzeOptions) {
+		o.jobs = jobs
+	}
+}
+
+// forEach calls f with every index below n, from at most jobs goroutines at
+// once, or one per CPU if jobs is not positive, and waits for them.
+func forEach(jobs, n int, f func(i int)) {
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(jobs, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				f(i)
+			}
+		}()
+	}
+	for i := range n {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
@@ -0,0 +1,102 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandGlobs(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{"pom.xml", "a/pom.xml", "a/b/pom.xml", "a/target/pom.xml", "c/build.gradle", "c/other.xml"} {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("<project/>"), 0644))
+	}
+	in := func(paths ...string) []string {
+		for i, path := range paths {
+			paths[i] = filepath.Join(dir, filepath.FromSlash(path))
+		}
+		return paths
+	}
+
+	got, err := ExpandGlobs(in("**/pom.xml"), NewPathFilter(nil, nil))
+	require.NoError(t, err)
+	assert.Equal(t, in("a/b/pom.xml", "a/pom.xml", "pom.xml"), got)
+
+	got, err = ExpandGlobs(in("*/pom.xml", "c/build.gradle", "a/pom.xml"), NewPathFilter(nil, []string{"target/"}))
+	require.NoError(t, err)
+	assert.Equal(t, in("a/pom.xml", "c/build.gradle"), got)
+
+	got, err = ExpandGlobs(in("a/**/pom.xml"), NewPathFilter(nil, []string{"target/"}))
+	require.NoError(t, err)
+	assert.Equal(t, in("a/b/pom.xml", "a/pom.xml", "a/target/pom.xml"), got)
+
+	_, err = ExpandGlobs(in("**/missing.xml"), nil)
+	assert.Error(t, err)
+	_, err = ExpandGlobs(in("[/pom.xml"), nil)
+	assert.Error(t, err)
+	assert.False(t, IsGlob("pom.xml"))
+}
+
+func TestAnalyzeFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.xml")
+	require.NoError(t, os.WriteFile(first, []byte(`<project>
+  <properties><netty.version>4.1.94.Final</netty.version></properties>
+  <dependencies>
+    <dependency><groupId>io.netty</groupId><artifactId>netty-handler</artifactId><version>${netty.version}</version></dependency>
+  </dependencies>
+</project>`), 0644))
+	second := filepath.Join(dir, "second.xml")
+	require.NoError(t, os.WriteFile(second, []byte(`<project>
+  <dependencies>
+    <dependency><groupId>io.netty</groupId><artifactId>netty-codec</artifactId><version>${netty.version}</version></dependency>
+  </dependencies>
+</project>`), 0644))
+	broken := filepath.Join(dir, "broken.xml")
+	require.NoError(t, os.WriteFile(broken, []byte("<project>"), 0644))
+
+	files := AnalyzeFiles(context.Background(), []string{first, broken, second}, 2)
+	require.Len(t, files, 3)
+	assert.Equal(t, []string{first, broken, second}, []string{files[0].Path, files[1].Path, files[2].Path})
+	assert.NoError(t, files[0].Err)
+	assert.Error(t, files[1].Err)
+	assert.Equal(t, 1, files[2].Result.ModuleAnalysis(second).DependenciesUsingProperties)
+
+	_, err := MergeFileAnalyses(context.Background(), files)
+	assert.Error(t, err)
+
+	merged, err := MergeFileAnalyses(context.Background(), []FileAnalysis{files[0], files[2]})
+	require.NoError(t, err)
+	assert.Len(t, merged.AggregateReport().Modules, 2)
+	// The property is shared by the dependencies of both files.
+	direct, properties := PatchStrategy(context.Background(), merged, []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final"},
+		{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "4.1.118.Final"},
+	})
+	assert.Empty(t, direct)
+	assert.Equal(t, map[string]string{"netty.version": "4.1.118.Final"}, properties)
+}
+
+func TestForEach(t *testing.T) {
+	var running, most, calls atomic.Int32
+	forEach(3, 20, func(int) {
+		n := running.Add(1)
+		for {
+			m := most.Load()
+			if n <= m || most.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		calls.Add(1)
+		running.Add(-1)
+	})
+	assert.Equal(t, int32(20), calls.Load())
+	assert.LessOrEqual(t, most.Load(), int32(3))
+}
@@ -0,0 +1,145 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+)
+
+// ResolveBOMs fetches every BOM the project imports from repo and records
+// the artifacts each one manages, with their versions, in BOMInfo.Managed.
+// The effective dependencyManagement of a BOM is used, with what it inherits
+// from its parents and the BOMs it imports itself. The dependencyManagement
+// of the parent of the project is resolved the same way. Dependencies
+// declared without a version then get ManagedBy set to what manages them. A
+// BOM or parent that can not be resolved is skipped with a warning.
+func (result *AnalysisResult) ResolveBOMs(ctx context.Context, repo *MavenRepository) {
+	log := clog.FromContext(ctx)
+	result.IndexDependencies(ctx)
+	if result.project == nil {
+		return
+	}
+	boms := result.BOMs()
+	for i := range boms {
+		bom := &boms[i]
+		version := interpolate(bom.Version, result.Properties)
+		if version == "" || strings.Contains(version, "${") {
+			log.Warnf("Not resolving BOM %s:%s, its version %q is unknown", bom.GroupID, bom.ArtifactID, bom.Version)
+			continue
+		}
+		managed, err := resolveBOM(ctx, repo, bom.GroupID, bom.ArtifactID, version, map[string]bool{})
+		if err != nil {
+			log.Warnf("Unable to resolve BOM %s:%s:%s: %v", bom.GroupID, bom.ArtifactID, version, err)
+			continue
+		}
+		bom.Managed = managed
+		log.Infof("BOM %s:%s:%s manages %d artifacts", bom.GroupID, bom.ArtifactID, version, len(managed))
+	}
+
+	// The parent manages versions like a BOM does.
+	if parent := result.project.Parent; parent != nil && parent.Version != "" {
+		managed, err := resolveBOM(ctx, repo, parent.GroupID, parent.ArtifactID, parent.Version, map[string]bool{})
+		if err != nil {
+			log.Warnf("Unable to resolve parent %s:%s:%s: %v", parent.GroupID, parent.ArtifactID, parent.Version, err)
+		} else {
+			result.parent = &Management{Source: ManagedByParent, GroupID: parent.GroupID, ArtifactID: parent.ArtifactID}
+			result.parentManaged = managed
+			log.Infof("Parent %s:%s:%s manages %d artifacts", parent.GroupID, parent.ArtifactID, parent.Version, len(managed))
+		}
+	}
+
+	result.attributeVersionless()
+}
+
+// ManagingBOM returns the resolved BOM that manages groupID:artifactID, and
+// the version it manages. Like Maven, the first BOM imported wins. Only BOMs
+// resolved by ResolveBOMs are considered.
+func (result *AnalysisResult) ManagingBOM(groupID, artifactID string) (BOMInfo, string, bool) {
+	key := fmt.Sprintf("%s:%s", groupID, artifactID)
+	for _, bom := range result.BOMs() {
+		if version, ok := bom.Managed[key]; ok {
+			return bom, version, true
+		}
+	}
+	return BOMInfo{}, "", false
+}
+
+// resolveBOM returns the artifacts the BOM groupID:artifactID:version
+// manages, keyed by groupId:artifactId. Its own dependencyManagement, with
+// what its parents add, wins over the BOMs it imports, of which the first
+// wins. seen guards against import cycles.
+func resolveBOM(ctx context.Context, repo *MavenRepository, groupID, artifactID, version string, seen map[string]bool) (map[string]string, error) {
+	gav := fmt.Sprintf("%s:%s:%s", groupID, artifactID, version)
+	if seen[gav] {
+		clog.FromContext(ctx).Debugf("BOM %s imports itself, ignoring", gav)
+		return map[string]string{}, nil
+	}
+	if len(seen) >= repo.maxDepth() {
+		return nil, fmt.Errorf("BOM imports deeper than %d", repo.maxDepth())
+	}
+	seen[gav] = true
+	defer delete(seen, gav)
+
+	chain, err := fetchParentChain(ctx, repo, groupID, artifactID, version)
+	if err != nil {
+		return nil, err
+	}
+	return chainManaged(ctx, repo, chain, seen)
+}
+
+// chainProperties returns the properties of chain[0], with those it inherits
+// from its parents chain[1:].
+func chainProperties(chain []*gopom.Project) map[string]string {
+	props := map[string]string{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		for k, v := range extractPropertiesFromProject(chain[i]) {
+			props[k] = v
+		}
+	}
+	props["project.version"] = projectVersion(chain[0])
+	props["project.groupId"] = projectGroupID(chain[0])
+	return props
+}
+
+// chainManaged returns the artifacts chain[0] manages, with what its parents
+// chain[1:] manage, as resolveBOM does.
+func chainManaged(ctx context.Context, repo *MavenRepository, chain []*gopom.Project, seen map[string]bool) (map[string]string, error) {
+	props := chainProperties(chain)
+	managed := map[string]string{}
+	imports := []gopom.Dependency{}
+	// Closest POM first, so that children override and their imports come
+	// first.
+	for _, p := range chain {
+		if p.DependencyManagement == nil || p.DependencyManagement.Dependencies == nil {
+			continue
+		}
+		for _, dep := range *p.DependencyManagement.Dependencies {
+			dep.GroupID = interpolate(dep.GroupID, props)
+			dep.ArtifactID = interpolate(dep.ArtifactID, props)
+			dep.Version = interpolate(dep.Version, props)
+			if dep.Scope == "import" && dep.Type == "pom" {
+				imports = append(imports, dep)
+				continue
+			}
+			key := fmt.Sprintf("%s:%s", dep.GroupID, dep.ArtifactID)
+			if _, exists := managed[key]; !exists {
+				managed[key] = dep.Version
+			}
+		}
+	}
+	for _, imported := range imports {
+		nested, err := resolveBOM(ctx, repo, imported.GroupID, imported.ArtifactID, imported.Version, seen)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve imported BOM %s:%s: %w", imported.GroupID, imported.ArtifactID, err)
+		}
+		for key, v := range nested {
+			if _, exists := managed[key]; !exists {
+				managed[key] = v
+			}
+		}
+	}
+	return managed, nil
+}
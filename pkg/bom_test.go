@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveBOMs(t *testing.T) {
+	repo := fakeRepository(t, map[string]string{
+		"io/netty/netty-bom/4.1.94.Final/netty-bom-4.1.94.Final.pom": `<project>
+  <groupId>io.netty</groupId>
+  <artifactId>netty-bom</artifactId>
+  <version>4.1.94.Final</version>
+  <parent>
+    <groupId>org.sonatype.oss</groupId>
+    <artifactId>oss-parent</artifactId>
+    <version>7</version>
+  </parent>
+  <properties>
+    <tcnative.version>2.0.61.Final</tcnative.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-handler</artifactId>
+        <version>${project.version}</version>
+      </dependency>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-tcnative</artifactId>
+        <version>${tcnative.version}</version>
+      </dependency>
+      <dependency>
+        <groupId>com.example</groupId>
+        <artifactId>nested-bom</artifactId>
+        <version>1.0</version>
+        <type>pom</type>
+        <scope>import</scope>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`,
+		"org/sonatype/oss/oss-parent/7/oss-parent-7.pom": `<project>
+  <groupId>org.sonatype.oss</groupId>
+  <artifactId>oss-parent</artifactId>
+  <version>7</version>
+</project>`,
+		"com/example/nested-bom/1.0/nested-bom-1.0.pom": `<project>
+  <groupId>com.example</groupId>
+  <artifactId>nested-bom</artifactId>
+  <version>1.0</version>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-handler</artifactId>
+        <version>4.0.0</version>
+      </dependency>
+      <dependency>
+        <groupId>com.example</groupId>
+        <artifactId>nested</artifactId>
+        <version>1.1</version>
+      </dependency>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-bom</artifactId>
+        <version>4.1.94.Final</version>
+        <type>pom</type>
+        <scope>import</scope>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`,
+		"org/slf4j/slf4j-bom/2.0.7/slf4j-bom-2.0.7.pom": `<project>
+  <groupId>org.slf4j</groupId>
+  <artifactId>slf4j-bom</artifactId>
+  <version>2.0.7</version>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-handler</artifactId>
+        <version>1.0</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`,
+	})
+
+	project := &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{"netty.version": "4.1.94.Final"}},
+		DependencyManagement: &gopom.DependencyManagement{Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "${netty.version}", Type: "pom", Scope: "import"},
+			{GroupID: "org.slf4j", ArtifactID: "slf4j-bom", Version: "2.0.7", Type: "pom", Scope: "import"},
+			{GroupID: "com.example", ArtifactID: "missing-bom", Version: "1.0", Type: "pom", Scope: "import"},
+		}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-handler"},
+			{GroupID: "org.example", ArtifactID: "unmanaged"},
+		},
+	}
+	ctx := context.Background()
+	analysis, err := AnalyzeProject(ctx, project)
+	require.NoError(t, err)
+	analysis.ResolveBOMs(ctx, repo)
+
+	boms := analysis.BOMs()
+	require.Len(t, boms, 3)
+	assert.Equal(t, map[string]string{
+		// The BOM itself wins over the one it imports.
+		"io.netty:netty-handler":  "4.1.94.Final",
+		"io.netty:netty-tcnative": "2.0.61.Final",
+		"com.example:nested":      "1.1",
+	}, boms[0].Managed)
+	assert.Nil(t, boms[2].Managed)
+
+	// The first BOM imported wins.
+	bom, version, ok := analysis.ManagingBOM("io.netty", "netty-handler")
+	require.True(t, ok)
+	assert.Equal(t, "netty-bom", bom.ArtifactID)
+	assert.Equal(t, "4.1.94.Final", version)
+	_, _, ok = analysis.ManagingBOM("org.example", "unmanaged")
+	assert.False(t, ok)
+
+	assert.Equal(t, &Management{Source: ManagedByBOM, GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.94.Final"}, analysis.Dependencies["io.netty:netty-handler"].ManagedBy)
+	assert.Nil(t, analysis.Dependencies["org.example:unmanaged"].ManagedBy)
+	assert.Equal(t, "4.1.94.Final", analysis.CurrentVersions()["io.netty:netty-handler"])
+}
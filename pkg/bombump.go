@@ -0,0 +1,157 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// BOMBump is a recommended change of the version of an imported BOM, and how
+// the new version fares against the versions originally requested for the
+// artifacts it manages.
+type BOMBump struct {
+	GroupID    string `json:"groupId" yaml:"groupId"`
+	ArtifactID string `json:"artifactId" yaml:"artifactId"`
+	OldVersion string `json:"oldVersion" yaml:"oldVersion"`
+	NewVersion string `json:"newVersion" yaml:"newVersion"`
+	// Satisfied are the requested artifacts the new version manages at or
+	// above the requested version, BelowFix the others. An artifact the new
+	// version no longer manages is below the fix, with no Managed version.
+	Satisfied []BOMRequirement `json:"satisfied,omitempty" yaml:"satisfied,omitempty"`
+	BelowFix  []BOMRequirement `json:"belowFix,omitempty" yaml:"belowFix,omitempty"`
+	Warnings  []Warning        `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+// Kinds of Warning.
+const (
+	// WarningBOMDowngrade is a BOM managing artifacts at a lower version
+	// than the POM pins them at.
+	WarningBOMDowngrade = "bom-downgrade"
+)
+
+// Warning is something a recommendation may break that needs a closer look
+// before applying it.
+type Warning struct {
+	// Code identifies the kind of warning for good, see CodeName.
+	Code    string `json:"code" yaml:"code"`
+	Kind    string `json:"kind" yaml:"kind"`
+	Message string `json:"message" yaml:"message"`
+	// Artifacts are the affected artifacts, named groupId:artifactId, with
+	// the version they have now and the one they would get.
+	Artifacts []VersionChange `json:"artifacts,omitempty" yaml:"artifacts,omitempty"`
+}
+
+// BOMRequirement is the version requested for an artifact, and the one a BOM
+// manages.
+type BOMRequirement struct {
+	GroupID    string `json:"groupId" yaml:"groupId"`
+	ArtifactID string `json:"artifactId" yaml:"artifactId"`
+	Requested  string `json:"requested" yaml:"requested"`
+	Managed    string `json:"managed,omitempty" yaml:"managed,omitempty"`
+}
+
+// FindBOMBumps returns the imported BOMs whose version changes with the
+// recommended direct patches and property patches, as returned by
+// PatchStrategy.
+func FindBOMBumps(result *AnalysisResult, directPatches []Patch, propertyPatches map[string]string) []BOMBump {
+	properties := maps.Clone(result.Properties)
+	maps.Copy(properties, propertyPatches)
+
+	bumps := []BOMBump{}
+	for _, bom := range result.BOMs() {
+		oldVersion := interpolate(bom.Version, result.Properties)
+		newVersion := interpolate(bom.Version, properties)
+		for _, p := range directPatches {
+			if p.GroupID == bom.GroupID && p.ArtifactID == bom.ArtifactID && p.Version != "" {
+				newVersion = p.Version
+			}
+		}
+		if newVersion == oldVersion || strings.Contains(newVersion, "${") {
+			continue
+		}
+		bumps = append(bumps, BOMBump{GroupID: bom.GroupID, ArtifactID: bom.ArtifactID, OldVersion: oldVersion, NewVersion: newVersion})
+	}
+	return bumps
+}
+
+// CheckBOMBump resolves the new version of the BOM of bump from repo and
+// sorts the requested patches of the artifacts it manages into Satisfied
+// and BelowFix. Patches of artifacts neither version of the BOM manages are
+// left out; the current version is only known if resolved by ResolveBOMs.
+func CheckBOMBump(ctx context.Context, result *AnalysisResult, repo *MavenRepository, bump *BOMBump, requested []Patch) error {
+	log := clog.FromContext(ctx)
+	log.Infof("Resolving BOM %s:%s %s", bump.GroupID, bump.ArtifactID, bump.NewVersion)
+	managed, err := resolveBOM(ctx, repo, bump.GroupID, bump.ArtifactID, bump.NewVersion, map[string]bool{})
+	if err != nil {
+		return fmt.Errorf("failed to resolve BOM %s:%s:%s: %w", bump.GroupID, bump.ArtifactID, bump.NewVersion, err)
+	}
+	var current map[string]string
+	for _, bom := range result.BOMs() {
+		if bom.GroupID == bump.GroupID && bom.ArtifactID == bump.ArtifactID {
+			current = bom.Managed
+		}
+	}
+
+	bump.Satisfied, bump.BelowFix = []BOMRequirement{}, []BOMRequirement{}
+	seen := map[string]bool{}
+	for _, p := range requested {
+		key := fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID)
+		if p.Version == "" || p.Operation == PatchOperationRemove || seen[key] {
+			continue
+		}
+		version, ok := managed[key]
+		if _, before := current[key]; !ok && !before {
+			continue
+		}
+		seen[key] = true
+		requirement := BOMRequirement{GroupID: p.GroupID, ArtifactID: p.ArtifactID, Requested: p.Version, Managed: version}
+		if ok && compareVersions(version, p.Version) >= 0 {
+			bump.Satisfied = append(bump.Satisfied, requirement)
+		} else {
+			bump.BelowFix = append(bump.BelowFix, requirement)
+		}
+	}
+	if warning := bomDowngrades(result, bump, managed); warning != nil {
+		log.Warnf("%s", warning.Message)
+		bump.Warnings = append(bump.Warnings, *warning)
+	}
+	log.Infof("BOM %s:%s %s satisfies %d requested versions, %d still below the fix", bump.GroupID, bump.ArtifactID, bump.NewVersion, len(bump.Satisfied), len(bump.BelowFix))
+	return nil
+}
+
+// bomDowngrades warns about the artifacts the POM pins with an explicit
+// version higher than the one the new version of the BOM of bump, managing
+// managed, has for them. The pins still win for the dependencies declaring
+// them, but the BOM would bring the lower version everywhere else, e.g. to
+// transitive dependencies, and once the pins are dropped.
+func bomDowngrades(result *AnalysisResult, bump *BOMBump, managed map[string]string) *Warning {
+	current := result.CurrentVersions()
+	changes := []VersionChange{}
+	for key, dep := range result.Dependencies {
+		version, ok := managed[key]
+		if !ok || dep.Version == "" || current[key] == "" || strings.Contains(current[key], "${") {
+			continue
+		}
+		if compareVersions(version, current[key]) < 0 {
+			changes = append(changes, VersionChange{Name: key, Old: current[key], New: version})
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	names := make([]string, 0, len(changes))
+	for _, c := range changes {
+		names = append(names, fmt.Sprintf("%s (%s -> %s)", c.Name, c.Old, c.New))
+	}
+	return &Warning{
+		Code:      CodeBOMDowngrade,
+		Kind:      WarningBOMDowngrade,
+		Message:   fmt.Sprintf("BOM %s:%s %s manages %d artifacts below the version the POM pins: %s", bump.GroupID, bump.ArtifactID, bump.NewVersion, len(changes), strings.Join(names, ", ")),
+		Artifacts: changes,
+	}
+}
@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nettyBOM(version string, managed map[string]string) string {
+	deps := ""
+	for artifactID, v := range managed {
+		deps += `
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>` + artifactID + `</artifactId>
+        <version>` + v + `</version>
+      </dependency>`
+	}
+	return `<project>
+  <groupId>io.netty</groupId>
+  <artifactId>netty-bom</artifactId>
+  <version>` + version + `</version>
+  <dependencyManagement>
+    <dependencies>` + deps + `
+    </dependencies>
+  </dependencyManagement>
+</project>`
+}
+
+func TestBOMBumpSatisfaction(t *testing.T) {
+	repo := fakeRepository(t, map[string]string{
+		"io/netty/netty-bom/4.1.94.Final/netty-bom-4.1.94.Final.pom": nettyBOM("4.1.94.Final", map[string]string{
+			"netty-handler":      "4.1.94.Final",
+			"netty-codec-http2":  "4.1.94.Final",
+			"netty-tcnative-old": "2.0.60.Final",
+		}),
+		"io/netty/netty-bom/4.1.100.Final/netty-bom-4.1.100.Final.pom": nettyBOM("4.1.100.Final", map[string]string{
+			"netty-handler":     "4.1.100.Final",
+			"netty-codec-http2": "4.1.100.Final",
+		}),
+	})
+	project := &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{"netty.version": "4.1.94.Final"}},
+		DependencyManagement: &gopom.DependencyManagement{Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "${netty.version}", Type: "pom", Scope: "import"},
+		}},
+	}
+	ctx := context.Background()
+	analysis, err := AnalyzeProject(ctx, project)
+	require.NoError(t, err)
+	analysis.ResolveBOMs(ctx, repo)
+
+	requested := []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.100.Final", Type: "pom"},
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final"},
+		{GroupID: "io.netty", ArtifactID: "netty-codec-http2", Version: "4.1.101.Final"},
+		{GroupID: "io.netty", ArtifactID: "netty-tcnative-old", Version: "2.0.61.Final"},
+		{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.10"},
+	}
+	direct, properties := PatchStrategy(ctx, analysis, requested[:1])
+	bumps := FindBOMBumps(analysis, direct, properties)
+	require.Len(t, bumps, 1)
+	assert.Equal(t, BOMBump{GroupID: "io.netty", ArtifactID: "netty-bom", OldVersion: "4.1.94.Final", NewVersion: "4.1.100.Final"}, bumps[0])
+
+	require.NoError(t, CheckBOMBump(ctx, analysis, repo, &bumps[0], requested))
+	assert.Equal(t, []BOMRequirement{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Requested: "4.1.100.Final", Managed: "4.1.100.Final"},
+	}, bumps[0].Satisfied)
+	assert.Equal(t, []BOMRequirement{
+		{GroupID: "io.netty", ArtifactID: "netty-codec-http2", Requested: "4.1.101.Final", Managed: "4.1.100.Final"},
+		{GroupID: "io.netty", ArtifactID: "netty-tcnative-old", Requested: "2.0.61.Final"},
+	}, bumps[0].BelowFix)
+
+	// A version nobody published.
+	missing := BOMBump{GroupID: "io.netty", ArtifactID: "netty-bom", OldVersion: "4.1.94.Final", NewVersion: "4.1.999.Final"}
+	assert.Error(t, CheckBOMBump(ctx, analysis, repo, &missing, requested))
+}
+
+func TestFindBOMBumps(t *testing.T) {
+	project := &gopom.Project{
+		DependencyManagement: &gopom.DependencyManagement{Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.94.Final", Type: "pom", Scope: "import"},
+			{GroupID: "com.fasterxml.jackson", ArtifactID: "jackson-bom", Version: "${jackson.version}", Type: "pom", Scope: "import"},
+		}},
+	}
+	analysis, err := AnalyzeProject(context.Background(), project)
+	require.NoError(t, err)
+
+	assert.Empty(t, FindBOMBumps(analysis, nil, nil))
+	assert.Empty(t, FindBOMBumps(analysis, []Patch{{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.94.Final"}}, nil))
+	assert.Equal(t, []BOMBump{
+		{GroupID: "io.netty", ArtifactID: "netty-bom", OldVersion: "4.1.94.Final", NewVersion: "4.1.100.Final"},
+		{GroupID: "com.fasterxml.jackson", ArtifactID: "jackson-bom", OldVersion: "${jackson.version}", NewVersion: "2.16.0"},
+	}, FindBOMBumps(analysis,
+		[]Patch{{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.100.Final"}},
+		map[string]string{"jackson.version": "2.16.0"}))
+}
+
+func TestBOMBumpDowngrades(t *testing.T) {
+	repo := fakeRepository(t, map[string]string{
+		"io/netty/netty-bom/4.1.100.Final/netty-bom-4.1.100.Final.pom": nettyBOM("4.1.100.Final", map[string]string{
+			"netty-handler":     "4.1.100.Final",
+			"netty-codec-http2": "4.1.100.Final",
+			"netty-buffer":      "4.1.100.Final",
+		}),
+	})
+	project := &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{"http2.version": "4.1.105.Final"}},
+		DependencyManagement: &gopom.DependencyManagement{Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.94.Final", Type: "pom", Scope: "import"},
+			{GroupID: "io.netty", ArtifactID: "netty-buffer", Version: "4.1.90.Final"},
+		}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-handler"},
+			{GroupID: "io.netty", ArtifactID: "netty-codec-http2", Version: "${http2.version}"},
+		},
+	}
+	ctx := context.Background()
+	analysis, err := AnalyzeProject(ctx, project)
+	require.NoError(t, err)
+
+	bump := BOMBump{GroupID: "io.netty", ArtifactID: "netty-bom", OldVersion: "4.1.94.Final", NewVersion: "4.1.100.Final"}
+	require.NoError(t, CheckBOMBump(ctx, analysis, repo, &bump, nil))
+	require.Len(t, bump.Warnings, 1)
+	assert.Equal(t, WarningBOMDowngrade, bump.Warnings[0].Kind)
+	assert.Equal(t, []VersionChange{
+		{Name: "io.netty:netty-codec-http2", Old: "4.1.105.Final", New: "4.1.100.Final"},
+	}, bump.Warnings[0].Artifacts)
+	assert.Contains(t, bump.Warnings[0].Message, "io.netty:netty-codec-http2 (4.1.105.Final -> 4.1.100.Final)")
+}
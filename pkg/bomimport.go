@@ -0,0 +1,114 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+)
+
+// PatchOperationImport imports the patch as a BOM in <dependencyManagement>
+// (type pom, scope import) at Position, e.g. to adopt netty-bom in a project
+// managing netty artifacts one by one. A BOM already imported has its
+// version bumped where it is.
+const PatchOperationImport = "import"
+
+// Positions of an imported BOM. Maven takes the version of an artifact from
+// the first BOM managing it, so the position decides which BOM wins.
+const (
+	// PositionFirst imports the BOM ahead of every other BOM. It is the
+	// default.
+	PositionFirst = "first"
+	// PositionLast imports the BOM after every other entry.
+	PositionLast = "last"
+	// PositionBefore and PositionAfter are prefixes of
+	// before:groupId:artifactId and after:groupId:artifactId, to import
+	// the BOM next to an existing entry.
+	PositionBefore = "before:"
+	PositionAfter  = "after:"
+)
+
+// validatePosition checks that position is one of the known positions.
+func validatePosition(position string) error {
+	switch position {
+	case "", PositionFirst, PositionLast:
+		return nil
+	}
+	for _, prefix := range []string{PositionBefore, PositionAfter} {
+		if rest, ok := strings.CutPrefix(position, prefix); ok {
+			if g, a, ok := strings.Cut(rest, ":"); ok && g != "" && a != "" {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("invalid position %q, use %q, %q, %sgroupId:artifactId or %sgroupId:artifactId", position, PositionFirst, PositionLast, PositionBefore, PositionAfter)
+}
+
+// positionIndex returns the index of deps at which to insert a BOM at
+// position.
+func positionIndex(deps []gopom.Dependency, position string) (int, error) {
+	switch position {
+	case "", PositionFirst:
+		if at := slices.IndexFunc(deps, isBOMImport); at >= 0 {
+			return at, nil
+		}
+		return len(deps), nil
+	case PositionLast:
+		return len(deps), nil
+	}
+	after := strings.HasPrefix(position, PositionAfter)
+	ga := strings.TrimPrefix(strings.TrimPrefix(position, PositionAfter), PositionBefore)
+	at := slices.IndexFunc(deps, func(d gopom.Dependency) bool {
+		return d.GroupID+":"+d.ArtifactID == ga
+	})
+	if at < 0 {
+		return 0, fmt.Errorf("%s is not in dependencyManagement", ga)
+	}
+	if after {
+		at++
+	}
+	return at, nil
+}
+
+func isBOMImport(dep gopom.Dependency) bool {
+	return dep.Scope == "import" && dep.Type == "pom"
+}
+
+// importBOM applies an import patch to project.
+func importBOM(ctx context.Context, project *gopom.Project, patch Patch) error {
+	log := clog.FromContext(ctx)
+	if project.DependencyManagement == nil {
+		project.DependencyManagement = &gopom.DependencyManagement{}
+	}
+	if project.DependencyManagement.Dependencies == nil {
+		project.DependencyManagement.Dependencies = &[]gopom.Dependency{}
+	}
+	deps := project.DependencyManagement.Dependencies
+	props := coordinateProperties(project)
+
+	patch.Type, patch.Scope = "pom", "import"
+	if matches := exactDependencies(*deps, patch, props); len(matches) > 0 {
+		for _, i := range matches {
+			log.Infof("BOM %s.%s is already imported, patching it from %s to %s", patch.GroupID, patch.ArtifactID, (*deps)[i].Version, patch.Version)
+			patchDependency(&(*deps)[i], patch, props)
+		}
+		return nil
+	}
+
+	at, err := positionIndex(*deps, patch.Position)
+	if err != nil {
+		return fmt.Errorf("failed to import BOM %s.%s: %w", patch.GroupID, patch.ArtifactID, err)
+	}
+	log.Infof("Importing BOM %s.%s:%s at position %d of dependencyManagement", patch.GroupID, patch.ArtifactID, patch.Version, at)
+	*deps = slices.Insert(*deps, at, gopom.Dependency{
+		GroupID:    patch.GroupID,
+		ArtifactID: patch.ArtifactID,
+		Version:    patch.Version,
+		Type:       patch.Type,
+		Scope:      patch.Scope,
+	})
+	return nil
+}
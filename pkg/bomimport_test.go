@@ -0,0 +1,101 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePatchesImport(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{{
+		name: "valid",
+		content: `patches:
+  - groupId: io.netty
+    artifactId: netty-bom
+    version: 4.1.100.Final
+    operation: import
+    position: before:org.springframework.boot:spring-boot-dependencies
+`,
+	}, {
+		name: "no version",
+		content: `patches:
+  - groupId: io.netty
+    artifactId: netty-bom
+    operation: import
+`,
+		wantErr: true,
+	}, {
+		name: "jar",
+		content: `patches:
+  - groupId: io.netty
+    artifactId: netty-bom
+    version: 4.1.100.Final
+    type: jar
+    operation: import
+`,
+		wantErr: true,
+	}, {
+		name: "bad position",
+		content: `patches:
+  - groupId: io.netty
+    artifactId: netty-bom
+    version: 4.1.100.Final
+    operation: import
+    position: middle
+`,
+		wantErr: true,
+	}, {
+		name: "position without import",
+		content: `patches:
+  - groupId: io.netty
+    artifactId: netty-handler
+    version: 4.1.100.Final
+    position: first
+`,
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "patches.yaml")
+			require.NoError(t, os.WriteFile(file, []byte(tt.content), 0644))
+			got, err := ParsePatches(context.Background(), file, "")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, got, 1)
+			// Defaults to a BOM, not a jar.
+			assert.Equal(t, "pom", got[0].Type)
+			assert.Equal(t, "import", got[0].Scope)
+		})
+	}
+}
+
+func TestQuarantineImport(t *testing.T) {
+	ctx := context.Background()
+	project := &gopom.Project{DependencyManagement: makeManaged(
+		pomDep("io.netty", "netty-handler", "4.1.94.Final"),
+		pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"),
+	)}
+	analysis, err := AnalyzeProject(ctx, project)
+	require.NoError(t, err)
+	patch := Patch{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.100.Final", Type: "pom", Scope: "import", Operation: PatchOperationImport}
+
+	direct, _ := PatchStrategy(ctx, analysis, []Patch{patch})
+	assert.Equal(t, []Patch{patch}, direct)
+
+	safe, _, plan := QuarantineRisky(ctx, analysis, project, direct, nil)
+	assert.Empty(t, safe)
+	require.Len(t, plan.Patches, 1)
+	assert.Equal(t, RiskBOMIntroduction, plan.Patches[0].Reason)
+}
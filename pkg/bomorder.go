@@ -0,0 +1,101 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+)
+
+// PatchOperationReorder moves a BOM already imported in <dependencyManagement>
+// to Position, bumping its version too if the patch has one. It fails if the
+// BOM is not imported.
+const PatchOperationReorder = "reorder"
+
+// WarningBOMShadowed is a BOM managing artifacts at a version that an
+// earlier BOM managing them too overrides.
+const WarningBOMShadowed = "bom-shadowed"
+
+// reorderBOM applies a reorder patch to project.
+func reorderBOM(ctx context.Context, project *gopom.Project, patch Patch) error {
+	log := clog.FromContext(ctx)
+	patch.Type, patch.Scope = "pom", "import"
+	var deps *[]gopom.Dependency
+	props := coordinateProperties(project)
+	from := -1
+	if dm := project.DependencyManagement; dm != nil && dm.Dependencies != nil {
+		deps = dm.Dependencies
+		if matches := exactDependencies(*deps, patch, props); len(matches) > 0 {
+			from = matches[0]
+		}
+	}
+	if from < 0 {
+		return fmt.Errorf("failed to reorder BOM %s.%s: it is not imported", patch.GroupID, patch.ArtifactID)
+	}
+
+	bom := (*deps)[from]
+	if patch.Version != "" {
+		log.Infof("Patching BOM %s.%s from %s to %s", patch.GroupID, patch.ArtifactID, bom.Version, patch.Version)
+		patchDependency(&bom, patch, props)
+	}
+	rest := slices.Delete(slices.Clone(*deps), from, from+1)
+	at, err := positionIndex(rest, patch.Position)
+	if err != nil {
+		return fmt.Errorf("failed to reorder BOM %s.%s: %w", patch.GroupID, patch.ArtifactID, err)
+	}
+	log.Infof("Moving BOM %s.%s from position %d to %d of dependencyManagement", patch.GroupID, patch.ArtifactID, from, at)
+	*deps = slices.Insert(rest, at, bom)
+	return nil
+}
+
+// ShadowedBOMVersions warns, for every BOM managing artifacts an earlier BOM
+// manages at another version, about the versions that never apply because
+// Maven takes the first one. Only BOMs resolved by ResolveBOMs are
+// considered.
+func (result *AnalysisResult) ShadowedBOMVersions() []Warning {
+	warnings, _ := result.suppressions.Warnings(result.shadowedBOMVersions())
+	return warnings
+}
+
+// shadowedBOMVersions is ShadowedBOMVersions, suppressed ones included.
+func (result *AnalysisResult) shadowedBOMVersions() []Warning {
+	boms := result.BOMs()
+	warnings := []Warning{}
+	for j, later := range boms {
+		// The BOM the version comes from is the first one managing it.
+		shadowing := map[int][]VersionChange{}
+		for key, version := range later.Managed {
+			for i, earlier := range boms[:j] {
+				if effective, ok := earlier.Managed[key]; ok {
+					if effective != version {
+						shadowing[i] = append(shadowing[i], VersionChange{Name: key, Old: effective, New: version})
+					}
+					break
+				}
+			}
+		}
+		for i, earlier := range boms[:j] {
+			changes := shadowing[i]
+			if len(changes) == 0 {
+				continue
+			}
+			sort.Slice(changes, func(a, b int) bool { return changes[a].Name < changes[b].Name })
+			names := make([]string, 0, len(changes))
+			for _, c := range changes {
+				names = append(names, fmt.Sprintf("%s (%s, not %s)", c.Name, c.Old, c.New))
+			}
+			warnings = append(warnings, Warning{
+				Code: CodeBOMShadowed,
+				Kind: WarningBOMShadowed,
+				Message: fmt.Sprintf("BOM %s:%s is imported after %s:%s, which wins for %d artifacts: %s",
+					later.GroupID, later.ArtifactID, earlier.GroupID, earlier.ArtifactID, len(changes), strings.Join(names, ", ")),
+				Artifacts: changes,
+			})
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,47 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowedBOMVersions(t *testing.T) {
+	analysis, err := AnalyzeProject(context.Background(), &gopom.Project{DependencyManagement: makeManaged(
+		pomDep("org.slf4j", "slf4j-api", "2.0.7"),
+		pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"),
+		pomDep("com.fasterxml.jackson", "jackson-bom", "2.15.0", "import", "pom"),
+		pomDep("io.netty", "netty-bom", "4.1.94.Final", "import", "pom"),
+	)})
+	require.NoError(t, err)
+	assert.Empty(t, analysis.ShadowedBOMVersions())
+
+	boms := analysis.BOMs()
+	boms[0].Managed = map[string]string{
+		"io.netty:netty-handler":                  "4.1.101.Final",
+		"com.fasterxml.jackson.core:jackson-core": "2.15.3",
+	}
+	boms[1].Managed = map[string]string{
+		"com.fasterxml.jackson.core:jackson-core":     "2.15.0",
+		"com.fasterxml.jackson.core:jackson-databind": "2.15.0",
+	}
+	boms[2].Managed = map[string]string{
+		"io.netty:netty-handler": "4.1.94.Final",
+		// The same version, nothing is lost.
+		"com.fasterxml.jackson.core:jackson-core": "2.15.3",
+	}
+
+	warnings := analysis.ShadowedBOMVersions()
+	require.Len(t, warnings, 2)
+	assert.Equal(t, Warning{
+		Code:      CodeBOMShadowed,
+		Kind:      WarningBOMShadowed,
+		Message:   "BOM com.fasterxml.jackson:jackson-bom is imported after org.springframework.boot:spring-boot-dependencies, which wins for 1 artifacts: com.fasterxml.jackson.core:jackson-core (2.15.3, not 2.15.0)",
+		Artifacts: []VersionChange{{Name: "com.fasterxml.jackson.core:jackson-core", Old: "2.15.3", New: "2.15.0"}},
+	}, warnings[0])
+	assert.Equal(t, []VersionChange{{Name: "io.netty:netty-handler", Old: "4.1.101.Final", New: "4.1.94.Final"}}, warnings[1].Artifacts)
+	assert.Contains(t, analysis.AnalysisReport(), "Warning: [POMBUMP-W002] BOM io.netty:netty-bom is imported after org.springframework.boot:spring-boot-dependencies")
+}
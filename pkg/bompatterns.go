@@ -0,0 +1,128 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// BOMPatterns tells BOMForGroup which imported BOM manages a group when that
+// can not be guessed from the coordinates, e.g. spring-boot-dependencies or
+// quarkus-universe-bom. It is usually read from a file with LoadBOMPatterns:
+//
+//	boms:
+//	  - org.springframework.boot:spring-boot-dependencies
+//	  - io.quarkus:quarkus-*
+//	groups:
+//	  org.springframework.*: org.springframework.boot:spring-boot-dependencies
+//	  com.fasterxml.jackson.*: com.fasterxml.jackson:jackson-bom
+type BOMPatterns struct {
+	// BOMs are groupId:artifactId patterns of known BOMs, on top of the
+	// artifacts following the *-bom convention. They manage the artifacts
+	// of their own group.
+	BOMs []string `json:"boms,omitempty" yaml:"boms,omitempty"`
+	// Groups maps groupId patterns to the groupId:artifactId of the BOM
+	// managing them. They win over BOMs.
+	Groups map[string]string `json:"groups,omitempty" yaml:"groups,omitempty"`
+}
+
+// LoadBOMPatterns reads BOM patterns from a YAML or JSON file.
+func LoadBOMPatterns(file string) (*BOMPatterns, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read BOM patterns file: %w", err)
+	}
+	patterns := &BOMPatterns{}
+	if err := yaml.Unmarshal(data, patterns); err != nil {
+		return nil, fmt.Errorf("failed to parse BOM patterns file: %w", err)
+	}
+	for _, pattern := range patterns.BOMs {
+		if err := validateBOMCoordinates(pattern); err != nil {
+			return nil, err
+		}
+	}
+	for group, bom := range patterns.Groups {
+		if _, err := path.Match(group, ""); group == "" || err != nil {
+			return nil, fmt.Errorf("invalid group pattern %q mapped to BOM %s", group, bom)
+		}
+		if err := validateBOMCoordinates(bom); err != nil {
+			return nil, err
+		}
+	}
+	return patterns, nil
+}
+
+// WithBOMPatterns makes BOMForGroup use patterns on top of the *-bom
+// convention.
+func WithBOMPatterns(patterns *BOMPatterns) AnalyzeOption {
+	return func(o *analyzeOptions) {
+		o.bomPatterns = patterns
+	}
+}
+
+func validateBOMCoordinates(coordinates string) error {
+	groupID, artifactID, ok := strings.Cut(coordinates, ":")
+	if !ok || groupID == "" || artifactID == "" {
+		return fmt.Errorf("invalid BOM coordinates %q, expected groupId:artifactId", coordinates)
+	}
+	if _, err := path.Match(coordinates, ""); err != nil {
+		return fmt.Errorf("invalid BOM pattern %q: %w", coordinates, err)
+	}
+	return nil
+}
+
+// BOMForGroup returns the imported BOM most likely to manage the artifacts
+// of groupID, without resolving any BOM: the one the BOM patterns map the
+// group to or, failing that, the first BOM of the same group that is either
+// a known BOM or follows the *-bom convention. Only ManagingBOM knows for
+// sure, once the BOMs are resolved.
+func (result *AnalysisResult) BOMForGroup(groupID string) (BOMInfo, bool) {
+	boms := result.BOMs()
+	if patterns := result.bomPatterns; patterns != nil {
+		// The most specific group pattern wins.
+		best, bestLength := "", -1
+		for group, bom := range patterns.Groups {
+			if matchGlob(group, groupID) && len(group) > bestLength {
+				best, bestLength = bom, len(group)
+			}
+		}
+		for _, bom := range boms {
+			if best != "" && matchGlob(best, bom.GroupID+":"+bom.ArtifactID) {
+				return bom, true
+			}
+		}
+	}
+	for _, bom := range boms {
+		if bom.GroupID == groupID && result.isKnownBOM(bom) {
+			return bom, true
+		}
+	}
+	return BOMInfo{}, false
+}
+
+// isKnownBOM reports whether bom follows the *-bom convention or matches the
+// BOM patterns.
+func (result *AnalysisResult) isKnownBOM(bom BOMInfo) bool {
+	if strings.HasSuffix(bom.ArtifactID, "-bom") {
+		return true
+	}
+	if result.bomPatterns == nil {
+		return false
+	}
+	for _, pattern := range result.bomPatterns.BOMs {
+		if matchGlob(pattern, bom.GroupID+":"+bom.ArtifactID) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches name against a path.Match pattern. The coordinates have
+// no separators, so * matches dots and colons alike.
+func matchGlob(pattern, name string) bool {
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
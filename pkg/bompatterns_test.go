@@ -0,0 +1,114 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBOMForGroup(t *testing.T) {
+	patterns := &BOMPatterns{
+		BOMs: []string{"org.springframework.boot:spring-boot-dependencies"},
+		Groups: map[string]string{
+			"org.springframework*":         "org.springframework.boot:spring-boot-dependencies",
+			"org.springframework.security": "org.springframework.security:spring-security-bom",
+			"io.quarkus*":                  "io.quarkus:quarkus-*-bom",
+		},
+	}
+
+	project := &gopom.Project{
+		DependencyManagement: makeManaged(
+			pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"),
+			pomDep("io.quarkus", "quarkus-universe-bom", "3.6.0", "import", "pom"),
+			pomDep("io.netty", "netty-bom", "4.1.94.Final", "import", "pom"),
+			pomDep("com.example", "platform", "1.0", "import", "pom"),
+		),
+	}
+
+	tests := []struct {
+		name     string
+		patterns *BOMPatterns
+		groupID  string
+		want     string
+	}{
+		{name: "convention", groupID: "io.netty", want: "io.netty:netty-bom"},
+		{name: "convention needs the same group", groupID: "io.netty.incubator"},
+		{name: "not named like a BOM", groupID: "com.example"},
+		{name: "unknown without patterns", groupID: "org.springframework.boot"},
+		{name: "known BOM", patterns: &BOMPatterns{BOMs: patterns.BOMs}, groupID: "org.springframework.boot", want: "org.springframework.boot:spring-boot-dependencies"},
+		{name: "group mapping", patterns: patterns, groupID: "org.springframework", want: "org.springframework.boot:spring-boot-dependencies"},
+		{name: "group pattern matching the BOM", patterns: patterns, groupID: "io.quarkus.arc", want: "io.quarkus:quarkus-universe-bom"},
+		// The most specific mapping is to a BOM not imported.
+		{name: "mapped BOM not imported", patterns: patterns, groupID: "org.springframework.security"},
+		{name: "convention with patterns", patterns: patterns, groupID: "io.netty", want: "io.netty:netty-bom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis, err := AnalyzeProject(context.Background(), project, WithBOMPatterns(tt.patterns))
+			require.NoError(t, err)
+			bom, ok := analysis.BOMForGroup(tt.groupID)
+			if tt.want == "" {
+				assert.False(t, ok, "got %s:%s", bom.GroupID, bom.ArtifactID)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tt.want, bom.GroupID+":"+bom.ArtifactID)
+		})
+	}
+}
+
+func TestBOMForGroupReport(t *testing.T) {
+	patterns := &BOMPatterns{Groups: map[string]string{"org.springframework": "org.springframework.boot:spring-boot-dependencies"}}
+	analysis, err := AnalyzeProject(context.Background(), &gopom.Project{
+		DependencyManagement: makeManaged(pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom")),
+		Dependencies:         makeDeps(pomDep("org.springframework", "spring-core", "")),
+	}, WithBOMPatterns(patterns))
+	require.NoError(t, err)
+	assert.Contains(t, analysis.AnalysisReport(), "org.springframework:spring-core: probably managed by BOM org.springframework.boot:spring-boot-dependencies")
+}
+
+func TestLoadBOMPatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    *BOMPatterns
+		wantErr bool
+	}{{
+		name: "valid",
+		content: `boms:
+  - org.springframework.boot:spring-boot-dependencies
+groups:
+  io.quarkus*: io.quarkus:quarkus-universe-bom
+`,
+		want: &BOMPatterns{
+			BOMs:   []string{"org.springframework.boot:spring-boot-dependencies"},
+			Groups: map[string]string{"io.quarkus*": "io.quarkus:quarkus-universe-bom"},
+		},
+	}, {
+		name:    "missing artifactId",
+		content: "boms:\n  - org.springframework.boot\n",
+		wantErr: true,
+	}, {
+		name:    "bad pattern",
+		content: "groups:\n  \"io.[quarkus\": io.quarkus:quarkus-bom\n",
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "boms.yaml")
+			require.NoError(t, os.WriteFile(file, []byte(tt.content), 0644))
+			got, err := LoadBOMPatterns(file)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// minimumPrefix marks a version as a minimum, e.g. >=4.1.115.Final.
+const minimumPrefix = ">="
+
+// Reasons a candidate version was chosen.
+const (
+	ReasonAlreadySatisfied = "already satisfied by the version in use"
+	ReasonFirstPublished   = "first acceptable version published in the repository"
+	ReasonLowestPublished  = "lowest published release satisfying the minimum"
+	ReasonNotVerified      = "repository not available, picked without checking"
+)
+
+// CandidateChoice records which of the acceptable versions of a patch was
+// picked, and why.
+type CandidateChoice struct {
+	GroupID    string `json:"groupId" yaml:"groupId"`
+	ArtifactID string `json:"artifactId" yaml:"artifactId"`
+	Requested  string `json:"requested" yaml:"requested"`
+	Chosen     string `json:"chosen" yaml:"chosen"`
+	Reason     string `json:"reason" yaml:"reason"`
+}
+
+// IsVersionCandidates reports whether version lists several acceptable
+// versions (4.1.118.Final | 4.1.119.Final) or a minimum (>=4.1.115.Final)
+// rather than a single version.
+func IsVersionCandidates(version string) bool {
+	return strings.Contains(version, "|") || strings.HasPrefix(strings.TrimSpace(version), minimumPrefix)
+}
+
+// ResolveVersionCandidates picks an actual version for every patch listing
+// acceptable versions or a minimum. The version currently in use (current
+// maps groupId:artifactId to it, e.g. as managed by a BOM) is kept if it is
+// acceptable. Otherwise, from an ordered list the first version published
+// in repo wins, for a minimum the lowest published release at or above it.
+func ResolveVersionCandidates(ctx context.Context, repo *MavenRepository, patches []Patch, current map[string]string) (_ []Patch, _ []CandidateChoice, err error) {
+	ctx, end := startSpan(ctx, OperationResolve, attribute.String("pombump.resolver", "candidates"))
+	defer func() { end(err) }()
+	log := clog.FromContext(ctx)
+
+	resolved := make([]Patch, 0, len(patches))
+	choices := []CandidateChoice{}
+	for _, p := range patches {
+		if !IsVersionCandidates(p.Version) {
+			resolved = append(resolved, p)
+			continue
+		}
+		key := fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID)
+		choice, err := chooseCandidate(ctx, repo, p, current[key])
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to pick a version of %s from %q: %w", key, p.Version, err)
+		}
+		log.Infof("Picked %s for %s from %q: %s", choice.Chosen, key, p.Version, choice.Reason)
+		choices = append(choices, choice)
+		p.Version = choice.Chosen
+		resolved = append(resolved, p)
+	}
+	return resolved, choices, nil
+}
+
+func chooseCandidate(ctx context.Context, repo *MavenRepository, p Patch, current string) (CandidateChoice, error) {
+	choice := CandidateChoice{GroupID: p.GroupID, ArtifactID: p.ArtifactID, Requested: p.Version}
+	requested := strings.TrimSpace(p.Version)
+
+	if strings.HasPrefix(requested, minimumPrefix) {
+		minimum := strings.TrimSpace(strings.TrimPrefix(requested, minimumPrefix))
+		if minimum == "" {
+			return choice, fmt.Errorf("missing version after %s", minimumPrefix)
+		}
+		if current != "" && compareVersions(current, minimum) >= 0 {
+			choice.Chosen, choice.Reason = current, ReasonAlreadySatisfied
+			return choice, nil
+		}
+		published, err := repo.Versions(ctx, p.GroupID, p.ArtifactID)
+		if err != nil {
+			clog.FromContext(ctx).Warnf("Unable to list versions of %s:%s: %v", p.GroupID, p.ArtifactID, err)
+			choice.Chosen, choice.Reason = minimum, ReasonNotVerified
+			return choice, nil
+		}
+		for _, v := range published {
+			if isPreRelease(v) || compareVersions(v, minimum) < 0 {
+				continue
+			}
+			if choice.Chosen == "" || compareVersions(v, choice.Chosen) < 0 {
+				choice.Chosen = v
+			}
+		}
+		if choice.Chosen == "" {
+			return choice, fmt.Errorf("no published release at or above %s", minimum)
+		}
+		choice.Reason = ReasonLowestPublished
+		return choice, nil
+	}
+
+	candidates := []string{}
+	for _, c := range strings.Split(requested, "|") {
+		if c = strings.TrimSpace(c); c != "" {
+			candidates = append(candidates, c)
+		}
+	}
+	if len(candidates) == 0 {
+		return choice, fmt.Errorf("no candidate versions")
+	}
+	if slices.Contains(candidates, current) {
+		choice.Chosen, choice.Reason = current, ReasonAlreadySatisfied
+		return choice, nil
+	}
+	published, err := repo.Versions(ctx, p.GroupID, p.ArtifactID)
+	if err != nil {
+		clog.FromContext(ctx).Warnf("Unable to list versions of %s:%s: %v", p.GroupID, p.ArtifactID, err)
+		choice.Chosen, choice.Reason = candidates[0], ReasonNotVerified
+		return choice, nil
+	}
+	for _, c := range candidates {
+		if slices.Contains(published, c) {
+			choice.Chosen, choice.Reason = c, ReasonFirstPublished
+			return choice, nil
+		}
+	}
+	return choice, fmt.Errorf("none of the candidates is published")
+}
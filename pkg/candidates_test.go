@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveVersionCandidates(t *testing.T) {
+	repo := fakeRepository(t, map[string]string{
+		"io/netty/netty-handler/maven-metadata.xml": nettyMetadata,
+	})
+
+	tests := []struct {
+		name       string
+		version    string
+		current    string
+		wantChoice string
+		wantReason string
+		wantErr    bool
+	}{
+		{name: "first published candidate", version: "4.1.101.Final | 4.1.100.Final | 4.2.0.Final", wantChoice: "4.1.100.Final", wantReason: ReasonFirstPublished},
+		{name: "current version is a candidate", version: "4.1.100.Final|4.1.94.Final", current: "4.1.94.Final", wantChoice: "4.1.94.Final", wantReason: ReasonAlreadySatisfied},
+		{name: "no candidate published", version: "4.1.101.Final|4.1.102.Final", wantErr: true},
+		{name: "minimum", version: ">=4.1.95.Final", current: "4.1.94.Final", wantChoice: "4.1.100.Final", wantReason: ReasonLowestPublished},
+		{name: "minimum skips pre-releases", version: ">=4.1.101.Final", wantChoice: "4.2.0.Final", wantReason: ReasonLowestPublished},
+		{name: "minimum already satisfied", version: ">= 4.1.90.Final", current: "4.1.94.Final", wantChoice: "4.1.94.Final", wantReason: ReasonAlreadySatisfied},
+		{name: "minimum above everything", version: ">=6.0.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patches := []Patch{
+				{GroupID: "io.netty", ArtifactID: "netty-handler", Version: tt.version},
+				{GroupID: "junit", ArtifactID: "junit", Version: "4.13.2"},
+			}
+			current := map[string]string{}
+			if tt.current != "" {
+				current["io.netty:netty-handler"] = tt.current
+			}
+			resolved, choices, err := ResolveVersionCandidates(context.Background(), repo, patches, current)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantChoice, resolved[0].Version)
+			assert.Equal(t, patches[1], resolved[1])
+			assert.Equal(t, []CandidateChoice{{
+				GroupID: "io.netty", ArtifactID: "netty-handler", Requested: tt.version, Chosen: tt.wantChoice, Reason: tt.wantReason,
+			}}, choices)
+		})
+	}
+}
+
+func TestResolveVersionCandidatesWithoutRepository(t *testing.T) {
+	repo := fakeRepository(t, map[string]string{})
+	patches := []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final|4.1.119.Final"}}
+	resolved, choices, err := ResolveVersionCandidates(context.Background(), repo, patches, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "4.1.118.Final", resolved[0].Version)
+	assert.Equal(t, ReasonNotVerified, choices[0].Reason)
+}
@@ -0,0 +1,105 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chainguard-dev/gopom"
+)
+
+// CheckResult tells whether a POM satisfies a single patch or property
+// patch.
+type CheckResult struct {
+	// Name is groupId:artifactId for patches, the property name for
+	// property patches.
+	Name      string `json:"name" yaml:"name"`
+	Requested string `json:"requested" yaml:"requested"`
+	// Actual is the effective version in the POM, empty if not found.
+	Actual    string `json:"actual,omitempty" yaml:"actual,omitempty"`
+	Satisfied bool   `json:"satisfied" yaml:"satisfied"`
+	// Code is CodeVerificationFailed when the patch is not satisfied.
+	Code string `json:"code,omitempty" yaml:"code,omitempty"`
+}
+
+// CheckOption configures CheckPatches.
+type CheckOption func(*checkOptions)
+
+type checkOptions struct {
+	repo *MavenRepository
+}
+
+// WithBOMResolution makes CheckPatches resolve the BOMs project imports from
+// repo, so that a version a BOM manages satisfies a patch too.
+func WithBOMResolution(repo *MavenRepository) CheckOption {
+	return func(o *checkOptions) {
+		o.repo = repo
+	}
+}
+
+// CheckPatches checks that project already satisfies every patch and
+// property patch, that is the effective version is at least the requested
+// one. A range satisfies a version when it only allows that version or
+// later, requested ranges must match exactly.
+func CheckPatches(ctx context.Context, project *gopom.Project, patches []Patch, propertyPatches map[string]string, opts ...CheckOption) ([]CheckResult, error) {
+	options := checkOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	analyzeOpts := []AnalyzeOption{WithoutBOMDetection()}
+	if options.repo != nil {
+		analyzeOpts = nil
+	}
+	analysis, err := AnalyzeProject(ctx, project, analyzeOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if options.repo != nil {
+		analysis.ResolveBOMs(ctx, options.repo)
+	}
+	current := analysis.CurrentVersions()
+	if project.Parent != nil {
+		current[fmt.Sprintf("%s:%s", project.Parent.GroupID, project.Parent.ArtifactID)] = project.Parent.Version
+	}
+
+	results := []CheckResult{}
+	for _, p := range patches {
+		name := fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID)
+		actual, ok := current[name]
+		if !ok {
+			// Not declared, but the version may still be managed.
+			if managed, found := analysis.ManagedVersion(p.GroupID, p.ArtifactID); found {
+				actual = managed.Version
+			}
+		}
+		results = append(results, checkVersion(name, p.Version, actual))
+	}
+	properties := make([]string, 0, len(propertyPatches))
+	for name := range propertyPatches {
+		properties = append(properties, name)
+	}
+	sort.Strings(properties)
+	for _, name := range properties {
+		results = append(results, checkVersion(name, propertyPatches[name], analysis.Properties[name]))
+	}
+	return results, nil
+}
+
+func checkVersion(name, requested, actual string) CheckResult {
+	result := CheckResult{Name: name, Requested: requested, Actual: actual}
+	switch {
+	case actual == "" || strings.Contains(actual, "${"):
+		result.Satisfied = false
+	case isVersionRange(actual) && !isVersionRange(requested):
+		result.Satisfied = rangeAtLeast(actual, requested)
+	case isVersionRange(requested) || isVersionRange(actual):
+		result.Satisfied = requested == actual
+	default:
+		result.Satisfied = compareVersions(actual, requested) >= 0
+	}
+	if !result.Satisfied {
+		result.Code = CodeVerificationFailed
+	}
+	return result
+}
@@ -0,0 +1,309 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CIReport is the outcome of a full analyze -> plan -> apply -> verify run,
+// as produced by `pombump ci`.
+type CIReport struct {
+	POM                         string           `json:"pom"`
+	Dependencies                int              `json:"dependencies"`
+	DependenciesUsingProperties int              `json:"dependenciesUsingProperties"`
+	Properties                  int              `json:"properties"`
+	DirectPatches               []Patch          `json:"directPatches"`
+	PropertyPatches             []PropertyPatch  `json:"propertyPatches"`
+	Unfixable                   []UnfixableIssue `json:"unfixable,omitempty"`
+	Verification                []CheckResult    `json:"verification"`
+	// Candidates records which version was picked for patches listing
+	// several acceptable ones.
+	Candidates []CandidateChoice `json:"candidates,omitempty"`
+	// Quarantined are the risky changes held back for human review.
+	Quarantined *QuarantinePlan `json:"quarantined,omitempty"`
+	// Advisories are the NVD details of the CVEs patched, if looked up
+	// (see LookupCVEs).
+	Advisories []CVEDetails `json:"advisories,omitempty"`
+	// Suppressed are the warnings, issues and patches left out of the
+	// report, listed for auditing.
+	Suppressed []SuppressedIssue `json:"suppressed,omitempty"`
+	// Skipped are the patches dropped due to policy, such as the strategy
+	// rules, or as their version is already in effect.
+	Skipped []SkippedPatch `json:"skipped,omitempty"`
+	Passed  bool           `json:"passed"`
+}
+
+// NewCIReport puts together the report for a CI run.
+func NewCIReport(pom string, analysis *AnalysisResult, directPatches []Patch, propertyPatches map[string]string, unfixable []UnfixableIssue, verification []CheckResult) *CIReport {
+	analysis.ensureDependencies()
+	report := &CIReport{
+		POM:                         pom,
+		Dependencies:                len(analysis.Dependencies),
+		DependenciesUsingProperties: countPropertiesUsage(analysis),
+		Properties:                  len(analysis.Properties),
+		DirectPatches:               directPatches,
+		PropertyPatches:             SortedPropertyPatches(propertyPatches),
+		Unfixable:                   unfixable,
+		Verification:                verification,
+		Suppressed:                  analysis.SuppressedIssues(),
+		Passed:                      len(unfixable) == 0,
+	}
+	for _, v := range verification {
+		if !v.Satisfied {
+			report.Passed = false
+		}
+	}
+	return report
+}
+
+// SortedPropertyPatches turns a property patch map into a list sorted by
+// property name.
+func SortedPropertyPatches(propertyPatches map[string]string) []PropertyPatch {
+	props := make([]PropertyPatch, 0, len(propertyPatches))
+	for k, v := range propertyPatches {
+		props = append(props, PropertyPatch{Property: k, Value: v})
+	}
+	sort.Slice(props, func(i, j int) bool { return props[i].Property < props[j].Property })
+	return props
+}
+
+// JSON renders the report as indented JSON.
+func (r *CIReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Markdown renders the report as markdown, suitable for a PR comment or a
+// CI job summary.
+func (r *CIReport) Markdown() string {
+	var md strings.Builder
+
+	status := "✅ passed"
+	if !r.Passed {
+		status = "❌ failed"
+	}
+	md.WriteString(fmt.Sprintf("# pombump report for `%s`: %s\n\n", r.POM, status))
+	md.WriteString(fmt.Sprintf("%d dependencies (%d using properties), %d properties.\n\n",
+		r.Dependencies, r.DependenciesUsingProperties, r.Properties))
+
+	if len(r.PropertyPatches) > 0 {
+		md.WriteString("## Property updates\n\n| Property | Version |\n| --- | --- |\n")
+		for _, p := range r.PropertyPatches {
+			md.WriteString(fmt.Sprintf("| `%s` | %s |\n", p.Property, p.Value))
+		}
+		md.WriteString("\n")
+	}
+
+	if len(r.DirectPatches) > 0 {
+		md.WriteString("## Direct dependency updates\n\n| Dependency | Version |\n| --- | --- |\n")
+		for _, p := range r.DirectPatches {
+			md.WriteString(fmt.Sprintf("| `%s:%s` | %s |\n", p.GroupID, p.ArtifactID, p.Version))
+		}
+		md.WriteString("\n")
+	}
+
+	if len(r.Unfixable) > 0 {
+		md.WriteString("## Unfixable\n\n| Dependency | Version | Reason | Code |\n| --- | --- | --- | --- |\n")
+		for _, u := range r.Unfixable {
+			md.WriteString(fmt.Sprintf("| `%s:%s` | %s | %s | %s |\n", u.GroupID, u.ArtifactID, u.Version, u.Reason, u.Code))
+		}
+		md.WriteString("\n")
+	}
+
+	if r.Quarantined != nil && !r.Quarantined.Empty() {
+		md.WriteString("## Quarantined for review\n\n| Change | Current | Requested | Reason |\n| --- | --- | --- | --- |\n")
+		for _, p := range r.Quarantined.Patches {
+			md.WriteString(fmt.Sprintf("| `%s:%s` | %s | %s | %s |\n", p.GroupID, p.ArtifactID, p.Current, p.Version, p.Reason))
+		}
+		for _, p := range r.Quarantined.Properties {
+			md.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s |\n", p.Property, p.Current, p.Value, p.Reason))
+		}
+		md.WriteString("\n")
+	}
+
+	if len(r.Advisories) > 0 {
+		md.WriteString("## Advisories\n\n| CVE | Score | Severity | Published |\n| --- | --- | --- | --- |\n")
+		for _, a := range r.Advisories {
+			md.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", a.ID, formatScore(a.Score), a.Severity, a.Published))
+		}
+		md.WriteString("\n")
+	}
+
+	if len(r.Skipped) > 0 {
+		md.WriteString("## Skipped\n\n| Name | Current | Version | Reason |\n| --- | --- | --- | --- |\n")
+		for _, s := range r.Skipped {
+			md.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", s.Name, s.Current, s.Version, s.Reason))
+		}
+		md.WriteString("\n")
+	}
+
+	if len(r.Suppressed) > 0 {
+		md.WriteString("## Suppressed\n\n| Code | Issue | Suppressed by |\n| --- | --- | --- |\n")
+		for _, s := range r.Suppressed {
+			md.WriteString(fmt.Sprintf("| %s | %s | %s |\n", s.Code, s.Message, s.By))
+		}
+		md.WriteString("\n")
+	}
+
+	if len(r.Verification) > 0 {
+		md.WriteString("## Verification\n\n| Name | Requested | Actual | Result |\n| --- | --- | --- | --- |\n")
+		for _, v := range r.Verification {
+			result := "✅"
+			if !v.Satisfied {
+				result = "❌"
+			}
+			actual := v.Actual
+			if actual == "" {
+				actual = "(not found)"
+			}
+			md.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s |\n", v.Name, v.Requested, actual, result))
+		}
+	}
+
+	return md.String()
+}
+
+// SARIF rule ids used in the report.
+const (
+	sarifRuleDependencyUpdate   = "dependency-update"
+	sarifRulePropertyUpdate     = "property-update"
+	sarifRuleUnfixable          = "unfixable"
+	sarifRuleVerificationFailed = "verification-failed"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	// Properties carries the NVD details of the CVEs a result fixes, and
+	// the highest of their scores as security-severity, which code scanning
+	// UIs sort by, or the code of an error, e.g. POMBUMP-E001.
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+	} `json:"physicalLocation"`
+}
+
+// SARIF renders the report as a SARIF 2.1.0 log, so that code scanning UIs
+// can show the findings.
+func (r *CIReport) SARIF() ([]byte, error) {
+	location := sarifLocation{}
+	location.PhysicalLocation.ArtifactLocation.URI = r.POM
+	result := func(rule, level, text string) sarifResult {
+		return sarifResult{RuleID: rule, Level: level, Message: sarifMessage{Text: text}, Locations: []sarifLocation{location}}
+	}
+
+	results := []sarifResult{}
+	for _, p := range r.PropertyPatches {
+		results = append(results, result(sarifRulePropertyUpdate, "note", fmt.Sprintf("Property %s updated to %s", p.Property, p.Value)))
+	}
+	for _, p := range r.DirectPatches {
+		res := result(sarifRuleDependencyUpdate, "note", fmt.Sprintf("Dependency %s:%s updated to %s", p.GroupID, p.ArtifactID, p.Version))
+		if cves := r.advisoryDetails(p); len(cves) > 0 {
+			score := 0.0
+			for _, cve := range cves {
+				score = max(score, cve.Score)
+			}
+			res.Properties = map[string]any{"cves": cves}
+			if score > 0 {
+				res.Properties["security-severity"] = formatScore(score)
+			}
+		}
+		results = append(results, res)
+	}
+	for _, u := range r.Unfixable {
+		res := result(sarifRuleUnfixable, "error", fmt.Sprintf("Unable to patch %s:%s to %s: %s", u.GroupID, u.ArtifactID, u.Version, u.Reason))
+		res.Properties = map[string]any{"code": u.Code}
+		results = append(results, res)
+	}
+	for _, v := range r.Verification {
+		if !v.Satisfied {
+			actual := v.Actual
+			if actual == "" {
+				actual = "not found"
+			}
+			res := result(sarifRuleVerificationFailed, "error", fmt.Sprintf("%s is %s after patching, wanted at least %s", v.Name, actual, v.Requested))
+			res.Properties = map[string]any{"code": v.Code}
+			results = append(results, res)
+		}
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "pombump",
+				InformationURI: "https://github.com/chainguard-dev/pombump",
+				Rules: []sarifRule{
+					{ID: sarifRuleDependencyUpdate, ShortDescription: sarifMessage{Text: "Dependency version updated"}},
+					{ID: sarifRulePropertyUpdate, ShortDescription: sarifMessage{Text: "Property updated"}},
+					{ID: sarifRuleUnfixable, ShortDescription: sarifMessage{Text: "Requested patch can not be applied"}},
+					{ID: sarifRuleVerificationFailed, ShortDescription: sarifMessage{Text: "Patched POM does not have the requested version"}},
+				},
+			}},
+			Results: results,
+		}},
+	}
+	return json.MarshalIndent(log, "", "  ")
+}
+
+// advisoryDetails returns the NVD details in the report of the advisories p
+// fixes.
+func (r *CIReport) advisoryDetails(p Patch) []CVEDetails {
+	details := []CVEDetails{}
+	for _, a := range r.Advisories {
+		if slices.Contains(p.Advisories, a.ID) {
+			details = append(details, a)
+		}
+	}
+	return details
+}
+
+// formatScore formats a CVSS score the way the NVD does, or as empty if the
+// CVE is not scored.
+func formatScore(score float64) string {
+	if score == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(score, 'f', 1, 64)
+}
@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPatches(t *testing.T) {
+	project := &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{"netty.version": "4.1.94.Final"}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "${netty.version}"},
+			{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.9"},
+			{GroupID: "ch.qos.logback", ArtifactID: "logback-core", Version: "[1.4.12,2.0.0)"},
+		},
+	}
+	patches := []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.94.Final"},
+		{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.10"},
+		{GroupID: "ch.qos.logback", ArtifactID: "logback-core", Version: "[1.4.12,2.0.0)"},
+		{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "4.1.94.Final"},
+	}
+	results, err := CheckPatches(context.Background(), project, patches, map[string]string{"netty.version": "4.1.90.Final"})
+	require.NoError(t, err)
+	assert.Equal(t, []CheckResult{
+		{Name: "io.netty:netty-handler", Requested: "4.1.94.Final", Actual: "4.1.94.Final", Satisfied: true},
+		{Name: "org.slf4j:slf4j-api", Requested: "2.0.10", Actual: "2.0.9", Satisfied: false, Code: CodeVerificationFailed},
+		{Name: "ch.qos.logback:logback-core", Requested: "[1.4.12,2.0.0)", Actual: "[1.4.12,2.0.0)", Satisfied: true},
+		{Name: "io.netty:netty-codec", Requested: "4.1.94.Final", Code: CodeVerificationFailed},
+		{Name: "netty.version", Requested: "4.1.90.Final", Actual: "4.1.94.Final", Satisfied: true},
+	}, results)
+}
+
+func TestCIReport(t *testing.T) {
+	analysis := &AnalysisResult{
+		Dependencies: map[string]*DependencyInfo{},
+		Properties:   map[string]string{"netty.version": "4.1.94.Final"},
+	}
+	report := NewCIReport("pom.xml", analysis,
+		[]Patch{{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.10"}},
+		map[string]string{"netty.version": "4.1.100.Final"},
+		nil,
+		[]CheckResult{
+			{Name: "org.slf4j:slf4j-api", Requested: "2.0.10", Actual: "2.0.9"},
+			{Name: "netty.version", Requested: "4.1.100.Final", Actual: "4.1.100.Final", Satisfied: true},
+		})
+	assert.False(t, report.Passed)
+	assert.Equal(t, []PropertyPatch{{Property: "netty.version", Value: "4.1.100.Final"}}, report.PropertyPatches)
+
+	md := report.Markdown()
+	assert.Contains(t, md, "❌ failed")
+	assert.Contains(t, md, "| `org.slf4j:slf4j-api` | 2.0.10 | 2.0.9 | ❌ |")
+	assert.NotContains(t, md, "## Suppressed")
+
+	assert.NotContains(t, md, "## Skipped\n")
+	report.Skipped = []SkippedPatch{{Name: "com.google.guava:guava", Current: "31.1-jre", Version: "33.0.0-jre", Reason: "the rules forbid major bumps of com.google.guava:guava"}}
+	assert.Contains(t, report.Markdown(), "## Skipped\n\n| Name | Current | Version | Reason |\n| --- | --- | --- | --- |\n| com.google.guava:guava | 31.1-jre | 33.0.0-jre | the rules forbid major bumps of com.google.guava:guava |\n")
+	report.Suppressed = []SuppressedIssue{{Message: "io.netty:netty-codec 4.1.100.Final fixing CVE-2023-34462", By: "CVE-2023-34462"}}
+	assert.Contains(t, report.Markdown(), "## Suppressed\n\n| Code | Issue | Suppressed by |\n| --- | --- | --- |\n|  | io.netty:netty-codec 4.1.100.Final fixing CVE-2023-34462 | CVE-2023-34462 |\n")
+
+	data, err := report.SARIF()
+	require.NoError(t, err)
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(data, &log))
+	require.Len(t, log.Runs, 1)
+	rules := []string{}
+	for _, r := range log.Runs[0].Results {
+		rules = append(rules, r.RuleID)
+	}
+	assert.Equal(t, []string{sarifRulePropertyUpdate, sarifRuleDependencyUpdate, sarifRuleVerificationFailed}, rules)
+	assert.Equal(t, "pom.xml", log.Runs[0].Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+}
@@ -0,0 +1,104 @@
+package pkg
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/chainguard-dev/gopom"
+)
+
+// CIFriendlyProperties are the properties of Maven's CI friendly versions,
+// e.g. <version>${revision}${changelist}</version>. They are usually given
+// on the command line (-Drevision=1.2.3) or in .mvn/maven.config, so the
+// POM not defining them is intended.
+var CIFriendlyProperties = []string{"revision", "sha1", "changelist"}
+
+// flattenPlugin is the artifactId of flatten-maven-plugin, which replaces
+// the CI friendly properties with their values in the POMs installed and
+// deployed.
+const flattenPlugin = "flatten-maven-plugin"
+
+// IsCIFriendlyProperty reports whether name is one of CIFriendlyProperties.
+func IsCIFriendlyProperty(name string) bool {
+	return slices.Contains(CIFriendlyProperties, name)
+}
+
+// CIFriendlyVersions describes how a project uses CI friendly versions.
+type CIFriendlyVersions struct {
+	// Properties are the CI friendly properties used by the version of the
+	// project, of its parent or of its dependencies, in the order of
+	// CIFriendlyProperties.
+	Properties []string `json:"properties" yaml:"properties"`
+	// Flattened reports whether the project configures
+	// flatten-maven-plugin, without which the published POMs keep the
+	// placeholders.
+	Flattened bool `json:"flattened" yaml:"flattened"`
+}
+
+// ciFriendlyVersions returns how project uses CI friendly versions, nil if
+// it does not.
+func ciFriendlyVersions(project *gopom.Project) *CIFriendlyVersions {
+	versions := []string{project.Version}
+	if project.Parent != nil {
+		versions = append(versions, project.Parent.Version)
+	}
+	if project.Dependencies != nil {
+		for _, dep := range *project.Dependencies {
+			versions = append(versions, dep.Version)
+		}
+	}
+	if project.DependencyManagement != nil && project.DependencyManagement.Dependencies != nil {
+		for _, dep := range *project.DependencyManagement.Dependencies {
+			versions = append(versions, dep.Version)
+		}
+	}
+
+	used := map[string]bool{}
+	for _, version := range versions {
+		for _, name := range propertyReferences(version) {
+			used[name] = true
+		}
+	}
+	properties := slices.DeleteFunc(slices.Clone(CIFriendlyProperties), func(name string) bool {
+		return !used[name]
+	})
+	if len(properties) == 0 {
+		return nil
+	}
+	return &CIFriendlyVersions{Properties: properties, Flattened: usesPlugin(project, flattenPlugin)}
+}
+
+// mergeCIFriendlyVersions merges how two modules use CI friendly versions,
+// flattened if either is, as flatten-maven-plugin is usually configured
+// once in the root POM.
+func mergeCIFriendlyVersions(a, b *CIFriendlyVersions) *CIFriendlyVersions {
+	if a == nil || b == nil {
+		if a == nil {
+			return b
+		}
+		return a
+	}
+	properties := slices.DeleteFunc(slices.Clone(CIFriendlyProperties), func(name string) bool {
+		return !slices.Contains(a.Properties, name) && !slices.Contains(b.Properties, name)
+	})
+	return &CIFriendlyVersions{Properties: properties, Flattened: a.Flattened || b.Flattened}
+}
+
+// ciFriendlyReport describes the CI friendly versions of the project.
+func (result *AnalysisResult) ciFriendlyReport() string {
+	if result.CIFriendly == nil {
+		return ""
+	}
+	var report strings.Builder
+	report.WriteString("CI Friendly Versions:\n")
+	report.WriteString("---------------------\n")
+	report.WriteString(fmt.Sprintf("  Properties: ${%s}\n", strings.Join(result.CIFriendly.Properties, "}, ${")))
+	if result.CIFriendly.Flattened {
+		report.WriteString("  Flattened by flatten-maven-plugin\n")
+	} else {
+		report.WriteString("  Not flattened, the published POMs keep the placeholders (see flatten-maven-plugin)\n")
+	}
+	report.WriteString("\n")
+	return report.String()
+}
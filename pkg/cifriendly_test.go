@@ -0,0 +1,55 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCIFriendlyVersions(t *testing.T) {
+	ctx := context.Background()
+	project := &gopom.Project{
+		Version: "${revision}${changelist}",
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "com.example", ArtifactID: "core", Version: "${revision}"},
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.94.Final"},
+		},
+	}
+	result, err := AnalyzeProject(ctx, project)
+	require.NoError(t, err)
+	assert.Equal(t, &CIFriendlyVersions{Properties: []string{"revision", "changelist"}}, result.CIFriendly)
+	assert.Empty(t, result.UndefinedProperties())
+	report := result.AnalysisReport()
+	assert.Contains(t, report, "  revision (used by 1 dependencies) - CI friendly, set with -Drevision\n")
+	assert.Contains(t, report, "CI Friendly Versions:\n---------------------\n  Properties: ${revision}, ${changelist}\n  Not flattened")
+
+	// The module is patched, not the version of the whole build.
+	direct, properties := PatchStrategy(ctx, result, []Patch{{GroupID: "com.example", ArtifactID: "core", Version: "1.2.3"}})
+	assert.Equal(t, []Patch{{GroupID: "com.example", ArtifactID: "core", Version: "1.2.3"}}, direct)
+	assert.Empty(t, properties)
+
+	// Unless set explicitly.
+	patched, err := PatchProject(ctx, project, nil, map[string]string{"revision": "1.2.3"})
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3", patched.Properties.Entries["revision"])
+
+	project.Build = &gopom.Build{BuildBase: gopom.BuildBase{Plugins: &[]gopom.Plugin{{GroupID: "org.codehaus.mojo", ArtifactID: "flatten-maven-plugin"}}}}
+	result, err = AnalyzeProject(ctx, project)
+	require.NoError(t, err)
+	assert.True(t, result.CIFriendly.Flattened)
+
+	result, err = AnalyzeProject(ctx, &gopom.Project{Version: "1.0.0"})
+	require.NoError(t, err)
+	assert.Nil(t, result.CIFriendly)
+}
+
+func TestUnusedCIFriendlyProperties(t *testing.T) {
+	unused, err := UnusedProperties(context.Background(), &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{"sha1": "", "changelist": "-SNAPSHOT", "old.version": "1.0"}},
+	}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old.version"}, unused)
+}
@@ -0,0 +1,294 @@
+package pkg
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Codes of the warnings and issues pombump reports, in every output format,
+// so that automation can tell them apart without matching their message.
+// They never change meaning: a code that is no longer reported is not
+// reused. The W codes are warnings, the E codes issues keeping a patch from
+// being applied as requested.
+const (
+	CodePropertyNotFound     = "POMBUMP-W001"
+	CodeBOMShadowed          = "POMBUMP-W002"
+	CodeBOMDowngrade         = "POMBUMP-W003"
+	CodeSnapshot             = "POMBUMP-W004"
+	CodeVersionMismatch      = "POMBUMP-W005"
+	CodeSchemaViolation      = "POMBUMP-W006"
+	CodeMalformedPOM         = "POMBUMP-W007"
+	CodeVersionNotPublished  = "POMBUMP-E001"
+	CodeArtifactNotPublished = "POMBUMP-E002"
+	CodeVersionConflict      = "POMBUMP-E003"
+	CodeVerificationFailed   = "POMBUMP-E004"
+)
+
+// issueCodes are the codes in order, with their name: the Kind of the warnings
+// that have one.
+var issueCodes = []struct{ code, name string }{
+	{CodePropertyNotFound, WarningPropertyNotFound},
+	{CodeBOMShadowed, WarningBOMShadowed},
+	{CodeBOMDowngrade, WarningBOMDowngrade},
+	{CodeSnapshot, WarningSnapshot},
+	{CodeVersionMismatch, "version-mismatch"},
+	{CodeSchemaViolation, WarningSchemaViolation},
+	{CodeMalformedPOM, WarningMalformedPOM},
+	{CodeVersionNotPublished, "version-not-published"},
+	{CodeArtifactNotPublished, "artifact-not-published"},
+	{CodeVersionConflict, "version-conflict"},
+	{CodeVerificationFailed, "verification-failed"},
+}
+
+// CodeName returns the name of code, e.g. property-not-found for
+// POMBUMP-W001, or "" if it is unknown.
+func CodeName(code string) string {
+	for _, c := range issueCodes {
+		if c.code == code {
+			return c.name
+		}
+	}
+	return ""
+}
+
+// Codes returns every code, in order.
+func Codes() []string {
+	all := make([]string, 0, len(issueCodes))
+	for _, c := range issueCodes {
+		all = append(all, c.code)
+	}
+	return all
+}
+
+// Suppressions are what is left out of what pombump reports, and of what
+// --fail-on counts: warning and issue codes, the groupId:artifactId (where
+// * matches any part) the warnings and issues are about, and advisory
+// identifiers, whose patches are not recommended. Codes do not change what
+// is patched, nor make a failed verification pass.
+type Suppressions map[string]bool
+
+// SuppressedIssue is a warning, issue or patch left out by Suppressions,
+// still listed for auditing.
+type SuppressedIssue struct {
+	// Code is the code of the warning or issue, empty for a patch.
+	Code    string `json:"code,omitempty" yaml:"code,omitempty"`
+	Message string `json:"message" yaml:"message"`
+	// By is what suppressed it: a code, coordinates or advisories.
+	By string `json:"by" yaml:"by"`
+}
+
+// ParseSuppressions parses codes, e.g. POMBUMP-W004, or their names, e.g.
+// snapshot, case-insensitively.
+func ParseSuppressions(values []string) (Suppressions, error) {
+	suppressions := Suppressions{}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		code := parseCode(value)
+		if code == "" {
+			return nil, fmt.Errorf("unknown warning code %q, use one of %s or their names", value, strings.Join(Codes(), ", "))
+		}
+		suppressions[code] = true
+	}
+	return suppressions, nil
+}
+
+// parseCode returns the code value is, or the name of, or "".
+func parseCode(value string) string {
+	for _, c := range issueCodes {
+		if strings.EqualFold(value, c.code) || strings.EqualFold(value, c.name) {
+			return c.code
+		}
+	}
+	return ""
+}
+
+// Suppressed reports whether the warnings and issues with code are
+// suppressed.
+func (s Suppressions) Suppressed(code string) bool {
+	return s[code]
+}
+
+// match returns what suppresses a warning or issue with code about
+// subjects: the code, or the entries matching every subject. It returns ""
+// if it is not suppressed.
+func (s Suppressions) match(code string, subjects ...string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	if s[code] {
+		return code
+	}
+	if len(subjects) == 0 {
+		return ""
+	}
+	by := []string{}
+	for _, subject := range subjects {
+		entry := s.matchSubject(subject)
+		if entry == "" {
+			return ""
+		}
+		if !slices.Contains(by, entry) {
+			by = append(by, entry)
+		}
+	}
+	return strings.Join(by, ", ")
+}
+
+// matchSubject returns the entry matching subject, or "".
+func (s Suppressions) matchSubject(subject string) string {
+	if s[subject] {
+		return subject
+	}
+	for _, entry := range sortedKeys(s) {
+		if strings.Contains(entry, "*") {
+			if ok, _ := path.Match(entry, subject); ok {
+				return entry
+			}
+		}
+	}
+	return ""
+}
+
+// Warnings splits warnings into those that are not suppressed and those
+// that are, by their code or because every one of their artifacts is.
+func (s Suppressions) Warnings(warnings []Warning) ([]Warning, []SuppressedIssue) {
+	return splitSuppressed(s, warnings, func(w Warning) (string, string, []string) {
+		names := make([]string, 0, len(w.Artifacts))
+		for _, a := range w.Artifacts {
+			names = append(names, a.Name)
+		}
+		return w.Code, w.Message, names
+	})
+}
+
+// Unfixable splits the unfixable issues into those that are not suppressed
+// and those that are.
+func (s Suppressions) Unfixable(issues []UnfixableIssue) ([]UnfixableIssue, []SuppressedIssue) {
+	return splitSuppressed(s, issues, func(i UnfixableIssue) (string, string, []string) {
+		key := fmt.Sprintf("%s:%s", i.GroupID, i.ArtifactID)
+		return i.Code, fmt.Sprintf("%s %s: %s", key, i.Version, i.Reason), []string{key}
+	})
+}
+
+// Conflicts splits the version conflicts into those that are not
+// suppressed and those that are.
+func (s Suppressions) Conflicts(conflicts []VersionConflict) ([]VersionConflict, []SuppressedIssue) {
+	return splitSuppressed(s, conflicts, func(c VersionConflict) (string, string, []string) {
+		return c.Code, c.String(), []string{c.Group}
+	})
+}
+
+// Mismatches splits the version mismatches into those that are not
+// suppressed and those that are.
+func (s Suppressions) Mismatches(mismatches []VersionMismatch) ([]VersionMismatch, []SuppressedIssue) {
+	return splitSuppressed(s, mismatches, func(m VersionMismatch) (string, string, []string) {
+		key := fmt.Sprintf("%s:%s", m.GroupID, m.ArtifactID)
+		return m.Code, fmt.Sprintf("%s: %s (dependencies) vs %s (dependencyManagement)", key, m.DependencyVersion, m.ManagedVersion), []string{key}
+	})
+}
+
+// Patches splits patches into those to recommend and those fixing only
+// suppressed advisories, the advisory their version may be included.
+// Patches without advisories are always kept.
+func (s Suppressions) Patches(patches []Patch) ([]Patch, []SuppressedIssue) {
+	return splitSuppressed(s, patches, func(p Patch) (string, string, []string) {
+		advisories := p.Advisories
+		if IsAdvisoryID(p.Version) && !slices.Contains(advisories, p.Version) {
+			advisories = append(slices.Clone(advisories), p.Version)
+		}
+		message := fmt.Sprintf("%s:%s %s", p.GroupID, p.ArtifactID, p.Version)
+		switch {
+		case p.GroupID == "":
+			message = "Patches"
+		case IsAdvisoryID(p.Version):
+			message = fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID)
+		}
+		return "", fmt.Sprintf("%s fixing %s", message, strings.Join(advisories, ", ")), advisories
+	})
+}
+
+// splitSuppressed splits items into those that are not suppressed and
+// those that are, described by describe.
+func splitSuppressed[T any](s Suppressions, items []T, describe func(T) (code, message string, subjects []string)) ([]T, []SuppressedIssue) {
+	if len(s) == 0 {
+		return items, nil
+	}
+	kept := make([]T, 0, len(items))
+	var suppressed []SuppressedIssue
+	for _, item := range items {
+		code, message, subjects := describe(item)
+		if by := s.match(code, subjects...); by != "" {
+			suppressed = append(suppressed, SuppressedIssue{Code: code, Message: message, By: by})
+			continue
+		}
+		kept = append(kept, item)
+	}
+	return kept, suppressed
+}
+
+// Suppress leaves the warnings and issues matching s out of Warnings,
+// UndefinedProperties, ShadowedBOMVersions, SnapshotVersions and the
+// analysis report, and out of the recommendations made against the result.
+// The suppressions add up over calls.
+func (result *AnalysisResult) Suppress(s Suppressions) {
+	if len(s) == 0 {
+		return
+	}
+	if result.suppressions == nil {
+		result.suppressions = Suppressions{}
+	}
+	for entry := range s {
+		result.suppressions[entry] = true
+	}
+}
+
+// Suppressions returns what is suppressed for the result.
+func (result *AnalysisResult) Suppressions() Suppressions {
+	return result.suppressions
+}
+
+// SuppressedIssues returns the warnings about the analyzed project that are
+// suppressed, see Suppress.
+func (result *AnalysisResult) SuppressedIssues() []SuppressedIssue {
+	var suppressed []SuppressedIssue
+	for _, warnings := range [][]Warning{result.undefinedProperties(), result.shadowedBOMVersions(), result.snapshotVersions(), result.schemaViolations, result.malformed} {
+		_, s := result.suppressions.Warnings(warnings)
+		suppressed = append(suppressed, s...)
+	}
+	_, s := result.suppressions.Mismatches(result.VersionMismatches)
+	return append(suppressed, s...)
+}
+
+// suppressedReport lists the suppressed warnings of the analysis.
+func (result *AnalysisResult) suppressedReport() string {
+	suppressed := result.SuppressedIssues()
+	if len(suppressed) == 0 {
+		return ""
+	}
+	var report strings.Builder
+	report.WriteString("Suppressed:\n")
+	report.WriteString("-----------\n")
+	for _, issue := range suppressed {
+		report.WriteString(fmt.Sprintf("  %s\n", FormatSuppressed(issue)))
+	}
+	report.WriteString("\n")
+	return report.String()
+}
+
+// FormatIssue prefixes message with code, as the human readable reports
+// show warnings and issues.
+func FormatIssue(code, message string) string {
+	return fmt.Sprintf("[%s] %s", code, message)
+}
+
+// FormatSuppressed describes issue as the human readable reports list the
+// suppressed warnings, issues and patches.
+func FormatSuppressed(issue SuppressedIssue) string {
+	message := issue.Message
+	if issue.Code != "" {
+		message = FormatIssue(issue.Code, message)
+	}
+	return fmt.Sprintf("%s (suppressed by %s)", message, issue.By)
+}
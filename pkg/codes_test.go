@@ -0,0 +1,113 @@
+package pkg
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSuppressions(t *testing.T) {
+	suppressions, err := ParseSuppressions([]string{"POMBUMP-W004", "property-not-found", " pombump-e003 "})
+	require.NoError(t, err)
+	assert.Equal(t, Suppressions{CodeSnapshot: true, CodePropertyNotFound: true, CodeVersionConflict: true}, suppressions)
+	assert.True(t, suppressions.Suppressed(CodeSnapshot))
+	assert.False(t, suppressions.Suppressed(CodeBOMShadowed))
+
+	_, err = ParseSuppressions([]string{"POMBUMP-W999"})
+	assert.ErrorContains(t, err, `unknown warning code "POMBUMP-W999"`)
+}
+
+func TestCodes(t *testing.T) {
+	seen := map[string]bool{}
+	for _, code := range Codes() {
+		assert.False(t, seen[code], "duplicate code %s", code)
+		seen[code] = true
+		assert.NotEmpty(t, CodeName(code), code)
+	}
+	assert.Equal(t, "property-not-found", CodeName(CodePropertyNotFound))
+	assert.Empty(t, CodeName("POMBUMP-W999"))
+}
+
+func TestSuppress(t *testing.T) {
+	project, err := StreamPOM(strings.NewReader(`<project>
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0</version>
+  <properties>
+    <lib.version>2.0-SNAPSHOT</lib.version>
+  </properties>
+  <dependencies>
+    <dependency>
+      <groupId>com.example</groupId>
+      <artifactId>lib</artifactId>
+      <version>${lib.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>com.example</groupId>
+      <artifactId>sibling</artifactId>
+      <version>${project.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-handler</artifactId>
+      <version>${netty.version}</version>
+    </dependency>
+  </dependencies>
+</project>`))
+	require.NoError(t, err)
+	analysis, err := AnalyzeProject(context.Background(), project)
+	require.NoError(t, err)
+
+	// project.version is provided by Maven, netty.version is not defined.
+	assert.Equal(t, []Warning{
+		{Code: CodePropertyNotFound, Kind: WarningPropertyNotFound, Message: "Property netty.version is used by 1 dependencies but not defined, it may be in an external parent POM", Artifacts: []VersionChange{{Name: "netty.version"}}},
+	}, analysis.UndefinedProperties())
+	assert.Len(t, analysis.Warnings(), 3)
+	assert.Contains(t, analysis.AnalysisReport(), "  [POMBUMP-W001] netty.version (used by 1 dependencies) - NOT DEFINED\n")
+
+	suppressions, err := ParseSuppressions([]string{"snapshot"})
+	require.NoError(t, err)
+	analysis.Suppress(suppressions)
+	assert.Empty(t, analysis.SnapshotVersions())
+	assert.Equal(t, analysis.UndefinedProperties(), analysis.Warnings())
+	assert.NotContains(t, analysis.AnalysisReport(), "SNAPSHOT Versions:")
+	assert.Equal(t, []SuppressedIssue{
+		{Code: CodeSnapshot, Message: "Property lib.version is the SNAPSHOT version 2.0-SNAPSHOT", By: CodeSnapshot},
+		{Code: CodeSnapshot, Message: "com.example:lib resolves to the SNAPSHOT version 2.0-SNAPSHOT through the property lib.version", By: CodeSnapshot},
+	}, analysis.SuppressedIssues())
+	assert.Contains(t, analysis.AnalysisReport(), "Suppressed:\n-----------\n  [POMBUMP-W004] Property lib.version is the SNAPSHOT version 2.0-SNAPSHOT (suppressed by POMBUMP-W004)\n")
+	assert.Equal(t, analysis.SuppressedIssues(), analysis.AggregateReport().Suppressed)
+
+	// Suppressions add up.
+	analysis.Suppress(Suppressions{CodePropertyNotFound: true})
+	assert.Empty(t, analysis.Warnings())
+	assert.Contains(t, analysis.AnalysisReport(), "  netty.version (used by 1 dependencies) - NOT DEFINED\n")
+	assert.Equal(t, Suppressions{CodeSnapshot: true}, suppressions, "the suppressions passed in are not changed")
+}
+
+func TestSuppressConflictsAndUnfixable(t *testing.T) {
+	suppressions := Suppressions{CodeVersionNotPublished: true}
+	issues := []UnfixableIssue{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.999.Final", Reason: ReasonVersionNotPublished, Code: CodeVersionNotPublished},
+		{GroupID: "io.netty", ArtifactID: "netty-bogus", Version: "4.1.118.Final", Reason: ReasonArtifactNotPublished, Code: CodeArtifactNotPublished},
+	}
+	kept, suppressed := suppressions.Unfixable(issues)
+	assert.Equal(t, issues[1:], kept)
+	assert.Equal(t, []SuppressedIssue{{Code: CodeVersionNotPublished, Message: "io.netty:netty-handler 4.1.999.Final: " + ReasonVersionNotPublished, By: CodeVersionNotPublished}}, suppressed)
+	kept, suppressed = Suppressions{}.Unfixable(issues)
+	assert.Equal(t, issues, kept)
+	assert.Empty(t, suppressed)
+
+	conflicts := []VersionConflict{{Group: "io.netty:netty-handler", Code: CodeVersionConflict}}
+	keptConflicts, _ := suppressions.Conflicts(conflicts)
+	assert.Equal(t, conflicts, keptConflicts)
+	keptConflicts, _ = Suppressions{CodeVersionConflict: true}.Conflicts(conflicts)
+	assert.Empty(t, keptConflicts)
+}
+
+func TestFormatIssue(t *testing.T) {
+	assert.Equal(t, "[POMBUMP-W004] lib.version is a SNAPSHOT", FormatIssue(CodeSnapshot, "lib.version is a SNAPSHOT"))
+}
@@ -0,0 +1,156 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// compositeParts splits a composite version like ${major}.${minor}.0 into
+// the properties it references and the literals around them, so that
+// literals has one more entry than names: ["", ".", ".0"] and
+// ["major", "minor"].
+func compositeParts(version string) (literals []string, names []string) {
+	rest := version
+	for {
+		start := strings.Index(rest, "${")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			break
+		}
+		literals = append(literals, rest[:start])
+		names = append(names, rest[start+2:start+end])
+		rest = rest[start+end+1:]
+	}
+	return append(literals, rest), names
+}
+
+// compositeValues returns the values the properties of the composite
+// version template must take for it to resolve to version, its literals
+// unchanged. It fails when the literals do not match, when two properties
+// are adjacent or the literals between them allow several splits of version
+// (4.1.118 for ${a}.${b}), or when a property would be empty or take two
+// values.
+func compositeValues(template, version string) (map[string]string, bool) {
+	literals, names := compositeParts(template)
+	if len(names) == 0 || slices.Contains(literals[1:len(names)], "") {
+		return nil, false
+	}
+	// The split is only trusted if matching the fewest and the most
+	// characters for each property agree on it.
+	match := func(group string) []string {
+		quoted := make([]string, len(literals))
+		for i, literal := range literals {
+			quoted[i] = regexp.QuoteMeta(literal)
+		}
+		return regexp.MustCompile("^" + strings.Join(quoted, group) + "$").FindStringSubmatch(version)
+	}
+	matched := match("(.+?)")
+	if matched == nil || !slices.Equal(matched, match("(.+)")) {
+		return nil, false
+	}
+	values := map[string]string{}
+	for i, name := range names {
+		if value, seen := values[name]; seen && value != matched[i+1] {
+			return nil, false
+		}
+		values[name] = matched[i+1]
+	}
+	return values, true
+}
+
+// rewriteLiterals returns the composite version template with its literals
+// changed so that it resolves to version with the current values of its
+// properties, e.g. 1.1-${suffix} for 1.0-${suffix} and 1.1-jre when suffix
+// is jre. It fails when a property has no value in props or version does not
+// contain their values in order.
+func rewriteLiterals(template, version string, props map[string]string) (string, bool) {
+	_, names := compositeParts(template)
+	if len(names) == 0 {
+		return "", false
+	}
+	var rewritten strings.Builder
+	rest := version
+	for _, name := range names {
+		value, ok := props[name]
+		if value = interpolate(value, props); !ok || value == "" || strings.Contains(value, "${") {
+			return "", false
+		}
+		i := strings.Index(rest, value)
+		if i < 0 {
+			return "", false
+		}
+		rewritten.WriteString(rest[:i])
+		rewritten.WriteString("${" + name + "}")
+		rest = rest[i+len(value):]
+	}
+	rewritten.WriteString(rest)
+	return rewritten.String(), true
+}
+
+// patchedVersion is the version a dependency declared with version gets
+// when patched to newVersion: newVersion, unless version is composite and
+// its literals can be rewritten to resolve to it with props, keeping its
+// properties.
+func patchedVersion(version, newVersion string, props map[string]string) string {
+	if _, pure := propertyReference(version); pure || !strings.Contains(version, "${") {
+		return newVersion
+	}
+	if rewritten, ok := rewriteLiterals(version, newVersion, props); ok {
+		return rewritten
+	}
+	return newVersion
+}
+
+// compositePropertyPatches returns the property patches bringing the
+// composite version of dep to version, only those properties whose value
+// changes. It fails if the literals of the version would have to change,
+// if a property to change is not defined, or is one of the
+// CIFriendlyProperties.
+func (result *AnalysisResult) compositePropertyPatches(ctx context.Context, dep *DependencyInfo, version string) (map[string]string, bool) {
+	if dep == nil || len(dep.ReferencedProperties) == 0 || version == "" {
+		return nil, false
+	}
+	values, ok := compositeValues(dep.Version, version)
+	if !ok {
+		return nil, false
+	}
+	patches := map[string]string{}
+	for name, value := range values {
+		current, defined := result.Properties[name]
+		if defined && interpolate(current, result.Properties) == value {
+			continue
+		}
+		if !defined || IsCIFriendlyProperty(name) {
+			return nil, false
+		}
+		patches[result.bumpedProperty(ctx, name)] = value
+	}
+	return patches, true
+}
+
+// compositeReport lists the dependencies with a composite version and the
+// properties it is made of.
+func (result *AnalysisResult) compositeReport() string {
+	var report strings.Builder
+	for _, key := range sortedKeys(result.Dependencies) {
+		dep := result.Dependencies[key]
+		if len(dep.ReferencedProperties) == 0 {
+			continue
+		}
+		if report.Len() == 0 {
+			report.WriteString("Dependencies With Composite Versions:\n")
+			report.WriteString("-------------------------------------\n")
+		}
+		report.WriteString(fmt.Sprintf("  %s -> %s (%s)\n", key, dep.Version, strings.Join(dep.ReferencedProperties, ", ")))
+	}
+	if report.Len() > 0 {
+		report.WriteString("\n")
+	}
+	return report.String()
+}
@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompositeValues(t *testing.T) {
+	testCases := []struct {
+		template string
+		version  string
+		want     map[string]string
+	}{
+		{"${major}.${minor}.0", "5.3.0", map[string]string{"major": "5", "minor": "3"}},
+		{"${netty.version}.Final", "4.1.118.Final", map[string]string{"netty.version": "4.1.118"}},
+		{"${v}-${v}", "1-1", map[string]string{"v": "1"}},
+		// The literals differ.
+		{"${major}.${minor}.0", "5.3.1", nil},
+		// Several splits.
+		{"${a}.${b}", "4.1.118", nil},
+		{"${a}${b}", "12", nil},
+		{"${v}-${v}", "1-2", nil},
+	}
+	for _, tc := range testCases {
+		got, ok := compositeValues(tc.template, tc.version)
+		assert.Equal(t, tc.want != nil, ok, "%s to %s", tc.template, tc.version)
+		assert.Equal(t, tc.want, got, "%s to %s", tc.template, tc.version)
+	}
+}
+
+func TestRewriteLiterals(t *testing.T) {
+	props := map[string]string{"suffix": "jre", "qualifier": "${suffix}"}
+	got, ok := rewriteLiterals("1.0-${suffix}", "1.1-jre", props)
+	require.True(t, ok)
+	assert.Equal(t, "1.1-${suffix}", got)
+	got, ok = rewriteLiterals("1.0-${qualifier}", "2.0-jre", props)
+	require.True(t, ok)
+	assert.Equal(t, "2.0-${qualifier}", got)
+	_, ok = rewriteLiterals("1.0-${suffix}", "1.1-android", props)
+	assert.False(t, ok)
+	_, ok = rewriteLiterals("1.0-${undefined}", "1.1", props)
+	assert.False(t, ok)
+}
+
+func TestCompositePatchStrategy(t *testing.T) {
+	ctx := context.Background()
+	project := &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{"major": "5", "minor": "2", "suffix": "jre"}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "org.springframework", ArtifactID: "spring-core", Version: "${major}.${minor}.0"},
+			{GroupID: "com.google.guava", ArtifactID: "guava", Version: "32.0.0-${suffix}"},
+		},
+	}
+	result, err := AnalyzeProject(ctx, project)
+	require.NoError(t, err)
+	assert.Contains(t, result.AnalysisReport(), "Dependencies With Composite Versions:\n-------------------------------------\n"+
+		"  com.google.guava:guava -> 32.0.0-${suffix} (suffix)\n"+
+		"  org.springframework:spring-core -> ${major}.${minor}.0 (major, minor)\n")
+
+	patches := []Patch{
+		{GroupID: "org.springframework", ArtifactID: "spring-core", Version: "5.3.0"},
+		{GroupID: "com.google.guava", ArtifactID: "guava", Version: "32.1.3-jre"},
+	}
+	direct, properties := PatchStrategy(ctx, result, patches)
+	// Only the property that changes is bumped.
+	assert.Equal(t, map[string]string{"minor": "3"}, properties)
+	assert.Equal(t, []Patch{patches[1]}, direct)
+
+	patched, err := PatchProject(ctx, project, direct, properties)
+	require.NoError(t, err)
+	assert.Equal(t, "${major}.${minor}.0", (*patched.Dependencies)[0].Version)
+	assert.Equal(t, "32.1.3-${suffix}", (*patched.Dependencies)[1].Version)
+	assert.Equal(t, "3", patched.Properties.Entries["minor"])
+
+	// The literals are rewritten if the properties can stay, and what the
+	// composite version can not express replaces it.
+	direct, properties = PatchStrategy(ctx, result, []Patch{
+		{GroupID: "org.springframework", ArtifactID: "spring-core", Version: "5.3.1"},
+		{GroupID: "com.google.guava", ArtifactID: "guava", Version: "33.0.0-android"},
+	})
+	assert.Empty(t, properties)
+	patched, err = PatchProject(ctx, patched, direct, properties)
+	require.NoError(t, err)
+	assert.Equal(t, "${major}.${minor}.1", (*patched.Dependencies)[0].Version)
+	assert.Equal(t, "33.0.0-android", (*patched.Dependencies)[1].Version)
+}
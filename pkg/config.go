@@ -0,0 +1,254 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConfigFileName is the name of the project configuration file, looked up
+// from the directory of the POM up to the root of the repository.
+const ConfigFileName = ".pombump.yaml"
+
+// Config holds default flag values read from configuration files, so that
+// long flag sets need not be repeated on every run. In the file, the
+// top-level keys are flag names, applying to every command that has the
+// flag, and a map under the name of a command holds flags for that command
+// only:
+//
+//	strategy: prefer-property
+//	repository: https://maven.example.com/releases
+//	exclude: [generated/, third_party/]
+//	analyze:
+//	  output: json
+//	  fail-on: [unfixable]
+//
+// A value is a string, number or boolean, or a list of them for the flags
+// taking several values.
+type Config struct {
+	// Flags are the values of the top-level flags, by flag name.
+	Flags map[string][]string
+	// Commands are the values of the flags of each command section, by
+	// command name and then flag name.
+	Commands map[string]map[string][]string
+	// Files are the files the configuration was read from, in the order they
+	// were merged.
+	Files []string
+}
+
+// LoadConfig reads the configuration file at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	raw := map[string]any{}
+	if err := unmarshalPatchFile(path, data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	config := &Config{Flags: map[string][]string{}, Commands: map[string]map[string][]string{}, Files: []string{path}}
+	for key, value := range raw {
+		section, ok := value.(map[string]any)
+		if !ok {
+			if config.Flags[key], err = configValues(value); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, key, err)
+			}
+			continue
+		}
+		config.Commands[key] = map[string][]string{}
+		for flag, value := range section {
+			if config.Commands[key][flag], err = configValues(value); err != nil {
+				return nil, fmt.Errorf("%s: %s.%s: %w", path, key, flag, err)
+			}
+		}
+	}
+	return config, nil
+}
+
+// configValues turns the value of a flag in a configuration file into the
+// strings the flag would be given on the command line.
+func configValues(value any) ([]string, error) {
+	list, ok := value.([]any)
+	if !ok {
+		list = []any{value}
+	}
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		switch item := item.(type) {
+		case string:
+			values = append(values, item)
+		case bool:
+			values = append(values, strconv.FormatBool(item))
+		case float64:
+			values = append(values, strconv.FormatFloat(item, 'f', -1, 64))
+		default:
+			return nil, fmt.Errorf("expected a string, number or boolean, or a list of them, got %v", item)
+		}
+	}
+	return values, nil
+}
+
+// Merge overlays other onto c: the flags other sets replace those of c.
+func (c *Config) Merge(other *Config) {
+	for flag, values := range other.Flags {
+		c.Flags[flag] = values
+	}
+	for command, flags := range other.Commands {
+		if c.Commands[command] == nil {
+			c.Commands[command] = map[string][]string{}
+		}
+		for flag, values := range flags {
+			c.Commands[command][flag] = values
+		}
+	}
+	c.Files = append(c.Files, other.Files...)
+}
+
+// FlagsFor returns the flag values for command: those of its section over
+// the top-level ones that are among the flags the command has.
+func (c *Config) FlagsFor(command string, has func(flag string) bool) map[string][]string {
+	flags := map[string][]string{}
+	for flag, values := range c.Flags {
+		if has(flag) {
+			flags[flag] = values
+		}
+	}
+	for flag, values := range c.Commands[command] {
+		flags[flag] = values
+	}
+	return flags
+}
+
+// Validate checks the configuration against the flags of each command, so
+// that a typo is reported rather than silently ignored: a top-level flag
+// must be one of some command, and the flags of a command section must be
+// flags of that command.
+func (c *Config) Validate(commandFlags map[string][]string) error {
+	where := strings.Join(c.Files, ", ")
+	all := []string{}
+	for _, flags := range commandFlags {
+		all = append(all, flags...)
+	}
+	slices.Sort(all)
+	all = slices.Compact(all)
+	for _, flag := range sortedKeys(c.Flags) {
+		if !slices.Contains(all, flag) {
+			return unknownConfigKey(where, "flag", flag, all)
+		}
+	}
+	commands := sortedKeys(commandFlags)
+	for _, command := range sortedKeys(c.Commands) {
+		flags, ok := commandFlags[command]
+		if !ok {
+			return unknownConfigKey(where, "command", command, commands)
+		}
+		for _, flag := range sortedKeys(c.Commands[command]) {
+			if !slices.Contains(flags, flag) {
+				return unknownConfigKey(where+": "+command, "flag", flag, flags)
+			}
+		}
+	}
+	return nil
+}
+
+// unknownConfigKey is the error for a flag or command a configuration file
+// names that does not exist, suggesting the one it is likely a typo of.
+func unknownConfigKey(where, kind, key string, known []string) error {
+	if suggestion := closestKey(key, known); suggestion != "" {
+		return fmt.Errorf("%s: unknown %s %q, did you mean %q?", where, kind, key, suggestion)
+	}
+	return fmt.Errorf("%s: unknown %s %q", where, kind, key)
+}
+
+// sortedKeys returns the keys of m, sorted.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// UserConfigPath returns the path of the user configuration file,
+// $XDG_CONFIG_HOME/pombump/config.yaml, or ~/.config/pombump/config.yaml if
+// XDG_CONFIG_HOME is not set.
+func UserConfigPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "pombump", "config.yaml"), nil
+}
+
+// FindProjectConfig looks for ConfigFileName in dir and its parents, up to
+// the root of the git repository dir is in. It returns "" if there is none.
+func FindProjectConfig(dir string) (string, error) {
+	return findUp(dir, ConfigFileName)
+}
+
+// findUp looks for the file name in dir and its parents, up to the root of
+// the git repository dir is in. It returns "" if there is none.
+func findUp(dir, name string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	for {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("failed to look for %s: %w", path, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// LoadConfigs reads the user configuration file, if there is one, and then
+// the project one, projectConfig or else the one FindProjectConfig finds
+// from dir, which takes precedence.
+func LoadConfigs(dir, projectConfig string) (*Config, error) {
+	config := &Config{Flags: map[string][]string{}, Commands: map[string]map[string][]string{}}
+	paths := []string{}
+	if userConfig, err := UserConfigPath(); err == nil {
+		if _, err := os.Stat(userConfig); err == nil {
+			paths = append(paths, userConfig)
+		}
+	}
+	if projectConfig == "" {
+		found, err := FindProjectConfig(dir)
+		if err != nil {
+			return nil, err
+		}
+		projectConfig = found
+	}
+	if projectConfig != "" {
+		paths = append(paths, projectConfig)
+	}
+	for _, path := range paths {
+		loaded, err := LoadConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		config.Merge(loaded)
+	}
+	return config, nil
+}
@@ -0,0 +1,106 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ConfigFileName)
+	writeConfig(t, path, `strategy: property
+jobs: 4
+verify-versions: true
+exclude: [generated/, third_party/]
+analyze:
+  output: json
+  strategy: direct
+`)
+	config, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"strategy":        {"property"},
+		"jobs":            {"4"},
+		"verify-versions": {"true"},
+		"exclude":         {"generated/", "third_party/"},
+	}, config.Flags)
+	assert.Equal(t, map[string]map[string][]string{"analyze": {"output": {"json"}, "strategy": {"direct"}}}, config.Commands)
+
+	has := func(flag string) bool { return flag != "verify-versions" }
+	assert.Equal(t, map[string][]string{
+		"strategy": {"direct"},
+		"jobs":     {"4"},
+		"exclude":  {"generated/", "third_party/"},
+		"output":   {"json"},
+	}, config.FlagsFor("analyze", has))
+	assert.Equal(t, []string{"property"}, config.FlagsFor("ci", has)["strategy"])
+
+	writeConfig(t, path, "exclude: [{path: generated/}]\n")
+	_, err = LoadConfig(path)
+	assert.ErrorContains(t, err, "exclude: expected a string")
+}
+
+func TestConfigValidate(t *testing.T) {
+	commands := map[string][]string{
+		"pombump": {"dependencies", "repository"},
+		"analyze": {"output", "repository", "strategy"},
+	}
+	config := func(content string) *Config {
+		path := filepath.Join(t.TempDir(), ConfigFileName)
+		writeConfig(t, path, content)
+		config, err := LoadConfig(path)
+		require.NoError(t, err)
+		return config
+	}
+
+	assert.NoError(t, config("repository: https://example.com\nanalyze:\n  strategy: direct\n").Validate(commands))
+	assert.ErrorContains(t, config("repositroy: x\n").Validate(commands), `unknown flag "repositroy", did you mean "repository"?`)
+	assert.ErrorContains(t, config("analyse:\n  output: json\n").Validate(commands), `unknown command "analyse", did you mean "analyze"?`)
+	assert.ErrorContains(t, config("analyze:\n  dependencies: x\n").Validate(commands), `analyze: unknown flag "dependencies"`)
+}
+
+func TestLoadConfigs(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "xdg"))
+	repo := filepath.Join(dir, "repo")
+	module := filepath.Join(repo, "services", "api")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0755))
+	require.NoError(t, os.MkdirAll(module, 0755))
+	// Outside of the repository, so never found.
+	writeConfig(t, filepath.Join(dir, ConfigFileName), "strategy: direct\n")
+
+	path, err := FindProjectConfig(module)
+	require.NoError(t, err)
+	assert.Empty(t, path)
+
+	writeConfig(t, filepath.Join(dir, "xdg", "pombump", "config.yaml"), "strategy: property\nrepository: https://user.example.com\n")
+	writeConfig(t, filepath.Join(repo, ConfigFileName), "repository: https://project.example.com\nanalyze:\n  output: yaml\n")
+	path, err = FindProjectConfig(module)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(repo, ConfigFileName), path)
+
+	config, err := LoadConfigs(module, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "xdg", "pombump", "config.yaml"), filepath.Join(repo, ConfigFileName)}, config.Files)
+	// The project configuration takes precedence over the user's.
+	assert.Equal(t, map[string][]string{"strategy": {"property"}, "repository": {"https://project.example.com"}}, config.Flags)
+	assert.Equal(t, []string{"yaml"}, config.Commands["analyze"]["output"])
+
+	explicit := filepath.Join(dir, "ci.yaml")
+	writeConfig(t, explicit, "strategy: direct\n")
+	config, err = LoadConfigs(module, explicit)
+	require.NoError(t, err)
+	assert.True(t, slices.Contains(config.Files, explicit))
+	assert.Equal(t, []string{"direct"}, config.Flags["strategy"])
+	assert.Equal(t, []string{"https://user.example.com"}, config.Flags["repository"])
+}
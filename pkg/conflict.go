@@ -0,0 +1,196 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// ConflictPolicy is what ResolveVersionConflicts does when the patches of a
+// group of dependencies, sharing a version property or being the same
+// artifact, request different versions.
+type ConflictPolicy string
+
+const (
+	// ConflictPolicyHighest picks the highest version requested. It is the
+	// default.
+	ConflictPolicyHighest ConflictPolicy = "highest"
+	// ConflictPolicyLowestFix picks the lowest version that still satisfies
+	// every fix. Versions requested for the same advisory are alternative
+	// fixes, e.g. on several release lines, of which the lowest at or above
+	// the version in use is enough.
+	ConflictPolicyLowestFix ConflictPolicy = "lowest-fix"
+	// ConflictPolicyFail fails on any conflict.
+	ConflictPolicyFail ConflictPolicy = "fail"
+)
+
+// ConflictPolicies are the known conflict policies.
+var ConflictPolicies = []ConflictPolicy{ConflictPolicyHighest, ConflictPolicyLowestFix, ConflictPolicyFail}
+
+// ParseConflictPolicy returns the policy named s, ConflictPolicyHighest if s
+// is empty.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	if s == "" {
+		return ConflictPolicyHighest, nil
+	}
+	names := make([]string, 0, len(ConflictPolicies))
+	for _, policy := range ConflictPolicies {
+		if string(policy) == s {
+			return policy, nil
+		}
+		names = append(names, string(policy))
+	}
+	return "", fmt.Errorf("unknown conflict policy %q, use one of %s", s, strings.Join(names, ", "))
+}
+
+// VersionConflict is a group of patches requesting different versions for
+// dependencies that can only have one: the same artifact, or artifacts
+// sharing a version property.
+type VersionConflict struct {
+	// Code is CodeVersionConflict.
+	Code string `json:"code" yaml:"code"`
+	// Group is the property the dependencies share, or their
+	// groupId:artifactId.
+	Group    string `json:"group" yaml:"group"`
+	Property bool   `json:"property,omitempty" yaml:"property,omitempty"`
+	// Current is the version in use, if known.
+	Current  string   `json:"current,omitempty" yaml:"current,omitempty"`
+	Versions []string `json:"versions" yaml:"versions"`
+	// Chosen is the version ResolveVersionConflicts picked.
+	Chosen  string  `json:"chosen,omitempty" yaml:"chosen,omitempty"`
+	Patches []Patch `json:"-" yaml:"-"`
+}
+
+func (c VersionConflict) String() string {
+	if c.Chosen != "" {
+		return fmt.Sprintf("%s: %s -> %s", c.Group, strings.Join(c.Versions, ", "), c.Chosen)
+	}
+	return fmt.Sprintf("%s: %s", c.Group, strings.Join(c.Versions, ", "))
+}
+
+// detectVersionConflicts groups the version patches by the property they
+// bump, or by artifact, and returns the groups requesting several versions,
+// sorted by group.
+func detectVersionConflicts(ctx context.Context, result *AnalysisResult, patches []Patch) []VersionConflict {
+	result.IndexDependencies(ctx)
+	current := result.CurrentVersions()
+	groups := map[string]*VersionConflict{}
+	for _, p := range patches {
+		if p.Operation != "" || p.Version == "" {
+			continue
+		}
+		key := fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID)
+		group := &VersionConflict{Group: key, Current: current[key]}
+		if useProperty, name := result.ShouldUseProperty(p.GroupID, p.ArtifactID); useProperty && name != "" {
+			name = result.bumpedProperty(ctx, name)
+			group = &VersionConflict{Group: name, Property: true, Current: interpolate(result.Properties[name], result.Properties)}
+		}
+		if existing, ok := groups[group.Group]; ok {
+			group = existing
+		} else {
+			groups[group.Group] = group
+		}
+		group.Patches = append(group.Patches, p)
+		if !slices.Contains(group.Versions, p.Version) {
+			group.Versions = append(group.Versions, p.Version)
+		}
+	}
+
+	conflicts := []VersionConflict{}
+	for _, group := range groups {
+		if len(group.Versions) < 2 {
+			continue
+		}
+		if strings.Contains(group.Current, "${") {
+			group.Current = ""
+		}
+		sort.Slice(group.Versions, func(i, j int) bool { return compareVersions(group.Versions[i], group.Versions[j]) < 0 })
+		group.Code = CodeVersionConflict
+		conflicts = append(conflicts, *group)
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Group < conflicts[j].Group })
+	return conflicts
+}
+
+// ResolveVersionConflicts makes the patches of every VersionConflict agree
+// on one version, as picked by policy, or fails if policy is
+// ConflictPolicyFail. The conflicts found are returned along with the
+// patches.
+func ResolveVersionConflicts(ctx context.Context, result *AnalysisResult, patches []Patch, policy ConflictPolicy) ([]Patch, []VersionConflict, error) {
+	log := clog.FromContext(ctx)
+	conflicts := detectVersionConflicts(ctx, result, patches)
+	if len(conflicts) == 0 {
+		return patches, conflicts, nil
+	}
+	if policy == ConflictPolicyFail {
+		descriptions := make([]string, 0, len(conflicts))
+		for _, c := range conflicts {
+			descriptions = append(descriptions, c.String())
+		}
+		return nil, conflicts, fmt.Errorf("conflicting versions requested for %s", strings.Join(descriptions, "; "))
+	}
+
+	chosen := map[string]string{}
+	for i, c := range conflicts {
+		version := c.Versions[len(c.Versions)-1]
+		if policy == ConflictPolicyLowestFix {
+			version = lowestFix(c)
+		}
+		conflicts[i].Chosen = version
+		log.Warnf("Conflicting versions requested for %s (%s), picking %s (%s)", c.Group, strings.Join(c.Versions, ", "), version, policy)
+		for _, p := range c.Patches {
+			chosen[fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID)] = version
+		}
+	}
+
+	resolved := make([]Patch, 0, len(patches))
+	for _, p := range patches {
+		if version, ok := chosen[fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID)]; ok && p.Operation == "" && p.Version != "" {
+			p.Version = version
+		}
+		resolved = append(resolved, p)
+	}
+	return resolved, conflicts, nil
+}
+
+// lowestFix returns the lowest version satisfying every fix of c: for each
+// advisory, the lowest version requested for it at or above the one in use,
+// and for a patch without advisories its version. An advisory the version
+// in use is above every fix of is already fixed.
+func lowestFix(c VersionConflict) string {
+	fixes := map[string][]string{}
+	for i, p := range c.Patches {
+		if len(p.Advisories) == 0 {
+			// A fix of its own.
+			fixes[fmt.Sprintf("patch %d", i)] = []string{p.Version}
+		}
+		for _, advisory := range p.Advisories {
+			fixes[advisory] = append(fixes[advisory], p.Version)
+		}
+	}
+
+	satisfying := ""
+	for _, versions := range fixes {
+		sort.Slice(versions, func(i, j int) bool { return compareVersions(versions[i], versions[j]) < 0 })
+		fix := versions[0]
+		if c.Current != "" {
+			at := slices.IndexFunc(versions, func(v string) bool { return compareVersions(v, c.Current) >= 0 })
+			if at < 0 {
+				continue
+			}
+			fix = versions[at]
+		}
+		if satisfying == "" || compareVersions(fix, satisfying) > 0 {
+			satisfying = fix
+		}
+	}
+	if satisfying == "" {
+		// Everything is fixed already.
+		return c.Current
+	}
+	return satisfying
+}
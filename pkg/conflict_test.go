@@ -0,0 +1,124 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveVersionConflicts(t *testing.T) {
+	core := func(version string, advisories ...string) Patch {
+		return Patch{GroupID: "org.apache.logging.log4j", ArtifactID: "log4j-core", Version: version, Advisories: advisories}
+	}
+	api := func(version string, advisories ...string) Patch {
+		return Patch{GroupID: "org.apache.logging.log4j", ArtifactID: "log4j-api", Version: version, Advisories: advisories}
+	}
+	slf4j := func(version string) Patch {
+		return Patch{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: version}
+	}
+
+	project := &gopom.Project{
+		Properties: makeProperties("log4j.version", "2.12.1"),
+		Dependencies: makeDeps(
+			pomDep("org.apache.logging.log4j", "log4j-core", "${log4j.version}"),
+			pomDep("org.apache.logging.log4j", "log4j-api", "${log4j.version}"),
+			pomDep("org.slf4j", "slf4j-api", "2.0.7"),
+		),
+	}
+
+	tests := []struct {
+		name    string
+		patches []Patch
+		policy  ConflictPolicy
+		want    []string
+		wantErr bool
+	}{{
+		name:    "no conflict",
+		patches: []Patch{core("2.17.1"), api("2.17.1"), slf4j("2.0.10")},
+		policy:  ConflictPolicyFail,
+		want:    []string{"2.17.1", "2.17.1", "2.0.10"},
+	}, {
+		name:    "highest through a property",
+		patches: []Patch{core("2.17.1"), api("2.15.0"), slf4j("2.0.10")},
+		policy:  ConflictPolicyHighest,
+		want:    []string{"2.17.1", "2.17.1", "2.0.10"},
+	}, {
+		name:    "highest of the same artifact",
+		patches: []Patch{slf4j("2.0.10"), slf4j("2.0.9")},
+		policy:  ConflictPolicyHighest,
+		want:    []string{"2.0.10", "2.0.10"},
+	}, {
+		name:    "lowest fix on the release line in use",
+		patches: []Patch{core("2.12.2", "CVE-2021-44228"), core("2.15.0", "CVE-2021-44228")},
+		policy:  ConflictPolicyLowestFix,
+		want:    []string{"2.12.2", "2.12.2"},
+	}, {
+		name:    "lowest fix satisfying every advisory",
+		patches: []Patch{core("2.12.2", "CVE-2021-44228"), core("2.15.0", "CVE-2021-44228"), api("2.12.4", "CVE-2021-44832"), api("2.17.1", "CVE-2021-44832")},
+		policy:  ConflictPolicyLowestFix,
+		want:    []string{"2.12.4", "2.12.4", "2.12.4", "2.12.4"},
+	}, {
+		name:    "lowest fix of patches without advisories",
+		patches: []Patch{core("2.12.2"), api("2.15.0")},
+		policy:  ConflictPolicyLowestFix,
+		want:    []string{"2.15.0", "2.15.0"},
+	}, {
+		name:    "highest across release lines",
+		patches: []Patch{core("2.12.2", "CVE-2021-44228"), core("2.15.0", "CVE-2021-44228")},
+		policy:  ConflictPolicyHighest,
+		want:    []string{"2.15.0", "2.15.0"},
+	}, {
+		name:    "fail",
+		patches: []Patch{core("2.17.1"), api("2.15.0")},
+		policy:  ConflictPolicyFail,
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			analysis, err := AnalyzeProject(ctx, project)
+			require.NoError(t, err)
+			got, conflicts, err := ResolveVersionConflicts(ctx, analysis, tt.patches, tt.policy)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "log4j.version: 2.15.0, 2.17.1")
+				assert.Len(t, conflicts, 1)
+				return
+			}
+			require.NoError(t, err)
+			versions := []string{}
+			for _, p := range got {
+				versions = append(versions, p.Version)
+			}
+			assert.Equal(t, tt.want, versions)
+		})
+	}
+}
+
+func TestLowestFixAlreadyFixed(t *testing.T) {
+	c := VersionConflict{
+		Group:   "log4j.version",
+		Current: "2.17.2",
+		Patches: []Patch{{Version: "2.12.2", Advisories: []string{"CVE-2021-44228"}}, {Version: "2.15.0", Advisories: []string{"CVE-2021-44228"}}},
+	}
+	assert.Equal(t, "2.17.2", lowestFix(c))
+}
+
+func TestPatchStrategyHighestProperty(t *testing.T) {
+	ctx := context.Background()
+	analysis, err := AnalyzeProject(ctx, &gopom.Project{
+		Properties: makeProperties("log4j.version", "2.12.1"),
+		Dependencies: makeDeps(
+			pomDep("org.apache.logging.log4j", "log4j-core", "${log4j.version}"),
+			pomDep("org.apache.logging.log4j", "log4j-api", "${log4j.version}"),
+		),
+	})
+	require.NoError(t, err)
+	_, properties := PatchStrategy(ctx, analysis, []Patch{
+		{GroupID: "org.apache.logging.log4j", ArtifactID: "log4j-core", Version: "2.15.0"},
+		{GroupID: "org.apache.logging.log4j", ArtifactID: "log4j-api", Version: "2.17.1"},
+	})
+	assert.Equal(t, map[string]string{"log4j.version": "2.17.1"}, properties)
+}
@@ -0,0 +1,105 @@
+package pkg
+
+import (
+	"fmt"
+
+	"github.com/chainguard-dev/gopom"
+)
+
+// normalizeType returns t with Maven's default applied: a dependency without
+// a <type> is a jar.
+func normalizeType(t string) string {
+	if t == "" {
+		return defaultType
+	}
+	return t
+}
+
+// sameType reports whether a and b are the same dependency type once the
+// default is applied, so that an omitted <type> and "jar" unify.
+func sameType(a, b string) bool {
+	return normalizeType(a) == normalizeType(b)
+}
+
+// dependencyKey identifies dep by groupId:artifactId:type:classifier with the
+// defaults applied, so that logically identical coordinates get the same key.
+func dependencyKey(dep gopom.Dependency) string {
+	return fmt.Sprintf("%s:%s:%s:%s", dep.GroupID, dep.ArtifactID, normalizeType(dep.Type), dep.Classifier)
+}
+
+// isDefaultArtifact reports whether dep is the main artifact of its
+// groupId:artifactId, a jar without classifier.
+func isDefaultArtifact(dep gopom.Dependency) bool {
+	return sameType(dep.Type, defaultType) && dep.Classifier == ""
+}
+
+// patchKey identifies what p adds when the dependency is missing, with the
+// default type applied, so that patches differing only in an omitted or
+// explicit jar type are the same patch.
+func patchKey(p Patch) string {
+	return fmt.Sprintf("%s:%s:%s:%s:%s:%s", p.GroupID, p.ArtifactID, normalizeType(p.Type), p.Classifier, p.Version, p.Scope)
+}
+
+// coordinateProperties are the properties the coordinates of the
+// dependencies of project may reference, e.g. ${project.groupId} for another
+// module of a multi-module project: those it defines and those of its model.
+func coordinateProperties(project *gopom.Project) map[string]string {
+	props := extractPropertiesFromProject(project)
+	props["project.groupId"] = projectGroupID(project)
+	props["project.artifactId"] = project.ArtifactID
+	props["project.version"] = projectVersion(project)
+	if project.Parent != nil {
+		props["project.parent.groupId"] = project.Parent.GroupID
+		props["project.parent.artifactId"] = project.Parent.ArtifactID
+		props["project.parent.version"] = project.Parent.Version
+	}
+	return props
+}
+
+// resolveCoordinates returns dep with the properties its groupId and
+// artifactId reference resolved from props, so that it is keyed and matched
+// as Maven sees it. The version is left as declared.
+func resolveCoordinates(dep gopom.Dependency, props map[string]string) gopom.Dependency {
+	dep.GroupID = interpolate(dep.GroupID, props)
+	dep.ArtifactID = interpolate(dep.ArtifactID, props)
+	return dep
+}
+
+// matchingDependencies returns the indexes of the entries of deps that patch
+// applies to: those with its groupId:artifactId and type (and classifier, if
+// the patch has one), or, if there are none, every entry with its
+// groupId:artifactId. A jar patch therefore
+// leaves a test-jar of the same artifact alone when the jar is declared too,
+// but still bumps a BOM patched without an explicit pom type. The
+// coordinates of deps are resolved from props, see coordinateProperties.
+func matchingDependencies(deps []gopom.Dependency, patch Patch, props map[string]string) []int {
+	sameArtifact, sameTypeToo := []int{}, []int{}
+	for i, dep := range deps {
+		dep = resolveCoordinates(dep, props)
+		if dep.GroupID != patch.GroupID || dep.ArtifactID != patch.ArtifactID {
+			continue
+		}
+		sameArtifact = append(sameArtifact, i)
+		if sameType(dep.Type, patch.Type) && (patch.Classifier == "" || dep.Classifier == patch.Classifier) {
+			sameTypeToo = append(sameTypeToo, i)
+		}
+	}
+	if len(sameTypeToo) > 0 {
+		return sameTypeToo
+	}
+	return sameArtifact
+}
+
+// exactDependencies returns the indexes of the entries of deps with exactly
+// the coordinates of patch, groupId:artifactId:type:classifier, once
+// resolved from props.
+func exactDependencies(deps []gopom.Dependency, patch Patch, props map[string]string) []int {
+	want := dependencyKey(gopom.Dependency{GroupID: patch.GroupID, ArtifactID: patch.ArtifactID, Type: patch.Type, Classifier: patch.Classifier})
+	matches := []int{}
+	for i, dep := range deps {
+		if dependencyKey(resolveCoordinates(dep, props)) == want {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
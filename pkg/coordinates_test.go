@@ -0,0 +1,160 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchProjectTypes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		deps     []gopom.Dependency
+		dmDeps   []gopom.Dependency
+		patches  []Patch
+		wantDeps []gopom.Dependency
+		wantDM   []gopom.Dependency
+	}{{
+		name: "explicit jar matches omitted type",
+		deps: []gopom.Dependency{
+			{GroupID: "g", ArtifactID: "a", Version: "1.0"},
+			{GroupID: "g", ArtifactID: "a", Version: "1.0", Type: "test-jar", Scope: "test"},
+		},
+		patches: []Patch{{GroupID: "g", ArtifactID: "a", Version: "1.1", Type: "jar"}},
+		wantDeps: []gopom.Dependency{
+			{GroupID: "g", ArtifactID: "a", Version: "1.1"},
+			{GroupID: "g", ArtifactID: "a", Version: "1.0", Type: "test-jar", Scope: "test"},
+		},
+	}, {
+		name: "omitted type matches explicit jar",
+		deps: []gopom.Dependency{
+			{GroupID: "g", ArtifactID: "a", Version: "1.0", Type: "jar"},
+			{GroupID: "g", ArtifactID: "a", Version: "1.0", Type: "ejb"},
+		},
+		patches: []Patch{{GroupID: "g", ArtifactID: "a", Version: "1.1"}},
+		wantDeps: []gopom.Dependency{
+			{GroupID: "g", ArtifactID: "a", Version: "1.1", Type: "jar"},
+			{GroupID: "g", ArtifactID: "a", Version: "1.0", Type: "ejb"},
+		},
+	}, {
+		name: "test-jar only patches the test-jar",
+		deps: []gopom.Dependency{
+			{GroupID: "g", ArtifactID: "a", Version: "1.0"},
+			{GroupID: "g", ArtifactID: "a", Version: "1.0", Type: "test-jar", Scope: "test"},
+		},
+		patches: []Patch{{GroupID: "g", ArtifactID: "a", Version: "1.1", Type: "test-jar"}},
+		wantDeps: []gopom.Dependency{
+			{GroupID: "g", ArtifactID: "a", Version: "1.0"},
+			{GroupID: "g", ArtifactID: "a", Version: "1.1", Type: "test-jar", Scope: "test"},
+		},
+	}, {
+		name:     "ejb patch falls back to the only declared type",
+		deps:     []gopom.Dependency{{GroupID: "g", ArtifactID: "a", Version: "1.0"}},
+		patches:  []Patch{{GroupID: "g", ArtifactID: "a", Version: "1.1", Type: "ejb"}},
+		wantDeps: []gopom.Dependency{{GroupID: "g", ArtifactID: "a", Version: "1.1"}},
+	}, {
+		name:    "defaulted jar patch bumps a pom BOM",
+		dmDeps:  []gopom.Dependency{{GroupID: "g", ArtifactID: "bom", Version: "1.0", Type: "pom", Scope: "import"}},
+		patches: []Patch{{GroupID: "g", ArtifactID: "bom", Version: "1.1", Type: "jar", Scope: "import"}},
+		wantDM:  []gopom.Dependency{{GroupID: "g", ArtifactID: "bom", Version: "1.1", Type: "pom", Scope: "import"}},
+	}, {
+		name: "patches differing only in the default type are added once",
+		patches: []Patch{
+			{GroupID: "g", ArtifactID: "a", Version: "1.1", Type: "jar"},
+			{GroupID: "g", ArtifactID: "a", Version: "1.1"},
+		},
+		wantDM: []gopom.Dependency{{GroupID: "g", ArtifactID: "a", Version: "1.1"}},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			project := &gopom.Project{}
+			if tc.deps != nil {
+				project.Dependencies = &tc.deps
+			}
+			if tc.dmDeps != nil {
+				project.DependencyManagement = &gopom.DependencyManagement{Dependencies: &tc.dmDeps}
+			}
+			got, err := PatchProject(context.Background(), project, tc.patches, nil)
+			require.NoError(t, err)
+			if tc.wantDeps != nil {
+				if diff := cmp.Diff(tc.wantDeps, *got.Dependencies); diff != "" {
+					t.Errorf("dependencies (-want +got):\n%s", diff)
+				}
+			}
+			if tc.wantDM != nil {
+				// Which of the equivalent patches is added is unspecified.
+				gotDM := *got.DependencyManagement.Dependencies
+				require.Len(t, gotDM, len(tc.wantDM))
+				for i := range gotDM {
+					assert.Equal(t, dependencyKey(tc.wantDM[i]), dependencyKey(gotDM[i]))
+					assert.Equal(t, tc.wantDM[i].Version, gotDM[i].Version)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzeProjectTypes(t *testing.T) {
+	project := &gopom.Project{
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "g", ArtifactID: "a", Version: "2.0", Type: "test-jar", Scope: "test"},
+			{GroupID: "g", ArtifactID: "a", Version: "${a.version}"},
+			{GroupID: "g", ArtifactID: "b", Version: "${b.version}"},
+		},
+		DependencyManagement: &gopom.DependencyManagement{Dependencies: &[]gopom.Dependency{
+			{GroupID: "g", ArtifactID: "a", Version: "1.9", Type: "ejb"},
+			{GroupID: "g", ArtifactID: "b", Version: "0.9", Type: "jar"},
+			{GroupID: "g", ArtifactID: "bom", Version: "3.0", Type: "pom", Scope: "import"},
+		}},
+	}
+	result, err := AnalyzeProject(context.Background(), project)
+	require.NoError(t, err)
+
+	// The main jar is indexed, not the test-jar declared before it.
+	assert.Equal(t, &DependencyInfo{GroupID: "g", ArtifactID: "a", Version: "${a.version}", UsesProperty: true, PropertyName: "a.version", PropertyUsageCount: 1, Purl: "pkg:maven/g/a"}, result.Dependencies["g:a"])
+	// Only b, declared as an omitted type and managed as an explicit jar,
+	// disagrees with itself; the ejb of a is another artifact.
+	assert.Equal(t, []VersionMismatch{
+		{Code: CodeVersionMismatch, GroupID: "g", ArtifactID: "b", DependencyVersion: "${b.version}", ManagedVersion: "0.9"},
+	}, result.VersionMismatches)
+	assert.Equal(t, []BOMInfo{{GroupID: "g", ArtifactID: "bom", Version: "3.0"}}, result.BOMs())
+}
+
+func TestPropertyCoordinates(t *testing.T) {
+	ctx := context.Background()
+	project := &gopom.Project{
+		GroupID:    "com.example",
+		ArtifactID: "app",
+		Version:    "1.0",
+		Properties: &gopom.Properties{Entries: map[string]string{"netty.group": "io.netty", "scala.binary": "2.13"}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "${project.groupId}", ArtifactID: "core", Version: "${project.version}"},
+			{GroupID: "${netty.group}", ArtifactID: "netty-handler", Version: "4.1.94.Final"},
+			{GroupID: "com.typesafe.akka", ArtifactID: "akka-actor_${scala.binary}", Version: "2.6.0"},
+		},
+	}
+
+	result, err := AnalyzeProject(ctx, project)
+	require.NoError(t, err)
+	assert.Contains(t, result.Dependencies, "com.example:core")
+	assert.Contains(t, result.Dependencies, "io.netty:netty-handler")
+	assert.Contains(t, result.Dependencies, "com.typesafe.akka:akka-actor_2.13")
+
+	patched, err := PatchProject(ctx, project, []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final"},
+		{GroupID: "com.typesafe.akka", ArtifactID: "akka-actor_2.13", Version: "2.6.21"},
+	}, nil)
+	require.NoError(t, err)
+	// The placeholders are kept, nothing is added.
+	if diff := cmp.Diff([]gopom.Dependency{
+		{GroupID: "${project.groupId}", ArtifactID: "core", Version: "${project.version}"},
+		{GroupID: "${netty.group}", ArtifactID: "netty-handler", Version: "4.1.118.Final"},
+		{GroupID: "com.typesafe.akka", ArtifactID: "akka-actor_${scala.binary}", Version: "2.6.21"},
+	}, *patched.Dependencies); diff != "" {
+		t.Errorf("dependencies (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,44 @@
+package pkg
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// diffContext is how many unchanged lines surround each hunk, as in diff -u.
+const diffContext = 3
+
+// UnifiedDiff returns the unified diff turning before into after, the
+// contents of the file at path, with the a/ and b/ prefixes git uses so that
+// `patch -p1` or `git apply` applies it from the root of the repository. It
+// is empty if they are equal.
+func UnifiedDiff(path string, before, after []byte) (string, error) {
+	path = filepath.ToSlash(filepath.Clean(path))
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(before),
+		B:        splitLines(after),
+		FromFile: "a/" + path,
+		ToFile:   "b/" + path,
+		Context:  diffContext,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to diff %s: %w", path, err)
+	}
+	return diff, nil
+}
+
+// splitLines splits data into lines, each keeping its newline. Unlike
+// difflib.SplitLines, a trailing newline does not make an extra empty line,
+// which patch would then look for.
+func splitLines(data []byte) []string {
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	// The diff format needs every line to end in a newline.
+	lines[len(lines)-1] += "\n"
+	return lines
+}
@@ -0,0 +1,47 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	before := `<project>
+  <properties>
+    <netty.version>4.1.94.Final</netty.version>
+    <slf4j.version>2.0.7</slf4j.version>
+  </properties>
+</project>
+`
+	after := `<project>
+  <properties>
+    <netty.version>4.1.100.Final</netty.version>
+    <slf4j.version>2.0.7</slf4j.version>
+  </properties>
+</project>
+`
+	diff, err := UnifiedDiff("./app/pom.xml", []byte(before), []byte(after))
+	require.NoError(t, err)
+	assert.Equal(t, `--- a/app/pom.xml
++++ b/app/pom.xml
+@@ -1,6 +1,6 @@
+ <project>
+   <properties>
+-    <netty.version>4.1.94.Final</netty.version>
++    <netty.version>4.1.100.Final</netty.version>
+     <slf4j.version>2.0.7</slf4j.version>
+   </properties>
+ </project>
+`, diff)
+
+	diff, err = UnifiedDiff("pom.xml", []byte(before), []byte(before))
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+}
+
+func TestSplitLines(t *testing.T) {
+	assert.Equal(t, []string{"a\n", "b\n"}, splitLines([]byte("a\nb\n")))
+	assert.Equal(t, []string{"a\n", "b\n"}, splitLines([]byte("a\nb")))
+}
@@ -0,0 +1,23 @@
+// Package pkg is the library behind the pombump command line, for Go
+// programs that patch Maven POMs without shelling out to it.
+//
+// The entry point is the Analyzer, configured once with AnalyzerOptions:
+//
+//	analyzer := pkg.NewAnalyzer(
+//		pkg.WithRepository(pkg.NewMavenRepository("https://maven.example.com/releases")),
+//		pkg.WithResolvedBOMs(),
+//		pkg.WithResolutionDepth(5),
+//	)
+//	analysis, err := analyzer.Analyze(ctx, "pom.xml")
+//	...
+//	rec, err := analyzer.Recommend(ctx, analysis, patches)
+//	...
+//	patched, err := analyzer.Patch(ctx, "pom.xml", rec.DirectPatches, rec.PropertyPatches)
+//
+// Every method takes a context, which carries the logger (see clog) and the
+// telemetry (see WithTelemetry) and cancels the requests to the Maven
+// repository and OSV. WithoutRemoteAccess keeps an Analyzer from making any.
+//
+// The functions the Analyzer is built on, such as AnalyzeProject,
+// PatchStrategy and PatchProject, remain available for finer control.
+package pkg
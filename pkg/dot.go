@@ -0,0 +1,126 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DOT renders the analysis as a Graphviz graph of what controls the version
+// of each dependency: the properties it uses (and the properties those
+// refer to), and the BOMs or parent managing it. Following the edges out of
+// a property shows everything bumping it touches, e.g. with
+//
+//	pombump analyze pom.xml --output dot | dot -Tsvg > pom.svg
+//
+// Only resolved BOMs and parents (see ResolveBOMs) are known to manage
+// dependencies; unresolved BOMs are guessed by group, see BOMForGroup.
+func (result *AnalysisResult) DOT() string {
+	result.ensureDependencies()
+	var dot strings.Builder
+	dot.WriteString("digraph pombump {\n")
+	dot.WriteString("  rankdir=LR;\n")
+	dot.WriteString("  node [shape=box, fontname=\"Helvetica\"];\n")
+
+	nodes := map[string]bool{}
+	node := func(id, label, attrs string) {
+		if nodes[id] {
+			return
+		}
+		nodes[id] = true
+		dot.WriteString(fmt.Sprintf("  %s [label=%s%s];\n", dotQuote(id), dotQuote(label), attrs))
+	}
+	property := func(name string) string {
+		id := "property:" + name
+		label := "${" + name + "}"
+		if value, ok := result.Properties[name]; ok {
+			label += "\n" + value
+		}
+		node(id, label, `, shape=note, style=filled, fillcolor="lightyellow"`)
+		return id
+	}
+	edges := []string{}
+	edge := func(from, to, attrs string) {
+		edges = append(edges, fmt.Sprintf("  %s -> %s%s;\n", dotQuote(from), dotQuote(to), attrs))
+	}
+	// usesProperty links the property name, and the ones it refers to down
+	// to the one holding the value, to the node id.
+	usesProperty := func(name string, chain []string, id string) {
+		if len(chain) < 2 {
+			edge(property(name), id, "")
+			return
+		}
+		for i := len(chain) - 1; i > 0; i-- {
+			edge(property(chain[i]), property(chain[i-1]), "")
+		}
+		edge(property(chain[0]), id, "")
+	}
+
+	boms := map[string]bool{}
+	for _, bom := range result.BOMs() {
+		id := fmt.Sprintf("%s:%s", bom.GroupID, bom.ArtifactID)
+		boms[id] = true
+		label := id
+		if !bom.UsesProperty && bom.Version != "" {
+			label += "\n" + bom.Version
+		}
+		node(id, label, `, shape=component, style=filled, fillcolor="lightblue"`)
+		if bom.UsesProperty && bom.PropertyName != "" {
+			var chain []string
+			if info := result.Dependencies[id]; info != nil {
+				chain = info.PropertyChain
+			}
+			usesProperty(bom.PropertyName, chain, id)
+		}
+	}
+
+	for _, key := range sortedKeys(result.Dependencies) {
+		if boms[key] {
+			continue
+		}
+		info := result.Dependencies[key]
+		label := key
+		if !info.UsesProperty && info.Version != "" {
+			label += "\n" + info.Version
+		}
+		node(key, label, "")
+		if info.UsesProperty && info.PropertyName != "" {
+			usesProperty(info.PropertyName, info.PropertyChain, key)
+		}
+		for _, p := range info.ReferencedProperties {
+			edge(property(p), key, ` [style=dashed, label="embedded"]`)
+		}
+		// The project overrides the version of the BOMs managing it.
+		for _, bom := range result.BOMs() {
+			if info.Version != "" && result.managesPinned(bom, info) {
+				edge(fmt.Sprintf("%s:%s", bom.GroupID, bom.ArtifactID), key, ` [style=dashed, label="pinned"]`)
+			}
+		}
+		if m := info.ManagedBy; m != nil && (m.Source == ManagedByBOM || m.Source == ManagedByParent) {
+			from := fmt.Sprintf("%s:%s", m.GroupID, m.ArtifactID)
+			if m.Source == ManagedByParent {
+				node(from, "parent\n"+from, `, shape=folder, style=filled, fillcolor="lightgrey"`)
+			}
+			edge(from, key, fmt.Sprintf(` [style=dotted, label=%s]`, dotQuote(m.Version)))
+		}
+	}
+
+	// Edges are written last, so that every node has its attributes by
+	// then, and once, as chains are shared.
+	seen := map[string]bool{}
+	for _, e := range edges {
+		if !seen[e] {
+			seen[e] = true
+			dot.WriteString(e)
+		}
+	}
+	dot.WriteString("}\n")
+	return dot.String()
+}
+
+// dotQuote quotes s as a DOT string, in which newlines are line breaks of
+// the label.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
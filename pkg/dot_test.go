@@ -0,0 +1,46 @@
+package pkg
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDOT(t *testing.T) {
+	project, err := StreamPOM(strings.NewReader(renovatePOM))
+	require.NoError(t, err)
+	analysis, err := AnalyzeProject(context.Background(), project)
+	require.NoError(t, err)
+
+	dot := analysis.DOT()
+	assert.True(t, strings.HasPrefix(dot, "digraph pombump {\n"))
+	for _, line := range []string{
+		`"io.netty:netty-bom" [label="io.netty:netty-bom", shape=component, style=filled, fillcolor="lightblue"];`,
+		`"property:netty.version" [label="${netty.version}\n4.1.94.Final", shape=note, style=filled, fillcolor="lightyellow"];`,
+		`"io.netty:netty-tcnative" [label="io.netty:netty-tcnative\n2.0.61.Final"];`,
+		// Bumping netty.version touches the BOM and netty-handler.
+		`"property:netty.version" -> "io.netty:netty-bom";`,
+		`"property:netty.version" -> "io.netty:netty-handler";`,
+		`"io.netty:netty-bom" -> "io.netty:netty-tcnative" [style=dashed, label="pinned"];`,
+		// lib.version holds the value of shared.version.
+		`"property:shared.version" -> "property:lib.version";`,
+		`"property:lib.version" -> "com.example:lib";`,
+	} {
+		assert.Contains(t, dot, "  "+line+"\n")
+	}
+	// Edges come after every node.
+	assert.Greater(t, strings.Index(dot, "->"), strings.Index(dot, `"io.netty:netty-tcnative" [`))
+	assert.Equal(t, dot, analysis.DOT())
+}
+
+func TestDOTManagedBy(t *testing.T) {
+	analysis := &AnalysisResult{Dependencies: map[string]*DependencyInfo{
+		"org.slf4j:slf4j-api": {GroupID: "org.slf4j", ArtifactID: "slf4j-api", ManagedBy: &Management{Source: ManagedByParent, GroupID: "org.springframework.boot", ArtifactID: "spring-boot-starter-parent", Version: "2.0.9"}},
+	}}
+	dot := analysis.DOT()
+	assert.Contains(t, dot, `  "org.springframework.boot:spring-boot-starter-parent" [label="parent\norg.springframework.boot:spring-boot-starter-parent", shape=folder, style=filled, fillcolor="lightgrey"];`)
+	assert.Contains(t, dot, `  "org.springframework.boot:spring-boot-starter-parent" -> "org.slf4j:slf4j-api" [style=dotted, label="2.0.9"];`)
+}
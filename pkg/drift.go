@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+)
+
+// DriftEntry compares a single local override with what upstream has.
+type DriftEntry struct {
+	// Name is groupId:artifactId for dependencies, the property name for
+	// properties.
+	Name     string `json:"name" yaml:"name"`
+	Override string `json:"override" yaml:"override"`
+	// Upstream is empty if upstream does not declare it at all.
+	Upstream string `json:"upstream,omitempty" yaml:"upstream,omitempty"`
+	// Redundant is true when upstream is at or past the override, so the
+	// override can be dropped.
+	Redundant bool `json:"redundant" yaml:"redundant"`
+}
+
+// DriftReport lists which local overrides upstream has caught up with.
+type DriftReport struct {
+	Upstream     string       `json:"upstream" yaml:"upstream"`
+	Dependencies []DriftEntry `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+	Properties   []DriftEntry `json:"properties,omitempty" yaml:"properties,omitempty"`
+}
+
+// Redundant returns the number of overrides that can be dropped.
+func (r *DriftReport) Redundant() int {
+	count := 0
+	for _, entries := range [][]DriftEntry{r.Dependencies, r.Properties} {
+		for _, e := range entries {
+			if e.Redundant {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// FetchUpstreamPOM fetches the POM of an upstream release. If version is
+// empty, the newest release is used.
+func FetchUpstreamPOM(ctx context.Context, repo *MavenRepository, groupID, artifactID, version string) (*gopom.Project, error) {
+	if version == "" {
+		versions, err := repo.Versions(ctx, groupID, artifactID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up versions of %s:%s: %w", groupID, artifactID, err)
+		}
+		if version = latestRelease(versions, ""); version == "" {
+			return nil, fmt.Errorf("no release of %s:%s found", groupID, artifactID)
+		}
+		clog.FromContext(ctx).Infof("Newest release of %s:%s is %s", groupID, artifactID, version)
+	}
+	return repo.FetchPOM(ctx, groupID, artifactID, version)
+}
+
+// CompareWithUpstream reports, for every local override (patches and
+// property patches), whether the upstream POM has caught up with it.
+func CompareWithUpstream(ctx context.Context, upstream *gopom.Project, patches []Patch, propertyPatches map[string]string) (*DriftReport, error) {
+	analysis, err := AnalyzeProject(ctx, upstream, WithoutBOMDetection())
+	if err != nil {
+		return nil, err
+	}
+	upstreamVersions := analysis.CurrentVersions()
+	if upstream.Parent != nil {
+		upstreamVersions[fmt.Sprintf("%s:%s", upstream.Parent.GroupID, upstream.Parent.ArtifactID)] = upstream.Parent.Version
+	}
+
+	report := &DriftReport{
+		Upstream:     fmt.Sprintf("%s:%s:%s", projectGroupID(upstream), upstream.ArtifactID, projectVersion(upstream)),
+		Dependencies: []DriftEntry{},
+		Properties:   []DriftEntry{},
+	}
+	for _, p := range patches {
+		name := fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID)
+		report.Dependencies = append(report.Dependencies, driftEntry(name, p.Version, upstreamVersions[name]))
+	}
+	for name, value := range propertyPatches {
+		report.Properties = append(report.Properties, driftEntry(name, value, analysis.Properties[name]))
+	}
+	sort.Slice(report.Dependencies, func(i, j int) bool { return report.Dependencies[i].Name < report.Dependencies[j].Name })
+	sort.Slice(report.Properties, func(i, j int) bool { return report.Properties[i].Name < report.Properties[j].Name })
+	return report, nil
+}
+
+func driftEntry(name, override, upstream string) DriftEntry {
+	return DriftEntry{
+		Name:      name,
+		Override:  override,
+		Upstream:  upstream,
+		Redundant: upstream != "" && !strings.Contains(upstream, "${") && !isVersionRange(override) && compareVersions(upstream, override) >= 0,
+	}
+}
@@ -0,0 +1,76 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareWithUpstream(t *testing.T) {
+	repo := fakeRepository(t, map[string]string{
+		"io/zipkin/zipkin-parent/maven-metadata.xml": `<metadata>
+  <versioning>
+    <versions>
+      <version>3.3.0</version>
+      <version>3.4.0</version>
+      <version>3.5.0-RC1</version>
+    </versions>
+  </versioning>
+</metadata>`,
+		"io/zipkin/zipkin-parent/3.4.0/zipkin-parent-3.4.0.pom": `<project>
+  <groupId>io.zipkin</groupId>
+  <artifactId>zipkin-parent</artifactId>
+  <version>3.4.0</version>
+  <properties>
+    <netty.version>4.1.100.Final</netty.version>
+    <jackson.version>2.15.2</jackson.version>
+    <logback.version>${unresolved.version}</logback.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-handler</artifactId>
+        <version>${netty.version}</version>
+      </dependency>
+      <dependency>
+        <groupId>org.json</groupId>
+        <artifactId>json</artifactId>
+        <version>20230618</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`,
+	})
+	ctx := context.Background()
+
+	upstream, err := FetchUpstreamPOM(ctx, repo, "io.zipkin", "zipkin-parent", "")
+	require.NoError(t, err)
+	assert.Equal(t, "3.4.0", upstream.Version)
+
+	patches := []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.94.Final"},
+		{GroupID: "org.json", ArtifactID: "json", Version: "20231013"},
+		{GroupID: "org.springframework", ArtifactID: "spring-context", Version: "6.2.7"},
+	}
+	properties := map[string]string{
+		"jackson.version": "2.15.2",
+		"logback.version": "1.2.13",
+	}
+	report, err := CompareWithUpstream(ctx, upstream, patches, properties)
+	require.NoError(t, err)
+
+	assert.Equal(t, "io.zipkin:zipkin-parent:3.4.0", report.Upstream)
+	assert.Equal(t, []DriftEntry{
+		{Name: "io.netty:netty-handler", Override: "4.1.94.Final", Upstream: "4.1.100.Final", Redundant: true},
+		{Name: "org.json:json", Override: "20231013", Upstream: "20230618"},
+		{Name: "org.springframework:spring-context", Override: "6.2.7"},
+	}, report.Dependencies)
+	assert.Equal(t, []DriftEntry{
+		{Name: "jackson.version", Override: "2.15.2", Upstream: "2.15.2", Redundant: true},
+		{Name: "logback.version", Override: "1.2.13", Upstream: "${unresolved.version}"},
+	}, report.Properties)
+	assert.Equal(t, 2, report.Redundant())
+}
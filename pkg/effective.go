@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+)
+
+// ManagedByEffectivePOM is the source of a version only known from the
+// effective POM Maven computed, see ApplyEffectivePOM.
+const ManagedByEffectivePOM = "effective-pom"
+
+// EffectivePOM runs mvn help:effective-pom on the POM at path, which must be
+// on the PATH, and returns the effective POM: with everything inherited from
+// parents and imported BOMs, and properties interpolated. Maven resolves
+// them with its own settings and local repository, so this works where
+// pombump can not reach the remote repository itself.
+func EffectivePOM(ctx context.Context, path string) (_ *gopom.Project, err error) {
+	log := clog.FromContext(ctx)
+	mvn, err := exec.LookPath("mvn")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find mvn: %w", err)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	output, err := os.CreateTemp("", "effective-pom-*.xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	if err := output.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	defer func() {
+		if err := os.Remove(output.Name()); err != nil {
+			log.Warnf("failed to remove %s: %v", output.Name(), err)
+		}
+	}()
+
+	log.Infof("Running mvn help:effective-pom on %s", abs)
+	var out bytes.Buffer
+	// Only this project, not the modules it aggregates.
+	command := exec.CommandContext(ctx, mvn, "-q", "-N", "-f", abs, "help:effective-pom", "-Doutput="+output.Name())
+	command.Dir = filepath.Dir(abs)
+	command.Stdout, command.Stderr = &out, &out
+	if err := command.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run mvn help:effective-pom: %w: %s", err, strings.TrimSpace(out.String()))
+	}
+	effective, err := ParsePOMFile(output.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the effective POM: %w", err)
+	}
+	return effective, nil
+}
+
+// ApplyEffectivePOM completes the analysis with what effective, the
+// effective POM of the project, resolved: the properties the project does not
+// define (inherited from its parents) and the managed version of every
+// artifact, used for dependencies whose version is otherwise unknown. The
+// parent and BOMs resolved by ResolveBOMs still win.
+func (result *AnalysisResult) ApplyEffectivePOM(ctx context.Context, effective *gopom.Project) {
+	result.IndexDependencies(ctx)
+	if effective.Properties != nil {
+		mergeProperties(ctx, result.Properties, effective.Properties.Entries, "effective POM")
+		for _, info := range result.Dependencies {
+			result.resolveDependencyProperties(ctx, info)
+		}
+	}
+
+	result.effectiveManaged = map[string]string{}
+	lists := []*[]gopom.Dependency{}
+	if effective.DependencyManagement != nil {
+		lists = append(lists, effective.DependencyManagement.Dependencies)
+	}
+	lists = append(lists, effective.Dependencies)
+	for _, deps := range lists {
+		if deps == nil {
+			continue
+		}
+		for _, dep := range *deps {
+			key := fmt.Sprintf("%s:%s", dep.GroupID, dep.ArtifactID)
+			if _, exists := result.effectiveManaged[key]; !exists && dep.Version != "" && isDefaultArtifact(dep) {
+				result.effectiveManaged[key] = dep.Version
+			}
+		}
+	}
+	clog.FromContext(ctx).Infof("Effective POM manages %d artifacts", len(result.effectiveManaged))
+	result.attributeVersionless()
+}
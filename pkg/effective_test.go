@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyEffectivePOM(t *testing.T) {
+	project := &gopom.Project{
+		Parent: &gopom.Parent{GroupID: "com.example", ArtifactID: "parent", Version: "1.0"},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-handler"},
+			{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "${slf4j.version}"},
+		},
+	}
+	analysis, err := AnalyzeProject(context.Background(), project)
+	require.NoError(t, err)
+	assert.Empty(t, analysis.CurrentVersions())
+
+	effective := &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{"slf4j.version": "2.0.7"}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.94.Final"},
+			{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.7"},
+		},
+		DependencyManagement: &gopom.DependencyManagement{Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.94.Final"},
+			{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "4.1.94.Final"},
+		}},
+	}
+	analysis.ApplyEffectivePOM(context.Background(), effective)
+
+	assert.Equal(t, map[string]string{
+		"io.netty:netty-handler": "4.1.94.Final",
+		"org.slf4j:slf4j-api":    "2.0.7",
+	}, analysis.CurrentVersions())
+	assert.Equal(t, &Management{Source: ManagedByEffectivePOM, Version: "4.1.94.Final"}, analysis.Dependencies["io.netty:netty-handler"].ManagedBy)
+	assert.Equal(t, "pkg:maven/org.slf4j/slf4j-api@2.0.7", analysis.Dependencies["org.slf4j:slf4j-api"].Purl)
+	// Not a dependency, but managed all the same.
+	managed, ok := analysis.ManagedVersion("io.netty", "netty-codec")
+	require.True(t, ok)
+	assert.Equal(t, "effective POM (4.1.94.Final)", managed.String())
+}
+
+func TestEffectivePOM(t *testing.T) {
+	// A fake mvn writing a canned effective POM to -Doutput.
+	bin := t.TempDir()
+	script := `#!/bin/sh
+for arg in "$@"; do
+  case "$arg" in
+    -Doutput=*) out="${arg#-Doutput=}" ;;
+  esac
+done
+cat > "$out" <<'POM'
+<project>
+  <modelVersion>4.0.0</modelVersion>
+  <properties><netty.version>4.1.94.Final</netty.version></properties>
+</project>
+POM
+`
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "mvn"), []byte(script), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	pom := filepath.Join(t.TempDir(), "pom.xml")
+	require.NoError(t, os.WriteFile(pom, []byte("<project/>"), 0644))
+	effective, err := EffectivePOM(context.Background(), pom)
+	require.NoError(t, err)
+	assert.Equal(t, "4.1.94.Final", effective.Properties.Entries["netty.version"])
+
+	t.Setenv("PATH", t.TempDir())
+	_, err = EffectivePOM(context.Background(), pom)
+	assert.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/chainguard-dev/gopom"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/transform"
+)
+
+// xmlDeclarationEncoding matches the encoding of an XML declaration, which
+// is all ASCII whatever the encoding.
+var xmlDeclarationEncoding = regexp.MustCompile(`^(?:\xef\xbb\xbf)?<\?xml\s[^>]*?encoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// POMEncoding returns the encoding the XML declaration of data names, UTF-8
+// if it has none.
+func POMEncoding(data []byte) string {
+	if m := xmlDeclarationEncoding.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return "UTF-8"
+}
+
+// isUTF8 reports whether name is UTF-8, or US-ASCII, which is a subset.
+func isUTF8(name string) bool {
+	return strings.EqualFold(name, "UTF-8") || strings.EqualFold(name, "UTF8") || strings.EqualFold(name, "US-ASCII")
+}
+
+// lookupEncoding returns the encoding named name, by its IANA name or
+// alias, such as ISO-8859-1 or latin1.
+func lookupEncoding(name string) (encoding.Encoding, error) {
+	enc, err := ianaindex.IANA.Encoding(name)
+	if err != nil || enc == nil {
+		return nil, fmt.Errorf("unsupported encoding %q", name)
+	}
+	return enc, nil
+}
+
+// charsetReader is the CharsetReader of the decoders of POMs, so that the
+// encoding their declaration names is honored.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	if isUTF8(label) {
+		return input, nil
+	}
+	enc, err := lookupEncoding(label)
+	if err != nil {
+		return nil, err
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// newPOMDecoder returns a decoder of the POM read from r, in the encoding
+// its declaration names.
+func newPOMDecoder(r io.Reader) *xml.Decoder {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charsetReader
+	return decoder
+}
+
+// unmarshalPOM is xml.Unmarshal, honoring the encoding of the POM.
+func unmarshalPOM(data []byte, v any) error {
+	return newPOMDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// ParsePOMFile is gopom.Parse, honoring the encoding the XML declaration of
+// the POM names, such as the ISO-8859-1 of older projects, which gopom
+// rejects.
+func ParsePOMFile(path string) (*gopom.Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var project gopom.Project
+	if err := unmarshalPOM(data, &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// DecodePOM converts data, a POM, to UTF-8 from the encoding its
+// declaration names, to embed it in JSON or YAML say. The declaration itself
+// is left as it is.
+func DecodePOM(data []byte) ([]byte, error) {
+	name := POMEncoding(data)
+	if isUTF8(name) {
+		return data, nil
+	}
+	enc, err := lookupEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	out, _, err := transform.Bytes(enc.NewDecoder(), data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode POM from %s: %w", name, err)
+	}
+	return out, nil
+}
+
+// EncodePOM converts data, a POM written as UTF-8, to the encoding its
+// declaration names, so that a POM is written back in the encoding it was
+// read in (see RestorePOMProlog for the declaration). Characters the encoding
+// lacks are written as character references.
+func EncodePOM(data []byte) ([]byte, error) {
+	name := POMEncoding(data)
+	if isUTF8(name) {
+		return data, nil
+	}
+	enc, err := lookupEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	out, _, err := transform.Bytes(encoding.HTMLEscapeUnsupported(enc.NewEncoder()), data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode POM as %s: %w", name, err)
+	}
+	return out, nil
+}
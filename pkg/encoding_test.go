@@ -0,0 +1,88 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// latin1POM is an ISO-8859-1 POM, \xfc being ü and \xe9 é.
+const latin1POM = "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n" +
+	"<!-- Copyright J\xfcrgen M\xfcller -->\n" +
+	`<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <modelVersion>4.0.0</modelVersion>
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0</version>
+` + "  <name>Caf\xe9 M\xfcller</name>\n" + `  <properties>
+    <netty.version>4.1.94.Final</netty.version>
+  </properties>
+</project>
+`
+
+func TestPOMEncoding(t *testing.T) {
+	assert.Equal(t, "ISO-8859-1", POMEncoding([]byte(latin1POM)))
+	assert.Equal(t, "latin1", POMEncoding([]byte(`<?xml version='1.0' encoding='latin1'?><project/>`)))
+	assert.Equal(t, "UTF-8", POMEncoding([]byte(`<?xml version="1.0"?><project/>`)))
+	assert.Equal(t, "UTF-8", POMEncoding([]byte(`<project/>`)))
+}
+
+func TestParsePOMFileLatin1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pom.xml")
+	require.NoError(t, os.WriteFile(path, []byte(latin1POM), 0644))
+
+	project, err := ParsePOMFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Café Müller", project.Name)
+	assert.Equal(t, "4.1.94.Final", project.Properties.Entries["netty.version"])
+
+	streamed, err := StreamPOM(bytes.NewReader([]byte(latin1POM)))
+	require.NoError(t, err)
+	assert.Equal(t, "Café Müller", streamed.Name)
+
+	violations, err := ValidatePOMSchema([]byte(latin1POM))
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+
+	_, err = parsePOMData([]byte(`<?xml version="1.0" encoding="x-made-up"?><project/>`))
+	assert.ErrorContains(t, err, `unsupported encoding "x-made-up"`)
+}
+
+func TestAnalyzerPatchLatin1(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "pom.xml")
+	require.NoError(t, os.WriteFile(path, []byte(latin1POM), 0644))
+
+	patched, err := NewAnalyzer().Patch(ctx, path, nil, map[string]string{"netty.version": "4.1.100.Final"})
+	require.NoError(t, err)
+	// Written back as ISO-8859-1, the declaration and comment as they were.
+	assert.True(t, bytes.HasPrefix(patched, []byte(latin1POM[:bytes.Index([]byte(latin1POM), []byte("<modelVersion>"))])), string(patched))
+	assert.Contains(t, string(patched), "<name>Caf\xe9 M\xfcller</name>")
+	assert.NotContains(t, string(patched), "Müller")
+	assert.Contains(t, string(patched), "<netty.version>4.1.100.Final</netty.version>")
+
+	decoded, err := DecodePOM(patched)
+	require.NoError(t, err)
+	assert.Contains(t, string(decoded), "<name>Café Müller</name>")
+}
+
+func TestEncodePOM(t *testing.T) {
+	// UTF-8 POMs are left alone.
+	utf8 := []byte("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<project><name>Müller</name></project>")
+	out, err := EncodePOM(utf8)
+	require.NoError(t, err)
+	assert.Equal(t, utf8, out)
+
+	// What ISO-8859-1 lacks becomes a character reference.
+	out, err = EncodePOM([]byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<project><name>Müller €</name></project>"))
+	require.NoError(t, err)
+	assert.Equal(t, "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n<project><name>M\xfcller &#8364;</name></project>", string(out))
+	project, err := parsePOMData(out)
+	require.NoError(t, err)
+	assert.Equal(t, "Müller €", project.Name)
+}
@@ -0,0 +1,102 @@
+package pkg
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// DefaultExcludePatterns are generated or vendored locations that should not
+// be treated as part of the project when searching for POM files. Patterns
+// with a trailing slash match directories, everything else matches files.
+// Patterns without a slash are matched against the base name only.
+var DefaultExcludePatterns = []string{
+	"target/",
+	"build/",
+	"node_modules/",
+	"dist/",
+	"out/",
+	".flattened-pom.xml",
+	"flattened-pom.xml",
+	"dependency-reduced-pom.xml",
+}
+
+// PathFilter decides which files and directories are skipped when walking a
+// project tree looking for POM files.
+type PathFilter struct {
+	// Exclude patterns, see DefaultExcludePatterns for the format.
+	Exclude []string
+	// Include patterns take precedence over Exclude, so that specific
+	// skipped files or directories can be opted back in.
+	Include []string
+}
+
+// NewPathFilter returns a PathFilter with the default exclusions plus any
+// extra ones given.
+func NewPathFilter(exclude, include []string) *PathFilter {
+	return &PathFilter{
+		Exclude: append(append([]string{}, DefaultExcludePatterns...), exclude...),
+		Include: include,
+	}
+}
+
+// skipDir reports whether a directory (relative to the project root) should
+// not be descended into. Directories that contain an included path are still
+// walked.
+func (f *PathFilter) skipDir(rel string) bool {
+	if f == nil || !matchesAny(f.Exclude, rel, true) {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, p := range f.Include {
+		if matchPattern(p, rel, true) || strings.HasPrefix(strings.TrimSuffix(p, "/"), rel+"/") {
+			return false
+		}
+	}
+	return true
+}
+
+// skipFile reports whether a file (relative to the project root) should be
+// ignored, either because it matches an exclusion itself or because it lives
+// in an excluded directory.
+func (f *PathFilter) skipFile(rel string) bool {
+	if f == nil {
+		return false
+	}
+	excluded := matchesAny(f.Exclude, rel, false)
+	included := matchesAny(f.Include, rel, false)
+	for dir := filepath.Dir(rel); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		if matchesAny(f.Exclude, dir, true) {
+			excluded = true
+		}
+		if matchesAny(f.Include, dir, true) {
+			included = true
+		}
+	}
+	return excluded && !included
+}
+
+func matchesAny(patterns []string, rel string, isDir bool) bool {
+	rel = filepath.ToSlash(rel)
+	for _, p := range patterns {
+		if matchPattern(p, rel, isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern matches a single exclude/include pattern against a slash
+// separated relative path.
+func matchPattern(pattern, rel string, isDir bool) bool {
+	dirPattern := strings.HasSuffix(pattern, "/")
+	if dirPattern != isDir {
+		return false
+	}
+	pattern = strings.TrimSuffix(pattern, "/")
+	target := rel
+	if !strings.Contains(pattern, "/") {
+		target = filepath.Base(rel)
+	}
+	matched, err := filepath.Match(pattern, target)
+	return err == nil && matched
+}
@@ -0,0 +1,65 @@
+package pkg
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/chainguard-dev/gopom"
+)
+
+// Exclusion is a transitive dependency a patch excludes from the patched
+// dependency, or with Remove stops excluding. Either ID may be * as in
+// Maven.
+type Exclusion struct {
+	GroupID    string `json:"groupId" yaml:"groupId"`
+	ArtifactID string `json:"artifactId" yaml:"artifactId"`
+	Remove     bool   `json:"remove,omitempty" yaml:"remove,omitempty"`
+}
+
+// validateExclusions checks that every exclusion of p names an artifact.
+func validateExclusions(p Patch) error {
+	for _, e := range p.Exclusions {
+		if e.GroupID == "" || e.ArtifactID == "" {
+			return fmt.Errorf("patch %s.%s: exclusions need both a groupId and an artifactId", p.GroupID, p.ArtifactID)
+		}
+	}
+	return nil
+}
+
+// patchDependency applies patch to dep: bumps its version, unless the patch
+// only changes exclusions, and adds or removes its exclusions. A composite
+// version keeps its properties where it can, see patchedVersion.
+func patchDependency(dep *gopom.Dependency, patch Patch, props map[string]string) {
+	if patch.Version != "" {
+		dep.Version = patchedVersion(dep.Version, patch.Version, props)
+	}
+	applyExclusions(dep, patch.Exclusions)
+}
+
+// applyExclusions adds the exclusions dep does not have yet and removes
+// those marked Remove. The <exclusions> element is dropped once empty.
+func applyExclusions(dep *gopom.Dependency, exclusions []Exclusion) {
+	if len(exclusions) == 0 {
+		return
+	}
+	current := []gopom.Exclusion{}
+	if dep.Exclusions != nil {
+		current = *dep.Exclusions
+	}
+	for _, e := range exclusions {
+		i := slices.IndexFunc(current, func(c gopom.Exclusion) bool {
+			return c.GroupID == e.GroupID && c.ArtifactID == e.ArtifactID
+		})
+		switch {
+		case e.Remove && i >= 0:
+			current = slices.Delete(current, i, i+1)
+		case !e.Remove && i < 0:
+			current = append(current, gopom.Exclusion{GroupID: e.GroupID, ArtifactID: e.ArtifactID})
+		}
+	}
+	if len(current) == 0 {
+		dep.Exclusions = nil
+		return
+	}
+	dep.Exclusions = &current
+}
@@ -0,0 +1,111 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyExclusions(t *testing.T) {
+	testCases := []struct {
+		name       string
+		current    *[]gopom.Exclusion
+		exclusions []Exclusion
+		want       *[]gopom.Exclusion
+	}{{
+		name:       "added",
+		exclusions: []Exclusion{{GroupID: "org.apache.avro", ArtifactID: "avro"}},
+		want:       &[]gopom.Exclusion{{GroupID: "org.apache.avro", ArtifactID: "avro"}},
+	}, {
+		name:       "not duplicated",
+		current:    &[]gopom.Exclusion{{GroupID: "org.apache.avro", ArtifactID: "avro"}},
+		exclusions: []Exclusion{{GroupID: "org.apache.avro", ArtifactID: "avro"}, {GroupID: "*", ArtifactID: "*"}},
+		want:       &[]gopom.Exclusion{{GroupID: "org.apache.avro", ArtifactID: "avro"}, {GroupID: "*", ArtifactID: "*"}},
+	}, {
+		name:       "removed",
+		current:    &[]gopom.Exclusion{{GroupID: "log4j", ArtifactID: "log4j"}, {GroupID: "org.apache.avro", ArtifactID: "avro"}},
+		exclusions: []Exclusion{{GroupID: "log4j", ArtifactID: "log4j", Remove: true}},
+		want:       &[]gopom.Exclusion{{GroupID: "org.apache.avro", ArtifactID: "avro"}},
+	}, {
+		name:       "last one removed",
+		current:    &[]gopom.Exclusion{{GroupID: "log4j", ArtifactID: "log4j"}},
+		exclusions: []Exclusion{{GroupID: "log4j", ArtifactID: "log4j", Remove: true}},
+	}, {
+		name:       "removing a missing one",
+		exclusions: []Exclusion{{GroupID: "log4j", ArtifactID: "log4j", Remove: true}},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dep := gopom.Dependency{GroupID: "g", ArtifactID: "a", Exclusions: tc.current}
+			applyExclusions(&dep, tc.exclusions)
+			if diff := cmp.Diff(tc.want, dep.Exclusions); diff != "" {
+				t.Errorf("exclusions (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPatchProjectExclusions(t *testing.T) {
+	project := &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{"netty.version": "4.1.94.Final"}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "org.apache.hadoop", ArtifactID: "hadoop-common", Version: "3.3.4", Exclusions: &[]gopom.Exclusion{{GroupID: "log4j", ArtifactID: "log4j"}}},
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "${netty.version}"},
+		},
+	}
+	patches, err := ParsePatches(context.Background(), "testdata/exclusions-patches.yaml", "")
+	require.NoError(t, err)
+
+	analysis, err := AnalyzeProject(context.Background(), project)
+	require.NoError(t, err)
+	patches = append(patches, Patch{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final"})
+	direct, props := PatchStrategy(context.Background(), analysis, patches)
+	assert.Equal(t, map[string]string{"netty.version": "4.1.118.Final"}, props)
+
+	got, err := PatchProject(context.Background(), project, direct, props)
+	require.NoError(t, err)
+	want := []gopom.Dependency{
+		{GroupID: "org.apache.hadoop", ArtifactID: "hadoop-common", Version: "3.3.6", Exclusions: &[]gopom.Exclusion{{GroupID: "org.apache.avro", ArtifactID: "avro"}}},
+		// The version stays a property reference, only the exclusion is added.
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "${netty.version}", Exclusions: &[]gopom.Exclusion{{GroupID: "io.netty", ArtifactID: "netty-tcnative-classes"}}},
+	}
+	if diff := cmp.Diff(want, *got.Dependencies); diff != "" {
+		t.Errorf("dependencies (-want +got):\n%s", diff)
+	}
+	// Nothing without a version gets added.
+	assert.Nil(t, got.DependencyManagement)
+}
+
+func TestPatchProjectExclusionsMissing(t *testing.T) {
+	patches := []Patch{
+		{GroupID: "org.apache.hadoop", ArtifactID: "hadoop-common", Version: "3.3.6", Scope: "import", Type: "jar", Exclusions: []Exclusion{{GroupID: "org.apache.avro", ArtifactID: "avro"}}},
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Exclusions: []Exclusion{{GroupID: "io.netty", ArtifactID: "netty-tcnative-classes"}}},
+	}
+	got, err := PatchProject(context.Background(), &gopom.Project{}, patches, nil)
+	require.NoError(t, err)
+	want := []gopom.Dependency{
+		{GroupID: "org.apache.hadoop", ArtifactID: "hadoop-common", Version: "3.3.6", Scope: "import", Type: "jar", Exclusions: &[]gopom.Exclusion{{GroupID: "org.apache.avro", ArtifactID: "avro"}}},
+	}
+	if diff := cmp.Diff(want, *got.DependencyManagement.Dependencies); diff != "" {
+		t.Errorf("dependencyManagement (-want +got):\n%s", diff)
+	}
+}
+
+func TestParsePatchesInvalidExclusion(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "patches.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`patches:
+  - groupId: io.netty
+    artifactId: netty-handler
+    version: 4.1.118.Final
+    exclusions:
+      - groupId: io.netty
+`), 0644))
+	_, err := ParsePatches(context.Background(), file, "")
+	assert.Error(t, err)
+}
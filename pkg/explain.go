@@ -0,0 +1,258 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chainguard-dev/gopom"
+)
+
+// settingsSource is the PropertySource of the properties of the Maven
+// settings.
+const settingsSource = "settings.xml"
+
+// Where the version of a dependency comes from, besides the ManagedBy
+// sources, see VersionProvenance.
+const (
+	ProvenanceLiteral  = "literal"
+	ProvenanceProperty = "property"
+	ProvenanceUnknown  = "unknown"
+)
+
+// recordPropertySources records that the properties in properties the
+// analysis does not define yet are defined in the file sources gives for
+// each, the Maven settings if sources is nil.
+func (result *AnalysisResult) recordPropertySources(properties, sources map[string]string) {
+	if result.propertySources == nil {
+		result.propertySources = map[string]string{}
+	}
+	for name := range properties {
+		if _, defined := result.Properties[name]; defined {
+			continue
+		}
+		if sources == nil {
+			result.propertySources[name] = settingsSource
+		} else {
+			result.propertySources[name] = sources[name]
+		}
+	}
+}
+
+// PropertySource returns the file defining the property name: a nearby POM
+// relative to the project root, or settings.xml. It is empty for the
+// properties of the analyzed POM itself.
+func (result *AnalysisResult) PropertySource(name string) string {
+	return result.propertySources[name]
+}
+
+// Declaration is an entry of a POM for an artifact.
+type Declaration struct {
+	// Section is dependencies, dependencyManagement or the plugin whose
+	// dependencies it is in, e.g. plugin maven-compiler-plugin.
+	Section string `json:"section" yaml:"section"`
+	// Version is the version as written, empty if there is none.
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// VersionProvenance tells where the version of an artifact comes from, see
+// Explain.
+type VersionProvenance struct {
+	GroupID    string `json:"groupId" yaml:"groupId"`
+	ArtifactID string `json:"artifactId" yaml:"artifactId"`
+	// Version is the version in effect, empty if unknown.
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Source is what sets the version: literal, property, one of the
+	// ManagedBy sources, or unknown.
+	Source string `json:"source" yaml:"source"`
+	// Declarations are the entries of the POM for the artifact.
+	Declarations []Declaration `json:"declarations,omitempty" yaml:"declarations,omitempty"`
+	// Property is the property holding the version, PropertyChain the
+	// properties leading to it, if any, and PropertyFile the file defining
+	// it, empty for the POM itself.
+	Property      string   `json:"property,omitempty" yaml:"property,omitempty"`
+	PropertyChain []string `json:"propertyChain,omitempty" yaml:"propertyChain,omitempty"`
+	PropertyFile  string   `json:"propertyFile,omitempty" yaml:"propertyFile,omitempty"`
+	// Properties are those a composite version like ${major}.${minor}.0 is
+	// made of.
+	Properties []string `json:"properties,omitempty" yaml:"properties,omitempty"`
+	// ManagedBy is what manages the version when the POM declares the
+	// artifact without one, or not at all.
+	ManagedBy *Management `json:"managedBy,omitempty" yaml:"managedBy,omitempty"`
+	// Overrides is the parent or BOM whose managed version the declared
+	// one wins over.
+	Overrides *Management `json:"overrides,omitempty" yaml:"overrides,omitempty"`
+	// Target is what to patch to change the version.
+	Target string `json:"target" yaml:"target"`
+}
+
+// declarations returns the entries of the project for groupID:artifactID.
+func (result *AnalysisResult) declarations(groupID, artifactID string) []Declaration {
+	project := result.project
+	props := coordinateProperties(project)
+	patch := Patch{GroupID: groupID, ArtifactID: artifactID}
+	type section struct {
+		name string
+		deps *[]gopom.Dependency
+	}
+	sections := []section{{targetDependencies, project.Dependencies}}
+	if project.DependencyManagement != nil {
+		sections = append(sections, section{targetDependencyManagement, project.DependencyManagement.Dependencies})
+	}
+	for _, plugin := range pluginDependencyLists(project) {
+		sections = append(sections, section{"plugin " + plugin.Plugin, plugin.Dependencies})
+	}
+
+	declarations := []Declaration{}
+	for _, section := range sections {
+		if section.deps == nil {
+			continue
+		}
+		for _, i := range matchingDependencies(*section.deps, patch, props) {
+			declarations = append(declarations, Declaration{Section: section.name, Version: (*section.deps)[i].Version})
+		}
+	}
+	return declarations
+}
+
+// Explain tells where the version of groupID:artifactID comes from. Only a
+// parent and BOMs resolved by ResolveBOMs, and an effective POM applied by
+// ApplyEffectivePOM, are known to manage it. It fails for an artifact the
+// POM neither declares nor is known to manage.
+func (result *AnalysisResult) Explain(groupID, artifactID string) (*VersionProvenance, error) {
+	if result.project == nil {
+		return nil, fmt.Errorf("the provenance of versions is only known for a single POM")
+	}
+	result.ensureDependencies()
+	key := fmt.Sprintf("%s:%s", groupID, artifactID)
+	provenance := &VersionProvenance{GroupID: groupID, ArtifactID: artifactID, Declarations: result.declarations(groupID, artifactID)}
+
+	// A version in dependencies, or that of a plugin, wins over the
+	// managed one.
+	var declared *Declaration
+	for i, d := range provenance.Declarations {
+		if d.Version != "" && (declared == nil || declared.Section == targetDependencyManagement) {
+			declared = &provenance.Declarations[i]
+		}
+	}
+	if declared == nil {
+		managed, ok := result.ManagedVersion(groupID, artifactID)
+		if info := result.Dependencies[key]; info != nil && info.ManagedBy != nil {
+			managed, ok = info.ManagedBy, true
+		}
+		if !ok && len(provenance.Declarations) == 0 {
+			return nil, fmt.Errorf("%s is neither declared in the POM nor known to be managed by its parent or BOMs", key)
+		}
+		if !ok {
+			provenance.Source = ProvenanceUnknown
+			provenance.Target = "resolve the parent and BOMs to know what manages it"
+			if bom, found := result.BOMForGroup(groupID); found {
+				provenance.Target = fmt.Sprintf("probably BOM %s:%s, resolve it to know", bom.GroupID, bom.ArtifactID)
+			}
+			return provenance, nil
+		}
+		provenance.Source = managed.Source
+		provenance.ManagedBy = managed
+		provenance.Version = managed.Version
+		switch managed.Source {
+		case ManagedByParent:
+			provenance.Target = fmt.Sprintf("bump the parent %s:%s, or override it in dependencyManagement", managed.GroupID, managed.ArtifactID)
+		case ManagedByBOM:
+			provenance.Target = fmt.Sprintf("bump BOM %s:%s, or override it in dependencyManagement", managed.GroupID, managed.ArtifactID)
+		default:
+			provenance.Target = "override it in dependencyManagement"
+		}
+		return provenance, nil
+	}
+
+	provenance.Version = interpolate(declared.Version, result.Properties)
+	provenance.Source = ProvenanceLiteral
+	provenance.Target = "the version in " + declared.Section
+	if declared.Section == targetDependencyManagement && len(provenance.Declarations) > 1 {
+		// Declared without a version, managed by the project.
+		provenance.Source = ManagedByDependencyManagement
+		provenance.ManagedBy = &Management{Source: ManagedByDependencyManagement, Version: provenance.Version}
+	}
+	if name, ok := propertyReference(declared.Version); ok {
+		chain, _, err := result.PropertyChain(name)
+		if err != nil || len(chain) == 0 {
+			chain = []string{name}
+		}
+		if provenance.Source == ProvenanceLiteral {
+			provenance.Source = ProvenanceProperty
+		}
+		provenance.Property = chain[len(chain)-1]
+		if len(chain) > 1 {
+			provenance.PropertyChain = chain
+		}
+		provenance.PropertyFile = result.PropertySource(provenance.Property)
+		if _, defined := result.Properties[provenance.Property]; !defined {
+			provenance.Version = ""
+			provenance.Target = fmt.Sprintf("property %s, not defined here, it may be in an external parent POM", provenance.Property)
+		} else {
+			provenance.Target = "property " + provenance.Property
+			if provenance.PropertyFile != "" {
+				provenance.Target += " in " + provenance.PropertyFile
+			}
+		}
+	} else if names := propertyReferences(declared.Version); len(names) > 0 {
+		if provenance.Source == ProvenanceLiteral {
+			provenance.Source = ProvenanceProperty
+		}
+		provenance.Properties = names
+		provenance.Target = fmt.Sprintf("properties %s or the literal parts of %s", strings.Join(names, ", "), declared.Version)
+	}
+	if strings.Contains(provenance.Version, "${") {
+		provenance.Version = ""
+	}
+	if declared.Section == targetDependencies {
+		if managed, ok := result.ManagedVersion(groupID, artifactID); ok {
+			provenance.Overrides = managed
+		}
+	}
+	return provenance, nil
+}
+
+// String renders the provenance for humans.
+func (p *VersionProvenance) String() string {
+	var out strings.Builder
+	version := p.Version
+	if version == "" {
+		version = "(unknown version)"
+	}
+	out.WriteString(fmt.Sprintf("%s:%s %s\n", p.GroupID, p.ArtifactID, version))
+	for _, d := range p.Declarations {
+		if d.Version == "" {
+			out.WriteString(fmt.Sprintf("  Declared in %s without a version\n", d.Section))
+		} else {
+			out.WriteString(fmt.Sprintf("  Declared in %s as %s\n", d.Section, d.Version))
+		}
+	}
+	if len(p.Declarations) == 0 {
+		out.WriteString("  Not declared in the POM\n")
+	}
+	switch {
+	case p.Property != "":
+		source := "property " + p.Property
+		if len(p.PropertyChain) > 0 {
+			source += " through " + strings.Join(p.PropertyChain[:len(p.PropertyChain)-1], ", ")
+		}
+		if p.PropertyFile != "" {
+			source += ", defined in " + p.PropertyFile
+		}
+		if p.Source == ManagedByDependencyManagement {
+			source = "dependencyManagement, " + source
+		}
+		out.WriteString(fmt.Sprintf("  Source: %s\n", source))
+	case len(p.Properties) > 0:
+		out.WriteString(fmt.Sprintf("  Source: composite version of properties %s\n", strings.Join(p.Properties, ", ")))
+	case p.ManagedBy != nil:
+		out.WriteString(fmt.Sprintf("  Source: %s\n", managementSource(p.ManagedBy)))
+	default:
+		out.WriteString(fmt.Sprintf("  Source: %s\n", p.Source))
+	}
+	if p.Overrides != nil {
+		out.WriteString(fmt.Sprintf("  Pins over %s\n", p.Overrides))
+	}
+	out.WriteString(fmt.Sprintf("  Patch: %s\n", p.Target))
+	return out.String()
+}
@@ -0,0 +1,115 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pom.xml"), []byte(`<project>
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0</version>
+  <properties><netty-handler.version>${netty.version}</netty-handler.version></properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency><groupId>io.netty</groupId><artifactId>netty-codec</artifactId><version>4.1.94.Final</version></dependency>
+      <dependency><groupId>io.netty</groupId><artifactId>netty-bom</artifactId><version>4.1.94.Final</version><type>pom</type><scope>import</scope></dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency><groupId>io.netty</groupId><artifactId>netty-handler</artifactId><version>${netty-handler.version}</version></dependency>
+    <dependency><groupId>io.netty</groupId><artifactId>netty-codec</artifactId></dependency>
+    <dependency><groupId>io.netty</groupId><artifactId>netty-buffer</artifactId></dependency>
+    <dependency><groupId>org.yaml</groupId><artifactId>snakeyaml</artifactId><version>2.0</version></dependency>
+  </dependencies>
+</project>`), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "parent"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "parent", "pom.xml"), []byte(`<project>
+  <artifactId>parent</artifactId>
+  <properties><netty.version>4.1.100.Final</netty.version></properties>
+</project>`), 0o644))
+
+	result, err := AnalyzeProjectPath(ctx, filepath.Join(dir, "pom.xml"))
+	require.NoError(t, err)
+
+	provenance, err := result.Explain("io.netty", "netty-handler")
+	require.NoError(t, err)
+	assert.Equal(t, &VersionProvenance{
+		GroupID:       "io.netty",
+		ArtifactID:    "netty-handler",
+		Version:       "4.1.100.Final",
+		Source:        ProvenanceProperty,
+		Declarations:  []Declaration{{Section: "dependencies", Version: "${netty-handler.version}"}},
+		Property:      "netty.version",
+		PropertyChain: []string{"netty-handler.version", "netty.version"},
+		PropertyFile:  "parent/pom.xml",
+		Target:        "property netty.version in parent/pom.xml",
+	}, provenance)
+	assert.Equal(t, `io.netty:netty-handler 4.1.100.Final
+  Declared in dependencies as ${netty-handler.version}
+  Source: property netty.version through netty-handler.version, defined in parent/pom.xml
+  Patch: property netty.version in parent/pom.xml
+`, provenance.String())
+
+	provenance, err = result.Explain("io.netty", "netty-codec")
+	require.NoError(t, err)
+	assert.Equal(t, ManagedByDependencyManagement, provenance.Source)
+	assert.Equal(t, "4.1.94.Final", provenance.Version)
+	assert.Equal(t, "the version in dependencyManagement", provenance.Target)
+
+	provenance, err = result.Explain("io.netty", "netty-buffer")
+	require.NoError(t, err)
+	assert.Equal(t, ProvenanceUnknown, provenance.Source)
+	assert.Equal(t, "probably BOM io.netty:netty-bom, resolve it to know", provenance.Target)
+
+	// Once resolved, the BOM manages it, and the literal version pins over
+	// what it manages.
+	result.boms[0].Managed = map[string]string{"io.netty:netty-buffer": "4.1.94.Final", "org.yaml:snakeyaml": "1.33"}
+	provenance, err = result.Explain("io.netty", "netty-buffer")
+	require.NoError(t, err)
+	assert.Equal(t, ManagedByBOM, provenance.Source)
+	assert.Equal(t, "4.1.94.Final", provenance.Version)
+	assert.Equal(t, "bump BOM io.netty:netty-bom, or override it in dependencyManagement", provenance.Target)
+
+	provenance, err = result.Explain("org.yaml", "snakeyaml")
+	require.NoError(t, err)
+	assert.Equal(t, ProvenanceLiteral, provenance.Source)
+	assert.Equal(t, &Management{Source: ManagedByBOM, GroupID: "io.netty", ArtifactID: "netty-bom", Version: "1.33"}, provenance.Overrides)
+	assert.Contains(t, provenance.String(), "  Pins over BOM io.netty:netty-bom (1.33)\n")
+
+	_, err = result.Explain("com.example", "missing")
+	assert.Error(t, err)
+}
+
+func TestExplainSettingsProperty(t *testing.T) {
+	settings := &Settings{
+		Profiles:       []SettingsProfile{{ID: "versions", Properties: &gopom.Properties{Entries: map[string]string{"a.version": "1.1"}}}},
+		ActiveProfiles: []string{"versions"},
+	}
+	result, err := AnalyzeProject(context.Background(), &gopom.Project{
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "g", ArtifactID: "a", Version: "${a.version}"},
+			{GroupID: "g", ArtifactID: "b", Version: "${b.version}"},
+		},
+	}, WithSettings(settings))
+	require.NoError(t, err)
+
+	provenance, err := result.Explain("g", "a")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1", provenance.Version)
+	assert.Equal(t, "property a.version in settings.xml", provenance.Target)
+
+	provenance, err = result.Explain("g", "b")
+	require.NoError(t, err)
+	assert.Empty(t, provenance.Version)
+	assert.Equal(t, "property b.version, not defined here, it may be in an external parent POM", provenance.Target)
+}
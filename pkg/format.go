@@ -0,0 +1,111 @@
+package pkg
+
+import (
+	"bytes"
+	"strings"
+)
+
+// gopomIndent is the indentation gopom marshals POMs with, per level.
+const gopomIndent = "    "
+
+// POMFormat is how a POM is laid out, beyond what XML makes of it.
+type POMFormat struct {
+	// Indent is one level of indentation: a tab, or some spaces.
+	Indent string
+	// LineEnding is "\n", or "\r\n".
+	LineEnding string
+	// FinalNewline is whether the file ends with a line ending.
+	FinalNewline bool
+}
+
+// DetectPOMFormat returns the format of data, a POM. The indentation is that
+// of the elements: tabs if most of the indented ones are indented with tabs,
+// else the fewest spaces any is indented with, gopom's four spaces if none is
+// indented. The line endings are CRLF if most lines end with CRLF.
+func DetectPOMFormat(data []byte) POMFormat {
+	format := POMFormat{Indent: gopomIndent, LineEnding: "\n"}
+	if len(data) == 0 {
+		return format
+	}
+	format.FinalNewline = data[len(data)-1] == '\n'
+	if crlf := bytes.Count(data, []byte("\r\n")); crlf > bytes.Count(data, []byte("\n"))-crlf {
+		format.LineEnding = "\r\n"
+	}
+	tabs, spaced, fewest := 0, 0, 0
+	for _, line := range strings.Split(string(data), "\n") {
+		content := strings.TrimLeft(line, " \t")
+		if !strings.HasPrefix(content, "<") || len(content) == len(line) {
+			continue
+		}
+		if line[0] == '\t' {
+			tabs++
+			continue
+		}
+		spaced++
+		if n := len(line) - len(strings.TrimLeft(line, " ")); fewest == 0 || n < fewest {
+			fewest = n
+		}
+	}
+	switch {
+	case tabs > spaced:
+		format.Indent = "\t"
+	case fewest > 0:
+		format.Indent = strings.Repeat(" ", fewest)
+	}
+	return format
+}
+
+// FormatPOM lays out data, a POM as gopom marshals it, in format. Only the
+// indentation of the lines starting with an element is changed, so that of
+// the lines of a multi-line value is left as it is. The line breaks and tabs
+// encoding/xml escapes in values are written as they were read.
+func FormatPOM(data []byte, format POMFormat) []byte {
+	lines := strings.Split(unescapeWhitespace(string(data)), "\n")
+	for i, line := range lines {
+		content := strings.TrimLeft(line, " ")
+		n := len(line) - len(content)
+		if format.Indent == gopomIndent || n == 0 || n%len(gopomIndent) != 0 || !strings.HasPrefix(content, "<") {
+			continue
+		}
+		lines[i] = strings.Repeat(format.Indent, n/len(gopomIndent)) + content
+	}
+	out := strings.Join(lines, format.LineEnding)
+	if format.FinalNewline && !strings.HasSuffix(out, format.LineEnding) {
+		out += format.LineEnding
+	}
+	return []byte(out)
+}
+
+// escapedWhitespace are the references encoding/xml writes for whitespace.
+var escapedWhitespace = strings.NewReplacer("&#xA;", "\n", "&#x9;", "\t", "&#xD;", "\r")
+
+// unescapeWhitespace turns the whitespace references of the values of data
+// back into whitespace. Those of attributes are left as they are, where
+// whitespace would not read the same.
+func unescapeWhitespace(data string) string {
+	var b strings.Builder
+	for data != "" {
+		// data starts with a value, up to the next tag.
+		end := strings.IndexByte(data, '<')
+		if end < 0 {
+			end = len(data)
+		}
+		b.WriteString(escapedWhitespace.Replace(data[:end]))
+		data = data[end:]
+		end = strings.IndexByte(data, '>')
+		if end < 0 {
+			end = len(data) - 1
+		}
+		b.WriteString(data[:end+1])
+		data = data[end+1:]
+	}
+	return b.String()
+}
+
+// RestorePOMFormat gives out, a POM marshalled from original, the format of
+// original, so that a diff between them shows only what was patched: its line
+// endings and indentation, see DetectPOMFormat, its XML declaration and
+// <project> start tag, see RestorePOMProlog, and its encoding, see EncodePOM.
+func RestorePOMFormat(original, out []byte) ([]byte, error) {
+	return EncodePOM(RestorePOMProlog(original, FormatPOM(out, DetectPOMFormat(original))))
+}
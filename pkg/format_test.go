@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// formatPOM is laid out the way gopom orders elements, with two spaces
+// per level.
+const formatPOM = `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://maven.apache.org/POM/4.0.0 http://maven.apache.org/xsd/maven-4.0.0.xsd">
+  <modelVersion>4.0.0</modelVersion>
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0</version>
+  <description>An app,
+    over two lines.</description>
+  <properties>
+    <netty.version>4.1.94.Final</netty.version>
+  </properties>
+  <dependencies>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-handler</artifactId>
+      <version>${netty.version}</version>
+    </dependency>
+  </dependencies>
+</project>
+`
+
+func TestDetectPOMFormat(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		pom  string
+		want POMFormat
+	}{{
+		name: "two spaces",
+		pom:  formatPOM,
+		want: POMFormat{Indent: "  ", LineEnding: "\n", FinalNewline: true},
+	}, {
+		name: "tabs and CRLF",
+		pom:  strings.ReplaceAll(strings.ReplaceAll(formatPOM, "  ", "\t"), "\n", "\r\n"),
+		want: POMFormat{Indent: "\t", LineEnding: "\r\n", FinalNewline: true},
+	}, {
+		name: "four spaces, no final newline",
+		pom:  strings.TrimSuffix(strings.ReplaceAll(formatPOM, "  ", "    "), "\n"),
+		want: POMFormat{Indent: "    ", LineEnding: "\n"},
+	}, {
+		name: "not indented",
+		pom:  "<project><modelVersion>4.0.0</modelVersion></project>",
+		want: POMFormat{Indent: "    ", LineEnding: "\n"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, DetectPOMFormat([]byte(tc.pom)))
+		})
+	}
+}
+
+func TestFormatPOM(t *testing.T) {
+	out := "<project>\n    <description>a\n        b</description>\n    <properties>\n        <a>1</a>\n    </properties>\n</project>"
+	assert.Equal(t,
+		"<project>\r\n\t<description>a\r\n        b</description>\r\n\t<properties>\r\n\t\t<a>1</a>\r\n\t</properties>\r\n</project>\r\n",
+		string(FormatPOM([]byte(out), POMFormat{Indent: "\t", LineEnding: "\r\n", FinalNewline: true})))
+	// gopom's own format is left as it is.
+	assert.Equal(t, out, string(FormatPOM([]byte(out), POMFormat{Indent: "    ", LineEnding: "\n"})))
+}
+
+func TestAnalyzerPatchKeepsFormat(t *testing.T) {
+	ctx := context.Background()
+	for name, pom := range map[string]string{
+		"two spaces":    formatPOM,
+		"tabs and CRLF": strings.ReplaceAll(strings.ReplaceAll(formatPOM, "  ", "\t"), "\n", "\r\n"),
+		"three spaces":  strings.TrimSuffix(strings.ReplaceAll(formatPOM, "  ", "   "), "\n"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "pom.xml")
+			require.NoError(t, os.WriteFile(path, []byte(pom), 0644))
+
+			patched, err := NewAnalyzer().Patch(ctx, path, nil, map[string]string{"netty.version": "4.1.100.Final"})
+			require.NoError(t, err)
+			// Only the patched property differs.
+			assert.Equal(t, strings.Replace(pom, "4.1.94.Final", "4.1.100.Final", 1), string(patched))
+		})
+	}
+}
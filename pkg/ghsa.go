@@ -0,0 +1,205 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ghsaCacheSuffix ends the name of the files GHSAResolver caches, so that
+// they can share a directory with the OSV entries of the same advisories.
+const ghsaCacheSuffix = ".github.json"
+
+// githubAdvisory is the subset of a global security advisory of the GitHub
+// Advisory Database that we care about.
+type githubAdvisory struct {
+	GHSAID          string `json:"ghsa_id"`
+	Vulnerabilities []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		FirstPatchedVersion string `json:"first_patched_version"`
+	} `json:"vulnerabilities"`
+}
+
+// GHSAResolver resolves GHSA identifiers into fixed versions with the GitHub
+// Advisory Database, which is where they come from. Responses are cached in
+// CacheDir if set.
+type GHSAResolver struct {
+	APIURL   string
+	Token    string
+	CacheDir string
+	// CacheTTL is how long a cached response is used for. Zero keeps
+	// them forever.
+	CacheTTL time.Duration
+	Client   *http.Client
+}
+
+// NewGHSAResolver returns a resolver talking to the GitHub API (see
+// NewGitHubClient for $GITHUB_API_URL), with the token in $GITHUB_TOKEN or
+// $GH_TOKEN if set: the API allows few requests without one. Responses are
+// cached in cacheDir or, if empty, the user cache directory.
+func NewGHSAResolver(cacheDir string) *GHSAResolver {
+	if cacheDir == "" {
+		if userCache, err := os.UserCacheDir(); err == nil {
+			cacheDir = filepath.Join(userCache, "pombump", "ghsa")
+		}
+	}
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	client := NewGitHubClient(token)
+	return &GHSAResolver{
+		APIURL:   client.APIURL,
+		Token:    token,
+		CacheDir: cacheDir,
+		CacheTTL: DefaultCacheTTL,
+		Client:   client.Client,
+	}
+}
+
+// IsGHSAID reports whether id is a GitHub Security Advisory identifier.
+func IsGHSAID(id string) bool {
+	return strings.HasPrefix(id, "GHSA-")
+}
+
+// FixedVersion returns the minimal version of groupID:artifactID that fixes
+// the advisory id. An advisory lists one vulnerable range per release line,
+// of which the one of current is preferred.
+func (r *GHSAResolver) FixedVersion(ctx context.Context, id, groupID, artifactID, current string) (string, error) {
+	advisory, err := r.fetch(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s:%s", groupID, artifactID)
+	fixes := []string{}
+	for _, v := range advisory.Vulnerabilities {
+		if strings.EqualFold(v.Package.Ecosystem, "maven") && v.Package.Name == name && v.FirstPatchedVersion != "" {
+			fixes = append(fixes, v.FirstPatchedVersion)
+		}
+	}
+	if len(fixes) == 0 {
+		return "", fmt.Errorf("no fixed version of %s found in %s", name, id)
+	}
+	return minimalFixVersion(current, fixes), nil
+}
+
+// AffectedArtifacts returns the Maven artifacts the advisory id affects.
+func (r *GHSAResolver) AffectedArtifacts(ctx context.Context, id string) ([]string, error) {
+	advisory, err := r.fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	artifacts := []string{}
+	for _, v := range advisory.Vulnerabilities {
+		if strings.EqualFold(v.Package.Ecosystem, "maven") && !slices.Contains(artifacts, v.Package.Name) {
+			artifacts = append(artifacts, v.Package.Name)
+		}
+	}
+	if len(artifacts) == 0 {
+		return nil, fmt.Errorf("no Maven artifact found in %s", id)
+	}
+	return artifacts, nil
+}
+
+// fetch gets an advisory from the cache, or the GitHub API.
+func (r *GHSAResolver) fetch(ctx context.Context, id string) (_ *githubAdvisory, err error) {
+	log := clog.FromContext(ctx)
+
+	var cacheFile string
+	if r.CacheDir != "" {
+		cacheFile = filepath.Join(r.CacheDir, filepath.Base(id)+ghsaCacheSuffix)
+		if data, ok := readCache(cacheFile, r.CacheTTL); ok {
+			var advisory githubAdvisory
+			if err := json.Unmarshal(data, &advisory); err == nil {
+				log.Debugf("Using cached GitHub advisory %s", id)
+				return &advisory, nil
+			}
+		}
+	}
+
+	ctx, end := startSpan(ctx, OperationFetch, attribute.String("pombump.advisory", id))
+	defer func() { end(err) }()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/advisories/%s", r.APIURL, id), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	}
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the GitHub Advisory Database: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Warnf("failed to close response body: %v", err)
+		}
+	}()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitHub response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub returned %s for %s: %s", resp.Status, id, githubErrorMessage(data))
+	}
+	var advisory githubAdvisory
+	if err := json.Unmarshal(data, &advisory); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub advisory: %w", err)
+	}
+
+	if cacheFile != "" {
+		if err := os.MkdirAll(r.CacheDir, 0755); err == nil {
+			if err := os.WriteFile(cacheFile, data, 0644); err != nil {
+				log.Warnf("failed to cache GitHub advisory %s: %v", id, err)
+			}
+		}
+	}
+	return &advisory, nil
+}
+
+// AdvisoryResolvers resolves GHSA identifiers with GitHub, falling back to
+// OSV (which mirrors the GitHub Advisory Database) when GitHub fails, and
+// every other advisory with OSV. GitHub may be nil to only use OSV.
+type AdvisoryResolvers struct {
+	OSV    *OSVResolver
+	GitHub *GHSAResolver
+}
+
+// FixedVersion implements AdvisoryResolver.
+func (r AdvisoryResolvers) FixedVersion(ctx context.Context, id, groupID, artifactID, current string) (string, error) {
+	if r.GitHub != nil && IsGHSAID(id) {
+		version, err := r.GitHub.FixedVersion(ctx, id, groupID, artifactID, current)
+		if err == nil {
+			return version, nil
+		}
+		clog.FromContext(ctx).Warnf("Unable to resolve %s with GitHub, trying OSV: %v", id, err)
+	}
+	return r.OSV.FixedVersion(ctx, id, groupID, artifactID, current)
+}
+
+// AffectedArtifacts implements AdvisoryResolver.
+func (r AdvisoryResolvers) AffectedArtifacts(ctx context.Context, id string) ([]string, error) {
+	if r.GitHub != nil && IsGHSAID(id) {
+		artifacts, err := r.GitHub.AffectedArtifacts(ctx, id)
+		if err == nil {
+			return artifacts, nil
+		}
+		clog.FromContext(ctx).Warnf("Unable to look up %s with GitHub, trying OSV: %v", id, err)
+	}
+	return r.OSV.AffectedArtifacts(ctx, id)
+}
@@ -0,0 +1,134 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// githubGHSA affects two artifacts, one of them on two release lines.
+const githubGHSA = `{
+  "ghsa_id": "GHSA-5jpm-x58v-624v",
+  "cve_id": "CVE-2023-44487",
+  "vulnerabilities": [
+    {
+      "package": {"ecosystem": "maven", "name": "io.netty:netty-codec-http2"},
+      "vulnerable_version_range": "< 4.1.100.Final",
+      "first_patched_version": "4.1.100.Final"
+    },
+    {
+      "package": {"ecosystem": "maven", "name": "org.eclipse.jetty.http2:http2-common"},
+      "vulnerable_version_range": ">= 9.3.0, < 9.4.53.v20231009",
+      "first_patched_version": "9.4.53.v20231009"
+    },
+    {
+      "package": {"ecosystem": "maven", "name": "org.eclipse.jetty.http2:http2-common"},
+      "vulnerable_version_range": ">= 10.0.0, < 10.0.17",
+      "first_patched_version": "10.0.17"
+    },
+    {
+      "package": {"ecosystem": "npm", "name": "http2"},
+      "first_patched_version": "1.0.0"
+    }
+  ]
+}`
+
+func githubAdvisoryServer(t *testing.T, requests map[string]int) *GHSAResolver {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/advisories/")
+		requests[id]++
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		if id != "GHSA-5jpm-x58v-624v" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(githubGHSA))
+	}))
+	t.Cleanup(server.Close)
+
+	resolver := NewGHSAResolver(t.TempDir())
+	resolver.APIURL = server.URL
+	resolver.Token = "secret"
+	return resolver
+}
+
+func TestGHSAResolver(t *testing.T) {
+	ctx := context.Background()
+	requests := map[string]int{}
+	resolver := githubAdvisoryServer(t, requests)
+
+	version, err := resolver.FixedVersion(ctx, "GHSA-5jpm-x58v-624v", "org.eclipse.jetty.http2", "http2-common", "10.0.15")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.17", version)
+	version, err = resolver.FixedVersion(ctx, "GHSA-5jpm-x58v-624v", "org.eclipse.jetty.http2", "http2-common", "9.4.51.v20230217")
+	require.NoError(t, err)
+	assert.Equal(t, "9.4.53.v20231009", version)
+
+	artifacts, err := resolver.AffectedArtifacts(ctx, "GHSA-5jpm-x58v-624v")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"io.netty:netty-codec-http2", "org.eclipse.jetty.http2:http2-common"}, artifacts)
+
+	// Everything but the first lookup comes from the cache.
+	assert.Equal(t, map[string]int{"GHSA-5jpm-x58v-624v": 1}, requests)
+
+	_, err = resolver.FixedVersion(ctx, "GHSA-5jpm-x58v-624v", "org.json", "json", "")
+	assert.ErrorContains(t, err, "no fixed version of org.json:json")
+	_, err = resolver.FixedVersion(ctx, "GHSA-xxxx-xxxx-xxxx", "org.json", "json", "")
+	assert.ErrorContains(t, err, "404 Not Found for GHSA-xxxx-xxxx-xxxx: Not Found")
+}
+
+func TestResolveAdvisoriesExpandsAdvisories(t *testing.T) {
+	ctx := context.Background()
+	resolvers := AdvisoryResolvers{OSV: NewOSVResolver(t.TempDir()), GitHub: githubAdvisoryServer(t, map[string]int{})}
+
+	patches, err := ParsePatches(ctx, "", "GHSA-5jpm-x58v-624v org.json@json@20231013")
+	require.NoError(t, err)
+	current := map[string]string{
+		"io.netty:netty-codec-http2":           "4.1.94.Final",
+		"org.eclipse.jetty.http2:http2-common": "9.4.51.v20230217",
+		"org.json:json":                        "20230227",
+	}
+	got, err := ResolveAdvisories(ctx, resolvers, patches, current)
+	require.NoError(t, err)
+	assert.Equal(t, []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-codec-http2", Version: "4.1.100.Final", Scope: defaultScope, Type: defaultType, Advisories: []string{"GHSA-5jpm-x58v-624v"}},
+		{GroupID: "org.eclipse.jetty.http2", ArtifactID: "http2-common", Version: "9.4.53.v20231009", Scope: defaultScope, Type: defaultType, Advisories: []string{"GHSA-5jpm-x58v-624v"}},
+		{GroupID: "org.json", ArtifactID: "json", Version: "20231013", Scope: defaultScope, Type: defaultType},
+	}, got)
+
+	// Only the artifacts the project uses are patched.
+	got, err = ResolveAdvisories(ctx, resolvers, patches[:1], map[string]string{"io.netty:netty-codec-http2": "4.1.94.Final"})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "netty-codec-http2", got[0].ArtifactID)
+	got, err = ResolveAdvisories(ctx, resolvers, patches[:1], nil)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestAdvisoryResolversFallBackToOSV(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/vulns/GHSA-6mjq-h674-j845" {
+			_, _ = w.Write([]byte(osvGHSA))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	osv := NewOSVResolver(t.TempDir())
+	osv.BaseURL = server.URL
+	resolvers := AdvisoryResolvers{OSV: osv, GitHub: githubAdvisoryServer(t, map[string]int{})}
+
+	version, err := resolvers.FixedVersion(context.Background(), "GHSA-6mjq-h674-j845", "io.netty", "netty-handler", "4.1.86.Final")
+	require.NoError(t, err)
+	assert.Equal(t, "4.1.94.Final", version)
+	artifacts, err := resolvers.AffectedArtifacts(context.Background(), "GHSA-6mjq-h674-j845")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"io.netty:netty-handler"}, artifacts)
+}
@@ -0,0 +1,137 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultGitHubAPIURL is the API of github.com. GitHub Enterprise Server
+// serves it under /api/v3 of the instance instead.
+const DefaultGitHubAPIURL = "https://api.github.com"
+
+// GitHubClient opens pull requests through the GitHub REST API.
+type GitHubClient struct {
+	APIURL string
+	Token  string
+	Client *http.Client
+}
+
+// NewGitHubClient returns a client authenticating with token. The API URL is
+// $GITHUB_API_URL if set, as it is in GitHub Actions, and DefaultGitHubAPIURL
+// otherwise.
+func NewGitHubClient(token string) *GitHubClient {
+	apiURL := os.Getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		apiURL = DefaultGitHubAPIURL
+	}
+	return &GitHubClient{
+		APIURL: strings.TrimSuffix(apiURL, "/"),
+		Token:  token,
+		Client: NewHTTPClient(nil),
+	}
+}
+
+// PullRequest is a pull request to open, from the Head branch into Base.
+type PullRequest struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Head  string `json:"head"`
+	Base  string `json:"base"`
+	Draft bool   `json:"draft,omitempty"`
+}
+
+// CreatedPullRequest is the pull request GitHub opened.
+type CreatedPullRequest struct {
+	Number int    `json:"number"`
+	URL    string `json:"html_url"`
+}
+
+// CreatePullRequest opens pr on the repository owner/name.
+func (c *GitHubClient) CreatePullRequest(ctx context.Context, owner, name string, pr PullRequest) (_ *CreatedPullRequest, err error) {
+	ctx, end := startSpan(ctx, OperationFetch, attribute.String("pombump.repository", owner+"/"+name))
+	defer func() { end(err) }()
+	log := clog.FromContext(ctx)
+
+	body, err := json.Marshal(pr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal pull request: %w", err)
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/pulls", c.APIURL, owner, name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pull request: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Warnf("failed to close response body: %v", err)
+		}
+	}()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitHub response: %w", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("GitHub returned %s creating the pull request: %s", resp.Status, githubErrorMessage(data))
+	}
+	created := &CreatedPullRequest{}
+	if err := json.Unmarshal(data, created); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub response: %w", err)
+	}
+	return created, nil
+}
+
+// githubErrorMessage is the message of a GitHub error response, with the
+// details of each error, such as a pull request that already exists.
+func githubErrorMessage(data []byte) string {
+	var response struct {
+		Message string `json:"message"`
+		Errors  []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil || response.Message == "" {
+		return strings.TrimSpace(string(data))
+	}
+	messages := []string{response.Message}
+	for _, e := range response.Errors {
+		if e.Message != "" {
+			messages = append(messages, e.Message)
+		}
+	}
+	return strings.Join(messages, ": ")
+}
+
+// githubRemote matches the URLs of a GitHub repository git remotes use:
+// https://github.com/owner/name(.git), git@github.com:owner/name(.git) and
+// ssh://git@github.com/owner/name(.git), on any host for GitHub Enterprise.
+var githubRemote = regexp.MustCompile(`^(?:https?://(?:[^@/]+@)?[^/]+/|ssh://(?:[^@/]+@)?[^/]+/|[^@/]+@[^:/]+:)([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+// ParseGitHubRemote returns the owner and name of the repository at the URL
+// of a git remote.
+func ParseGitHubRemote(remote string) (owner, name string, err error) {
+	m := githubRemote.FindStringSubmatch(strings.TrimSpace(remote))
+	if m == nil {
+		return "", "", fmt.Errorf("can not tell the GitHub repository of remote %q", remote)
+	}
+	return m[1], m[2], nil
+}
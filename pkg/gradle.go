@@ -0,0 +1,321 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+)
+
+// gradlePropertiesFile holds the project properties of a Gradle build, next
+// to its build script.
+const gradlePropertiesFile = "gradle.properties"
+
+// IsGradleBuild reports whether path is a Groovy or Kotlin Gradle build
+// script.
+func IsGradleBuild(path string) bool {
+	return strings.HasSuffix(path, ".gradle") || strings.HasSuffix(path, ".gradle.kts")
+}
+
+// GradleBuild is a Gradle build script and the gradle.properties next to
+// it, if any.
+type GradleBuild struct {
+	Path   string
+	Script []byte
+	// PropertiesPath is the gradle.properties file, empty if there is none.
+	PropertiesPath string
+	Properties     []byte
+	// Dependencies are the dependencies declared in the script.
+	Dependencies []GradleDependency
+	// Variables maps the ext properties and variables the script defines,
+	// and the properties of gradle.properties, to their value.
+	Variables map[string]string
+	variables map[string]gradleSpan
+}
+
+// GradleDependency is a dependency declared in a build script, in string
+// notation ("group:name:version[:classifier]") or with named arguments
+// (group: ..., name: ..., version: ...).
+type GradleDependency struct {
+	Configuration string
+	GroupID       string
+	ArtifactID    string
+	// Version uses the Maven ${name} syntax for the variables it refers to.
+	Version    string
+	Classifier string
+	// Platform is set for platform(), enforcedPlatform() and mavenBom
+	// dependencies, which import a BOM.
+	Platform bool
+	Line     int
+	version  gradleSpan
+	// quote wraps a literal replacing a version that is not quoted.
+	quote string
+}
+
+// gradleSpan locates a value in the script, or in gradle.properties.
+type gradleSpan struct {
+	inProperties bool
+	start, end   int
+}
+
+var (
+	// configuration 'group:name:version[:classifier][@ext]', with optional
+	// parentheses and platform().
+	gradleStringNotation = regexp.MustCompile(`(?m)^[ \t]*([A-Za-z]\w*)[ \t]*\(?[ \t]*(?:(platform|enforcedPlatform)[ \t]*\(?[ \t]*)?['"]([^'"\s:]+):([^'"\s:]+):([^'"\s:@]+)(?::([^'"\s:@]+))?(?:@\w+)?['"]`)
+	// configuration group: 'g', name: 'a', version: 'v', classifier: 'c',
+	// or with = for Kotlin.
+	gradleNamedArguments = regexp.MustCompile(`(?m)^[ \t]*([A-Za-z]\w*)[ \t]*\(?[ \t]*group[ \t]*[:=][ \t]*['"]([^'"]+)['"][ \t]*,[ \t]*name[ \t]*[:=][ \t]*['"]([^'"]+)['"](?:[ \t]*,[ \t]*version[ \t]*[:=][ \t]*(?:['"]([^'"]+)['"]|([A-Za-z_][\w.]*)))?(?:[ \t]*,[ \t]*classifier[ \t]*[:=][ \t]*['"]([^'"]+)['"])?`)
+	// The ways a build script defines a variable or ext property.
+	gradleVariableDefinitions = []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^[ \t]*(?:(?:def|val|var)[ \t]+|(?:(?:project|rootProject)\.)?ext\.)?([A-Za-z_][\w.]*)[ \t]*=[ \t]*['"]([^'"$\n]*)['"]`),
+		regexp.MustCompile(`extra\[[ \t]*"([\w.]+)"[ \t]*\][ \t]*=[ \t]*"([^"$\n]*)"`),
+		regexp.MustCompile(`\b(?:val|var)[ \t]+(\w+)[ \t]+by[ \t]+extra\([ \t]*"([^"$\n]*)"[ \t]*\)`),
+		regexp.MustCompile(`\bset\([ \t]*['"]([\w.]+)['"][ \t]*,[ \t]*['"]([^'"$\n]*)['"][ \t]*\)`),
+	}
+	gradlePropertyDefinition = regexp.MustCompile(`(?m)^[ \t]*([^#!\s=:]+)[ \t]*[=:][ \t]*([^\r\n]*?)[ \t]*\r?$`)
+	gradleReference          = regexp.MustCompile(`\$(?:\{([\w.]+)\}|(\w+))`)
+)
+
+// LoadGradleBuild reads the build script at path and the gradle.properties
+// next to it.
+func LoadGradleBuild(path string) (*GradleBuild, error) {
+	script, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading file: %w", err)
+	}
+	propertiesPath := filepath.Join(filepath.Dir(path), gradlePropertiesFile)
+	properties, err := os.ReadFile(propertiesPath)
+	if errors.Is(err, os.ErrNotExist) {
+		propertiesPath, properties = "", nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed reading file: %w", err)
+	}
+	return ParseGradleBuild(path, script, propertiesPath, properties), nil
+}
+
+// ParseGradleBuild finds the dependencies and variables of a build script,
+// and the properties of its gradle.properties, which may be empty.
+func ParseGradleBuild(path string, script []byte, propertiesPath string, properties []byte) *GradleBuild {
+	build := &GradleBuild{
+		Path:           path,
+		Script:         script,
+		PropertiesPath: propertiesPath,
+		Properties:     properties,
+		Variables:      map[string]string{},
+		variables:      map[string]gradleSpan{},
+	}
+	quote := "'"
+	if strings.HasSuffix(path, ".kts") {
+		quote = `"`
+	}
+	line := func(offset int) int {
+		return bytes.Count(script[:offset], []byte("\n")) + 1
+	}
+
+	for _, m := range gradleStringNotation.FindAllSubmatchIndex(script, -1) {
+		dep := GradleDependency{
+			Configuration: string(script[m[2]:m[3]]),
+			GroupID:       string(script[m[6]:m[7]]),
+			ArtifactID:    string(script[m[8]:m[9]]),
+			Version:       gradleVersion(string(script[m[10]:m[11]])),
+			Line:          line(m[0]),
+			version:       gradleSpan{start: m[10], end: m[11]},
+		}
+		dep.Platform = m[4] >= 0 || dep.Configuration == "mavenBom"
+		if m[12] >= 0 {
+			dep.Classifier = string(script[m[12]:m[13]])
+		}
+		build.Dependencies = append(build.Dependencies, dep)
+	}
+	for _, m := range gradleNamedArguments.FindAllSubmatchIndex(script, -1) {
+		dep := GradleDependency{
+			Configuration: string(script[m[2]:m[3]]),
+			GroupID:       string(script[m[4]:m[5]]),
+			ArtifactID:    string(script[m[6]:m[7]]),
+			Line:          line(m[0]),
+		}
+		switch {
+		case m[8] >= 0:
+			dep.Version = gradleVersion(string(script[m[8]:m[9]]))
+			dep.version = gradleSpan{start: m[8], end: m[9]}
+		case m[10] >= 0:
+			dep.Version = gradleVersion("${" + string(script[m[10]:m[11]]) + "}")
+			dep.version = gradleSpan{start: m[10], end: m[11]}
+			dep.quote = quote
+		}
+		if m[12] >= 0 {
+			dep.Classifier = string(script[m[12]:m[13]])
+		}
+		build.Dependencies = append(build.Dependencies, dep)
+	}
+	sort.SliceStable(build.Dependencies, func(i, j int) bool { return build.Dependencies[i].Line < build.Dependencies[j].Line })
+
+	// The script wins over gradle.properties, and the first definition
+	// over later ones.
+	for _, re := range gradleVariableDefinitions {
+		for _, m := range re.FindAllSubmatchIndex(script, -1) {
+			build.define(string(script[m[2]:m[3]]), string(script[m[4]:m[5]]), gradleSpan{start: m[4], end: m[5]})
+		}
+	}
+	for _, m := range gradlePropertyDefinition.FindAllSubmatchIndex(properties, -1) {
+		build.define(string(properties[m[2]:m[3]]), string(properties[m[4]:m[5]]), gradleSpan{inProperties: true, start: m[4], end: m[5]})
+	}
+	return build
+}
+
+func (build *GradleBuild) define(name, value string, span gradleSpan) {
+	if _, exists := build.variables[name]; exists {
+		return
+	}
+	build.Variables[name] = value
+	build.variables[name] = span
+}
+
+// gradleVersion turns the Groovy and Kotlin $name and ${name} references of
+// version into Maven's ${name}, dropping a project. qualifier.
+func gradleVersion(version string) string {
+	return gradleReference.ReplaceAllStringFunc(version, func(ref string) string {
+		m := gradleReference.FindStringSubmatch(ref)
+		name := m[1] + m[2]
+		for _, prefix := range []string{"project.ext.", "rootProject.ext.", "project.", "rootProject.", "ext."} {
+			name = strings.TrimPrefix(name, prefix)
+		}
+		return "${" + name + "}"
+	})
+}
+
+// AnalyzeGradle analyzes a Gradle build like AnalyzeProject does a POM: the
+// variables are the properties, and platform() dependencies the imported
+// BOMs. The first declaration of an artifact wins.
+func AnalyzeGradle(ctx context.Context, build *GradleBuild) *AnalysisResult {
+	log := clog.FromContext(ctx)
+	result := &AnalysisResult{
+		Dependencies:        make(map[string]*DependencyInfo),
+		PropertyUsageCounts: make(map[string]int),
+		Properties:          make(map[string]string, len(build.Variables)),
+		boms:                []BOMInfo{},
+	}
+	// There is no POM to index lazily, everything is done here.
+	result.depsOnce.Do(func() {})
+	result.bomsOnce.Do(func() {})
+	for name, value := range build.Variables {
+		result.Properties[name] = value
+	}
+
+	for _, dep := range build.Dependencies {
+		mavenDep := gopom.Dependency{GroupID: dep.GroupID, ArtifactID: dep.ArtifactID, Version: dep.Version, Classifier: dep.Classifier}
+		position := SourcePosition{File: build.Path, Line: dep.Line}
+		if dep.Platform {
+			mavenDep.Type, mavenDep.Scope = "pom", "import"
+			bom := BOMInfo{GroupID: dep.GroupID, ArtifactID: dep.ArtifactID, Version: dep.Version, Position: &position}
+			if name, ok := propertyReference(dep.Version); ok {
+				bom.UsesProperty = true
+				bom.PropertyName = name
+			}
+			result.boms = append(result.boms, bom)
+		}
+		if _, exists := result.Dependencies[fmt.Sprintf("%s:%s", dep.GroupID, dep.ArtifactID)]; exists {
+			continue
+		}
+		analyzeDependency(ctx, mavenDep, result)
+		if info, ok := result.Dependencies[fmt.Sprintf("%s:%s", dep.GroupID, dep.ArtifactID)]; ok {
+			info.Position = &position
+		}
+	}
+	log.Infof("Analysis complete: found %d dependencies, %d using properties",
+		len(result.Dependencies), countPropertiesUsage(result))
+	return result
+}
+
+// PatchGradle applies patches and property patches to build, and returns the
+// patched build. A patch bumps the version of every declaration of its
+// artifact, replacing a variable with a literal version, while a property
+// patch changes the value of a variable. Only version bumps are supported,
+// and a variable must already be defined. A patch whose artifact is not
+// declared is skipped with a warning.
+func PatchGradle(ctx context.Context, build *GradleBuild, patches []Patch, propertyPatches map[string]string) (*GradleBuild, error) {
+	log := clog.FromContext(ctx)
+	type edit struct {
+		gradleSpan
+		text string
+	}
+	edits := map[gradleSpan]edit{}
+
+	for _, p := range patches {
+		if p.Operation != "" {
+			return nil, fmt.Errorf("patch %s.%s: the %q operation is not supported for Gradle builds", p.GroupID, p.ArtifactID, p.Operation)
+		}
+		if p.Version == "" {
+			continue
+		}
+		matches := []GradleDependency{}
+		for _, dep := range build.Dependencies {
+			if dep.GroupID == p.GroupID && dep.ArtifactID == p.ArtifactID {
+				matches = append(matches, dep)
+			}
+		}
+		// Like for POMs, the declarations of the classifier if any.
+		if p.Classifier != "" {
+			exact := []GradleDependency{}
+			for _, dep := range matches {
+				if dep.Classifier == p.Classifier {
+					exact = append(exact, dep)
+				}
+			}
+			if len(exact) > 0 {
+				matches = exact
+			}
+		}
+		if len(matches) == 0 {
+			log.Warnf("%s:%s is not declared in %s, not patching it", p.GroupID, p.ArtifactID, build.Path)
+			continue
+		}
+		for _, dep := range matches {
+			if dep.version == (gradleSpan{}) {
+				log.Warnf("%s:%s is declared without a version on line %d of %s, not patching it", p.GroupID, p.ArtifactID, dep.Line, build.Path)
+				continue
+			}
+			log.Infof("Patching %s:%s on line %d of %s from %s to %s", p.GroupID, p.ArtifactID, dep.Line, build.Path, dep.Version, p.Version)
+			edits[dep.version] = edit{gradleSpan: dep.version, text: dep.quote + p.Version + dep.quote}
+		}
+	}
+
+	names := make([]string, 0, len(propertyPatches))
+	for name := range propertyPatches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		span, exists := build.variables[name]
+		if !exists {
+			return nil, fmt.Errorf("property %s is not defined in %s or its %s", name, build.Path, gradlePropertiesFile)
+		}
+		log.Infof("Patching property %s from %s to %s", name, build.Variables[name], propertyPatches[name])
+		edits[span] = edit{gradleSpan: span, text: propertyPatches[name]}
+	}
+
+	// Apply from the end so that the earlier offsets stay valid.
+	sorted := make([]edit, 0, len(edits))
+	for _, e := range edits {
+		sorted = append(sorted, e)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start > sorted[j].start })
+	script := bytes.Clone(build.Script)
+	properties := bytes.Clone(build.Properties)
+	for _, e := range sorted {
+		target := &script
+		if e.inProperties {
+			target = &properties
+		}
+		*target = append((*target)[:e.start:e.start], append([]byte(e.text), (*target)[e.end:]...)...)
+	}
+	return ParseGradleBuild(build.Path, script, build.PropertiesPath, properties), nil
+}
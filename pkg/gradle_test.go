@@ -0,0 +1,138 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const groovyBuild = `plugins {
+    id 'java'
+}
+
+ext {
+    nettyVersion = '4.1.94.Final'
+}
+def jacksonVersion = "2.15.2"
+
+dependencies {
+    implementation platform('io.netty:netty-bom:4.1.94.Final')
+    implementation "io.netty:netty-handler:$nettyVersion"
+    implementation "io.netty:netty-codec:${nettyVersion}"
+    implementation group: 'com.fasterxml.jackson.core', name: 'jackson-databind', version: jacksonVersion
+    implementation 'org.slf4j:slf4j-api:2.0.7'
+    runtimeOnly 'io.netty:netty-transport-native-epoll:4.1.94.Final:linux-x86_64'
+    testImplementation "org.junit.jupiter:junit-jupiter:${junitVersion}"
+    // implementation 'commented:out:1.0'
+}
+`
+
+const kotlinBuild = `val nettyVersion by extra("4.1.94.Final")
+val slf4jVersion = "2.0.7"
+extra["logback.version"] = "1.4.11"
+
+dependencies {
+    implementation(platform("io.netty:netty-bom:$nettyVersion"))
+    implementation("io.netty:netty-handler")
+    implementation(group = "org.slf4j", name = "slf4j-api", version = "$slf4jVersion")
+    implementation(group = "ch.qos.logback", name = "logback-core", version = "1.4.11")
+}
+`
+
+func TestParseGradleBuild(t *testing.T) {
+	build := ParseGradleBuild("build.gradle", []byte(groovyBuild), "gradle.properties", []byte("# versions\njunitVersion = 5.10.0\n"))
+	got := []string{}
+	for _, dep := range build.Dependencies {
+		got = append(got, dep.Configuration+" "+dep.GroupID+":"+dep.ArtifactID+":"+dep.Version+":"+dep.Classifier)
+	}
+	assert.Equal(t, []string{
+		"implementation io.netty:netty-bom:4.1.94.Final:",
+		"implementation io.netty:netty-handler:${nettyVersion}:",
+		"implementation io.netty:netty-codec:${nettyVersion}:",
+		"implementation com.fasterxml.jackson.core:jackson-databind:${jacksonVersion}:",
+		"implementation org.slf4j:slf4j-api:2.0.7:",
+		"runtimeOnly io.netty:netty-transport-native-epoll:4.1.94.Final:linux-x86_64",
+		"testImplementation org.junit.jupiter:junit-jupiter:${junitVersion}:",
+	}, got)
+	assert.True(t, build.Dependencies[0].Platform)
+	assert.Equal(t, 12, build.Dependencies[1].Line)
+	assert.Equal(t, map[string]string{
+		"nettyVersion":   "4.1.94.Final",
+		"jacksonVersion": "2.15.2",
+		"junitVersion":   "5.10.0",
+	}, build.Variables)
+}
+
+func TestAnalyzeGradle(t *testing.T) {
+	build := ParseGradleBuild("build.gradle.kts", []byte(kotlinBuild), "", nil)
+	analysis := AnalyzeGradle(context.Background(), build)
+
+	assert.Equal(t, []BOMInfo{{
+		GroupID: "io.netty", ArtifactID: "netty-bom", Version: "${nettyVersion}", UsesProperty: true, PropertyName: "nettyVersion",
+		Position: &SourcePosition{File: "build.gradle.kts", Line: build.Dependencies[0].Line},
+	}}, analysis.BOMs())
+	assert.Equal(t, &SourcePosition{File: "build.gradle.kts", Line: 8}, analysis.Dependencies["org.slf4j:slf4j-api"].Position)
+	assert.Equal(t, "1.4.11", build.Variables["logback.version"])
+	assert.Equal(t, map[string]string{
+		"io.netty:netty-bom":          "4.1.94.Final",
+		"org.slf4j:slf4j-api":         "2.0.7",
+		"ch.qos.logback:logback-core": "1.4.11",
+	}, analysis.CurrentVersions())
+
+	directPatches, propertyPatches := PatchStrategy(context.Background(), analysis, []Patch{
+		{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.9"},
+		{GroupID: "ch.qos.logback", ArtifactID: "logback-core", Version: "1.4.14"},
+	})
+	assert.Equal(t, map[string]string{"slf4jVersion": "2.0.9"}, propertyPatches)
+	require.Len(t, directPatches, 1)
+	assert.Equal(t, "logback-core", directPatches[0].ArtifactID)
+}
+
+func TestPatchGradle(t *testing.T) {
+	build := ParseGradleBuild("build.gradle", []byte(groovyBuild), "gradle.properties", []byte("junitVersion=5.10.0\n"))
+	patched, err := PatchGradle(context.Background(), build, []Patch{
+		{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.9"},
+		// A variable is replaced by a literal.
+		{GroupID: "com.fasterxml.jackson.core", ArtifactID: "jackson-databind", Version: "2.15.3"},
+		{GroupID: "io.netty", ArtifactID: "netty-transport-native-epoll", Version: "4.1.118.Final", Classifier: "linux-x86_64"},
+		{GroupID: "not", ArtifactID: "declared", Version: "1.0"},
+	}, map[string]string{"nettyVersion": "4.1.118.Final", "junitVersion": "5.10.2"})
+	require.NoError(t, err)
+
+	script := string(patched.Script)
+	assert.Contains(t, script, "nettyVersion = '4.1.118.Final'")
+	assert.Contains(t, script, `implementation "io.netty:netty-handler:$nettyVersion"`)
+	assert.Contains(t, script, "version: '2.15.3'")
+	assert.Contains(t, script, "'org.slf4j:slf4j-api:2.0.9'")
+	assert.Contains(t, script, "'io.netty:netty-transport-native-epoll:4.1.118.Final:linux-x86_64'")
+	assert.Contains(t, script, "implementation platform('io.netty:netty-bom:4.1.94.Final')")
+	assert.Equal(t, "junitVersion=5.10.2\n", string(patched.Properties))
+	assert.Equal(t, "4.1.118.Final", patched.Variables["nettyVersion"])
+
+	_, err = PatchGradle(context.Background(), build, nil, map[string]string{"undefined": "1.0"})
+	assert.Error(t, err)
+	_, err = PatchGradle(context.Background(), build, []Patch{{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Operation: PatchOperationRemove}}, nil)
+	assert.Error(t, err)
+}
+
+func TestLoadGradleBuild(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "build.gradle.kts")
+	require.NoError(t, os.WriteFile(path, []byte(kotlinBuild), 0644))
+	build, err := LoadGradleBuild(path)
+	require.NoError(t, err)
+	assert.Empty(t, build.PropertiesPath)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gradle.properties"), []byte("nettyVersion=4.1.100.Final\nlogbackVersion=1.4.14\n"), 0644))
+	build, err = LoadGradleBuild(path)
+	require.NoError(t, err)
+	// The script wins.
+	assert.Equal(t, "4.1.94.Final", build.Variables["nettyVersion"])
+	assert.Equal(t, "1.4.14", build.Variables["logbackVersion"])
+	assert.True(t, IsGradleBuild(path))
+	assert.False(t, IsGradleBuild("pom.xml"))
+}
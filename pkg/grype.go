@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// grypeReport is the subset of the Grype JSON output (grype -o json) that we
+// care about.
+type grypeReport struct {
+	Matches []grypeMatch `json:"matches"`
+}
+
+type grypeMatch struct {
+	Vulnerability struct {
+		ID  string `json:"id"`
+		Fix struct {
+			Versions []string `json:"versions"`
+			State    string   `json:"state"`
+		} `json:"fix"`
+	} `json:"vulnerability"`
+	Artifact struct {
+		Name     string `json:"name"`
+		Version  string `json:"version"`
+		Type     string `json:"type"`
+		PURL     string `json:"purl"`
+		Metadata struct {
+			PomGroupID    string `json:"pomGroupID"`
+			PomArtifactID string `json:"pomArtifactID"`
+		} `json:"metadata"`
+	} `json:"artifact"`
+}
+
+// PatchesFromGrype reads a Grype JSON report and turns every fixable Maven
+// vulnerability into a Patch. If several vulnerabilities hit the same
+// artifact, the highest fixed version wins so that all of them get fixed.
+func PatchesFromGrype(ctx context.Context, reportFile string) ([]Patch, error) {
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading file: %w", err)
+	}
+	var report grypeReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse grype report: %w", err)
+	}
+
+	findings := []scanFinding{}
+	for _, m := range report.Matches {
+		if m.Artifact.Type != "java-archive" && !strings.HasPrefix(m.Artifact.PURL, "pkg:maven/") {
+			continue
+		}
+		groupID, artifactID := m.Artifact.Metadata.PomGroupID, m.Artifact.Metadata.PomArtifactID
+		if groupID == "" || artifactID == "" {
+			groupID, artifactID = gaFromMavenPURL(m.Artifact.PURL)
+		}
+		finding := scanFinding{
+			ID:         m.Vulnerability.ID,
+			GroupID:    groupID,
+			ArtifactID: artifactID,
+			Installed:  m.Artifact.Version,
+		}
+		if m.Vulnerability.Fix.State == "fixed" {
+			finding.FixedVersions = m.Vulnerability.Fix.Versions
+		}
+		findings = append(findings, finding)
+	}
+	return patchesFromFindings(ctx, "Grype", findings), nil
+}
+
+// gaFromMavenPURL extracts the groupId and artifactId from a maven purl, e.g.
+// pkg:maven/io.netty/netty-handler@4.1.94.Final
+func gaFromMavenPURL(purl string) (string, string) {
+	rest, found := strings.CutPrefix(purl, "pkg:maven/")
+	if !found {
+		return "", ""
+	}
+	rest, _, _ = strings.Cut(rest, "?")
+	rest, _, _ = strings.Cut(rest, "@")
+	groupID, artifactID, found := strings.Cut(rest, "/")
+	if !found {
+		return "", ""
+	}
+	groupID, _ = url.PathUnescape(groupID)
+	artifactID, _ = url.PathUnescape(artifactID)
+	return groupID, artifactID
+}
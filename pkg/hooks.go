@@ -0,0 +1,66 @@
+package pkg
+
+import "context"
+
+// StrategyHook adds custom logic around PatchStrategy, such as the rules of
+// an organization ("never bump guava across majors"), see
+// WithStrategyHooks. StrategyRules is one, read from a file.
+type StrategyHook interface {
+	// BeforeStrategy is given the patches PatchStrategy is called with, and
+	// returns the ones it is to plan, e.g. without those a rule forbids.
+	BeforeStrategy(ctx context.Context, result *AnalysisResult, patches []Patch) []Patch
+	// AfterStrategy is given the direct and property patches PatchStrategy
+	// decided on, and returns the ones it is to return.
+	AfterStrategy(ctx context.Context, result *AnalysisResult, directPatches []Patch, propertyPatches map[string]string) ([]Patch, map[string]string)
+}
+
+// StrategyHookFuncs is a StrategyHook made of functions, either of which may
+// be nil to leave the patches as they are.
+type StrategyHookFuncs struct {
+	Before func(ctx context.Context, result *AnalysisResult, patches []Patch) []Patch
+	After  func(ctx context.Context, result *AnalysisResult, directPatches []Patch, propertyPatches map[string]string) ([]Patch, map[string]string)
+}
+
+// BeforeStrategy calls Before, if set.
+func (h StrategyHookFuncs) BeforeStrategy(ctx context.Context, result *AnalysisResult, patches []Patch) []Patch {
+	if h.Before == nil {
+		return patches
+	}
+	return h.Before(ctx, result, patches)
+}
+
+// AfterStrategy calls After, if set.
+func (h StrategyHookFuncs) AfterStrategy(ctx context.Context, result *AnalysisResult, directPatches []Patch, propertyPatches map[string]string) ([]Patch, map[string]string) {
+	if h.After == nil {
+		return directPatches, propertyPatches
+	}
+	return h.After(ctx, result, directPatches, propertyPatches)
+}
+
+// skippedPatchesKey is the context key of where RecordSkipped records.
+type skippedPatchesKey struct{}
+
+// WithSkippedPatches makes PatchStrategy add to skipped the patches its
+// hooks drop due to policy, as they report them with RecordSkipped.
+func WithSkippedPatches(skipped *[]SkippedPatch) PatchStrategyOption {
+	return func(o *patchStrategyOptions) {
+		o.skipped = skipped
+	}
+}
+
+// RecordSkipped records that a StrategyHook, given ctx, dropped a patch due
+// to policy, for the caller of PatchStrategy to report.
+func RecordSkipped(ctx context.Context, skipped SkippedPatch) {
+	if record, ok := ctx.Value(skippedPatchesKey{}).(*[]SkippedPatch); ok {
+		*record = append(*record, skipped)
+	}
+}
+
+// WithStrategyHooks makes PatchStrategy call hooks, the BeforeStrategy of
+// each in order on the patches and then their AfterStrategy in the same
+// order on what it decided.
+func WithStrategyHooks(hooks ...StrategyHook) PatchStrategyOption {
+	return func(o *patchStrategyOptions) {
+		o.hooks = append(o.hooks, hooks...)
+	}
+}
@@ -0,0 +1,48 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchStrategyHooks(t *testing.T) {
+	result, err := AnalyzeProject(context.Background(), &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{"netty.version": "4.1.94.Final"}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "${netty.version}"},
+			{GroupID: "org.json", ArtifactID: "json", Version: "20230227"},
+		},
+	})
+	require.NoError(t, err)
+
+	calls := []string{}
+	dropJSON := StrategyHookFuncs{Before: func(_ context.Context, _ *AnalysisResult, patches []Patch) []Patch {
+		calls = append(calls, "before")
+		kept := []Patch{}
+		for _, p := range patches {
+			if p.ArtifactID != "json" {
+				kept = append(kept, p)
+			}
+		}
+		return kept
+	}}
+	pinProperty := StrategyHookFuncs{After: func(_ context.Context, _ *AnalysisResult, direct []Patch, properties map[string]string) ([]Patch, map[string]string) {
+		calls = append(calls, "after")
+		// Only what the first hook kept is planned.
+		assert.Empty(t, direct)
+		properties["netty.version"] = "4.1.100.Final"
+		return direct, properties
+	}}
+
+	direct, properties := PatchStrategy(context.Background(), result, []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final"},
+		{GroupID: "org.json", ArtifactID: "json", Version: "20231013"},
+	}, WithStrategyHooks(dropJSON, pinProperty))
+	assert.Empty(t, direct)
+	assert.Equal(t, map[string]string{"netty.version": "4.1.100.Final"}, properties)
+	assert.Equal(t, []string{"before", "after"}, calls)
+}
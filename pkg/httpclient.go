@@ -0,0 +1,264 @@
+package pkg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// HTTPOptions configures the HTTP client all the remote lookups share: the
+// Maven repositories, OSV, the GitHub Advisory Database, NVD and GitHub.
+type HTTPOptions struct {
+	// Timeout bounds each request, retries included. It defaults to 30s.
+	Timeout time.Duration
+	// Retries is how many times a GET or HEAD request failing with a network
+	// error, a 429 or a 5xx is retried. Zero disables retries.
+	Retries int
+	// Backoff is the wait before the first retry, doubled before every
+	// other one, unless the server sends a Retry-After. It defaults to
+	// 500ms.
+	Backoff time.Duration
+	// CacheDir, if set, keeps the responses carrying an ETag or a
+	// Last-Modified there, and revalidates them with conditional requests
+	// rather than downloading them again.
+	CacheDir string
+}
+
+// maxRetryAfter caps how long a Retry-After makes a retry wait.
+const maxRetryAfter = time.Minute
+
+var (
+	httpOptionsMu sync.Mutex
+	httpOptions   = HTTPOptions{Timeout: 30 * time.Second, Retries: 3, Backoff: 500 * time.Millisecond}
+)
+
+// SetHTTPOptions sets the options of the HTTP clients created from then on,
+// by NewHTTPClient and the constructors of every remote client.
+func SetHTTPOptions(opts HTTPOptions) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 500 * time.Millisecond
+	}
+	httpOptionsMu.Lock()
+	defer httpOptionsMu.Unlock()
+	httpOptions = opts
+}
+
+// NewHTTPClient returns an HTTP client that retries and caches as set by
+// SetHTTPOptions, on top of transport (http.DefaultTransport if nil).
+func NewHTTPClient(transport http.RoundTripper) *http.Client {
+	httpOptionsMu.Lock()
+	opts := httpOptions
+	httpOptionsMu.Unlock()
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if opts.CacheDir != "" {
+		transport = &cachingTransport{next: transport, dir: opts.CacheDir}
+	}
+	if opts.Retries > 0 {
+		transport = &retryingTransport{next: transport, retries: opts.Retries, backoff: opts.Backoff}
+	}
+	return &http.Client{Timeout: opts.Timeout, Transport: transport}
+}
+
+// retryingTransport retries the idempotent requests that fail with a
+// network error or a status worth retrying, backing off exponentially.
+type retryingTransport struct {
+	next    http.RoundTripper
+	retries int
+	backoff time.Duration
+}
+
+func (t *retryingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return t.next.RoundTrip(req)
+	}
+	ctx := req.Context()
+	log := clog.FromContext(ctx)
+	wait := t.backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if ctx.Err() != nil || attempt == t.retries || !retryable(resp, err) {
+			return resp, err
+		}
+		delay := wait
+		if err != nil {
+			log.Debugf("Retrying %s in %s: %v", req.URL, delay, err)
+		} else {
+			if after, ok := retryAfter(resp); ok {
+				delay = after
+			}
+			log.Debugf("Retrying %s in %s: %s", req.URL, delay, resp.Status)
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		wait *= 2
+	}
+}
+
+// retryable reports whether a request that got resp, or err, is worth
+// retrying: network errors, rate limiting and server errors.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// retryAfter returns the wait a Retry-After header of resp asks for, in
+// seconds or as a date, if any and reasonable.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	var after time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		after = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		after = time.Until(date)
+	} else {
+		return 0, false
+	}
+	if after < 0 || after > maxRetryAfter {
+		return 0, false
+	}
+	return after, true
+}
+
+// cachedResponse is a response kept by cachingTransport.
+type cachedResponse struct {
+	URL          string      `json:"url"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"lastModified,omitempty"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+}
+
+// cachingTransport caches the successful GET responses that carry an ETag
+// or a Last-Modified, and answers from the cache when the server says they
+// have not changed.
+type cachingTransport struct {
+	next http.RoundTripper
+	dir  string
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+	log := clog.FromContext(req.Context())
+	file := filepath.Join(t.dir, cacheKey(req)+".json")
+	cached := readCachedResponse(file)
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		_ = resp.Body.Close()
+		log.Debugf("Using cached response for %s", req.URL)
+		return cached.response(req), nil
+	}
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || (etag == "" && lastModified == "") {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	entry := cachedResponse{URL: req.URL.String(), ETag: etag, LastModified: lastModified, Header: resp.Header, Body: body}
+	if err := writeCachedResponse(file, &entry); err != nil {
+		log.Warnf("failed to cache the response for %s: %v", req.URL, err)
+	}
+	return resp, nil
+}
+
+// cacheKey identifies the response to req: its URL and headers, so that
+// the responses to different credentials are kept apart.
+func cacheKey(req *http.Request) string {
+	h := sha256.New()
+	fmt.Fprintln(h, req.URL.String())
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(h, "%s: %s\n", name, strings.Join(req.Header[name], ","))
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// readCachedResponse reads the response cached in file, nil if none.
+func readCachedResponse(file string) *cachedResponse {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	var cached cachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil
+	}
+	return &cached
+}
+
+// writeCachedResponse writes entry to file, atomically so that concurrent
+// lookups never read half of it.
+func writeCachedResponse(file string, entry *cachedResponse) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return writeCacheFile(file, data)
+}
+
+// response returns the cached response as the answer to req.
+func (c *cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,61 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// IgnoreFileName is the name of the file listing what to suppress, looked
+// up from the directory of the POM up to the root of the repository.
+const IgnoreFileName = ".pombump-ignore"
+
+// FindIgnoreFile looks for IgnoreFileName in dir and its parents, up to the
+// root of the git repository dir is in. It returns "" if there is none.
+func FindIgnoreFile(dir string) (string, error) {
+	return findUp(dir, IgnoreFileName)
+}
+
+// LoadIgnoreFile reads the Suppressions listed in the ignore file at path,
+// one per line: a warning code or its name, groupId:artifactId coordinates,
+// where * matches any part, or an advisory identifier. Blank lines and what
+// follows a # are ignored, so that each entry can say why:
+//
+//	# The parent POM, not published, defines them
+//	property-not-found
+//	org.example:legacy-*
+//	CVE-2023-34462  # the affected codec is not used
+func LoadIgnoreFile(path string) (Suppressions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ignore file: %w", err)
+	}
+	suppressions := Suppressions{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		entry, _, _ := strings.Cut(scanner.Text(), "#")
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		switch {
+		case parseCode(entry) != "":
+			suppressions[parseCode(entry)] = true
+		case IsAdvisoryID(entry):
+			suppressions[entry] = true
+		case isIgnoredCoordinates(entry):
+			suppressions[entry] = true
+		default:
+			return nil, fmt.Errorf("%s:%d: %q is not a warning code, groupId:artifactId or advisory identifier", path, line, entry)
+		}
+	}
+	return suppressions, nil
+}
+
+// isIgnoredCoordinates reports whether entry is groupId:artifactId.
+func isIgnoredCoordinates(entry string) bool {
+	groupID, artifactID, ok := strings.Cut(entry, ":")
+	return ok && groupID != "" && artifactID != "" && !strings.ContainsAny(artifactID, ": \t")
+}
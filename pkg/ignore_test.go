@@ -0,0 +1,100 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadIgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+	repo := filepath.Join(dir, "repo")
+	module := filepath.Join(repo, "services", "api")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0755))
+	require.NoError(t, os.MkdirAll(module, 0755))
+
+	path, err := FindIgnoreFile(module)
+	require.NoError(t, err)
+	assert.Empty(t, path)
+
+	writeConfig(t, filepath.Join(repo, IgnoreFileName), `# The parent POM, not published, defines them
+property-not-found
+
+POMBUMP-W004
+org.example:legacy-*
+io.netty:netty-codec
+CVE-2023-34462  # the affected codec is not used
+GHSA-jjjh-jjxp-wpff
+`)
+	path, err = FindIgnoreFile(module)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(repo, IgnoreFileName), path)
+
+	suppressions, err := LoadIgnoreFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, Suppressions{
+		CodePropertyNotFound:   true,
+		CodeSnapshot:           true,
+		"org.example:legacy-*": true,
+		"io.netty:netty-codec": true,
+		"CVE-2023-34462":       true,
+		"GHSA-jjjh-jjxp-wpff":  true,
+	}, suppressions)
+
+	writeConfig(t, path, "POMBUMP-W001\nPOMBUMP-W999\n")
+	_, err = LoadIgnoreFile(path)
+	assert.ErrorContains(t, err, IgnoreFileName+`:2: "POMBUMP-W999" is not a warning code`)
+	writeConfig(t, path, "netty\n")
+	_, err = LoadIgnoreFile(path)
+	assert.ErrorContains(t, err, `"netty" is not a warning code, groupId:artifactId or advisory identifier`)
+}
+
+func TestSuppressByCoordinates(t *testing.T) {
+	suppressions := Suppressions{"org.example:legacy-*": true, "io.netty:netty-codec": true}
+	issues := []UnfixableIssue{
+		{GroupID: "org.example", ArtifactID: "legacy-core", Version: "2.0", Reason: ReasonVersionNotPublished, Code: CodeVersionNotPublished},
+		{GroupID: "org.example", ArtifactID: "core", Version: "2.0", Reason: ReasonVersionNotPublished, Code: CodeVersionNotPublished},
+	}
+	kept, suppressed := suppressions.Unfixable(issues)
+	assert.Equal(t, issues[1:], kept)
+	assert.Equal(t, []SuppressedIssue{{Code: CodeVersionNotPublished, Message: "org.example:legacy-core 2.0: " + ReasonVersionNotPublished, By: "org.example:legacy-*"}}, suppressed)
+
+	// A warning about several artifacts is suppressed when all of them are.
+	warnings := []Warning{
+		{Code: CodeBOMDowngrade, Message: "one", Artifacts: []VersionChange{{Name: "io.netty:netty-codec"}, {Name: "org.example:legacy-api"}}},
+		{Code: CodeBOMDowngrade, Message: "two", Artifacts: []VersionChange{{Name: "io.netty:netty-codec"}, {Name: "io.netty:netty-handler"}}},
+	}
+	keptWarnings, suppressed := suppressions.Warnings(warnings)
+	assert.Equal(t, warnings[1:], keptWarnings)
+	assert.Equal(t, []SuppressedIssue{{Code: CodeBOMDowngrade, Message: "one", By: "io.netty:netty-codec, org.example:legacy-*"}}, suppressed)
+}
+
+func TestSuppressAdvisories(t *testing.T) {
+	suppressions := Suppressions{"CVE-2023-34462": true, "GHSA-jjjh-jjxp-wpff": true}
+	patches := []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.94.Final", Advisories: []string{"CVE-2023-34462"}},
+		{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "4.1.100.Final", Advisories: []string{"CVE-2023-34462", "CVE-2023-44487"}},
+		{GroupID: "com.fasterxml.jackson.core", ArtifactID: "jackson-databind", Version: "GHSA-jjjh-jjxp-wpff"},
+		{GroupID: "org.json", ArtifactID: "json", Version: "20231013"},
+	}
+	kept, suppressed := suppressions.Patches(patches)
+	// A patch fixing another advisory too is kept.
+	assert.Equal(t, []Patch{patches[1], patches[3]}, kept)
+	assert.Equal(t, []SuppressedIssue{
+		{Message: "io.netty:netty-handler 4.1.94.Final fixing CVE-2023-34462", By: "CVE-2023-34462"},
+		{Message: "com.fasterxml.jackson.core:jackson-databind fixing GHSA-jjjh-jjxp-wpff", By: "GHSA-jjjh-jjxp-wpff"},
+	}, suppressed)
+
+	ctx := context.Background()
+	analyzer := NewAnalyzer(WithoutRemoteAccess(), WithSuppressions(suppressions))
+	analysis, err := analyzer.Analyze(ctx, writeAnalyzerPOM(t))
+	require.NoError(t, err)
+	rec, err := analyzer.Recommend(ctx, analysis, patches[:2])
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"netty.version": "4.1.100.Final"}, rec.PropertyPatches)
+	assert.Equal(t, suppressed[:1], rec.Suppressed)
+}
@@ -0,0 +1,108 @@
+package pkg
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+)
+
+//go:generate go test . -run TestSchemas -update
+
+// schemaFiles are the JSON Schemas generated from the types below, see
+// TestSchemas.
+//
+//go:embed schemas/*.schema.json
+var schemaFiles embed.FS
+
+// SchemaBaseURL is where the published schemas live, their $id being the
+// URL of their file there.
+const SchemaBaseURL = "https://raw.githubusercontent.com/chainguard-dev/pombump/main/pkg/schemas/"
+
+// outputSchema is a format pombump writes, or reads back, and the type its
+// documents are the JSON (or YAML) encoding of.
+type outputSchema struct {
+	Name        string
+	Title       string
+	Description string
+	Value       any
+	// Input formats are also written by hand, so none of their fields is
+	// required: a patch may give a purl rather than its coordinates.
+	Input bool
+}
+
+// outputSchemas are the formats with a published schema, in the order
+// `pombump schema` lists them.
+var outputSchemas = []outputSchema{
+	{
+		Name:        "analysis",
+		Title:       "pombump analysis",
+		Description: "The output of `pombump analyze --output json`, and what --output-template renders: the analysis of the POMs and what pombump recommends for the patches given.",
+		Value:       AnalysisOutput{},
+	},
+	{
+		Name:        "report",
+		Title:       "pombump aggregate report",
+		Description: "The output of `pombump analyze --output json` with --all-modules or several POMs and no patches: the analysis summarized per module.",
+		Value:       AggregateReport{},
+	},
+	{
+		Name:        "patches",
+		Title:       "pombump patch file",
+		Description: "The dependency patches `pombump --patch-file` applies and `pombump analyze --output-deps` writes.",
+		Value:       PatchList{},
+		Input:       true,
+	},
+	{
+		Name:        "properties",
+		Title:       "pombump properties file",
+		Description: "The property patches `pombump --properties-file` applies and `pombump analyze --output-properties` writes.",
+		Value:       PropertyList{},
+		Input:       true,
+	},
+	{
+		Name:        "quarantine",
+		Title:       "pombump quarantine plan",
+		Description: "The risky changes `pombump --quarantine` holds back for review, which `pombump apply --quarantine` applies.",
+		Value:       QuarantinePlan{},
+		Input:       true,
+	},
+	{
+		Name:        "patch-report",
+		Title:       "pombump patch report",
+		Description: "The output of `pombump --output json`: the patches applied, skipped and failed, per file patched.",
+		Value:       PatchReport{},
+	},
+	{
+		Name:        "changes",
+		Title:       "pombump analysis changes",
+		Description: "The output of `pombump analyze --baseline --output json`: the issues new and resolved since the baseline analysis, and the imported BOMs that changed.",
+		Value:       AnalysisChanges{},
+	},
+}
+
+// SchemaNames returns the names of the published JSON Schemas: analysis,
+// report, patches, properties, quarantine, patch-report and changes.
+func SchemaNames() []string {
+	names := make([]string, 0, len(outputSchemas))
+	for _, s := range outputSchemas {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// Schema returns the JSON Schema (draft 2020-12) of the format called name,
+// see SchemaNames. The schemas of the patch files apply to their YAML form
+// too.
+func Schema(name string) ([]byte, error) {
+	for _, s := range outputSchemas {
+		if s.Name == name {
+			return schemaFiles.ReadFile(schemaFileName(name))
+		}
+	}
+	return nil, fmt.Errorf("unknown schema %q, use one of %s", name, strings.Join(SchemaNames(), ", "))
+}
+
+// schemaFileName returns the file the schema called name is embedded from.
+func schemaFileName(name string) string {
+	return "schemas/" + name + ".schema.json"
+}
@@ -0,0 +1,444 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateSchemas = flag.Bool("update", false, "regenerate the JSON Schemas in schemas/")
+
+// TestSchemas checks that the embedded schemas are up to date with the types
+// they describe, and regenerates them with -update (see go generate).
+func TestSchemas(t *testing.T) {
+	docs := parseDocs(t)
+	for _, s := range outputSchemas {
+		t.Run(s.Name, func(t *testing.T) {
+			got, err := generateSchema(s, docs)
+			require.NoError(t, err)
+			if *updateSchemas {
+				require.NoError(t, os.MkdirAll("schemas", 0755))
+				require.NoError(t, os.WriteFile(schemaFileName(s.Name), got, 0644))
+				return
+			}
+			want, err := Schema(s.Name)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got), "the schema is out of date, run go generate ./pkg")
+		})
+	}
+}
+
+func TestSchemaUnknown(t *testing.T) {
+	_, err := Schema("nope")
+	assert.ErrorContains(t, err, `unknown schema "nope", use one of analysis, report, patches, properties, quarantine, patch-report, changes`)
+}
+
+func TestAnalysisOutputMatchesSchema(t *testing.T) {
+	ctx := context.Background()
+	project, err := ParseAnalysisPOM(ctx, filepath.Join("testdata", "cloudwatch-exporter.pom.xml"))
+	require.NoError(t, err)
+	analysis, err := AnalyzeProject(ctx, project)
+	require.NoError(t, err)
+
+	output := AnalysisOutput{
+		POMs:       []string{"pom.xml"},
+		Analysis:   analysis,
+		Report:     analysis.AggregateReport(),
+		Patches:    WithPurls([]Patch{{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "4.1.100.Final", Advisories: []string{"CVE-2023-44487"}}}),
+		Properties: SortedPropertyPatches(map[string]string{"netty.version": "4.1.100.Final"}),
+		Licenses:   analysis.Licenses(),
+		Warnings:   analysis.Warnings(),
+	}
+	data, err := json.Marshal(output)
+	require.NoError(t, err)
+	assertMatchesSchema(t, "analysis", data)
+}
+
+func TestPatchFilesMatchSchema(t *testing.T) {
+	for file, schema := range map[string]string{
+		"patches.yaml":            "patches",
+		"annotated-patches.yaml":  "patches",
+		"exclusions-patches.yaml": "patches",
+		"properties.yaml":         "properties",
+	} {
+		t.Run(file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", file))
+			require.NoError(t, err)
+			data, err = yaml.YAMLToJSON(data)
+			require.NoError(t, err)
+			assertMatchesSchema(t, schema, data)
+		})
+	}
+}
+
+func TestSchemaRejects(t *testing.T) {
+	schema := loadSchema(t, "patches")
+	assert.NotEmpty(t, validateSchema(schema, schema, map[string]any{"patches": "io.netty@netty-codec@4.1.100.Final"}, ""))
+	assert.NotEmpty(t, validateSchema(schema, schema, map[string]any{"patches": []any{map[string]any{"group": "io.netty"}}}, ""))
+
+	schema = loadSchema(t, "analysis")
+	assert.Equal(t, []string{`/: missing "poms"`}, validateSchema(schema, schema, map[string]any{"analysis": nil, "report": nil}, ""))
+}
+
+// assertMatchesSchema asserts that the JSON document data is valid against
+// the schema called name.
+func assertMatchesSchema(t *testing.T, name string, data []byte) {
+	t.Helper()
+	var doc any
+	require.NoError(t, json.Unmarshal(data, &doc))
+	schema := loadSchema(t, name)
+	assert.Empty(t, validateSchema(schema, schema, doc, ""))
+}
+
+func loadSchema(t *testing.T, name string) map[string]any {
+	t.Helper()
+	data, err := Schema(name)
+	require.NoError(t, err)
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(data, &schema))
+	return schema
+}
+
+// validateSchema returns what makes value invalid against schema, a part
+// of root. It implements the subset of JSON Schema the generated schemas
+// use, and is stricter in rejecting the properties a schema does not list.
+func validateSchema(root, schema map[string]any, value any, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		defs, _ := root["$defs"].(map[string]any)
+		def, ok := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: unresolved %s", path, ref)}
+		}
+		return validateSchema(root, def, value, path)
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		for _, s := range anyOf {
+			if len(validateSchema(root, s.(map[string]any), value, path)) == 0 {
+				return nil
+			}
+		}
+		return []string{fmt.Sprintf("%s: matches none of anyOf", path)}
+	}
+	if types, ok := schema["type"]; ok {
+		allowed := []any{types}
+		if list, ok := types.([]any); ok {
+			allowed = list
+		}
+		if !slices.Contains(allowed, any(jsonType(value))) && !(jsonType(value) == "integer" && slices.Contains(allowed, any("number"))) {
+			return []string{fmt.Sprintf("%s: %s is not %v", path, jsonType(value), types)}
+		}
+	}
+
+	var errs []string
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		additional, _ := schema["additionalProperties"].(map[string]any)
+		for _, key := range sortedKeys(v) {
+			if s, ok := schemaProperty(properties, key); ok {
+				errs = append(errs, validateSchema(root, s, v[key], path+"/"+key)...)
+			} else if additional != nil {
+				errs = append(errs, validateSchema(root, additional, v[key], path+"/"+key)...)
+			} else {
+				errs = append(errs, fmt.Sprintf("%s: unexpected %q", path, key))
+			}
+		}
+		required, _ := schema["required"].([]any)
+		for _, key := range required {
+			if _, ok := v[key.(string)]; !ok {
+				errs = append(errs, fmt.Sprintf("%s/: missing %q", path, key))
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				errs = append(errs, validateSchema(root, items, item, fmt.Sprintf("%s/%d", path, i))...)
+			}
+		}
+	}
+	return errs
+}
+
+// schemaProperty returns the schema of the property key, matched without
+// regard to case as encoding/json does: patch files spell groupID too.
+func schemaProperty(properties map[string]any, key string) (map[string]any, bool) {
+	if s, ok := properties[key].(map[string]any); ok {
+		return s, true
+	}
+	for name, s := range properties {
+		if strings.EqualFold(name, key) {
+			return s.(map[string]any), true
+		}
+	}
+	return nil, false
+}
+
+// jsonType returns the JSON Schema type of a decoded JSON value.
+func jsonType(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// generateSchema generates the JSON Schema of s, with the documentation of
+// the types and fields in docs.
+func generateSchema(s outputSchema, docs map[string]string) ([]byte, error) {
+	g := &schemaGenerator{docs: docs, input: s.Input, defs: map[string]any{}, names: map[reflect.Type]string{}}
+	schema := g.schemaOf(reflect.TypeOf(s.Value))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = SchemaBaseURL + strings.TrimPrefix(schemaFileName(s.Name), "schemas/")
+	schema["title"] = s.Title
+	schema["description"] = s.Description
+	schema["$defs"] = g.defs
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(schema); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// schemaGenerator generates a JSON Schema from Go types as encoding/json
+// marshals them, each named struct being a definition in $defs.
+type schemaGenerator struct {
+	docs  map[string]string
+	input bool
+	defs  map[string]any
+	names map[reflect.Type]string
+}
+
+var textMarshaler = reflect.TypeFor[encoding.TextMarshaler]()
+
+func (g *schemaGenerator) schemaOf(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Implements(textMarshaler) || reflect.PointerTo(t).Implements(textMarshaler) {
+		return map[string]any{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return map[string]any{"$ref": "#/$defs/" + g.define(t)}
+	default:
+		return map[string]any{}
+	}
+}
+
+// define adds the named struct t to $defs, under its name qualified by its
+// package if another type has it already, and returns that name.
+func (g *schemaGenerator) define(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := g.defs[name]; taken {
+		name = filepath.Base(t.PkgPath()) + "." + name
+	}
+	g.names[t] = name
+	g.defs[name] = nil
+	def := g.object(t)
+	if doc := g.docs[t.Name()]; doc != "" && ownType(t) {
+		def["description"] = doc
+	}
+	g.defs[name] = def
+	return name
+}
+
+// object returns the schema of the struct t, of which the fields not
+// omitted when empty are required, unless generating an input format.
+func (g *schemaGenerator) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	g.fields(t, properties, &required)
+	object := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 && !g.input {
+		slices.Sort(required)
+		object["required"] = required
+	}
+	return object
+}
+
+// fields adds the fields of t to properties, and the ones always marshaled
+// to required. As in encoding/json, the fields of embedded structs are
+// promoted unless t has a field of the same name.
+func (g *schemaGenerator) fields(t reflect.Type, properties map[string]any, required *[]string) {
+	var embedded []reflect.Type
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		schema := g.schemaOf(f.Type)
+		if doc := g.docs[t.Name()+"."+f.Name]; doc != "" && ownType(t) {
+			schema["description"] = doc
+		}
+		omitempty := slices.Contains(strings.Split(options, ","), "omitempty")
+		if !omitempty {
+			schema = nullable(f.Type, schema)
+			*required = append(*required, name)
+		}
+		properties[name] = schema
+	}
+	for _, e := range embedded {
+		promoted := map[string]any{}
+		var promotedRequired []string
+		g.fields(e, promoted, &promotedRequired)
+		for name, schema := range promoted {
+			if _, ok := properties[name]; !ok {
+				properties[name] = schema
+				if slices.Contains(promotedRequired, name) {
+					*required = append(*required, name)
+				}
+			}
+		}
+	}
+}
+
+// ownType reports whether t is a named type of this package, of which
+// parseDocs has the documentation.
+func ownType(t reflect.Type) bool {
+	return t.Name() != "" && t.PkgPath() == reflect.TypeFor[Patch]().PkgPath()
+}
+
+// nullable allows null for schema, the schema of a field of type t always
+// marshaled, if a nil t marshals to null.
+func nullable(t reflect.Type, schema map[string]any) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+	default:
+		return schema
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return schema
+	}
+	if typ, ok := schema["type"].(string); ok {
+		schema["type"] = []string{typ, "null"}
+		return schema
+	}
+	if ref, ok := schema["$ref"]; ok {
+		delete(schema, "$ref")
+		schema["anyOf"] = []any{map[string]any{"$ref": ref}, map[string]any{"type": "null"}}
+	}
+	return schema
+}
+
+// parseDocs returns the first paragraph of the doc comments of the types of
+// the package by name, and of their fields by type.field name, on a single
+// line.
+func parseDocs(t *testing.T) map[string]string {
+	t.Helper()
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+	docs := map[string]string{}
+	text := func(groups ...*ast.CommentGroup) string {
+		for _, group := range groups {
+			if group != nil {
+				paragraph, _, _ := strings.Cut(group.Text(), "\n\n")
+				return strings.Join(strings.Fields(paragraph), " ")
+			}
+		}
+		return ""
+	}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		require.NoError(t, err)
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if len(gen.Specs) == 1 {
+					docs[ts.Name.Name] = text(ts.Doc, gen.Doc)
+				} else {
+					docs[ts.Name.Name] = text(ts.Doc)
+				}
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				for _, field := range st.Fields.List {
+					for _, name := range field.Names {
+						docs[ts.Name.Name+"."+name.Name] = text(field.Doc, field.Comment)
+					}
+				}
+			}
+		}
+	}
+	return docs
+}
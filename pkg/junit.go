@@ -0,0 +1,196 @@
+package pkg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// JUnitReport is a JUnit XML report, the format generic CI dashboards track
+// test results in: each requested patch and each issue is a test case,
+// passing when nothing needs to be done and failing when action is
+// required.
+type JUnitReport struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite groups the test cases of a JUnitReport.
+type JUnitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase is a requested patch or an issue. It passes when it has
+// neither a Failure nor is Skipped.
+type JUnitTestCase struct {
+	Name      string `xml:"name,attr"`
+	ClassName string `xml:"classname,attr"`
+	// File and Line point at the declaration, when located (see
+	// LocateDeclarations).
+	File    string        `xml:"file,attr,omitempty"`
+	Line    int           `xml:"line,attr,omitempty"`
+	Failure *JUnitFailure `xml:"failure,omitempty"`
+	Skipped *JUnitSkipped `xml:"skipped,omitempty"`
+}
+
+// JUnitFailure says what action a failing test case requires. Type is the
+// code of the issue, see CodeName.
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnitSkipped says why a test case was not run, e.g. the policy dropping
+// a patch.
+type JUnitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// Names of the test suites of a JUnitReport.
+const (
+	junitSuitePatches  = "patches"
+	junitSuiteWarnings = "warnings"
+)
+
+// addSuite adds a suite of cases to the report, unless there are none, and
+// counts them.
+func (r *JUnitReport) addSuite(name string, cases []JUnitTestCase) {
+	if len(cases) == 0 {
+		return
+	}
+	suite := JUnitTestSuite{Name: name, Tests: len(cases), Cases: cases}
+	for _, c := range cases {
+		if c.Failure != nil {
+			suite.Failures++
+		}
+		if c.Skipped != nil {
+			suite.Skipped++
+		}
+	}
+	r.Tests += suite.Tests
+	r.Failures += suite.Failures
+	r.Skipped += suite.Skipped
+	r.Suites = append(r.Suites, suite)
+}
+
+// NewJUnitReport reports the analysis of pom given the patches requested,
+// with their versions resolved: the patches in skipped, such as those
+// already in effect, are skipped, a patch passes when the version in use
+// already satisfies it and fails when it is to be applied or is in
+// unfixable, and every warning of the analysis fails. Patches that do not
+// set a version, such as removals, are left out.
+func NewJUnitReport(pom string, analysis *AnalysisResult, requested []Patch, unfixable []UnfixableIssue, skipped []SkippedPatch) *JUnitReport {
+	report := &JUnitReport{Name: "pombump " + pom, Suites: []JUnitTestSuite{}}
+	current := analysis.CurrentVersions()
+
+	patches := []JUnitTestCase{}
+	for _, p := range requested {
+		if !changesVersion(p) {
+			continue
+		}
+		name := fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID)
+		c := JUnitTestCase{Name: fmt.Sprintf("%s >= %s", name, p.Version), ClassName: pom}
+		info := analysis.Dependencies[name]
+		if info != nil && info.Position != nil {
+			c.File, c.Line = info.Position.File, info.Position.Line
+		}
+		actual, ok := current[name]
+		switch {
+		case ok:
+		case info != nil && strings.Contains(info.Version, "${"):
+			// Using a property that is not defined.
+			actual = info.Version
+		default:
+			if managed, found := analysis.ManagedVersion(p.GroupID, p.ArtifactID); found {
+				actual = managed.Version
+			}
+		}
+		check := checkVersion(name, p.Version, actual)
+		i := slices.IndexFunc(unfixable, func(u UnfixableIssue) bool {
+			return u.GroupID == p.GroupID && u.ArtifactID == p.ArtifactID
+		})
+		j := slices.IndexFunc(skipped, func(s SkippedPatch) bool {
+			return s.Name == name && s.Version == p.Version
+		})
+		switch {
+		case j >= 0:
+			c.Skipped = &JUnitSkipped{Message: skipped[j].Reason}
+		case check.Satisfied:
+		case i >= 0:
+			u := unfixable[i]
+			c.Failure = &JUnitFailure{Message: fmt.Sprintf("Unable to patch %s to %s: %s", name, u.Version, u.Reason), Type: u.Code}
+		default:
+			c.Failure = &JUnitFailure{Message: fmt.Sprintf("%s is %s, patch it to %s", name, describeActual(actual), p.Version)}
+		}
+		patches = append(patches, c)
+	}
+	// Those skipped before being requested, e.g. as already in effect.
+	for _, s := range skipped {
+		if !slices.ContainsFunc(requested, func(p Patch) bool {
+			return s.Name == fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID) && s.Version == p.Version
+		}) {
+			patches = append(patches, JUnitTestCase{
+				Name: fmt.Sprintf("%s >= %s", s.Name, s.Version), ClassName: pom,
+				Skipped: &JUnitSkipped{Message: s.Reason},
+			})
+		}
+	}
+	report.addSuite(junitSuitePatches, patches)
+
+	warnings := []JUnitTestCase{}
+	for _, w := range analysis.Warnings() {
+		warnings = append(warnings, JUnitTestCase{
+			Name:      w.Message,
+			ClassName: pom,
+			Failure:   &JUnitFailure{Message: w.Message, Type: w.Code, Text: w.Kind},
+		})
+	}
+	report.addSuite(junitSuiteWarnings, warnings)
+	return report
+}
+
+// CheckJUnitReport reports the results of CheckPatches on pom, a test case
+// per patch passing when satisfied.
+func CheckJUnitReport(pom string, results []CheckResult) *JUnitReport {
+	report := &JUnitReport{Name: "pombump " + pom, Suites: []JUnitTestSuite{}}
+	cases := []JUnitTestCase{}
+	for _, r := range results {
+		c := JUnitTestCase{Name: fmt.Sprintf("%s >= %s", r.Name, r.Requested), ClassName: pom}
+		if !r.Satisfied {
+			c.Failure = &JUnitFailure{Message: fmt.Sprintf("%s is %s, wanted at least %s", r.Name, describeActual(r.Actual), r.Requested), Type: r.Code}
+		}
+		cases = append(cases, c)
+	}
+	report.addSuite(junitSuitePatches, cases)
+	return report
+}
+
+// describeActual describes the version in use for a failure message.
+func describeActual(actual string) string {
+	switch {
+	case actual == "":
+		return "not found"
+	case strings.Contains(actual, "${"):
+		return "unresolved " + actual
+	}
+	return actual
+}
+
+// XML renders the report as indented XML, with the XML declaration.
+func (r *JUnitReport) XML() ([]byte, error) {
+	out, err := xml.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
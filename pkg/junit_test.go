@@ -0,0 +1,72 @@
+package pkg
+
+import (
+	"context"
+	"encoding/xml"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJUnitReport(t *testing.T) {
+	analysis, err := AnalyzeProject(context.Background(), &gopom.Project{
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "${netty.version}"},
+			{GroupID: "org.yaml", ArtifactID: "snakeyaml", Version: "2.2"},
+			{GroupID: "com.google.guava", ArtifactID: "guava", Version: "31.1-jre"},
+			{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "1.7.36"},
+		},
+	})
+	require.NoError(t, err)
+
+	report := NewJUnitReport("pom.xml", analysis, []Patch{
+		{GroupID: "org.yaml", ArtifactID: "snakeyaml", Version: "2.0"},
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final"},
+		{GroupID: "com.google.guava", ArtifactID: "guava", Version: "33.0.0-jre"},
+		{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.16"},
+		{GroupID: "com.example", ArtifactID: "missing", Version: "1.0"},
+		{GroupID: "org.yaml", ArtifactID: "snakeyaml", Operation: PatchOperationRemove},
+	}, []UnfixableIssue{
+		{Code: CodeVersionNotPublished, GroupID: "com.example", ArtifactID: "missing", Version: "1.0", Reason: "version 1.0 is not published"},
+	}, []SkippedPatch{
+		{Name: "com.google.guava:guava", Current: "31.1-jre", Version: "33.0.0-jre", Reason: "major bumps of guava are forbidden"},
+	})
+
+	assert.Equal(t, 6, report.Tests)
+	assert.Equal(t, 4, report.Failures)
+	assert.Equal(t, 1, report.Skipped)
+	require.Len(t, report.Suites, 2)
+	patches := report.Suites[0]
+	assert.Equal(t, "patches", patches.Name)
+	require.Len(t, patches.Cases, 5)
+	assert.Equal(t, JUnitTestCase{Name: "org.yaml:snakeyaml >= 2.0", ClassName: "pom.xml"}, patches.Cases[0])
+	assert.Equal(t, "io.netty:netty-handler is unresolved ${netty.version}, patch it to 4.1.118.Final", patches.Cases[1].Failure.Message)
+	assert.Equal(t, &JUnitSkipped{Message: "major bumps of guava are forbidden"}, patches.Cases[2].Skipped)
+	assert.Equal(t, "org.slf4j:slf4j-api is 1.7.36, patch it to 2.0.16", patches.Cases[3].Failure.Message)
+	assert.Equal(t, CodeVersionNotPublished, patches.Cases[4].Failure.Type)
+
+	warnings := report.Suites[1]
+	assert.Equal(t, "warnings", warnings.Name)
+	require.Len(t, warnings.Cases, 1)
+	assert.Equal(t, CodePropertyNotFound, warnings.Cases[0].Failure.Type)
+
+	out, err := report.XML()
+	require.NoError(t, err)
+	var parsed JUnitReport
+	require.NoError(t, xml.Unmarshal(out, &parsed))
+	assert.Equal(t, "pombump pom.xml", parsed.Name)
+	assert.Equal(t, report.Suites, parsed.Suites)
+}
+
+func TestCheckJUnitReport(t *testing.T) {
+	report := CheckJUnitReport("pom.xml", []CheckResult{
+		{Name: "org.yaml:snakeyaml", Requested: "2.0", Actual: "2.2", Satisfied: true},
+		{Name: "netty.version", Requested: "4.1.118.Final", Code: CodeVerificationFailed},
+	})
+	assert.Equal(t, 2, report.Tests)
+	assert.Equal(t, 1, report.Failures)
+	assert.Nil(t, report.Suites[0].Cases[0].Failure)
+	assert.Equal(t, &JUnitFailure{Message: "netty.version is not found, wanted at least 4.1.118.Final", Type: CodeVerificationFailed}, report.Suites[0].Cases[1].Failure)
+}
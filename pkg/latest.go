@@ -0,0 +1,128 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/chainguard-dev/clog"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Version keywords that can be used instead of a version in a patch.
+const (
+	// VersionLatest resolves to the newest release.
+	VersionLatest = "latest"
+	// VersionLatestPatch resolves to the newest release with the same
+	// major.minor as the version currently in use.
+	VersionLatestPatch = "latest-patch"
+)
+
+// preReleaseQualifiers are the qualifiers that make a version a pre-release.
+var preReleaseQualifiers = map[string]bool{
+	"alpha":     true,
+	"a":         true,
+	"beta":      true,
+	"b":         true,
+	"milestone": true,
+	"m":         true,
+	"rc":        true,
+	"cr":        true,
+	"snapshot":  true,
+	"ea":        true,
+	"preview":   true,
+}
+
+// IsVersionKeyword reports whether version is one of the version keywords.
+func IsVersionKeyword(version string) bool {
+	return version == VersionLatest || version == VersionLatestPatch
+}
+
+// ResolveVersionKeywords replaces @latest and @latest-patch versions in
+// patches with actual versions looked up in repo. current maps
+// groupId:artifactId to the version currently in use, which @latest-patch
+// needs.
+func ResolveVersionKeywords(ctx context.Context, repo *MavenRepository, patches []Patch, current map[string]string) (_ []Patch, err error) {
+	ctx, end := startSpan(ctx, OperationResolve, attribute.String("pombump.resolver", "keywords"))
+	defer func() { end(err) }()
+	log := clog.FromContext(ctx)
+	resolved := make([]Patch, 0, len(patches))
+	for _, p := range patches {
+		if !IsVersionKeyword(p.Version) {
+			resolved = append(resolved, p)
+			continue
+		}
+		key := fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID)
+		versions, err := repo.Versions(ctx, p.GroupID, p.ArtifactID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up versions of %s: %w", key, err)
+		}
+		var version string
+		if p.Version == VersionLatest {
+			version = latestRelease(versions, "")
+		} else {
+			if current[key] == "" {
+				return nil, fmt.Errorf("%s for %s needs the current version, but it is not known", p.Version, key)
+			}
+			version = latestRelease(versions, current[key])
+		}
+		if version == "" {
+			return nil, fmt.Errorf("no release of %s found for %s", key, p.Version)
+		}
+		log.Infof("Resolved %s@%s to %s", key, p.Version, version)
+		p.Version = version
+		resolved = append(resolved, p)
+	}
+	return resolved, nil
+}
+
+// latestRelease returns the newest release in versions. If sameMinorAs is
+// set, only versions with the same major.minor are considered.
+func latestRelease(versions []string, sameMinorAs string) string {
+	latest := ""
+	for _, v := range versions {
+		if isPreRelease(v) {
+			continue
+		}
+		if sameMinorAs != "" && !sameMinor(v, sameMinorAs) {
+			continue
+		}
+		if latest == "" || compareVersions(v, latest) > 0 {
+			latest = v
+		}
+	}
+	return latest
+}
+
+// isPreRelease reports whether a version has a pre-release qualifier.
+func isPreRelease(version string) bool {
+	for _, part := range splitVersion(version) {
+		// Qualifiers like rc1 get split into rc and 1.
+		if preReleaseQualifiers[part] {
+			return true
+		}
+	}
+	return false
+}
+
+// sameMinor reports whether two versions share the same major.minor.
+func sameMinor(a, b string) bool {
+	pa, pb := numericPrefix(splitVersion(a), 2), numericPrefix(splitVersion(b), 2)
+	return len(pa) == 2 && len(pb) == 2 && pa[0] == pb[0] && pa[1] == pb[1]
+}
+
+// numericPrefix returns up to n leading numeric parts of a split version.
+func numericPrefix(parts []string, n int) []int {
+	numbers := []int{}
+	for _, part := range parts {
+		if len(numbers) == n {
+			break
+		}
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers
+}
@@ -0,0 +1,54 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nettyMetadata = `<?xml version="1.0" encoding="UTF-8"?>
+<metadata>
+  <groupId>io.netty</groupId>
+  <artifactId>netty-handler</artifactId>
+  <versioning>
+    <latest>5.0.0.Alpha2</latest>
+    <release>5.0.0.Alpha2</release>
+    <versions>
+      <version>4.1.86.Final</version>
+      <version>4.1.94.Final</version>
+      <version>4.1.100.Final</version>
+      <version>4.2.0.RC1</version>
+      <version>4.2.0.Final</version>
+      <version>4.2.1.Final</version>
+      <version>5.0.0.Alpha2</version>
+    </versions>
+  </versioning>
+</metadata>`
+
+func TestResolveVersionKeywords(t *testing.T) {
+	repo := fakeRepository(t, map[string]string{
+		"io/netty/netty-handler/maven-metadata.xml": nettyMetadata,
+	})
+	ctx := context.Background()
+	current := map[string]string{"io.netty:netty-handler": "4.1.86.Final"}
+
+	got, err := ResolveVersionKeywords(ctx, repo, []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "latest"},
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "latest-patch"},
+		{GroupID: "org.json", ArtifactID: "json", Version: "20231013"},
+	}, current)
+	require.NoError(t, err)
+	assert.Equal(t, "4.2.1.Final", got[0].Version)
+	assert.Equal(t, "4.1.100.Final", got[1].Version)
+	assert.Equal(t, "20231013", got[2].Version)
+
+	// latest-patch needs to know the current version
+	_, err = ResolveVersionKeywords(ctx, repo, []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "latest-patch"}}, nil)
+	assert.Error(t, err)
+
+	// Unknown artifact
+	_, err = ResolveVersionKeywords(ctx, repo, []Patch{{GroupID: "org.json", ArtifactID: "json", Version: "latest"}}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// License is a license a POM declares in <licenses>.
+type License struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	URL  string `json:"url,omitempty" yaml:"url,omitempty"`
+}
+
+// DependencyLicenses are the licenses of a dependency, as its POM declares
+// them.
+type DependencyLicenses struct {
+	GroupID    string    `json:"groupId" yaml:"groupId"`
+	ArtifactID string    `json:"artifactId" yaml:"artifactId"`
+	Version    string    `json:"version" yaml:"version"`
+	Licenses   []License `json:"licenses" yaml:"licenses"`
+}
+
+// projectLicenses returns the licenses project declares, nil if none.
+func projectLicenses(project *gopom.Project) []License {
+	if project == nil || project.Licenses == nil {
+		return nil
+	}
+	licenses := []License{}
+	for _, l := range *project.Licenses {
+		if l.Name == "" && l.URL == "" {
+			continue
+		}
+		licenses = append(licenses, License{Name: strings.TrimSpace(l.Name), URL: strings.TrimSpace(l.URL)})
+	}
+	if len(licenses) == 0 {
+		return nil
+	}
+	return licenses
+}
+
+// Licenses returns the licenses the analyzed POM declares. A POM inheriting
+// them from its parent declares none.
+func (result *AnalysisResult) Licenses() []License {
+	return projectLicenses(result.project)
+}
+
+// ResolveLicenses fetches the POM of every dependency whose version is known
+// from repo and records the licenses it declares, or inherits from its
+// parents, in DependencyInfo.Licenses. A POM that can not be fetched is
+// skipped with a warning, and so are the dependencies without a known
+// version (resolve the BOMs first, see ResolveBOMs).
+func (result *AnalysisResult) ResolveLicenses(ctx context.Context, repo *MavenRepository) {
+	ctx, end := startSpan(ctx, OperationResolve, attribute.String("pombump.resolver", "licenses"))
+	defer end(nil)
+	log := clog.FromContext(ctx)
+	result.IndexDependencies(ctx)
+	for _, key := range sortedKeys(result.Dependencies) {
+		info := result.Dependencies[key]
+		version := result.dependencyVersion(info)
+		if version == "" || strings.Contains(version, "${") || isVersionRange(version) {
+			log.Debugf("Not resolving the licenses of %s, its version %q is unknown", key, version)
+			continue
+		}
+		chain, err := fetchParentChain(ctx, repo, info.GroupID, info.ArtifactID, version)
+		if err != nil {
+			log.Warnf("Unable to resolve the licenses of %s:%s: %v", key, version, err)
+			continue
+		}
+		info.Licenses = []License{}
+		// The closest POM declaring licenses wins, as in Maven.
+		for _, p := range chain {
+			if licenses := projectLicenses(p); licenses != nil {
+				info.Licenses = licenses
+				break
+			}
+		}
+		if len(info.Licenses) == 0 {
+			log.Warnf("%s:%s declares no license", key, version)
+		}
+	}
+}
+
+// DependencyLicenses lists the licenses of the dependencies, sorted by
+// groupId:artifactId. Only the dependencies whose licenses ResolveLicenses
+// looked up are listed, with no licenses if their POM declares none.
+func (result *AnalysisResult) DependencyLicenses() []DependencyLicenses {
+	result.ensureDependencies()
+	licenses := []DependencyLicenses{}
+	for _, key := range sortedKeys(result.Dependencies) {
+		info := result.Dependencies[key]
+		if info.Licenses == nil {
+			continue
+		}
+		licenses = append(licenses, DependencyLicenses{
+			GroupID:    info.GroupID,
+			ArtifactID: info.ArtifactID,
+			Version:    result.dependencyVersion(info),
+			Licenses:   info.Licenses,
+		})
+	}
+	return licenses
+}
+
+// licenseReport lists the licenses of the project and of its dependencies,
+// if resolved.
+func (result *AnalysisResult) licenseReport() string {
+	own := result.Licenses()
+	deps := result.DependencyLicenses()
+	if len(own) == 0 && len(deps) == 0 {
+		return ""
+	}
+	var report strings.Builder
+	report.WriteString("Licenses:\n")
+	report.WriteString("---------\n")
+	if len(own) > 0 {
+		report.WriteString(fmt.Sprintf("  (project): %s\n", licenseNames(own)))
+	}
+	for _, d := range deps {
+		names := licenseNames(d.Licenses)
+		if names == "" {
+			names = "NONE DECLARED"
+		}
+		report.WriteString(fmt.Sprintf("  %s:%s:%s: %s\n", d.GroupID, d.ArtifactID, d.Version, names))
+	}
+	report.WriteString("\n")
+	return report.String()
+}
+
+// licenseNames joins the names of licenses, or their URL if unnamed.
+func licenseNames(licenses []License) string {
+	names := make([]string, 0, len(licenses))
+	for _, l := range licenses {
+		if l.Name != "" {
+			names = append(names, l.Name)
+		} else {
+			names = append(names, l.URL)
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,115 @@
+package pkg
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const licensePOM = `<project>
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0</version>
+  <licenses>
+    <license>
+      <name>Apache License, Version 2.0</name>
+      <url>https://www.apache.org/licenses/LICENSE-2.0.txt</url>
+    </license>
+  </licenses>
+  <properties>
+    <netty.version>4.1.94.Final</netty.version>
+  </properties>
+  <dependencies>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-handler</artifactId>
+      <version>${netty.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>org.json</groupId>
+      <artifactId>json</artifactId>
+      <version>20231013</version>
+    </dependency>
+    <dependency>
+      <groupId>com.example</groupId>
+      <artifactId>unlicensed</artifactId>
+      <version>1.0</version>
+    </dependency>
+    <dependency>
+      <groupId>com.example</groupId>
+      <artifactId>missing</artifactId>
+      <version>1.0</version>
+    </dependency>
+    <dependency>
+      <groupId>com.example</groupId>
+      <artifactId>managed</artifactId>
+    </dependency>
+  </dependencies>
+</project>`
+
+func TestResolveLicenses(t *testing.T) {
+	repo := fakeRepository(t, map[string]string{
+		// netty-handler inherits its license from its parent.
+		"io/netty/netty-handler/4.1.94.Final/netty-handler-4.1.94.Final.pom": `<project>
+  <artifactId>netty-handler</artifactId>
+  <parent>
+    <groupId>io.netty</groupId>
+    <artifactId>netty-parent</artifactId>
+    <version>4.1.94.Final</version>
+  </parent>
+</project>`,
+		"io/netty/netty-parent/4.1.94.Final/netty-parent-4.1.94.Final.pom": `<project>
+  <groupId>io.netty</groupId>
+  <artifactId>netty-parent</artifactId>
+  <version>4.1.94.Final</version>
+  <licenses>
+    <license>
+      <name>Apache License, Version 2.0</name>
+      <url>https://www.apache.org/licenses/LICENSE-2.0</url>
+    </license>
+  </licenses>
+</project>`,
+		"org/json/json/20231013/json-20231013.pom": `<project>
+  <groupId>org.json</groupId>
+  <artifactId>json</artifactId>
+  <version>20231013</version>
+  <licenses>
+    <license>
+      <name>Public Domain</name>
+    </license>
+    <license>
+      <url>https://github.com/stleary/JSON-java/blob/master/LICENSE</url>
+    </license>
+  </licenses>
+</project>`,
+		"com/example/unlicensed/1.0/unlicensed-1.0.pom": `<project>
+  <groupId>com.example</groupId>
+  <artifactId>unlicensed</artifactId>
+  <version>1.0</version>
+</project>`,
+	})
+
+	project, err := StreamPOM(strings.NewReader(licensePOM))
+	require.NoError(t, err)
+	analysis, err := AnalyzeProject(context.Background(), project)
+	require.NoError(t, err)
+	assert.Equal(t, []License{{Name: "Apache License, Version 2.0", URL: "https://www.apache.org/licenses/LICENSE-2.0.txt"}}, analysis.Licenses())
+	assert.Empty(t, analysis.DependencyLicenses())
+
+	analysis.ResolveLicenses(context.Background(), repo)
+	// The POM of missing is not found, and the version of managed is
+	// unknown.
+	assert.Equal(t, []DependencyLicenses{
+		{GroupID: "com.example", ArtifactID: "unlicensed", Version: "1.0", Licenses: []License{}},
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.94.Final", Licenses: []License{{Name: "Apache License, Version 2.0", URL: "https://www.apache.org/licenses/LICENSE-2.0"}}},
+		{GroupID: "org.json", ArtifactID: "json", Version: "20231013", Licenses: []License{{Name: "Public Domain"}, {URL: "https://github.com/stleary/JSON-java/blob/master/LICENSE"}}},
+	}, analysis.DependencyLicenses())
+
+	report := analysis.AnalysisReport()
+	assert.Contains(t, report, "  (project): Apache License, Version 2.0\n")
+	assert.Contains(t, report, "  org.json:json:20231013: Public Domain, https://github.com/stleary/JSON-java/blob/master/LICENSE\n")
+	assert.Contains(t, report, "  com.example:unlicensed:1.0: NONE DECLARED\n")
+}
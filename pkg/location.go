@@ -0,0 +1,217 @@
+package pkg
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/chainguard-dev/gopom"
+)
+
+// SourcePosition is where an element starts in a POM or build script, for
+// editors and CI annotations to point at.
+type SourcePosition struct {
+	// File is the POM as given to LocateDeclarations, relative to the
+	// reactor root for the modules of AnalyzeReactor.
+	File string `json:"file" yaml:"file"`
+	Line int    `json:"line" yaml:"line"`
+	// Column is 1-based, in bytes. It is 0 when unknown, as it is for
+	// Gradle builds.
+	Column int `json:"column,omitempty" yaml:"column,omitempty"`
+}
+
+func (p SourcePosition) String() string {
+	if p.Column == 0 {
+		return fmt.Sprintf("%s:%d", p.File, p.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// declaredDependency is a dependency entry of a POM, with the section it is
+// in, see Declaration, and where it starts.
+type declaredDependency struct {
+	section  string
+	dep      gopom.Dependency
+	position SourcePosition
+}
+
+// pomDeclarations are the dependencies and properties of a POM, with where
+// they are declared.
+type pomDeclarations struct {
+	dependencies []declaredDependency
+	properties   map[string]SourcePosition
+}
+
+// scanDeclarations reads the POM from r and returns where its dependencies,
+// those of dependencyManagement and of its plugins, and the properties of
+// the project are declared, in file. Profiles are left out, as the analysis
+// does.
+func scanDeclarations(r io.Reader, file string) (*pomDeclarations, error) {
+	declarations := &pomDeclarations{properties: map[string]SourcePosition{}}
+	decoder := newPOMDecoder(r)
+	var stack []string
+	// current is the dependency being read, depth that of its element.
+	var current *declaredDependency
+	var depth int
+	var field *string
+	for {
+		line, column := decoder.InputPos()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return declarations, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			position := SourcePosition{File: file, Line: line, Column: column}
+			path := strings.Join(stack, "/")
+			switch {
+			case current != nil:
+				if len(stack) == depth+1 {
+					field = dependencyField(&current.dep, t.Name.Local)
+				}
+			case path == "project/dependencies/dependency":
+				current, depth = &declaredDependency{section: targetDependencies, position: position}, len(stack)
+			case path == "project/dependencyManagement/dependencies/dependency":
+				current, depth = &declaredDependency{section: targetDependencyManagement, position: position}, len(stack)
+			case strings.HasPrefix(path, "project/build/") && strings.HasSuffix(path, "/plugin/dependencies/dependency"):
+				current, depth = &declaredDependency{section: "plugin", position: position}, len(stack)
+			case len(stack) == 3 && strings.HasPrefix(path, "project/properties/"):
+				declarations.properties[t.Name.Local] = position
+			}
+		case xml.CharData:
+			if field != nil {
+				*field += string(t)
+			}
+		case xml.EndElement:
+			field = nil
+			if current != nil && len(stack) == depth {
+				current.dep = trimDependency(current.dep)
+				declarations.dependencies = append(declarations.dependencies, *current)
+				current = nil
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+// dependencyField returns the field of dep the child element name of a
+// dependency sets, nil for those the positions do not need.
+func dependencyField(dep *gopom.Dependency, name string) *string {
+	switch name {
+	case "groupId":
+		return &dep.GroupID
+	case "artifactId":
+		return &dep.ArtifactID
+	case "version":
+		return &dep.Version
+	case "type":
+		return &dep.Type
+	case "classifier":
+		return &dep.Classifier
+	case "scope":
+		return &dep.Scope
+	}
+	return nil
+}
+
+// trimDependency trims the whitespace around the coordinates of dep, as
+// gopom does not but POMs split over lines may have.
+func trimDependency(dep gopom.Dependency) gopom.Dependency {
+	for _, field := range []*string{&dep.GroupID, &dep.ArtifactID, &dep.Version, &dep.Type, &dep.Classifier, &dep.Scope} {
+		*field = strings.TrimSpace(*field)
+	}
+	return dep
+}
+
+// WithPositions makes AnalyzeProjectPath, AnalyzeFiles and AnalyzeReactor
+// locate the declarations of the POMs they analyze, see
+// LocateDeclarations. AnalyzeProject has no file to locate them in.
+func WithPositions() AnalyzeOption {
+	return func(o *analyzeOptions) {
+		o.positions = true
+	}
+}
+
+// LocateDeclarations records where the dependencies, properties and BOM
+// imports of the analyzed POM are declared in the file at path, in their
+// Position and in PropertyPositions. The file must be the one analyzed.
+// Nothing is located in a POM recovered by WithLenientParsing.
+func (result *AnalysisResult) LocateDeclarations(path string) error {
+	return result.locateDeclarations(path, path)
+}
+
+// locateDeclarations is LocateDeclarations, naming the file name in the
+// positions.
+func (result *AnalysisResult) locateDeclarations(path, name string) error {
+	if result.project == nil {
+		return fmt.Errorf("declarations are only located for a single POM")
+	}
+	if len(result.malformed) > 0 {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open POM file: %w", err)
+	}
+	defer file.Close()
+	declarations, err := scanDeclarations(bufio.NewReader(file), name)
+	if err != nil {
+		return err
+	}
+	result.ensureDependencies()
+
+	result.PropertyPositions = declarations.properties
+	props := coordinateProperties(result.project)
+	// The entries in the order the analysis indexes them.
+	rank := map[string]int{targetDependencies: 0, targetDependencyManagement: 1, "plugin": 2}
+	entries := slices.Clone(declarations.dependencies)
+	slices.SortStableFunc(entries, func(a, b declaredDependency) int {
+		return rank[a.section] - rank[b.section]
+	})
+	for i := range entries {
+		entries[i].dep = resolveCoordinates(entries[i].dep, props)
+	}
+	find := func(groupID, artifactID string, match func(gopom.Dependency) bool) *SourcePosition {
+		var found *SourcePosition
+		for i := range entries {
+			dep := entries[i].dep
+			if dep.GroupID != groupID || dep.ArtifactID != artifactID {
+				continue
+			}
+			position := entries[i].position
+			if match(dep) {
+				return &position
+			}
+			if found == nil {
+				found = &position
+			}
+		}
+		return found
+	}
+
+	for _, info := range result.Dependencies {
+		// The entry analyzed is the one with the same version as written,
+		// and the same type and classifier.
+		info.Position = find(info.GroupID, info.ArtifactID, func(dep gopom.Dependency) bool {
+			return dep.Version == info.Version && dependencyKey(dep) == dependencyKey(gopom.Dependency{
+				GroupID: info.GroupID, ArtifactID: info.ArtifactID, Type: info.depType, Classifier: info.classifier,
+			})
+		})
+	}
+	result.BOMs()
+	for i := range result.boms {
+		bom := &result.boms[i]
+		bom.Position = find(bom.GroupID, bom.ArtifactID, func(dep gopom.Dependency) bool {
+			return dep.Scope == "import" && dep.Type == "pom"
+		})
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const locatedPOM = `<project>
+  <modelVersion>4.0.0</modelVersion>
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0</version>
+  <properties>
+    <netty.version>4.1.94.Final</netty.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-codec</artifactId>
+        <version>${netty.version}</version>
+      </dependency>
+      <dependency><groupId>io.netty</groupId><artifactId>netty-bom</artifactId><version>${netty.version}</version><type>pom</type><scope>import</scope></dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>${project.groupId}</groupId>
+      <artifactId>core</artifactId>
+      <version>1.0</version>
+      <exclusions>
+        <exclusion><groupId>org.yaml</groupId><artifactId>snakeyaml</artifactId></exclusion>
+      </exclusions>
+    </dependency>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-codec</artifactId>
+    </dependency>
+  </dependencies>
+  <build>
+    <plugins>
+      <plugin>
+        <artifactId>maven-enforcer-plugin</artifactId>
+        <dependencies>
+          <dependency><groupId>org.codehaus.mojo</groupId><artifactId>extra-enforcer-rules</artifactId><version>1.7.0</version></dependency>
+        </dependencies>
+      </plugin>
+    </plugins>
+  </build>
+</project>
+`
+
+func TestLocateDeclarations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pom.xml")
+	require.NoError(t, os.WriteFile(path, []byte(locatedPOM), 0644))
+
+	result, err := AnalyzeProjectPath(context.Background(), path, WithPositions())
+	require.NoError(t, err)
+
+	assert.Equal(t, &SourcePosition{File: path, Line: 20, Column: 5}, result.Dependencies["com.example:core"].Position)
+	// The version of netty-codec is the managed one.
+	assert.Equal(t, &SourcePosition{File: path, Line: 11, Column: 7}, result.Dependencies["io.netty:netty-codec"].Position)
+	assert.Equal(t, &SourcePosition{File: path, Line: 38, Column: 11}, result.Dependencies["org.codehaus.mojo:extra-enforcer-rules"].Position)
+	assert.Equal(t, map[string]SourcePosition{"netty.version": {File: path, Line: 7, Column: 5}}, result.PropertyPositions)
+	require.Len(t, result.BOMs(), 1)
+	assert.Equal(t, &SourcePosition{File: path, Line: 16, Column: 7}, result.BOMs()[0].Position)
+	assert.Equal(t, path+":16:7", result.BOMs()[0].Position.String())
+
+	result, err = AnalyzeProjectPath(context.Background(), path)
+	require.NoError(t, err)
+	assert.Nil(t, result.Dependencies["com.example:core"].Position)
+	assert.Empty(t, result.PropertyPositions)
+}
+
+func TestLocateDeclarationsReactor(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pom.xml"), []byte(`<project>
+  <groupId>com.example</groupId>
+  <artifactId>parent</artifactId>
+  <version>1.0</version>
+  <modules><module>app</module></modules>
+</project>
+`), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "app"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app", "pom.xml"), []byte(locatedPOM), 0644))
+
+	ctx := context.Background()
+	modules, err := DiscoverModules(ctx, filepath.Join(dir, "pom.xml"))
+	require.NoError(t, err)
+	result, err := AnalyzeReactor(ctx, modules, WithPositions())
+	require.NoError(t, err)
+	assert.Equal(t, &SourcePosition{File: "app/pom.xml", Line: 20, Column: 5}, result.Dependencies["com.example:core"].Position)
+	require.Len(t, result.BOMs(), 1)
+	assert.Equal(t, "app/pom.xml:16:7", result.BOMs()[0].Position.String())
+}
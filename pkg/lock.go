@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// lockPollInterval is how often we retry a lock that is held by someone else.
+const lockPollInterval = 50 * time.Millisecond
+
+// errLockHeld is returned by tryLock when another process holds the lock.
+var errLockHeld = errors.New("lock is held")
+
+// LockFile takes an advisory, exclusive lock for path so that several
+// pombump processes doing read-modify-write on the same file (for example
+// parallel package builds appending to a shared patch file) do not lose each
+// others updates. The lock is taken on a file of its own, see lockFilePath,
+// so that the file itself can be freely rewritten while the lock is held. It
+// blocks until the lock is acquired or the context is done. The returned
+// function releases the lock.
+func LockFile(ctx context.Context, path string) (func(), error) {
+	log := clog.FromContext(ctx)
+	lockPath, err := lockFilePath(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	waiting := false
+	for {
+		err := tryLock(file)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLockHeld) {
+			_ = file.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", lockPath, err)
+		}
+		if !waiting {
+			log.Infof("Waiting for lock on %s", lockPath)
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			_ = file.Close()
+			return nil, fmt.Errorf("waiting for lock on %s: %w", lockPath, ctx.Err())
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	return func() {
+		if err := unlock(file); err != nil {
+			log.Warnf("failed to unlock %s: %v", lockPath, err)
+		}
+		if err := file.Close(); err != nil {
+			log.Warnf("failed to close lock file: %v", err)
+		}
+	}, nil
+}
+
+// lockFilePath returns the file LockFile locks for path: one named after the
+// hash of its absolute path, in a pombump-locks directory of the temporary
+// directory. Lock files are never removed, as a process may be waiting on
+// one, so they are kept out of the working tree.
+func lockFilePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	dir := filepath.Join(os.TempDir(), "pombump-locks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".lock"), nil
+}
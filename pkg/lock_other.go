@@ -0,0 +1,15 @@
+//go:build !unix
+
+package pkg
+
+import "os"
+
+// Advisory locking is only implemented on unix platforms, elsewhere locking
+// is a no-op.
+func tryLock(_ *os.File) error {
+	return nil
+}
+
+func unlock(_ *os.File) error {
+	return nil
+}
@@ -0,0 +1,63 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "pombump-deps.yaml")
+
+	release, err := LockFile(ctx, path)
+	require.NoError(t, err)
+
+	// A second lock must wait for the first one to be released.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 3*lockPollInterval)
+	defer cancel()
+	_, err = LockFile(timeoutCtx, path)
+	require.Error(t, err)
+
+	acquired := make(chan func())
+	go func() {
+		release2, err := LockFile(ctx, path)
+		if err != nil {
+			t.Errorf("LockFile failed: %v", err)
+			close(acquired)
+			return
+		}
+		acquired <- release2
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired while still held")
+	case <-time.After(2 * lockPollInterval):
+	}
+
+	release()
+	select {
+	case release2 := <-acquired:
+		if release2 != nil {
+			release2()
+		}
+	case <-time.After(time.Second):
+		t.Fatal("lock not acquired after release")
+	}
+}
+
+func TestLockFileOutsideTree(t *testing.T) {
+	dir := t.TempDir()
+	release, err := LockFile(context.Background(), filepath.Join(dir, "pom.xml"))
+	require.NoError(t, err)
+	release()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
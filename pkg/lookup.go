@@ -0,0 +1,114 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PropertyLookup is the answer of WhichProperty: the property controlling
+// the version of a dependency and the dependencies it controls.
+type PropertyLookup struct {
+	// Dependency is the groupId:artifactId looked up, if it was one.
+	Dependency string `json:"dependency,omitempty" yaml:"dependency,omitempty"`
+	// Property is the property looked up, or the one holding the version
+	// of Dependency, which bumping it changes. It is empty if no single
+	// property controls the version.
+	Property string `json:"property,omitempty" yaml:"property,omitempty"`
+	// Value is the value of Property, if defined.
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+	// PropertyChain is set when the version of Dependency goes through
+	// other properties to reach Property, e.g. [a.version b.version].
+	PropertyChain []string `json:"propertyChain,omitempty" yaml:"propertyChain,omitempty"`
+	// Properties are those a composite version of Dependency, like
+	// ${major}.${minor}.0, is made of.
+	Properties []string `json:"properties,omitempty" yaml:"properties,omitempty"`
+	// Dependencies are the groupId:artifactId of the dependencies whose
+	// version Property controls, sorted.
+	Dependencies []string `json:"dependencies" yaml:"dependencies"`
+}
+
+// WhichProperty looks query up: for a groupId:artifactId, the property
+// controlling its version and every dependency that property controls too;
+// for a property, the dependencies it controls, see
+// GetAffectedDependencies. It fails for a dependency the project does not
+// declare, or a property it neither defines nor uses.
+func (result *AnalysisResult) WhichProperty(query string) (*PropertyLookup, error) {
+	result.ensureDependencies()
+	if !strings.Contains(query, ":") {
+		lookup := &PropertyLookup{Property: query, Value: result.Properties[query], Dependencies: result.affectedKeys(query)}
+		if _, defined := result.Properties[query]; !defined && len(lookup.Dependencies) == 0 {
+			return nil, fmt.Errorf("property %s is neither defined nor used by a dependency", query)
+		}
+		return lookup, nil
+	}
+
+	dep, ok := result.Dependencies[query]
+	if !ok {
+		return nil, fmt.Errorf("dependency %s is not declared in the POM", query)
+	}
+	lookup := &PropertyLookup{Dependency: query, Dependencies: []string{}}
+	switch {
+	case dep.UsesProperty:
+		lookup.Property = dep.PropertyName
+		if len(dep.PropertyChain) > 0 {
+			lookup.PropertyChain = dep.PropertyChain
+			lookup.Property = dep.PropertyChain[len(dep.PropertyChain)-1]
+		}
+		lookup.Value = result.Properties[lookup.Property]
+		lookup.Dependencies = result.affectedKeys(lookup.Property)
+	case len(dep.ReferencedProperties) > 0:
+		lookup.Properties = dep.ReferencedProperties
+	}
+	return lookup, nil
+}
+
+// affectedKeys returns the sorted groupId:artifactId of the dependencies
+// property controls.
+func (result *AnalysisResult) affectedKeys(property string) []string {
+	keys := []string{}
+	for _, dep := range result.GetAffectedDependencies(property) {
+		keys = append(keys, fmt.Sprintf("%s:%s", dep.GroupID, dep.ArtifactID))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String renders the lookup for humans.
+func (l *PropertyLookup) String() string {
+	var out strings.Builder
+	value := l.Value
+	if value == "" {
+		value = "(not defined)"
+	}
+	if l.Dependency != "" {
+		switch {
+		case l.Property != "":
+			out.WriteString(fmt.Sprintf("%s -> ${%s} = %s", l.Dependency, l.Property, value))
+			if len(l.PropertyChain) > 0 {
+				out.WriteString(fmt.Sprintf(" (through %s)", strings.Join(l.PropertyChain[:len(l.PropertyChain)-1], ", ")))
+			}
+			out.WriteString("\n")
+		case len(l.Properties) > 0:
+			out.WriteString(fmt.Sprintf("%s has a composite version of %s\n", l.Dependency, strings.Join(l.Properties, ", ")))
+		default:
+			out.WriteString(fmt.Sprintf("%s does not use a property\n", l.Dependency))
+		}
+		if others := len(l.Dependencies) - 1; others > 0 {
+			out.WriteString(fmt.Sprintf("Bumping it also changes %d other dependencies:\n", others))
+			for _, key := range l.Dependencies {
+				if key != l.Dependency {
+					out.WriteString(fmt.Sprintf("  %s\n", key))
+				}
+			}
+		}
+		return out.String()
+	}
+
+	out.WriteString(fmt.Sprintf("%s = %s\n", l.Property, value))
+	out.WriteString(fmt.Sprintf("Used by %d dependencies:\n", len(l.Dependencies)))
+	for _, key := range l.Dependencies {
+		out.WriteString(fmt.Sprintf("  %s\n", key))
+	}
+	return out.String()
+}
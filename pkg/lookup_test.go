@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhichProperty(t *testing.T) {
+	result, err := AnalyzeProject(context.Background(), &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{
+			"netty.version":         "4.1.94.Final",
+			"netty-handler.version": "${netty.version}",
+			"unused.version":        "1.0",
+		}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "${netty-handler.version}"},
+			{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "${netty.version}"},
+			{GroupID: "io.netty", ArtifactID: "netty-tcnative", Version: "2.0.61.${netty.version}"},
+			{GroupID: "org.yaml", ArtifactID: "snakeyaml", Version: "2.0"},
+		},
+	})
+	require.NoError(t, err)
+
+	lookup, err := result.WhichProperty("io.netty:netty-handler")
+	require.NoError(t, err)
+	assert.Equal(t, &PropertyLookup{
+		Dependency:    "io.netty:netty-handler",
+		Property:      "netty.version",
+		Value:         "4.1.94.Final",
+		PropertyChain: []string{"netty-handler.version", "netty.version"},
+		Dependencies:  []string{"io.netty:netty-codec", "io.netty:netty-handler", "io.netty:netty-tcnative"},
+	}, lookup)
+	assert.Equal(t, `io.netty:netty-handler -> ${netty.version} = 4.1.94.Final (through netty-handler.version)
+Bumping it also changes 2 other dependencies:
+  io.netty:netty-codec
+  io.netty:netty-tcnative
+`, lookup.String())
+
+	lookup, err = result.WhichProperty("netty.version")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"io.netty:netty-codec", "io.netty:netty-handler", "io.netty:netty-tcnative"}, lookup.Dependencies)
+
+	lookup, err = result.WhichProperty("io.netty:netty-tcnative")
+	require.NoError(t, err)
+	assert.Empty(t, lookup.Property)
+	assert.Equal(t, []string{"netty.version"}, lookup.Properties)
+
+	lookup, err = result.WhichProperty("org.yaml:snakeyaml")
+	require.NoError(t, err)
+	assert.Equal(t, "org.yaml:snakeyaml does not use a property\n", lookup.String())
+
+	lookup, err = result.WhichProperty("unused.version")
+	require.NoError(t, err)
+	assert.Empty(t, lookup.Dependencies)
+
+	_, err = result.WhichProperty("com.example:missing")
+	assert.Error(t, err)
+	_, err = result.WhichProperty("missing.version")
+	assert.Error(t, err)
+}
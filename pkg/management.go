@@ -0,0 +1,116 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Where the version of a dependency declared without one comes from.
+const (
+	ManagedByDependencyManagement = "dependencyManagement"
+	ManagedByParent               = "parent"
+	ManagedByBOM                  = "bom"
+)
+
+// Management is what manages the version of a dependency declared without
+// one: the dependencyManagement of the project itself, its parent, an
+// imported BOM or, when nothing else tells, the effective POM.
+type Management struct {
+	Source string `json:"source" yaml:"source"`
+	// GroupID and ArtifactID are those of the parent or BOM.
+	GroupID    string `json:"groupId,omitempty" yaml:"groupId,omitempty"`
+	ArtifactID string `json:"artifactId,omitempty" yaml:"artifactId,omitempty"`
+	// Version is the managed version of the dependency.
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+func (m *Management) String() string {
+	switch m.Source {
+	case ManagedByDependencyManagement:
+		return fmt.Sprintf("dependencyManagement (%s)", m.Version)
+	case ManagedByParent:
+		return fmt.Sprintf("parent %s:%s (%s)", m.GroupID, m.ArtifactID, m.Version)
+	case ManagedByEffectivePOM:
+		return fmt.Sprintf("effective POM (%s)", m.Version)
+	default:
+		return fmt.Sprintf("BOM %s:%s (%s)", m.GroupID, m.ArtifactID, m.Version)
+	}
+}
+
+// ManagedVersion returns what manages groupID:artifactID outside of the
+// project: its parent or, failing that, the first imported BOM managing it,
+// or the effective POM. Only a parent and BOMs resolved by ResolveBOMs, and
+// an effective POM applied by ApplyEffectivePOM, are considered.
+func (result *AnalysisResult) ManagedVersion(groupID, artifactID string) (*Management, bool) {
+	key := fmt.Sprintf("%s:%s", groupID, artifactID)
+	if version, ok := result.parentManaged[key]; ok {
+		m := *result.parent
+		m.Version = version
+		return &m, true
+	}
+	if bom, version, ok := result.ManagingBOM(groupID, artifactID); ok {
+		return &Management{Source: ManagedByBOM, GroupID: bom.GroupID, ArtifactID: bom.ArtifactID, Version: version}, true
+	}
+	if version, ok := result.effectiveManaged[key]; ok {
+		return &Management{Source: ManagedByEffectivePOM, Version: version}, true
+	}
+	return nil, false
+}
+
+// attributeVersionless sets ManagedBy on every dependency declared without a
+// version. The dependencyManagement of the project wins over the parent,
+// which wins over imported BOMs, as in Maven.
+func (result *AnalysisResult) attributeVersionless() {
+	if result.project == nil {
+		return
+	}
+	for key, declared := range result.versionless {
+		info, exists := result.Dependencies[key]
+		if !exists {
+			continue
+		}
+		info.ManagedBy = nil
+		if dm := result.project.DependencyManagement; dm != nil && dm.Dependencies != nil {
+			patch := Patch{GroupID: declared.GroupID, ArtifactID: declared.ArtifactID, Type: declared.Type, Classifier: declared.Classifier}
+			for _, i := range matchingDependencies(*dm.Dependencies, patch, coordinateProperties(result.project)) {
+				if managed := (*dm.Dependencies)[i]; managed.Version != "" {
+					info.ManagedBy = &Management{Source: ManagedByDependencyManagement, Version: interpolate(managed.Version, result.Properties)}
+					break
+				}
+			}
+		}
+		if info.ManagedBy == nil {
+			info.ManagedBy, _ = result.ManagedVersion(declared.GroupID, declared.ArtifactID)
+		}
+		result.setPurl(info)
+	}
+}
+
+// versionlessReport lists the dependencies declared without a version and
+// what manages them.
+func (result *AnalysisResult) versionlessReport() string {
+	if len(result.versionless) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(result.versionless))
+	for key := range result.versionless {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var report strings.Builder
+	report.WriteString("Dependencies Without a Version:\n")
+	report.WriteString("-------------------------------\n")
+	for _, key := range keys {
+		if info := result.Dependencies[key]; info != nil && info.ManagedBy != nil {
+			report.WriteString(fmt.Sprintf("  %s: managed by %s\n", key, info.ManagedBy))
+		} else if bom, ok := result.BOMForGroup(result.versionless[key].GroupID); ok {
+			report.WriteString(fmt.Sprintf("  %s: probably managed by BOM %s:%s (use --resolve-boms to find out)\n", key, bom.GroupID, bom.ArtifactID))
+		} else {
+			report.WriteString(fmt.Sprintf("  %s: managed elsewhere (use --resolve-boms to find out)\n", key))
+		}
+	}
+	report.WriteString("\n")
+	return report.String()
+}
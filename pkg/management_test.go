@@ -0,0 +1,99 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionlessDependencies(t *testing.T) {
+	repo := fakeRepository(t, map[string]string{
+		"com/example/parent/1.0/parent-1.0.pom": `<project>
+  <groupId>com.example</groupId>
+  <artifactId>parent</artifactId>
+  <version>1.0</version>
+  <properties>
+    <jackson.version>2.15.2</jackson.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>com.fasterxml.jackson.core</groupId>
+        <artifactId>jackson-databind</artifactId>
+        <version>${jackson.version}</version>
+      </dependency>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-handler</artifactId>
+        <version>4.0.0</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`,
+		"io/netty/netty-bom/4.1.94.Final/netty-bom-4.1.94.Final.pom": `<project>
+  <groupId>io.netty</groupId>
+  <artifactId>netty-bom</artifactId>
+  <version>4.1.94.Final</version>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-handler</artifactId>
+        <version>4.1.94.Final</version>
+      </dependency>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-codec</artifactId>
+        <version>4.1.94.Final</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`,
+	})
+
+	project := &gopom.Project{
+		Parent:     &gopom.Parent{GroupID: "com.example", ArtifactID: "parent", Version: "1.0"},
+		Properties: &gopom.Properties{Entries: map[string]string{"slf4j.version": "2.0.7"}},
+		DependencyManagement: &gopom.DependencyManagement{Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.94.Final", Type: "pom", Scope: "import"},
+			{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "${slf4j.version}"},
+		}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "org.slf4j", ArtifactID: "slf4j-api"},
+			{GroupID: "com.fasterxml.jackson.core", ArtifactID: "jackson-databind"},
+			{GroupID: "io.netty", ArtifactID: "netty-handler"},
+			{GroupID: "io.netty", ArtifactID: "netty-codec"},
+			{GroupID: "org.example", ArtifactID: "unknown"},
+			{GroupID: "org.example", ArtifactID: "versioned", Version: "1.0"},
+		},
+	}
+	ctx := context.Background()
+	analysis, err := AnalyzeProject(ctx, project)
+	require.NoError(t, err)
+
+	// The project's own dependencyManagement is known right away.
+	managedBy := func(key string) *Management { return analysis.Dependencies[key].ManagedBy }
+	assert.Equal(t, &Management{Source: ManagedByDependencyManagement, Version: "2.0.7"}, managedBy("org.slf4j:slf4j-api"))
+	assert.Nil(t, managedBy("io.netty:netty-handler"))
+	assert.Contains(t, analysis.AnalysisReport(), "io.netty:netty-handler: probably managed by BOM io.netty:netty-bom")
+	assert.Contains(t, analysis.AnalysisReport(), "org.example:unknown: managed elsewhere")
+
+	analysis.ResolveBOMs(ctx, repo)
+	assert.Equal(t, &Management{Source: ManagedByDependencyManagement, Version: "2.0.7"}, managedBy("org.slf4j:slf4j-api"))
+	assert.Equal(t, &Management{Source: ManagedByParent, GroupID: "com.example", ArtifactID: "parent", Version: "2.15.2"}, managedBy("com.fasterxml.jackson.core:jackson-databind"))
+	// The parent wins over imported BOMs.
+	assert.Equal(t, &Management{Source: ManagedByParent, GroupID: "com.example", ArtifactID: "parent", Version: "4.0.0"}, managedBy("io.netty:netty-handler"))
+	assert.Equal(t, &Management{Source: ManagedByBOM, GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.94.Final"}, managedBy("io.netty:netty-codec"))
+	assert.Nil(t, managedBy("org.example:unknown"))
+	assert.Nil(t, managedBy("org.example:versioned"))
+	assert.Equal(t, "4.1.94.Final", analysis.CurrentVersions()["io.netty:netty-codec"])
+
+	report := analysis.AnalysisReport()
+	assert.Contains(t, report, "io.netty:netty-codec: managed by BOM io.netty:netty-bom (4.1.94.Final)")
+	assert.Contains(t, report, "com.fasterxml.jackson.core:jackson-databind: managed by parent com.example:parent (2.15.2)")
+	assert.Contains(t, report, "org.slf4j:slf4j-api: managed by dependencyManagement (2.0.7)")
+	assert.NotContains(t, report, "org.example:versioned:")
+}
@@ -0,0 +1,120 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+)
+
+// Maven 4 POMs declare model version 4.1.0. Only those get the Maven 4
+// handling below, 4.0.0 POMs are left exactly as they were.
+const (
+	ModelVersion40 = "4.0.0"
+	ModelVersion41 = "4.1.0"
+)
+
+// PackagingBOM is the Maven 4 packaging of a project that is a BOM: only its
+// dependencyManagement is published, in the consumer POM.
+const PackagingBOM = "bom"
+
+// IsMaven4 reports whether project uses the Maven 4 model.
+func IsMaven4(project *gopom.Project) bool {
+	return project != nil && project.ModelVersion == ModelVersion41
+}
+
+// Maven4Model holds the parts of a Maven 4 POM that gopom does not model,
+// so that they are not lost when the POM is written back.
+type Maven4Model struct {
+	// Subprojects replace <modules> in Maven 4.
+	Subprojects []string
+	// ProfileSubprojects are the subprojects of each profile, by profile id.
+	ProfileSubprojects map[string][]string
+}
+
+type maven4POM struct {
+	ModelVersion string   `xml:"modelVersion"`
+	Subprojects  []string `xml:"subprojects>subproject"`
+	Profiles     []struct {
+		ID          string   `xml:"id"`
+		Subprojects []string `xml:"subprojects>subproject"`
+	} `xml:"profiles>profile"`
+}
+
+// ReadMaven4Model reads the Maven 4 additions of the POM at path. It returns
+// nil for POMs that do not use the Maven 4 model.
+func ReadMaven4Model(ctx context.Context, path string) (*Maven4Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading file: %w", err)
+	}
+	var pom maven4POM
+	if err := unmarshalPOM(data, &pom); err != nil {
+		return nil, fmt.Errorf("failed to parse POM file: %w", err)
+	}
+	if pom.ModelVersion != ModelVersion41 {
+		return nil, nil
+	}
+	model := &Maven4Model{Subprojects: pom.Subprojects, ProfileSubprojects: map[string][]string{}}
+	for _, profile := range pom.Profiles {
+		if len(profile.Subprojects) > 0 {
+			model.ProfileSubprojects[profile.ID] = profile.Subprojects
+		}
+	}
+	clog.FromContext(ctx).Debugf("%s uses the Maven 4 model, %d subprojects", path, len(model.Subprojects))
+	return model, nil
+}
+
+// MarshalPOM is project.Marshal, putting back what model holds. model may
+// be nil. Unlike project.Marshal, it leaves project as it is, so it can be
+// marshalled again.
+func MarshalPOM(project *gopom.Project, model *Maven4Model) ([]byte, error) {
+	// Marshal moves the schema location around, work on a copy.
+	c := *project
+	out, err := c.Marshal()
+	if err != nil || model == nil {
+		return out, err
+	}
+	// gopom indents with four spaces per level.
+	if len(model.Subprojects) > 0 {
+		end := bytes.LastIndex(out, []byte("</project>"))
+		if end < 0 {
+			return nil, fmt.Errorf("failed to put back subprojects: no </project>")
+		}
+		block := subprojectsBlock(model.Subprojects, "    ")
+		out = append(out[:end:end], append([]byte(block+"\n"), out[end:]...)...)
+	}
+	for id, subprojects := range model.ProfileSubprojects {
+		marker := []byte(fmt.Sprintf("<id>%s</id>", id))
+		start := bytes.Index(out, []byte("<profiles>"))
+		at := -1
+		if start >= 0 {
+			if i := bytes.Index(out[start:], marker); i >= 0 {
+				at = start + i + len(marker)
+			}
+		}
+		if at < 0 {
+			return nil, fmt.Errorf("failed to put back subprojects: profile %q not found", id)
+		}
+		block := "\n" + subprojectsBlock(subprojects, "            ")
+		out = append(out[:at:at], append([]byte(block), out[at:]...)...)
+	}
+	return out, nil
+}
+
+func subprojectsBlock(subprojects []string, indent string) string {
+	var b strings.Builder
+	b.WriteString(indent + "<subprojects>\n")
+	for _, s := range subprojects {
+		var escaped bytes.Buffer
+		_ = xml.EscapeText(&escaped, []byte(s))
+		b.WriteString(indent + "    <subproject>" + escaped.String() + "</subproject>\n")
+	}
+	b.WriteString(indent + "</subprojects>")
+	return b.String()
+}
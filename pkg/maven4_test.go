@@ -0,0 +1,137 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func maven4Reactor(t *testing.T) string {
+	return writeReactor(t, map[string]string{
+		"pom.xml": `<project xmlns="http://maven.apache.org/POM/4.1.0">
+  <modelVersion>4.1.0</modelVersion>
+  <groupId>org.example</groupId>
+  <artifactId>root</artifactId>
+  <version>1.0</version>
+  <packaging>pom</packaging>
+  <subprojects>
+    <subproject>core</subproject>
+  </subprojects>
+  <profiles>
+    <profile>
+      <id>bom</id>
+      <subprojects>
+        <subproject>bom</subproject>
+      </subprojects>
+    </profile>
+  </profiles>
+</project>`,
+		"core/pom.xml": `<project xmlns="http://maven.apache.org/POM/4.1.0">
+  <modelVersion>4.1.0</modelVersion>
+  <parent>
+    <groupId>org.example</groupId>
+    <artifactId>root</artifactId>
+  </parent>
+  <artifactId>core</artifactId>
+  <dependencies>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-handler</artifactId>
+      <version>4.1.94.Final</version>
+    </dependency>
+  </dependencies>
+</project>`,
+		"bom/pom.xml": `<project xmlns="http://maven.apache.org/POM/4.1.0">
+  <modelVersion>4.1.0</modelVersion>
+  <groupId>org.example</groupId>
+  <artifactId>bom</artifactId>
+  <version>1.0</version>
+  <packaging>bom</packaging>
+</project>`,
+	})
+}
+
+func TestReadMaven4Model(t *testing.T) {
+	rootPOM := maven4Reactor(t)
+	model, err := ReadMaven4Model(context.Background(), rootPOM)
+	require.NoError(t, err)
+	assert.Equal(t, &Maven4Model{
+		Subprojects:        []string{"core"},
+		ProfileSubprojects: map[string][]string{"bom": {"bom"}},
+	}, model)
+
+	// Maven 3 POMs are left alone.
+	model, err = ReadMaven4Model(context.Background(), "testdata/zookeeper.pom.xml")
+	require.NoError(t, err)
+	assert.Nil(t, model)
+}
+
+func TestMarshalPOMKeepsSubprojects(t *testing.T) {
+	ctx := context.Background()
+	rootPOM := maven4Reactor(t)
+	project, err := gopom.Parse(rootPOM)
+	require.NoError(t, err)
+	require.True(t, IsMaven4(project))
+	model, err := ReadMaven4Model(ctx, rootPOM)
+	require.NoError(t, err)
+
+	patched, err := PatchProject(ctx, project, []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final"}}, nil)
+	require.NoError(t, err)
+	out, err := MarshalPOM(patched, model)
+	require.NoError(t, err)
+
+	written := filepath.Join(t.TempDir(), "pom.xml")
+	require.NoError(t, os.WriteFile(written, out, 0644))
+	roundTrip, err := ReadMaven4Model(ctx, written)
+	require.NoError(t, err)
+	assert.Equal(t, model, roundTrip)
+	reparsed, err := gopom.Parse(written)
+	require.NoError(t, err)
+	assert.Equal(t, "4.1.118.Final", (*reparsed.DependencyManagement.Dependencies)[0].Version)
+}
+
+func TestMaven4Reactor(t *testing.T) {
+	ctx := context.Background()
+	modules, err := DiscoverModules(ctx, maven4Reactor(t))
+	require.NoError(t, err)
+	paths := []string{}
+	for _, m := range modules {
+		paths = append(paths, m.Path)
+	}
+	assert.ElementsMatch(t, []string{"pom.xml", "core/pom.xml", "bom/pom.xml"}, paths)
+
+	// The version of the parent of core is inferred, there is nothing to
+	// patch, nor anything to add to the root.
+	results, err := PatchReactor(ctx, modules, []Patch{
+		{GroupID: "org.example", ArtifactID: "root", Version: "2.0"},
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final"},
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "core/pom.xml", results[0].Path)
+	assert.NotNil(t, results[0].Maven4)
+	assert.Empty(t, results[0].Project.Parent.Version)
+}
+
+func TestPatchProjectAddToBOMPackaging(t *testing.T) {
+	project := &gopom.Project{ModelVersion: ModelVersion41, Packaging: PackagingBOM}
+	got, err := PatchProject(context.Background(), project, []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final", Operation: PatchOperationAdd},
+	}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, got.Dependencies)
+	assert.Equal(t, &[]gopom.Dependency{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final"},
+	}, got.DependencyManagement.Dependencies)
+}
+
+func TestFindParentPatchInferredVersion(t *testing.T) {
+	project := &gopom.Project{Parent: &gopom.Parent{GroupID: "org.example", ArtifactID: "root"}}
+	_, found := FindParentPatch(project, []Patch{{GroupID: "org.example", ArtifactID: "root", Version: "2.0"}})
+	assert.False(t, found)
+}
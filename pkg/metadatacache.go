@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultCacheTTL is how long cached metadata and advisories are used before
+// being looked up again.
+const DefaultCacheTTL = 24 * time.Hour
+
+// MetadataCache keeps what is fetched from a Maven repository on disk, keyed
+// by the repository and the path of the file, so that repeated runs do not
+// fetch the same version lists and POMs (parents, BOMs) over and over.
+type MetadataCache struct {
+	Dir string
+	// TTL is how long an entry is used for. Zero keeps entries forever.
+	TTL time.Duration
+}
+
+// NewMetadataCache returns a cache in dir, or in the user cache directory
+// if empty, whose entries expire after ttl.
+func NewMetadataCache(dir string, ttl time.Duration) *MetadataCache {
+	if dir == "" {
+		if userCache, err := os.UserCacheDir(); err == nil {
+			dir = filepath.Join(userCache, "pombump", "metadata")
+		}
+	}
+	return &MetadataCache{Dir: dir, TTL: ttl}
+}
+
+// file returns the file the entry for key is kept in.
+func (c *MetadataCache) file(key string) string {
+	return filepath.Join(c.Dir, fmt.Sprintf("%x", sha256.Sum256([]byte(key))))
+}
+
+// Get returns the entry for key, if cached and not expired. A nil cache
+// caches nothing.
+func (c *MetadataCache) Get(key string) ([]byte, bool) {
+	if c == nil || c.Dir == "" {
+		return nil, false
+	}
+	return readCache(c.file(key), c.TTL)
+}
+
+// Put caches data as the entry for key.
+func (c *MetadataCache) Put(key string, data []byte) error {
+	if c == nil || c.Dir == "" {
+		return nil
+	}
+	return writeCacheFile(c.file(key), data)
+}
+
+// readCache reads the cache file, unless it is older than ttl. A zero ttl
+// never expires.
+func readCache(file string, ttl time.Duration) ([]byte, bool) {
+	info, err := os.Stat(file)
+	if err != nil || (ttl > 0 && time.Since(info.ModTime()) > ttl) {
+		return nil, false
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// writeCacheFile writes data to the cache file, creating its directory.
+func writeCacheFile(file string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(file, data, 0644)
+}
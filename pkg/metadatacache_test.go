@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataCache(t *testing.T) {
+	cache := NewMetadataCache(t.TempDir(), time.Hour)
+	_, ok := cache.Get("https://repo/g/a/maven-metadata.xml")
+	assert.False(t, ok)
+
+	require.NoError(t, cache.Put("https://repo/g/a/maven-metadata.xml", []byte("data")))
+	data, ok := cache.Get("https://repo/g/a/maven-metadata.xml")
+	assert.True(t, ok)
+	assert.Equal(t, "data", string(data))
+	// Keyed by repository.
+	_, ok = cache.Get("https://mirror/g/a/maven-metadata.xml")
+	assert.False(t, ok)
+
+	// Expired entries are not used.
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(cache.file("https://repo/g/a/maven-metadata.xml"), old, old))
+	_, ok = cache.Get("https://repo/g/a/maven-metadata.xml")
+	assert.False(t, ok)
+	// Unless they never expire.
+	cache.TTL = 0
+	_, ok = cache.Get("https://repo/g/a/maven-metadata.xml")
+	assert.True(t, ok)
+
+	var none *MetadataCache
+	assert.NoError(t, none.Put("key", []byte("data")))
+	_, ok = none.Get("key")
+	assert.False(t, ok)
+}
+
+func TestRepositoryMetadataCache(t *testing.T) {
+	requests := 0
+	checksum := "0000"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/g/a/maven-metadata.xml", "/g/b/maven-metadata.xml":
+			_, _ = w.Write([]byte(checksumMetadata))
+		case "/g/b/maven-metadata.xml.sha1":
+			_, _ = w.Write([]byte(checksum))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	repo := NewMavenRepository(server.URL)
+	repo.Cache = NewMetadataCache(t.TempDir(), time.Hour)
+	ctx := context.Background()
+
+	for range 2 {
+		versions, err := repo.Versions(ctx, "g", "a")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1.0"}, versions)
+	}
+	// The metadata and its two checksums, once.
+	assert.Equal(t, 3, requests)
+
+	// What does not match its checksum is not cached, even if used.
+	repo.Insecure = true
+	requests = 0
+	for range 2 {
+		_, err := repo.Versions(ctx, "g", "b")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 6, requests)
+}
@@ -0,0 +1,144 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+)
+
+// VersionMismatch is a dependency that declares its version both in
+// <dependencies> and in <dependencyManagement>, either to different versions
+// or one through a property and the other not (or through a different
+// property). The version in <dependencies> is the one Maven uses, so patching
+// only the managed side is a silent no-op.
+type VersionMismatch struct {
+	// Code is CodeVersionMismatch.
+	Code       string `json:"code" yaml:"code"`
+	GroupID    string `json:"groupId" yaml:"groupId"`
+	ArtifactID string `json:"artifactId" yaml:"artifactId"`
+	// DependencyVersion is the version in <dependencies>, the one that wins.
+	DependencyVersion string `json:"dependencyVersion" yaml:"dependencyVersion"`
+	// ManagedVersion is the version in <dependencyManagement>.
+	ManagedVersion string `json:"managedVersion" yaml:"managedVersion"`
+}
+
+// PatchStrategyOption configures PatchStrategy.
+type PatchStrategyOption func(*patchStrategyOptions)
+
+type patchStrategyOptions struct {
+	syncMismatches bool
+	overrideBOMs   bool
+	strategy       Strategy
+	hooks          []StrategyHook
+	skipped        *[]SkippedPatch
+	optionalPolicy OptionalPolicy
+}
+
+// WithMismatchSync makes PatchStrategy also update the losing side of a
+// VersionMismatch, so that both declarations agree after patching.
+func WithMismatchSync() PatchStrategyOption {
+	return func(o *patchStrategyOptions) {
+		o.syncMismatches = true
+	}
+}
+
+// recordMismatch records a VersionMismatch if declared (from <dependencies>)
+// and managed declare their version differently, or resolve to different
+// versions.
+func (result *AnalysisResult) recordMismatch(declared *DependencyInfo, managed gopom.Dependency) {
+	managedProperty, managedUsesProperty := propertyReference(managed.Version)
+	if declared.UsesProperty == managedUsesProperty && declared.PropertyName == managedProperty &&
+		interpolate(declared.Version, result.Properties) == interpolate(managed.Version, result.Properties) {
+		return
+	}
+	result.VersionMismatches = append(result.VersionMismatches, VersionMismatch{
+		Code:              CodeVersionMismatch,
+		GroupID:           declared.GroupID,
+		ArtifactID:        declared.ArtifactID,
+		DependencyVersion: declared.Version,
+		ManagedVersion:    managed.Version,
+	})
+}
+
+// normalizeMismatches makes sure the patches for dependencies with a
+// VersionMismatch update the winning <dependencies> side, and only that side
+// unless sync is set. A direct patch would otherwise also overwrite a
+// property reference in <dependencyManagement> with a literal version.
+func normalizeMismatches(ctx context.Context, result *AnalysisResult, patches, directPatches []Patch, propertyPatches map[string]string, sync bool) []Patch {
+	log := clog.FromContext(ctx)
+	for _, m := range result.VersionMismatches {
+		var patch *Patch
+		for i := range patches {
+			if patches[i].GroupID == m.GroupID && patches[i].ArtifactID == m.ArtifactID {
+				patch = &patches[i]
+				break
+			}
+		}
+		if patch == nil || patch.Target != "" {
+			continue
+		}
+		log.Warnf("%s:%s is declared as %s in dependencies and %s in dependencyManagement: %s",
+			m.GroupID, m.ArtifactID, m.DependencyVersion, m.ManagedVersion, result.ExplainMismatch(m))
+
+		// The winning side is already handled by the strategy, if it is a
+		// literal make sure the patch stays in <dependencies>.
+		if _, ok := propertyReference(m.DependencyVersion); !ok {
+			for i := range directPatches {
+				if directPatches[i].GroupID == m.GroupID && directPatches[i].ArtifactID == m.ArtifactID {
+					directPatches[i].Target = targetDependencies
+				}
+			}
+		}
+		if !sync {
+			continue
+		}
+		if name, ok := propertyReference(m.ManagedVersion); ok {
+			if _, exists := propertyPatches[name]; !exists {
+				log.Infof("Syncing managed version of %s:%s through property %s", m.GroupID, m.ArtifactID, name)
+				propertyPatches[name] = patch.Version
+			}
+		} else {
+			log.Infof("Syncing managed version of %s:%s", m.GroupID, m.ArtifactID)
+			managed := *patch
+			managed.Target = targetDependencyManagement
+			directPatches = append(directPatches, managed)
+		}
+	}
+	return directPatches
+}
+
+// ExplainMismatch tells which version of m Maven uses, and what the other one
+// still affects.
+func (result *AnalysisResult) ExplainMismatch(m VersionMismatch) string {
+	effective := interpolate(m.DependencyVersion, result.Properties)
+	managed := interpolate(m.ManagedVersion, result.Properties)
+	if effective != managed {
+		return fmt.Sprintf("Maven uses %s from dependencies, %s from dependencyManagement only applies to transitive dependencies and child modules",
+			effective, managed)
+	}
+	if name, ok := propertyReference(m.ManagedVersion); ok {
+		return fmt.Sprintf("both resolve to %s, but Maven uses the version from dependencies, updating only the property %s would change nothing",
+			effective, name)
+	}
+	return fmt.Sprintf("both resolve to %s, but Maven uses the version from dependencies, updating only dependencyManagement would change nothing",
+		effective)
+}
+
+// mismatchReport describes the VersionMismatches of the analysis, or is
+// empty if there are none.
+func (result *AnalysisResult) mismatchReport() string {
+	mismatches, _ := result.suppressions.Mismatches(result.VersionMismatches)
+	if len(mismatches) == 0 {
+		return ""
+	}
+	report := "Version Mismatches (dependencies wins over dependencyManagement):\n"
+	report += "----------------------------------------------------------------\n"
+	for _, m := range mismatches {
+		report += fmt.Sprintf("  %s\n", FormatIssue(m.Code, fmt.Sprintf("%s:%s: %s (dependencies) vs %s (dependencyManagement)",
+			m.GroupID, m.ArtifactID, m.DependencyVersion, m.ManagedVersion)))
+		report += fmt.Sprintf("    %s\n", result.ExplainMismatch(m))
+	}
+	return report + "\n"
+}
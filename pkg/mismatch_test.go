@@ -0,0 +1,181 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionMismatches(t *testing.T) {
+	// netty-handler is declared through a property in dependencies and as a
+	// literal in dependencyManagement, slf4j-api the other way around.
+	result, err := AnalyzeProject(context.Background(), &gopom.Project{
+		Properties: makeProperties("netty.version", "4.1.94.Final", "slf4j.version", "2.0.9"),
+		Dependencies: makeDeps(
+			pomDep("io.netty", "netty-handler", "${netty.version}"),
+			pomDep("org.slf4j", "slf4j-api", "2.0.7"),
+		),
+		DependencyManagement: makeManaged(
+			pomDep("io.netty", "netty-handler", "4.1.90.Final"),
+			pomDep("org.slf4j", "slf4j-api", "${slf4j.version}"),
+		),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []VersionMismatch{
+		{Code: CodeVersionMismatch, GroupID: "io.netty", ArtifactID: "netty-handler", DependencyVersion: "${netty.version}", ManagedVersion: "4.1.90.Final"},
+		{Code: CodeVersionMismatch, GroupID: "org.slf4j", ArtifactID: "slf4j-api", DependencyVersion: "2.0.7", ManagedVersion: "${slf4j.version}"},
+	}, result.VersionMismatches)
+	// The index reflects the winning side.
+	assert.Equal(t, map[string]string{
+		"io.netty:netty-handler": "4.1.94.Final",
+		"org.slf4j:slf4j-api":    "2.0.7",
+	}, result.CurrentVersions())
+	assert.Contains(t, result.AnalysisReport(), "Version Mismatches")
+	assert.Equal(t, "Maven uses 4.1.94.Final from dependencies, 4.1.90.Final from dependencyManagement only applies to transitive dependencies and child modules",
+		result.ExplainMismatch(result.VersionMismatches[0]))
+	assert.Equal(t, "Maven uses 2.0.7 from dependencies, 2.0.9 from dependencyManagement only applies to transitive dependencies and child modules",
+		result.ExplainMismatch(result.VersionMismatches[1]))
+}
+
+func TestPatchStrategyMismatches(t *testing.T) {
+	patches := []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final"},
+		{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.10"},
+	}
+
+	tests := []struct {
+		name           string
+		opts           []PatchStrategyOption
+		wantDirect     []Patch
+		wantProperties map[string]string
+		wantDeps       []string
+		wantManaged    []string
+		wantPropsInPOM map[string]string
+	}{{
+		name:           "winning side only",
+		wantDirect:     []Patch{{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.10", Target: "dependencies"}},
+		wantProperties: map[string]string{"netty.version": "4.1.118.Final"},
+		wantDeps:       []string{"${netty.version}", "2.0.10"},
+		wantManaged:    []string{"4.1.90.Final", "${slf4j.version}"},
+		wantPropsInPOM: map[string]string{"netty.version": "4.1.118.Final", "slf4j.version": "2.0.9"},
+	}, {
+		name: "sync",
+		opts: []PatchStrategyOption{WithMismatchSync()},
+		wantDirect: []Patch{
+			{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.10", Target: "dependencies"},
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final", Target: "dependencyManagement"},
+		},
+		wantProperties: map[string]string{"netty.version": "4.1.118.Final", "slf4j.version": "2.0.10"},
+		wantDeps:       []string{"${netty.version}", "2.0.10"},
+		wantManaged:    []string{"4.1.118.Final", "${slf4j.version}"},
+		wantPropsInPOM: map[string]string{"netty.version": "4.1.118.Final", "slf4j.version": "2.0.10"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			project := &gopom.Project{
+				Properties: makeProperties("netty.version", "4.1.94.Final", "slf4j.version", "2.0.9"),
+				Dependencies: makeDeps(
+					pomDep("io.netty", "netty-handler", "${netty.version}"),
+					pomDep("org.slf4j", "slf4j-api", "2.0.7"),
+				),
+				DependencyManagement: makeManaged(
+					pomDep("io.netty", "netty-handler", "4.1.90.Final"),
+					pomDep("org.slf4j", "slf4j-api", "${slf4j.version}"),
+				),
+			}
+			result, err := AnalyzeProject(ctx, project)
+			require.NoError(t, err)
+
+			directPatches, propertyPatches := PatchStrategy(ctx, result, patches, tt.opts...)
+			assert.Equal(t, tt.wantDirect, directPatches)
+			assert.Equal(t, tt.wantProperties, propertyPatches)
+
+			patched, err := PatchProject(ctx, project, directPatches, propertyPatches)
+			require.NoError(t, err)
+			versions := func(deps []gopom.Dependency) []string {
+				out := []string{}
+				for _, d := range deps {
+					out = append(out, d.Version)
+				}
+				return out
+			}
+			assert.Equal(t, tt.wantDeps, versions(*patched.Dependencies))
+			assert.Equal(t, tt.wantManaged, versions(*patched.DependencyManagement.Dependencies))
+			assert.Equal(t, tt.wantPropsInPOM, patched.Properties.Entries)
+		})
+	}
+}
+
+func TestLiteralVersionMismatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []PatchStrategyOption
+		wantDirect  []Patch
+		wantManaged string
+	}{{
+		name:        "winning side only",
+		wantDirect:  []Patch{{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.10", Target: "dependencies"}},
+		wantManaged: "2.0.9",
+	}, {
+		name: "sync",
+		opts: []PatchStrategyOption{WithMismatchSync()},
+		wantDirect: []Patch{
+			{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.10", Target: "dependencies"},
+			{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.10", Target: "dependencyManagement"},
+		},
+		wantManaged: "2.0.10",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			project := &gopom.Project{
+				Properties: makeProperties("guava.version", "32.0.0-jre"),
+				Dependencies: makeDeps(
+					pomDep("org.slf4j", "slf4j-api", "2.0.7"),
+					pomDep("com.google.guava", "guava", "${guava.version}"),
+					pomDep("org.json", "json", "20231013"),
+				),
+				DependencyManagement: makeManaged(
+					pomDep("org.slf4j", "slf4j-api", "2.0.9"),
+					pomDep("com.google.guava", "guava", "${guava.version}"),
+					pomDep("org.json", "json", "20231013"),
+				),
+			}
+			result, err := AnalyzeProject(ctx, project)
+			require.NoError(t, err)
+			// Declaring the same version twice is not a mismatch.
+			require.Equal(t, []VersionMismatch{
+				{Code: CodeVersionMismatch, GroupID: "org.slf4j", ArtifactID: "slf4j-api", DependencyVersion: "2.0.7", ManagedVersion: "2.0.9"},
+			}, result.VersionMismatches)
+			assert.Equal(t, "Maven uses 2.0.7 from dependencies, 2.0.9 from dependencyManagement only applies to transitive dependencies and child modules",
+				result.ExplainMismatch(result.VersionMismatches[0]))
+			assert.Contains(t, result.AnalysisReport(), "  [POMBUMP-W005] org.slf4j:slf4j-api: 2.0.7 (dependencies) vs 2.0.9 (dependencyManagement)\n    Maven uses 2.0.7")
+
+			directPatches, _ := PatchStrategy(ctx, result, []Patch{{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.10"}}, tt.opts...)
+			assert.Equal(t, tt.wantDirect, directPatches)
+			patched, err := PatchProject(ctx, project, directPatches, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "2.0.10", (*patched.Dependencies)[0].Version)
+			assert.Equal(t, tt.wantManaged, (*patched.DependencyManagement.Dependencies)[0].Version)
+		})
+	}
+}
+
+func TestExplainMismatch(t *testing.T) {
+	result, err := AnalyzeProject(context.Background(), &gopom.Project{
+		Properties:   &gopom.Properties{Entries: map[string]string{"a.version": "1.0"}},
+		Dependencies: &[]gopom.Dependency{{GroupID: "g", ArtifactID: "a", Version: "1.0"}},
+		DependencyManagement: &gopom.DependencyManagement{Dependencies: &[]gopom.Dependency{
+			{GroupID: "g", ArtifactID: "a", Version: "${a.version}"},
+		}},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.VersionMismatches, 1)
+	assert.Equal(t, "both resolve to 1.0, but Maven uses the version from dependencies, updating only the property a.version would change nothing",
+		result.ExplainMismatch(result.VersionMismatches[0]))
+}
@@ -0,0 +1,209 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultNVDURL is the CVE API of the National Vulnerability Database.
+const DefaultNVDURL = "https://services.nvd.nist.gov/rest/json/cves/2.0"
+
+// nvdCacheSuffix ends the name of the files NVDClient caches, so that they
+// can share a directory with the OSV entries of the same advisories.
+const nvdCacheSuffix = ".nvd.json"
+
+// CVEDetails is what the NVD knows of a CVE that helps prioritize its fix.
+type CVEDetails struct {
+	ID string `json:"id" yaml:"id"`
+	// Score is the CVSS base score, and Severity its rating (LOW, MEDIUM,
+	// HIGH or CRITICAL). They are zero if the CVE is not scored yet.
+	Score    float64 `json:"score,omitempty" yaml:"score,omitempty"`
+	Severity string  `json:"severity,omitempty" yaml:"severity,omitempty"`
+	// Vector is the CVSS vector string the score was computed from.
+	Vector string `json:"vector,omitempty" yaml:"vector,omitempty"`
+	// Published is when the CVE was published, as the NVD gives it.
+	Published string `json:"published,omitempty" yaml:"published,omitempty"`
+}
+
+// nvdResponse is the subset of a response of the NVD CVE API that we care
+// about.
+type nvdResponse struct {
+	Vulnerabilities []struct {
+		CVE struct {
+			ID        string `json:"id"`
+			Published string `json:"published"`
+			Metrics   struct {
+				CVSSMetricV31 []nvdMetric `json:"cvssMetricV31"`
+				CVSSMetricV30 []nvdMetric `json:"cvssMetricV30"`
+				CVSSMetricV2  []nvdMetric `json:"cvssMetricV2"`
+			} `json:"metrics"`
+		} `json:"cve"`
+	} `json:"vulnerabilities"`
+}
+
+type nvdMetric struct {
+	Type     string `json:"type"`
+	CVSSData struct {
+		VectorString string  `json:"vectorString"`
+		BaseScore    float64 `json:"baseScore"`
+		BaseSeverity string  `json:"baseSeverity"`
+	} `json:"cvssData"`
+	// BaseSeverity is outside of cvssData in CVSS v2 metrics.
+	BaseSeverity string `json:"baseSeverity"`
+}
+
+// NVDClient looks up CVEs in the National Vulnerability Database. Responses
+// are cached in CacheDir if set.
+type NVDClient struct {
+	BaseURL string
+	// APIKey raises the rate limit of the NVD API, which is low without one.
+	APIKey   string
+	CacheDir string
+	// CacheTTL is how long a cached response is used for. Zero keeps
+	// them forever.
+	CacheTTL time.Duration
+	Client   *http.Client
+}
+
+// NewNVDClient returns a client of the public NVD API, with the API key in
+// $NVD_API_KEY if set, caching responses in cacheDir. If cacheDir is empty,
+// the user cache directory is used.
+func NewNVDClient(cacheDir string) *NVDClient {
+	if cacheDir == "" {
+		if userCache, err := os.UserCacheDir(); err == nil {
+			cacheDir = filepath.Join(userCache, "pombump", "nvd")
+		}
+	}
+	return &NVDClient{
+		BaseURL:  DefaultNVDURL,
+		APIKey:   os.Getenv("NVD_API_KEY"),
+		CacheDir: cacheDir,
+		CacheTTL: DefaultCacheTTL,
+		Client:   NewHTTPClient(nil),
+	}
+}
+
+// LookupCVEs returns the details of every CVE the patches fix, sorted by
+// ID. A CVE the NVD fails to return is left out with a warning, as the
+// details only help prioritize.
+func LookupCVEs(ctx context.Context, client *NVDClient, patches []Patch) []CVEDetails {
+	ids := []string{}
+	for _, p := range patches {
+		for _, advisory := range p.Advisories {
+			if strings.HasPrefix(advisory, "CVE-") && !slices.Contains(ids, advisory) {
+				ids = append(ids, advisory)
+			}
+		}
+	}
+	slices.Sort(ids)
+	details := []CVEDetails{}
+	for _, id := range ids {
+		cve, err := client.Lookup(ctx, id)
+		if err != nil {
+			clog.FromContext(ctx).Warnf("Unable to look up %s in the NVD: %v", id, err)
+			continue
+		}
+		details = append(details, *cve)
+	}
+	return details
+}
+
+// Lookup returns the details of the CVE id.
+func (c *NVDClient) Lookup(ctx context.Context, id string) (*CVEDetails, error) {
+	data, err := c.fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var response nvdResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse NVD response: %w", err)
+	}
+	if len(response.Vulnerabilities) == 0 {
+		return nil, fmt.Errorf("%s not found in the NVD", id)
+	}
+	cve := response.Vulnerabilities[0].CVE
+	details := &CVEDetails{ID: id, Published: cve.Published}
+	// The newest CVSS version scored wins, and the NVD's own (primary)
+	// score over those of other sources.
+	for _, metrics := range [][]nvdMetric{cve.Metrics.CVSSMetricV31, cve.Metrics.CVSSMetricV30, cve.Metrics.CVSSMetricV2} {
+		if len(metrics) == 0 {
+			continue
+		}
+		metric := metrics[0]
+		for _, m := range metrics {
+			if m.Type == "Primary" {
+				metric = m
+				break
+			}
+		}
+		details.Score = metric.CVSSData.BaseScore
+		details.Vector = metric.CVSSData.VectorString
+		details.Severity = metric.CVSSData.BaseSeverity
+		if details.Severity == "" {
+			details.Severity = metric.BaseSeverity
+		}
+		break
+	}
+	return details, nil
+}
+
+// fetch gets the NVD response for the CVE id from the cache, or the NVD API.
+func (c *NVDClient) fetch(ctx context.Context, id string) (_ []byte, err error) {
+	log := clog.FromContext(ctx)
+
+	var cacheFile string
+	if c.CacheDir != "" {
+		cacheFile = filepath.Join(c.CacheDir, filepath.Base(id)+nvdCacheSuffix)
+		if data, ok := readCache(cacheFile, c.CacheTTL); ok {
+			log.Debugf("Using cached NVD entry for %s", id)
+			return data, nil
+		}
+	}
+
+	ctx, end := startSpan(ctx, OperationFetch, attribute.String("pombump.advisory", id))
+	defer func() { end(err) }()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"?cveId="+url.QueryEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.APIKey != "" {
+		req.Header.Set("apiKey", c.APIKey)
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the NVD: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Warnf("failed to close response body: %v", err)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("NVD returned %s for %s", resp.Status, id)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read NVD response: %w", err)
+	}
+
+	if cacheFile != "" {
+		if err := os.MkdirAll(c.CacheDir, 0755); err == nil {
+			if err := os.WriteFile(cacheFile, data, 0644); err != nil {
+				log.Warnf("failed to cache NVD entry for %s: %v", id, err)
+			}
+		}
+	}
+	return data, nil
+}
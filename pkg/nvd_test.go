@@ -0,0 +1,146 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nvdCVE is scored by the NVD and by a CNA, and has an older v2 score.
+const nvdCVE = `{
+  "totalResults": 1,
+  "vulnerabilities": [{
+    "cve": {
+      "id": "CVE-2023-34462",
+      "published": "2023-06-22T23:15:09.573",
+      "metrics": {
+        "cvssMetricV31": [
+          {"source": "security-advisories@github.com", "type": "Secondary",
+           "cvssData": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:L", "baseScore": 5.3, "baseSeverity": "MEDIUM"}},
+          {"source": "nvd@nist.gov", "type": "Primary",
+           "cvssData": {"version": "3.1", "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H", "baseScore": 7.5, "baseSeverity": "HIGH"}}
+        ],
+        "cvssMetricV2": [
+          {"source": "nvd@nist.gov", "type": "Primary", "baseSeverity": "MEDIUM",
+           "cvssData": {"version": "2.0", "vectorString": "AV:N/AC:L/Au:N/C:N/I:N/A:P", "baseScore": 5.0}}
+        ]
+      }
+    }
+  }]
+}`
+
+// nvdCVEv2 only has a v2 score, whose severity is outside of cvssData.
+const nvdCVEv2 = `{
+  "vulnerabilities": [{
+    "cve": {
+      "id": "CVE-2014-3488",
+      "published": "2014-11-06T15:55:03.717",
+      "metrics": {
+        "cvssMetricV2": [
+          {"source": "nvd@nist.gov", "type": "Primary", "baseSeverity": "MEDIUM",
+           "cvssData": {"version": "2.0", "vectorString": "AV:N/AC:L/Au:N/C:N/I:N/A:P", "baseScore": 5.0}}
+        ]
+      }
+    }
+  }]
+}`
+
+func nvdServer(t *testing.T, requests map[string]int) *NVDClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("cveId")
+		requests[id]++
+		assert.Equal(t, "secret", r.Header.Get("apiKey"))
+		switch id {
+		case "CVE-2023-34462":
+			_, _ = w.Write([]byte(nvdCVE))
+		case "CVE-2014-3488":
+			_, _ = w.Write([]byte(nvdCVEv2))
+		case "CVE-2000-0001":
+			_, _ = w.Write([]byte(`{"totalResults": 0, "vulnerabilities": []}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewNVDClient(t.TempDir())
+	client.BaseURL = server.URL
+	client.APIKey = "secret"
+	return client
+}
+
+func TestNVDClientLookup(t *testing.T) {
+	ctx := context.Background()
+	requests := map[string]int{}
+	client := nvdServer(t, requests)
+
+	for range 2 {
+		cve, err := client.Lookup(ctx, "CVE-2023-34462")
+		require.NoError(t, err)
+		assert.Equal(t, &CVEDetails{
+			ID:        "CVE-2023-34462",
+			Score:     7.5,
+			Severity:  "HIGH",
+			Vector:    "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H",
+			Published: "2023-06-22T23:15:09.573",
+		}, cve)
+	}
+	// The second lookup comes from the cache.
+	assert.Equal(t, 1, requests["CVE-2023-34462"])
+
+	cve, err := client.Lookup(ctx, "CVE-2014-3488")
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, cve.Score)
+	assert.Equal(t, "MEDIUM", cve.Severity)
+	assert.Equal(t, "AV:N/AC:L/Au:N/C:N/I:N/A:P", cve.Vector)
+
+	_, err = client.Lookup(ctx, "CVE-2000-0001")
+	assert.ErrorContains(t, err, "CVE-2000-0001 not found in the NVD")
+}
+
+func TestLookupCVEs(t *testing.T) {
+	requests := map[string]int{}
+	client := nvdServer(t, requests)
+
+	patches := []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.94.Final", Advisories: []string{"CVE-2023-34462", "GHSA-6mjq-h674-j845"}},
+		{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "4.1.94.Final", Advisories: []string{"CVE-2023-34462", "CVE-2099-0001"}},
+		{GroupID: "io.netty", ArtifactID: "netty", Version: "3.9.2.Final", Advisories: []string{"CVE-2014-3488"}},
+	}
+	cves := LookupCVEs(context.Background(), client, patches)
+	ids := []string{}
+	for _, cve := range cves {
+		ids = append(ids, cve.ID)
+	}
+	// GHSA identifiers are not looked up, and CVEs failing to are left out.
+	assert.Equal(t, []string{"CVE-2014-3488", "CVE-2023-34462"}, ids)
+	assert.Equal(t, map[string]int{"CVE-2014-3488": 1, "CVE-2023-34462": 1, "CVE-2099-0001": 1}, requests)
+
+	report := NewCIReport("pom.xml", &AnalysisResult{Dependencies: map[string]*DependencyInfo{}}, patches, nil, nil, nil)
+	report.Advisories = cves
+	assert.Contains(t, report.Markdown(), "| CVE-2023-34462 | 7.5 | HIGH | 2023-06-22T23:15:09.573 |")
+
+	data, err := report.SARIF()
+	require.NoError(t, err)
+	var log struct {
+		Runs []struct {
+			Results []struct {
+				Properties struct {
+					SecuritySeverity string       `json:"security-severity"`
+					CVEs             []CVEDetails `json:"cves"`
+				} `json:"properties"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(data, &log))
+	results := log.Runs[0].Results
+	require.Len(t, results, 3)
+	assert.Equal(t, "7.5", results[0].Properties.SecuritySeverity)
+	assert.Equal(t, []CVEDetails{cves[1]}, results[0].Properties.CVEs)
+	assert.Equal(t, "5.0", results[2].Properties.SecuritySeverity)
+}
@@ -0,0 +1,115 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// OptionalPolicy is how PatchStrategy treats the patches of optional
+// dependencies, those declared <optional>true</optional> which the projects
+// depending on this one do not get.
+type OptionalPolicy string
+
+const (
+	// OptionalPolicyInclude patches optional dependencies like any other.
+	// It is the default.
+	OptionalPolicyInclude OptionalPolicy = "include"
+	// OptionalPolicySkip drops the patches of optional dependencies,
+	// reporting them as skipped (see WithSkippedPatches).
+	OptionalPolicySkip OptionalPolicy = "skip"
+	// OptionalPolicyDeprioritize plans the patches of optional
+	// dependencies after the others, and never bumps for them a property
+	// dependencies that are not optional share: they are patched with a
+	// literal version instead, unless the others bump it far enough.
+	OptionalPolicyDeprioritize OptionalPolicy = "deprioritize"
+)
+
+// OptionalPolicies are the known optional dependency policies.
+var OptionalPolicies = []OptionalPolicy{OptionalPolicyInclude, OptionalPolicySkip, OptionalPolicyDeprioritize}
+
+// ParseOptionalPolicy returns the policy named s, OptionalPolicyInclude if
+// s is empty.
+func ParseOptionalPolicy(s string) (OptionalPolicy, error) {
+	if s == "" {
+		return OptionalPolicyInclude, nil
+	}
+	names := make([]string, 0, len(OptionalPolicies))
+	for _, policy := range OptionalPolicies {
+		if string(policy) == s {
+			return policy, nil
+		}
+		names = append(names, string(policy))
+	}
+	return "", fmt.Errorf("unknown optional dependency policy %q, use one of %s", s, strings.Join(names, ", "))
+}
+
+// WithOptionalPolicy makes PatchStrategy treat the patches of optional
+// dependencies as policy says.
+func WithOptionalPolicy(policy OptionalPolicy) PatchStrategyOption {
+	return func(o *patchStrategyOptions) {
+		o.optionalPolicy = policy
+	}
+}
+
+// isOptional reports whether the dependency groupId:artifactId key is
+// declared optional.
+func (result *AnalysisResult) isOptional(key string) bool {
+	dep, ok := result.Dependencies[key]
+	return ok && dep.Optional
+}
+
+// applyOptionalPolicy drops the patches of optional dependencies, or moves
+// them after the others, as policy says.
+func (result *AnalysisResult) applyOptionalPolicy(ctx context.Context, patches []Patch, policy OptionalPolicy) []Patch {
+	if policy != OptionalPolicySkip && policy != OptionalPolicyDeprioritize {
+		return patches
+	}
+	required := make([]Patch, 0, len(patches))
+	optional := []Patch{}
+	current := result.CurrentVersions()
+	for _, patch := range patches {
+		key := fmt.Sprintf("%s:%s", patch.GroupID, patch.ArtifactID)
+		if !result.isOptional(key) || !changesVersion(patch) {
+			required = append(required, patch)
+			continue
+		}
+		if policy == OptionalPolicySkip {
+			clog.FromContext(ctx).Warnf("Dropping the patch of %s to %s, it is an optional dependency", key, patch.Version)
+			RecordSkipped(ctx, SkippedPatch{Name: key, Current: current[key], Version: patch.Version, Reason: "it is an optional dependency"})
+			continue
+		}
+		optional = append(optional, patch)
+	}
+	return append(required, optional...)
+}
+
+// sharedWithRequired reports whether property is used by a dependency that
+// is not optional.
+func (result *AnalysisResult) sharedWithRequired(property string) bool {
+	return slices.ContainsFunc(result.GetAffectedDependencies(property), func(dep *DependencyInfo) bool {
+		return !dep.Optional
+	})
+}
+
+// optionalReport lists the optional dependencies.
+func (result *AnalysisResult) optionalReport() string {
+	var report strings.Builder
+	for _, key := range sortedKeys(result.Dependencies) {
+		if !result.Dependencies[key].Optional {
+			continue
+		}
+		if report.Len() == 0 {
+			report.WriteString("Optional Dependencies:\n")
+			report.WriteString("----------------------\n")
+		}
+		report.WriteString(fmt.Sprintf("  %s\n", key))
+	}
+	if report.Len() > 0 {
+		report.WriteString("\n")
+	}
+	return report.String()
+}
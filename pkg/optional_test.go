@@ -0,0 +1,71 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOptionalPolicy(t *testing.T) {
+	policy, err := ParseOptionalPolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, OptionalPolicyInclude, policy)
+	policy, err = ParseOptionalPolicy("deprioritize")
+	require.NoError(t, err)
+	assert.Equal(t, OptionalPolicyDeprioritize, policy)
+	_, err = ParseOptionalPolicy("ignore")
+	assert.Error(t, err)
+}
+
+func TestOptionalPolicy(t *testing.T) {
+	ctx := context.Background()
+	result, err := AnalyzeProject(ctx, &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{"jackson.version": "2.15.0"}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "com.fasterxml.jackson.core", ArtifactID: "jackson-databind", Version: "${jackson.version}"},
+			{GroupID: "com.fasterxml.jackson.dataformat", ArtifactID: "jackson-dataformat-yaml", Version: "${jackson.version}", Optional: "true"},
+			{GroupID: "org.yaml", ArtifactID: "snakeyaml", Version: "1.33", Optional: "true"},
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, result.Dependencies["org.yaml:snakeyaml"].Optional)
+	assert.False(t, result.Dependencies["com.fasterxml.jackson.core:jackson-databind"].Optional)
+	assert.Contains(t, result.AnalysisReport(), "Optional Dependencies:\n----------------------\n  com.fasterxml.jackson.dataformat:jackson-dataformat-yaml\n  org.yaml:snakeyaml\n")
+
+	patches := []Patch{
+		{GroupID: "org.yaml", ArtifactID: "snakeyaml", Version: "2.0"},
+		{GroupID: "com.fasterxml.jackson.dataformat", ArtifactID: "jackson-dataformat-yaml", Version: "2.15.2"},
+	}
+
+	direct, properties := PatchStrategy(ctx, result, patches)
+	assert.Equal(t, []Patch{{GroupID: "org.yaml", ArtifactID: "snakeyaml", Version: "2.0"}}, direct)
+	assert.Equal(t, map[string]string{"jackson.version": "2.15.2"}, properties)
+
+	var skipped []SkippedPatch
+	direct, properties = PatchStrategy(ctx, result, patches, WithOptionalPolicy(OptionalPolicySkip), WithSkippedPatches(&skipped))
+	assert.Empty(t, direct)
+	assert.Empty(t, properties)
+	assert.Equal(t, []SkippedPatch{
+		{Name: "org.yaml:snakeyaml", Current: "1.33", Version: "2.0", Reason: "it is an optional dependency"},
+		{Name: "com.fasterxml.jackson.dataformat:jackson-dataformat-yaml", Current: "2.15.0", Version: "2.15.2", Reason: "it is an optional dependency"},
+	}, skipped)
+
+	// The shared property is not bumped for the optional dependency alone.
+	direct, properties = PatchStrategy(ctx, result, patches, WithOptionalPolicy(OptionalPolicyDeprioritize))
+	assert.Equal(t, []Patch{
+		{GroupID: "org.yaml", ArtifactID: "snakeyaml", Version: "2.0"},
+		{GroupID: "com.fasterxml.jackson.dataformat", ArtifactID: "jackson-dataformat-yaml", Version: "2.15.2"},
+	}, direct)
+	assert.Empty(t, properties)
+
+	// Unless a required dependency bumps it far enough, even if requested
+	// after the optional one.
+	direct, properties = PatchStrategy(ctx, result, append(patches,
+		Patch{GroupID: "com.fasterxml.jackson.core", ArtifactID: "jackson-databind", Version: "2.15.3"},
+	), WithOptionalPolicy(OptionalPolicyDeprioritize))
+	assert.Equal(t, []Patch{{GroupID: "org.yaml", ArtifactID: "snakeyaml", Version: "2.0"}}, direct)
+	assert.Equal(t, map[string]string{"jackson.version": "2.15.3"}, properties)
+}
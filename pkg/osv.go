@@ -0,0 +1,297 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultOSVURL is the base URL of the OSV API.
+const DefaultOSVURL = "https://api.osv.dev/v1"
+
+// osvVulnerability is the subset of the OSV schema that we care about.
+type osvVulnerability struct {
+	ID       string   `json:"id"`
+	Aliases  []string `json:"aliases"`
+	Related  []string `json:"related"`
+	Affected []struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Ranges []struct {
+			Type   string `json:"type"`
+			Events []struct {
+				Introduced string `json:"introduced,omitempty"`
+				Fixed      string `json:"fixed,omitempty"`
+			} `json:"events"`
+		} `json:"ranges"`
+	} `json:"affected"`
+}
+
+// OSVResolver resolves advisory identifiers (CVE-..., GHSA-...) into fixed
+// versions using the OSV API. Responses are cached in CacheDir if set.
+type OSVResolver struct {
+	BaseURL  string
+	CacheDir string
+	// CacheTTL is how long a cached response is used for. Zero keeps
+	// them forever.
+	CacheTTL time.Duration
+	Client   *http.Client
+}
+
+// NewOSVResolver returns a resolver talking to the public OSV API, caching
+// responses in cacheDir. If cacheDir is empty, the user cache directory is
+// used.
+func NewOSVResolver(cacheDir string) *OSVResolver {
+	if cacheDir == "" {
+		if userCache, err := os.UserCacheDir(); err == nil {
+			cacheDir = filepath.Join(userCache, "pombump", "osv")
+		}
+	}
+	return &OSVResolver{
+		BaseURL:  DefaultOSVURL,
+		CacheDir: cacheDir,
+		CacheTTL: DefaultCacheTTL,
+		Client:   NewHTTPClient(nil),
+	}
+}
+
+// IsAdvisoryID reports whether version is an advisory identifier rather
+// than an actual version.
+func IsAdvisoryID(version string) bool {
+	return strings.HasPrefix(version, "CVE-") || strings.HasPrefix(version, "GHSA-")
+}
+
+// AdvisoryResolver looks up advisories, see OSVResolver and GHSAResolver.
+type AdvisoryResolver interface {
+	// FixedVersion returns the minimal version of groupID:artifactID fixing
+	// the advisory id, on the release line of current where possible.
+	FixedVersion(ctx context.Context, id, groupID, artifactID, current string) (string, error)
+	// AffectedArtifacts returns the groupId:artifactId of every Maven
+	// artifact the advisory id affects.
+	AffectedArtifacts(ctx context.Context, id string) ([]string, error)
+}
+
+// ResolveAdvisories replaces advisory identifiers in patch versions (e.g.
+// io.netty@netty-handler@CVE-2023-34462) with the minimal version fixing the
+// advisory. current maps groupId:artifactId to the version currently in use,
+// which is used to stay on the same release line where possible.
+//
+// A patch with an advisory but no groupId and artifactId stands for every
+// artifact the advisory affects, and becomes a patch of each the project
+// uses, that is found in current.
+func ResolveAdvisories(ctx context.Context, resolver AdvisoryResolver, patches []Patch, current map[string]string) (_ []Patch, err error) {
+	ctx, end := startSpan(ctx, OperationResolve, attribute.String("pombump.resolver", "advisories"))
+	defer func() { end(err) }()
+	log := clog.FromContext(ctx)
+	if patches, err = expandAdvisories(ctx, resolver, patches, current); err != nil {
+		return nil, err
+	}
+	resolved := make([]Patch, 0, len(patches))
+	for _, p := range patches {
+		if !IsAdvisoryID(p.Version) {
+			resolved = append(resolved, p)
+			continue
+		}
+		key := fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID)
+		version, err := resolver.FixedVersion(ctx, p.Version, p.GroupID, p.ArtifactID, current[key])
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s for %s: %w", p.Version, key, err)
+		}
+		log.Infof("Resolved %s for %s to %s", p.Version, key, version)
+		if !slices.Contains(p.Advisories, p.Version) {
+			p.Advisories = append(slices.Clone(p.Advisories), p.Version)
+		}
+		p.Version = version
+		resolved = append(resolved, p)
+	}
+	return resolved, nil
+}
+
+// expandAdvisories replaces the patches with an advisory but no artifact by
+// a patch of each artifact the advisory affects that current has.
+func expandAdvisories(ctx context.Context, resolver AdvisoryResolver, patches []Patch, current map[string]string) ([]Patch, error) {
+	log := clog.FromContext(ctx)
+	expanded := make([]Patch, 0, len(patches))
+	for _, p := range patches {
+		if p.GroupID != "" || p.ArtifactID != "" || !IsAdvisoryID(p.Version) {
+			expanded = append(expanded, p)
+			continue
+		}
+		artifacts, err := resolver.AffectedArtifacts(ctx, p.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up the artifacts affected by %s: %w", p.Version, err)
+		}
+		used := 0
+		for _, artifact := range artifacts {
+			if _, ok := current[artifact]; !ok {
+				log.Debugf("%s affects %s, which the project does not use", p.Version, artifact)
+				continue
+			}
+			patch := p
+			patch.GroupID, patch.ArtifactID, _ = strings.Cut(artifact, ":")
+			expanded = append(expanded, patch)
+			used++
+		}
+		if used == 0 {
+			log.Warnf("%s affects none of the artifacts the project uses (%s), ignoring it", p.Version, strings.Join(artifacts, ", "))
+		}
+	}
+	return expanded, nil
+}
+
+// FixedVersion returns the minimal version of groupID:artifactID that fixes
+// the advisory id. If the advisory itself has no Maven data (common for CVE
+// records), its aliases are consulted.
+func (r *OSVResolver) FixedVersion(ctx context.Context, id, groupID, artifactID, current string) (string, error) {
+	var fixes []string
+	err := r.withAliases(ctx, id, func(vuln *osvVulnerability) bool {
+		fixes = vuln.fixedVersions(groupID, artifactID)
+		return len(fixes) > 0
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(fixes) == 0 {
+		return "", fmt.Errorf("no fixed version of %s:%s found in %s", groupID, artifactID, id)
+	}
+	return minimalFixVersion(current, fixes), nil
+}
+
+// AffectedArtifacts returns the Maven artifacts the advisory id affects,
+// from its aliases if it has no Maven data itself.
+func (r *OSVResolver) AffectedArtifacts(ctx context.Context, id string) ([]string, error) {
+	var artifacts []string
+	err := r.withAliases(ctx, id, func(vuln *osvVulnerability) bool {
+		artifacts = vuln.mavenPackages()
+		return len(artifacts) > 0
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(artifacts) == 0 {
+		return nil, fmt.Errorf("no Maven artifact found in %s", id)
+	}
+	return artifacts, nil
+}
+
+// withAliases calls found with the advisory id and then, until it returns
+// true, with each of its aliases.
+func (r *OSVResolver) withAliases(ctx context.Context, id string, found func(*osvVulnerability) bool) error {
+	vuln, err := r.fetch(ctx, id)
+	if err != nil {
+		return err
+	}
+	if found(vuln) {
+		return nil
+	}
+	for _, alias := range append(vuln.Aliases, vuln.Related...) {
+		if alias == id {
+			continue
+		}
+		aliasVuln, err := r.fetch(ctx, alias)
+		if err != nil {
+			clog.FromContext(ctx).Debugf("Failed to fetch alias %s of %s: %v", alias, id, err)
+			continue
+		}
+		if found(aliasVuln) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// mavenPackages returns the Maven packages the vulnerability affects, in
+// the order listed.
+func (v *osvVulnerability) mavenPackages() []string {
+	packages := []string{}
+	for _, affected := range v.Affected {
+		if affected.Package.Ecosystem == "Maven" && !slices.Contains(packages, affected.Package.Name) {
+			packages = append(packages, affected.Package.Name)
+		}
+	}
+	return packages
+}
+
+// fixedVersions returns all fixed versions listed for a Maven package.
+func (v *osvVulnerability) fixedVersions(groupID, artifactID string) []string {
+	name := fmt.Sprintf("%s:%s", groupID, artifactID)
+	fixes := []string{}
+	for _, affected := range v.Affected {
+		if affected.Package.Ecosystem != "Maven" || affected.Package.Name != name {
+			continue
+		}
+		for _, r := range affected.Ranges {
+			for _, e := range r.Events {
+				if e.Fixed != "" {
+					fixes = append(fixes, e.Fixed)
+				}
+			}
+		}
+	}
+	return fixes
+}
+
+// fetch gets a vulnerability from the cache, or the OSV API.
+func (r *OSVResolver) fetch(ctx context.Context, id string) (_ *osvVulnerability, err error) {
+	log := clog.FromContext(ctx)
+
+	var cacheFile string
+	if r.CacheDir != "" {
+		cacheFile = filepath.Join(r.CacheDir, filepath.Base(id)+".json")
+		if data, ok := readCache(cacheFile, r.CacheTTL); ok {
+			var vuln osvVulnerability
+			if err := json.Unmarshal(data, &vuln); err == nil {
+				log.Debugf("Using cached OSV entry for %s", id)
+				return &vuln, nil
+			}
+		}
+	}
+
+	ctx, end := startSpan(ctx, OperationFetch, attribute.String("pombump.advisory", id))
+	defer func() { end(err) }()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/vulns/%s", r.BaseURL, id), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query OSV: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Warnf("failed to close response body: %v", err)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OSV returned %s for %s", resp.Status, id)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OSV response: %w", err)
+	}
+	var vuln osvVulnerability
+	if err := json.Unmarshal(data, &vuln); err != nil {
+		return nil, fmt.Errorf("failed to parse OSV response: %w", err)
+	}
+
+	if cacheFile != "" {
+		if err := os.MkdirAll(r.CacheDir, 0755); err == nil {
+			if err := os.WriteFile(cacheFile, data, 0644); err != nil {
+				log.Warnf("failed to cache OSV entry for %s: %v", id, err)
+			}
+		}
+	}
+	return &vuln, nil
+}
@@ -0,0 +1,80 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const osvCVE = `{
+  "id": "CVE-2023-34462",
+  "aliases": ["GHSA-6mjq-h674-j845"]
+}`
+
+const osvGHSA = `{
+  "id": "GHSA-6mjq-h674-j845",
+  "aliases": ["CVE-2023-34462"],
+  "affected": [{
+    "package": {"ecosystem": "Maven", "name": "io.netty:netty-handler"},
+    "ranges": [{
+      "type": "ECOSYSTEM",
+      "events": [{"introduced": "0"}, {"fixed": "4.1.94.Final"}]
+    }]
+  }, {
+    "package": {"ecosystem": "Maven", "name": "io.netty:netty-handler"},
+    "ranges": [{
+      "type": "ECOSYSTEM",
+      "events": [{"introduced": "5.0.0.Alpha1"}, {"fixed": "5.0.0.Alpha3"}]
+    }]
+  }]
+}`
+
+func TestResolveAdvisories(t *testing.T) {
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/vulns/")
+		requests[id]++
+		switch id {
+		case "CVE-2023-34462":
+			_, _ = w.Write([]byte(osvCVE))
+		case "GHSA-6mjq-h674-j845":
+			_, _ = w.Write([]byte(osvGHSA))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	resolver := NewOSVResolver(t.TempDir())
+	resolver.BaseURL = server.URL
+
+	patches := []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "CVE-2023-34462"},
+		{GroupID: "org.json", ArtifactID: "json", Version: "20231013"},
+	}
+	current := map[string]string{"io.netty:netty-handler": "4.1.86.Final"}
+
+	got, err := ResolveAdvisories(ctx, resolver, patches, current)
+	require.NoError(t, err)
+	assert.Equal(t, "4.1.94.Final", got[0].Version)
+	assert.Equal(t, []string{"CVE-2023-34462"}, got[0].Advisories)
+	assert.Equal(t, "20231013", got[1].Version)
+	assert.Empty(t, got[1].Advisories)
+
+	// Second time around everything comes from the cache.
+	_, err = ResolveAdvisories(ctx, resolver, patches, current)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"CVE-2023-34462": 1, "GHSA-6mjq-h674-j845": 1}, requests)
+
+	// Unknown advisories and advisories not affecting the artifact fail.
+	_, err = ResolveAdvisories(ctx, resolver, []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "GHSA-xxxx-xxxx-xxxx"}}, nil)
+	assert.Error(t, err)
+	_, err = ResolveAdvisories(ctx, resolver, []Patch{{GroupID: "org.json", ArtifactID: "json", Version: "CVE-2023-34462"}}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,88 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+)
+
+// PatchOperationOverride pins the version of an artifact a BOM manages with
+// an explicit <dependencyManagement> entry ahead of the BOM imports, leaving
+// the BOM and the dependencies alone.
+const PatchOperationOverride = "override"
+
+// WithBOMOverrides makes PatchStrategy turn the patches of artifacts whose
+// version comes from an imported BOM into PatchOperationOverride patches,
+// rather than pinning the version on the dependency itself. BOMs must have
+// been resolved with ResolveBOMs for this to apply.
+func WithBOMOverrides() PatchStrategyOption {
+	return func(o *patchStrategyOptions) {
+		o.overrideBOMs = true
+	}
+}
+
+// managedByBOM reports whether the version patch bumps comes from an
+// imported BOM: the artifact is not declared with a version of its own, and
+// neither the project nor its parent manages it.
+func (result *AnalysisResult) managedByBOM(patch Patch) (*Management, bool) {
+	if dep, exists := result.Dependencies[fmt.Sprintf("%s:%s", patch.GroupID, patch.ArtifactID)]; exists {
+		if dep.ManagedBy != nil && dep.ManagedBy.Source == ManagedByBOM {
+			return dep.ManagedBy, true
+		}
+		return nil, false
+	}
+	if managed, ok := result.ManagedVersion(patch.GroupID, patch.ArtifactID); ok && managed.Source == ManagedByBOM {
+		return managed, true
+	}
+	return nil, false
+}
+
+// overrideManagedVersion applies an override patch: the dependencyManagement
+// entry of the artifact is bumped if there is one, or inserted ahead of the
+// first BOM import otherwise. Maven lets any such entry win over imported
+// BOMs, being ahead of them makes that obvious to readers.
+func overrideManagedVersion(ctx context.Context, project *gopom.Project, patch Patch) {
+	log := clog.FromContext(ctx)
+	if project.DependencyManagement == nil {
+		project.DependencyManagement = &gopom.DependencyManagement{}
+	}
+	if project.DependencyManagement.Dependencies == nil {
+		project.DependencyManagement.Dependencies = &[]gopom.Dependency{}
+	}
+	deps := project.DependencyManagement.Dependencies
+	props := coordinateProperties(project)
+
+	if matches := exactDependencies(*deps, patch, props); len(matches) > 0 {
+		for _, i := range matches {
+			log.Infof("Patching the override of %s.%s from %s to %s", patch.GroupID, patch.ArtifactID, (*deps)[i].Version, patch.Version)
+			patchDependency(&(*deps)[i], patch, props)
+		}
+		return
+	}
+
+	scope := patch.Scope
+	// The default import scope would make a jar a BOM.
+	if scope == defaultScope && !sameType(patch.Type, "pom") {
+		scope = ""
+	}
+	dep := gopom.Dependency{
+		GroupID:    patch.GroupID,
+		ArtifactID: patch.ArtifactID,
+		Version:    patch.Version,
+		Scope:      scope,
+		Type:       patch.Type,
+		Classifier: patch.Classifier,
+	}
+	applyExclusions(&dep, patch.Exclusions)
+	at := slices.IndexFunc(*deps, func(d gopom.Dependency) bool {
+		return d.Scope == "import" && d.Type == "pom"
+	})
+	if at < 0 {
+		at = len(*deps)
+	}
+	log.Infof("Overriding %s.%s:%s ahead of the BOMs", patch.GroupID, patch.ArtifactID, patch.Version)
+	*deps = slices.Insert(*deps, at, dep)
+}
@@ -0,0 +1,62 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchStrategyBOMOverrides(t *testing.T) {
+	repo := fakeRepository(t, map[string]string{
+		"io/netty/netty-bom/4.1.94.Final/netty-bom-4.1.94.Final.pom": `<project>
+  <groupId>io.netty</groupId>
+  <artifactId>netty-bom</artifactId>
+  <version>4.1.94.Final</version>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-handler</artifactId>
+        <version>4.1.94.Final</version>
+      </dependency>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-codec-http2</artifactId>
+        <version>4.1.94.Final</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`,
+	})
+	ctx := context.Background()
+	analysis, err := AnalyzeProject(ctx, &gopom.Project{
+		DependencyManagement: makeManaged(pomDep("org.slf4j", "slf4j-api", "2.0.7"), pomDep("io.netty", "netty-bom", "4.1.94.Final", "import", "pom")),
+		Dependencies:         makeDeps(pomDep("io.netty", "netty-handler", "")),
+	})
+	require.NoError(t, err)
+	analysis.ResolveBOMs(ctx, repo)
+
+	patches := []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Scope: "import", Type: "jar"},
+		{GroupID: "io.netty", ArtifactID: "netty-codec-http2", Version: "4.1.100.Final", Scope: "import", Type: "jar"},
+		{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.10", Scope: "import", Type: "jar"},
+	}
+
+	// Without the option, nothing changes.
+	direct, _ := PatchStrategy(ctx, analysis, patches)
+	assert.Equal(t, patches, direct)
+
+	direct, _ = PatchStrategy(ctx, analysis, patches, WithBOMOverrides())
+	want := []Patch{patches[0], patches[1], patches[2]}
+	want[0].Operation = PatchOperationOverride
+	want[1].Operation = PatchOperationOverride
+	assert.Equal(t, want, direct)
+}
+
+func TestValidateOperationOverride(t *testing.T) {
+	assert.NoError(t, validateOperation(Patch{GroupID: "g", ArtifactID: "a", Version: "1", Operation: PatchOperationOverride}))
+	assert.Error(t, validateOperation(Patch{GroupID: "g", ArtifactID: "a", Operation: PatchOperationOverride}))
+}
@@ -0,0 +1,223 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+)
+
+// maxParentDepth bounds how far up a parent chain we are willing to go.
+const maxParentDepth = 10
+
+// VersionChange describes how a single property or managed dependency
+// differs between two versions of a parent POM. Old or New is empty if the
+// entry was added or removed.
+type VersionChange struct {
+	Name string `json:"name" yaml:"name"`
+	Old  string `json:"old,omitempty" yaml:"old,omitempty"`
+	New  string `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+// ParentDelta is the blast radius of bumping the <parent> of a project:
+// every inherited property and managed dependency version that changes.
+type ParentDelta struct {
+	GroupID             string          `json:"groupId" yaml:"groupId"`
+	ArtifactID          string          `json:"artifactId" yaml:"artifactId"`
+	OldVersion          string          `json:"oldVersion" yaml:"oldVersion"`
+	NewVersion          string          `json:"newVersion" yaml:"newVersion"`
+	Properties          []VersionChange `json:"properties,omitempty" yaml:"properties,omitempty"`
+	ManagedDependencies []VersionChange `json:"managedDependencies,omitempty" yaml:"managedDependencies,omitempty"`
+}
+
+// inheritedModel is what a project inherits from its parent chain.
+type inheritedModel struct {
+	properties map[string]string
+	// managed maps groupId:artifactId to the (interpolated) version
+	managed map[string]string
+}
+
+// FindParentPatch returns the patch that bumps the parent of project, if
+// there is one.
+func FindParentPatch(project *gopom.Project, patches []Patch) (Patch, bool) {
+	// Maven 4 infers a parent version left out from the reactor, it is not
+	// for a patch to pin it.
+	if project == nil || project.Parent == nil || project.Parent.Version == "" {
+		return Patch{}, false
+	}
+	for _, p := range patches {
+		if p.GroupID == project.Parent.GroupID && p.ArtifactID == project.Parent.ArtifactID {
+			return p, true
+		}
+	}
+	return Patch{}, false
+}
+
+// ParentBumpDelta resolves both the current and the new version of the
+// parent of project from repo, and reports every inherited property and
+// managed dependency version that differs between them.
+func ParentBumpDelta(ctx context.Context, repo *MavenRepository, project *gopom.Project, newVersion string) (*ParentDelta, error) {
+	if project == nil || project.Parent == nil {
+		return nil, fmt.Errorf("project has no parent")
+	}
+	parent := project.Parent
+	log := clog.FromContext(ctx)
+	log.Infof("Resolving parent %s:%s %s and %s", parent.GroupID, parent.ArtifactID, parent.Version, newVersion)
+
+	oldModel, err := resolveInherited(ctx, repo, project, parent.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve current parent: %w", err)
+	}
+	newModel, err := resolveInherited(ctx, repo, project, newVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve new parent: %w", err)
+	}
+
+	return &ParentDelta{
+		GroupID:             parent.GroupID,
+		ArtifactID:          parent.ArtifactID,
+		OldVersion:          parent.Version,
+		NewVersion:          newVersion,
+		Properties:          diffVersions(oldModel.properties, newModel.properties),
+		ManagedDependencies: diffVersions(oldModel.managed, newModel.managed),
+	}, nil
+}
+
+// resolveInherited walks up the parent chain of project, using parentVersion
+// as the version of its parent, and merges properties and
+// dependencyManagement, closest POM winning. The project itself is included,
+// so that values it overrides do not show up as changed.
+func resolveInherited(ctx context.Context, repo *MavenRepository, project *gopom.Project, parentVersion string) (*inheritedModel, error) {
+	ancestors, err := fetchParentChain(ctx, repo, project.Parent.GroupID, project.Parent.ArtifactID, parentVersion)
+	if err != nil {
+		return nil, err
+	}
+	chain := append([]*gopom.Project{project}, ancestors...)
+
+	model := &inheritedModel{properties: map[string]string{}, managed: map[string]string{}}
+	// Apply from the top most ancestor down so that children override.
+	for i := len(chain) - 1; i >= 0; i-- {
+		for k, v := range extractPropertiesFromProject(chain[i]) {
+			model.properties[k] = v
+		}
+	}
+	// Interpolation happens on the effective model, so project.* refers to
+	// the project itself. Those are not inherited properties though.
+	props := make(map[string]string, len(model.properties)+2)
+	for k, v := range model.properties {
+		props[k] = v
+	}
+	props["project.version"] = projectVersion(project)
+	props["project.groupId"] = projectGroupID(project)
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		dm := chain[i].DependencyManagement
+		if dm == nil || dm.Dependencies == nil {
+			continue
+		}
+		for _, dep := range *dm.Dependencies {
+			key := fmt.Sprintf("%s:%s", interpolate(dep.GroupID, props), interpolate(dep.ArtifactID, props))
+			model.managed[key] = interpolate(dep.Version, props)
+		}
+	}
+	return model, nil
+}
+
+// fetchParentChain fetches the given parent POM and its ancestors from repo,
+// closest first.
+func fetchParentChain(ctx context.Context, repo *MavenRepository, groupID, artifactID, version string) ([]*gopom.Project, error) {
+	chain := []*gopom.Project{}
+	for depth := 0; ; depth++ {
+		if depth >= repo.maxDepth() {
+			return nil, fmt.Errorf("parent chain of %s:%s deeper than %d", groupID, artifactID, repo.maxDepth())
+		}
+		ancestor, err := repo.FetchPOM(ctx, groupID, artifactID, version)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, ancestor)
+		if ancestor.Parent == nil {
+			return chain, nil
+		}
+		groupID, artifactID, version = ancestor.Parent.GroupID, ancestor.Parent.ArtifactID, ancestor.Parent.Version
+	}
+}
+
+// projectVersion returns the version of a project, falling back to the
+// version of the parent as Maven does.
+func projectVersion(project *gopom.Project) string {
+	if project.Version == "" && project.Parent != nil {
+		return project.Parent.Version
+	}
+	return project.Version
+}
+
+// projectGroupID returns the groupId of a project, falling back to the
+// groupId of the parent as Maven does.
+func projectGroupID(project *gopom.Project) string {
+	if project.GroupID == "" && project.Parent != nil {
+		return project.Parent.GroupID
+	}
+	return project.GroupID
+}
+
+// interpolate replaces ${...} references in value with their property values.
+// Unknown properties are left as is. Properties referring to other properties
+// are resolved up to maxParentDepth levels deep.
+func interpolate(value string, properties map[string]string) string {
+	for i := 0; i < maxParentDepth; i++ {
+		replaced := interpolateOnce(value, properties)
+		if replaced == value {
+			break
+		}
+		value = replaced
+	}
+	return value
+}
+
+// interpolateOnce does a single pass of property replacement.
+func interpolateOnce(value string, properties map[string]string) string {
+	var out strings.Builder
+	rest := value
+	for {
+		start := strings.Index(rest, "${")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			break
+		}
+		out.WriteString(rest[:start])
+		name := rest[start+2 : start+end]
+		if resolved, ok := properties[name]; ok {
+			out.WriteString(resolved)
+		} else {
+			out.WriteString(rest[start : start+end+1])
+		}
+		rest = rest[start+end+1:]
+	}
+	out.WriteString(rest)
+	return out.String()
+}
+
+// diffVersions returns the entries that differ between before and after,
+// sorted by name.
+func diffVersions(before, after map[string]string) []VersionChange {
+	changes := []VersionChange{}
+	for name, oldValue := range before {
+		if newValue := after[name]; newValue != oldValue {
+			changes = append(changes, VersionChange{Name: name, Old: oldValue, New: newValue})
+		}
+	}
+	for name, newValue := range after {
+		if _, exists := before[name]; !exists {
+			changes = append(changes, VersionChange{Name: name, New: newValue})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
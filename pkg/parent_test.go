@@ -0,0 +1,151 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepository serves the given files (path relative to the repository
+// root -> content) as a Maven repository.
+func fakeRepository(t *testing.T, files map[string]string) *MavenRepository {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, exists := files[r.URL.Path[1:]]
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	t.Cleanup(server.Close)
+	return NewMavenRepository(server.URL)
+}
+
+func TestParentBumpDelta(t *testing.T) {
+	repo := fakeRepository(t, map[string]string{
+		"com/example/parent/1.0/parent-1.0.pom": `<project>
+  <groupId>com.example</groupId>
+  <artifactId>parent</artifactId>
+  <version>1.0</version>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>dependencies</artifactId>
+    <version>1.0</version>
+  </parent>
+  <properties>
+    <java.version>17</java.version>
+    <netty.version>4.1.94.Final</netty.version>
+  </properties>
+</project>`,
+		"com/example/dependencies/1.0/dependencies-1.0.pom": `<project>
+  <groupId>com.example</groupId>
+  <artifactId>dependencies</artifactId>
+  <version>1.0</version>
+  <properties>
+    <netty.version>4.1.90.Final</netty.version>
+    <jackson.version>2.15.2</jackson.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-handler</artifactId>
+        <version>${netty.version}</version>
+      </dependency>
+      <dependency>
+        <groupId>com.fasterxml.jackson.core</groupId>
+        <artifactId>jackson-databind</artifactId>
+        <version>${jackson.version}</version>
+      </dependency>
+      <dependency>
+        <groupId>com.example</groupId>
+        <artifactId>example-core</artifactId>
+        <version>${project.version}</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`,
+		"com/example/parent/2.0/parent-2.0.pom": `<project>
+  <groupId>com.example</groupId>
+  <artifactId>parent</artifactId>
+  <version>2.0</version>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>dependencies</artifactId>
+    <version>2.0</version>
+  </parent>
+  <properties>
+    <java.version>17</java.version>
+    <netty.version>4.1.100.Final</netty.version>
+    <slf4j.version>2.0.9</slf4j.version>
+  </properties>
+</project>`,
+		"com/example/dependencies/2.0/dependencies-2.0.pom": `<project>
+  <groupId>com.example</groupId>
+  <artifactId>dependencies</artifactId>
+  <version>2.0</version>
+  <properties>
+    <netty.version>4.1.90.Final</netty.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-handler</artifactId>
+        <version>${netty.version}</version>
+      </dependency>
+      <dependency>
+        <groupId>com.example</groupId>
+        <artifactId>example-core</artifactId>
+        <version>${project.version}</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`,
+	})
+
+	project := &gopom.Project{
+		ArtifactID: "app",
+		Version:    "0.1",
+		Parent:     &gopom.Parent{GroupID: "com.example", ArtifactID: "parent", Version: "1.0"},
+		// Overridden locally, so the parent bump does not change it.
+		Properties: &gopom.Properties{Entries: map[string]string{"slf4j.version": "2.0.7"}},
+	}
+
+	patch, found := FindParentPatch(project, []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "1"}, {GroupID: "com.example", ArtifactID: "parent", Version: "2.0"}})
+	require.True(t, found)
+	assert.Equal(t, "2.0", patch.Version)
+
+	delta, err := ParentBumpDelta(context.Background(), repo, project, patch.Version)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.0", delta.OldVersion)
+	assert.Equal(t, "2.0", delta.NewVersion)
+	assert.Equal(t, []VersionChange{
+		{Name: "jackson.version", Old: "2.15.2"},
+		{Name: "netty.version", Old: "4.1.94.Final", New: "4.1.100.Final"},
+	}, delta.Properties)
+	assert.Equal(t, []VersionChange{
+		{Name: "com.fasterxml.jackson.core:jackson-databind", Old: "2.15.2"},
+		{Name: "io.netty:netty-handler", Old: "4.1.94.Final", New: "4.1.100.Final"},
+	}, delta.ManagedDependencies)
+
+	// Unknown parent version
+	_, err = ParentBumpDelta(context.Background(), repo, project, "3.0")
+	assert.Error(t, err)
+}
+
+func TestPatchProjectBumpsParent(t *testing.T) {
+	project := &gopom.Project{
+		Parent: &gopom.Parent{GroupID: "org.springframework.boot", ArtifactID: "spring-boot-starter-parent", Version: "3.1.0"},
+	}
+	got, err := PatchProject(context.Background(), project, []Patch{{GroupID: "org.springframework.boot", ArtifactID: "spring-boot-starter-parent", Version: "3.1.5"}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "3.1.5", got.Parent.Version)
+	assert.Nil(t, got.DependencyManagement)
+}
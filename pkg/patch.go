@@ -5,11 +5,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/chainguard-dev/clog"
 	"github.com/chainguard-dev/gopom"
-	"github.com/ghodss/yaml"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 /* Example patch for 'proper' dependency:
@@ -20,10 +21,13 @@ import (
 </dependency>
 */
 
+// PatchList is the content of a patch file.
 type PatchList struct {
 	Patches []Patch `json:"patches"`
 }
 
+// Patch is a change to a dependency, an entry of a patch file.
+//
 // Should this just be a gopom.Dependency??
 // Just start with this for now, change to it if need arises.
 // For now, this is easier to read since the upstream is
@@ -34,9 +38,42 @@ type Patch struct {
 	Version    string `json:"version" yaml:"version"`
 	Scope      string `json:"scope,omitempty" yaml:"scope,omitempty"`
 	Type       string `json:"type,omitempty" yaml:"type,omitempty"`
+	Classifier string `json:"classifier,omitempty" yaml:"classifier,omitempty"`
+	// Purl is the package URL of the artifact,
+	// pkg:maven/groupId/artifactId@version. In a patch file it may stand in
+	// for the coordinates, which must agree with it if set too.
+	Purl string `json:"purl,omitempty" yaml:"purl,omitempty"`
+	// Operation is empty to bump the dependency wherever it is found (and
+	// manage it if it is not), PatchOperationAdd, PatchOperationRemove,
+	// PatchOperationOverride, PatchOperationImport or PatchOperationReorder.
+	Operation string `json:"operation,omitempty" yaml:"operation,omitempty"`
+	// Position is where PatchOperationImport imports the BOM, or where
+	// PatchOperationReorder moves it, see PositionFirst.
+	Position string `json:"position,omitempty" yaml:"position,omitempty"`
+	// Target optionally pins where the patch is applied, overriding the
+	// heuristics. See patchTarget for the supported selectors.
+	Target string `json:"target,omitempty" yaml:"target,omitempty"`
+	// Module optionally directs the patch at a single module of a reactor,
+	// by the path of its POM or directory relative to the root POM, e.g.
+	// services/api, or by its artifactId. Only PatchReactor honors it.
+	Module string `json:"module,omitempty" yaml:"module,omitempty"`
+	// Advisories optionally lists the advisories (CVE-..., GHSA-...) the
+	// patch fixes, as recorded when importing a scanner report.
+	Advisories []string `json:"advisories,omitempty" yaml:"advisories,omitempty"`
+	// Exclusions optionally adds (or removes) <exclusions> on the patched
+	// dependency. A patch with exclusions but no version leaves the version
+	// alone.
+	Exclusions []Exclusion `json:"exclusions,omitempty" yaml:"exclusions,omitempty"`
+	// Reason is a free-form note on why the patch is needed. It is carried
+	// along with the patch and logged when the patch is applied.
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	// CVE is shorthand for a single advisory in a patch file, ParsePatches
+	// adds it to Advisories.
+	CVE string `json:"cve,omitempty" yaml:"cve,omitempty"`
 }
 
 
+// PropertyList is the content of a properties file.
 type PropertyList struct {
 	Properties []PropertyPatch `json:"properties" yaml:"properties"`
 }
@@ -53,6 +90,11 @@ type PropertyPatch struct {
 	Value    string `json:"value" yaml:"value"`
 }
 
+// PatchOperationAdd declares the dependency directly in <dependencies> (or
+// the section given by Target) unless that exact dependency is already
+// there, e.g. to force the fixed version of a transitive dependency.
+const PatchOperationAdd = "add"
+
 // Default scope and type for a dependency. Are these even right?
 const (
 	defaultScope = "import"
@@ -61,21 +103,71 @@ const (
 
 // PatchProject will update versions for all matched dependencies
 // if they are found in Project.Dependencies. If there is no
-// match, it will add the dependency to the project.
+// match, it will add the dependency to the project. A patch matching
+// the project's parent bumps the parent version instead.
 // Also does a blind overwrite of any properties with propertyPatches.
 // TODO(vaikas): Figure out when / if to use DependencyManagement instead.
-func PatchProject(ctx context.Context, project *gopom.Project, patches []Patch, propertyPatches map[string]string) (*gopom.Project, error) {
+func PatchProject(ctx context.Context, project *gopom.Project, patches []Patch, propertyPatches map[string]string) (_ *gopom.Project, err error) {
+	ctx, end := startSpan(ctx, OperationApply, attribute.Int("pombump.patches", len(patches)), attribute.Int("pombump.properties", len(propertyPatches)))
+	defer func() { end(err) }()
 	log := clog.FromContext(ctx)
 	if project == nil {
 		return nil, fmt.Errorf("project is nil")
 	}
+	// Patches with an explicit target are applied exactly there, the rest
+	// go through the heuristics below.
+	targeted := []Patch{}
+	untargeted := []Patch{}
+	removals := []Patch{}
+	overrides := []Patch{}
+	imports := []Patch{}
+	reorders := []Patch{}
+	for _, p := range patches {
+		if p.Reason != "" {
+			log.Infof("Patch %s.%s: %s", p.GroupID, p.ArtifactID, p.Reason)
+		}
+		if p.Operation == PatchOperationRemove {
+			removals = append(removals, p)
+			continue
+		}
+		if p.Operation == PatchOperationOverride {
+			overrides = append(overrides, p)
+			continue
+		}
+		if p.Operation == PatchOperationImport {
+			imports = append(imports, p)
+			continue
+		}
+		if p.Operation == PatchOperationReorder {
+			reorders = append(reorders, p)
+			continue
+		}
+		if p.Operation == PatchOperationAdd && p.Target == "" {
+			p.Target = targetDependencies
+			// A Maven 4 bom project only publishes its
+			// dependencyManagement.
+			if project.Packaging == PackagingBOM {
+				p.Target = targetDependencyManagement
+			}
+		}
+		if p.Target != "" {
+			targeted = append(targeted, p)
+		} else {
+			untargeted = append(untargeted, p)
+		}
+	}
+	patches = untargeted
+	props := coordinateProperties(project)
+
 	// If there are no straight up version replacements, but
 	// for some reason a dependency is missing, gather them here
 	// so that we can add them later.
-	missingDeps := make(map[Patch]Patch)
+	// Keyed by the normalized patch, so that patches only differing in an
+	// omitted or explicit jar type are added once.
+	missingDeps := make(map[string]Patch)
 	for _, p := range patches {
 		log.Infof("Have patch: %s.%s:%s", p.GroupID, p.ArtifactID, p.Version)
-		missingDeps[p] = p
+		missingDeps[patchKey(p)] = p
 	}
 
 	// If there are any hard coded dependencies that need to be patched, do
@@ -83,22 +175,28 @@ func PatchProject(ctx context.Context, project *gopom.Project, patches []Patch,
 	// Note that we do not patch scope, or type, since they should already be
 	// configured correctly.
 	if project.Dependencies != nil {
-		for i, dep := range *project.Dependencies {
+		for _, dep := range *project.Dependencies {
 			log.Infof("Checking DEP: %s.%s:%s", dep.GroupID, dep.ArtifactID, dep.Version)
-			for _, patch := range patches {
-				if dep.ArtifactID == patch.ArtifactID &&
-					dep.GroupID == patch.GroupID {
-					log.Infof("Patching %s.%s from %s to %s with scope: %s", patch.GroupID, patch.ArtifactID, dep.Version, patch.Version, patch.Scope)
-					(*project.Dependencies)[i].Version = patch.Version
-
-					// Found it, so remove it from the missing deps
-					// This is dump, make it better.
-					delete(missingDeps, patch)
-				}
+		}
+		for _, patch := range patches {
+			for _, i := range matchingDependencies(*project.Dependencies, patch, props) {
+				dep := (*project.Dependencies)[i]
+				log.Infof("Patching %s.%s from %s to %s with scope: %s", patch.GroupID, patch.ArtifactID, dep.Version, patch.Version, patch.Scope)
+				patchDependency(&(*project.Dependencies)[i], patch, props)
+
+				// Found it, so remove it from the missing deps
+				delete(missingDeps, patchKey(patch))
 			}
 		}
 	}
 
+	// A patch for the parent itself bumps the <parent> version.
+	if parentPatch, found := FindParentPatch(project, patches); found && parentPatch.Version != "" {
+		log.Infof("Patching parent %s.%s from %s to %s", parentPatch.GroupID, parentPatch.ArtifactID, project.Parent.Version, parentPatch.Version)
+		project.Parent.Version = parentPatch.Version
+		delete(missingDeps, patchKey(parentPatch))
+	}
+
 	if project.Dependencies != nil {
 		for _, dep := range *project.Dependencies {
 			log.Debugf("DEP AFTER patching: %s.%s:%s", dep.GroupID, dep.ArtifactID, dep.Version)
@@ -108,21 +206,42 @@ func PatchProject(ctx context.Context, project *gopom.Project, patches []Patch,
 	// Note that we do not patch scope, or type, since they should already be
 	// configured correctly.
 	if project.DependencyManagement != nil && project.DependencyManagement.Dependencies != nil {
-		for i, dep := range *project.DependencyManagement.Dependencies {
+		for _, dep := range *project.DependencyManagement.Dependencies {
 			log.Debugf("Checking DM DEP: %s.%s:%s", dep.GroupID, dep.ArtifactID, dep.Version)
-			for _, patch := range patches {
-				if dep.ArtifactID == patch.ArtifactID &&
-					dep.GroupID == patch.GroupID {
-					log.Infof("Patching DM dep %s.%s from %s to %s with scope: %s", patch.GroupID, patch.ArtifactID, dep.Version, patch.Version, patch.Scope)
-					(*project.DependencyManagement.Dependencies)[i].Version = patch.Version
-					// Found it, so remove it from the missing deps
-					// This is dump, make it better.
-					delete(missingDeps, patch)
-				}
+		}
+		for _, patch := range patches {
+			for _, i := range matchingDependencies(*project.DependencyManagement.Dependencies, patch, props) {
+				dep := (*project.DependencyManagement.Dependencies)[i]
+				log.Infof("Patching DM dep %s.%s from %s to %s with scope: %s", patch.GroupID, patch.ArtifactID, dep.Version, patch.Version, patch.Scope)
+				patchDependency(&(*project.DependencyManagement.Dependencies)[i], patch, props)
+				// Found it, so remove it from the missing deps
+				delete(missingDeps, patchKey(patch))
+			}
+		}
+	}
+
+	// Plugins have their own classpath, so a dependency of a plugin is
+	// patched where it is declared. It is not missing either, managing it
+	// would not change what the plugin uses.
+	for _, plugin := range pluginDependencyLists(project) {
+		for _, patch := range patches {
+			for _, i := range matchingDependencies(*plugin.Dependencies, patch, props) {
+				dep := (*plugin.Dependencies)[i]
+				log.Infof("Patching %s dep %s.%s from %s to %s", plugin.Plugin, patch.GroupID, patch.ArtifactID, dep.Version, patch.Version)
+				patchDependency(&(*plugin.Dependencies)[i], patch, props)
+				delete(missingDeps, patchKey(patch))
 			}
 		}
 	}
 
+	// A patch only changing exclusions has nothing to add.
+	for key, md := range missingDeps {
+		if md.Version == "" {
+			log.Warnf("%s.%s not found, not adding it without a version", md.GroupID, md.ArtifactID)
+			delete(missingDeps, key)
+		}
+	}
+
 	// Initialize DependencyManagement if needed for missing dependencies
 	if len(missingDeps) > 0 {
 		if project.DependencyManagement == nil {
@@ -133,17 +252,43 @@ func PatchProject(ctx context.Context, project *gopom.Project, patches []Patch,
 			project.DependencyManagement.Dependencies = &[]gopom.Dependency{}
 		}
 	}
-	for md := range missingDeps {
+	for _, md := range missingDeps {
 		md := md
 		log.Infof("Adding missing dependency: %s.%s:%s", md.GroupID, md.ArtifactID, md.Version)
 
-		*project.DependencyManagement.Dependencies = append(*project.DependencyManagement.Dependencies, gopom.Dependency{
+		dep := gopom.Dependency{
 			GroupID:    md.GroupID,
 			ArtifactID: md.ArtifactID,
 			Version:    md.Version,
 			Scope:      md.Scope,
 			Type:       md.Type,
-		})
+			Classifier: md.Classifier,
+		}
+		applyExclusions(&dep, md.Exclusions)
+		*project.DependencyManagement.Dependencies = append(*project.DependencyManagement.Dependencies, dep)
+	}
+	for _, p := range targeted {
+		if err := applyTargetedPatch(ctx, project, p); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range removals {
+		if err := removeDependency(ctx, project, p); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range overrides {
+		overrideManagedVersion(ctx, project, p)
+	}
+	for _, p := range imports {
+		if err := importBOM(ctx, project, p); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range reorders {
+		if err := reorderBOM(ctx, project, p); err != nil {
+			return nil, err
+		}
 	}
 	if project.Properties == nil && len(propertyPatches) > 0 {
 		project.Properties = &gopom.Properties{Entries: propertyPatches}
@@ -163,7 +308,6 @@ func PatchProject(ctx context.Context, project *gopom.Project, patches []Patch,
 
 func ParsePatches(ctx context.Context, patchFile, patchFlag string) ([]Patch, error) {
 	if patchFile != "" {
-		var patchList PatchList
 		file, err := os.Open(patchFile)
 		if err != nil {
 			return nil, fmt.Errorf("failed reading file: %w", err)
@@ -175,10 +319,37 @@ func ParsePatches(ctx context.Context, patchFile, patchFlag string) ([]Patch, er
 			}
 		}()
 		byteValue, _ := io.ReadAll(file)
-		if err := yaml.Unmarshal(byteValue, &patchList); err != nil {
+		patchList, err := parsePatchList(patchFile, byteValue)
+		if err != nil {
 			return nil, err
 		}
 		for i := range patchList.Patches {
+			if err := applyPurl(&patchList.Patches[i]); err != nil {
+				return nil, err
+			}
+			if patchList.Patches[i].Target != "" {
+				if _, err := parseTarget(patchList.Patches[i].Target); err != nil {
+					return nil, err
+				}
+			}
+			if err := validateExclusions(patchList.Patches[i]); err != nil {
+				return nil, err
+			}
+			if err := validateOperation(patchList.Patches[i]); err != nil {
+				return nil, err
+			}
+			if patchList.Patches[i].Operation == PatchOperationBump {
+				patchList.Patches[i].Operation = ""
+			}
+			if cve := patchList.Patches[i].CVE; cve != "" {
+				if !slices.Contains(patchList.Patches[i].Advisories, cve) {
+					patchList.Patches[i].Advisories = append(patchList.Patches[i].Advisories, cve)
+				}
+				patchList.Patches[i].CVE = ""
+			}
+			if op := patchList.Patches[i].Operation; op == PatchOperationImport || op == PatchOperationReorder {
+				patchList.Patches[i].Scope, patchList.Patches[i].Type = "import", "pom"
+			}
 			if patchList.Patches[i].Scope == "" {
 				patchList.Patches[i].Scope = defaultScope
 			}
@@ -194,24 +365,89 @@ func ParsePatches(ctx context.Context, patchFile, patchFlag string) ([]Patch, er
 		if dep == "" {
 			continue
 		}
+		if IsPurl(dep) {
+			p, err := ParsePurl(dep)
+			if err != nil {
+				return nil, err
+			}
+			if p.Version == "" {
+				return nil, fmt.Errorf("invalid dependency %s: the package URL has no version", dep)
+			}
+			p.Scope = defaultScope
+			if p.Type == "" {
+				p.Type = defaultType
+			}
+			patches = append(patches, p)
+			continue
+		}
+		// An advisory alone patches every artifact it affects, see
+		// ResolveAdvisories.
+		if IsAdvisoryID(dep) {
+			patches = append(patches, Patch{Version: dep, Scope: defaultScope, Type: defaultType})
+			continue
+		}
 		parts := strings.Split(dep, "@")
-		if len(parts) < 3 {
-			return nil, fmt.Errorf("invalid dependencies format (%s). Each dependency should be in the format <groupID@artifactID@version[@scope]>. Usage: pombump --dependencies=\"<groupID@artifactID@version@scope> <groupID@artifactID@version> ...\"", dep)
+		if len(parts) < 3 || len(parts) > 6 {
+			return nil, fmt.Errorf("invalid dependencies format (%s). Each dependency should be in the format <groupID@artifactID@version[@scope[@type[@classifier]]]>. Usage: pombump --dependencies=\"<groupID@artifactID@version@scope> <groupID@artifactID@version> ...\"", dep)
 		}
 		// Default scope. Maybe make this configurable?
+		// An empty field keeps the default, e.g. g@a@v@@@classifier.
 		scope := defaultScope
-		if len(parts) >= 4 {
+		if len(parts) >= 4 && parts[3] != "" {
 			scope = parts[3]
 		}
 		depType := defaultType
-		if len(parts) >= 5 {
+		if len(parts) >= 5 && parts[4] != "" {
 			depType = parts[4]
 		}
-		patches = append(patches, Patch{GroupID: parts[0], ArtifactID: parts[1], Version: parts[2], Scope: scope, Type: depType})
+		classifier := ""
+		if len(parts) >= 6 {
+			classifier = parts[5]
+		}
+		patches = append(patches, Patch{GroupID: parts[0], ArtifactID: parts[1], Version: parts[2], Scope: scope, Type: depType, Classifier: classifier})
 	}
 	return patches, nil
 }
 
+// validateOperation checks that p has a known operation, and a version if it
+// adds a dependency, overrides its version or imports a BOM.
+func validateOperation(p Patch) error {
+	if p.Position != "" && p.Operation != PatchOperationImport && p.Operation != PatchOperationReorder {
+		return fmt.Errorf("patch %s.%s: a position is only for the %q and %q operations", p.GroupID, p.ArtifactID, PatchOperationImport, PatchOperationReorder)
+	}
+	switch p.Operation {
+	case "", PatchOperationBump:
+		return nil
+	case PatchOperationAdd:
+		if p.Version == "" {
+			return fmt.Errorf("patch %s.%s: a dependency can not be added without a version", p.GroupID, p.ArtifactID)
+		}
+		return nil
+	case PatchOperationOverride:
+		if p.Version == "" {
+			return fmt.Errorf("patch %s.%s: a version can not be overridden without a version", p.GroupID, p.ArtifactID)
+		}
+		return nil
+	case PatchOperationImport:
+		if p.Version == "" {
+			return fmt.Errorf("patch %s.%s: a BOM can not be imported without a version", p.GroupID, p.ArtifactID)
+		}
+		if p.Type != "" && p.Type != "pom" || p.Scope != "" && p.Scope != "import" {
+			return fmt.Errorf("patch %s.%s: a BOM is imported with type pom and scope import", p.GroupID, p.ArtifactID)
+		}
+		return validatePosition(p.Position)
+	case PatchOperationReorder:
+		if p.Type != "" && p.Type != "pom" || p.Scope != "" && p.Scope != "import" {
+			return fmt.Errorf("patch %s.%s: only a BOM, with type pom and scope import, can be reordered", p.GroupID, p.ArtifactID)
+		}
+		return validatePosition(p.Position)
+	case PatchOperationRemove:
+		return nil
+	default:
+		return fmt.Errorf("patch %s.%s: unknown operation %q, use %q, %q, %q, %q, %q, %q or nothing", p.GroupID, p.ArtifactID, p.Operation, PatchOperationBump, PatchOperationAdd, PatchOperationRemove, PatchOperationOverride, PatchOperationImport, PatchOperationReorder)
+	}
+}
+
 func ParseProperties(ctx context.Context, propertyFile, propertiesFlag string) (map[string]string, error) {
 	propertiesPatches := map[string]string{}
 	if propertyFile != "" {
@@ -227,8 +463,8 @@ func ParseProperties(ctx context.Context, propertyFile, propertiesFlag string) (
 			}
 		}()
 		byteValue, _ := io.ReadAll(file)
-		if err := yaml.Unmarshal(byteValue, &propertyList); err != nil {
-			return nil, err
+		if err := unmarshalPatchFile(propertyFile, byteValue, &propertyList); err != nil {
+			return nil, fmt.Errorf("failed to parse the properties file: %w", err)
 		}
 		for _, v := range propertyList.Properties {
 			propertiesPatches[v.Property] = v.Value
@@ -21,6 +21,34 @@ func makeDep(groupID, artifactID, version string, opts ...string) gopom.Dependen
 	return dep
 }
 
+// pomDep is a dependency as declared in a POM, without the scope and type
+// makeDep defaults to.
+func pomDep(groupID, artifactID, version string, opts ...string) gopom.Dependency {
+	return makeDep(groupID, artifactID, version, append(opts, "", "")...)
+}
+
+func makeDeps(deps ...gopom.Dependency) *[]gopom.Dependency {
+	return &deps
+}
+
+func makeManaged(deps ...gopom.Dependency) *gopom.DependencyManagement {
+	return &gopom.DependencyManagement{Dependencies: makeDeps(deps...)}
+}
+
+// makeProperties takes name and value pairs, in order.
+func makeProperties(pairs ...string) *gopom.Properties {
+	props := &gopom.Properties{Entries: map[string]string{}}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		props.Entries[pairs[i]] = pairs[i+1]
+		props.Order = append(props.Order, pairs[i])
+	}
+	return props
+}
+
+func makeBuild(plugins ...gopom.Plugin) *gopom.Build {
+	return &gopom.Build{BuildBase: gopom.BuildBase{Plugins: &plugins}}
+}
+
 func TestSimplePoms(t *testing.T) {
 	testCases := []struct {
 		name    string
@@ -28,26 +56,299 @@ func TestSimplePoms(t *testing.T) {
 		patches []Patch
 		props   map[string]string
 		want    *gopom.Project
+		wantErr bool
 	}{{
 		name:    "simple dependency, bumped inline, type and scope unmodified",
 		in:      &gopom.Project{Dependencies: &[]gopom.Dependency{makeDep("a1", "b1", "1.0.0", "import", "jar")}},
-		patches: []Patch{{"a1", "b1", "1.0.1", "INVALID_SCOPE", "INVALID_TYPE"}},
+		patches: []Patch{{GroupID: "a1", ArtifactID: "b1", Version: "1.0.1", Scope: "INVALID_SCOPE", Type: "INVALID_TYPE"}},
 		want:    &gopom.Project{Dependencies: &[]gopom.Dependency{makeDep("a1", "b1", "1.0.1", "import", "jar")}},
 	}, {
 		name:    "simple dependencymanagement, bumped inline, type and scope unmodified",
 		in:      &gopom.Project{DependencyManagement: &gopom.DependencyManagement{Dependencies: &[]gopom.Dependency{makeDep("a2", "b2", "2.0.0", "compile", "pom")}}},
-		patches: []Patch{{"a2", "b2", "2.0.1", "INVALID_SCOPE", "INVALID_TYPE"}},
+		patches: []Patch{{GroupID: "a2", ArtifactID: "b2", Version: "2.0.1", Scope: "INVALID_SCOPE", Type: "INVALID_TYPE"}},
 		want:    &gopom.Project{DependencyManagement: &gopom.DependencyManagement{Dependencies: &[]gopom.Dependency{makeDep("a2", "b2", "2.0.1", "compile", "pom")}}},
 	}, {
 		name:    "dependencymanagement, added to dependency management",
 		in:      &gopom.Project{DependencyManagement: &gopom.DependencyManagement{Dependencies: &[]gopom.Dependency{makeDep("other", "b3", "2.0.0")}}},
-		patches: []Patch{{"added", "b", "2.0.1", "import", "somethingelse"}},
+		patches: []Patch{{GroupID: "added", ArtifactID: "b", Version: "2.0.1", Scope: "import", Type: "somethingelse"}},
 		want:    &gopom.Project{DependencyManagement: &gopom.DependencyManagement{Dependencies: &[]gopom.Dependency{makeDep("other", "b3", "2.0.0"), makeDep("added", "b", "2.0.1", "import", "somethingelse")}}},
+	}, {
+		name:    "targeted at dependencies",
+		in:      &gopom.Project{Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final")), DependencyManagement: makeManaged(pomDep("io.netty", "netty-handler", "4.1.86.Final"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Scope: "import", Type: "jar", Target: "dependencies"}},
+		want:    &gopom.Project{Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.100.Final")), DependencyManagement: makeManaged(pomDep("io.netty", "netty-handler", "4.1.86.Final"))},
+	}, {
+		name:    "targeted at dependencyManagement",
+		in:      &gopom.Project{Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final")), DependencyManagement: makeManaged(pomDep("io.netty", "netty-handler", "4.1.86.Final"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Scope: "import", Type: "jar", Target: "dependencyManagement"}},
+		want:    &gopom.Project{Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final")), DependencyManagement: makeManaged(pomDep("io.netty", "netty-handler", "4.1.100.Final"))},
+	}, {
+		name: "targeted at profile dependencies",
+		in: &gopom.Project{
+			Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final")),
+			Profiles:     &[]gopom.Profile{{ID: "shade", Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final"))}},
+		},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Scope: "import", Type: "jar", Target: "profile:shade/dependencies"}},
+		want: &gopom.Project{
+			Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final")),
+			Profiles:     &[]gopom.Profile{{ID: "shade", Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.100.Final"))}},
+		},
+	}, {
+		name:    "targeted at profile dependencyManagement, created without the import scope",
+		in:      &gopom.Project{Profiles: &[]gopom.Profile{{ID: "shade", Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final"))}}},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Scope: "import", Type: "jar", Target: "profile:shade/dependencyManagement"}},
+		want: &gopom.Project{Profiles: &[]gopom.Profile{{
+			ID:                   "shade",
+			Dependencies:         makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final")),
+			DependencyManagement: makeManaged(pomDep("io.netty", "netty-handler", "4.1.100.Final", "", "jar")),
+		}}},
+	}, {
+		name:    "targeted BOM import in profile dependencyManagement, created",
+		in:      &gopom.Project{Profiles: &[]gopom.Profile{{ID: "shade", Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final"))}}},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Scope: "import", Type: "pom", Target: "profile:shade/dependencyManagement"}},
+		want: &gopom.Project{Profiles: &[]gopom.Profile{{
+			ID:                   "shade",
+			Dependencies:         makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final")),
+			DependencyManagement: makeManaged(pomDep("io.netty", "netty-handler", "4.1.100.Final", "import", "pom")),
+		}}},
+	}, {
+		name: "targeted at plugin dependencies",
+		in: &gopom.Project{
+			Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final")),
+			Build:        makeBuild(gopom.Plugin{ArtifactID: "maven-compiler-plugin"}, gopom.Plugin{ArtifactID: "maven-shade-plugin", Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final"))}),
+		},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Scope: "import", Type: "jar", Target: "plugin:maven-shade-plugin/dependencies"}},
+		want: &gopom.Project{
+			Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final")),
+			Build:        makeBuild(gopom.Plugin{ArtifactID: "maven-compiler-plugin"}, gopom.Plugin{ArtifactID: "maven-shade-plugin", Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.100.Final"))}),
+		},
+	}, {
+		name: "targeted at plugin dependencies, created",
+		in: &gopom.Project{
+			Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final")),
+			Build:        makeBuild(gopom.Plugin{ArtifactID: "maven-compiler-plugin"}),
+		},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Scope: "import", Type: "jar", Target: "plugin:maven-compiler-plugin/dependencies"}},
+		want: &gopom.Project{
+			Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final")),
+			Build:        makeBuild(gopom.Plugin{ArtifactID: "maven-compiler-plugin", Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.100.Final", "", "jar"))}),
+		},
+	}, {
+		name:    "targeted at a missing profile",
+		in:      &gopom.Project{Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Scope: "import", Type: "jar", Target: "profile:missing/dependencies"}},
+		wantErr: true,
+	}, {
+		name:    "targeted at a missing plugin",
+		in:      &gopom.Project{Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Scope: "import", Type: "jar", Target: "plugin:missing/dependencies"}},
+		wantErr: true,
+	}, {
+		name:    "targeted at an invalid section",
+		in:      &gopom.Project{Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.86.Final"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Scope: "import", Type: "jar", Target: "build/plugins"}},
+		wantErr: true,
+	}, {
+		name: "plugin dependencies, patched in place and not added to dependencyManagement",
+		in: &gopom.Project{
+			Build: &gopom.Build{BuildBase: gopom.BuildBase{
+				Plugins: &[]gopom.Plugin{{ArtifactID: "maven-compiler-plugin", Dependencies: makeDeps(pomDep("org.ow2.asm", "asm", "9.4"))}},
+				PluginManagement: &gopom.PluginManagement{Plugins: &[]gopom.Plugin{{
+					ArtifactID:   "maven-shade-plugin",
+					Dependencies: makeDeps(pomDep("org.ow2.asm", "asm-commons", "9.5")),
+				}}},
+			}},
+			Profiles: &[]gopom.Profile{{
+				ID:    "native",
+				Build: &gopom.BuildBase{Plugins: &[]gopom.Plugin{{ArtifactID: "native-maven-plugin", Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.94.Final"))}}},
+			}},
+		},
+		patches: []Patch{
+			{GroupID: "org.ow2.asm", ArtifactID: "asm", Version: "9.7", Scope: "import", Type: "jar"},
+			{GroupID: "org.ow2.asm", ArtifactID: "asm-commons", Version: "9.7", Scope: "import", Type: "jar"},
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final", Scope: "import", Type: "jar"},
+		},
+		want: &gopom.Project{
+			Build: &gopom.Build{BuildBase: gopom.BuildBase{
+				Plugins: &[]gopom.Plugin{{ArtifactID: "maven-compiler-plugin", Dependencies: makeDeps(pomDep("org.ow2.asm", "asm", "9.7"))}},
+				PluginManagement: &gopom.PluginManagement{Plugins: &[]gopom.Plugin{{
+					ArtifactID:   "maven-shade-plugin",
+					Dependencies: makeDeps(pomDep("org.ow2.asm", "asm-commons", "9.7")),
+				}}},
+			}},
+			Profiles: &[]gopom.Profile{{
+				ID:    "native",
+				Build: &gopom.BuildBase{Plugins: &[]gopom.Plugin{{ArtifactID: "native-maven-plugin", Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.118.Final"))}}},
+			}},
+		},
+	}, {
+		name:    "add a new dependency, without the import scope",
+		in:      &gopom.Project{Properties: makeProperties("netty.version", "4.1.94.Final"), Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "${netty.version}"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-codec-http2", Version: "4.1.100.Final", Scope: "import", Type: "jar", Operation: PatchOperationAdd}},
+		want:    &gopom.Project{Properties: makeProperties("netty.version", "4.1.94.Final"), Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "${netty.version}"), pomDep("io.netty", "netty-codec-http2", "4.1.100.Final", "", "jar"))},
+	}, {
+		name:    "add a classifier of a declared artifact",
+		in:      &gopom.Project{Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "${netty.version}"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Scope: "test", Classifier: "tests", Operation: PatchOperationAdd}},
+		want: &gopom.Project{Dependencies: makeDeps(
+			pomDep("io.netty", "netty-handler", "${netty.version}"),
+			gopom.Dependency{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Scope: "test", Classifier: "tests"},
+		)},
+	}, {
+		name:    "add a test-jar of a declared artifact",
+		in:      &gopom.Project{Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "${netty.version}"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Scope: "test", Type: "test-jar", Operation: PatchOperationAdd}},
+		want:    &gopom.Project{Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "${netty.version}"), pomDep("io.netty", "netty-handler", "4.1.100.Final", "test", "test-jar"))},
+	}, {
+		name:    "add an already declared dependency",
+		in:      &gopom.Project{Properties: makeProperties("netty.version", "4.1.94.Final"), Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "${netty.version}"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Type: "jar", Operation: PatchOperationAdd}},
+		want:    &gopom.Project{Properties: makeProperties("netty.version", "4.1.94.Final"), Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.100.Final"))},
+	}, {
+		name:    "add targeted at dependencyManagement, without the import scope",
+		in:      &gopom.Project{Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "${netty.version}"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-codec-http2", Version: "4.1.100.Final", Scope: "import", Type: "jar", Operation: PatchOperationAdd, Target: "dependencyManagement"}},
+		want: &gopom.Project{
+			Dependencies:         makeDeps(pomDep("io.netty", "netty-handler", "${netty.version}")),
+			DependencyManagement: makeManaged(pomDep("io.netty", "netty-codec-http2", "4.1.100.Final", "", "jar")),
+		},
+	}, {
+		name: "remove everywhere",
+		in: &gopom.Project{
+			Dependencies:         makeDeps(pomDep("commons-collections", "commons-collections", ""), pomDep("io.netty", "netty-handler", "4.1.94.Final")),
+			DependencyManagement: makeManaged(pomDep("commons-collections", "commons-collections", "3.2.1")),
+			Profiles: &[]gopom.Profile{{
+				ID:           "legacy",
+				Dependencies: makeDeps(gopom.Dependency{GroupID: "commons-collections", ArtifactID: "commons-collections", Classifier: "tests"}),
+			}},
+		},
+		patches: []Patch{{GroupID: "commons-collections", ArtifactID: "commons-collections", Operation: PatchOperationRemove}},
+		want: &gopom.Project{
+			Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.94.Final")),
+			Profiles:     &[]gopom.Profile{{ID: "legacy"}},
+		},
+	}, {
+		name: "remove targeted at dependencyManagement",
+		in: &gopom.Project{
+			Dependencies:         makeDeps(pomDep("commons-collections", "commons-collections", "")),
+			DependencyManagement: makeManaged(pomDep("commons-collections", "commons-collections", "3.2.1")),
+		},
+		patches: []Patch{{GroupID: "commons-collections", ArtifactID: "commons-collections", Operation: PatchOperationRemove, Target: "dependencyManagement"}},
+		want:    &gopom.Project{Dependencies: makeDeps(pomDep("commons-collections", "commons-collections", ""))},
+	}, {
+		name: "remove a classifier only",
+		in: &gopom.Project{
+			Dependencies: makeDeps(pomDep("commons-collections", "commons-collections", "")),
+			Profiles: &[]gopom.Profile{{
+				ID:           "legacy",
+				Dependencies: makeDeps(gopom.Dependency{GroupID: "commons-collections", ArtifactID: "commons-collections", Classifier: "tests"}),
+			}},
+		},
+		patches: []Patch{{GroupID: "commons-collections", ArtifactID: "commons-collections", Classifier: "tests", Operation: PatchOperationRemove, Target: "profile:legacy/dependencies"}},
+		want: &gopom.Project{
+			Dependencies: makeDeps(pomDep("commons-collections", "commons-collections", "")),
+			Profiles:     &[]gopom.Profile{{ID: "legacy"}},
+		},
+	}, {
+		name:    "remove what is not declared",
+		in:      &gopom.Project{Dependencies: makeDeps(pomDep("commons-collections", "commons-collections", ""))},
+		patches: []Patch{{GroupID: "org.example", ArtifactID: "gone", Operation: PatchOperationRemove}},
+		want:    &gopom.Project{Dependencies: makeDeps(pomDep("commons-collections", "commons-collections", ""))},
+	}, {
+		name:    "import a BOM, first by default",
+		in:      &gopom.Project{DependencyManagement: makeManaged(pomDep("io.netty", "netty-handler", "4.1.94.Final"), pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.100.Final", Operation: PatchOperationImport}},
+		want:    &gopom.Project{DependencyManagement: makeManaged(pomDep("io.netty", "netty-handler", "4.1.94.Final"), pomDep("io.netty", "netty-bom", "4.1.100.Final", "import", "pom"), pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"))},
+	}, {
+		name:    "import a BOM first",
+		in:      &gopom.Project{DependencyManagement: makeManaged(pomDep("io.netty", "netty-handler", "4.1.94.Final"), pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.100.Final", Operation: PatchOperationImport, Position: PositionFirst}},
+		want:    &gopom.Project{DependencyManagement: makeManaged(pomDep("io.netty", "netty-handler", "4.1.94.Final"), pomDep("io.netty", "netty-bom", "4.1.100.Final", "import", "pom"), pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"))},
+	}, {
+		name:    "import a BOM last",
+		in:      &gopom.Project{DependencyManagement: makeManaged(pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"), pomDep("io.netty", "netty-codec", "4.1.94.Final"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.100.Final", Operation: PatchOperationImport, Position: PositionLast}},
+		want:    &gopom.Project{DependencyManagement: makeManaged(pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"), pomDep("io.netty", "netty-codec", "4.1.94.Final"), pomDep("io.netty", "netty-bom", "4.1.100.Final", "import", "pom"))},
+	}, {
+		name:    "import a BOM before an entry",
+		in:      &gopom.Project{DependencyManagement: makeManaged(pomDep("io.netty", "netty-handler", "4.1.94.Final"), pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.100.Final", Operation: PatchOperationImport, Position: "before:io.netty:netty-handler"}},
+		want:    &gopom.Project{DependencyManagement: makeManaged(pomDep("io.netty", "netty-bom", "4.1.100.Final", "import", "pom"), pomDep("io.netty", "netty-handler", "4.1.94.Final"), pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"))},
+	}, {
+		name:    "import a BOM after an entry",
+		in:      &gopom.Project{DependencyManagement: makeManaged(pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"), pomDep("io.netty", "netty-codec", "4.1.94.Final"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.100.Final", Operation: PatchOperationImport, Position: "after:org.springframework.boot:spring-boot-dependencies"}},
+		want:    &gopom.Project{DependencyManagement: makeManaged(pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"), pomDep("io.netty", "netty-bom", "4.1.100.Final", "import", "pom"), pomDep("io.netty", "netty-codec", "4.1.94.Final"))},
+	}, {
+		name:    "import a BOM after an unknown entry",
+		in:      &gopom.Project{DependencyManagement: makeManaged(pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.100.Final", Operation: PatchOperationImport, Position: "after:com.example:platform"}},
+		wantErr: true,
+	}, {
+		name:    "import a BOM already imported, bumped in place",
+		in:      &gopom.Project{DependencyManagement: makeManaged(pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"), pomDep("io.netty", "netty-codec", "4.1.94.Final"))},
+		patches: []Patch{{GroupID: "org.springframework.boot", ArtifactID: "spring-boot-dependencies", Version: "3.2.5", Operation: PatchOperationImport, Position: PositionLast}},
+		want:    &gopom.Project{DependencyManagement: makeManaged(pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.5", "import", "pom"), pomDep("io.netty", "netty-codec", "4.1.94.Final"))},
+	}, {
+		name:    "reorder a BOM first",
+		in:      &gopom.Project{DependencyManagement: makeManaged(pomDep("org.slf4j", "slf4j-api", "2.0.7"), pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"), pomDep("io.netty", "netty-bom", "4.1.94.Final", "import", "pom"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-bom", Operation: PatchOperationReorder}},
+		want:    &gopom.Project{DependencyManagement: makeManaged(pomDep("org.slf4j", "slf4j-api", "2.0.7"), pomDep("io.netty", "netty-bom", "4.1.94.Final", "import", "pom"), pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"))},
+	}, {
+		name:    "reorder a BOM first and bump it",
+		in:      &gopom.Project{DependencyManagement: makeManaged(pomDep("org.slf4j", "slf4j-api", "2.0.7"), pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"), pomDep("io.netty", "netty-bom", "4.1.94.Final", "import", "pom"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.100.Final", Operation: PatchOperationReorder}},
+		want:    &gopom.Project{DependencyManagement: makeManaged(pomDep("org.slf4j", "slf4j-api", "2.0.7"), pomDep("io.netty", "netty-bom", "4.1.100.Final", "import", "pom"), pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"))},
+	}, {
+		name:    "reorder a BOM last",
+		in:      &gopom.Project{DependencyManagement: makeManaged(pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"), pomDep("io.netty", "netty-bom", "4.1.94.Final", "import", "pom"))},
+		patches: []Patch{{GroupID: "org.springframework.boot", ArtifactID: "spring-boot-dependencies", Operation: PatchOperationReorder, Position: PositionLast}},
+		want:    &gopom.Project{DependencyManagement: makeManaged(pomDep("io.netty", "netty-bom", "4.1.94.Final", "import", "pom"), pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"))},
+	}, {
+		name:    "reorder a BOM after another",
+		in:      &gopom.Project{DependencyManagement: makeManaged(pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"), pomDep("com.fasterxml.jackson", "jackson-bom", "2.15.0", "import", "pom"), pomDep("io.netty", "netty-bom", "4.1.94.Final", "import", "pom"))},
+		patches: []Patch{{GroupID: "org.springframework.boot", ArtifactID: "spring-boot-dependencies", Operation: PatchOperationReorder, Position: "after:com.fasterxml.jackson:jackson-bom"}},
+		want:    &gopom.Project{DependencyManagement: makeManaged(pomDep("com.fasterxml.jackson", "jackson-bom", "2.15.0", "import", "pom"), pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"), pomDep("io.netty", "netty-bom", "4.1.94.Final", "import", "pom"))},
+	}, {
+		name:    "reorder a BOM before another",
+		in:      &gopom.Project{DependencyManagement: makeManaged(pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"), pomDep("com.fasterxml.jackson", "jackson-bom", "2.15.0", "import", "pom"), pomDep("io.netty", "netty-bom", "4.1.94.Final", "import", "pom"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-bom", Operation: PatchOperationReorder, Position: "before:com.fasterxml.jackson:jackson-bom"}},
+		want:    &gopom.Project{DependencyManagement: makeManaged(pomDep("org.springframework.boot", "spring-boot-dependencies", "3.2.0", "import", "pom"), pomDep("io.netty", "netty-bom", "4.1.94.Final", "import", "pom"), pomDep("com.fasterxml.jackson", "jackson-bom", "2.15.0", "import", "pom"))},
+	}, {
+		name:    "reorder a BOM relative to itself",
+		in:      &gopom.Project{DependencyManagement: makeManaged(pomDep("io.netty", "netty-bom", "4.1.94.Final", "import", "pom"))},
+		patches: []Patch{{GroupID: "io.netty", ArtifactID: "netty-bom", Operation: PatchOperationReorder, Position: "after:io.netty:netty-bom"}},
+		wantErr: true,
+	}, {
+		name:    "reorder a BOM not imported",
+		in:      &gopom.Project{DependencyManagement: makeManaged(pomDep("io.netty", "netty-bom", "4.1.94.Final", "import", "pom"))},
+		patches: []Patch{{GroupID: "io.grpc", ArtifactID: "grpc-bom", Operation: PatchOperationReorder}},
+		wantErr: true,
+	}, {
+		name: "override a BOM, ahead of it and without the import scope",
+		in: &gopom.Project{
+			DependencyManagement: makeManaged(pomDep("org.slf4j", "slf4j-api", "2.0.7"), pomDep("io.netty", "netty-bom", "4.1.94.Final", "import", "pom")),
+			Dependencies:         makeDeps(pomDep("io.netty", "netty-handler", "")),
+		},
+		patches: []Patch{
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Scope: "import", Type: "jar", Operation: PatchOperationOverride},
+			{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.10", Scope: "import", Type: "jar", Operation: PatchOperationOverride},
+		},
+		want: &gopom.Project{
+			DependencyManagement: makeManaged(pomDep("org.slf4j", "slf4j-api", "2.0.10"), pomDep("io.netty", "netty-handler", "4.1.100.Final", "", "jar"), pomDep("io.netty", "netty-bom", "4.1.94.Final", "import", "pom")),
+			Dependencies:         makeDeps(pomDep("io.netty", "netty-handler", "")),
+		},
 	}}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			in := tc.in
 			got, err := PatchProject(context.Background(), in, tc.patches, tc.props)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("%s: Patched %+v, wanted an error", tc.name, tc.in)
+				}
+				return
+			}
 			if err != nil {
 				t.Errorf("%s: Failed to patch %+v: %v", tc.name, tc.in, err)
 			}
@@ -131,12 +432,12 @@ func checkDeps(t *testing.T, indeps *[]gopom.Dependency, wantdeps []Patch) {
 	// In addition to version mismatches, make sure we are not missing any
 	// dependencies that should be there. Knock them off of this when we find
 	// them, regardless of whether the version is matched or not.
-	missing := make(map[Patch]Patch, len(wantdeps))
-	for _, p := range wantdeps {
-		missing[p] = p
+	missing := make(map[int]Patch, len(wantdeps))
+	for i, p := range wantdeps {
+		missing[i] = p
 	}
 	for _, dep := range *indeps {
-		for _, patch := range wantdeps {
+		for i, patch := range wantdeps {
 			if dep.ArtifactID == patch.ArtifactID &&
 				dep.GroupID == patch.GroupID {
 				if dep.Version != patch.Version {
@@ -148,7 +449,7 @@ func checkDeps(t *testing.T, indeps *[]gopom.Dependency, wantdeps []Patch) {
 				if dep.Type != patch.Type {
 					t.Errorf("dep %s.%s type %s != %s", patch.GroupID, patch.ArtifactID, dep.Type, patch.Type)
 				}
-				delete(missing, patch)
+				delete(missing, i)
 			}
 		}
 	}
@@ -262,10 +563,77 @@ func TestParsePatches(t *testing.T) {
 			Scope:      "import", // defaulted
 			Type:       "jar",    // defaulted
 		}},
+	}, {
+		name:   "file - annotated",
+		inFile: "testdata/annotated-patches.yaml",
+		want: []Patch{{
+			GroupID:    "io.netty",
+			ArtifactID: "netty-handler",
+			Version:    "4.1.118.Final",
+			Scope:      "import", // defaulted
+			Type:       "jar",    // defaulted
+			Reason:     "Fixes a native crash in SslHandler",
+			Advisories: []string{"CVE-2025-24970"},
+		}, {
+			GroupID:    "org.json",
+			ArtifactID: "json",
+			Version:    "20231013",
+			Scope:      "runtime",
+			Type:       "jar", // defaulted
+			Classifier: "tests",
+			Operation:  PatchOperationAdd,
+			Advisories: []string{"CVE-2023-5072", "GHSA-rm7j-f5g5-27vv"},
+		}, {
+			GroupID:    "log4j",
+			ArtifactID: "log4j",
+			Scope:      "import", // defaulted
+			Type:       "jar",    // defaulted
+			Operation:  PatchOperationRemove,
+			Reason:     "Replaced by reload4j",
+		}},
+	}, {
+		name:   "json file",
+		inFile: "testdata/patches.json",
+		want: []Patch{{
+			GroupID:    "io.netty",
+			ArtifactID: "netty-handler",
+			Version:    "4.1.118.Final",
+			Scope:      "import", // defaulted
+			Type:       "jar",    // defaulted
+			Advisories: []string{"CVE-2025-24970"},
+		}, {
+			GroupID:    "org.json",
+			ArtifactID: "json",
+			Version:    "20231013",
+			Scope:      "runtime",
+			Type:       "jar", // defaulted
+		}},
 	}, {
 		name:    "invalid flag",
 		inDeps:  "g1@a1 g2",
 		wantErr: true,
+	}, {
+		name:    "flag with too many fields",
+		inDeps:  "g1@a1@v1@test@jar@linux-x86_64@extra",
+		wantErr: true,
+	}, {
+		name:   "flag with classifier",
+		inDeps: "g1@a1@v1@test@jar@linux-x86_64 g2@a2@v2@@@tests",
+		want: []Patch{{
+			GroupID:    "g1",
+			ArtifactID: "a1",
+			Version:    "v1",
+			Scope:      "test",
+			Type:       "jar",
+			Classifier: "linux-x86_64",
+		}, {
+			GroupID:    "g2",
+			ArtifactID: "a2",
+			Version:    "v2",
+			Scope:      "import", // default
+			Type:       "jar",    // default
+			Classifier: "tests",
+		}},
 	}, {
 		name:   "flag",
 		inFile: "",
@@ -328,6 +696,13 @@ func TestParseProperties(t *testing.T) {
 			"prop2": "value2",
 			"prop1": "value1",
 		},
+	}, {
+		name:   "json file",
+		inFile: "testdata/properties.json",
+		want: map[string]string{
+			"prop2": "value2",
+			"prop1": "value1",
+		},
 	}, {
 		name:    "flag",
 		inFile:  "",
@@ -354,3 +729,66 @@ func TestParseProperties(t *testing.T) {
 		})
 	}
 }
+
+func TestParsePatchListUnknownKeys(t *testing.T) {
+	testCases := []struct {
+		name    string
+		path    string
+		in      string
+		wantErr string
+	}{{
+		name: "known keys",
+		in:   "patches:\n- groupId: g\n  artifactId: a\n  version: v\n  reason: r\n  exclusions:\n  - groupId: x\n    artifactId: z\n",
+	}, {
+		name:    "misspelt key",
+		in:      "patches:\n- groupId: g\n  artifactId: a\n  versoin: v\n",
+		wantErr: `patch 1: unknown key "versoin", did you mean "version"?`,
+	}, {
+		name:    "second patch",
+		in:      "patches:\n- groupID: g\n  artifactID: a\n  version: v\n- group_id: g\n  artifactId: a\n",
+		wantErr: `patch 2: unknown key "group_id", did you mean "groupId"?`,
+	}, {
+		name:    "unknown exclusion key",
+		in:      "patches:\n- groupId: g\n  artifactId: a\n  exclusions:\n  - groupId: x\n    artifact: z\n",
+		wantErr: `patch 1, exclusion 1: unknown key "artifact", did you mean "artifactId"?`,
+	}, {
+		name:    "unrelated key",
+		in:      "patches:\n- groupId: g\n  artifactId: a\n  severity: high\n",
+		wantErr: `patch 1: unknown key "severity", the known keys are groupId, artifactId, version, scope, type, classifier, purl, operation, position, target, module, advisories, exclusions, reason, cve`,
+	}, {
+		name:    "unknown top level key",
+		in:      "patch:\n- groupId: g\n",
+		wantErr: `the patch file: unknown key "patch", did you mean "patches"?`,
+	}, {
+		name: "json",
+		path: "patches.json",
+		in:   `{"patches": [{"groupId": "g", "artifactId": "a", "version": "v", "reason": "r"}]}`,
+	}, {
+		name:    "json misspelt key",
+		path:    "patches.json",
+		in:      `{"patches": [{"groupId": "g", "artifactId": "a", "verison": "v"}]}`,
+		wantErr: `patch 1: unknown key "verison", did you mean "version"?`,
+	}, {
+		name:    "json syntax error",
+		path:    "patches.JSON",
+		in:      "{\n  \"patches\": [\n    {\"groupId\": \"g\",}\n  ]\n}",
+		wantErr: `failed to parse the patch file: line 3: invalid character '}' looking for beginning of object key string`,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.path == "" {
+				tc.path = "patches.yaml"
+			}
+			_, err := parsePatchList(tc.path, []byte(tc.in))
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("parsePatchList() = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("parsePatchList() = %v, want %s", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// PatchOperationBump is the explicit name of the default operation: bump the
+// dependency wherever it is found, and manage it if it is not. ParsePatches
+// turns it into the empty operation.
+const PatchOperationBump = "bump"
+
+// isJSONFile tells whether the patch or property file at path is JSON rather
+// than YAML.
+func isJSONFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
+
+// unmarshalPatchFile decodes the patch or property file at path, JSON or
+// YAML, into v.
+func unmarshalPatchFile(path string, data []byte, v any) error {
+	if !isJSONFile(path) {
+		return yaml.Unmarshal(data, v)
+	}
+	err := json.Unmarshal(data, v)
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line := 1 + bytes.Count(data[:syntaxErr.Offset], []byte("\n"))
+		return fmt.Errorf("line %d: %w", line, err)
+	}
+	return err
+}
+
+// parsePatchList parses the patch file at path, YAML or JSON. Unlike
+// yaml.Unmarshal, it rejects keys it does not know, so that a misspelt key is
+// not silently ignored, and suggests the key that was probably meant.
+func parsePatchList(path string, data []byte) (PatchList, error) {
+	var patchList PatchList
+	if err := unmarshalPatchFile(path, data, &patchList); err != nil {
+		return patchList, fmt.Errorf("failed to parse the patch file: %w", err)
+	}
+	// The keys are checked on the JSON form.
+	raw := data
+	if !isJSONFile(path) {
+		var err error
+		if raw, err = yaml.YAMLToJSON(data); err != nil {
+			return patchList, fmt.Errorf("failed to parse the patch file: %w", err)
+		}
+	}
+
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return patchList, fmt.Errorf("failed to parse the patch file: %w", err)
+	}
+	if err := checkKeys("the patch file", document, PatchList{}); err != nil {
+		return patchList, err
+	}
+	var patches []map[string]json.RawMessage
+	if err := json.Unmarshal(document["patches"], &patches); err != nil && document["patches"] != nil {
+		return patchList, fmt.Errorf("failed to parse the patch file: patches must be a list: %w", err)
+	}
+	for i, patch := range patches {
+		where := fmt.Sprintf("patch %d", i+1)
+		if err := checkKeys(where, patch, Patch{}); err != nil {
+			return patchList, err
+		}
+		var exclusions []map[string]json.RawMessage
+		if err := json.Unmarshal(patch["exclusions"], &exclusions); err != nil && patch["exclusions"] != nil {
+			return patchList, fmt.Errorf("%s: exclusions must be a list: %w", where, err)
+		}
+		for j, exclusion := range exclusions {
+			if err := checkKeys(fmt.Sprintf("%s, exclusion %d", where, j+1), exclusion, Exclusion{}); err != nil {
+				return patchList, err
+			}
+		}
+	}
+
+	return patchList, nil
+}
+
+// checkKeys fails on the first key of object, in order, that is not a field
+// of v, a struct with json tags.
+func checkKeys(where string, object map[string]json.RawMessage, v any) error {
+	known := jsonFields(v)
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		// Like encoding/json, keys match the fields regardless of case,
+		// e.g. groupID.
+		if slices.ContainsFunc(known, func(k string) bool { return strings.EqualFold(k, key) }) {
+			continue
+		}
+		if suggestion := closestKey(key, known); suggestion != "" {
+			return fmt.Errorf("%s: unknown key %q, did you mean %q?", where, key, suggestion)
+		}
+		return fmt.Errorf("%s: unknown key %q, the known keys are %s", where, key, strings.Join(known, ", "))
+	}
+	return nil
+}
+
+// jsonFields returns the json names of the fields of v, a struct.
+func jsonFields(v any) []string {
+	t := reflect.TypeOf(v)
+	fields := make([]string, 0, t.NumField())
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// closestKey returns the known key key is most likely a typo of, at most two
+// edits away regardless of case. It returns "" if there is none.
+func closestKey(key string, known []string) string {
+	best, bestDistance := "", 3
+	for _, k := range known {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(k)); d < bestDistance {
+			best, bestDistance = k, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
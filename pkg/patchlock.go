@@ -0,0 +1,200 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+)
+
+// PatchLockFileName is the usual name of the file a PatchLock is kept in,
+// next to the root POM.
+const PatchLockFileName = "pombump.lock"
+
+// PatchLockVersion is the version of the lock format.
+const PatchLockVersion = 1
+
+// PatchLock records the patches applied to POMs, so that automation running
+// again does not apply them again, and so that when and why a version was
+// bumped can be looked up later.
+type PatchLock struct {
+	Version int              `json:"version" yaml:"version"`
+	Entries []PatchLockEntry `json:"entries" yaml:"entries"`
+}
+
+// PatchLockEntry is a patch applied to a POM: the version of a dependency, or
+// the value of a property.
+type PatchLockEntry struct {
+	POM        string `json:"pom" yaml:"pom"`
+	GroupID    string `json:"groupId,omitempty" yaml:"groupId,omitempty"`
+	ArtifactID string `json:"artifactId,omitempty" yaml:"artifactId,omitempty"`
+	Property   string `json:"property,omitempty" yaml:"property,omitempty"`
+	Version    string `json:"version" yaml:"version"`
+	// Advisories are those the patch fixes, if known.
+	Advisories []string  `json:"advisories,omitempty" yaml:"advisories,omitempty"`
+	AppliedAt  time.Time `json:"appliedAt" yaml:"appliedAt"`
+}
+
+// Name is the groupId:artifactId of the entry, or its property.
+func (e PatchLockEntry) Name() string {
+	if e.Property != "" {
+		return e.Property
+	}
+	return e.GroupID + ":" + e.ArtifactID
+}
+
+// Record records that patches and propertyPatches were applied to pom at
+// the time at, and returns how many entries it added. Patches already
+// recorded at the same version are not recorded again, so that recording
+// the same run twice changes nothing.
+func (l *PatchLock) Record(pom string, patches []Patch, propertyPatches map[string]string, at time.Time) int {
+	l.Version = PatchLockVersion
+	at = at.UTC().Truncate(time.Second)
+	recorded := 0
+	add := func(entry PatchLockEntry) {
+		if slices.ContainsFunc(l.Entries, func(e PatchLockEntry) bool {
+			return e.POM == entry.POM && e.Name() == entry.Name() && e.Version == entry.Version
+		}) {
+			return
+		}
+		l.Entries = append(l.Entries, entry)
+		recorded++
+	}
+	for _, p := range patches {
+		if changesVersion(p) && !IsAdvisoryID(p.Version) {
+			add(PatchLockEntry{POM: pom, GroupID: p.GroupID, ArtifactID: p.ArtifactID, Version: p.Version, Advisories: p.Advisories, AppliedAt: at})
+		}
+	}
+	for _, name := range sortedKeys(propertyPatches) {
+		add(PatchLockEntry{POM: pom, Property: name, Version: propertyPatches[name], AppliedAt: at})
+	}
+	return recorded
+}
+
+// Unapplied splits patches into those the lock does not record for pom and
+// the entries of those it does, at their version or a later one. A
+// recorded patch is applied again when current, the versions in use by
+// groupId:artifactId, shows it was undone since.
+func (l *PatchLock) Unapplied(pom string, patches []Patch, current map[string]string) ([]Patch, []PatchLockEntry) {
+	pending := make([]Patch, 0, len(patches))
+	var applied []PatchLockEntry
+	for _, p := range patches {
+		if !changesVersion(p) || IsAdvisoryID(p.Version) {
+			pending = append(pending, p)
+			continue
+		}
+		name := p.GroupID + ":" + p.ArtifactID
+		i := slices.IndexFunc(l.Entries, func(e PatchLockEntry) bool {
+			return e.POM == pom && e.Property == "" && e.Name() == name && compareVersions(e.Version, p.Version) >= 0
+		})
+		if version, ok := current[name]; i < 0 || ok && !checkVersion(name, p.Version, version).Satisfied {
+			pending = append(pending, p)
+			continue
+		}
+		applied = append(applied, l.Entries[i])
+	}
+	return pending, applied
+}
+
+// History returns the entries of the lock for pom, or every POM if empty,
+// matching query, if not empty: a groupId:artifactId, a property or an
+// advisory. They are sorted by when they were applied.
+func (l *PatchLock) History(pom, query string) []PatchLockEntry {
+	entries := []PatchLockEntry{}
+	for _, e := range l.Entries {
+		if pom != "" && e.POM != pom {
+			continue
+		}
+		if query != "" && e.Name() != query && !slices.ContainsFunc(e.Advisories, func(a string) bool {
+			return strings.EqualFold(a, query)
+		}) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	slices.SortStableFunc(entries, func(a, b PatchLockEntry) int {
+		return a.AppliedAt.Compare(b.AppliedAt)
+	})
+	return entries
+}
+
+// Drop drops the entries of the lock for pom that current, the versions of
+// its dependencies by groupId:artifactId and the values of its properties,
+// no longer satisfies, as when the patches were rolled back, and returns how
+// many it dropped.
+func (l *PatchLock) Drop(pom string, current map[string]string) int {
+	kept := l.Entries[:0]
+	for _, e := range l.Entries {
+		if e.POM != pom || checkVersion(e.Name(), e.Version, current[e.Name()]).Satisfied {
+			kept = append(kept, e)
+		}
+	}
+	dropped := len(l.Entries) - len(kept)
+	l.Entries = kept
+	return dropped
+}
+
+// PatchTime returns the time to record patches as applied at: that
+// $SOURCE_DATE_EPOCH gives in seconds since the epoch, if set, so that a
+// reproducible build records the same lock whenever it runs, or else the
+// current time. It fails if $SOURCE_DATE_EPOCH is not a number.
+func PatchTime() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Now(), nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %w", epoch, err)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// UpdatePatchLock reads the lock in file, or starts one if there is none,
+// passes it to update and writes it back, holding LockFile meanwhile so
+// that concurrent runs do not lose each other's entries.
+func UpdatePatchLock(ctx context.Context, file string, update func(*PatchLock)) error {
+	unlock, err := LockFile(ctx, file)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	lock, err := ReadPatchLock(file)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		lock = &PatchLock{Version: PatchLockVersion}
+	case err != nil:
+		return err
+	}
+	update(lock)
+	return WritePatchLock(file, lock)
+}
+
+// WritePatchLock writes lock to file as YAML.
+func WritePatchLock(file string, lock *PatchLock) error {
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return fmt.Errorf("failed to marshal lock: %w", err)
+	}
+	return WriteFileAtomic(file, data, false)
+}
+
+// ReadPatchLock reads a lock written by WritePatchLock.
+func ReadPatchLock(file string) (*PatchLock, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading file: %w", err)
+	}
+	var lock PatchLock
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse lock: %w", err)
+	}
+	return &lock, nil
+}
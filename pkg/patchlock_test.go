@@ -0,0 +1,80 @@
+package pkg
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchLock(t *testing.T) {
+	first := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	lock := &PatchLock{}
+	patches := []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.94.Final", Advisories: []string{"CVE-2023-34462"}},
+		{GroupID: "org.yaml", ArtifactID: "snakeyaml", Operation: PatchOperationRemove},
+	}
+	assert.Equal(t, 2, lock.Record("pom.xml", patches, map[string]string{"jackson.version": "2.15.0"}, first))
+	// Recording the same run again changes nothing.
+	assert.Equal(t, 0, lock.Record("pom.xml", patches, map[string]string{"jackson.version": "2.15.0"}, first.Add(time.Hour)))
+	assert.Equal(t, 1, lock.Record("app/pom.xml", patches[:1], nil, first.Add(time.Hour)))
+
+	file := filepath.Join(t.TempDir(), PatchLockFileName)
+	require.NoError(t, WritePatchLock(file, lock))
+	read, err := ReadPatchLock(file)
+	require.NoError(t, err)
+	assert.Equal(t, lock, read)
+
+	history := lock.History("", "CVE-2023-34462")
+	require.Len(t, history, 2)
+	assert.Equal(t, "pom.xml", history[0].POM)
+	assert.Equal(t, first, history[0].AppliedAt)
+	assert.Equal(t, []PatchLockEntry{{POM: "pom.xml", Property: "jackson.version", Version: "2.15.0", AppliedAt: first}}, lock.History("pom.xml", "jackson.version"))
+	assert.Len(t, lock.History("pom.xml", ""), 2)
+
+	requested := []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.90.Final"},
+		{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "4.1.94.Final"},
+	}
+	pending, applied := lock.Unapplied("pom.xml", requested, map[string]string{"io.netty:netty-handler": "4.1.94.Final"})
+	assert.Equal(t, requested[1:], pending)
+	require.Len(t, applied, 1)
+	assert.Equal(t, "io.netty:netty-handler", applied[0].Name())
+	// Undone since, so applied again.
+	pending, _ = lock.Unapplied("pom.xml", requested, map[string]string{"io.netty:netty-handler": "4.1.80.Final"})
+	assert.Equal(t, requested, pending)
+	pending, _ = lock.Unapplied("other/pom.xml", requested, nil)
+	assert.Equal(t, requested, pending)
+
+	// Rolled back, the property but not the dependency.
+	assert.Equal(t, 1, lock.Drop("pom.xml", map[string]string{"io.netty:netty-handler": "4.1.94.Final", "jackson.version": "2.14.0"}))
+	assert.Empty(t, lock.History("pom.xml", "jackson.version"))
+	assert.Len(t, lock.History("", "CVE-2023-34462"), 2)
+}
+
+func TestPatchTime(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1772359200")
+	at, err := PatchTime()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), at)
+
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	_, err = PatchTime()
+	assert.Error(t, err)
+}
+
+func TestUpdatePatchLock(t *testing.T) {
+	file := filepath.Join(t.TempDir(), PatchLockFileName)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, UpdatePatchLock(context.Background(), file, func(lock *PatchLock) {
+			lock.Record("pom.xml", nil, map[string]string{"a.version": "1"}, time.Now())
+		}))
+	}
+	lock, err := ReadPatchLock(file)
+	require.NoError(t, err)
+	assert.Equal(t, PatchLockVersion, lock.Version)
+	assert.Len(t, lock.Entries, 1)
+}
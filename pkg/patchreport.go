@@ -0,0 +1,96 @@
+package pkg
+
+import (
+	"fmt"
+)
+
+// PatchReport is the outcome of the patch command, per file patched and per
+// patch, as printed by --output json and yaml.
+type PatchReport struct {
+	// Applied, Skipped and Failed count the patches of every file.
+	Applied int               `json:"applied" yaml:"applied"`
+	Skipped int               `json:"skipped" yaml:"skipped"`
+	Failed  int               `json:"failed" yaml:"failed"`
+	Files   []FilePatchReport `json:"files" yaml:"files"`
+}
+
+// FilePatchReport is the outcome of the patches of one POM or build script.
+type FilePatchReport struct {
+	File    string         `json:"file" yaml:"file"`
+	Applied []PatchOutcome `json:"applied" yaml:"applied"`
+	Skipped []SkippedPatch `json:"skipped" yaml:"skipped"`
+	Failed  []PatchOutcome `json:"failed" yaml:"failed"`
+	// Patched is the patched file, when it is printed rather than written
+	// in place.
+	Patched string `json:"patched,omitempty" yaml:"patched,omitempty"`
+}
+
+// PatchOutcome is a patch or property update and what it changed.
+type PatchOutcome struct {
+	// Name is groupId:artifactId, or the property.
+	Name string `json:"name" yaml:"name"`
+	// Operation is that of the patch, if any, see Patch.
+	Operation string `json:"operation,omitempty" yaml:"operation,omitempty"`
+	// Previous is the version, or value, in use before patching, and
+	// Actual the one after.
+	Previous string `json:"previous,omitempty" yaml:"previous,omitempty"`
+	Version  string `json:"version,omitempty" yaml:"version,omitempty"`
+	Actual   string `json:"actual,omitempty" yaml:"actual,omitempty"`
+	// Reason says why a failed patch did not take.
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// NewFilePatchReport reports patching file with patches and propertyPatches,
+// given the analyses of file before and after: a patch setting a version is
+// applied when the version in use after patching satisfies it and failed
+// otherwise, one with another operation is applied, and skipped lists those
+// that were left out.
+func NewFilePatchReport(file string, before, after *AnalysisResult, patches []Patch, propertyPatches map[string]string, skipped []SkippedPatch) FilePatchReport {
+	report := FilePatchReport{File: file, Applied: []PatchOutcome{}, Skipped: skipped, Failed: []PatchOutcome{}}
+	if report.Skipped == nil {
+		report.Skipped = []SkippedPatch{}
+	}
+	previous, actual := before.CurrentVersions(), after.CurrentVersions()
+	version := func(result *AnalysisResult, current map[string]string, p Patch) string {
+		if v, ok := current[fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID)]; ok {
+			return v
+		}
+		if managed, found := result.ManagedVersion(p.GroupID, p.ArtifactID); found {
+			return managed.Version
+		}
+		return ""
+	}
+	for _, p := range patches {
+		name := fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID)
+		outcome := PatchOutcome{Name: name, Operation: p.Operation, Previous: version(before, previous, p), Version: p.Version}
+		if !changesVersion(p) {
+			report.Applied = append(report.Applied, outcome)
+			continue
+		}
+		outcome.Actual = version(after, actual, p)
+		if !checkVersion(name, p.Version, outcome.Actual).Satisfied {
+			outcome.Reason = fmt.Sprintf("%s is %s after patching", name, describeActual(outcome.Actual))
+			report.Failed = append(report.Failed, outcome)
+			continue
+		}
+		report.Applied = append(report.Applied, outcome)
+	}
+	for _, name := range sortedKeys(propertyPatches) {
+		outcome := PatchOutcome{Name: name, Previous: before.Properties[name], Version: propertyPatches[name], Actual: after.Properties[name]}
+		if outcome.Actual != outcome.Version {
+			outcome.Reason = fmt.Sprintf("%s is %s after patching", name, describeActual(outcome.Actual))
+			report.Failed = append(report.Failed, outcome)
+			continue
+		}
+		report.Applied = append(report.Applied, outcome)
+	}
+	return report
+}
+
+// Add adds the report of a file, and counts its patches.
+func (r *PatchReport) Add(file FilePatchReport) {
+	r.Applied += len(file.Applied)
+	r.Skipped += len(file.Skipped)
+	r.Failed += len(file.Failed)
+	r.Files = append(r.Files, file)
+}
@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFilePatchReport(t *testing.T) {
+	ctx := context.Background()
+	project, err := parsePOMData([]byte(analyzerPOM))
+	require.NoError(t, err)
+	before, err := AnalyzeProject(ctx, project)
+	require.NoError(t, err)
+
+	patches := []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final"},
+		{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "4.1.100.Final", Operation: PatchOperationRemove},
+	}
+	properties := map[string]string{"netty.version": "4.1.100.Final"}
+	skipped := []SkippedPatch{{Name: "org.yaml:snakeyaml", Current: "2.2", Version: "2.2", Reason: effectiveReason}}
+	patched, err := PatchProject(ctx, project, patches, properties)
+	require.NoError(t, err)
+	after, err := AnalyzeProject(ctx, patched)
+	require.NoError(t, err)
+
+	file := NewFilePatchReport("pom.xml", before, after, patches, properties, skipped)
+	assert.Equal(t, FilePatchReport{
+		File: "pom.xml",
+		Applied: []PatchOutcome{
+			{Name: "io.netty:netty-handler", Previous: "4.1.94.Final", Version: "4.1.100.Final", Actual: "4.1.100.Final"},
+			{Name: "io.netty:netty-codec", Operation: PatchOperationRemove, Previous: "4.1.94.Final", Version: "4.1.100.Final"},
+			{Name: "netty.version", Previous: "4.1.94.Final", Version: "4.1.100.Final", Actual: "4.1.100.Final"},
+		},
+		Skipped: skipped,
+		Failed:  []PatchOutcome{},
+	}, file)
+
+	// A version the patched POM does not use fails.
+	file = NewFilePatchReport("pom.xml", before, after, []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.2.0.Final"}}, nil, nil)
+	assert.Empty(t, file.Applied)
+	assert.Equal(t, []SkippedPatch{}, file.Skipped)
+	assert.Equal(t, []PatchOutcome{{
+		Name: "io.netty:netty-handler", Previous: "4.1.94.Final", Version: "4.2.0.Final", Actual: "4.1.100.Final",
+		Reason: "io.netty:netty-handler is 4.1.100.Final after patching",
+	}}, file.Failed)
+
+	report := &PatchReport{}
+	report.Add(NewFilePatchReport("pom.xml", before, after, patches, properties, skipped))
+	report.Add(file)
+	assert.Equal(t, 3, report.Applied)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, 1, report.Failed)
+	assert.Len(t, report.Files, 2)
+}
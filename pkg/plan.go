@@ -0,0 +1,461 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/ghodss/yaml"
+)
+
+// PlanVersion is the version of the plan format NewPlan produces.
+const PlanVersion = 1
+
+// What a Mutation does to its element.
+const (
+	MutationSet    = "set"
+	MutationAdd    = "add"
+	MutationRemove = "remove"
+	MutationMove   = "move"
+)
+
+// Mutation is a change to the XML of a POM.
+type Mutation struct {
+	// Action is one of set, add, remove or move.
+	Action string `json:"action" yaml:"action"`
+	// Element is the path of the element from the root, naming the
+	// dependencies by groupId:artifactId, the plugins by artifactId and the
+	// profiles by id, e.g.
+	// project/dependencies/dependency[io.netty:netty-handler]/version.
+	Element string `json:"element" yaml:"element"`
+	// From and To are the text of the element before and after, empty
+	// when added or removed. A moved dependency goes from one 1-based
+	// position among its siblings to another.
+	From string `json:"from,omitempty" yaml:"from,omitempty"`
+	To   string `json:"to,omitempty" yaml:"to,omitempty"`
+	// Content is the XML of a removed dependency, to restore it from.
+	Content string `json:"content,omitempty" yaml:"content,omitempty"`
+	// Position is where the element changed is declared, if known.
+	Position *SourcePosition `json:"position,omitempty" yaml:"position,omitempty"`
+}
+
+func (m Mutation) String() string {
+	switch m.Action {
+	case MutationAdd:
+		return fmt.Sprintf("add %s %s", m.Element, m.To)
+	case MutationRemove:
+		return fmt.Sprintf("remove %s", m.Element)
+	}
+	return fmt.Sprintf("%s %s: %s -> %s", m.Action, m.Element, orNone(m.From), orNone(m.To))
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
+
+// Plan is every change patching a POM would make, worked out ahead of
+// making them so that they can be reviewed, then applied by Apply.
+type Plan struct {
+	Version int `json:"version" yaml:"version"`
+	// POM is the file the plan is for, and Checksum the SHA-256 of its
+	// contents when planned.
+	POM      string `json:"pom" yaml:"pom"`
+	Checksum string `json:"checksum" yaml:"checksum"`
+	// Patches and Properties are what is applied, as PatchProject takes
+	// them.
+	Patches    []Patch         `json:"patches,omitempty" yaml:"patches,omitempty"`
+	Properties []PropertyPatch `json:"properties,omitempty" yaml:"properties,omitempty"`
+	// Mutations are the changes to the XML the patches make, in the order
+	// of the POM.
+	Mutations []Mutation `json:"mutations" yaml:"mutations"`
+}
+
+// Empty reports whether the plan changes nothing.
+func (p *Plan) Empty() bool {
+	return len(p.Mutations) == 0
+}
+
+// pomChecksum is the hex SHA-256 of the contents of a POM.
+func pomChecksum(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// parsePOMData parses the contents of a POM, as ParsePOMFile does a file.
+func parsePOMData(data []byte) (*gopom.Project, error) {
+	var project gopom.Project
+	if err := unmarshalPOM(data, &project); err != nil {
+		return nil, fmt.Errorf("failed to parse POM file: %w", err)
+	}
+	return &project, nil
+}
+
+// NewPlan plans patching the POM pom, whose contents are data, with patches
+// and propertyPatches.
+func NewPlan(ctx context.Context, pom string, data []byte, patches []Patch, propertyPatches map[string]string) (*Plan, error) {
+	plan := &Plan{
+		Version:    PlanVersion,
+		POM:        pom,
+		Checksum:   pomChecksum(data),
+		Patches:    patches,
+		Properties: SortedPropertyPatches(propertyPatches),
+	}
+	_, mutations, err := plan.patch(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	plan.Mutations = mutations
+	return plan, nil
+}
+
+// Apply applies the plan to data, the contents of its POM, and returns the
+// patched project. It fails if the POM changed since it was planned, or if
+// patching it does not make exactly the planned mutations.
+func (p *Plan) Apply(ctx context.Context, data []byte) (*gopom.Project, error) {
+	if p.Version != PlanVersion {
+		return nil, fmt.Errorf("unsupported plan version %d, expected %d", p.Version, PlanVersion)
+	}
+	if checksum := pomChecksum(data); checksum != p.Checksum {
+		return nil, fmt.Errorf("%s changed since it was planned, plan again", p.POM)
+	}
+	patched, mutations, err := p.patch(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	planned, _ := json.Marshal(p.Mutations)
+	actual, _ := json.Marshal(mutations)
+	if !bytes.Equal(planned, actual) {
+		return nil, fmt.Errorf("patching %s does not make the planned changes, plan again", p.POM)
+	}
+	return patched, nil
+}
+
+// patch patches data with the changes of the plan, returning the patched
+// project and the mutations it went through.
+func (p *Plan) patch(ctx context.Context, data []byte) (*gopom.Project, []Mutation, error) {
+	before, err := parsePOMData(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	after, err := parsePOMData(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	properties := make(map[string]string, len(p.Properties))
+	for _, prop := range p.Properties {
+		properties[prop.Property] = prop.Value
+	}
+	// PatchProject may modify the patches it is given.
+	after, err = PatchProject(ctx, after, slices.Clone(p.Patches), properties)
+	if err != nil {
+		return nil, nil, err
+	}
+	declarations, err := scanDeclarations(bytes.NewReader(data), p.POM)
+	if err != nil {
+		return nil, nil, err
+	}
+	return after, projectMutations(before, after, declarations), nil
+}
+
+// dependencySection is a list of dependencies of a POM, by the path of its
+// element.
+type dependencySection struct {
+	path string
+	// section is that of the declarations, see declaredDependency.
+	section string
+	deps    *[]gopom.Dependency
+}
+
+// dependencySections returns the dependency lists PatchProject changes:
+// dependencies, dependencyManagement and those of the plugins, of the
+// project and of its profiles.
+func dependencySections(project *gopom.Project) []dependencySection {
+	sections := []dependencySection{{"project/dependencies", targetDependencies, project.Dependencies}}
+	if project.DependencyManagement != nil {
+		sections = append(sections, dependencySection{"project/dependencyManagement/dependencies", targetDependencyManagement, project.DependencyManagement.Dependencies})
+	}
+	builds := []struct {
+		path  string
+		build *gopom.BuildBase
+	}{}
+	if project.Build != nil {
+		builds = append(builds, struct {
+			path  string
+			build *gopom.BuildBase
+		}{"project/build", &project.Build.BuildBase})
+	}
+	if project.Profiles != nil {
+		for _, profile := range *project.Profiles {
+			if profile.Build != nil {
+				builds = append(builds, struct {
+					path  string
+					build *gopom.BuildBase
+				}{fmt.Sprintf("project/profiles/profile[%s]/build", profile.ID), profile.Build})
+			}
+		}
+	}
+	for _, b := range builds {
+		for _, list := range []struct {
+			path    string
+			plugins *[]gopom.Plugin
+		}{{b.path + "/plugins", b.build.Plugins}, {b.path + "/pluginManagement/plugins", pluginManagementPlugins(b.build)}} {
+			if list.plugins == nil {
+				continue
+			}
+			for _, plugin := range *list.plugins {
+				if plugin.Dependencies != nil {
+					sections = append(sections, dependencySection{fmt.Sprintf("%s/plugin[%s]/dependencies", list.path, plugin.ArtifactID), "plugin", plugin.Dependencies})
+				}
+			}
+		}
+	}
+	return sections
+}
+
+// projectMutations returns the mutations turning before into after, with
+// the positions of the elements in declarations.
+func projectMutations(before, after *gopom.Project, declarations *pomDeclarations) []Mutation {
+	mutations := []Mutation{}
+	if before.Parent != nil && after.Parent != nil && before.Parent.Version != after.Parent.Version {
+		mutations = append(mutations, Mutation{Action: MutationSet, Element: "project/parent/version", From: before.Parent.Version, To: after.Parent.Version})
+	}
+
+	beforeProps, afterProps := map[string]string{}, map[string]string{}
+	if before.Properties != nil {
+		beforeProps = before.Properties.Entries
+	}
+	if after.Properties != nil {
+		afterProps = after.Properties.Entries
+	}
+	names := sortedKeys(beforeProps)
+	for _, name := range sortedKeys(afterProps) {
+		if _, ok := beforeProps[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		from, inBefore := beforeProps[name]
+		to, inAfter := afterProps[name]
+		m := Mutation{Element: "project/properties/" + name, From: from, To: to}
+		if position, ok := declarations.properties[name]; ok {
+			m.Position = &position
+		}
+		switch {
+		case !inAfter:
+			m.Action = MutationRemove
+		case !inBefore:
+			m.Action = MutationAdd
+		case from != to:
+			m.Action = MutationSet
+		default:
+			continue
+		}
+		mutations = append(mutations, m)
+	}
+
+	props := coordinateProperties(before)
+	afterSections := map[string]dependencySection{}
+	for _, s := range dependencySections(after) {
+		afterSections[s.path] = s
+	}
+	for _, s := range dependencySections(before) {
+		mutations = append(mutations, dependencyMutations(s, afterSections[s.path].deps, props, declarations)...)
+		delete(afterSections, s.path)
+	}
+	// Sections PatchProject created, such as dependencyManagement.
+	for _, s := range dependencySections(after) {
+		if _, created := afterSections[s.path]; created {
+			mutations = append(mutations, dependencyMutations(dependencySection{path: s.path, section: s.section}, s.deps, props, declarations)...)
+		}
+	}
+	return mutations
+}
+
+// dependencyMutations returns the mutations turning the dependencies of
+// section into after.
+func dependencyMutations(section dependencySection, after *[]gopom.Dependency, props map[string]string, declarations *pomDeclarations) []Mutation {
+	// Entries are told apart by their resolved coordinates, and by their
+	// rank among those with the same coordinates.
+	keys := func(deps *[]gopom.Dependency) ([]string, map[string]gopom.Dependency) {
+		if deps == nil {
+			return nil, map[string]gopom.Dependency{}
+		}
+		order := []string{}
+		entries := map[string]gopom.Dependency{}
+		seen := map[string]int{}
+		for _, dep := range *deps {
+			resolved := resolveCoordinates(dep, props)
+			key := dependencyKey(resolved)
+			if seen[key]++; seen[key] > 1 {
+				key += "#" + strconv.Itoa(seen[key])
+			}
+			order = append(order, key)
+			entries[key] = dep
+		}
+		return order, entries
+	}
+	beforeOrder, beforeEntries := keys(section.deps)
+	afterOrder, afterEntries := keys(after)
+
+	element := func(dep gopom.Dependency) string {
+		return dependencyElement(section.path, dep, props)
+	}
+	position := func(dep gopom.Dependency) *SourcePosition {
+		// The declarations leave profiles out.
+		if strings.HasPrefix(section.path, "project/profiles/") {
+			return nil
+		}
+		key := dependencyKey(resolveCoordinates(dep, props))
+		for _, d := range declarations.dependencies {
+			if d.section == section.section && dependencyKey(resolveCoordinates(d.dep, props)) == key {
+				position := d.position
+				return &position
+			}
+		}
+		return nil
+	}
+
+	mutations := []Mutation{}
+	for _, key := range beforeOrder {
+		dep := beforeEntries[key]
+		patched, ok := afterEntries[key]
+		if !ok {
+			mutations = append(mutations, Mutation{
+				Action: MutationRemove, Element: element(dep), From: dep.Version,
+				Content: marshalDependency(dep), Position: position(dep),
+			})
+			continue
+		}
+		for _, field := range []struct{ name, from, to string }{
+			{"version", dep.Version, patched.Version},
+			{"scope", dep.Scope, patched.Scope},
+			{"exclusions", exclusionList(dep), exclusionList(patched)},
+		} {
+			if field.from == field.to {
+				continue
+			}
+			action := MutationSet
+			if field.from == "" {
+				action = MutationAdd
+			} else if field.to == "" {
+				action = MutationRemove
+			}
+			mutations = append(mutations, Mutation{Action: action, Element: element(dep) + "/" + field.name, From: field.from, To: field.to, Position: position(dep)})
+		}
+	}
+	for _, key := range afterOrder {
+		if _, ok := beforeEntries[key]; !ok {
+			dep := afterEntries[key]
+			mutations = append(mutations, Mutation{Action: MutationAdd, Element: element(dep), To: dep.Version})
+		}
+	}
+
+	// The entries kept, in their order before and after.
+	kept := slices.DeleteFunc(slices.Clone(beforeOrder), func(key string) bool {
+		_, ok := afterEntries[key]
+		return !ok
+	})
+	keptAfter := slices.DeleteFunc(slices.Clone(afterOrder), func(key string) bool {
+		_, ok := beforeEntries[key]
+		return !ok
+	})
+	for i, key := range kept {
+		if j := slices.Index(keptAfter, key); j != i {
+			dep := beforeEntries[key]
+			mutations = append(mutations, Mutation{
+				Action: MutationMove, Element: element(dep),
+				From: strconv.Itoa(i + 1), To: strconv.Itoa(j + 1), Position: position(dep),
+			})
+		}
+	}
+	return mutations
+}
+
+// dependencyElement is the path of the element of dep in the dependency
+// list at path, naming it by its resolved coordinates.
+func dependencyElement(path string, dep gopom.Dependency, props map[string]string) string {
+	resolved := resolveCoordinates(dep, props)
+	name := resolved.GroupID + ":" + resolved.ArtifactID
+	if !isDefaultArtifact(resolved) {
+		name += ":" + normalizeType(resolved.Type)
+		if resolved.Classifier != "" {
+			name += ":" + resolved.Classifier
+		}
+	}
+	return fmt.Sprintf("%s/dependency[%s]", path, name)
+}
+
+// dependencyXML is a dependency as the XML element it is declared as.
+type dependencyXML struct {
+	XMLName xml.Name `xml:"dependency"`
+	gopom.Dependency
+}
+
+// marshalDependency renders dep as its XML element.
+func marshalDependency(dep gopom.Dependency) string {
+	// A dependency is only strings, it always marshals.
+	data, _ := xml.Marshal(dependencyXML{Dependency: dep})
+	return string(data)
+}
+
+// exclusionList lists the exclusions of dep as groupId:artifactId, comma
+// separated.
+func exclusionList(dep gopom.Dependency) string {
+	if dep.Exclusions == nil {
+		return ""
+	}
+	exclusions := make([]string, 0, len(*dep.Exclusions))
+	for _, e := range *dep.Exclusions {
+		exclusions = append(exclusions, e.GroupID+":"+e.ArtifactID)
+	}
+	return strings.Join(exclusions, ",")
+}
+
+// WritePlan writes plan to file, as JSON if its name ends in .json and as
+// YAML otherwise.
+func WritePlan(file string, plan *Plan) error {
+	data, err := MarshalPlan(plan, strings.HasSuffix(file, ".json"))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// MarshalPlan renders plan as indented JSON, or as YAML.
+func MarshalPlan(plan *Plan, asJSON bool) ([]byte, error) {
+	if asJSON {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal plan: %w", err)
+		}
+		return append(data, '\n'), nil
+	}
+	data, err := yaml.Marshal(plan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal plan: %w", err)
+	}
+	return data, nil
+}
+
+// ReadPlan reads a plan written by WritePlan, JSON or YAML.
+func ReadPlan(file string) (*Plan, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading file: %w", err)
+	}
+	var plan Plan
+	if err := yaml.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+	return &plan, nil
+}
@@ -0,0 +1,68 @@
+package pkg
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPlan(t *testing.T) {
+	ctx := context.Background()
+	data := []byte(locatedPOM)
+	patches := []Patch{
+		{GroupID: "com.example", ArtifactID: "core", Version: "1.1", Scope: "import", Type: "jar"},
+		{GroupID: "com.fasterxml.jackson.core", ArtifactID: "jackson-databind", Version: "2.15.0", Scope: "import", Type: "jar"},
+	}
+	plan, err := NewPlan(ctx, "pom.xml", data, patches, map[string]string{"netty.version": "4.1.100.Final"})
+	require.NoError(t, err)
+
+	assert.Equal(t, PlanVersion, plan.Version)
+	assert.Equal(t, pomChecksum(data), plan.Checksum)
+	assert.Equal(t, []Mutation{
+		{
+			Action: MutationSet, Element: "project/properties/netty.version", From: "4.1.94.Final", To: "4.1.100.Final",
+			Position: &SourcePosition{File: "pom.xml", Line: 7, Column: 5},
+		},
+		{
+			Action: MutationSet, Element: "project/dependencies/dependency[com.example:core]/version", From: "1.0", To: "1.1",
+			Position: &SourcePosition{File: "pom.xml", Line: 20, Column: 5},
+		},
+		{Action: MutationAdd, Element: "project/dependencyManagement/dependencies/dependency[com.fasterxml.jackson.core:jackson-databind]", To: "2.15.0"},
+	}, plan.Mutations)
+
+	// The plan applies to the POM it was made for, and makes the changes.
+	project, err := plan.Apply(ctx, data)
+	require.NoError(t, err)
+	assert.Equal(t, "4.1.100.Final", project.Properties.Entries["netty.version"])
+	assert.Equal(t, "1.1", (*project.Dependencies)[0].Version)
+
+	_, err = plan.Apply(ctx, append(data, '\n'))
+	assert.ErrorContains(t, err, "changed since it was planned")
+
+	plan.Mutations = plan.Mutations[1:]
+	_, err = plan.Apply(ctx, data)
+	assert.ErrorContains(t, err, "does not make the planned changes")
+}
+
+func TestNewPlanNothingToDo(t *testing.T) {
+	plan, err := NewPlan(context.Background(), "pom.xml", []byte(locatedPOM), []Patch{
+		{GroupID: "com.example", ArtifactID: "core", Version: "1.0", Scope: "import", Type: "jar"},
+	}, nil)
+	require.NoError(t, err)
+	assert.True(t, plan.Empty())
+}
+
+func TestWriteReadPlan(t *testing.T) {
+	plan, err := NewPlan(context.Background(), "pom.xml", []byte(locatedPOM), nil, map[string]string{"netty.version": "4.1.100.Final"})
+	require.NoError(t, err)
+	for _, name := range []string{"plan.json", "plan.yaml"} {
+		file := filepath.Join(t.TempDir(), name)
+		require.NoError(t, WritePlan(file, plan))
+		read, err := ReadPlan(file)
+		require.NoError(t, err)
+		assert.Equal(t, plan, read, name)
+	}
+}
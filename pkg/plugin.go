@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"slices"
+
+	"github.com/chainguard-dev/gopom"
+)
+
+// pluginDependencies is the <dependencies> block of a plugin, which puts
+// jars on the plugin's own classpath (e.g. ASM for maven-compiler-plugin)
+// rather than on the project's.
+type pluginDependencies struct {
+	// Plugin is the artifactId of the plugin.
+	Plugin       string
+	Dependencies *[]gopom.Dependency
+}
+
+// projectBuilds returns the build of project and then those of each of its
+// profiles.
+func projectBuilds(project *gopom.Project) []*gopom.BuildBase {
+	builds := []*gopom.BuildBase{}
+	if project.Build != nil {
+		builds = append(builds, &project.Build.BuildBase)
+	}
+	if project.Profiles != nil {
+		for i := range *project.Profiles {
+			if build := (*project.Profiles)[i].Build; build != nil {
+				builds = append(builds, build)
+			}
+		}
+	}
+	return builds
+}
+
+// usesPlugin reports whether project configures the plugin artifactID, in
+// build/plugins or build/pluginManagement, of the project or a profile.
+func usesPlugin(project *gopom.Project, artifactID string) bool {
+	for _, build := range projectBuilds(project) {
+		for _, plugins := range []*[]gopom.Plugin{build.Plugins, pluginManagementPlugins(build)} {
+			if plugins != nil && slices.ContainsFunc(*plugins, func(plugin gopom.Plugin) bool { return plugin.ArtifactID == artifactID }) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pluginDependencyLists returns the dependencies of every plugin of project
+// that has some, from build/plugins and build/pluginManagement, of the
+// project and then of each of its profiles.
+func pluginDependencyLists(project *gopom.Project) []pluginDependencies {
+	lists := []pluginDependencies{}
+	for _, build := range projectBuilds(project) {
+		for _, plugins := range []*[]gopom.Plugin{build.Plugins, pluginManagementPlugins(build)} {
+			if plugins == nil {
+				continue
+			}
+			for _, plugin := range *plugins {
+				if plugin.Dependencies != nil && len(*plugin.Dependencies) > 0 {
+					lists = append(lists, pluginDependencies{Plugin: plugin.ArtifactID, Dependencies: plugin.Dependencies})
+				}
+			}
+		}
+	}
+	return lists
+}
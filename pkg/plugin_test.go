@@ -0,0 +1,43 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeProjectPluginDependencies(t *testing.T) {
+	// ASM hides in the dependencies of maven-compiler-plugin, and of a
+	// managed plugin through a property, and netty in the dependencies of
+	// a plugin of a profile.
+	result, err := AnalyzeProject(context.Background(), &gopom.Project{
+		Properties: makeProperties("asm.version", "9.5"),
+		Build: &gopom.Build{BuildBase: gopom.BuildBase{
+			Plugins: &[]gopom.Plugin{{ArtifactID: "maven-compiler-plugin", Dependencies: makeDeps(pomDep("org.ow2.asm", "asm", "9.4"))}},
+			PluginManagement: &gopom.PluginManagement{Plugins: &[]gopom.Plugin{{
+				ArtifactID:   "maven-shade-plugin",
+				Dependencies: makeDeps(pomDep("org.ow2.asm", "asm-commons", "${asm.version}")),
+			}}},
+		}},
+		Profiles: &[]gopom.Profile{{
+			ID:    "native",
+			Build: &gopom.BuildBase{Plugins: &[]gopom.Plugin{{ArtifactID: "native-maven-plugin", Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "4.1.94.Final"))}}},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"org.ow2.asm:asm":         "9.4",
+		"org.ow2.asm:asm-commons": "9.5",
+		"io.netty:netty-handler":  "4.1.94.Final",
+	}, result.CurrentVersions())
+
+	// A plugin dependency using a property is patched through it.
+	direct, props := PatchStrategy(context.Background(), result, []Patch{
+		{GroupID: "org.ow2.asm", ArtifactID: "asm-commons", Version: "9.7"},
+	})
+	assert.Empty(t, direct)
+	assert.Equal(t, map[string]string{"asm.version": "9.7"}, props)
+}
@@ -0,0 +1,374 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// ErrRemoteAccessDisabled is returned by an Analyzer created
+// WithoutRemoteAccess when something can only be done by contacting the
+// Maven repository or OSV, such as resolving @latest or an advisory.
+var ErrRemoteAccessDisabled = errors.New("remote access is disabled")
+
+// Analyzer analyzes POMs (and Gradle build scripts) and recommends how to
+// patch them, as the pombump command line does, for Go programs that embed
+// pombump. Its configuration is set once with AnalyzerOptions and an
+// Analyzer is safe for concurrent use.
+type Analyzer struct {
+	repo             *MavenRepository
+	osv              *OSVResolver
+	ghsa             *GHSAResolver
+	ghsaSet          bool
+	remote           bool
+	depth            int
+	resolveBOMs      bool
+	searchProperties bool
+	filter           *PathFilter
+	verifyVersions   bool
+	force            bool
+	conflictPolicy   ConflictPolicy
+	suppressions     Suppressions
+	analyzeOpts      []AnalyzeOption
+	strategyOpts     []PatchStrategyOption
+}
+
+// AnalyzerOption configures an Analyzer.
+type AnalyzerOption func(*Analyzer)
+
+// WithRepository sets the Maven repository an Analyzer resolves versions,
+// BOMs and parents from. It defaults to Maven Central.
+func WithRepository(repo *MavenRepository) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.repo = repo
+	}
+}
+
+// WithOSVResolver sets how an Analyzer resolves advisory identifiers given
+// as versions. It defaults to OSV, cached in the user cache directory.
+func WithOSVResolver(resolver *OSVResolver) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.osv = resolver
+	}
+}
+
+// WithGHSAResolver sets how an Analyzer resolves GHSA identifiers given as
+// versions, falling back to OSV. It defaults to the GitHub Advisory Database,
+// cached in the user cache directory, and nil resolves them with OSV only.
+func WithGHSAResolver(resolver *GHSAResolver) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.ghsa, a.ghsaSet = resolver, true
+	}
+}
+
+// WithoutRemoteAccess keeps an Analyzer from contacting the Maven repository
+// or OSV: BOMs are not resolved, and patches whose version needs resolving
+// are rejected with ErrRemoteAccessDisabled.
+func WithoutRemoteAccess() AnalyzerOption {
+	return func(a *Analyzer) {
+		a.remote = false
+	}
+}
+
+// WithResolutionDepth bounds how many parents, and levels of BOM imports, an
+// Analyzer follows when resolving what a POM inherits. It defaults to 10.
+func WithResolutionDepth(depth int) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.depth = depth
+	}
+}
+
+// WithResolvedBOMs makes an Analyzer fetch the BOMs and the parent a POM
+// imports to learn the versions they manage, see
+// AnalysisResult.ResolveBOMs.
+func WithResolvedBOMs() AnalyzerOption {
+	return func(a *Analyzer) {
+		a.resolveBOMs = true
+	}
+}
+
+// WithBOMConfig sets the patterns an Analyzer recognizes BOMs by, see
+// LoadBOMPatterns.
+func WithBOMConfig(patterns *BOMPatterns) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.analyzeOpts = append(a.analyzeOpts, WithBOMPatterns(patterns))
+	}
+}
+
+// WithPropertySearch makes an Analyzer look for the properties a POM uses
+// but does not define in the POMs around it, those filter does not skip, see
+// AnalyzeProjectPathWithFilter.
+func WithPropertySearch(filter *PathFilter) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.searchProperties = true
+		a.filter = filter
+	}
+}
+
+// WithVersionVerification makes an Analyzer check that the versions it
+// recommends are published, reporting those that are not as unfixable.
+func WithVersionVerification() AnalyzerOption {
+	return func(a *Analyzer) {
+		a.verifyVersions = true
+	}
+}
+
+// WithForce makes an Analyzer recommend the patches whose version is
+// already in effect too, instead of skipping them, see
+// AnalysisResult.SkipEffectivePatches.
+func WithForce() AnalyzerOption {
+	return func(a *Analyzer) {
+		a.force = true
+	}
+}
+
+// WithConflictPolicy sets how an Analyzer settles patches asking for
+// different versions of what one property or BOM controls. It defaults to
+// ConflictPolicyHighest.
+func WithConflictPolicy(policy ConflictPolicy) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.conflictPolicy = policy
+	}
+}
+
+// WithSuppressions leaves the warnings, issues and patches matching s out
+// of the analyses and recommendations of an Analyzer, see
+// AnalysisResult.Suppress.
+func WithSuppressions(s Suppressions) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.suppressions = s
+	}
+}
+
+// WithAnalyzeOptions passes options to every analysis, e.g. WithJobs or
+// WithStreamingThreshold.
+func WithAnalyzeOptions(opts ...AnalyzeOption) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.analyzeOpts = append(a.analyzeOpts, opts...)
+	}
+}
+
+// WithPatchStrategyOptions passes options to PatchStrategy when
+// recommending, e.g. WithStrategy or WithMismatchSync.
+func WithPatchStrategyOptions(opts ...PatchStrategyOption) AnalyzerOption {
+	return func(a *Analyzer) {
+		a.strategyOpts = append(a.strategyOpts, opts...)
+	}
+}
+
+// NewAnalyzer returns an Analyzer configured by opts.
+func NewAnalyzer(opts ...AnalyzerOption) *Analyzer {
+	a := &Analyzer{remote: true, conflictPolicy: ConflictPolicyHighest}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.repo == nil {
+		a.repo = NewMavenRepository("")
+	}
+	if a.depth > 0 {
+		// A copy, not to change the repository of the caller.
+		repo := *a.repo
+		repo.MaxDepth = a.depth
+		a.repo = &repo
+	}
+	if a.osv == nil {
+		a.osv = NewOSVResolver("")
+	}
+	if !a.ghsaSet {
+		a.ghsa = NewGHSAResolver("")
+	}
+	return a
+}
+
+// Analyze analyzes the POM or Gradle build script at path.
+func (a *Analyzer) Analyze(ctx context.Context, path string) (*AnalysisResult, error) {
+	if IsGradleBuild(path) {
+		build, err := LoadGradleBuild(path)
+		if err != nil {
+			return nil, err
+		}
+		result := AnalyzeGradle(ctx, build)
+		result.Suppress(a.suppressions)
+		return result, nil
+	}
+
+	var result *AnalysisResult
+	if a.searchProperties {
+		var err error
+		if result, err = AnalyzeProjectPathWithFilter(ctx, path, a.filter, a.analyzeOpts...); err != nil {
+			return nil, err
+		}
+	} else {
+		project, err := ParseAnalysisPOM(ctx, path, a.analyzeOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse POM file: %w", err)
+		}
+		if result, err = AnalyzeProject(ctx, project, a.analyzeOpts...); err != nil {
+			return nil, err
+		}
+	}
+	a.resolve(ctx, result)
+	return result, nil
+}
+
+// AnalyzeReactor analyzes every module of the reactor rootPOM is the root
+// of together, see DiscoverModules and AnalyzeReactor.
+func (a *Analyzer) AnalyzeReactor(ctx context.Context, rootPOM string) (*AnalysisResult, error) {
+	modules, err := DiscoverModules(ctx, rootPOM, a.analyzeOpts...)
+	if err != nil {
+		return nil, err
+	}
+	result, err := AnalyzeReactor(ctx, modules, a.analyzeOpts...)
+	if err != nil {
+		return nil, err
+	}
+	a.resolve(ctx, result)
+	return result, nil
+}
+
+// resolve resolves the BOMs of result, if the Analyzer is to, and applies
+// its suppressions.
+func (a *Analyzer) resolve(ctx context.Context, result *AnalysisResult) {
+	result.Suppress(a.suppressions)
+	if a.resolveBOMs && a.remote {
+		result.ResolveBOMs(ctx, a.repo)
+	}
+}
+
+// Recommendation is how an Analyzer recommends patching a project.
+type Recommendation struct {
+	// Patches are the requested patches with their versions resolved and
+	// their conflicts settled.
+	Patches []Patch
+	// DirectPatches and PropertyPatches are how Patches are best applied,
+	// see PatchStrategy.
+	DirectPatches   []Patch
+	PropertyPatches map[string]string
+	// BOMBumps are the BOM version bumps among them.
+	BOMBumps []BOMBump
+	// Conflicts are the conflicts between the patches that were settled.
+	Conflicts []VersionConflict
+	// Candidates are the versions picked for patches listing several.
+	Candidates []CandidateChoice
+	// Unfixable are the patches whose version is not published, with
+	// WithVersionVerification.
+	Unfixable []UnfixableIssue
+	// Suppressed are the patches, conflicts, unfixable issues and BOM bump
+	// warnings left out by the suppressions of the analysis.
+	Suppressed []SuppressedIssue
+	// Skipped are the patches and property updates the strategy hooks,
+	// such as StrategyRules, dropped due to policy, and the patches whose
+	// version is already in effect.
+	Skipped []SkippedPatch
+}
+
+// Recommend works out how to apply patches to the project analysis is of:
+// it resolves their symbolic versions (advisories, @latest, candidates),
+// settles their conflicts and picks, for each, whether to bump a property or
+// the dependency itself.
+func (a *Analyzer) Recommend(ctx context.Context, analysis *AnalysisResult, patches []Patch) (*Recommendation, error) {
+	rec := &Recommendation{}
+	patches, rec.Suppressed = analysis.suppressions.Patches(patches)
+	patches, rec.Skipped = analysis.SkipScopedPatches(patches)
+	var suppressed []SuppressedIssue
+	var err error
+	if patches, rec.Candidates, err = a.ResolveVersions(ctx, patches, analysis.CurrentVersions()); err != nil {
+		return nil, err
+	}
+	if !a.force {
+		var effective []SkippedPatch
+		patches, effective = analysis.SkipEffectivePatches(patches)
+		rec.Skipped = append(rec.Skipped, effective...)
+	}
+	if a.verifyVersions && a.remote {
+		if patches, rec.Unfixable, err = VerifyVersions(ctx, a.repo, patches); err != nil {
+			return nil, err
+		}
+		rec.Unfixable, suppressed = analysis.suppressions.Unfixable(rec.Unfixable)
+		rec.Suppressed = append(rec.Suppressed, suppressed...)
+	}
+	if rec.Patches, rec.Conflicts, err = ResolveVersionConflicts(ctx, analysis, patches, a.conflictPolicy); err != nil {
+		return nil, err
+	}
+	rec.Conflicts, suppressed = analysis.suppressions.Conflicts(rec.Conflicts)
+	rec.Suppressed = append(rec.Suppressed, suppressed...)
+	rec.DirectPatches, rec.PropertyPatches = PatchStrategy(ctx, analysis, rec.Patches, append(slices.Clip(a.strategyOpts), WithSkippedPatches(&rec.Skipped))...)
+
+	// A BOM bump may not bring every artifact it manages up to the
+	// requested version, check them against the new BOM.
+	if a.remote {
+		for _, bump := range FindBOMBumps(analysis, rec.DirectPatches, rec.PropertyPatches) {
+			if err := CheckBOMBump(ctx, analysis, a.repo, &bump, rec.Patches); err != nil {
+				clog.FromContext(ctx).Warnf("Unable to check the BOM bump: %v", err)
+				continue
+			}
+			bump.Warnings, suppressed = analysis.suppressions.Warnings(bump.Warnings)
+			rec.Suppressed = append(rec.Suppressed, suppressed...)
+			rec.BOMBumps = append(rec.BOMBumps, bump)
+		}
+	}
+	return rec, nil
+}
+
+// ResolveVersions turns the symbolic versions patches may carry (advisory
+// identifiers, @latest, @latest-patch, candidate lists and minimums) into
+// actual versions, and returns which candidates were picked. current maps
+// groupId:artifactId to the version in use, see
+// AnalysisResult.CurrentVersions.
+func (a *Analyzer) ResolveVersions(ctx context.Context, patches []Patch, current map[string]string) ([]Patch, []CandidateChoice, error) {
+	if !a.remote {
+		for _, p := range patches {
+			if IsAdvisoryID(p.Version) || IsVersionKeyword(p.Version) || IsVersionCandidates(p.Version) {
+				return nil, nil, fmt.Errorf("failed to resolve version %q of %s:%s: %w", p.Version, p.GroupID, p.ArtifactID, ErrRemoteAccessDisabled)
+			}
+		}
+		return patches, []CandidateChoice{}, nil
+	}
+	patches, err := ResolveAdvisories(ctx, AdvisoryResolvers{OSV: a.osv, GitHub: a.ghsa}, patches, current)
+	if err != nil {
+		return nil, nil, err
+	}
+	if patches, err = ResolveVersionKeywords(ctx, a.repo, patches, current); err != nil {
+		return nil, nil, err
+	}
+	return ResolveVersionCandidates(ctx, a.repo, patches, current)
+}
+
+// Patch applies the patches and property patches to the POM at path, and
+// returns the patched POM, in the format of the POM, see RestorePOMFormat.
+// It fails if the patched POM does not follow the Maven 4.0.0 schema where
+// the POM did, see CheckPOMSchema.
+func (a *Analyzer) Patch(ctx context.Context, path string, patches []Patch, propertyPatches map[string]string) ([]byte, error) {
+	project, err := parsePOM(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse POM file: %w", err)
+	}
+	maven4, err := ReadMaven4Model(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := PatchProject(ctx, project, patches, propertyPatches)
+	if err != nil {
+		return nil, err
+	}
+	out, err := MarshalPOM(patched, maven4)
+	if err != nil {
+		return nil, err
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading file: %w", err)
+	}
+	out, err = RestorePOMFormat(original, out)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckPOMSchema(original, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
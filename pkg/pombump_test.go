@@ -0,0 +1,119 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const analyzerPOM = `<project>
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0</version>
+  <properties>
+    <netty.version>4.1.94.Final</netty.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-bom</artifactId>
+        <version>4.1.94.Final</version>
+        <type>pom</type>
+        <scope>import</scope>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-handler</artifactId>
+      <version>${netty.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-codec</artifactId>
+      <version>${netty.version}</version>
+    </dependency>
+  </dependencies>
+</project>`
+
+// analyzerRepository serves netty-bom 4.1.94.Final, which has a parent, and
+// the releases of netty-handler.
+func analyzerRepository(t *testing.T) *MavenRepository {
+	bom := nettyBOM("4.1.94.Final", map[string]string{"netty-handler": "4.1.94.Final"})
+	bom = bom[:len("<project>")] + `
+  <parent><groupId>io.netty</groupId><artifactId>netty-parent</artifactId><version>1</version></parent>` + bom[len("<project>"):]
+	return fakeRepository(t, map[string]string{
+		"io/netty/netty-bom/4.1.94.Final/netty-bom-4.1.94.Final.pom": bom,
+		"io/netty/netty-parent/1/netty-parent-1.pom":                 `<project><groupId>io.netty</groupId><artifactId>netty-parent</artifactId><version>1</version></project>`,
+		"io/netty/netty-handler/maven-metadata.xml":                  nettyMetadata,
+	})
+}
+
+func writeAnalyzerPOM(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "pom.xml")
+	require.NoError(t, os.WriteFile(path, []byte(analyzerPOM), 0644))
+	return path
+}
+
+func TestAnalyzer(t *testing.T) {
+	ctx := context.Background()
+	path := writeAnalyzerPOM(t)
+	repo := analyzerRepository(t)
+	analyzer := NewAnalyzer(WithRepository(repo), WithResolvedBOMs())
+
+	analysis, err := analyzer.Analyze(ctx, path)
+	require.NoError(t, err)
+	require.Len(t, analysis.BOMs(), 1)
+	assert.Equal(t, map[string]string{"io.netty:netty-handler": "4.1.94.Final"}, analysis.BOMs()[0].Managed)
+
+	rec, err := analyzer.Recommend(ctx, analysis, []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "latest-patch"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "4.1.100.Final", rec.Patches[0].Version)
+	assert.Empty(t, rec.DirectPatches)
+	assert.Equal(t, map[string]string{"netty.version": "4.1.100.Final"}, rec.PropertyPatches)
+
+	patched, err := analyzer.Patch(ctx, path, rec.DirectPatches, rec.PropertyPatches)
+	require.NoError(t, err)
+	assert.Contains(t, string(patched), "<netty.version>4.1.100.Final</netty.version>")
+}
+
+func TestAnalyzerResolutionDepth(t *testing.T) {
+	ctx := context.Background()
+	path := writeAnalyzerPOM(t)
+	repo := analyzerRepository(t)
+
+	// The BOM has a parent, one level more than allowed.
+	analysis, err := NewAnalyzer(WithRepository(repo), WithResolvedBOMs(), WithResolutionDepth(1)).Analyze(ctx, path)
+	require.NoError(t, err)
+	assert.Nil(t, analysis.BOMs()[0].Managed)
+	assert.Zero(t, repo.MaxDepth)
+
+	analysis, err = NewAnalyzer(WithRepository(repo), WithResolvedBOMs(), WithResolutionDepth(2)).Analyze(ctx, path)
+	require.NoError(t, err)
+	assert.NotNil(t, analysis.BOMs()[0].Managed)
+}
+
+func TestAnalyzerWithoutRemoteAccess(t *testing.T) {
+	ctx := context.Background()
+	path := writeAnalyzerPOM(t)
+	analyzer := NewAnalyzer(WithRepository(analyzerRepository(t)), WithResolvedBOMs(), WithoutRemoteAccess())
+
+	analysis, err := analyzer.Analyze(ctx, path)
+	require.NoError(t, err)
+	assert.Nil(t, analysis.BOMs()[0].Managed)
+
+	_, err = analyzer.Recommend(ctx, analysis, []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "latest"}})
+	assert.ErrorIs(t, err, ErrRemoteAccessDisabled)
+
+	rec, err := analyzer.Recommend(ctx, analysis, []Patch{{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "4.1.118.Final"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"netty.version": "4.1.118.Final"}, rec.PropertyPatches)
+}
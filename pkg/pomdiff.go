@@ -0,0 +1,127 @@
+package pkg
+
+import (
+	"fmt"
+
+	"github.com/chainguard-dev/gopom"
+)
+
+// Kinds of POMChange.
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// POMChange is a dependency, property, BOM or parent that differs between
+// two POMs. Old is empty if it was added, New if it was removed.
+type POMChange struct {
+	// Name is groupId:artifactId, or the property.
+	Name   string `json:"name" yaml:"name"`
+	Change string `json:"change" yaml:"change"`
+	Old    string `json:"old,omitempty" yaml:"old,omitempty"`
+	New    string `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+// POMDiff is what differs between two POMs, say two upstream releases: the
+// versions are those in effect, with the properties resolved.
+type POMDiff struct {
+	// Old and New are the groupId:artifactId:version of the POMs.
+	Old string `json:"old" yaml:"old"`
+	New string `json:"new" yaml:"new"`
+	// Parent has a removed and an added entry when the parent changed
+	// coordinates.
+	Parent       []POMChange `json:"parent" yaml:"parent"`
+	Dependencies []POMChange `json:"dependencies" yaml:"dependencies"`
+	Properties   []POMChange `json:"properties" yaml:"properties"`
+	BOMs         []POMChange `json:"boms" yaml:"boms"`
+}
+
+// Empty reports whether the POMs do not differ.
+func (d *POMDiff) Empty() bool {
+	return len(d.Parent) == 0 && len(d.Dependencies) == 0 && len(d.Properties) == 0 && len(d.BOMs) == 0
+}
+
+// DiffPOMs compares the analyses of two POMs: their parent, the dependencies
+// they declare (in dependencies and dependencyManagement), their properties
+// and the BOMs they import.
+func DiffPOMs(before, after *AnalysisResult) *POMDiff {
+	return &POMDiff{
+		Old:          diffCoordinates(before.project),
+		New:          diffCoordinates(after.project),
+		Parent:       diffEntries(parentEntries(before.project), parentEntries(after.project)),
+		Dependencies: diffEntries(dependencyEntries(before), dependencyEntries(after)),
+		Properties:   diffEntries(before.Properties, after.Properties),
+		BOMs:         diffEntries(bomEntries(before), bomEntries(after)),
+	}
+}
+
+// diffCoordinates returns the groupId:artifactId:version of project.
+func diffCoordinates(project *gopom.Project) string {
+	if project == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%s:%s", projectGroupID(project), project.ArtifactID, projectVersion(project))
+}
+
+// parentEntries maps the groupId:artifactId of the parent of project, if
+// any, to its version.
+func parentEntries(project *gopom.Project) map[string]string {
+	if project == nil || project.Parent == nil {
+		return nil
+	}
+	return map[string]string{fmt.Sprintf("%s:%s", project.Parent.GroupID, project.Parent.ArtifactID): project.Parent.Version}
+}
+
+// dependencyEntries maps the dependencies of result, but the BOMs, to the
+// version in effect, or the declared one if it is not known.
+func dependencyEntries(result *AnalysisResult) map[string]string {
+	boms := bomEntries(result)
+	versions := result.CurrentVersions()
+	entries := map[string]string{}
+	for key, dep := range result.Dependencies {
+		if _, ok := boms[key]; ok {
+			continue
+		}
+		entries[key] = dep.Version
+		if version, ok := versions[key]; ok {
+			entries[key] = version
+		}
+	}
+	return entries
+}
+
+// bomEntries maps the BOMs result imports to their version.
+func bomEntries(result *AnalysisResult) map[string]string {
+	entries := map[string]string{}
+	for _, bom := range result.BOMs() {
+		entries[fmt.Sprintf("%s:%s", bom.GroupID, bom.ArtifactID)] = interpolate(bom.Version, result.Properties)
+	}
+	return entries
+}
+
+// diffEntries returns the entries added, removed or changed from before to
+// after, sorted by name.
+func diffEntries(before, after map[string]string) []POMChange {
+	changes := []POMChange{}
+	names := map[string]bool{}
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+	for _, name := range sortedKeys(names) {
+		oldValue, inBefore := before[name]
+		newValue, inAfter := after[name]
+		switch {
+		case !inBefore:
+			changes = append(changes, POMChange{Name: name, Change: ChangeAdded, New: newValue})
+		case !inAfter:
+			changes = append(changes, POMChange{Name: name, Change: ChangeRemoved, Old: oldValue})
+		case oldValue != newValue:
+			changes = append(changes, POMChange{Name: name, Change: ChangeChanged, Old: oldValue, New: newValue})
+		}
+	}
+	return changes
+}
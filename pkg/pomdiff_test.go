@@ -0,0 +1,48 @@
+package pkg
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffPOMs(t *testing.T) {
+	ctx := context.Background()
+	analyze := func(pom string) *AnalysisResult {
+		project, err := parsePOMData([]byte(pom))
+		require.NoError(t, err)
+		analysis, err := AnalyzeProject(ctx, project)
+		require.NoError(t, err)
+		return analysis
+	}
+	before := analyze(analyzerPOM)
+	after := analyze(strings.NewReplacer(
+		"<version>1.0</version>", "<version>1.1</version>",
+		"<netty.version>4.1.94.Final</netty.version>", "<netty.version>4.1.100.Final</netty.version><jackson.version>2.17.0</jackson.version>",
+		"<groupId>io.netty</groupId>\n      <artifactId>netty-codec</artifactId>\n      <version>${netty.version}</version>",
+		"<groupId>com.fasterxml.jackson.core</groupId>\n      <artifactId>jackson-databind</artifactId>\n      <version>${jackson.version}</version>",
+	).Replace(analyzerPOM))
+
+	diff := DiffPOMs(before, after)
+	assert.Equal(t, &POMDiff{
+		Old:    "com.example:app:1.0",
+		New:    "com.example:app:1.1",
+		Parent: []POMChange{},
+		Dependencies: []POMChange{
+			{Name: "com.fasterxml.jackson.core:jackson-databind", Change: ChangeAdded, New: "2.17.0"},
+			{Name: "io.netty:netty-codec", Change: ChangeRemoved, Old: "4.1.94.Final"},
+			{Name: "io.netty:netty-handler", Change: ChangeChanged, Old: "4.1.94.Final", New: "4.1.100.Final"},
+		},
+		Properties: []POMChange{
+			{Name: "jackson.version", Change: ChangeAdded, New: "2.17.0"},
+			{Name: "netty.version", Change: ChangeChanged, Old: "4.1.94.Final", New: "4.1.100.Final"},
+		},
+		BOMs: []POMChange{},
+	}, diff)
+	assert.False(t, diff.Empty())
+
+	assert.True(t, DiffPOMs(before, analyze(analyzerPOM)).Empty())
+}
@@ -0,0 +1,59 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+)
+
+// RestorePOMProlog gives out, a POM marshalled from original, the XML
+// declaration, comments and <project> start tag of original, byte for byte.
+// gopom writes its own: it always adds a declaration, drops the comments,
+// names the XMLSchema-instance namespace xsi whatever its prefix was, and
+// loses the schema location when a project is marshalled twice. pombump never
+// edits these, so they are put back as they were. out is returned as it is
+// when either POM has no <project> root without a prefix, which gopom cannot
+// write back anyway.
+//
+// The result is UTF-8 like out, even if the declaration of original names
+// another encoding: EncodePOM converts it to that encoding.
+func RestorePOMProlog(original, out []byte) []byte {
+	original, err := DecodePOM(original)
+	if err != nil {
+		return out
+	}
+	originalEnd, ok := projectStartTagEnd(original)
+	if !ok {
+		return out
+	}
+	outEnd, ok := projectStartTagEnd(out)
+	if !ok {
+		return out
+	}
+	restored := make([]byte, 0, originalEnd+len(out)-outEnd)
+	restored = append(restored, original[:originalEnd]...)
+	return append(restored, out[outEnd:]...)
+}
+
+// projectStartTagEnd returns the offset in data just past the start tag of
+// its root element, if that is <project> without a namespace prefix. data
+// is UTF-8, whatever its declaration says.
+func projectStartTagEnd(data []byte) (int, bool) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	for {
+		// RawToken keeps the prefix in Name.Space, rather than the namespace.
+		token, err := decoder.RawToken()
+		if err != nil {
+			return 0, false
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if start.Name.Space != "" || start.Name.Local != "project" {
+				return 0, false
+			}
+			return int(decoder.InputOffset()), true
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// prologBody follows the <project> start tag of the POMs below.
+const prologBody = `
+  <modelVersion>4.0.0</modelVersion>
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0</version>
+  <properties>
+    <netty.version>4.1.94.Final</netty.version>
+  </properties>
+</project>
+`
+
+func TestRestorePOMProlog(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name   string
+		prolog string
+	}{{
+		name: "unusual xsi prefix",
+		prolog: `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0"
+         xmlns:schema="http://www.w3.org/2001/XMLSchema-instance"
+         schema:schemaLocation="http://maven.apache.org/POM/4.0.0 https://maven.apache.org/xsd/maven-4.0.0.xsd">`,
+	}, {
+		name: "attributes reordered",
+		prolog: `<?xml version="1.0"?>
+<project xsi:schemaLocation="http://maven.apache.org/POM/4.0.0 http://maven.apache.org/maven-v4_0_0.xsd" xmlns="http://maven.apache.org/POM/4.0.0" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">`,
+	}, {
+		name: "declaration with standalone and comments",
+		prolog: `<?xml version='1.0' encoding='utf-8' standalone="no"?>
+<!--
+  Licensed under the Apache License, Version 2.0.
+-->
+<project xmlns="http://maven.apache.org/POM/4.0.0" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://maven.apache.org/POM/4.0.0 http://maven.apache.org/xsd/maven-4.0.0.xsd">`,
+	}, {
+		name:   "no declaration",
+		prolog: `<project xmlns="http://maven.apache.org/POM/4.0.0">`,
+	}, {
+		name: "extra namespace",
+		prolog: `<project xmlns="http://maven.apache.org/POM/4.0.0" xmlns:pom="http://maven.apache.org/POM/4.0.0"
+    xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://maven.apache.org/POM/4.0.0 http://maven.apache.org/xsd/maven-4.0.0.xsd">`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			original := []byte(tc.prolog + prologBody)
+			project, err := parsePOMData(original)
+			require.NoError(t, err)
+			patched, err := PatchProject(ctx, project, nil, map[string]string{"netty.version": "4.1.100.Final"})
+			require.NoError(t, err)
+			out, err := MarshalPOM(patched, nil)
+			require.NoError(t, err)
+
+			restored := RestorePOMProlog(original, out)
+			assert.True(t, strings.HasPrefix(string(restored), tc.prolog), string(restored))
+			assert.Contains(t, string(restored), "<netty.version>4.1.100.Final</netty.version>")
+			assert.NoError(t, CheckPOMSchema(original, restored))
+			reparsed, err := parsePOMData(restored)
+			require.NoError(t, err)
+			assert.Equal(t, "4.1.100.Final", reparsed.Properties.Entries["netty.version"])
+		})
+	}
+}
+
+func TestRestorePOMPrologPrefixedRoot(t *testing.T) {
+	// gopom cannot write a prefixed root back, out is left alone.
+	original := []byte(`<pom:project xmlns:pom="http://maven.apache.org/POM/4.0.0"><pom:modelVersion>4.0.0</pom:modelVersion></pom:project>`)
+	out := []byte(`<?xml version="1.0" encoding="UTF-8"?>` + "\n<project>\n</project>")
+	assert.Equal(t, string(out), string(RestorePOMProlog(original, out)))
+	assert.Equal(t, string(out), string(RestorePOMProlog([]byte("not a POM"), out)))
+}
+
+func TestMarshalPOMTwice(t *testing.T) {
+	project, err := parsePOMData([]byte(`<project xmlns="http://maven.apache.org/POM/4.0.0" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://maven.apache.org/POM/4.0.0 http://maven.apache.org/xsd/maven-4.0.0.xsd">` + prologBody))
+	require.NoError(t, err)
+	first, err := MarshalPOM(project, nil)
+	require.NoError(t, err)
+	second, err := MarshalPOM(project, nil)
+	require.NoError(t, err)
+	assert.Equal(t, string(first), string(second))
+	assert.Contains(t, string(second), `xsi:schemaLocation="http://maven.apache.org/POM/4.0.0 http://maven.apache.org/xsd/maven-4.0.0.xsd"`)
+}
+
+func TestAnalyzerPatchKeepsProlog(t *testing.T) {
+	ctx := context.Background()
+	prolog := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<!-- keep me -->
+<project xmlns="http://maven.apache.org/POM/4.0.0"
+    xmlns:i="http://www.w3.org/2001/XMLSchema-instance"
+    i:schemaLocation="http://maven.apache.org/POM/4.0.0 http://maven.apache.org/xsd/maven-4.0.0.xsd">`
+	path := filepath.Join(t.TempDir(), "pom.xml")
+	require.NoError(t, os.WriteFile(path, []byte(prolog+prologBody), 0644))
+
+	patched, err := NewAnalyzer().Patch(ctx, path, nil, map[string]string{"netty.version": "4.1.100.Final"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(patched), prolog), string(patched))
+}
@@ -0,0 +1,75 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// PropertyChain follows name through the properties whose value is nothing
+// but a reference to another property, like <a.version>${b.version}</a.version>.
+// It returns the names followed, starting with name and ending with the one
+// holding the actual value, which is the one to bump, and that value with
+// any remaining references resolved. A reference to an undefined property
+// ends the chain at the last defined one. A cycle is an error.
+func (result *AnalysisResult) PropertyChain(name string) ([]string, string, error) {
+	chain := []string{name}
+	value := result.Properties[name]
+	for {
+		next, ok := propertyReference(value)
+		if !ok {
+			break
+		}
+		if slices.Contains(chain, next) {
+			return append(chain, next), "", fmt.Errorf("property cycle %s", strings.Join(append(chain, next), " -> "))
+		}
+		nextValue, defined := result.Properties[next]
+		if !defined {
+			break
+		}
+		chain = append(chain, next)
+		value = nextValue
+	}
+	return chain, interpolate(value, result.Properties), nil
+}
+
+// bumpedProperty returns the property to bump for a dependency using name:
+// the end of its chain, or name itself if the chain has a cycle.
+func (result *AnalysisResult) bumpedProperty(ctx context.Context, name string) string {
+	log := clog.FromContext(ctx)
+	chain, _, err := result.PropertyChain(name)
+	if err != nil {
+		log.Warnf("Not following %s: %v", name, err)
+		return name
+	}
+	if len(chain) > 1 {
+		log.Infof("Property %s resolves through %s, bumping %s", name, strings.Join(chain, " -> "), chain[len(chain)-1])
+	}
+	return chain[len(chain)-1]
+}
+
+// resolveDependencyProperties records the property chain of info, whether
+// its resolved version is a range and its package URL. It is run again once
+// properties of nearby POMs are merged.
+func (result *AnalysisResult) resolveDependencyProperties(ctx context.Context, info *DependencyInfo) {
+	log := clog.FromContext(ctx)
+	version := info.Version
+	info.PropertyChain = nil
+	if info.UsesProperty {
+		chain, value, err := result.PropertyChain(info.PropertyName)
+		if err != nil {
+			log.Warnf("Dependency %s:%s: %v", info.GroupID, info.ArtifactID, err)
+		} else if len(chain) > 1 {
+			info.PropertyChain = chain
+		}
+		version = value
+	}
+	info.VersionRange = isVersionRange(version)
+	if info.VersionRange {
+		log.Debugf("Dependency %s:%s uses a version range", info.GroupID, info.ArtifactID)
+	}
+	result.setPurl(info)
+}
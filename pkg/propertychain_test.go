@@ -0,0 +1,83 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropertyChain(t *testing.T) {
+	result := &AnalysisResult{Properties: map[string]string{
+		"a.version":     "${b.version}",
+		"b.version":     "${c.version}",
+		"c.version":     "1.0",
+		"external":      "${parent.version}",
+		"composite":     "${c.version}-jre",
+		"cycle.a":       "${cycle.b}",
+		"cycle.b":       "${cycle.a}",
+		"plain.version": "2.0",
+	}}
+	testCases := []struct {
+		name      string
+		wantChain []string
+		wantValue string
+		wantErr   bool
+	}{
+		{"a.version", []string{"a.version", "b.version", "c.version"}, "1.0", false},
+		{"plain.version", []string{"plain.version"}, "2.0", false},
+		{"external", []string{"external"}, "${parent.version}", false},
+		{"composite", []string{"composite"}, "1.0-jre", false},
+		{"cycle.a", []string{"cycle.a", "cycle.b", "cycle.a"}, "", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chain, value, err := result.PropertyChain(tc.name)
+			assert.Equal(t, tc.wantErr, err != nil, "err: %v", err)
+			assert.Equal(t, tc.wantChain, chain)
+			assert.Equal(t, tc.wantValue, value)
+		})
+	}
+}
+
+func TestPatchStrategyPropertyChain(t *testing.T) {
+	ctx := context.Background()
+	project := &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{
+			"netty.version":         "4.1.94.Final",
+			"netty-handler.version": "${netty.version}",
+			"cycle.a":               "${cycle.b}",
+			"cycle.b":               "${cycle.a}",
+		}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "${netty-handler.version}"},
+			{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "${netty.version}"},
+			{GroupID: "org.example", ArtifactID: "cyclic", Version: "${cycle.a}"},
+		},
+	}
+	analysis, err := AnalyzeProject(ctx, project)
+	require.NoError(t, err)
+	handler := analysis.Dependencies["io.netty:netty-handler"]
+	assert.Equal(t, []string{"netty-handler.version", "netty.version"}, handler.PropertyChain)
+	assert.Nil(t, analysis.Dependencies["org.example:cyclic"].PropertyChain)
+	assert.Equal(t, "4.1.94.Final", analysis.CurrentVersions()["io.netty:netty-handler"])
+
+	_, props := PatchStrategy(ctx, analysis, []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final"},
+		{GroupID: "org.example", ArtifactID: "cyclic", Version: "2.0"},
+	})
+	assert.Equal(t, map[string]string{"netty.version": "4.1.100.Final", "cycle.a": "2.0"}, props)
+
+	impact := analysis.PropertyImpact("netty.version", "4.1.100.Final")
+	require.Len(t, impact, 2)
+	assert.Equal(t, DependencyImpact{
+		GroupID:       "io.netty",
+		ArtifactID:    "netty-handler",
+		Version:       "${netty-handler.version}",
+		Before:        "4.1.94.Final",
+		After:         "4.1.100.Final",
+		PropertyChain: []string{"netty-handler.version", "netty.version"},
+	}, impact[1])
+}
@@ -0,0 +1,150 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+)
+
+// implicitPropertyPrefixes are properties Maven and common plugins read
+// without any ${} reference, like maven.compiler.release or
+// project.build.sourceEncoding. They are never reported as unused.
+var implicitPropertyPrefixes = []string{"maven.", "project.", "surefire.", "failsafe.", "jacoco.", "sonar.", "argLine", "skip"}
+
+// UnusedProperties returns the properties project defines that are referenced
+// nowhere, sorted: not by a dependency (see PropertyUsageCounts), a plugin,
+// any other part of the POM, another used property, or one of modules, the
+// POMs that may inherit from project. keep lists properties to leave out on
+// top of the implicit ones.
+func UnusedProperties(ctx context.Context, project *gopom.Project, modules []*gopom.Project, keep []string) ([]string, error) {
+	log := clog.FromContext(ctx)
+	if project == nil {
+		return nil, fmt.Errorf("project is nil")
+	}
+	if project.Properties == nil || len(project.Properties.Entries) == 0 {
+		return []string{}, nil
+	}
+
+	analysis, err := AnalyzeProject(ctx, project, WithoutBOMDetection())
+	if err != nil {
+		return nil, err
+	}
+	used := map[string]bool{}
+	for name, count := range analysis.PropertyUsageCounts {
+		if count > 0 {
+			used[name] = true
+		}
+	}
+
+	// Everything but the properties themselves, which only count once the
+	// property defining them is used.
+	withoutProperties := *project
+	withoutProperties.Properties = nil
+	for _, p := range append([]*gopom.Project{&withoutProperties}, modules...) {
+		// Marshal moves the schema location around, work on a copy.
+		c := *p
+		out, err := c.Marshal()
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal POM: %w", err)
+		}
+		for _, name := range propertyReferences(string(out)) {
+			used[name] = true
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for name, value := range project.Properties.Entries {
+			if !used[name] {
+				continue
+			}
+			for _, ref := range propertyReferences(value) {
+				if !used[ref] {
+					used[ref], changed = true, true
+				}
+			}
+		}
+	}
+
+	unused := []string{}
+	for name := range project.Properties.Entries {
+		if used[name] || slices.Contains(keep, name) || isImplicitProperty(name) {
+			continue
+		}
+		unused = append(unused, name)
+	}
+	sort.Strings(unused)
+	log.Infof("Found %d unused properties of %d", len(unused), len(project.Properties.Entries))
+	return unused, nil
+}
+
+func isImplicitProperty(name string) bool {
+	if IsCIFriendlyProperty(name) {
+		// Defaults of the CI friendly versions, overridden with -D.
+		return true
+	}
+	for _, prefix := range implicitPropertyPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveProperties deletes names from the properties of project, dropping
+// the <properties> element once empty.
+func RemoveProperties(project *gopom.Project, names []string) {
+	if project.Properties == nil {
+		return
+	}
+	removed := map[string]bool{}
+	for _, name := range names {
+		removed[name] = true
+		delete(project.Properties.Entries, name)
+	}
+	project.Properties.Order = slices.DeleteFunc(project.Properties.Order, func(name string) bool {
+		return removed[name]
+	})
+	if len(project.Properties.Entries) == 0 {
+		project.Properties = nil
+	}
+}
+
+// WarningPropertyNotFound is a property dependencies use that the project
+// does not define, which an external parent POM may.
+const WarningPropertyNotFound = "property-not-found"
+
+// builtinPropertyPrefixes are the properties Maven provides itself, from the
+// model, the environment, the settings and the system properties.
+var builtinPropertyPrefixes = []string{"project.", "pom.", "env.", "settings.", "session.", "maven.", "java.", "os.", "user.", "basedir"}
+
+// UndefinedProperties warns about every property the dependencies use that
+// the project does not define, sorted by name, but those Maven provides and
+// the CIFriendlyProperties.
+// Each warning has the property as its only artifact.
+func (result *AnalysisResult) UndefinedProperties() []Warning {
+	warnings, _ := result.suppressions.Warnings(result.undefinedProperties())
+	return warnings
+}
+
+// undefinedProperties is UndefinedProperties, suppressed ones included.
+func (result *AnalysisResult) undefinedProperties() []Warning {
+	result.ensureDependencies()
+	warnings := []Warning{}
+	for _, name := range sortedKeys(result.PropertyUsageCounts) {
+		if _, ok := result.Properties[name]; ok || IsCIFriendlyProperty(name) || slices.ContainsFunc(builtinPropertyPrefixes, func(prefix string) bool { return strings.HasPrefix(name, prefix) }) {
+			continue
+		}
+		warnings = append(warnings, Warning{
+			Code:      CodePropertyNotFound,
+			Kind:      WarningPropertyNotFound,
+			Message:   fmt.Sprintf("Property %s is used by %d dependencies but not defined, it may be in an external parent POM", name, result.PropertyUsageCounts[name]),
+			Artifacts: []VersionChange{{Name: name}},
+		})
+	}
+	return warnings
+}
@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnusedProperties(t *testing.T) {
+	module := &gopom.Project{Dependencies: &[]gopom.Dependency{
+		{GroupID: "org.example", ArtifactID: "lib", Version: "${module.version}"},
+	}}
+	project := &gopom.Project{
+		Properties: makeProperties(
+			"netty.version", "4.1.94.Final",
+			"netty-handler.version", "${netty.version}",
+			"plugin.version", "3.2.0",
+			"qualifier", "jre",
+			"unused.version", "1.0",
+			"only-by-unused.version", "2.0",
+			"chained-unused.version", "${only-by-unused.version}",
+			"module.version", "5.0",
+			"maven.compiler.release", "17",
+			"kept", "yes",
+		),
+		Dependencies: makeDeps(
+			pomDep("io.netty", "netty-handler", "${netty-handler.version}"),
+			pomDep("com.google.guava", "guava", "32.1.3-${qualifier}"),
+		),
+		Build: makeBuild(gopom.Plugin{GroupID: "org.apache.maven.plugins", ArtifactID: "maven-jar-plugin", Version: "${plugin.version}"}),
+	}
+	unused, err := UnusedProperties(context.Background(), project, []*gopom.Project{module}, []string{"kept"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chained-unused.version", "only-by-unused.version", "unused.version"}, unused)
+
+	// Without the module its property is unused too.
+	unused, err = UnusedProperties(context.Background(), project, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chained-unused.version", "kept", "module.version", "only-by-unused.version", "unused.version"}, unused)
+}
+
+func TestRemoveProperties(t *testing.T) {
+	project := &gopom.Project{
+		Properties:   makeProperties("netty.version", "4.1.94.Final", "unused.version", "1.0", "kept", "yes"),
+		Dependencies: makeDeps(pomDep("io.netty", "netty-handler", "${netty.version}")),
+	}
+	RemoveProperties(project, []string{"unused.version", "kept"})
+	assert.NotContains(t, project.Properties.Entries, "unused.version")
+	assert.NotContains(t, project.Properties.Order, "unused.version")
+	assert.NotContains(t, project.Properties.Order, "kept")
+	assert.Len(t, project.Properties.Order, len(project.Properties.Entries))
+
+	RemoveProperties(project, project.Properties.Order)
+	assert.Nil(t, project.Properties)
+}
@@ -0,0 +1,134 @@
+package pkg
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// purlPrefix starts the package URL of a Maven artifact, e.g.
+// pkg:maven/io.netty/netty-handler@4.1.94.Final.
+const purlPrefix = "pkg:maven/"
+
+// IsPurl reports whether s is a package URL (of any type).
+func IsPurl(s string) bool {
+	return strings.HasPrefix(s, "pkg:")
+}
+
+// ParsePurl parses the package URL of a Maven artifact,
+// pkg:maven/groupId/artifactId[@version][?type=...&classifier=...], into a
+// patch. The version is optional, scope and type are left empty unless the
+// type qualifier is given.
+func ParsePurl(purl string) (Patch, error) {
+	rest, ok := strings.CutPrefix(purl, purlPrefix)
+	if !ok {
+		return Patch{}, fmt.Errorf("invalid package URL %q, expected %sgroupId/artifactId@version", purl, purlPrefix)
+	}
+	// The subpath means nothing for Maven.
+	rest, _, _ = strings.Cut(rest, "#")
+	rest, query, _ := strings.Cut(rest, "?")
+	path, version, _ := strings.Cut(rest, "@")
+
+	groupID, artifactID, ok := strings.Cut(path, "/")
+	if !ok || groupID == "" || artifactID == "" || strings.Contains(artifactID, "/") {
+		return Patch{}, fmt.Errorf("invalid package URL %q, expected %sgroupId/artifactId@version", purl, purlPrefix)
+	}
+	p := Patch{}
+	var err error
+	for field, value := range map[*string]string{&p.GroupID: groupID, &p.ArtifactID: artifactID, &p.Version: version} {
+		if *field, err = url.PathUnescape(value); err != nil {
+			return Patch{}, fmt.Errorf("invalid package URL %q: %w", purl, err)
+		}
+	}
+	qualifiers, err := url.ParseQuery(query)
+	if err != nil {
+		return Patch{}, fmt.Errorf("invalid package URL %q: %w", purl, err)
+	}
+	p.Type = qualifiers.Get("type")
+	p.Classifier = qualifiers.Get("classifier")
+	return p, nil
+}
+
+// Purl returns the package URL of a Maven artifact. The version is left out
+// if empty, and the type if it is the default jar.
+func Purl(groupID, artifactID, version, depType, classifier string) string {
+	var purl strings.Builder
+	purl.WriteString(purlPrefix)
+	purl.WriteString(url.PathEscape(groupID))
+	purl.WriteString("/")
+	purl.WriteString(url.PathEscape(artifactID))
+	if version != "" {
+		purl.WriteString("@")
+		purl.WriteString(url.PathEscape(version))
+	}
+	qualifiers := []string{}
+	// Qualifiers are sorted by key.
+	if classifier != "" {
+		qualifiers = append(qualifiers, "classifier="+url.QueryEscape(classifier))
+	}
+	if depType != "" && !sameType(depType, defaultType) {
+		qualifiers = append(qualifiers, "type="+url.QueryEscape(depType))
+	}
+	if len(qualifiers) > 0 {
+		purl.WriteString("?")
+		purl.WriteString(strings.Join(qualifiers, "&"))
+	}
+	return purl.String()
+}
+
+// PackageURL returns the package URL of the artifact p patches to.
+func (p Patch) PackageURL() string {
+	return Purl(p.GroupID, p.ArtifactID, p.Version, p.Type, p.Classifier)
+}
+
+// applyPurl fills the coordinates of p from its Purl field, which must agree
+// with the ones set already.
+func applyPurl(p *Patch) error {
+	if p.Purl == "" {
+		return nil
+	}
+	parsed, err := ParsePurl(p.Purl)
+	if err != nil {
+		return err
+	}
+	for _, field := range []struct {
+		name          string
+		value, parsed string
+		set           *string
+	}{
+		{"groupId", p.GroupID, parsed.GroupID, &p.GroupID},
+		{"artifactId", p.ArtifactID, parsed.ArtifactID, &p.ArtifactID},
+		{"version", p.Version, parsed.Version, &p.Version},
+		{"type", p.Type, parsed.Type, &p.Type},
+		{"classifier", p.Classifier, parsed.Classifier, &p.Classifier},
+	} {
+		if field.parsed == "" {
+			continue
+		}
+		if field.value != "" && field.value != field.parsed {
+			return fmt.Errorf("patch %s: %s %q does not match the package URL", p.Purl, field.name, field.value)
+		}
+		*field.set = field.parsed
+	}
+	return nil
+}
+
+// setPurl sets the package URL of info, leaving the version out while it
+// is not resolved.
+func (result *AnalysisResult) setPurl(info *DependencyInfo) {
+	version := result.dependencyVersion(info)
+	if strings.Contains(version, "${") {
+		version = ""
+	}
+	info.Purl = Purl(info.GroupID, info.ArtifactID, version, info.depType, info.classifier)
+}
+
+// WithPurls returns a copy of patches with their Purl set, for output.
+func WithPurls(patches []Patch) []Patch {
+	withPurls := make([]Patch, 0, len(patches))
+	for _, p := range patches {
+		p.Purl = p.PackageURL()
+		withPurls = append(withPurls, p)
+	}
+	return withPurls
+}
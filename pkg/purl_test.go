@@ -0,0 +1,150 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePurl(t *testing.T) {
+	testCases := []struct {
+		name    string
+		purl    string
+		want    Patch
+		wantErr bool
+	}{{
+		name: "version",
+		purl: "pkg:maven/io.netty/netty-handler@4.1.118.Final",
+		want: Patch{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final"},
+	}, {
+		name: "no version",
+		purl: "pkg:maven/io.netty/netty-handler",
+		want: Patch{GroupID: "io.netty", ArtifactID: "netty-handler"},
+	}, {
+		name: "qualifiers",
+		purl: "pkg:maven/io.netty/netty-transport-native-epoll@4.1.118.Final?classifier=linux-x86_64&type=jar",
+		want: Patch{GroupID: "io.netty", ArtifactID: "netty-transport-native-epoll", Version: "4.1.118.Final", Type: "jar", Classifier: "linux-x86_64"},
+	}, {
+		name: "escaped range",
+		purl: "pkg:maven/g/a@%5B1.4.12%2C2.0.0%29",
+		want: Patch{GroupID: "g", ArtifactID: "a", Version: "[1.4.12,2.0.0)"},
+	}, {
+		name:    "not maven",
+		purl:    "pkg:npm/lodash@4.17.21",
+		wantErr: true,
+	}, {
+		name:    "no artifact",
+		purl:    "pkg:maven/io.netty@4.1.118.Final",
+		wantErr: true,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParsePurl(tc.purl)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestPurlRoundTrip(t *testing.T) {
+	for _, p := range []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final"},
+		{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.118.Final", Type: "pom"},
+		{GroupID: "io.netty", ArtifactID: "netty-transport-native-epoll", Version: "4.1.118.Final", Classifier: "linux-x86_64"},
+		{GroupID: "g", ArtifactID: "a", Version: "[1.4.12,2.0.0)"},
+	} {
+		got, err := ParsePurl(p.PackageURL())
+		require.NoError(t, err)
+		assert.Equal(t, p, got, p.PackageURL())
+	}
+	// The default jar type is left out.
+	assert.Equal(t, "pkg:maven/g/a@1.0", Purl("g", "a", "1.0", "jar", ""))
+}
+
+func TestParsePatchesPurl(t *testing.T) {
+	got, err := ParsePatches(context.Background(), "", "pkg:maven/io.netty/netty-handler@4.1.118.Final org.slf4j@slf4j-api@2.0.7")
+	require.NoError(t, err)
+	assert.Equal(t, []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final", Scope: defaultScope, Type: defaultType},
+		{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.7", Scope: defaultScope, Type: defaultType},
+	}, got)
+
+	_, err = ParsePatches(context.Background(), "", "pkg:maven/io.netty/netty-handler")
+	assert.Error(t, err, "a patch needs a version")
+
+	testCases := []struct {
+		name    string
+		content string
+		want    Patch
+		wantErr bool
+	}{{
+		name: "purl only",
+		content: `patches:
+  - purl: pkg:maven/io.netty/netty-handler@4.1.118.Final
+`,
+		want: Patch{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final", Scope: defaultScope, Type: defaultType, Purl: "pkg:maven/io.netty/netty-handler@4.1.118.Final"},
+	}, {
+		name: "purl and matching coordinates",
+		content: `patches:
+  - groupId: io.netty
+    artifactId: netty-handler
+    version: 4.1.118.Final
+    purl: pkg:maven/io.netty/netty-handler@4.1.118.Final
+`,
+		want: Patch{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final", Scope: defaultScope, Type: defaultType, Purl: "pkg:maven/io.netty/netty-handler@4.1.118.Final"},
+	}, {
+		name: "purl and other version",
+		content: `patches:
+  - groupId: io.netty
+    artifactId: netty-handler
+    version: 4.1.100.Final
+    purl: pkg:maven/io.netty/netty-handler@4.1.118.Final
+`,
+		wantErr: true,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "patches.yaml")
+			require.NoError(t, os.WriteFile(file, []byte(tc.content), 0644))
+			got, err := ParsePatches(context.Background(), file, "")
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []Patch{tc.want}, got)
+		})
+	}
+}
+
+func TestAnalyzeProjectPurls(t *testing.T) {
+	project := &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{"netty.version": "4.1.94.Final"}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "${netty.version}"},
+			{GroupID: "io.netty", ArtifactID: "netty-transport-native-epoll", Version: "${netty.version}", Classifier: "linux-x86_64"},
+			{GroupID: "org.slf4j", ArtifactID: "slf4j-api"},
+			{GroupID: "g", ArtifactID: "a", Version: "${undefined.version}"},
+		},
+		DependencyManagement: &gopom.DependencyManagement{Dependencies: &[]gopom.Dependency{
+			{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.7"},
+		}},
+	}
+	result, err := AnalyzeProject(context.Background(), project)
+	require.NoError(t, err)
+
+	assert.Equal(t, "pkg:maven/io.netty/netty-handler@4.1.94.Final", result.Dependencies["io.netty:netty-handler"].Purl)
+	assert.Equal(t, "pkg:maven/io.netty/netty-transport-native-epoll@4.1.94.Final?classifier=linux-x86_64", result.Dependencies["io.netty:netty-transport-native-epoll"].Purl)
+	// A managed version is resolved, an undefined property is left out.
+	assert.Equal(t, "pkg:maven/org.slf4j/slf4j-api@2.0.7", result.Dependencies["org.slf4j:slf4j-api"].Purl)
+	assert.Equal(t, "pkg:maven/g/a", result.Dependencies["g:a"].Purl)
+}
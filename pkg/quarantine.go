@@ -0,0 +1,165 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+	"github.com/ghodss/yaml"
+)
+
+// Reasons a change is considered risky enough to be quarantined.
+const (
+	RiskMajorBump       = "major version bump"
+	RiskBOMIntroduction = "introduces a BOM"
+	RiskParentChange    = "changes the parent"
+	RiskRemoval         = "removes a dependency"
+)
+
+// QuarantinedPatch is a dependency patch held back for human review.
+type QuarantinedPatch struct {
+	Patch
+	Current string `json:"current,omitempty" yaml:"current,omitempty"`
+	Reason  string `json:"reason" yaml:"reason"`
+}
+
+// QuarantinedProperty is a property patch held back for human review.
+type QuarantinedProperty struct {
+	PropertyPatch
+	Current string `json:"current,omitempty" yaml:"current,omitempty"`
+	Reason  string `json:"reason" yaml:"reason"`
+}
+
+// QuarantinePlan holds the risky changes of a plan, which are only applied
+// once a human confirms them.
+type QuarantinePlan struct {
+	Patches    []QuarantinedPatch    `json:"patches,omitempty" yaml:"patches,omitempty"`
+	Properties []QuarantinedProperty `json:"properties,omitempty" yaml:"properties,omitempty"`
+}
+
+// Empty reports whether nothing was quarantined.
+func (q *QuarantinePlan) Empty() bool {
+	return len(q.Patches) == 0 && len(q.Properties) == 0
+}
+
+// Skipped lists the quarantined changes as skipped patches.
+func (q *QuarantinePlan) Skipped() []SkippedPatch {
+	var skipped []SkippedPatch
+	for _, p := range q.Patches {
+		skipped = append(skipped, SkippedPatch{
+			Name: fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID), Current: p.Current, Version: p.Version,
+			Reason: "quarantined: " + p.Reason,
+		})
+	}
+	for _, p := range q.Properties {
+		skipped = append(skipped, SkippedPatch{Name: p.Property, Current: p.Current, Version: p.Value, Reason: "quarantined: " + p.Reason})
+	}
+	return skipped
+}
+
+// Changes returns the quarantined changes in the form PatchProject takes.
+func (q *QuarantinePlan) Changes() ([]Patch, map[string]string) {
+	patches := make([]Patch, 0, len(q.Patches))
+	for _, p := range q.Patches {
+		patches = append(patches, p.Patch)
+	}
+	properties := make(map[string]string, len(q.Properties))
+	for _, p := range q.Properties {
+		properties[p.Property] = p.Value
+	}
+	return patches, properties
+}
+
+// QuarantineRisky splits a plan for project into the changes that are safe
+// to apply automatically and the risky ones: major version bumps, BOM
+// introductions, parent changes and removals.
+func QuarantineRisky(ctx context.Context, analysis *AnalysisResult, project *gopom.Project, patches []Patch, propertyPatches map[string]string) ([]Patch, map[string]string, *QuarantinePlan) {
+	log := clog.FromContext(ctx)
+	current := analysis.CurrentVersions()
+	boms := map[string]bool{}
+	for _, bom := range analysis.BOMs() {
+		boms[fmt.Sprintf("%s:%s", bom.GroupID, bom.ArtifactID)] = true
+	}
+
+	plan := &QuarantinePlan{}
+	safePatches := []Patch{}
+	for _, p := range patches {
+		key := fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID)
+		quarantined := QuarantinedPatch{Patch: p, Current: current[key]}
+		if p.Operation == PatchOperationRemove {
+			quarantined.Reason = RiskRemoval
+		} else if parentPatch, found := FindParentPatch(project, []Patch{p}); found {
+			quarantined.Current = project.Parent.Version
+			if parentPatch.Version != project.Parent.Version {
+				quarantined.Reason = RiskParentChange
+			}
+		} else if p.Type == "pom" && p.Scope == "import" && !boms[key] {
+			quarantined.Reason = RiskBOMIntroduction
+		} else if isMajorBump(current[key], p.Version) {
+			quarantined.Reason = RiskMajorBump
+		}
+		if quarantined.Reason == "" {
+			safePatches = append(safePatches, p)
+			continue
+		}
+		log.Infof("Quarantining %s %s: %s", key, p.Version, quarantined.Reason)
+		plan.Patches = append(plan.Patches, quarantined)
+	}
+
+	safeProperties := map[string]string{}
+	names := make([]string, 0, len(propertyPatches))
+	for name := range propertyPatches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := propertyPatches[name]
+		if isMajorBump(analysis.Properties[name], value) {
+			log.Infof("Quarantining property %s %s: %s", name, value, RiskMajorBump)
+			plan.Properties = append(plan.Properties, QuarantinedProperty{
+				PropertyPatch: PropertyPatch{Property: name, Value: value},
+				Current:       analysis.Properties[name],
+				Reason:        RiskMajorBump,
+			})
+			continue
+		}
+		safeProperties[name] = value
+	}
+	return safePatches, safeProperties, plan
+}
+
+// isMajorBump reports whether going from current to version changes the
+// leading number of the version.
+func isMajorBump(current, version string) bool {
+	if current == "" || strings.Contains(current, "${") {
+		return false
+	}
+	from, to := numericPrefix(splitVersion(current), 1), numericPrefix(splitVersion(version), 1)
+	return len(from) == 1 && len(to) == 1 && to[0] > from[0]
+}
+
+// WriteQuarantinePlan writes plan to file as YAML.
+func WriteQuarantinePlan(file string, plan *QuarantinePlan) error {
+	data, err := yaml.Marshal(plan)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+// ReadQuarantinePlan reads a plan written by WriteQuarantinePlan.
+func ReadQuarantinePlan(file string) (*QuarantinePlan, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading file: %w", err)
+	}
+	var plan QuarantinePlan
+	if err := yaml.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse quarantine plan: %w", err)
+	}
+	return &plan, nil
+}
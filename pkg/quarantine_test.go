@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuarantineRisky(t *testing.T) {
+	ctx := context.Background()
+	project := &gopom.Project{
+		Parent: &gopom.Parent{GroupID: "org.springframework.boot", ArtifactID: "spring-boot-starter-parent", Version: "3.1.0"},
+		Properties: &gopom.Properties{Entries: map[string]string{
+			"jackson.version": "2.15.0",
+			"guava.version":   "31.1-jre",
+		}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.94.Final"},
+			{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "1.7.36"},
+		},
+	}
+	analysis, err := AnalyzeProject(ctx, project)
+	require.NoError(t, err)
+
+	patches := []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final"},
+		{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.10"},
+		{GroupID: "org.springframework.boot", ArtifactID: "spring-boot-starter-parent", Version: "3.1.5"},
+		{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.118.Final", Scope: "import", Type: "pom"},
+	}
+	properties := map[string]string{"jackson.version": "2.15.3", "guava.version": "32.1.3-jre"}
+
+	safePatches, safeProperties, plan := QuarantineRisky(ctx, analysis, project, patches, properties)
+	assert.Equal(t, []Patch{patches[0]}, safePatches)
+	assert.Equal(t, map[string]string{"jackson.version": "2.15.3"}, safeProperties)
+	assert.Equal(t, []QuarantinedPatch{
+		{Patch: patches[1], Current: "1.7.36", Reason: RiskMajorBump},
+		{Patch: patches[2], Current: "3.1.0", Reason: RiskParentChange},
+		{Patch: patches[3], Reason: RiskBOMIntroduction},
+	}, plan.Patches)
+	assert.Equal(t, []QuarantinedProperty{
+		{PropertyPatch: PropertyPatch{Property: "guava.version", Value: "32.1.3-jre"}, Current: "31.1-jre", Reason: RiskMajorBump},
+	}, plan.Properties)
+
+	file := filepath.Join(t.TempDir(), "quarantine.yaml")
+	require.NoError(t, WriteQuarantinePlan(file, plan))
+	read, err := ReadQuarantinePlan(file)
+	require.NoError(t, err)
+	assert.Equal(t, plan, read)
+
+	readPatches, readProperties := read.Changes()
+	assert.Equal(t, patches[1:], readPatches)
+	assert.Equal(t, map[string]string{"guava.version": "32.1.3-jre"}, readProperties)
+}
@@ -0,0 +1,294 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+)
+
+// Module is a POM that is part of a reactor build.
+type Module struct {
+	// Path is the path of the POM file, relative to the directory of the
+	// reactor root POM.
+	Path    string
+	Project *gopom.Project
+	// Maven4 holds what gopom does not model of a Maven 4 POM, nil for
+	// other POMs. See MarshalPOM.
+	Maven4 *Maven4Model
+
+	// file is the path of the POM file as found by DiscoverModules, for
+	// WithPositions.
+	file string
+}
+
+// ModuleResult is what was applied to a single module by PatchReactor.
+type ModuleResult struct {
+	Path       string            `json:"path" yaml:"path"`
+	Patches    []Patch           `json:"patches,omitempty" yaml:"patches,omitempty"`
+	Properties map[string]string `json:"properties,omitempty" yaml:"properties,omitempty"`
+	// Project is the patched project, nil if nothing was applied.
+	Project *gopom.Project `json:"-" yaml:"-"`
+	// Maven4 is that of the module, to write Project with MarshalPOM.
+	Maven4 *Maven4Model `json:"-" yaml:"-"`
+}
+
+// DiscoverModules parses the reactor root POM at rootPOM and, recursively,
+// every module listed in <modules> (including those only listed in a
+// profile). The root is always the first module returned. The modules are
+// discovered a level at a time, parsing the POMs of a level concurrently,
+// see WithJobs.
+func DiscoverModules(ctx context.Context, rootPOM string, opts ...AnalyzeOption) ([]Module, error) {
+	log := clog.FromContext(ctx)
+	options := &analyzeOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	rootDir := filepath.Dir(rootPOM)
+
+	type parsed struct {
+		project   *gopom.Project
+		parseErr  error
+		maven4    *Maven4Model
+		maven4Err error
+	}
+	modules := []Module{}
+	seen := map[string]bool{}
+	level := []string{rootPOM}
+	for len(level) > 0 {
+		paths := []string{}
+		for _, pomPath := range level {
+			absPath, err := filepath.Abs(pomPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get absolute path: %w", err)
+			}
+			if !seen[absPath] {
+				seen[absPath] = true
+				paths = append(paths, pomPath)
+			}
+		}
+
+		results := make([]parsed, len(paths))
+		forEach(options.jobs, len(paths), func(i int) {
+			r := &results[i]
+			if r.project, r.parseErr = parsePOM(ctx, paths[i]); r.parseErr == nil {
+				r.maven4, r.maven4Err = ReadMaven4Model(ctx, paths[i])
+			}
+		})
+
+		// The next level, in the order of this one, as a queue would have
+		// it.
+		level = nil
+		for i, pomPath := range paths {
+			r := results[i]
+			if r.parseErr != nil {
+				if len(modules) == 0 {
+					return nil, fmt.Errorf("failed to parse POM file: %w", r.parseErr)
+				}
+				log.Warnf("Skipping module %s: %v", pomPath, r.parseErr)
+				continue
+			}
+			if r.maven4Err != nil {
+				return nil, r.maven4Err
+			}
+			relPath, err := filepath.Rel(rootDir, pomPath)
+			if err != nil {
+				return nil, err
+			}
+			modules = append(modules, Module{Path: filepath.ToSlash(relPath), Project: r.project, Maven4: r.maven4, file: pomPath})
+
+			for _, name := range moduleNames(r.project, r.maven4) {
+				modulePath := filepath.Join(filepath.Dir(pomPath), filepath.FromSlash(name))
+				if !strings.HasSuffix(name, ".xml") {
+					modulePath = filepath.Join(modulePath, "pom.xml")
+				}
+				if _, err := os.Stat(modulePath); err != nil {
+					log.Warnf("Module %s listed in %s not found: %v", name, relPath, err)
+					continue
+				}
+				level = append(level, modulePath)
+			}
+		}
+	}
+	log.Infof("Discovered %d modules", len(modules))
+	return modules, nil
+}
+
+// moduleNames returns the modules of project, including those only listed
+// in a profile, and the subprojects of a Maven 4 POM.
+func moduleNames(project *gopom.Project, maven4 *Maven4Model) []string {
+	names := []string{}
+	if project.Modules != nil {
+		names = append(names, *project.Modules...)
+	}
+	if project.Profiles != nil {
+		for _, profile := range *project.Profiles {
+			if profile.Modules != nil {
+				names = append(names, *profile.Modules...)
+			}
+		}
+	}
+	if maven4 != nil {
+		names = append(names, maven4.Subprojects...)
+		for _, subprojects := range maven4.ProfileSubprojects {
+			names = append(names, subprojects...)
+		}
+	}
+	return names
+}
+
+// PatchReactor applies patches and propertyPatches across the modules of a
+// reactor, each to the module that actually declares it: a patch goes to
+// every module declaring the dependency (through a property of the module
+// defining that property, if it uses one), a property patch to every module
+// defining the property. Anything not declared anywhere goes to the root
+// module, the first one. A patch with a Module only goes to that module, its
+// properties set in the module itself so that they override any inherited
+// value. Only the modules something was applied to are returned, in the
+// order of modules.
+func PatchReactor(ctx context.Context, modules []Module, patches []Patch, propertyPatches map[string]string) ([]ModuleResult, error) {
+	log := clog.FromContext(ctx)
+	if len(modules) == 0 {
+		return nil, fmt.Errorf("no modules")
+	}
+
+	analyses := make([]*AnalysisResult, len(modules))
+	errs := make([]error, len(modules))
+	forEach(0, len(modules), func(i int) {
+		analyses[i], errs[i] = AnalyzeProject(ctx, modules[i].Project, WithoutBOMDetection())
+	})
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze %s: %w", modules[i].Path, err)
+		}
+	}
+
+	results := make([]ModuleResult, len(modules))
+	for i, m := range modules {
+		results[i] = ModuleResult{Path: m.Path, Properties: map[string]string{}, Maven4: m.Maven4}
+	}
+
+	// Properties go to every module that defines them.
+	addProperty := func(name, value string, fallback int) {
+		defined := false
+		for i, m := range modules {
+			if m.Project.Properties != nil {
+				if _, ok := m.Project.Properties.Entries[name]; ok {
+					results[i].Properties[name] = value
+					defined = true
+				}
+			}
+		}
+		if !defined {
+			results[fallback].Properties[name] = value
+		}
+	}
+
+	for _, p := range patches {
+		if p.Module != "" {
+			i, ok := findModule(modules, p.Module)
+			if !ok {
+				return nil, fmt.Errorf("the patch of %s:%s targets the module %s, which is not in the reactor", p.GroupID, p.ArtifactID, p.Module)
+			}
+			directPatches, props := PatchStrategy(ctx, analyses[i], []Patch{p})
+			results[i].Patches = append(results[i].Patches, directPatches...)
+			for name, value := range props {
+				results[i].Properties[name] = value
+			}
+			continue
+		}
+		if p.Operation == PatchOperationRemove {
+			// Removed from every module declaring it, with or without a
+			// version, and never added anywhere.
+			for i := range modules {
+				if _, ok := analyses[i].Dependencies[fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID)]; ok {
+					results[i].Patches = append(results[i].Patches, p)
+				}
+			}
+			continue
+		}
+		declared := false
+		for i, m := range modules {
+			// A dependency without a version is managed elsewhere, the
+			// module that has the version is the one to patch.
+			dep, inModule := analyses[i].Dependencies[fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID)]
+			_, isParent := FindParentPatch(m.Project, []Patch{p})
+			if !(inModule && dep.Version != "") && !isParent {
+				continue
+			}
+			declared = true
+			directPatches, props := PatchStrategy(ctx, analyses[i], []Patch{p})
+			results[i].Patches = append(results[i].Patches, directPatches...)
+			for name, value := range props {
+				addProperty(name, value, i)
+			}
+		}
+		if !declared && inferredParent(modules, p) {
+			log.Warnf("%s:%s is a parent whose version Maven 4 infers from the reactor, not patching it", p.GroupID, p.ArtifactID)
+			continue
+		}
+		if !declared {
+			log.Infof("%s:%s is not declared in any module, adding it to %s", p.GroupID, p.ArtifactID, modules[0].Path)
+			results[0].Patches = append(results[0].Patches, p)
+		}
+	}
+
+	names := make([]string, 0, len(propertyPatches))
+	for name := range propertyPatches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		addProperty(name, propertyPatches[name], 0)
+	}
+
+	applied := []ModuleResult{}
+	for i, m := range modules {
+		if len(results[i].Patches) == 0 && len(results[i].Properties) == 0 {
+			continue
+		}
+		log.Infof("Patching module %s: %d patches, %d properties", m.Path, len(results[i].Patches), len(results[i].Properties))
+		project, err := PatchProject(ctx, m.Project, results[i].Patches, results[i].Properties)
+		if err != nil {
+			return nil, fmt.Errorf("failed to patch %s: %w", m.Path, err)
+		}
+		results[i].Project = project
+		applied = append(applied, results[i])
+	}
+	return applied, nil
+}
+
+// findModule returns the index of the module target names: the path of its
+// POM or of its directory, e.g. ./services/api/, or its artifactId.
+func findModule(modules []Module, target string) (int, bool) {
+	target = path.Clean(filepath.ToSlash(strings.TrimSpace(target)))
+	for i, m := range modules {
+		if m.Path == target || path.Dir(m.Path) == target {
+			return i, true
+		}
+	}
+	for i, m := range modules {
+		if m.Project.ArtifactID == target {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// inferredParent reports whether p is for the parent of a module that leaves
+// its version out for Maven 4 to infer from the reactor.
+func inferredParent(modules []Module, p Patch) bool {
+	for _, m := range modules {
+		parent := m.Project.Parent
+		if IsMaven4(m.Project) && parent != nil && parent.Version == "" && parent.GroupID == p.GroupID && parent.ArtifactID == p.ArtifactID {
+			return true
+		}
+	}
+	return false
+}
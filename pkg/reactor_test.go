@@ -0,0 +1,259 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeReactor(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return filepath.Join(dir, "pom.xml")
+}
+
+func TestPatchReactor(t *testing.T) {
+	rootPOM := writeReactor(t, map[string]string{
+		"pom.xml": `<project>
+  <groupId>org.example</groupId>
+  <artifactId>root</artifactId>
+  <version>1.0</version>
+  <modules>
+    <module>core</module>
+    <module>missing</module>
+  </modules>
+  <profiles>
+    <profile>
+      <id>extra</id>
+      <modules><module>extra/pom.xml</module></modules>
+    </profile>
+  </profiles>
+  <properties>
+    <netty.version>4.1.94.Final</netty.version>
+  </properties>
+</project>`,
+		"core/pom.xml": `<project>
+  <artifactId>core</artifactId>
+  <dependencies>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-handler</artifactId>
+      <version>${netty.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>org.slf4j</groupId>
+      <artifactId>slf4j-api</artifactId>
+    </dependency>
+  </dependencies>
+</project>`,
+		"extra/pom.xml": `<project>
+  <artifactId>extra</artifactId>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>org.slf4j</groupId>
+        <artifactId>slf4j-api</artifactId>
+        <version>2.0.7</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`,
+	})
+
+	ctx := context.Background()
+	modules, err := DiscoverModules(ctx, rootPOM)
+	require.NoError(t, err)
+	paths := []string{}
+	for _, m := range modules {
+		paths = append(paths, m.Path)
+	}
+	assert.Equal(t, []string{"pom.xml", "core/pom.xml", "extra/pom.xml"}, paths)
+
+	patches := []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final"},
+		{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.10"},
+		{GroupID: "com.example", ArtifactID: "new", Version: "1.0"},
+	}
+	results, err := PatchReactor(ctx, modules, patches, map[string]string{"other.version": "2"})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "pom.xml", results[0].Path)
+	assert.Equal(t, []Patch{patches[2]}, results[0].Patches)
+	assert.Equal(t, map[string]string{"netty.version": "4.1.118.Final", "other.version": "2"}, results[0].Properties)
+	assert.Equal(t, "4.1.118.Final", results[0].Project.Properties.Entries["netty.version"])
+
+	assert.Equal(t, "extra/pom.xml", results[1].Path)
+	assert.Equal(t, []Patch{patches[1]}, results[1].Patches)
+	assert.Equal(t, "2.0.10", (*results[1].Project.DependencyManagement.Dependencies)[0].Version)
+}
+
+func TestAnalyzeReactor(t *testing.T) {
+	rootPOM := writeReactor(t, map[string]string{
+		"pom.xml": `<project>
+  <artifactId>root</artifactId>
+  <modules><module>core</module></modules>
+  <properties><netty.version>4.1.94.Final</netty.version></properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-bom</artifactId>
+        <version>${netty.version}</version>
+        <type>pom</type>
+        <scope>import</scope>
+      </dependency>
+      <dependency>
+        <groupId>org.slf4j</groupId>
+        <artifactId>slf4j-api</artifactId>
+        <version>2.0.7</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`,
+		"core/pom.xml": `<project>
+  <artifactId>core</artifactId>
+  <properties><netty.version>4.1.90.Final</netty.version></properties>
+  <dependencies>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-handler</artifactId>
+      <version>${netty.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>org.slf4j</groupId>
+      <artifactId>slf4j-api</artifactId>
+    </dependency>
+  </dependencies>
+</project>`,
+	})
+
+	ctx := context.Background()
+	modules, err := DiscoverModules(ctx, rootPOM)
+	require.NoError(t, err)
+	result, err := AnalyzeReactor(ctx, modules)
+	require.NoError(t, err)
+
+	assert.Len(t, result.Modules, 2)
+	assert.Equal(t, "4.1.94.Final", result.Properties["netty.version"])
+	assert.Equal(t, "2.0.7", result.Dependencies["org.slf4j:slf4j-api"].Version)
+	assert.Equal(t, 2, result.PropertyUsageCounts["netty.version"])
+	assert.Len(t, result.BOMs(), 1)
+
+	useProperty, name := result.ShouldUseProperty("io.netty", "netty-handler")
+	assert.True(t, useProperty)
+	assert.Equal(t, "netty.version", name)
+
+	report := result.AggregateReport()
+	assert.Equal(t, 3, report.Dependencies)
+	assert.Equal(t, 1, report.BOMs)
+	require.Len(t, report.Modules, 2)
+	assert.Equal(t, "core/pom.xml", report.Modules[0].Path)
+	assert.Equal(t, 1, report.Modules[0].DependenciesUsingProperties)
+	assert.Equal(t, "pom.xml", report.Modules[1].Path)
+	assert.Len(t, report.Modules[1].BOMs, 1)
+	assert.Contains(t, result.AnalysisReport(), "core/pom.xml: 2 dependencies (1 using properties)")
+}
+
+func TestDiscoverModulesConcurrently(t *testing.T) {
+	module := func(name string, modules ...string) string {
+		pom := "<project><artifactId>" + name + "</artifactId><modules>"
+		for _, m := range modules {
+			pom += "<module>" + m + "</module>"
+		}
+		return pom + "</modules></project>"
+	}
+	// b and c both list the shared module, which is discovered once, from
+	// b, as a queue would.
+	rootPOM := writeReactor(t, map[string]string{
+		"pom.xml":          module("root", "a", "b", "c"),
+		"a/pom.xml":        module("a", "a1", "a2"),
+		"a/a1/pom.xml":     module("a1"),
+		"a/a2/pom.xml":     module("a2"),
+		"b/pom.xml":        module("b", "../shared"),
+		"c/pom.xml":        module("c", "c1", "../shared"),
+		"c/c1/pom.xml":     module("c1"),
+		"shared/pom.xml":   module("shared"),
+		"broken/pom.xml":   "<project>",
+		"unlisted/pom.xml": module("unlisted"),
+	})
+
+	want := []string{"pom.xml", "a/pom.xml", "b/pom.xml", "c/pom.xml", "a/a1/pom.xml", "a/a2/pom.xml", "shared/pom.xml", "c/c1/pom.xml"}
+	for _, jobs := range []int{1, 4} {
+		modules, err := DiscoverModules(context.Background(), rootPOM, WithJobs(jobs))
+		require.NoError(t, err)
+		paths := []string{}
+		for _, m := range modules {
+			paths = append(paths, m.Path)
+		}
+		assert.Equal(t, want, paths, "jobs %d", jobs)
+
+		result, err := AnalyzeReactor(context.Background(), modules, WithJobs(jobs))
+		require.NoError(t, err)
+		assert.Len(t, result.Modules, len(want))
+	}
+}
+
+func TestPatchReactorModule(t *testing.T) {
+	rootPOM := writeReactor(t, map[string]string{
+		"pom.xml": `<project>
+  <artifactId>root</artifactId>
+  <modules><module>core</module><module>services/api</module></modules>
+  <properties><netty.version>4.1.94.Final</netty.version></properties>
+</project>`,
+		"core/pom.xml": `<project>
+  <artifactId>core</artifactId>
+  <dependencies>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-handler</artifactId>
+      <version>${netty.version}</version>
+    </dependency>
+  </dependencies>
+</project>`,
+		"services/api/pom.xml": `<project>
+  <artifactId>api</artifactId>
+  <dependencies>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-codec</artifactId>
+      <version>${netty.version}</version>
+    </dependency>
+  </dependencies>
+</project>`,
+	})
+	ctx := context.Background()
+	modules, err := DiscoverModules(ctx, rootPOM)
+	require.NoError(t, err)
+
+	patches := []Patch{
+		// The property is overridden in the module, not bumped in the root.
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final", Module: "./core/"},
+		// Not declared in the module, added to it rather than to the root.
+		{GroupID: "com.example", ArtifactID: "new", Version: "1.0", Module: "api"},
+	}
+	results, err := PatchReactor(ctx, modules, patches, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "core/pom.xml", results[0].Path)
+	assert.Empty(t, results[0].Patches)
+	assert.Equal(t, map[string]string{"netty.version": "4.1.118.Final"}, results[0].Properties)
+	assert.Equal(t, "4.1.118.Final", results[0].Project.Properties.Entries["netty.version"])
+
+	assert.Equal(t, "services/api/pom.xml", results[1].Path)
+	assert.Equal(t, []Patch{patches[1]}, results[1].Patches)
+	assert.Empty(t, results[1].Properties)
+
+	_, err = PatchReactor(ctx, modules, []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final", Module: "web"}}, nil)
+	assert.ErrorContains(t, err, "targets the module web, which is not in the reactor")
+}
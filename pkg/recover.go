@@ -0,0 +1,315 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+)
+
+// WarningMalformedPOM is a POM that is not well-formed XML, analyzed from
+// what WithLenientParsing could recover of it.
+const WarningMalformedPOM = "malformed-pom"
+
+// lenientParsing records the projects ParseAnalysisPOM recovered from
+// malformed POMs, for AnalyzeProject to warn about them.
+type lenientParsing struct {
+	mu        sync.Mutex
+	recovered map[*gopom.Project]Warning
+}
+
+// WithLenientParsing makes ParseAnalysisPOM fall back to RecoverPOM when a
+// POM is not well-formed XML, rather than fail, so that a slightly broken POM
+// can still be analyzed. AnalyzeProject, given the same option, reports such
+// a project with a malformed-pom warning, as what was recovered may be
+// incomplete.
+func WithLenientParsing() AnalyzeOption {
+	lenient := &lenientParsing{recovered: map[*gopom.Project]Warning{}}
+	return func(o *analyzeOptions) {
+		o.lenient = lenient
+	}
+}
+
+// recover recovers the POM at path, which failed to parse with parseErr. It
+// returns parseErr if nothing could be recovered.
+func (l *lenientParsing) recover(ctx context.Context, path string, parseErr error) (*gopom.Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, parseErr
+	}
+	project, err := RecoverPOM(data)
+	if err != nil {
+		return nil, parseErr
+	}
+	warning := Warning{
+		Code:    CodeMalformedPOM,
+		Kind:    WarningMalformedPOM,
+		Message: fmt.Sprintf("%s is not well-formed XML (%v), what could be recovered of it was analyzed and may be incomplete", path, parseErr),
+	}
+	clog.FromContext(ctx).Warnf("%s", warning.Message)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.recovered[project] = warning
+	return project, nil
+}
+
+// warnings returns the warning about project, if it was recovered.
+func (l *lenientParsing) warnings(project *gopom.Project) []Warning {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if warning, ok := l.recovered[project]; ok {
+		return []Warning{warning}
+	}
+	return nil
+}
+
+// RecoverPOM extracts what it can of a POM that is not well-formed XML: the
+// coordinates, the parent, the properties and the dependencies of the
+// project and of its dependencyManagement. Its tokenizer tolerates unclosed,
+// mismatched and stray tags, bare ampersands and unknown entities: an end tag
+// closes the elements left open since its start tag, and an element with a
+// value ends where the next one starts. It fails if there is no <project>.
+// The project must not be written back, as everything else is left out.
+func RecoverPOM(data []byte) (*gopom.Project, error) {
+	if decoded, err := DecodePOM(data); err == nil {
+		data = decoded
+	}
+	r := &pomRecovery{project: &gopom.Project{}}
+	s := string(data)
+	for s != "" {
+		start := strings.IndexByte(s, '<')
+		if start < 0 {
+			r.text(html.UnescapeString(s))
+			break
+		}
+		r.text(html.UnescapeString(s[:start]))
+		s = s[start:]
+		switch {
+		case strings.HasPrefix(s, "<!--"):
+			s = skipPast(s, "-->")
+		case strings.HasPrefix(s, "<![CDATA["):
+			end := strings.Index(s, "]]>")
+			if end < 0 {
+				end = len(s)
+			}
+			r.text(s[len("<![CDATA["):end])
+			s = skipPast(s, "]]>")
+		case strings.HasPrefix(s, "<?"), strings.HasPrefix(s, "<!"):
+			s = skipPast(s, ">")
+		default:
+			// A tag left unterminated ends where the next one starts.
+			end := strings.IndexAny(s[1:], "<>") + 1
+			if end == 0 {
+				end = len(s)
+			}
+			tag := s[1:end]
+			if end < len(s) && s[end] == '>' {
+				end++
+			}
+			s = s[end:]
+			r.tag(tag)
+		}
+	}
+	for len(r.stack) > 0 {
+		r.pop()
+	}
+	if !r.found {
+		return nil, errors.New("no project element")
+	}
+	return r.project, nil
+}
+
+// skipPast returns what follows the first end in s, or "" if there is none.
+func skipPast(s, end string) string {
+	i := strings.Index(s, end)
+	if i < 0 {
+		return ""
+	}
+	return s[i+len(end):]
+}
+
+// recoveredContainers are the elements RecoverPOM reads the children of, the
+// others only have a value.
+var recoveredContainers = map[string]bool{
+	"project":              true,
+	"parent":               true,
+	"properties":           true,
+	"dependencyManagement": true,
+	"dependencies":         true,
+	"dependency":           true,
+	"exclusions":           true,
+	"exclusion":            true,
+}
+
+// The paths of the dependencies RecoverPOM recovers.
+const (
+	recoveredDependency        = "project>dependencies>dependency"
+	recoveredManagedDependency = "project>dependencyManagement>dependencies>dependency"
+)
+
+// pomRecovery is the state of RecoverPOM: the elements open, with the value
+// read so far of each.
+type pomRecovery struct {
+	project    *gopom.Project
+	found      bool
+	stack      []string
+	values     []string
+	dependency *gopom.Dependency
+}
+
+// tag handles the start or end tag whose text, between < and >, is tag.
+func (r *pomRecovery) tag(tag string) {
+	tag = strings.TrimSpace(tag)
+	end, empty := strings.HasPrefix(tag, "/"), strings.HasSuffix(tag, "/")
+	name := strings.Trim(tag, "/")
+	if i := strings.IndexAny(name, " \t\r\n"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.LastIndexByte(name, ':'); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" {
+		return
+	}
+	if end {
+		for i := len(r.stack) - 1; i >= 0; i-- {
+			if r.stack[i] == name {
+				for len(r.stack) > i {
+					r.pop()
+				}
+				return
+			}
+		}
+		return
+	}
+	// An element with a value has no children: its end tag is missing.
+	if n := len(r.stack); n > 0 && !recoveredContainers[r.stack[n-1]] && strings.TrimSpace(r.values[n-1]) != "" {
+		r.pop()
+	}
+	r.push(name)
+	if empty {
+		r.pop()
+	}
+}
+
+// text adds s to the value of the element open.
+func (r *pomRecovery) text(s string) {
+	if n := len(r.stack); n > 0 {
+		r.values[n-1] += s
+	}
+}
+
+func (r *pomRecovery) push(name string) {
+	if len(r.stack) == 0 && name == "project" {
+		r.found = true
+	}
+	r.stack = append(r.stack, name)
+	r.values = append(r.values, "")
+	switch strings.Join(r.stack, ">") {
+	case "project>parent":
+		r.project.Parent = &gopom.Parent{}
+	case recoveredDependency, recoveredManagedDependency:
+		r.dependency = &gopom.Dependency{}
+	}
+}
+
+// pop closes the element open, recording its value.
+func (r *pomRecovery) pop() {
+	n := len(r.stack)
+	path, value := strings.Join(r.stack, ">"), strings.TrimSpace(r.values[n-1])
+	r.stack, r.values = r.stack[:n-1], r.values[:n-1]
+
+	p := r.project
+	switch path {
+	case "project>modelVersion":
+		p.ModelVersion = value
+	case "project>groupId":
+		p.GroupID = value
+	case "project>artifactId":
+		p.ArtifactID = value
+	case "project>version":
+		p.Version = value
+	case "project>packaging":
+		p.Packaging = value
+	case recoveredDependency:
+		if p.Dependencies == nil {
+			p.Dependencies = &[]gopom.Dependency{}
+		}
+		*p.Dependencies = append(*p.Dependencies, *r.dependency)
+		r.dependency = nil
+	case recoveredManagedDependency:
+		if p.DependencyManagement == nil {
+			p.DependencyManagement = &gopom.DependencyManagement{Dependencies: &[]gopom.Dependency{}}
+		}
+		*p.DependencyManagement.Dependencies = append(*p.DependencyManagement.Dependencies, *r.dependency)
+		r.dependency = nil
+	}
+	if field, ok := strings.CutPrefix(path, "project>parent>"); ok && p.Parent != nil {
+		switch field {
+		case "groupId":
+			p.Parent.GroupID = value
+		case "artifactId":
+			p.Parent.ArtifactID = value
+		case "version":
+			p.Parent.Version = value
+		case "relativePath":
+			p.Parent.RelativePath = value
+		}
+	}
+	if name, ok := strings.CutPrefix(path, "project>properties>"); ok && !strings.Contains(name, ">") {
+		if p.Properties == nil {
+			p.Properties = &gopom.Properties{Entries: map[string]string{}}
+		}
+		if _, exists := p.Properties.Entries[name]; !exists {
+			p.Properties.Order = append(p.Properties.Order, name)
+		}
+		p.Properties.Entries[name] = value
+	}
+	if r.dependency == nil {
+		return
+	}
+	for _, prefix := range []string{recoveredDependency + ">", recoveredManagedDependency + ">"} {
+		switch field, _ := strings.CutPrefix(path, prefix); field {
+		case "groupId":
+			r.dependency.GroupID = value
+		case "artifactId":
+			r.dependency.ArtifactID = value
+		case "version":
+			r.dependency.Version = value
+		case "type":
+			r.dependency.Type = value
+		case "classifier":
+			r.dependency.Classifier = value
+		case "scope":
+			r.dependency.Scope = value
+		case "optional":
+			r.dependency.Optional = value
+		}
+	}
+}
+
+// MalformedPOMs returns the warnings about the analyzed POMs that were not
+// well-formed XML, see WithLenientParsing, but the suppressed ones.
+func (result *AnalysisResult) MalformedPOMs() []Warning {
+	warnings, _ := result.suppressions.Warnings(result.malformed)
+	return warnings
+}
+
+// malformedReport warns, ahead of everything else, that the report is of
+// what could be recovered of malformed POMs.
+func (result *AnalysisResult) malformedReport() string {
+	var report strings.Builder
+	for _, warning := range result.MalformedPOMs() {
+		report.WriteString(fmt.Sprintf("WARNING: %s\n", FormatIssue(warning.Code, warning.Message)))
+	}
+	if report.Len() > 0 {
+		report.WriteString("\n")
+	}
+	return report.String()
+}
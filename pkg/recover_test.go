@@ -0,0 +1,143 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// brokenPOM has a bare ampersand, a property and a dependency left
+// unclosed, a mismatched end tag and a prefixed element.
+const brokenPOM = `<?xml version="1.0" encoding="UTF-8"?>
+<!-- <dependency> in a comment -->
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <modelVersion>4.0.0</modelVersion>
+  <parent>
+    <groupId>org.example</groupId>
+    <artifactId>parent</artifactId>
+    <version>3</version>
+  </parent>
+  <artifactId>broken</artifactId>
+  <name>Tom & Jerry</name>
+  <properties>
+    <netty.version>4.1.94.Final</netty.version>
+    <jackson.version>2.15.0
+    <description><![CDATA[a <b>bold</b> & odd value]]></description>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-bom</artifactId>
+        <version>${netty.version}</version>
+        <type>pom</type>
+        <scope>import</scope>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-handler</artifactId>
+    </dependency>
+    <dependency>
+      <groupId>com.fasterxml.jackson.core</groupId>
+      <artifactId>jackson-databind</artifactId>
+      <version>${jackson.version}</versoin>
+    </dependency>
+    <dependency>
+      <pom:groupId>org.slf4j</pom:groupId>
+      <artifactId>slf4j-api</artifactId>
+      <version>2.0.9
+  </dependencies>
+</project>
+`
+
+func TestRecoverPOM(t *testing.T) {
+	_, err := parsePOMData([]byte(brokenPOM))
+	require.Error(t, err)
+
+	project, err := RecoverPOM([]byte(brokenPOM))
+	require.NoError(t, err)
+	assert.Equal(t, "4.0.0", project.ModelVersion)
+	assert.Equal(t, "broken", project.ArtifactID)
+	assert.Equal(t, &gopom.Parent{GroupID: "org.example", ArtifactID: "parent", Version: "3"}, project.Parent)
+	assert.Equal(t, map[string]string{
+		"netty.version":   "4.1.94.Final",
+		"jackson.version": "2.15.0",
+		"description":     "a <b>bold</b> & odd value",
+	}, project.Properties.Entries)
+	assert.Equal(t, []string{"netty.version", "jackson.version", "description"}, project.Properties.Order)
+	assert.Equal(t, []gopom.Dependency{
+		{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "${netty.version}", Type: "pom", Scope: "import"},
+	}, *project.DependencyManagement.Dependencies)
+	assert.Equal(t, []gopom.Dependency{
+		{GroupID: "io.netty", ArtifactID: "netty-handler"},
+		{GroupID: "com.fasterxml.jackson.core", ArtifactID: "jackson-databind", Version: "${jackson.version}"},
+		{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.9"},
+	}, *project.Dependencies)
+
+	_, err = RecoverPOM([]byte("<settings><profiles/></settings>"))
+	assert.ErrorContains(t, err, "no project element")
+}
+
+func TestWithLenientParsing(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "pom.xml")
+	require.NoError(t, os.WriteFile(path, []byte(brokenPOM), 0644))
+
+	_, err := ParseAnalysisPOM(ctx, path)
+	require.Error(t, err)
+
+	lenient := WithLenientParsing()
+	project, err := ParseAnalysisPOM(ctx, path, lenient)
+	require.NoError(t, err)
+	analysis, err := AnalyzeProject(ctx, project, lenient)
+	require.NoError(t, err)
+	require.NoError(t, analysis.LocateDeclarations(path))
+	require.NoError(t, analysis.ValidateSchema(path))
+
+	assert.Equal(t, "${jackson.version}", analysis.Dependencies["com.fasterxml.jackson.core:jackson-databind"].Version)
+	assert.Equal(t, 1, analysis.PropertyUsageCounts["jackson.version"])
+	malformed := analysis.MalformedPOMs()
+	require.Len(t, malformed, 1)
+	assert.Equal(t, CodeMalformedPOM, malformed[0].Code)
+	assert.Contains(t, malformed[0].Message, path+" is not well-formed XML (XML syntax error on line 11")
+	assert.Contains(t, analysis.Warnings(), malformed[0])
+	report := analysis.AnalysisReport()
+	assert.True(t, strings.HasPrefix(report, "POM Analysis Report\n===================\n\nWARNING: [POMBUMP-W007] "), report)
+
+	// A well-formed POM is analyzed as it is, without a warning.
+	analysis, err = AnalyzeProject(ctx, &gopom.Project{}, lenient)
+	require.NoError(t, err)
+	assert.Empty(t, analysis.MalformedPOMs())
+
+	// The files analyzed together are recovered as well.
+	merged, err := MergeFileAnalyses(ctx, AnalyzeFiles(ctx, []string{path}, 1, WithLenientParsing()))
+	require.NoError(t, err)
+	assert.Len(t, merged.MalformedPOMs(), 1)
+}
+
+func TestMalformedPOMSuppressed(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "pom.xml")
+	require.NoError(t, os.WriteFile(path, []byte(brokenPOM), 0644))
+	lenient := WithLenientParsing()
+	project, err := ParseAnalysisPOM(ctx, path, lenient)
+	require.NoError(t, err)
+	analysis, err := AnalyzeProject(ctx, project, lenient)
+	require.NoError(t, err)
+
+	suppressions, err := ParseSuppressions([]string{WarningMalformedPOM})
+	require.NoError(t, err)
+	analysis.Suppress(suppressions)
+	assert.Empty(t, analysis.MalformedPOMs())
+	assert.Len(t, analysis.SuppressedIssues(), 1)
+	assert.NotContains(t, analysis.AnalysisReport(), "WARNING:")
+}
@@ -0,0 +1,93 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+)
+
+// PatchOperationRemove deletes the dependency from the POM entirely, e.g.
+// when the mitigation is dropping an abandoned artifact.
+const PatchOperationRemove = "remove"
+
+// removeDependency applies a remove patch: every entry it matches is deleted
+// from the section its Target names or, without one, from the dependencies,
+// dependencyManagement and plugin dependencies of the project and of its
+// profiles.
+func removeDependency(ctx context.Context, project *gopom.Project, patch Patch) error {
+	log := clog.FromContext(ctx)
+	var lists []*[]gopom.Dependency
+	if patch.Target != "" {
+		target, err := parseTarget(patch.Target)
+		if err != nil {
+			return err
+		}
+		if lists, err = target.dependencyLists(project); err != nil {
+			return fmt.Errorf("patch %s.%s: %w", patch.GroupID, patch.ArtifactID, err)
+		}
+	} else {
+		lists = allDependencyLists(project)
+	}
+
+	props := coordinateProperties(project)
+	removed := 0
+	for _, list := range lists {
+		matches := matchingDependencies(*list, patch, props)
+		for i := len(matches) - 1; i >= 0; i-- {
+			*list = append((*list)[:matches[i]], (*list)[matches[i]+1:]...)
+		}
+		removed += len(matches)
+	}
+	pruneEmptySections(project)
+	if removed == 0 {
+		log.Warnf("%s.%s not found, nothing to remove", patch.GroupID, patch.ArtifactID)
+		return nil
+	}
+	log.Infof("Removed %d declarations of %s.%s", removed, patch.GroupID, patch.ArtifactID)
+	return nil
+}
+
+// allDependencyLists returns every dependency list of project that exists.
+func allDependencyLists(project *gopom.Project) []*[]gopom.Dependency {
+	lists := []*[]gopom.Dependency{}
+	add := func(deps *[]gopom.Dependency, dm *gopom.DependencyManagement) {
+		if deps != nil {
+			lists = append(lists, deps)
+		}
+		if dm != nil && dm.Dependencies != nil {
+			lists = append(lists, dm.Dependencies)
+		}
+	}
+	add(project.Dependencies, project.DependencyManagement)
+	if project.Profiles != nil {
+		for _, profile := range *project.Profiles {
+			add(profile.Dependencies, profile.DependencyManagement)
+		}
+	}
+	for _, plugin := range pluginDependencyLists(project) {
+		lists = append(lists, plugin.Dependencies)
+	}
+	return lists
+}
+
+// pruneEmptySections drops the dependency sections left empty, so that no
+// empty <dependencyManagement> is written.
+func pruneEmptySections(project *gopom.Project) {
+	prune := func(deps **[]gopom.Dependency, dm **gopom.DependencyManagement) {
+		if *deps != nil && len(**deps) == 0 {
+			*deps = nil
+		}
+		if *dm != nil && ((*dm).Dependencies == nil || len(*(*dm).Dependencies) == 0) {
+			*dm = nil
+		}
+	}
+	prune(&project.Dependencies, &project.DependencyManagement)
+	if project.Profiles != nil {
+		for i := range *project.Profiles {
+			profile := &(*project.Profiles)[i]
+			prune(&profile.Dependencies, &profile.DependencyManagement)
+		}
+	}
+}
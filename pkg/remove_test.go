@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePatchesRemove(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "patches.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`patches:
+  - groupId: commons-collections
+    artifactId: commons-collections
+    operation: remove
+`), 0644))
+	got, err := ParsePatches(context.Background(), file, "")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, PatchOperationRemove, got[0].Operation)
+	assert.Empty(t, got[0].Version)
+}
+
+func TestPatchStrategyRemove(t *testing.T) {
+	ctx := context.Background()
+	analysis, err := AnalyzeProject(ctx, &gopom.Project{
+		Dependencies:         makeDeps(pomDep("commons-collections", "commons-collections", "")),
+		DependencyManagement: makeManaged(pomDep("commons-collections", "commons-collections", "3.2.1")),
+	})
+	require.NoError(t, err)
+	patch := Patch{GroupID: "commons-collections", ArtifactID: "commons-collections", Operation: PatchOperationRemove}
+	direct, props := PatchStrategy(ctx, analysis, []Patch{patch})
+	assert.Equal(t, []Patch{patch}, direct)
+	assert.Empty(t, props)
+
+	// Nothing to verify about a dependency that is gone.
+	verified, unfixable, err := VerifyVersions(ctx, nil, []Patch{patch})
+	require.NoError(t, err)
+	assert.Equal(t, []Patch{patch}, verified)
+	assert.Empty(t, unfixable)
+}
+
+func TestPatchReactorRemove(t *testing.T) {
+	rootPOM := writeReactor(t, map[string]string{
+		"pom.xml": `<project>
+  <groupId>org.example</groupId>
+  <artifactId>root</artifactId>
+  <version>1.0</version>
+  <modules>
+    <module>core</module>
+  </modules>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>commons-collections</groupId>
+        <artifactId>commons-collections</artifactId>
+        <version>3.2.1</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+</project>`,
+		"core/pom.xml": `<project>
+  <artifactId>core</artifactId>
+  <dependencies>
+    <dependency>
+      <groupId>commons-collections</groupId>
+      <artifactId>commons-collections</artifactId>
+    </dependency>
+  </dependencies>
+</project>`,
+	})
+
+	ctx := context.Background()
+	modules, err := DiscoverModules(ctx, rootPOM)
+	require.NoError(t, err)
+	patch := Patch{GroupID: "commons-collections", ArtifactID: "commons-collections", Operation: PatchOperationRemove}
+	results, err := PatchReactor(ctx, modules, []Patch{patch}, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Nil(t, results[0].Project.DependencyManagement)
+	assert.Nil(t, results[1].Project.Dependencies)
+}
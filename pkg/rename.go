@@ -0,0 +1,119 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+)
+
+// RenameProperty renames the property oldName to newName throughout project:
+// its definition, in the properties of the project and of its profiles, and
+// every ${oldName} reference, wherever it is. It returns the number of
+// references renamed. It fails if newName is already defined where oldName
+// is, and only renames the references when oldName is defined elsewhere,
+// e.g. in a parent POM.
+func RenameProperty(ctx context.Context, project *gopom.Project, oldName, newName string) (int, error) {
+	log := clog.FromContext(ctx)
+	if project == nil {
+		return 0, fmt.Errorf("project is nil")
+	}
+	for _, name := range []string{oldName, newName} {
+		if name == "" || strings.ContainsAny(name, "${} \t\n<>") {
+			return 0, fmt.Errorf("invalid property name %q", name)
+		}
+	}
+	if oldName == newName {
+		return 0, nil
+	}
+
+	propertySets := []*gopom.Properties{project.Properties}
+	if project.Profiles != nil {
+		for _, profile := range *project.Profiles {
+			propertySets = append(propertySets, profile.Properties)
+		}
+	}
+	defined := false
+	for _, properties := range propertySets {
+		if properties == nil {
+			continue
+		}
+		if _, ok := properties.Entries[oldName]; !ok {
+			continue
+		}
+		if _, ok := properties.Entries[newName]; ok {
+			return 0, fmt.Errorf("property %s is already defined", newName)
+		}
+		defined = true
+	}
+	for _, properties := range propertySets {
+		if properties == nil {
+			continue
+		}
+		if value, ok := properties.Entries[oldName]; ok {
+			delete(properties.Entries, oldName)
+			properties.Entries[newName] = value
+			if i := slices.Index(properties.Order, oldName); i >= 0 {
+				properties.Order[i] = newName
+			}
+		}
+	}
+	if !defined {
+		log.Warnf("Property %s is not defined in this POM, only renaming its references", oldName)
+	}
+
+	oldRef, newRef := "${"+oldName+"}", "${"+newName+"}"
+	renamed := replaceStrings(reflect.ValueOf(project), func(s string) (string, int) {
+		n := strings.Count(s, oldRef)
+		if n == 0 {
+			return s, 0
+		}
+		return strings.ReplaceAll(s, oldRef, newRef), n
+	})
+	log.Infof("Renamed property %s to %s, %d references", oldName, newName, renamed)
+	return renamed, nil
+}
+
+// replaceStrings applies replace to every string reachable from v, including
+// map values, and returns the total count replace reports.
+func replaceStrings(v reflect.Value, replace func(string) (string, int)) int {
+	count := 0
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			count += replaceStrings(v.Elem(), replace)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				count += replaceStrings(v.Field(i), replace)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			count += replaceStrings(v.Index(i), replace)
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			break
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			replaced, n := replace(iter.Value().String())
+			if n > 0 {
+				v.SetMapIndex(iter.Key(), reflect.ValueOf(replaced).Convert(v.Type().Elem()))
+				count += n
+			}
+		}
+	case reflect.String:
+		if replaced, n := replace(v.String()); n > 0 && v.CanSet() {
+			v.SetString(replaced)
+			count += n
+		}
+	}
+	return count
+}
@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenameProperty(t *testing.T) {
+	project := &gopom.Project{
+		Properties: makeProperties("other", "1", "version.netty", "4.1.94.Final", "netty-handler.version", "${version.netty}"),
+		Dependencies: makeDeps(
+			pomDep("io.netty", "netty-codec", "${version.netty}"),
+			pomDep("io.netty", "netty-handler", "${netty-handler.version}"),
+		),
+		Build: makeBuild(gopom.Plugin{
+			ArtifactID:    "maven-enforcer-plugin",
+			Configuration: &gopom.Configuration{RawConfiguration: "<netty>${version.netty}</netty>"},
+		}),
+		Profiles: &[]gopom.Profile{{ID: "legacy", Properties: makeProperties("version.netty", "4.0.0")}},
+	}
+	renamed, err := RenameProperty(context.Background(), project, "version.netty", "netty.version")
+	require.NoError(t, err)
+	assert.Equal(t, 3, renamed)
+
+	assert.Equal(t, map[string]string{"netty.version": "4.1.94.Final", "netty-handler.version": "${netty.version}", "other": "1"}, project.Properties.Entries)
+	assert.Equal(t, []string{"other", "netty.version", "netty-handler.version"}, project.Properties.Order)
+	assert.Equal(t, "${netty.version}", (*project.Dependencies)[0].Version)
+	assert.Equal(t, "${netty-handler.version}", (*project.Dependencies)[1].Version)
+	assert.Equal(t, "<netty>${netty.version}</netty>", (*project.Build.Plugins)[0].Configuration.RawConfiguration)
+	assert.Equal(t, map[string]string{"netty.version": "4.0.0"}, (*project.Profiles)[0].Properties.Entries)
+}
+
+func TestRenamePropertyErrors(t *testing.T) {
+	ctx := context.Background()
+	project := &gopom.Project{
+		Properties:   makeProperties("other", "1", "version.netty", "4.1.94.Final"),
+		Dependencies: makeDeps(pomDep("io.netty", "netty-codec", "${version.netty}")),
+	}
+	_, err := RenameProperty(ctx, project, "version.netty", "other")
+	assert.Error(t, err)
+	_, err = RenameProperty(ctx, project, "version.netty", "${bad}")
+	assert.Error(t, err)
+
+	// Defined in a parent, only the references are renamed.
+	renamed, err := RenameProperty(ctx, project, "parent.version", "new.version")
+	require.NoError(t, err)
+	assert.Equal(t, 0, renamed)
+	assert.Equal(t, &gopom.Project{
+		Properties:   makeProperties("other", "1", "version.netty", "4.1.94.Final"),
+		Dependencies: makeDeps(pomDep("io.netty", "netty-codec", "${version.netty}")),
+	}, project)
+}
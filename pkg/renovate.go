@@ -0,0 +1,147 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+)
+
+// renovatePOMFiles is the fileMatch of the custom managers, the POMs of the
+// repository like the maven manager of Renovate.
+const renovatePOMFiles = `(^|/)pom\.xml$`
+
+// RenovateConfig is the part of a Renovate configuration pombump knows how
+// to write: which dependencies to update together, because they share a
+// version property or a BOM manages them, and custom managers for the
+// versions Renovate would not find by itself. It is meant to be merged into
+// renovate.json.
+type RenovateConfig struct {
+	PackageRules   []RenovatePackageRule   `json:"packageRules,omitempty"`
+	CustomManagers []RenovateCustomManager `json:"customManagers,omitempty"`
+}
+
+// RenovatePackageRule groups the updates of the packages it matches into one
+// pull request.
+type RenovatePackageRule struct {
+	Description       string   `json:"description"`
+	MatchDatasources  []string `json:"matchDatasources"`
+	MatchPackageNames []string `json:"matchPackageNames"`
+	GroupName         string   `json:"groupName"`
+}
+
+// RenovateCustomManager is a regex custom manager updating the version held
+// by a property.
+type RenovateCustomManager struct {
+	CustomType         string   `json:"customType"`
+	Description        string   `json:"description"`
+	FileMatch          []string `json:"fileMatch"`
+	MatchStrings       []string `json:"matchStrings"`
+	DepNameTemplate    string   `json:"depNameTemplate"`
+	DatasourceTemplate string   `json:"datasourceTemplate"`
+}
+
+// RenovateConfig describes what the analysis knows about updating the
+// project to Renovate:
+//
+//   - the artifacts a BOM manages but the project pins, grouped with the BOM
+//     so that they move along with it;
+//   - the dependencies sharing a version property, grouped so that the
+//     property is bumped once, for all of them (these win over the BOMs);
+//   - a custom manager for each property holding the value of another, like
+//     b.version for <a.version>${b.version}</a.version>, which Renovate
+//     does not follow.
+//
+// BOMs are only known for sure once resolved, see ResolveBOMs.
+func (result *AnalysisResult) RenovateConfig() *RenovateConfig {
+	result.ensureDependencies()
+	config := &RenovateConfig{}
+
+	keys := sortedKeys(result.Dependencies)
+	for _, bom := range result.BOMs() {
+		bomKey := fmt.Sprintf("%s:%s", bom.GroupID, bom.ArtifactID)
+		names := []string{bomKey}
+		for _, key := range keys {
+			info := result.Dependencies[key]
+			if info.Version == "" || key == bomKey {
+				continue
+			}
+			if result.managesPinned(bom, info) {
+				names = append(names, key)
+			}
+		}
+		if len(names) < 2 {
+			continue
+		}
+		config.PackageRules = append(config.PackageRules, RenovatePackageRule{
+			Description:       fmt.Sprintf("Artifacts managed by the BOM %s, updated along with it", bomKey),
+			MatchDatasources:  []string{"maven"},
+			MatchPackageNames: names,
+			GroupName:         bom.ArtifactID,
+		})
+	}
+
+	// By the property holding the value, at the end of any chain.
+	users := map[string][]string{}
+	chained := map[string]bool{}
+	for _, key := range keys {
+		info := result.Dependencies[key]
+		if !info.UsesProperty || info.PropertyName == "" {
+			continue
+		}
+		property := info.PropertyName
+		if len(info.PropertyChain) > 1 {
+			property = info.PropertyChain[len(info.PropertyChain)-1]
+			chained[property] = true
+		}
+		users[property] = append(users[property], key)
+	}
+	for _, bom := range result.BOMs() {
+		key := fmt.Sprintf("%s:%s", bom.GroupID, bom.ArtifactID)
+		if bom.UsesProperty && len(users[bom.PropertyName]) > 0 && !slices.Contains(users[bom.PropertyName], key) {
+			users[bom.PropertyName] = append(users[bom.PropertyName], key)
+		}
+	}
+	for _, property := range sortedKeys(users) {
+		names := users[property]
+		sort.Strings(names)
+		if len(names) > 1 {
+			config.PackageRules = append(config.PackageRules, RenovatePackageRule{
+				Description:       fmt.Sprintf("Dependencies sharing the property %s", property),
+				MatchDatasources:  []string{"maven"},
+				MatchPackageNames: names,
+				GroupName:         property,
+			})
+		}
+		if chained[property] {
+			quoted := regexp.QuoteMeta(property)
+			config.CustomManagers = append(config.CustomManagers, RenovateCustomManager{
+				CustomType:         "regex",
+				Description:        fmt.Sprintf("The property %s, which the version of %s refers to", property, names[0]),
+				FileMatch:          []string{renovatePOMFiles},
+				MatchStrings:       []string{fmt.Sprintf(`<%s>(?<currentValue>[^<$]+)</%s>`, quoted, quoted)},
+				DepNameTemplate:    names[0],
+				DatasourceTemplate: "maven",
+			})
+		}
+	}
+	return config
+}
+
+// managesPinned reports whether bom manages the dependency info, which the
+// project pins to a version of its own. Unresolved BOMs are guessed by
+// group, see BOMForGroup.
+func (result *AnalysisResult) managesPinned(bom BOMInfo, info *DependencyInfo) bool {
+	if bom.Managed != nil {
+		_, ok := bom.Managed[fmt.Sprintf("%s:%s", info.GroupID, info.ArtifactID)]
+		return ok
+	}
+	guess, ok := result.BOMForGroup(info.GroupID)
+	return ok && guess.GroupID == bom.GroupID && guess.ArtifactID == bom.ArtifactID
+}
+
+// JSON renders the configuration as indented JSON, for renovate.json.
+func (c *RenovateConfig) JSON() ([]byte, error) {
+	return json.MarshalIndent(c, "", "  ")
+}
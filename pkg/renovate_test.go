@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const renovatePOM = `<project>
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0</version>
+  <properties>
+    <netty.version>4.1.94.Final</netty.version>
+    <lib.version>${shared.version}</lib.version>
+    <shared.version>2.3</shared.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-bom</artifactId>
+        <version>${netty.version}</version>
+        <type>pom</type>
+        <scope>import</scope>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-handler</artifactId>
+      <version>${netty.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-tcnative</artifactId>
+      <version>2.0.61.Final</version>
+    </dependency>
+    <dependency>
+      <groupId>com.example</groupId>
+      <artifactId>lib</artifactId>
+      <version>${lib.version}</version>
+    </dependency>
+  </dependencies>
+</project>`
+
+func TestRenovateConfig(t *testing.T) {
+	project, err := StreamPOM(strings.NewReader(renovatePOM))
+	require.NoError(t, err)
+	analysis, err := AnalyzeProject(context.Background(), project)
+	require.NoError(t, err)
+
+	config := analysis.RenovateConfig()
+	assert.Equal(t, []RenovatePackageRule{
+		{
+			Description:       "Artifacts managed by the BOM io.netty:netty-bom, updated along with it",
+			MatchDatasources:  []string{"maven"},
+			MatchPackageNames: []string{"io.netty:netty-bom", "io.netty:netty-handler", "io.netty:netty-tcnative"},
+			GroupName:         "netty-bom",
+		},
+		{
+			Description:       "Dependencies sharing the property netty.version",
+			MatchDatasources:  []string{"maven"},
+			MatchPackageNames: []string{"io.netty:netty-bom", "io.netty:netty-handler"},
+			GroupName:         "netty.version",
+		},
+	}, config.PackageRules)
+	assert.Equal(t, []RenovateCustomManager{{
+		CustomType:         "regex",
+		Description:        "The property shared.version, which the version of com.example:lib refers to",
+		FileMatch:          []string{`(^|/)pom\.xml$`},
+		MatchStrings:       []string{`<shared\.version>(?<currentValue>[^<$]+)</shared\.version>`},
+		DepNameTemplate:    "com.example:lib",
+		DatasourceTemplate: "maven",
+	}}, config.CustomManagers)
+}
+
+func TestRenovateConfigEmpty(t *testing.T) {
+	data, err := (&AnalysisResult{Dependencies: map[string]*DependencyInfo{}}).RenovateConfig().JSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(data))
+}
@@ -0,0 +1,282 @@
+package pkg
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultRepositoryURL is Maven Central.
+const DefaultRepositoryURL = "https://repo1.maven.org/maven2"
+
+// ErrNotFound is returned when something does not exist in the repository.
+var ErrNotFound = errors.New("not found in repository")
+
+// ErrChecksumMismatch is returned when a file fetched from the repository
+// does not match the checksum published alongside it.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// checksumAlgorithm is a checksum published alongside the files of a
+// repository, in a file of the same name with extension added.
+type checksumAlgorithm struct {
+	extension string
+	sum       func([]byte) string
+}
+
+// checksumAlgorithms are the checksums verified, the strongest first. Only
+// the first one published alongside a file is checked.
+var checksumAlgorithms = []checksumAlgorithm{
+	{".sha256", func(data []byte) string { return fmt.Sprintf("%x", sha256.Sum256(data)) }},
+	{".sha1", func(data []byte) string { return fmt.Sprintf("%x", sha1.Sum(data)) }},
+}
+
+// MavenRepository is a minimal client for a remote Maven repository.
+type MavenRepository struct {
+	URL    string
+	Client *http.Client
+	// MaxDepth bounds how many parents, and levels of BOM imports, are
+	// followed when resolving what a POM inherits. It defaults to 10.
+	MaxDepth int
+	// Username and Password, if set, authenticate the requests to the
+	// repository, along with Headers. They are not sent anywhere else.
+	Username string
+	Password string
+	Headers  map[string]string
+	// Insecure only warns when a file does not match its published
+	// checksum, instead of failing.
+	Insecure bool
+	// Cache, if set, keeps the version lists and POMs fetched, once
+	// verified, so that they are not fetched again until they expire.
+	Cache *MetadataCache
+
+	// missing are the checksums found missing, shared by the copies of the
+	// repository.
+	missing *missingChecksums
+}
+
+// missingChecksums are the extensions of the checksums found missing for a
+// file of a repository, tried after the others from then on: Maven Central
+// publishes no .sha256 for most files, which would cost a 404 for each. A
+// nil missingChecksums remembers nothing.
+type missingChecksums struct {
+	mu         sync.Mutex
+	extensions map[string]bool
+}
+
+// order returns algorithms, those found missing last.
+func (m *missingChecksums) order(algorithms []checksumAlgorithm) []checksumAlgorithm {
+	if m == nil {
+		return algorithms
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ordered := slices.Clone(algorithms)
+	slices.SortStableFunc(ordered, func(a, b checksumAlgorithm) int {
+		switch missingA, missingB := m.extensions[a.extension], m.extensions[b.extension]; {
+		case missingA == missingB:
+			return 0
+		case missingA:
+			return 1
+		default:
+			return -1
+		}
+	})
+	return ordered
+}
+
+// add records that the checksum of extension was found missing.
+func (m *missingChecksums) add(extension string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.extensions[extension] = true
+}
+
+// NewMavenRepository returns a client for the repository at url. If url is
+// empty, Maven Central is used.
+func NewMavenRepository(url string) *MavenRepository {
+	if url == "" {
+		url = DefaultRepositoryURL
+	}
+	return &MavenRepository{
+		URL:     strings.TrimSuffix(url, "/"),
+		Client:  NewHTTPClient(nil),
+		missing: &missingChecksums{extensions: map[string]bool{}},
+	}
+}
+
+// maxDepth is MaxDepth, or its default if unset.
+func (r *MavenRepository) maxDepth() int {
+	if r.MaxDepth > 0 {
+		return r.MaxDepth
+	}
+	return maxParentDepth
+}
+
+// artifactPath returns the repository path for a groupId:artifactId, e.g.
+// io/netty/netty-handler
+func artifactPath(groupID, artifactID string) string {
+	return fmt.Sprintf("%s/%s", strings.ReplaceAll(groupID, ".", "/"), artifactID)
+}
+
+// FetchPOM downloads and parses the POM for the given coordinates.
+func (r *MavenRepository) FetchPOM(ctx context.Context, groupID, artifactID, version string) (*gopom.Project, error) {
+	url := fmt.Sprintf("%s/%s/%s/%s-%s.pom", r.URL, artifactPath(groupID, artifactID), version, artifactID, version)
+	return r.FetchPOMFromURL(ctx, url)
+}
+
+// FetchPOMFromURL downloads and parses the POM at url, which does not need
+// to live in this repository (e.g. a raw file in a source repository).
+func (r *MavenRepository) FetchPOMFromURL(ctx context.Context, url string) (*gopom.Project, error) {
+	data, err := r.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	_, end := startSpan(ctx, OperationParse, attribute.String("pombump.url", url))
+	var project gopom.Project
+	err = unmarshalPOM(data, &project)
+	end(err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", url, err)
+	}
+	return &project, nil
+}
+
+// mavenMetadata is the subset of maven-metadata.xml that we care about.
+type mavenMetadata struct {
+	Versioning struct {
+		Latest   string   `xml:"latest"`
+		Release  string   `xml:"release"`
+		Versions []string `xml:"versions>version"`
+	} `xml:"versioning"`
+}
+
+// Versions returns all the versions of groupId:artifactId published in the
+// repository, as listed in its maven-metadata.xml.
+func (r *MavenRepository) Versions(ctx context.Context, groupID, artifactID string) ([]string, error) {
+	url := fmt.Sprintf("%s/%s/maven-metadata.xml", r.URL, artifactPath(groupID, artifactID))
+	data, err := r.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	var metadata mavenMetadata
+	if err := xml.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata for %s:%s: %w", groupID, artifactID, err)
+	}
+	return metadata.Versioning.Versions, nil
+}
+
+// inRepository reports whether url is a file of this repository, rather than
+// one elsewhere as FetchPOMFromURL allows.
+func (r *MavenRepository) inRepository(url string) bool {
+	return strings.HasPrefix(url, r.URL+"/")
+}
+
+// get fetches url and, if it is a file of the repository, verifies it
+// against its published checksum. Files of the repository are read from,
+// and kept in, the Cache if set.
+func (r *MavenRepository) get(ctx context.Context, url string) ([]byte, error) {
+	log := clog.FromContext(ctx)
+	if !r.inRepository(url) {
+		return r.fetch(ctx, url)
+	}
+	if data, ok := r.Cache.Get(url); ok {
+		log.Debugf("Using cached %s", url)
+		return data, nil
+	}
+	data, err := r.fetch(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.verifyChecksum(ctx, url, data); err != nil {
+		if !r.Insecure {
+			return nil, err
+		}
+		// Not cached, so that a later run does not use it silently.
+		log.Warnf("Using %s anyway: %v", url, err)
+		return data, nil
+	}
+	if err := r.Cache.Put(url, data); err != nil {
+		log.Warnf("failed to cache %s: %v", url, err)
+	}
+	return data, nil
+}
+
+// verifyChecksum checks data, fetched from url, against the strongest
+// checksum published alongside it, trying first those not found missing for
+// another file, see missingChecksums. Files without any checksum pass,
+// with a warning.
+func (r *MavenRepository) verifyChecksum(ctx context.Context, url string, data []byte) error {
+	for _, alg := range r.missing.order(checksumAlgorithms) {
+		published, err := r.fetch(ctx, url+alg.extension)
+		if errors.Is(err, ErrNotFound) {
+			r.missing.add(alg.extension)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch the checksum of %s: %w", url, err)
+		}
+		// The file may hold the name of the file after the checksum.
+		fields := strings.Fields(string(published))
+		if len(fields) == 0 {
+			return fmt.Errorf("%s: %w: %s is empty", url, ErrChecksumMismatch, alg.extension)
+		}
+		if expected, actual := strings.ToLower(fields[0]), alg.sum(data); expected != actual {
+			return fmt.Errorf("%s: %w: %s is %s, got %s", url, ErrChecksumMismatch, alg.extension, expected, actual)
+		}
+		return nil
+	}
+	clog.FromContext(ctx).Warnf("No checksum published for %s, it was not verified", url)
+	return nil
+}
+
+// fetch fetches url, failing on anything but a 200.
+func (r *MavenRepository) fetch(ctx context.Context, url string) (_ []byte, err error) {
+	ctx, end := startSpan(ctx, OperationFetch, attribute.String("pombump.url", url))
+	defer func() { end(err) }()
+	log := clog.FromContext(ctx)
+	log.Debugf("Fetching %s", url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.inRepository(url) {
+		if r.Username != "" || r.Password != "" {
+			req.SetBasicAuth(r.Username, r.Password)
+		}
+		for name, value := range r.Headers {
+			req.Header.Set(name, value)
+		}
+	}
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Warnf("failed to close response body: %v", err)
+		}
+	}()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("fetching %s: %w", url, ErrNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,93 @@
+package pkg
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const checksumMetadata = `<metadata><versioning><versions><version>1.0</version></versions></versioning></metadata>`
+
+func TestRepositoryChecksums(t *testing.T) {
+	sha256sum := fmt.Sprintf("%x", sha256.Sum256([]byte(checksumMetadata)))
+	sha1sum := fmt.Sprintf("%x", sha1.Sum([]byte(checksumMetadata)))
+	tests := []struct {
+		name      string
+		checksums map[string]string
+		insecure  bool
+		wantErr   bool
+	}{
+		{name: "none published"},
+		{name: "sha256", checksums: map[string]string{".sha256": sha256sum}},
+		{name: "sha1 with the file name", checksums: map[string]string{".sha1": sha1sum + "  maven-metadata.xml\n"}},
+		{name: "sha256 wins", checksums: map[string]string{".sha256": sha256sum, ".sha1": "0000"}},
+		{name: "mismatch", checksums: map[string]string{".sha1": "0000"}, wantErr: true},
+		{name: "empty", checksums: map[string]string{".sha256": ""}, wantErr: true},
+		{name: "insecure mismatch", checksums: map[string]string{".sha256": "0000"}, insecure: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const path = "/g/a/maven-metadata.xml"
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == path {
+					_, _ = w.Write([]byte(checksumMetadata))
+					return
+				}
+				for ext, sum := range tt.checksums {
+					if r.URL.Path == path+ext {
+						_, _ = w.Write([]byte(sum))
+						return
+					}
+				}
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer server.Close()
+
+			repo := NewMavenRepository(server.URL)
+			repo.Insecure = tt.insecure
+			versions, err := repo.Versions(context.Background(), "g", "a")
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrChecksumMismatch)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []string{"1.0"}, versions)
+		})
+	}
+}
+
+func TestRepositoryMissingChecksums(t *testing.T) {
+	sha1sum := fmt.Sprintf("%x", sha1.Sum([]byte(checksumMetadata)))
+	requested := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested[r.URL.Path]++
+		switch path.Ext(r.URL.Path) {
+		case ".xml":
+			_, _ = w.Write([]byte(checksumMetadata))
+		case ".sha1":
+			_, _ = w.Write([]byte(sha1sum))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// Only SHA-1 is published, as on Maven Central: once the SHA-256 of a
+	// file is found missing, the SHA-1 is tried first.
+	repo := NewMavenRepository(server.URL)
+	for _, artifact := range []string{"a", "b"} {
+		_, err := repo.Versions(context.Background(), "g", artifact)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, requested["/g/a/maven-metadata.xml.sha256"])
+	assert.Equal(t, 0, requested["/g/b/maven-metadata.xml.sha256"])
+	assert.Equal(t, 1, requested["/g/b/maven-metadata.xml.sha1"])
+}
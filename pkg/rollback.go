@@ -0,0 +1,334 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/ghodss/yaml"
+)
+
+// RollbackStateVersion is the version of the state format Record writes.
+const RollbackStateVersion = 1
+
+// RollbackState records the changes made to POMs written in place, with
+// the values they replaced, so that Restore can undo them without version
+// control.
+type RollbackState struct {
+	Version int             `json:"version" yaml:"version"`
+	Changes []AppliedChange `json:"changes" yaml:"changes"`
+}
+
+// AppliedChange is a POM written in place: the mutations it went through
+// and the SHA-256 of what was written.
+type AppliedChange struct {
+	POM       string     `json:"pom" yaml:"pom"`
+	Checksum  string     `json:"checksum" yaml:"checksum"`
+	Mutations []Mutation `json:"mutations" yaml:"mutations"`
+}
+
+// Record records that pom was written as after over before. Writing it
+// without changing anything is not recorded.
+func (s *RollbackState) Record(pom string, before, after []byte) error {
+	beforeProject, err := parsePOMData(before)
+	if err != nil {
+		return err
+	}
+	afterProject, err := parsePOMData(after)
+	if err != nil {
+		return err
+	}
+	declarations, err := scanDeclarations(bytes.NewReader(before), pom)
+	if err != nil {
+		return err
+	}
+	mutations := projectMutations(beforeProject, afterProject, declarations)
+	if len(mutations) == 0 {
+		return nil
+	}
+	s.Version = RollbackStateVersion
+	s.Changes = append(s.Changes, AppliedChange{POM: pom, Checksum: pomChecksum(after), Mutations: mutations})
+	return nil
+}
+
+// POMs returns the POMs changed, in the order they were first changed.
+func (s *RollbackState) POMs() []string {
+	poms := []string{}
+	for _, c := range s.Changes {
+		if !slices.Contains(poms, c.POM) {
+			poms = append(poms, c.POM)
+		}
+	}
+	return poms
+}
+
+// Forget drops the changes recorded for pom, once it is restored.
+func (s *RollbackState) Forget(pom string) {
+	s.Changes = slices.DeleteFunc(s.Changes, func(c AppliedChange) bool {
+		return c.POM == pom
+	})
+}
+
+// Restore undoes the changes recorded for pom, the latest first, on data,
+// its contents, and returns the project as it was before them. It fails if
+// pom changed since it was last written.
+func (s *RollbackState) Restore(pom string, data []byte) (*gopom.Project, error) {
+	if s.Version != RollbackStateVersion {
+		return nil, fmt.Errorf("unsupported rollback state version %d, expected %d", s.Version, RollbackStateVersion)
+	}
+	var changes []AppliedChange
+	for _, c := range s.Changes {
+		if c.POM == pom {
+			changes = append(changes, c)
+		}
+	}
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("no changes recorded for %s", pom)
+	}
+	if pomChecksum(data) != changes[len(changes)-1].Checksum {
+		return nil, fmt.Errorf("%s changed since it was patched, restore it from version control", pom)
+	}
+	project, err := parsePOMData(data)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(changes) - 1; i >= 0; i-- {
+		if err := revertMutations(project, changes[i].Mutations); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", pom, err)
+		}
+	}
+	return project, nil
+}
+
+// revertMutations undoes mutations, as projectMutations computes them, on
+// project.
+func revertMutations(project *gopom.Project, mutations []Mutation) error {
+	// The dependency lists changed, by their path, in the order of the POM.
+	var paths []string
+	bySection := map[string][]Mutation{}
+	for _, m := range mutations {
+		switch {
+		case m.Element == "project/parent/version":
+			if project.Parent == nil {
+				return fmt.Errorf("the POM has no parent to restore the version of")
+			}
+			project.Parent.Version = m.From
+		case strings.HasPrefix(m.Element, "project/properties/"):
+			name := strings.TrimPrefix(m.Element, "project/properties/")
+			if m.Action == MutationAdd {
+				RemoveProperties(project, []string{name})
+				continue
+			}
+			if project.Properties == nil {
+				project.Properties = &gopom.Properties{Entries: map[string]string{}}
+			}
+			if _, ok := project.Properties.Entries[name]; !ok {
+				project.Properties.Order = append(project.Properties.Order, name)
+			}
+			project.Properties.Entries[name] = m.From
+		default:
+			i := strings.Index(m.Element, "/dependency[")
+			if i < 0 {
+				return fmt.Errorf("unknown element %s", m.Element)
+			}
+			path := m.Element[:i]
+			if _, ok := bySection[path]; !ok {
+				paths = append(paths, path)
+			}
+			bySection[path] = append(bySection[path], m)
+		}
+	}
+
+	// The dependencies are named by their coordinates before the changes,
+	// once the properties are restored.
+	props := coordinateProperties(project)
+	sections := map[string]*[]gopom.Dependency{}
+	for _, s := range dependencySections(project) {
+		sections[s.path] = s.deps
+	}
+	for _, path := range paths {
+		deps, ok := sections[path]
+		if !ok || deps == nil {
+			return fmt.Errorf("%s is not in the POM", path)
+		}
+		restored, err := revertDependencies(path, *deps, bySection[path], props)
+		if err != nil {
+			return err
+		}
+		*deps = restored
+		// Drop the dependencyManagement patching added.
+		if path == "project/dependencyManagement/dependencies" && len(restored) == 0 {
+			project.DependencyManagement = nil
+		}
+	}
+	return nil
+}
+
+// revertDependencies undoes mutations on the dependency list deps at path:
+// their fields are restored, the dependencies added dropped, those moved
+// put back and those removed added back, at the end.
+func revertDependencies(path string, deps []gopom.Dependency, mutations []Mutation, props map[string]string) ([]gopom.Dependency, error) {
+	deps = slices.Clone(deps)
+	find := func(element string) (int, error) {
+		i := slices.IndexFunc(deps, func(dep gopom.Dependency) bool {
+			return dependencyElement(path, dep, props) == element
+		})
+		if i < 0 {
+			return -1, fmt.Errorf("%s is not in the POM", element)
+		}
+		return i, nil
+	}
+
+	var added, removed []Mutation
+	moved := map[string]int{}
+	for _, m := range mutations {
+		entry := strings.HasSuffix(m.Element, "]")
+		switch {
+		case m.Action == MutationMove:
+			from, err := strconv.Atoi(m.From)
+			if err != nil {
+				return nil, fmt.Errorf("invalid position %q of %s: %w", m.From, m.Element, err)
+			}
+			moved[m.Element] = from - 1
+		case entry && m.Action == MutationAdd:
+			added = append(added, m)
+		case entry && m.Action == MutationRemove:
+			removed = append(removed, m)
+		default:
+			element, field := m.Element[:strings.LastIndex(m.Element, "/")], m.Element[strings.LastIndex(m.Element, "/")+1:]
+			i, err := find(element)
+			if err != nil {
+				return nil, err
+			}
+			switch field {
+			case "version":
+				deps[i].Version = m.From
+			case "scope":
+				deps[i].Scope = m.From
+			case "exclusions":
+				deps[i].Exclusions = parseExclusionList(m.From)
+			default:
+				return nil, fmt.Errorf("unknown element %s", m.Element)
+			}
+		}
+	}
+
+	for _, m := range added {
+		i, err := find(m.Element)
+		if err != nil {
+			return nil, err
+		}
+		deps = slices.Delete(deps, i, i+1)
+	}
+
+	if len(moved) > 0 {
+		ordered := make([]gopom.Dependency, len(deps))
+		placed := make([]bool, len(deps))
+		taken := make([]bool, len(deps))
+		for element, to := range moved {
+			i, err := find(element)
+			if err != nil {
+				return nil, err
+			}
+			if to < 0 || to >= len(deps) || placed[to] {
+				return nil, fmt.Errorf("can not restore the order of %s", path)
+			}
+			ordered[to], placed[to], taken[i] = deps[i], true, true
+		}
+		// The others did not move.
+		for i, dep := range deps {
+			if taken[i] {
+				continue
+			}
+			if placed[i] {
+				return nil, fmt.Errorf("can not restore the order of %s", path)
+			}
+			ordered[i], placed[i] = dep, true
+		}
+		deps = ordered
+	}
+
+	for _, m := range removed {
+		var dep dependencyXML
+		if err := xml.Unmarshal([]byte(m.Content), &dep); err != nil {
+			return nil, fmt.Errorf("failed to parse the removed %s: %w", m.Element, err)
+		}
+		deps = append(deps, dep.Dependency)
+	}
+	return deps, nil
+}
+
+// parseExclusionList parses the exclusions exclusionList lists.
+func parseExclusionList(list string) *[]gopom.Exclusion {
+	if list == "" {
+		return nil
+	}
+	exclusions := []gopom.Exclusion{}
+	for _, e := range strings.Split(list, ",") {
+		groupID, artifactID, _ := strings.Cut(e, ":")
+		exclusions = append(exclusions, gopom.Exclusion{GroupID: groupID, ArtifactID: artifactID})
+	}
+	return &exclusions
+}
+
+// WriteRollbackState writes state to file, as JSON if its name ends in
+// .json and as YAML otherwise.
+func WriteRollbackState(file string, state *RollbackState) error {
+	var data []byte
+	var err error
+	if strings.HasSuffix(file, ".json") {
+		data, err = json.MarshalIndent(state, "", "  ")
+	} else {
+		data, err = yaml.Marshal(state)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal rollback state: %w", err)
+	}
+	return WriteFileAtomic(file, data, false)
+}
+
+// UpdateRollbackState reads the state in file, or starts one if there is
+// none, passes it to update and writes it back, holding LockFile meanwhile so
+// that concurrent runs do not lose each other's changes. Nothing is written
+// if update fails.
+func UpdateRollbackState(ctx context.Context, file string, update func(*RollbackState) error) error {
+	unlock, err := LockFile(ctx, file)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	state, err := ReadRollbackState(file)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		state = &RollbackState{}
+	case err != nil:
+		return err
+	}
+	if err := update(state); err != nil {
+		return err
+	}
+	return WriteRollbackState(file, state)
+}
+
+// ReadRollbackState reads a state written by WriteRollbackState, JSON or
+// YAML.
+func ReadRollbackState(file string) (*RollbackState, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading file: %w", err)
+	}
+	var state RollbackState
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse rollback state: %w", err)
+	}
+	return &state, nil
+}
@@ -0,0 +1,117 @@
+package pkg
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollback(t *testing.T) {
+	ctx := context.Background()
+	before := []byte(locatedPOM)
+	project, err := parsePOMData(before)
+	require.NoError(t, err)
+	project, err = PatchProject(ctx, project, []Patch{
+		{GroupID: "com.example", ArtifactID: "core", Operation: PatchOperationRemove},
+		{GroupID: "org.codehaus.mojo", ArtifactID: "extra-enforcer-rules", Version: "1.8.0", Scope: "import", Type: "jar"},
+	}, map[string]string{"netty.version": "4.1.100.Final"})
+	require.NoError(t, err)
+	after, err := MarshalPOM(project, nil)
+	require.NoError(t, err)
+
+	state := &RollbackState{}
+	require.NoError(t, state.Record("pom.xml", before, after))
+	// Writing the same again changes nothing.
+	require.NoError(t, state.Record("pom.xml", after, after))
+	require.Len(t, state.Changes, 1)
+	actions := map[string]int{}
+	for _, m := range state.Changes[0].Mutations {
+		actions[m.Action]++
+	}
+	assert.Equal(t, map[string]int{MutationSet: 2, MutationRemove: 1}, actions)
+	assert.Equal(t, []string{"pom.xml"}, state.POMs())
+
+	file := filepath.Join(t.TempDir(), "state.yaml")
+	require.NoError(t, WriteRollbackState(file, state))
+	state, err = ReadRollbackState(file)
+	require.NoError(t, err)
+
+	restored, err := state.Restore("pom.xml", after)
+	require.NoError(t, err)
+	original, err := parsePOMData(before)
+	require.NoError(t, err)
+	assert.Equal(t, original.Properties.Entries, restored.Properties.Entries)
+	assert.Equal(t, original.DependencyManagement, restored.DependencyManagement)
+	assert.Equal(t, original.Build.Plugins, restored.Build.Plugins)
+	// The removed dependency is back, last.
+	require.Len(t, *restored.Dependencies, 2)
+	assert.Equal(t, "io.netty", (*restored.Dependencies)[0].GroupID)
+	assert.Equal(t, trimDependency((*original.Dependencies)[0]), trimDependency((*restored.Dependencies)[1]))
+
+	_, err = state.Restore("pom.xml", before)
+	assert.ErrorContains(t, err, "changed since it was patched")
+	_, err = state.Restore("other/pom.xml", after)
+	assert.ErrorContains(t, err, "no changes recorded")
+
+	state.Forget("other/pom.xml")
+	assert.Equal(t, []string{"pom.xml"}, state.POMs())
+	state.Forget("pom.xml")
+	assert.Empty(t, state.Changes)
+}
+
+func TestUpdateRollbackState(t *testing.T) {
+	ctx := context.Background()
+	before := []byte(locatedPOM)
+	project, err := parsePOMData(before)
+	require.NoError(t, err)
+	project, err = PatchProject(ctx, project, nil, map[string]string{"netty.version": "4.1.100.Final"})
+	require.NoError(t, err)
+	after, err := MarshalPOM(project, nil)
+	require.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "state.json")
+	for _, pom := range []string{"pom.xml", "app/pom.xml"} {
+		require.NoError(t, UpdateRollbackState(ctx, file, func(state *RollbackState) error {
+			return state.Record(pom, before, after)
+		}))
+	}
+	// A failed update writes nothing.
+	require.Error(t, UpdateRollbackState(ctx, file, func(state *RollbackState) error {
+		return state.Record("bad.xml", []byte("not a POM"), after)
+	}))
+	state, err := ReadRollbackState(file)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pom.xml", "app/pom.xml"}, state.POMs())
+}
+
+func TestRevertDependencies(t *testing.T) {
+	// a, b, c and d became c, new, a and b.
+	path := "project/dependencies"
+	deps := []gopom.Dependency{
+		{GroupID: "g", ArtifactID: "c", Version: "3"},
+		{GroupID: "g", ArtifactID: "new", Version: "1"},
+		{GroupID: "g", ArtifactID: "a", Version: "2"},
+		{GroupID: "g", ArtifactID: "b", Version: "1"},
+	}
+	deps[2].Exclusions = &[]gopom.Exclusion{{GroupID: "x", ArtifactID: "y"}}
+	restored, err := revertDependencies(path, deps, []Mutation{
+		{Action: MutationSet, Element: path + "/dependency[g:a]/version", From: "1", To: "2"},
+		{Action: MutationAdd, Element: path + "/dependency[g:a]/exclusions", To: "x:y"},
+		{Action: MutationAdd, Element: path + "/dependency[g:new]", To: "1"},
+		{Action: MutationMove, Element: path + "/dependency[g:a]", From: "1", To: "2"},
+		{Action: MutationMove, Element: path + "/dependency[g:b]", From: "2", To: "3"},
+		{Action: MutationMove, Element: path + "/dependency[g:c]", From: "3", To: "1"},
+		{Action: MutationRemove, Element: path + "/dependency[g:d]", From: "4", Content: marshalDependency(gopom.Dependency{GroupID: "g", ArtifactID: "d", Version: "4"})},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []gopom.Dependency{
+		{GroupID: "g", ArtifactID: "a", Version: "1"},
+		{GroupID: "g", ArtifactID: "b", Version: "1"},
+		{GroupID: "g", ArtifactID: "c", Version: "3"},
+		{GroupID: "g", ArtifactID: "d", Version: "4"},
+	}, restored)
+}
@@ -0,0 +1,335 @@
+// Package rpc serves the gRPC API of pombump, defined in api/pombump/v1, on
+// top of a pkg.Analyzer.
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	pombumpv1 "github.com/chainguard-dev/pombump/api/pombump/v1"
+	"github.com/chainguard-dev/pombump/pkg"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements pombumpv1.PombumpServiceServer.
+type Server struct {
+	pombumpv1.UnimplementedPombumpServiceServer
+	analyzer *pkg.Analyzer
+	root     string
+}
+
+// ServerOption configures a Server.
+type ServerOption func(*Server)
+
+// WithRoot lets requests name POMs by path, as long as they resolve, symbolic
+// links included, under dir; relative paths are relative to dir. Without it,
+// requests must send the content of their POM.
+func WithRoot(dir string) ServerOption {
+	return func(s *Server) { s.root = dir }
+}
+
+// NewServer returns a server analyzing and patching POMs with analyzer.
+func NewServer(analyzer *pkg.Analyzer, opts ...ServerOption) *Server {
+	s := &Server{analyzer: analyzer}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// pomSource is a request carrying a POM, by path or content.
+type pomSource interface {
+	GetPath() string
+	GetContent() []byte
+}
+
+// withPOM calls fn with the path of the POM of req, written to a temporary
+// directory if sent as content.
+func (s *Server) withPOM(req pomSource, fn func(path string) error) error {
+	if req.GetPath() != "" {
+		path, err := s.resolvePath(req.GetPath())
+		if err != nil {
+			return err
+		}
+		return fn(path)
+	}
+	if len(req.GetContent()) == 0 {
+		return status.Error(codes.InvalidArgument, "either the path or the content of the POM is required")
+	}
+	dir, err := os.MkdirTemp("", "pombump-rpc-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "pom.xml")
+	if err := os.WriteFile(path, req.GetContent(), 0644); err != nil {
+		return fmt.Errorf("failed to write POM: %w", err)
+	}
+	return fn(path)
+}
+
+// resolvePath returns the real path of the POM at path, refusing it unless it
+// lies under the root of s.
+func (s *Server) resolvePath(path string) (string, error) {
+	if s.root == "" {
+		return "", status.Error(codes.PermissionDenied, "the server does not read POMs by path, send their content")
+	}
+	base, err := filepath.Abs(s.root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve root %s: %w", s.root, err)
+	}
+	root, err := filepath.EvalSymlinks(base)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve root %s: %w", s.root, err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	// Check the path as given first, so that resolving it tells nothing about
+	// the files outside of the root.
+	if !within(base, path) && !within(root, path) {
+		return "", status.Errorf(codes.PermissionDenied, "%s is outside of the root of the server", path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", status.Errorf(codes.NotFound, "failed to resolve %s: %v", path, err)
+	}
+	if !within(root, resolved) {
+		return "", status.Errorf(codes.PermissionDenied, "%s is outside of the root of the server", path)
+	}
+	return resolved, nil
+}
+
+// within reports whether path lies under the directory root.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Analyze implements pombumpv1.PombumpServiceServer.
+func (s *Server) Analyze(ctx context.Context, req *pombumpv1.AnalyzeRequest) (*pombumpv1.AnalyzeResponse, error) {
+	resp := &pombumpv1.AnalyzeResponse{Id: req.GetId()}
+	suppressions, err := pkg.ParseSuppressions(req.GetSuppress())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	err = s.withPOM(req, func(path string) error {
+		analysis, err := s.analyzer.Analyze(ctx, path)
+		if err != nil {
+			return err
+		}
+		analysis.Suppress(suppressions)
+		fillAnalysis(resp, analysis)
+		if len(req.GetPatches()) == 0 {
+			return nil
+		}
+		rec, err := s.analyzer.Recommend(ctx, analysis, toPatches(req.GetPatches()))
+		if err != nil {
+			return err
+		}
+		resp.DirectPatches = fromPatches(rec.DirectPatches)
+		resp.PropertyPatches = rec.PropertyPatches
+		fillRecommendationIssues(resp, rec)
+		return nil
+	})
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	return resp, nil
+}
+
+// Patch implements pombumpv1.PombumpServiceServer.
+func (s *Server) Patch(ctx context.Context, req *pombumpv1.PatchRequest) (*pombumpv1.PatchResponse, error) {
+	resp := &pombumpv1.PatchResponse{Id: req.GetId()}
+	err := s.withPOM(req, func(path string) error {
+		content, err := s.analyzer.Patch(ctx, path, toPatches(req.GetPatches()), req.GetPropertyPatches())
+		resp.Content = content
+		return err
+	})
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	return resp, nil
+}
+
+// AnalyzeStream implements pombumpv1.PombumpServiceServer.
+func (s *Server) AnalyzeStream(stream pombumpv1.PombumpService_AnalyzeStreamServer) error {
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resp, err := s.Analyze(stream.Context(), req)
+		if err != nil {
+			if ctxErr := stream.Context().Err(); ctxErr != nil {
+				return status.FromContextError(ctxErr).Err()
+			}
+			resp = &pombumpv1.AnalyzeResponse{Id: req.GetId(), Error: status.Convert(err).Message()}
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// PatchStream implements pombumpv1.PombumpServiceServer.
+func (s *Server) PatchStream(stream pombumpv1.PombumpService_PatchStreamServer) error {
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resp, err := s.Patch(stream.Context(), req)
+		if err != nil {
+			if ctxErr := stream.Context().Err(); ctxErr != nil {
+				return status.FromContextError(ctxErr).Err()
+			}
+			resp = &pombumpv1.PatchResponse{Id: req.GetId(), Error: status.Convert(err).Message()}
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// rpcError turns err into a gRPC status error, keeping the status of the
+// errors that already have one.
+func rpcError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+// fillAnalysis sets what resp reports about analysis.
+func fillAnalysis(resp *pombumpv1.AnalyzeResponse, analysis *pkg.AnalysisResult) {
+	current := analysis.CurrentVersions()
+	keys := make([]string, 0, len(analysis.Dependencies))
+	for key := range analysis.Dependencies {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		info := analysis.Dependencies[key]
+		dep := &pombumpv1.Dependency{
+			GroupId:         info.GroupID,
+			ArtifactId:      info.ArtifactID,
+			Version:         info.Version,
+			ResolvedVersion: current[key],
+			PropertyName:    info.PropertyName,
+			Optional:        info.Optional,
+		}
+		if info.ManagedBy != nil {
+			dep.ManagedBy = info.ManagedBy.String()
+		}
+		resp.Dependencies = append(resp.Dependencies, dep)
+	}
+	resp.Properties = analysis.Properties
+	for _, bom := range analysis.BOMs() {
+		resp.Boms = append(resp.Boms, &pombumpv1.BOM{
+			GroupId:      bom.GroupID,
+			ArtifactId:   bom.ArtifactID,
+			Version:      bom.Version,
+			PropertyName: bom.PropertyName,
+		})
+	}
+	for _, warning := range analysis.Warnings() {
+		resp.Warnings = append(resp.Warnings, warning.Message)
+		resp.Issues = append(resp.Issues, issue(warning.Code, warning.Message))
+	}
+	mismatches, _ := analysis.Suppressions().Mismatches(analysis.VersionMismatches)
+	for _, m := range mismatches {
+		resp.Issues = append(resp.Issues, issue(m.Code, analysis.ExplainMismatch(m)))
+	}
+	resp.Suppressed = fromSuppressed(analysis.SuppressedIssues())
+	resp.Report = analysis.AnalysisReport()
+}
+
+// fillRecommendationIssues adds the issues with the recommendation rec to
+// resp.
+func fillRecommendationIssues(resp *pombumpv1.AnalyzeResponse, rec *pkg.Recommendation) {
+	for _, bump := range rec.BOMBumps {
+		for _, warning := range bump.Warnings {
+			resp.Issues = append(resp.Issues, issue(warning.Code, warning.Message))
+		}
+	}
+	for _, c := range rec.Conflicts {
+		resp.Issues = append(resp.Issues, issue(c.Code, c.String()))
+	}
+	for _, u := range rec.Unfixable {
+		resp.Issues = append(resp.Issues, issue(u.Code, fmt.Sprintf("%s:%s %s: %s", u.GroupID, u.ArtifactID, u.Version, u.Reason)))
+	}
+	resp.Suppressed = append(resp.Suppressed, fromSuppressed(rec.Suppressed)...)
+}
+
+// fromSuppressed converts suppressed issues for a response.
+func fromSuppressed(suppressed []pkg.SuppressedIssue) []*pombumpv1.Issue {
+	out := make([]*pombumpv1.Issue, 0, len(suppressed))
+	for _, s := range suppressed {
+		i := issue(s.Code, s.Message)
+		i.SuppressedBy = s.By
+		out = append(out, i)
+	}
+	return out
+}
+
+// issue returns the Issue of a warning or issue with code.
+func issue(code, message string) *pombumpv1.Issue {
+	return &pombumpv1.Issue{Code: code, Name: pkg.CodeName(code), Message: message}
+}
+
+// toPatches converts the patches of a request.
+func toPatches(patches []*pombumpv1.Patch) []pkg.Patch {
+	out := make([]pkg.Patch, 0, len(patches))
+	for _, p := range patches {
+		out = append(out, pkg.Patch{
+			GroupID:    p.GetGroupId(),
+			ArtifactID: p.GetArtifactId(),
+			Version:    p.GetVersion(),
+			Scope:      p.GetScope(),
+			Type:       p.GetType(),
+			Classifier: p.GetClassifier(),
+			Operation:  p.GetOperation(),
+			Target:     p.GetTarget(),
+			Advisories: p.GetAdvisories(),
+			Reason:     p.GetReason(),
+		})
+	}
+	return out
+}
+
+// fromPatches converts patches for a response.
+func fromPatches(patches []pkg.Patch) []*pombumpv1.Patch {
+	out := make([]*pombumpv1.Patch, 0, len(patches))
+	for _, p := range patches {
+		out = append(out, &pombumpv1.Patch{
+			GroupId:    p.GroupID,
+			ArtifactId: p.ArtifactID,
+			Version:    p.Version,
+			Scope:      p.Scope,
+			Type:       p.Type,
+			Classifier: p.Classifier,
+			Operation:  p.Operation,
+			Target:     p.Target,
+			Advisories: p.Advisories,
+			Reason:     p.Reason,
+		})
+	}
+	return out
+}
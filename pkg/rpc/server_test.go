@@ -0,0 +1,200 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pombumpv1 "github.com/chainguard-dev/pombump/api/pombump/v1"
+	"github.com/chainguard-dev/pombump/pkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testPOM = `<project>
+  <modelVersion>4.0.0</modelVersion>
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0</version>
+  <properties>
+    <netty.version>4.1.94.Final</netty.version>
+  </properties>
+  <dependencies>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-handler</artifactId>
+      <version>${netty.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>org.json</groupId>
+      <artifactId>json</artifactId>
+      <version>20231013</version>
+    </dependency>
+  </dependencies>
+</project>
+`
+
+// testClient serves the API in memory and returns a client for it.
+func testClient(t *testing.T, opts ...ServerOption) pombumpv1.PombumpServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	pombumpv1.RegisterPombumpServiceServer(server, NewServer(pkg.NewAnalyzer(pkg.WithoutRemoteAccess()), opts...))
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return pombumpv1.NewPombumpServiceClient(conn)
+}
+
+func TestAnalyze(t *testing.T) {
+	client := testClient(t)
+	resp, err := client.Analyze(context.Background(), &pombumpv1.AnalyzeRequest{
+		Id:  "app",
+		Pom: &pombumpv1.AnalyzeRequest_Content{Content: []byte(testPOM)},
+		Patches: []*pombumpv1.Patch{
+			{GroupId: "io.netty", ArtifactId: "netty-handler", Version: "4.1.118.Final"},
+			{GroupId: "org.json", ArtifactId: "json", Version: "20240303"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "app", resp.GetId())
+	require.Len(t, resp.GetDependencies(), 2)
+	assert.Equal(t, "netty.version", resp.GetDependencies()[0].GetPropertyName())
+	assert.Equal(t, "4.1.94.Final", resp.GetDependencies()[0].GetResolvedVersion())
+	assert.Equal(t, map[string]string{"netty.version": "4.1.94.Final"}, resp.GetProperties())
+	assert.Equal(t, map[string]string{"netty.version": "4.1.118.Final"}, resp.GetPropertyPatches())
+	require.Len(t, resp.GetDirectPatches(), 1)
+	assert.Equal(t, "20240303", resp.GetDirectPatches()[0].GetVersion())
+	assert.Contains(t, resp.GetReport(), "netty.version")
+
+	_, err = client.Analyze(context.Background(), &pombumpv1.AnalyzeRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAnalyzeIssues(t *testing.T) {
+	client := testClient(t)
+	pom := strings.Replace(testPOM, "<version>20231013</version>", "<version>${json.version}</version>", 1)
+	resp, err := client.Analyze(context.Background(), &pombumpv1.AnalyzeRequest{
+		Pom: &pombumpv1.AnalyzeRequest_Content{Content: []byte(pom)},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.GetIssues(), 1)
+	assert.Equal(t, pkg.CodePropertyNotFound, resp.GetIssues()[0].GetCode())
+	assert.Equal(t, "property-not-found", resp.GetIssues()[0].GetName())
+	assert.Contains(t, resp.GetIssues()[0].GetMessage(), "json.version")
+
+	resp, err = client.Analyze(context.Background(), &pombumpv1.AnalyzeRequest{
+		Pom:      &pombumpv1.AnalyzeRequest_Content{Content: []byte(pom)},
+		Suppress: []string{"property-not-found"},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.GetIssues())
+	assert.Empty(t, resp.GetWarnings())
+	require.Len(t, resp.GetSuppressed(), 1)
+	assert.Equal(t, pkg.CodePropertyNotFound, resp.GetSuppressed()[0].GetSuppressedBy())
+
+	_, err = client.Analyze(context.Background(), &pombumpv1.AnalyzeRequest{
+		Pom:      &pombumpv1.AnalyzeRequest_Content{Content: []byte(pom)},
+		Suppress: []string{"POMBUMP-W999"},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAnalyzePath(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	require.NoError(t, os.Mkdir(root, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "pom.xml"), []byte(testPOM), 0644))
+	outside := filepath.Join(dir, "pom.xml")
+	require.NoError(t, os.WriteFile(outside, []byte(testPOM), 0644))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "link.xml")))
+
+	client := testClient(t, WithRoot(root))
+	resp, err := client.Analyze(context.Background(), &pombumpv1.AnalyzeRequest{
+		Pom: &pombumpv1.AnalyzeRequest_Path{Path: "pom.xml"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, resp.GetDependencies(), 2)
+	_, err = client.Analyze(context.Background(), &pombumpv1.AnalyzeRequest{
+		Pom: &pombumpv1.AnalyzeRequest_Path{Path: filepath.Join(root, "pom.xml")},
+	})
+	require.NoError(t, err)
+
+	for _, path := range []string{outside, "../pom.xml", "link.xml", "/etc/passwd"} {
+		_, err = client.Analyze(context.Background(), &pombumpv1.AnalyzeRequest{
+			Pom: &pombumpv1.AnalyzeRequest_Path{Path: path},
+		})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err), path)
+	}
+
+	_, err = testClient(t).Analyze(context.Background(), &pombumpv1.AnalyzeRequest{
+		Pom: &pombumpv1.AnalyzeRequest_Path{Path: filepath.Join(root, "pom.xml")},
+	})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestPatch(t *testing.T) {
+	client := testClient(t)
+	resp, err := client.Patch(context.Background(), &pombumpv1.PatchRequest{
+		Pom:             &pombumpv1.PatchRequest_Content{Content: []byte(testPOM)},
+		Patches:         []*pombumpv1.Patch{{GroupId: "org.json", ArtifactId: "json", Version: "20240303"}},
+		PropertyPatches: map[string]string{"netty.version": "4.1.118.Final"},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(resp.GetContent()), "<netty.version>4.1.118.Final</netty.version>")
+	assert.Contains(t, string(resp.GetContent()), "<version>20240303</version>")
+}
+
+func TestAnalyzeStream(t *testing.T) {
+	client := testClient(t)
+	stream, err := client.AnalyzeStream(context.Background())
+	require.NoError(t, err)
+	requests := []*pombumpv1.AnalyzeRequest{
+		{Id: "missing", Pom: &pombumpv1.AnalyzeRequest_Path{Path: t.TempDir() + "/pom.xml"}},
+		{Id: "app", Pom: &pombumpv1.AnalyzeRequest_Content{Content: []byte(testPOM)}},
+	}
+	for _, req := range requests {
+		require.NoError(t, stream.Send(req))
+	}
+	require.NoError(t, stream.CloseSend())
+
+	// A POM failing does not end the stream.
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "missing", resp.GetId())
+	assert.NotEmpty(t, resp.GetError())
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "app", resp.GetId())
+	assert.Empty(t, resp.GetError())
+	assert.Len(t, resp.GetDependencies(), 2)
+}
+
+func TestPatchStream(t *testing.T) {
+	client := testClient(t)
+	stream, err := client.PatchStream(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pombumpv1.PatchRequest{
+		Id:              "app",
+		Pom:             &pombumpv1.PatchRequest_Content{Content: []byte(testPOM)},
+		PropertyPatches: map[string]string{"netty.version": "4.1.118.Final"},
+	}))
+	require.NoError(t, stream.CloseSend())
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "app", resp.GetId())
+	assert.Contains(t, string(resp.GetContent()), "<netty.version>4.1.118.Final</netty.version>")
+}
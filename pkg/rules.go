@@ -0,0 +1,248 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/ghodss/yaml"
+)
+
+// The bumps a StrategyRule can forbid.
+const (
+	// ForbidMajor forbids changing the major version.
+	ForbidMajor = "major"
+	// ForbidMinor forbids changing the major or minor version, leaving only
+	// patch releases.
+	ForbidMinor = "minor"
+	// ForbidAny forbids changing the version at all.
+	ForbidAny = "any"
+)
+
+// StrategyRule forbids some version bumps of the artifacts it matches.
+type StrategyRule struct {
+	// GroupID and ArtifactID are path.Match patterns, ArtifactID matching
+	// any artifact if empty.
+	GroupID    string `json:"groupId" yaml:"groupId"`
+	ArtifactID string `json:"artifactId,omitempty" yaml:"artifactId,omitempty"`
+	// Forbid is ForbidMajor, ForbidMinor or ForbidAny.
+	Forbid string `json:"forbid,omitempty" yaml:"forbid,omitempty"`
+	// MaxVersion, if set, forbids bumps past it, pinning the artifacts at
+	// or below it.
+	MaxVersion string `json:"maxVersion,omitempty" yaml:"maxVersion,omitempty"`
+	// Reason is logged and reported when the rule drops a patch.
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// SkippedPatch is a patch, or property update, a policy such as
+// StrategyRules kept PatchStrategy from applying, see WithSkippedPatches, or
+// that was not applied as its version is already in effect, see
+// SkipEffectivePatches.
+type SkippedPatch struct {
+	// Name is groupId:artifactId, or the property.
+	Name string `json:"name" yaml:"name"`
+	// Current is the version in use, if known.
+	Current string `json:"current,omitempty" yaml:"current,omitempty"`
+	Version string `json:"version" yaml:"version"`
+	// Reason says which policy forbids it, and why, or that the version
+	// is already in effect.
+	Reason string `json:"reason" yaml:"reason"`
+}
+
+// StrategyRules is a StrategyHook applying an organization's rules, usually
+// read from a file with LoadStrategyRules:
+//
+//	rules:
+//	  - groupId: com.google.guava
+//	    artifactId: guava
+//	    forbid: major
+//	    reason: Guava majors break our annotation processors
+//	  - groupId: org.springframework*
+//	    forbid: minor
+//	  - groupId: org.apache.kafka
+//	    maxVersion: 3.6.2
+//
+// It drops the patches that would make a forbidden bump, and after
+// PatchStrategy the property patches that would make one to any dependency
+// using the property.
+type StrategyRules struct {
+	Rules []StrategyRule `json:"rules" yaml:"rules"`
+}
+
+// LoadStrategyRules reads strategy rules from a YAML or JSON file.
+func LoadStrategyRules(file string) (*StrategyRules, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read strategy rules file: %w", err)
+	}
+	rules := &StrategyRules{}
+	if err := yaml.Unmarshal(data, rules); err != nil {
+		return nil, fmt.Errorf("failed to parse strategy rules file: %w", err)
+	}
+	for i, rule := range rules.Rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+	return rules, nil
+}
+
+// validate checks the patterns of the rule, and that it forbids something.
+func (rule StrategyRule) validate() error {
+	if _, err := path.Match(rule.GroupID, ""); rule.GroupID == "" || err != nil {
+		return fmt.Errorf("invalid groupId pattern %q", rule.GroupID)
+	}
+	if _, err := path.Match(rule.ArtifactID, ""); err != nil {
+		return fmt.Errorf("invalid artifactId pattern %q", rule.ArtifactID)
+	}
+	if rule.Forbid == "" && rule.MaxVersion == "" {
+		return fmt.Errorf("set forbid, maxVersion or both")
+	}
+	if rule.Forbid != "" && !slices.Contains([]string{ForbidMajor, ForbidMinor, ForbidAny}, rule.Forbid) {
+		return fmt.Errorf("unknown forbid %q, use one of %s, %s or %s", rule.Forbid, ForbidMajor, ForbidMinor, ForbidAny)
+	}
+	return nil
+}
+
+// ParsePins parses pins into StrategyRules: groupId:artifactId never to
+// patch, or groupId:artifactId@version never to patch past version, where
+// * matches any part of the coordinates.
+func ParsePins(pins []string) (*StrategyRules, error) {
+	rules := &StrategyRules{}
+	for _, pin := range pins {
+		coordinates, version, pinned := strings.Cut(strings.TrimSpace(pin), "@")
+		groupID, artifactID, ok := strings.Cut(coordinates, ":")
+		if !ok || artifactID == "" || pinned && version == "" {
+			return nil, fmt.Errorf("invalid pin %q, use groupId:artifactId or groupId:artifactId@version", pin)
+		}
+		rule := StrategyRule{GroupID: groupID, ArtifactID: artifactID, MaxVersion: version}
+		if !pinned {
+			rule.Forbid = ForbidAny
+		}
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("invalid pin %q: %w", pin, err)
+		}
+		rules.Rules = append(rules.Rules, rule)
+	}
+	return rules, nil
+}
+
+// BeforeStrategy drops the patches bumping an artifact in a way a rule
+// forbids.
+func (r *StrategyRules) BeforeStrategy(ctx context.Context, result *AnalysisResult, patches []Patch) []Patch {
+	current := result.CurrentVersions()
+	allowed := make([]Patch, 0, len(patches))
+	for _, patch := range patches {
+		if !changesVersion(patch) {
+			allowed = append(allowed, patch)
+			continue
+		}
+		key := fmt.Sprintf("%s:%s", patch.GroupID, patch.ArtifactID)
+		if rule, ok := r.forbidding(patch.GroupID, patch.ArtifactID, current[key], patch.Version); ok {
+			logForbidden(ctx, rule, fmt.Sprintf("patch of %s to %s", key, patch.Version), current[key], patch.Version)
+			RecordSkipped(ctx, SkippedPatch{Name: key, Current: current[key], Version: patch.Version, Reason: rule.describe()})
+			continue
+		}
+		allowed = append(allowed, patch)
+	}
+	return allowed
+}
+
+// AfterStrategy drops the property patches, and the direct patches such as
+// BOM bumps, that end up bumping a dependency in a way a rule forbids.
+func (r *StrategyRules) AfterStrategy(ctx context.Context, result *AnalysisResult, directPatches []Patch, propertyPatches map[string]string) ([]Patch, map[string]string) {
+	allowedProperties := make(map[string]string, len(propertyPatches))
+	for _, name := range sortedKeys(propertyPatches) {
+		value := propertyPatches[name]
+		forbidden := false
+		for _, impact := range result.PropertyImpact(name, value) {
+			if rule, ok := r.forbidding(impact.GroupID, impact.ArtifactID, impact.Before, impact.After); ok {
+				logForbidden(ctx, rule, fmt.Sprintf("property %s to %s, used by %s:%s", name, value, impact.GroupID, impact.ArtifactID), impact.Before, impact.After)
+				RecordSkipped(ctx, SkippedPatch{Name: name, Current: result.Properties[name], Version: value,
+					Reason: fmt.Sprintf("it would bump %s:%s, %s", impact.GroupID, impact.ArtifactID, rule.describe())})
+				forbidden = true
+				break
+			}
+		}
+		if !forbidden {
+			allowedProperties[name] = value
+		}
+	}
+	return r.BeforeStrategy(ctx, result, directPatches), allowedProperties
+}
+
+// forbidding returns the first rule matching groupID:artifactID that
+// forbids going from version current to version.
+func (r *StrategyRules) forbidding(groupID, artifactID, current, version string) (StrategyRule, bool) {
+	for _, rule := range r.Rules {
+		if !matchGlob(rule.GroupID, groupID) || rule.ArtifactID != "" && !matchGlob(rule.ArtifactID, artifactID) {
+			continue
+		}
+		if rule.Forbid != "" && forbidsBump(rule.Forbid, current, version) || rule.MaxVersion != "" && aboveMax(version, rule.MaxVersion) {
+			return rule, true
+		}
+	}
+	return StrategyRule{}, false
+}
+
+// forbidsBump reports whether forbid forbids going from current to version.
+// An unknown current version forbids nothing but ForbidAny.
+func forbidsBump(forbid, current, version string) bool {
+	if forbid == ForbidAny {
+		return current != version
+	}
+	if current == "" || strings.Contains(current, "${") {
+		return false
+	}
+	n := 1
+	if forbid == ForbidMinor {
+		n = 2
+	}
+	from, to := numericPrefix(splitVersion(current), n), numericPrefix(splitVersion(version), n)
+	return len(from) == n && len(to) == n && !slices.Equal(from, to)
+}
+
+// aboveMax reports whether version is past max. A range is never, its
+// bounds being up to Maven.
+func aboveMax(version, max string) bool {
+	return !isVersionRange(version) && compareVersions(version, max) > 0
+}
+
+// changesVersion reports whether patch sets the version of an artifact,
+// rather than only its exclusions or where it is declared.
+func changesVersion(patch Patch) bool {
+	return patch.Version != "" && patch.Operation != PatchOperationRemove && patch.Operation != PatchOperationReorder
+}
+
+// logForbidden warns that a rule dropped what, going from current to version.
+func logForbidden(ctx context.Context, rule StrategyRule, what, current, version string) {
+	clog.FromContext(ctx).Warnf("Dropping the %s (%s -> %s), %s", what, current, version, rule.describe())
+}
+
+// describe says what the rule forbids, and why if it says.
+func (rule StrategyRule) describe() string {
+	forbids := []string{}
+	if rule.Forbid != "" {
+		forbids = append(forbids, fmt.Sprintf("forbid %s bumps of %s:%s", rule.Forbid, rule.GroupID, orAny(rule.ArtifactID)))
+	}
+	if rule.MaxVersion != "" {
+		forbids = append(forbids, fmt.Sprintf("pin %s:%s at or below %s", rule.GroupID, orAny(rule.ArtifactID), rule.MaxVersion))
+	}
+	description := "the rules " + strings.Join(forbids, " and ")
+	if rule.Reason != "" {
+		description += ": " + rule.Reason
+	}
+	return description
+}
+
+// orAny is pattern, or * if it is empty.
+func orAny(pattern string) string {
+	if pattern == "" {
+		return "*"
+	}
+	return pattern
+}
@@ -0,0 +1,143 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadStrategyRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`rules:
+  - groupId: com.google.guava
+    artifactId: guava
+    forbid: major
+    reason: Guava majors break our annotation processors
+  - groupId: org.springframework*
+    forbid: minor
+`), 0644))
+	rules, err := LoadStrategyRules(path)
+	require.NoError(t, err)
+	assert.Equal(t, []StrategyRule{
+		{GroupID: "com.google.guava", ArtifactID: "guava", Forbid: ForbidMajor, Reason: "Guava majors break our annotation processors"},
+		{GroupID: "org.springframework*", Forbid: ForbidMinor},
+	}, rules.Rules)
+
+	for _, content := range []string{
+		"rules:\n  - artifactId: guava\n    forbid: major\n",
+		"rules:\n  - groupId: com.google.guava\n    forbid: majors\n",
+		"rules:\n  - groupId: '[com'\n    forbid: major\n",
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		_, err := LoadStrategyRules(path)
+		assert.Error(t, err, content)
+	}
+}
+
+func TestStrategyRules(t *testing.T) {
+	ctx := context.Background()
+	result, err := AnalyzeProject(ctx, &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{"spring.version": "5.3.20"}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "com.google.guava", ArtifactID: "guava", Version: "31.1-jre"},
+			{GroupID: "org.springframework", ArtifactID: "spring-core", Version: "${spring.version}"},
+			{GroupID: "com.example", ArtifactID: "spring-extras", Version: "${spring.version}"},
+		},
+	})
+	require.NoError(t, err)
+	rules := &StrategyRules{Rules: []StrategyRule{
+		{GroupID: "com.google.guava", ArtifactID: "guava", Forbid: ForbidMajor},
+		{GroupID: "org.springframework*", Forbid: ForbidMinor},
+	}}
+
+	direct, properties := PatchStrategy(ctx, result, []Patch{
+		{GroupID: "com.google.guava", ArtifactID: "guava", Version: "33.0.0-jre"},
+	}, WithStrategyHooks(rules))
+	assert.Empty(t, direct)
+	assert.Empty(t, properties)
+
+	direct, _ = PatchStrategy(ctx, result, []Patch{
+		{GroupID: "com.google.guava", ArtifactID: "guava", Version: "31.2-jre"},
+	}, WithStrategyHooks(rules))
+	assert.Len(t, direct, 1)
+
+	// Bumping spring-extras would bump spring-core through the property.
+	_, properties = PatchStrategy(ctx, result, []Patch{
+		{GroupID: "com.example", ArtifactID: "spring-extras", Version: "5.4.0"},
+	}, WithStrategyHooks(rules))
+	assert.Empty(t, properties)
+
+	_, properties = PatchStrategy(ctx, result, []Patch{
+		{GroupID: "com.example", ArtifactID: "spring-extras", Version: "5.3.39"},
+	}, WithStrategyHooks(rules))
+	assert.Equal(t, map[string]string{"spring.version": "5.3.39"}, properties)
+}
+
+func TestForbidsBump(t *testing.T) {
+	assert.True(t, forbidsBump(ForbidMajor, "1.2.3", "2.0.0"))
+	assert.False(t, forbidsBump(ForbidMajor, "1.2.3", "1.9.0"))
+	assert.True(t, forbidsBump(ForbidMinor, "1.2.3", "1.3.0"))
+	assert.False(t, forbidsBump(ForbidMinor, "1.2.3", "1.2.9"))
+	assert.False(t, forbidsBump(ForbidMajor, "", "2.0.0"))
+	assert.False(t, forbidsBump(ForbidMajor, "${guava.version}", "2.0.0"))
+	assert.True(t, forbidsBump(ForbidAny, "1.2.3", "1.2.4"))
+	assert.False(t, forbidsBump(ForbidAny, "1.2.3", "1.2.3"))
+}
+
+func TestParsePins(t *testing.T) {
+	rules, err := ParsePins([]string{"com.google.guava:guava", "org.apache.kafka:*@3.6.2"})
+	require.NoError(t, err)
+	assert.Equal(t, []StrategyRule{
+		{GroupID: "com.google.guava", ArtifactID: "guava", Forbid: ForbidAny},
+		{GroupID: "org.apache.kafka", ArtifactID: "*", MaxVersion: "3.6.2"},
+	}, rules.Rules)
+
+	for _, pin := range []string{"guava", "com.google.guava:", "com.google.guava:guava@", "[com:guava"} {
+		_, err := ParsePins([]string{pin})
+		assert.Error(t, err, pin)
+	}
+}
+
+func TestStrategyRulesSkipped(t *testing.T) {
+	ctx := context.Background()
+	result, err := AnalyzeProject(ctx, &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{"kafka.version": "3.5.1"}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "com.google.guava", ArtifactID: "guava", Version: "31.1-jre"},
+			{GroupID: "org.apache.kafka", ArtifactID: "kafka-clients", Version: "${kafka.version}"},
+			{GroupID: "com.example", ArtifactID: "kafka-extras", Version: "${kafka.version}"},
+		},
+	})
+	require.NoError(t, err)
+	rules := &StrategyRules{Rules: []StrategyRule{
+		{GroupID: "com.google.guava", ArtifactID: "guava", Forbid: ForbidAny},
+		{GroupID: "org.apache.kafka", MaxVersion: "3.6.2", Reason: "3.7 drops ZooKeeper"},
+	}}
+
+	var skipped []SkippedPatch
+	direct, properties := PatchStrategy(ctx, result, []Patch{
+		{GroupID: "com.google.guava", ArtifactID: "guava", Version: "31.2-jre"},
+		{GroupID: "org.apache.kafka", ArtifactID: "kafka-clients", Version: "3.7.0"},
+		// Bumping kafka-extras would bump kafka-clients through the property.
+		{GroupID: "com.example", ArtifactID: "kafka-extras", Version: "3.7.0"},
+	}, WithStrategyHooks(rules), WithSkippedPatches(&skipped))
+	assert.Empty(t, direct)
+	assert.Empty(t, properties)
+	assert.Equal(t, []SkippedPatch{
+		{Name: "com.google.guava:guava", Current: "31.1-jre", Version: "31.2-jre", Reason: "the rules forbid any bumps of com.google.guava:guava"},
+		{Name: "org.apache.kafka:kafka-clients", Current: "3.5.1", Version: "3.7.0", Reason: "the rules pin org.apache.kafka:* at or below 3.6.2: 3.7 drops ZooKeeper"},
+		{Name: "kafka.version", Current: "3.5.1", Version: "3.7.0", Reason: "it would bump org.apache.kafka:kafka-clients, the rules pin org.apache.kafka:* at or below 3.6.2: 3.7 drops ZooKeeper"},
+	}, skipped)
+
+	skipped = nil
+	_, properties = PatchStrategy(ctx, result, []Patch{
+		{GroupID: "org.apache.kafka", ArtifactID: "kafka-clients", Version: "3.6.2"},
+	}, WithStrategyHooks(rules), WithSkippedPatches(&skipped))
+	assert.Equal(t, map[string]string{"kafka.version": "3.6.2"}, properties)
+	assert.Empty(t, skipped)
+}
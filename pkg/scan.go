@@ -0,0 +1,93 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// scanFinding is a single vulnerable Maven artifact reported by a scanner,
+// normalized from whatever format the scanner uses.
+type scanFinding struct {
+	ID            string
+	GroupID       string
+	ArtifactID    string
+	Installed     string
+	FixedVersions []string
+}
+
+// patchesFromFindings turns scanner findings into patches. Findings without
+// a fix are skipped. If several findings hit the same artifact, the highest
+// fixed version wins so that all of them get fixed, and the patch lists all
+// of their advisories.
+func patchesFromFindings(ctx context.Context, scanner string, findings []scanFinding) []Patch {
+	log := clog.FromContext(ctx)
+
+	patches := map[string]Patch{}
+	advisories := map[string][]string{}
+	for _, f := range findings {
+		if f.GroupID == "" || f.ArtifactID == "" {
+			log.Warnf("Skipping %s: unable to determine groupId/artifactId", f.ID)
+			continue
+		}
+		key := fmt.Sprintf("%s:%s", f.GroupID, f.ArtifactID)
+		if len(f.FixedVersions) == 0 {
+			log.Warnf("Skipping %s for %s: no fixed version available", f.ID, key)
+			continue
+		}
+		if f.ID != "" && !slices.Contains(advisories[key], f.ID) {
+			advisories[key] = append(advisories[key], f.ID)
+		}
+		fixed := minimalFixVersion(f.Installed, f.FixedVersions)
+		if existing, exists := patches[key]; exists && compareVersions(existing.Version, fixed) >= 0 {
+			log.Debugf("%s %s: %s already patched to %s", scanner, f.ID, key, existing.Version)
+			continue
+		}
+		log.Infof("%s %s: %s %s -> %s", scanner, f.ID, key, f.Installed, fixed)
+		patches[key] = Patch{GroupID: f.GroupID, ArtifactID: f.ArtifactID, Version: fixed, Scope: defaultScope, Type: defaultType}
+	}
+
+	// Keep the output stable.
+	keys := make([]string, 0, len(patches))
+	for k := range patches {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := make([]Patch, 0, len(keys))
+	for _, k := range keys {
+		p := patches[k]
+		if len(advisories[k]) > 0 {
+			p.Advisories = advisories[k]
+			sort.Strings(p.Advisories)
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// minimalFixVersion picks the lowest fixed version that is not older than
+// the current one. Scanners list a fix for every release line, and the
+// lowest one on or above the current version is the least disruptive
+// upgrade. If all of them are older, the highest one is returned.
+func minimalFixVersion(current string, versions []string) string {
+	best := ""
+	highest := versions[0]
+	for _, v := range versions {
+		if compareVersions(v, highest) > 0 {
+			highest = v
+		}
+		if current != "" && compareVersions(v, current) < 0 {
+			continue
+		}
+		if best == "" || compareVersions(v, best) < 0 {
+			best = v
+		}
+	}
+	if best == "" {
+		return highest
+	}
+	return best
+}
@@ -0,0 +1,62 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPatchesFromGrype(t *testing.T) {
+	got, err := PatchesFromGrype(context.Background(), "testdata/grype.json")
+	if err != nil {
+		t.Fatalf("PatchesFromGrype failed: %v", err)
+	}
+	want := []Patch{{
+		GroupID:    "com.fasterxml.jackson.core",
+		ArtifactID: "jackson-databind",
+		Version:    "2.13.4.2",
+		Scope:      defaultScope,
+		Type:       defaultType,
+		Advisories: []string{"GHSA-jjjh-jjxp-wpff"},
+	}, {
+		GroupID:    "io.netty",
+		ArtifactID: "netty-handler",
+		Version:    "4.1.100.Final",
+		Scope:      defaultScope,
+		Type:       defaultType,
+		Advisories: []string{"CVE-2023-34462", "CVE-2023-44487"},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("PatchesFromGrype (-want +got)\n%s", diff)
+	}
+
+	if _, err := PatchesFromGrype(context.Background(), "testdata/missing"); err == nil {
+		t.Errorf("expected error for missing report")
+	}
+}
+
+func TestPatchesFromTrivy(t *testing.T) {
+	got, err := PatchesFromTrivy(context.Background(), "testdata/trivy.json")
+	if err != nil {
+		t.Fatalf("PatchesFromTrivy failed: %v", err)
+	}
+	want := []Patch{{
+		GroupID:    "com.fasterxml.jackson.core",
+		ArtifactID: "jackson-databind",
+		Version:    "2.13.4.2",
+		Scope:      defaultScope,
+		Type:       defaultType,
+		Advisories: []string{"CVE-2022-42003"},
+	}, {
+		GroupID:    "io.netty",
+		ArtifactID: "netty-handler",
+		Version:    "4.1.100.Final",
+		Scope:      defaultScope,
+		Type:       defaultType,
+		Advisories: []string{"CVE-2023-34462", "CVE-2023-44487"},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("PatchesFromTrivy (-want +got)\n%s", diff)
+	}
+}
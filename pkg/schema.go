@@ -0,0 +1,278 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// POMNamespace is the XML namespace of the Maven 4.0.0 POM schema.
+const POMNamespace = "http://maven.apache.org/POM/4.0.0"
+
+// WarningSchemaViolation is a POM that does not follow the Maven 4.0.0 XSD,
+// which Maven may reject.
+const WarningSchemaViolation = "schema-violation"
+
+// SchemaViolation is a place where a POM does not follow the Maven 4.0.0
+// XSD.
+type SchemaViolation struct {
+	Line    int    `json:"line" yaml:"line"`
+	Message string `json:"message" yaml:"message"`
+}
+
+func (v SchemaViolation) String() string {
+	return fmt.Sprintf("line %d: %s", v.Line, v.Message)
+}
+
+// pomSchema is the part of the Maven 4.0.0 XSD that pombump edits: for each
+// complex type, the type of each child element it allows, "" for elements
+// whose content is not checked. Types in pomListTypes repeat their single
+// child, the children of the other types may appear at most once.
+var pomSchema = map[string]map[string]string{
+	"project": {
+		"modelVersion": "", "parent": "parent", "groupId": "", "artifactId": "", "version": "",
+		"packaging": "", "name": "", "description": "", "url": "", "inceptionYear": "",
+		"organization": "", "licenses": "", "developers": "", "contributors": "", "mailingLists": "",
+		"prerequisites": "", "modules": "modules", "scm": "", "issueManagement": "", "ciManagement": "",
+		"distributionManagement": "", "properties": "", "dependencyManagement": "dependencyManagement",
+		"dependencies": "dependencies", "repositories": "", "pluginRepositories": "", "build": "build",
+		"reports": "", "reporting": "", "profiles": "profiles",
+		// Maven 4.1.0.
+		"subprojects": "",
+	},
+	"parent": {
+		"groupId": "", "artifactId": "", "version": "", "relativePath": "",
+	},
+	"modules": {
+		"module": "",
+	},
+	"dependencyManagement": {
+		"dependencies": "dependencies",
+	},
+	"dependencies": {
+		"dependency": "dependency",
+	},
+	"dependency": {
+		"groupId": "", "artifactId": "", "version": "", "type": "", "classifier": "", "scope": "",
+		"systemPath": "", "exclusions": "exclusions", "optional": "",
+	},
+	"exclusions": {
+		"exclusion": "exclusion",
+	},
+	"exclusion": {
+		"groupId": "", "artifactId": "",
+	},
+	"build": {
+		"sourceDirectory": "", "scriptSourceDirectory": "", "testSourceDirectory": "", "outputDirectory": "",
+		"testOutputDirectory": "", "extensions": "", "defaultGoal": "", "resources": "", "testResources": "",
+		"directory": "", "finalName": "", "filters": "", "pluginManagement": "pluginManagement", "plugins": "plugins",
+	},
+	"pluginManagement": {
+		"plugins": "plugins",
+	},
+	"plugins": {
+		"plugin": "plugin",
+	},
+	"plugin": {
+		"groupId": "", "artifactId": "", "version": "", "extensions": "", "executions": "",
+		"dependencies": "dependencies", "goals": "", "inherited": "", "configuration": "",
+	},
+	"profiles": {
+		"profile": "profile",
+	},
+	"profile": {
+		"id": "", "activation": "", "build": "profileBuild", "modules": "modules", "distributionManagement": "",
+		"properties": "", "dependencyManagement": "dependencyManagement", "dependencies": "dependencies",
+		"repositories": "", "pluginRepositories": "", "reports": "", "reporting": "",
+		// Maven 4.1.0.
+		"subprojects": "",
+	},
+	"profileBuild": {
+		"defaultGoal": "", "resources": "", "testResources": "", "directory": "", "finalName": "",
+		"filters": "", "pluginManagement": "pluginManagement", "plugins": "plugins",
+	},
+}
+
+var pomListTypes = map[string]bool{
+	"modules": true, "dependencies": true, "exclusions": true, "plugins": true, "profiles": true,
+}
+
+// ValidatePOMSchema checks data, a POM, against the parts of the Maven 4.0.0
+// XSD covering the elements pombump edits: the root is a <project> in the
+// POM namespace with model version 4.0.0 (or Maven 4's 4.1.0), and every
+// element of the project, its parent, dependencies, exclusions, plugins and
+// profiles is one the schema allows there, at most once unless it is a list
+// entry. Content the schema leaves open, like <properties> and plugin
+// <configuration>, is not checked. It only fails if data is not
+// well-formed XML.
+func ValidatePOMSchema(data []byte) ([]SchemaViolation, error) {
+	return validatePOMSchema(bytes.NewReader(data))
+}
+
+// validatePOMSchema is ValidatePOMSchema, reading the POM from r.
+func validatePOMSchema(r io.Reader) ([]SchemaViolation, error) {
+	decoder := newPOMDecoder(r)
+	line := func() int {
+		line, _ := decoder.InputPos()
+		return line
+	}
+
+	type frame struct {
+		// typ is the schema type of the element, "" if unchecked.
+		typ  string
+		name string
+		seen map[string]bool
+	}
+	violations := []SchemaViolation{}
+	stack := []*frame{}
+	modelVersion, inModelVersion, rootSeen := "", false, false
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse POM: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			if len(stack) == 0 {
+				rootSeen = true
+				if name != "project" {
+					violations = append(violations, SchemaViolation{Line: line(), Message: fmt.Sprintf("the root element is <%s>, not <project>", name)})
+				}
+				if t.Name.Space != "" && t.Name.Space != POMNamespace {
+					violations = append(violations, SchemaViolation{Line: line(), Message: fmt.Sprintf("the namespace is %q, not %q", t.Name.Space, POMNamespace)})
+				}
+				stack = append(stack, &frame{typ: "project", name: name, seen: map[string]bool{}})
+				continue
+			}
+			parent := stack[len(stack)-1]
+			child := &frame{name: name, seen: map[string]bool{}}
+			if parent.typ != "" {
+				typ, allowed := pomSchema[parent.typ][name]
+				switch {
+				case !allowed:
+					violations = append(violations, SchemaViolation{Line: line(), Message: fmt.Sprintf("<%s> is not allowed in <%s>", name, parent.name)})
+				case parent.seen[name] && !pomListTypes[parent.typ]:
+					violations = append(violations, SchemaViolation{Line: line(), Message: fmt.Sprintf("<%s> appears more than once in <%s>", name, parent.name)})
+				default:
+					child.typ = typ
+				}
+				parent.seen[name] = true
+			}
+			inModelVersion = len(stack) == 1 && name == "modelVersion"
+			stack = append(stack, child)
+		case xml.CharData:
+			if inModelVersion {
+				modelVersion += string(t)
+			}
+		case xml.EndElement:
+			inModelVersion = false
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if !rootSeen {
+		return nil, fmt.Errorf("failed to parse POM: no root element")
+	}
+
+	switch modelVersion = strings.TrimSpace(modelVersion); modelVersion {
+	case ModelVersion40, ModelVersion41:
+	case "":
+		violations = append(violations, SchemaViolation{Line: 1, Message: "<modelVersion> is missing"})
+	default:
+		violations = append(violations, SchemaViolation{Line: 1, Message: fmt.Sprintf("the model version is %s, not %s", modelVersion, ModelVersion40)})
+	}
+	return violations, nil
+}
+
+// ValidateSchema checks the POM at path, the one analyzed, against the Maven
+// 4.0.0 XSD, see ValidatePOMSchema, and reports every violation as a
+// warning. The POM is streamed, however large. A POM recovered by
+// WithLenientParsing is not checked, its malformed-pom warning says enough.
+func (result *AnalysisResult) ValidateSchema(path string) error {
+	if len(result.malformed) > 0 {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open POM file: %w", err)
+	}
+	defer file.Close()
+	violations, err := validatePOMSchema(bufio.NewReader(file))
+	if err != nil {
+		return err
+	}
+	result.schemaViolations = []Warning{}
+	for _, v := range violations {
+		result.schemaViolations = append(result.schemaViolations, Warning{
+			Code:    CodeSchemaViolation,
+			Kind:    WarningSchemaViolation,
+			Message: fmt.Sprintf("%s does not follow the Maven 4.0.0 schema, %s", path, v),
+		})
+	}
+	return nil
+}
+
+// SchemaViolations returns the schema violations found by ValidateSchema,
+// but the suppressed ones.
+func (result *AnalysisResult) SchemaViolations() []Warning {
+	warnings, _ := result.suppressions.Warnings(result.schemaViolations)
+	return warnings
+}
+
+// schemaReport lists the schema violations of the analyzed POM.
+func (result *AnalysisResult) schemaReport() string {
+	warnings := result.SchemaViolations()
+	if len(warnings) == 0 {
+		return ""
+	}
+	var report strings.Builder
+	report.WriteString("Schema Violations:\n")
+	report.WriteString("------------------\n")
+	for _, warning := range warnings {
+		report.WriteString(fmt.Sprintf("  Warning: %s\n", FormatIssue(warning.Code, warning.Message)))
+	}
+	report.WriteString("\n")
+	return report.String()
+}
+
+// CheckPOMSchema checks out, a POM about to be written or printed in place
+// of original, against the Maven 4.0.0 XSD: it fails if out has violations
+// that original does not, so that pombump never makes a POM Maven accepted
+// one it rejects. original may be nil, for a POM that is not replacing one.
+func CheckPOMSchema(original, out []byte) error {
+	violations, err := ValidatePOMSchema(out)
+	if err != nil {
+		return err
+	}
+	// The violations are told apart by their message, the lines move.
+	known := map[string]int{}
+	if original != nil {
+		before, err := ValidatePOMSchema(original)
+		if err != nil {
+			return err
+		}
+		for _, v := range before {
+			known[v.Message]++
+		}
+	}
+	added := []string{}
+	for _, v := range violations {
+		if known[v.Message] > 0 {
+			known[v.Message]--
+			continue
+		}
+		added = append(added, v.String())
+	}
+	if len(added) > 0 {
+		return fmt.Errorf("the POM would not follow the Maven 4.0.0 schema: %s", strings.Join(added, "; "))
+	}
+	return nil
+}
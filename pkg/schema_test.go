@@ -0,0 +1,148 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePOMSchema(t *testing.T) {
+	testCases := []struct {
+		name string
+		pom  string
+		want []SchemaViolation
+	}{{
+		name: "valid",
+		pom: `<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <modelVersion>4.0.0</modelVersion>
+  <properties><anything.goes>1</anything.goes></properties>
+  <dependencies>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-handler</artifactId>
+      <version>4.1.118.Final</version>
+      <exclusions><exclusion><groupId>g</groupId><artifactId>a</artifactId></exclusion></exclusions>
+    </dependency>
+  </dependencies>
+  <build><plugins><plugin><artifactId>p</artifactId><configuration><any/></configuration></plugin></plugins></build>
+</project>`,
+		want: []SchemaViolation{},
+	}, {
+		name: "misplaced and repeated elements",
+		pom: `<project>
+  <modelVersion>4.0.0</modelVersion>
+  <dependencies>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-handler</artifactId>
+      <version>4.1.94.Final</version>
+      <version>4.1.118.Final</version>
+    </dependency>
+  </dependencies>
+  <dependency>
+    <groupId>g</groupId>
+  </dependency>
+</project>`,
+		want: []SchemaViolation{
+			{Line: 8, Message: "<version> appears more than once in <dependency>"},
+			{Line: 11, Message: "<dependency> is not allowed in <project>"},
+		},
+	}, {
+		name: "wrong namespace and no model version",
+		pom:  `<project xmlns="http://maven.apache.org/POM/3.0.0"></project>`,
+		want: []SchemaViolation{
+			{Line: 1, Message: `the namespace is "http://maven.apache.org/POM/3.0.0", not "http://maven.apache.org/POM/4.0.0"`},
+			{Line: 1, Message: "<modelVersion> is missing"},
+		},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ValidatePOMSchema([]byte(tc.pom))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	_, err := ValidatePOMSchema([]byte("<project><dependencies></project>"))
+	assert.Error(t, err)
+}
+
+func TestValidateSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pom.xml")
+	require.NoError(t, os.WriteFile(path, []byte(`<project>
+  <modelVersion>4.0.0</modelVersion>
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0</version>
+  <dependency/>
+</project>`), 0644))
+	analysis, err := AnalyzeProjectPath(context.Background(), path)
+	require.NoError(t, err)
+	require.NoError(t, analysis.ValidateSchema(path))
+	want := Warning{
+		Code:    CodeSchemaViolation,
+		Kind:    WarningSchemaViolation,
+		Message: path + " does not follow the Maven 4.0.0 schema, line 6: <dependency> is not allowed in <project>",
+	}
+	assert.Equal(t, []Warning{want}, analysis.SchemaViolations())
+	assert.Contains(t, analysis.Warnings(), want)
+	assert.Contains(t, analysis.AnalysisReport(), "Schema Violations:")
+
+	suppressions, err := ParseSuppressions([]string{WarningSchemaViolation})
+	require.NoError(t, err)
+	analysis.Suppress(suppressions)
+	assert.Empty(t, analysis.SchemaViolations())
+	require.Len(t, analysis.SuppressedIssues(), 1)
+}
+
+func TestCheckPOMSchema(t *testing.T) {
+	valid := []byte(`<project><modelVersion>4.0.0</modelVersion><artifactId>app</artifactId></project>`)
+	broken := []byte(`<project><modelVersion>4.0.0</modelVersion><artifactId>app</artifactId><artifactId>app</artifactId></project>`)
+	noModelVersion := []byte(`<project><artifactId>app</artifactId><version>2</version></project>`)
+
+	assert.NoError(t, CheckPOMSchema(valid, valid))
+	assert.NoError(t, CheckPOMSchema(nil, valid))
+	assert.ErrorContains(t, CheckPOMSchema(valid, broken), "line 1: <artifactId> appears more than once in <project>")
+	assert.ErrorContains(t, CheckPOMSchema(nil, noModelVersion), "<modelVersion> is missing")
+	// What the POM already broke is not for pombump to fix.
+	assert.NoError(t, CheckPOMSchema([]byte(`<project><artifactId>app</artifactId></project>`), noModelVersion))
+}
+
+func TestCheckPatchesBOMResolution(t *testing.T) {
+	repo := fakeRepository(t, map[string]string{
+		"io/netty/netty-bom/4.1.118.Final/netty-bom-4.1.118.Final.pom": nettyBOM("4.1.118.Final", map[string]string{
+			"netty-handler": "4.1.118.Final",
+			"netty-codec":   "4.1.118.Final",
+		}),
+	})
+	project := &gopom.Project{
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-handler"},
+		},
+		DependencyManagement: &gopom.DependencyManagement{Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.118.Final", Type: "pom", Scope: "import"},
+		}},
+	}
+	patches := []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final"},
+		// Not declared, but managed by the BOM.
+		{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "4.1.100.Final"},
+	}
+
+	results, err := CheckPatches(context.Background(), project, patches, nil)
+	require.NoError(t, err)
+	assert.False(t, results[0].Satisfied)
+	assert.False(t, results[1].Satisfied)
+
+	results, err = CheckPatches(context.Background(), project, patches, nil, WithBOMResolution(repo))
+	require.NoError(t, err)
+	assert.Equal(t, []CheckResult{
+		{Name: "io.netty:netty-handler", Requested: "4.1.118.Final", Actual: "4.1.118.Final", Satisfied: true},
+		{Name: "io.netty:netty-codec", Requested: "4.1.100.Final", Actual: "4.1.118.Final", Satisfied: true},
+	}, results)
+}
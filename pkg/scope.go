@@ -0,0 +1,149 @@
+package pkg
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/chainguard-dev/gopom"
+)
+
+// scopes are the Maven dependency scopes.
+var scopes = []string{"compile", "provided", "runtime", "test", "system", "import"}
+
+// SkippedDependency is a dependency left out of the analysis because of its
+// scope, see WithSkippedScopes.
+type SkippedDependency struct {
+	GroupID    string `json:"groupId" yaml:"groupId"`
+	ArtifactID string `json:"artifactId" yaml:"artifactId"`
+	Version    string `json:"version,omitempty" yaml:"version,omitempty"`
+	Scope      string `json:"scope" yaml:"scope"`
+}
+
+// ParseScopes checks that values are Maven scopes, e.g. test and provided,
+// and returns them lower-cased.
+func ParseScopes(values []string) ([]string, error) {
+	parsed := make([]string, 0, len(values))
+	for _, value := range values {
+		scope := strings.ToLower(strings.TrimSpace(value))
+		if !slices.Contains(scopes, scope) {
+			return nil, fmt.Errorf("unknown scope %q, use one of %s", value, strings.Join(scopes, ", "))
+		}
+		parsed = append(parsed, scope)
+	}
+	return parsed, nil
+}
+
+// WithSkippedScopes leaves the dependencies in scopes out of the analysis,
+// and so out of its warnings and of the patches recommended for it (see
+// SkipScopedPatches). They are listed in SkippedDependencies instead. A
+// dependency declared without a scope is in the compile scope, and the
+// dependencyManagement entry of a skipped dependency is skipped with it.
+func WithSkippedScopes(scopes []string) AnalyzeOption {
+	return func(o *analyzeOptions) {
+		o.skippedScopes = scopes
+	}
+}
+
+// skipsScope reports whether dep is in a skipped scope.
+func (result *AnalysisResult) skipsScope(dep gopom.Dependency) bool {
+	scope := dep.Scope
+	if scope == "" {
+		scope = "compile"
+	}
+	return slices.Contains(result.skippedScopes, scope)
+}
+
+// skipDependency lists dep in SkippedDependencies.
+func (result *AnalysisResult) skipDependency(dep gopom.Dependency) {
+	scope := dep.Scope
+	if scope == "" {
+		scope = "compile"
+	}
+	result.addSkippedDependency(SkippedDependency{GroupID: dep.GroupID, ArtifactID: dep.ArtifactID, Version: dep.Version, Scope: scope})
+}
+
+// addSkippedDependency lists dep in SkippedDependencies once, with the
+// version its dependencyManagement entry gives it if it is declared without
+// one.
+func (result *AnalysisResult) addSkippedDependency(dep SkippedDependency) {
+	for i, skipped := range result.SkippedDependencies {
+		if skipped.GroupID == dep.GroupID && skipped.ArtifactID == dep.ArtifactID {
+			if skipped.Version == "" {
+				result.SkippedDependencies[i].Version = dep.Version
+			}
+			return
+		}
+	}
+	result.SkippedDependencies = append(result.SkippedDependencies, dep)
+}
+
+// isSkippedDependency reports whether key, a groupId:artifactId, is only
+// declared in skipped scopes, and returns how.
+func (result *AnalysisResult) isSkippedDependency(key string) (SkippedDependency, bool) {
+	if _, ok := result.Dependencies[key]; ok {
+		return SkippedDependency{}, false
+	}
+	for _, skipped := range result.SkippedDependencies {
+		if fmt.Sprintf("%s:%s", skipped.GroupID, skipped.ArtifactID) == key {
+			return skipped, true
+		}
+	}
+	return SkippedDependency{}, false
+}
+
+// pruneSkippedDependencies removes from SkippedDependencies what is also
+// declared in a scope that is not skipped, and sorts them.
+func (result *AnalysisResult) pruneSkippedDependencies() {
+	result.SkippedDependencies = slices.DeleteFunc(result.SkippedDependencies, func(skipped SkippedDependency) bool {
+		_, ok := result.Dependencies[fmt.Sprintf("%s:%s", skipped.GroupID, skipped.ArtifactID)]
+		return ok
+	})
+	sort.Slice(result.SkippedDependencies, func(i, j int) bool {
+		a, b := result.SkippedDependencies[i], result.SkippedDependencies[j]
+		return a.GroupID+":"+a.ArtifactID < b.GroupID+":"+b.ArtifactID
+	})
+}
+
+// SkipScopedPatches drops the patches of the dependencies only declared in
+// skipped scopes, see WithSkippedScopes, and returns them as skipped due to
+// policy. Patches of anything else, including what is not declared at all,
+// are kept.
+func (result *AnalysisResult) SkipScopedPatches(patches []Patch) ([]Patch, []SkippedPatch) {
+	result.ensureDependencies()
+	if len(result.SkippedDependencies) == 0 {
+		return patches, nil
+	}
+	kept := make([]Patch, 0, len(patches))
+	var skipped []SkippedPatch
+	for _, patch := range patches {
+		key := fmt.Sprintf("%s:%s", patch.GroupID, patch.ArtifactID)
+		if dep, ok := result.isSkippedDependency(key); ok {
+			skipped = append(skipped, SkippedPatch{Name: key, Current: dep.Version, Version: patch.Version,
+				Reason: fmt.Sprintf("the %s scope is skipped", dep.Scope)})
+			continue
+		}
+		kept = append(kept, patch)
+	}
+	return kept, skipped
+}
+
+// skippedScopeReport lists the dependencies left out for their scope.
+func (result *AnalysisResult) skippedScopeReport() string {
+	if len(result.SkippedDependencies) == 0 {
+		return ""
+	}
+	var report strings.Builder
+	report.WriteString("Skipped For Their Scope (--skip-scopes):\n")
+	report.WriteString("----------------------------------------\n")
+	for _, dep := range result.SkippedDependencies {
+		coordinates := fmt.Sprintf("%s:%s", dep.GroupID, dep.ArtifactID)
+		if dep.Version != "" {
+			coordinates += ":" + dep.Version
+		}
+		report.WriteString(fmt.Sprintf("  %s (%s)\n", coordinates, dep.Scope))
+	}
+	report.WriteString("\n")
+	return report.String()
+}
@@ -0,0 +1,69 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes([]string{"test", " Provided"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test", "provided"}, scopes)
+
+	_, err = ParseScopes([]string{"tests"})
+	assert.Error(t, err)
+}
+
+func TestSkippedScopes(t *testing.T) {
+	ctx := context.Background()
+	project := &gopom.Project{
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "com.google.guava", ArtifactID: "guava", Version: "31.1-jre"},
+			{GroupID: "org.junit.jupiter", ArtifactID: "junit-jupiter", Version: "${junit5.version}", Scope: "test"},
+			{GroupID: "org.mockito", ArtifactID: "mockito-core", Scope: "test"},
+			{GroupID: "javax.servlet", ArtifactID: "javax.servlet-api", Version: "3.1.0", Scope: "provided"},
+			// Also declared in a scope that is not skipped.
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.90.Final", Scope: "test"},
+			{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.90.Final", Classifier: "linux"},
+		},
+		DependencyManagement: &gopom.DependencyManagement{Dependencies: &[]gopom.Dependency{
+			{GroupID: "org.mockito", ArtifactID: "mockito-core", Version: "4.11.0"},
+		}},
+	}
+	result, err := AnalyzeProject(ctx, project, WithSkippedScopes([]string{"test"}))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"com.google.guava:guava", "javax.servlet:javax.servlet-api", "io.netty:netty-handler"}, sortedKeys(result.Dependencies))
+	assert.Equal(t, []SkippedDependency{
+		{GroupID: "org.junit.jupiter", ArtifactID: "junit-jupiter", Version: "${junit5.version}", Scope: "test"},
+		{GroupID: "org.mockito", ArtifactID: "mockito-core", Version: "4.11.0", Scope: "test"},
+	}, result.SkippedDependencies)
+	// The undefined property is only used by a skipped dependency.
+	assert.Empty(t, result.UndefinedProperties())
+	assert.Contains(t, result.AnalysisReport(), "Skipped For Their Scope (--skip-scopes):\n----------------------------------------\n  org.junit.jupiter:junit-jupiter:${junit5.version} (test)\n  org.mockito:mockito-core:4.11.0 (test)\n")
+
+	patches, skipped := result.SkipScopedPatches([]Patch{
+		{GroupID: "com.google.guava", ArtifactID: "guava", Version: "32.0.0-jre"},
+		{GroupID: "org.mockito", ArtifactID: "mockito-core", Version: "5.0.0"},
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final"},
+		{GroupID: "org.example", ArtifactID: "undeclared", Version: "1.0.0"},
+	})
+	assert.Equal(t, []Patch{
+		{GroupID: "com.google.guava", ArtifactID: "guava", Version: "32.0.0-jre"},
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final"},
+		{GroupID: "org.example", ArtifactID: "undeclared", Version: "1.0.0"},
+	}, patches)
+	assert.Equal(t, []SkippedPatch{
+		{Name: "org.mockito:mockito-core", Current: "4.11.0", Version: "5.0.0", Reason: "the test scope is skipped"},
+	}, skipped)
+
+	// Without skipped scopes, nothing is.
+	result, err = AnalyzeProject(ctx, project)
+	require.NoError(t, err)
+	assert.Empty(t, result.SkippedDependencies)
+	assert.Len(t, result.UndefinedProperties(), 1)
+	assert.Contains(t, result.Dependencies, "org.mockito:mockito-core")
+}
@@ -0,0 +1,352 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/chainguard-dev/gopom"
+)
+
+// CentralRepositoryID is the id of Maven Central, the one settings.xml
+// mirrors and servers refer to it by.
+const CentralRepositoryID = "central"
+
+// Settings is the subset of a Maven settings.xml used to reach remote
+// repositories: the mirrors, the proxies, the server credentials and the
+// profiles, of which the active ones provide properties and repositories.
+type Settings struct {
+	Mirrors        []Mirror          `xml:"mirrors>mirror"`
+	Proxies        []Proxy           `xml:"proxies>proxy"`
+	Servers        []Server          `xml:"servers>server"`
+	Profiles       []SettingsProfile `xml:"profiles>profile"`
+	ActiveProfiles []string          `xml:"activeProfiles>activeProfile"`
+}
+
+// Mirror replaces the repositories matching MirrorOf, e.g. "*",
+// "external:*" or "central,!snapshots", with the repository at URL.
+type Mirror struct {
+	ID       string `xml:"id"`
+	URL      string `xml:"url"`
+	MirrorOf string `xml:"mirrorOf"`
+}
+
+// Proxy is an HTTP proxy to reach the repositories through. A proxy is
+// active unless Active is "false".
+type Proxy struct {
+	ID            string `xml:"id"`
+	Active        string `xml:"active"`
+	Protocol      string `xml:"protocol"`
+	Host          string `xml:"host"`
+	Port          int    `xml:"port"`
+	Username      string `xml:"username"`
+	Password      string `xml:"password"`
+	NonProxyHosts string `xml:"nonProxyHosts"`
+}
+
+// Server holds the credentials of the repository, or mirror, with the same
+// id: a username and password, and the HTTP headers to send (e.g. a token).
+type Server struct {
+	ID          string             `xml:"id"`
+	Username    string             `xml:"username"`
+	Password    string             `xml:"password"`
+	HTTPHeaders []SettingsProperty `xml:"configuration>httpHeaders>property"`
+}
+
+// SettingsProperty is a name and value pair, as in the httpHeaders of a
+// server.
+type SettingsProperty struct {
+	Name  string `xml:"name"`
+	Value string `xml:"value"`
+}
+
+// SettingsProfile is a profile of settings.xml.
+type SettingsProfile struct {
+	ID         string `xml:"id"`
+	Activation struct {
+		ActiveByDefault bool `xml:"activeByDefault"`
+	} `xml:"activation"`
+	Properties   *gopom.Properties    `xml:"properties"`
+	Repositories []SettingsRepository `xml:"repositories>repository"`
+}
+
+// SettingsRepository is a repository a settings profile declares.
+type SettingsRepository struct {
+	ID  string `xml:"id"`
+	URL string `xml:"url"`
+}
+
+// DefaultSettingsPath returns the path of the user settings.xml,
+// ~/.m2/settings.xml, or "" if the home directory is unknown.
+func DefaultSettingsPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".m2", "settings.xml")
+}
+
+// LoadSettings reads the settings.xml at path. If path is empty, the user
+// settings.xml is read if there is one, and empty settings are returned
+// otherwise.
+func LoadSettings(path string) (*Settings, error) {
+	explicit := path != ""
+	if !explicit {
+		path = DefaultSettingsPath()
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return &Settings{}, nil
+		}
+		return nil, fmt.Errorf("failed to open settings: %w", err)
+	}
+	defer f.Close()
+	settings, err := ParseSettings(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// settingsExpression matches the ${env.NAME} and ${user.home} references a
+// settings.xml can hold, typically to keep credentials out of it.
+var settingsExpression = regexp.MustCompile(`\$\{(env\.[A-Za-z_][A-Za-z0-9_]*|user\.home)\}`)
+
+// ParseSettings parses a settings.xml, replacing the ${env.NAME} and
+// ${user.home} references with their value.
+func ParseSettings(r io.Reader) (*Settings, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = settingsExpression.ReplaceAllFunc(data, func(match []byte) []byte {
+		name := string(match[2 : len(match)-1])
+		var value string
+		if name == "user.home" {
+			value, _ = os.UserHomeDir()
+		} else {
+			value = os.Getenv(strings.TrimPrefix(name, "env."))
+		}
+		var escaped bytes.Buffer
+		_ = xml.EscapeText(&escaped, []byte(value))
+		return escaped.Bytes()
+	})
+	var settings Settings
+	if err := xml.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// activeProfiles returns the profiles listed in activeProfiles, or active by
+// default, in the order they are declared.
+func (s *Settings) activeProfiles() []SettingsProfile {
+	active := []SettingsProfile{}
+	for _, p := range s.Profiles {
+		if p.Activation.ActiveByDefault || slices.Contains(s.ActiveProfiles, p.ID) {
+			active = append(active, p)
+		}
+	}
+	return active
+}
+
+// Properties returns the properties of the active profiles, the last
+// profile declared winning.
+func (s *Settings) Properties() map[string]string {
+	props := map[string]string{}
+	if s == nil {
+		return props
+	}
+	for _, p := range s.activeProfiles() {
+		if p.Properties == nil {
+			continue
+		}
+		for k, v := range p.Properties.Entries {
+			props[k] = v
+		}
+	}
+	return props
+}
+
+// repositoryID returns the id settings.xml knows the repository at
+// repoURL by: central for Maven Central, or the id of a repository of an
+// active profile. It is empty if the repository is unknown.
+func (s *Settings) repositoryID(repoURL string) string {
+	repoURL = strings.TrimSuffix(repoURL, "/")
+	if repoURL == DefaultRepositoryURL {
+		return CentralRepositoryID
+	}
+	for _, p := range s.activeProfiles() {
+		for _, r := range p.Repositories {
+			if strings.TrimSuffix(r.URL, "/") == repoURL {
+				return r.ID
+			}
+		}
+	}
+	return ""
+}
+
+// mirrorFor returns the mirror of the repository with the given id and url,
+// nil if none. As in Maven, a mirror of exactly that id wins over the
+// patterns.
+func (s *Settings) mirrorFor(id, repoURL string) *Mirror {
+	if id != "" {
+		for i, m := range s.Mirrors {
+			if m.MirrorOf == id {
+				return &s.Mirrors[i]
+			}
+		}
+	}
+	for i, m := range s.Mirrors {
+		if mirrorOfMatches(m.MirrorOf, id, repoURL) {
+			return &s.Mirrors[i]
+		}
+	}
+	return nil
+}
+
+// mirrorOfMatches reports whether the mirrorOf pattern, a comma separated
+// list of ids, "*", "external:*", "external:http:*" and "!id" exclusions,
+// matches the repository with the given id and url.
+func mirrorOfMatches(pattern, id, repoURL string) bool {
+	matched := false
+	for _, p := range strings.Split(pattern, ",") {
+		p = strings.TrimSpace(p)
+		switch {
+		case strings.HasPrefix(p, "!"):
+			if p[1:] == id {
+				return false
+			}
+		case p == "*":
+			matched = true
+		case p == "external:*":
+			matched = matched || !isLocalRepository(repoURL)
+		case p == "external:http:*":
+			matched = matched || (strings.HasPrefix(repoURL, "http:") && !isLocalRepository(repoURL))
+		case p != "" && p == id:
+			matched = true
+		}
+	}
+	return matched
+}
+
+// isLocalRepository reports whether repoURL is a file or localhost
+// repository, which external:* mirrors leave alone.
+func isLocalRepository(repoURL string) bool {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	return u.Scheme == "file" || host == "localhost" || host == "127.0.0.1"
+}
+
+// proxyFor returns the active proxy to reach repoURL through, nil if none.
+// A proxy of the same protocol as the repository is preferred, an HTTP one
+// is used otherwise.
+func (s *Settings) proxyFor(repoURL string) *Proxy {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return nil
+	}
+	var fallback *Proxy
+	for i, p := range s.Proxies {
+		if p.Active == "false" || p.Host == "" || nonProxyHost(p.NonProxyHosts, u.Hostname()) {
+			continue
+		}
+		protocol := p.Protocol
+		if protocol == "" {
+			protocol = "http"
+		}
+		if strings.EqualFold(protocol, u.Scheme) {
+			return &s.Proxies[i]
+		}
+		if fallback == nil && strings.EqualFold(protocol, "http") {
+			fallback = &s.Proxies[i]
+		}
+	}
+	return fallback
+}
+
+// nonProxyHost reports whether host matches the | (or comma) separated
+// nonProxyHosts patterns, which may use * wildcards.
+func nonProxyHost(patterns, host string) bool {
+	for _, p := range strings.FieldsFunc(patterns, func(r rune) bool { return r == '|' || r == ',' }) {
+		if ok, _ := filepath.Match(strings.ToLower(strings.TrimSpace(p)), strings.ToLower(host)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// URL returns the URL of the proxy, with its credentials.
+func (p *Proxy) URL() *url.URL {
+	port := p.Port
+	if port == 0 {
+		port = 8080
+	}
+	u := &url.URL{Scheme: "http", Host: p.Host + ":" + strconv.Itoa(port)}
+	if strings.EqualFold(p.Protocol, "https") {
+		u.Scheme = "https"
+	}
+	if p.Username != "" {
+		u.User = url.UserPassword(p.Username, p.Password)
+	}
+	return u
+}
+
+// Repository returns a client for the repository at repoURL as settings.xml
+// configures it: through its mirror, if any, with the credentials of the
+// server of the same id as the mirror or repository, and through the proxy.
+// Nil settings return the plain client of NewMavenRepository.
+func (s *Settings) Repository(repoURL string) *MavenRepository {
+	repo := NewMavenRepository(repoURL)
+	if s == nil {
+		return repo
+	}
+	id := s.repositoryID(repo.URL)
+	if mirror := s.mirrorFor(id, repo.URL); mirror != nil {
+		repo.URL = strings.TrimSuffix(mirror.URL, "/")
+		id = mirror.ID
+	}
+	for _, server := range s.Servers {
+		if id == "" || server.ID != id {
+			continue
+		}
+		repo.Username = server.Username
+		repo.Password = server.Password
+		if len(server.HTTPHeaders) > 0 {
+			repo.Headers = map[string]string{}
+			for _, h := range server.HTTPHeaders {
+				repo.Headers[h.Name] = h.Value
+			}
+		}
+		break
+	}
+	if proxy := s.proxyFor(repo.URL); proxy != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(proxy.URL())
+		repo.Client = NewHTTPClient(transport)
+	}
+	return repo
+}
+
+// WithSettings adds the properties of the active profiles of settings to the
+// ones the POM defines. Unlike in Maven, where they win, the POM's own
+// properties are kept, so that what is patched is what the POM declares.
+func WithSettings(settings *Settings) AnalyzeOption {
+	return func(o *analyzeOptions) {
+		o.settings = settings
+	}
+}
@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WarningSnapshot is a dependency or property resolving to a -SNAPSHOT
+// version, which a release must not depend on.
+const WarningSnapshot = "snapshot"
+
+// IsSnapshot reports whether version is a Maven SNAPSHOT version.
+func IsSnapshot(version string) bool {
+	return strings.HasSuffix(strings.ToUpper(version), "-SNAPSHOT")
+}
+
+// SnapshotVersions warns about every property and dependency whose version
+// resolves to a SNAPSHOT, properties first, sorted by name. Each warning has
+// the property or dependency, with its version, as its only artifact.
+func (result *AnalysisResult) SnapshotVersions() []Warning {
+	warnings, _ := result.suppressions.Warnings(result.snapshotVersions())
+	return warnings
+}
+
+// snapshotVersions is SnapshotVersions, suppressed ones included.
+func (result *AnalysisResult) snapshotVersions() []Warning {
+	result.ensureDependencies()
+	warnings := []Warning{}
+	for _, name := range sortedKeys(result.Properties) {
+		value := interpolate(result.Properties[name], result.Properties)
+		if !IsSnapshot(value) {
+			continue
+		}
+		warnings = append(warnings, Warning{
+			Code:      CodeSnapshot,
+			Kind:      WarningSnapshot,
+			Message:   fmt.Sprintf("Property %s is the SNAPSHOT version %s", name, value),
+			Artifacts: []VersionChange{{Name: name, Old: value}},
+		})
+	}
+	for _, key := range sortedKeys(result.Dependencies) {
+		info := result.Dependencies[key]
+		version := result.dependencyVersion(info)
+		if !IsSnapshot(version) {
+			continue
+		}
+		message := fmt.Sprintf("%s resolves to the SNAPSHOT version %s", key, version)
+		if info.UsesProperty {
+			message += fmt.Sprintf(" through the property %s", info.PropertyName)
+		} else if info.ManagedBy != nil {
+			message += " managed by " + managementSource(info.ManagedBy)
+		}
+		warnings = append(warnings, Warning{
+			Code:      CodeSnapshot,
+			Kind:      WarningSnapshot,
+			Message:   message,
+			Artifacts: []VersionChange{{Name: key, Old: version}},
+		})
+	}
+	return warnings
+}
+
+// Warnings returns every warning about the analyzed project itself, but the
+// suppressed ones: the properties used but not defined, the BOM versions
+// shadowed by an earlier BOM, the SNAPSHOT versions, and the schema
+// violations found by ValidateSchema.
+func (result *AnalysisResult) Warnings() []Warning {
+	warnings := result.UndefinedProperties()
+	warnings = append(warnings, result.ShadowedBOMVersions()...)
+	warnings = append(warnings, result.SnapshotVersions()...)
+	warnings = append(warnings, result.SchemaViolations()...)
+	return append(warnings, result.MalformedPOMs()...)
+}
+
+// snapshotReport lists the SNAPSHOT versions the project resolves to.
+func (result *AnalysisResult) snapshotReport() string {
+	warnings := result.SnapshotVersions()
+	if len(warnings) == 0 {
+		return ""
+	}
+	var report strings.Builder
+	report.WriteString("SNAPSHOT Versions:\n")
+	report.WriteString("------------------\n")
+	for _, warning := range warnings {
+		report.WriteString(fmt.Sprintf("  Warning: %s\n", FormatIssue(warning.Code, warning.Message)))
+	}
+	report.WriteString("\n")
+	return report.String()
+}
@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotVersions(t *testing.T) {
+	project, err := StreamPOM(strings.NewReader(`<project>
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0</version>
+  <properties>
+    <lib.version>2.0-SNAPSHOT</lib.version>
+    <alias.version>${lib.version}</alias.version>
+    <netty.version>4.1.94.Final</netty.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>com.example</groupId>
+        <artifactId>managed</artifactId>
+        <version>1.1-snapshot</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>com.example</groupId>
+      <artifactId>lib</artifactId>
+      <version>${lib.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>com.example</groupId>
+      <artifactId>managed</artifactId>
+    </dependency>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-handler</artifactId>
+      <version>${netty.version}</version>
+    </dependency>
+  </dependencies>
+</project>`))
+	require.NoError(t, err)
+	analysis, err := AnalyzeProject(context.Background(), project)
+	require.NoError(t, err)
+
+	warnings := analysis.SnapshotVersions()
+	assert.Equal(t, []Warning{
+		{Code: CodeSnapshot, Kind: WarningSnapshot, Message: "Property alias.version is the SNAPSHOT version 2.0-SNAPSHOT", Artifacts: []VersionChange{{Name: "alias.version", Old: "2.0-SNAPSHOT"}}},
+		{Code: CodeSnapshot, Kind: WarningSnapshot, Message: "Property lib.version is the SNAPSHOT version 2.0-SNAPSHOT", Artifacts: []VersionChange{{Name: "lib.version", Old: "2.0-SNAPSHOT"}}},
+		{Code: CodeSnapshot, Kind: WarningSnapshot, Message: "com.example:lib resolves to the SNAPSHOT version 2.0-SNAPSHOT through the property lib.version", Artifacts: []VersionChange{{Name: "com.example:lib", Old: "2.0-SNAPSHOT"}}},
+		{Code: CodeSnapshot, Kind: WarningSnapshot, Message: "com.example:managed resolves to the SNAPSHOT version 1.1-snapshot managed by dependencyManagement", Artifacts: []VersionChange{{Name: "com.example:managed", Old: "1.1-snapshot"}}},
+	}, warnings)
+	assert.Equal(t, warnings, analysis.Warnings())
+	assert.Equal(t, warnings, analysis.AggregateReport().Warnings)
+	assert.Contains(t, analysis.AnalysisReport(), "SNAPSHOT Versions:\n------------------\n  Warning: [POMBUMP-W004] Property alias.version is the SNAPSHOT version 2.0-SNAPSHOT\n")
+}
+
+func TestIsSnapshot(t *testing.T) {
+	assert.True(t, IsSnapshot("1.0-SNAPSHOT"))
+	assert.True(t, IsSnapshot("1.0-snapshot"))
+	assert.False(t, IsSnapshot("1.0"))
+	assert.False(t, IsSnapshot("SNAPSHOT"))
+	assert.False(t, IsSnapshot("${lib.version}"))
+}
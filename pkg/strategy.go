@@ -0,0 +1,76 @@
+package pkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Strategy forces how PatchStrategy patches a dependency, instead of the
+// built-in heuristics.
+type Strategy string
+
+const (
+	// StrategyAuto bumps the property the version of a dependency comes
+	// from, if any, and patches the dependency directly otherwise. It is
+	// the default.
+	StrategyAuto Strategy = "auto"
+	// StrategyPreferProperty also bumps the version property of the BOM
+	// managing a dependency, when the BOM versions track the dependency.
+	StrategyPreferProperty Strategy = "prefer-property"
+	// StrategyPreferDirect never bumps properties, other dependencies
+	// sharing them keep their version: the dependencies are patched with a
+	// literal version.
+	StrategyPreferDirect Strategy = "prefer-direct"
+	// StrategyPreferBOM bumps the BOM managing a dependency when the BOM
+	// versions track the dependency, through its property if it has one,
+	// and overrides the version ahead of the BOM otherwise (see
+	// WithBOMOverrides).
+	StrategyPreferBOM Strategy = "prefer-bom"
+)
+
+// Strategies are the known strategies.
+var Strategies = []Strategy{StrategyAuto, StrategyPreferProperty, StrategyPreferDirect, StrategyPreferBOM}
+
+// ParseStrategy returns the strategy named s, StrategyAuto if s is empty.
+func ParseStrategy(s string) (Strategy, error) {
+	if s == "" {
+		return StrategyAuto, nil
+	}
+	names := make([]string, 0, len(Strategies))
+	for _, strategy := range Strategies {
+		if string(strategy) == s {
+			return strategy, nil
+		}
+		names = append(names, string(strategy))
+	}
+	return "", fmt.Errorf("unknown strategy %q, use one of %s", s, strings.Join(names, ", "))
+}
+
+// WithStrategy makes PatchStrategy follow strategy.
+func WithStrategy(strategy Strategy) PatchStrategyOption {
+	return func(o *patchStrategyOptions) {
+		o.strategy = strategy
+	}
+}
+
+// trackingBOM returns the imported BOM managing the version patch bumps,
+// if its versions track the version of the artifact, like netty-bom does
+// netty: the BOM is resolved and at the version it manages the artifact
+// at or, failing that, of the same group and following the *-bom
+// convention. An artifact declared with a version of its own is not managed
+// by the BOM.
+func (result *AnalysisResult) trackingBOM(patch Patch) (BOMInfo, bool) {
+	if dep, exists := result.Dependencies[fmt.Sprintf("%s:%s", patch.GroupID, patch.ArtifactID)]; exists {
+		if dep.Version != "" || dep.ManagedBy != nil && dep.ManagedBy.Source != ManagedByBOM {
+			return BOMInfo{}, false
+		}
+	}
+	if bom, version, ok := result.ManagingBOM(patch.GroupID, patch.ArtifactID); ok {
+		return bom, interpolate(bom.Version, result.Properties) == version
+	}
+	bom, ok := result.BOMForGroup(patch.GroupID)
+	if !ok || bom.Managed != nil || bom.GroupID != patch.GroupID || !strings.HasSuffix(bom.ArtifactID, "-bom") {
+		return BOMInfo{}, false
+	}
+	return bom, true
+}
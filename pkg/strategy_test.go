@@ -0,0 +1,123 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strategyProject(bomVersion string) *gopom.Project {
+	return &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{
+			"slf4j.version": "2.0.7",
+			"netty.version": "4.1.94.Final",
+		}},
+		DependencyManagement: &gopom.DependencyManagement{Dependencies: &[]gopom.Dependency{
+			{GroupID: "io.netty", ArtifactID: "netty-bom", Version: bomVersion, Type: "pom", Scope: "import"},
+		}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "${slf4j.version}"},
+			{GroupID: "org.slf4j", ArtifactID: "slf4j-simple", Version: "${slf4j.version}"},
+			{GroupID: "io.netty", ArtifactID: "netty-handler"},
+			{GroupID: "io.netty", ArtifactID: "netty-codec"},
+		},
+	}
+}
+
+func TestPatchStrategyStrategies(t *testing.T) {
+	slf4j := Patch{GroupID: "org.slf4j", ArtifactID: "slf4j-api", Version: "2.0.10", Scope: "import", Type: "jar"}
+	handler := Patch{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final", Scope: "import", Type: "jar"}
+	codec := Patch{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "4.1.100.Final", Scope: "import", Type: "jar"}
+	bom := Patch{GroupID: "io.netty", ArtifactID: "netty-bom", Version: "4.1.100.Final", Scope: "import", Type: "pom"}
+	override := func(p Patch) Patch {
+		p.Operation = PatchOperationOverride
+		return p
+	}
+
+	tests := []struct {
+		name           string
+		bomVersion     string
+		strategy       Strategy
+		wantDirect     []Patch
+		wantProperties map[string]string
+	}{{
+		name:           "auto",
+		bomVersion:     "${netty.version}",
+		strategy:       StrategyAuto,
+		wantDirect:     []Patch{handler, codec},
+		wantProperties: map[string]string{"slf4j.version": "2.0.10"},
+	}, {
+		name:           "default is auto",
+		bomVersion:     "${netty.version}",
+		wantDirect:     []Patch{handler, codec},
+		wantProperties: map[string]string{"slf4j.version": "2.0.10"},
+	}, {
+		name:           "prefer-direct",
+		bomVersion:     "${netty.version}",
+		strategy:       StrategyPreferDirect,
+		wantDirect:     []Patch{slf4j, handler, codec},
+		wantProperties: map[string]string{},
+	}, {
+		name:           "prefer-property",
+		bomVersion:     "${netty.version}",
+		strategy:       StrategyPreferProperty,
+		wantDirect:     []Patch{},
+		wantProperties: map[string]string{"slf4j.version": "2.0.10", "netty.version": "4.1.100.Final"},
+	}, {
+		name:           "prefer-property with a literal BOM version",
+		bomVersion:     "4.1.94.Final",
+		strategy:       StrategyPreferProperty,
+		wantDirect:     []Patch{handler, codec},
+		wantProperties: map[string]string{"slf4j.version": "2.0.10"},
+	}, {
+		name:           "prefer-bom",
+		bomVersion:     "4.1.94.Final",
+		strategy:       StrategyPreferBOM,
+		wantDirect:     []Patch{bom},
+		wantProperties: map[string]string{"slf4j.version": "2.0.10"},
+	}, {
+		name:           "prefer-bom through its property",
+		bomVersion:     "${netty.version}",
+		strategy:       StrategyPreferBOM,
+		wantDirect:     []Patch{},
+		wantProperties: map[string]string{"slf4j.version": "2.0.10", "netty.version": "4.1.100.Final"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			analysis, err := AnalyzeProject(ctx, strategyProject(tt.bomVersion))
+			require.NoError(t, err)
+			direct, properties := PatchStrategy(ctx, analysis, []Patch{slf4j, handler, codec}, WithStrategy(tt.strategy))
+			assert.Equal(t, tt.wantDirect, direct)
+			assert.Equal(t, tt.wantProperties, properties)
+		})
+	}
+
+	// A resolved BOM that does not track the artifact is overridden.
+	ctx := context.Background()
+	analysis, err := AnalyzeProject(ctx, strategyProject("4.1.94.Final"))
+	require.NoError(t, err)
+	analysis.BOMs()[0].Managed = map[string]string{
+		"io.netty:netty-handler": "4.1.94.Final",
+		"io.netty:netty-codec":   "4.1.93.Final",
+	}
+	analysis.attributeVersionless()
+	direct, _ := PatchStrategy(ctx, analysis, []Patch{handler, codec}, WithStrategy(StrategyPreferBOM))
+	assert.Equal(t, []Patch{bom, override(codec)}, direct)
+}
+
+func TestParseStrategy(t *testing.T) {
+	for _, s := range []string{"auto", "prefer-property", "prefer-direct", "prefer-bom"} {
+		strategy, err := ParseStrategy(s)
+		require.NoError(t, err)
+		assert.Equal(t, Strategy(s), strategy)
+	}
+	strategy, err := ParseStrategy("")
+	require.NoError(t, err)
+	assert.Equal(t, StrategyAuto, strategy)
+	_, err = ParseStrategy("prefer-magic")
+	assert.Error(t, err)
+}
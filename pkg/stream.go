@@ -0,0 +1,216 @@
+package pkg
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/chainguard-dev/gopom"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DefaultStreamingThreshold is the size in bytes above which a POM is
+// streamed rather than read whole, see WithStreamingThreshold.
+const DefaultStreamingThreshold int64 = 8 << 20
+
+// WithStreamingThreshold sets the size in bytes above which the POMs read for
+// analysis are streamed: decoded as they are read, one dependency at a time,
+// skipping the elements the analysis does not use, rather than read whole
+// and then decoded. This keeps memory bounded for generated POMs with tens of
+// thousands of dependencyManagement entries. A size of 0 keeps
+// DefaultStreamingThreshold and a negative one never streams.
+func WithStreamingThreshold(size int64) AnalyzeOption {
+	return func(o *analyzeOptions) {
+		o.streamingThreshold = size
+	}
+}
+
+// ParseAnalysisPOM parses the POM at path for analysis, streaming it if it
+// is larger than the threshold set by WithStreamingThreshold. A streamed
+// project lacks the elements the analysis does not use, such as developers,
+// scm or reporting, so it must not be written back. Neither must a project
+// recovered from a malformed POM, see WithLenientParsing.
+func ParseAnalysisPOM(ctx context.Context, path string, opts ...AnalyzeOption) (*gopom.Project, error) {
+	options := &analyzeOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	threshold := options.streamingThreshold
+	if threshold == 0 {
+		threshold = DefaultStreamingThreshold
+	}
+	var project *gopom.Project
+	var err error
+	if info, statErr := os.Stat(path); threshold > 0 && statErr == nil && info.Size() > threshold {
+		project, err = streamPOMFile(ctx, path)
+	} else {
+		project, err = parsePOM(ctx, path)
+	}
+	if err != nil && options.lenient != nil {
+		return options.lenient.recover(ctx, path, err)
+	}
+	return project, err
+}
+
+// streamPOMFile is StreamPOM on the file at path, traced as parsePOM is.
+func streamPOMFile(ctx context.Context, path string) (*gopom.Project, error) {
+	_, end := startSpan(ctx, OperationParse, attribute.String("pombump.path", path), attribute.Bool("pombump.streamed", true))
+	project, err := func() (*gopom.Project, error) {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return StreamPOM(bufio.NewReader(file))
+	}()
+	end(err)
+	return project, err
+}
+
+// StreamPOM decodes a POM from r as it is read, keeping only what the
+// analysis uses: the coordinates, parent, properties, modules, dependencies,
+// dependencyManagement, repositories, build, profiles and licenses. Unlike
+// gopom.Parse, it never holds the whole file, and list entries such as
+// dependencies are decoded one at a time.
+func StreamPOM(r io.Reader) (*gopom.Project, error) {
+	decoder := newPOMDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, errors.New("no project element")
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "project" {
+			return nil, fmt.Errorf("expected element type <project> but have <%s>", start.Name.Local)
+		}
+		project := &gopom.Project{XMLName: start.Name}
+		if err := streamProject(decoder, project); err != nil {
+			return nil, err
+		}
+		return project, nil
+	}
+}
+
+// streamProject decodes the children of the <project> element into project,
+// up to its end.
+func streamProject(decoder *xml.Decoder, project *gopom.Project) error {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token := token.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			if err := streamProjectElement(decoder, token, project); err != nil {
+				return fmt.Errorf("failed to decode <%s>: %w", token.Name.Local, err)
+			}
+		}
+	}
+}
+
+// streamProjectElement decodes one child of <project> into project, or
+// skips it if the analysis does not use it.
+func streamProjectElement(decoder *xml.Decoder, start xml.StartElement, project *gopom.Project) error {
+	var err error
+	switch start.Name.Local {
+	case "modelVersion":
+		return decoder.DecodeElement(&project.ModelVersion, &start)
+	case "groupId":
+		return decoder.DecodeElement(&project.GroupID, &start)
+	case "artifactId":
+		return decoder.DecodeElement(&project.ArtifactID, &start)
+	case "version":
+		return decoder.DecodeElement(&project.Version, &start)
+	case "packaging":
+		return decoder.DecodeElement(&project.Packaging, &start)
+	case "name":
+		return decoder.DecodeElement(&project.Name, &start)
+	case "parent":
+		return decoder.DecodeElement(&project.Parent, &start)
+	case "properties":
+		return decoder.DecodeElement(&project.Properties, &start)
+	case "build":
+		return decoder.DecodeElement(&project.Build, &start)
+	case "modules":
+		project.Modules, err = streamList[string](decoder, "module")
+	case "dependencies":
+		project.Dependencies, err = streamList[gopom.Dependency](decoder, "dependency")
+	case "repositories":
+		project.Repositories, err = streamList[gopom.Repository](decoder, "repository")
+	case "profiles":
+		project.Profiles, err = streamList[gopom.Profile](decoder, "profile")
+	case "licenses":
+		project.Licenses, err = streamList[gopom.License](decoder, "license")
+	case "dependencyManagement":
+		project.DependencyManagement, err = streamDependencyManagement(decoder)
+	default:
+		return decoder.Skip()
+	}
+	return err
+}
+
+// streamDependencyManagement decodes the children of a
+// <dependencyManagement> element up to its end.
+func streamDependencyManagement(decoder *xml.Decoder) (*gopom.DependencyManagement, error) {
+	management := &gopom.DependencyManagement{}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.EndElement:
+			return management, nil
+		case xml.StartElement:
+			if token.Name.Local != "dependencies" {
+				if err := decoder.Skip(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if management.Dependencies, err = streamList[gopom.Dependency](decoder, "dependency"); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// streamList decodes the <name> children of the current element one at a
+// time, up to its end, skipping any other child.
+func streamList[T any](decoder *xml.Decoder, name string) (*[]T, error) {
+	list := []T{}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.EndElement:
+			return &list, nil
+		case xml.StartElement:
+			if token.Name.Local != name {
+				if err := decoder.Skip(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			var item T
+			if err := decoder.DecodeElement(&item, &token); err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+	}
+}
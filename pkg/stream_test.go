@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generatedPOM is a POM managing n artifacts, as generated BOMs do, with
+// elements the analysis does not use around them.
+func generatedPOM(n int) string {
+	var pom strings.Builder
+	pom.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <modelVersion>4.0.0</modelVersion>
+  <parent><groupId>org.example</groupId><artifactId>parent</artifactId><version>1</version></parent>
+  <artifactId>generated-bom</artifactId>
+  <packaging>pom</packaging>
+  <licenses><license><name>Apache-2.0</name></license></licenses>
+  <properties><netty.version>4.1.94.Final</netty.version></properties>
+  <modules><module>core</module></modules>
+  <dependencyManagement>
+    <dependencies>
+`)
+	for i := range n {
+		fmt.Fprintf(&pom, "      <dependency><groupId>org.example</groupId><artifactId>artifact-%d</artifactId><version>1.%d</version></dependency>\n", i, i)
+	}
+	pom.WriteString(`      <!-- not a dependency -->
+      <dependency><groupId>io.netty</groupId><artifactId>netty-handler</artifactId><version>${netty.version}</version>
+        <exclusions><exclusion><groupId>*</groupId><artifactId>*</artifactId></exclusion></exclusions>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency><groupId>io.netty</groupId><artifactId>netty-handler</artifactId></dependency>
+  </dependencies>
+  <build><plugins><plugin><artifactId>maven-shade-plugin</artifactId><dependencies>
+    <dependency><groupId>org.ow2.asm</groupId><artifactId>asm</artifactId><version>9.5</version></dependency>
+  </dependencies></plugin></plugins></build>
+  <profiles><profile><id>extra</id><properties><extra.version>2</extra.version></properties></profile></profiles>
+  <scm><url>https://example.com</url></scm>
+</project>
+`)
+	return pom.String()
+}
+
+func TestStreamPOM(t *testing.T) {
+	pom := generatedPOM(1000)
+	streamed, err := StreamPOM(strings.NewReader(pom))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "pom.xml")
+	require.NoError(t, os.WriteFile(path, []byte(pom), 0644))
+	parsed, err := gopom.Parse(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, parsed.ArtifactID, streamed.ArtifactID)
+	assert.Equal(t, parsed.Packaging, streamed.Packaging)
+	assert.Equal(t, parsed.Parent, streamed.Parent)
+	assert.Equal(t, parsed.Properties.Entries, streamed.Properties.Entries)
+	assert.Equal(t, parsed.Modules, streamed.Modules)
+	assert.Equal(t, parsed.Dependencies, streamed.Dependencies)
+	assert.Equal(t, parsed.DependencyManagement, streamed.DependencyManagement)
+	assert.Len(t, *streamed.DependencyManagement.Dependencies, 1001)
+	assert.Equal(t, parsed.Build, streamed.Build)
+	assert.Equal(t, parsed.Profiles, streamed.Profiles)
+	assert.Equal(t, parsed.Licenses, streamed.Licenses)
+	// What the analysis does not use is skipped.
+	assert.Nil(t, streamed.SCM)
+
+	_, err = StreamPOM(strings.NewReader(`<settings><profiles/></settings>`))
+	assert.ErrorContains(t, err, "expected element type <project>")
+	_, err = StreamPOM(strings.NewReader(`<project><dependencies><dependency>`))
+	assert.Error(t, err)
+	_, err = StreamPOM(strings.NewReader(``))
+	assert.Error(t, err)
+}
+
+func TestParseAnalysisPOM(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "pom.xml")
+	require.NoError(t, os.WriteFile(path, []byte(generatedPOM(100)), 0644))
+
+	// Below the threshold the POM is read whole.
+	project, err := ParseAnalysisPOM(ctx, path)
+	require.NoError(t, err)
+	assert.NotNil(t, project.SCM)
+	project, err = ParseAnalysisPOM(ctx, path, WithStreamingThreshold(1))
+	require.NoError(t, err)
+	assert.Nil(t, project.SCM)
+	project, err = ParseAnalysisPOM(ctx, path, WithStreamingThreshold(-1))
+	require.NoError(t, err)
+	assert.NotNil(t, project.SCM)
+
+	// Streaming does not change the analysis.
+	whole, err := AnalyzeProjectPath(ctx, path, WithStreamingThreshold(-1))
+	require.NoError(t, err)
+	streamed, err := AnalyzeProjectPath(ctx, path, WithStreamingThreshold(1))
+	require.NoError(t, err)
+	assert.Equal(t, whole.Dependencies, streamed.Dependencies)
+	assert.Equal(t, whole.Properties, streamed.Properties)
+	assert.Equal(t, whole.PropertyUsageCounts, streamed.PropertyUsageCounts)
+}
@@ -0,0 +1,174 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+)
+
+// Sections a patch can be targeted at.
+const (
+	targetDependencies         = "dependencies"
+	targetDependencyManagement = "dependencyManagement"
+)
+
+// patchTarget is a parsed Patch.Target selector. The supported forms are:
+//
+//	dependencies
+//	dependencyManagement
+//	profile:<id>/dependencies
+//	profile:<id>/dependencyManagement
+//	plugin:<artifactId>/dependencies
+type patchTarget struct {
+	section string
+	profile string
+	plugin  string
+}
+
+// parseTarget parses a Patch.Target selector.
+func parseTarget(target string) (*patchTarget, error) {
+	invalid := fmt.Errorf("invalid target %q, use dependencies, dependencyManagement, profile:<id>/dependencies, profile:<id>/dependencyManagement or plugin:<artifactId>/dependencies", target)
+	t := &patchTarget{}
+	section := target
+	switch {
+	case strings.HasPrefix(target, "profile:"):
+		var found bool
+		t.profile, section, found = strings.Cut(strings.TrimPrefix(target, "profile:"), "/")
+		if !found || t.profile == "" {
+			return nil, invalid
+		}
+	case strings.HasPrefix(target, "plugin:"):
+		var found bool
+		t.plugin, section, found = strings.Cut(strings.TrimPrefix(target, "plugin:"), "/")
+		if !found || t.plugin == "" || section != targetDependencies {
+			return nil, invalid
+		}
+	}
+	if section != targetDependencies && section != targetDependencyManagement {
+		return nil, invalid
+	}
+	t.section = section
+	return t, nil
+}
+
+// dependencyLists returns the dependency lists the target refers to,
+// creating the section if it does not exist yet. Only plugins can map to
+// more than one list, when the plugin is both in build/plugins and in
+// build/pluginManagement.
+func (t *patchTarget) dependencyLists(project *gopom.Project) ([]*[]gopom.Dependency, error) {
+	switch {
+	case t.profile != "":
+		if project.Profiles != nil {
+			for i := range *project.Profiles {
+				profile := &(*project.Profiles)[i]
+				if profile.ID == t.profile {
+					return []*[]gopom.Dependency{sectionList(&profile.Dependencies, &profile.DependencyManagement, t.section)}, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("profile %q not found", t.profile)
+	case t.plugin != "":
+		lists := []*[]gopom.Dependency{}
+		if project.Build != nil {
+			for _, plugins := range []*[]gopom.Plugin{project.Build.Plugins, pluginManagementPlugins(&project.Build.BuildBase)} {
+				if plugins == nil {
+					continue
+				}
+				for i := range *plugins {
+					plugin := &(*plugins)[i]
+					if plugin.ArtifactID != t.plugin {
+						continue
+					}
+					if plugin.Dependencies == nil {
+						plugin.Dependencies = &[]gopom.Dependency{}
+					}
+					lists = append(lists, plugin.Dependencies)
+				}
+			}
+		}
+		if len(lists) == 0 {
+			return nil, fmt.Errorf("plugin %q not found", t.plugin)
+		}
+		return lists, nil
+	}
+	return []*[]gopom.Dependency{sectionList(&project.Dependencies, &project.DependencyManagement, t.section)}, nil
+}
+
+// sectionList returns either the dependencies or the dependencyManagement
+// dependencies, creating them if needed.
+func sectionList(deps **[]gopom.Dependency, dm **gopom.DependencyManagement, section string) *[]gopom.Dependency {
+	if section == targetDependencies {
+		if *deps == nil {
+			*deps = &[]gopom.Dependency{}
+		}
+		return *deps
+	}
+	if *dm == nil {
+		*dm = &gopom.DependencyManagement{}
+	}
+	if (*dm).Dependencies == nil {
+		(*dm).Dependencies = &[]gopom.Dependency{}
+	}
+	return (*dm).Dependencies
+}
+
+func pluginManagementPlugins(build *gopom.BuildBase) *[]gopom.Plugin {
+	if build.PluginManagement == nil {
+		return nil
+	}
+	return build.PluginManagement.Plugins
+}
+
+// applyTargetedPatch applies a patch exactly where its Target says, adding
+// the dependency there if it is not present yet.
+func applyTargetedPatch(ctx context.Context, project *gopom.Project, patch Patch) error {
+	log := clog.FromContext(ctx)
+	target, err := parseTarget(patch.Target)
+	if err != nil {
+		return err
+	}
+	lists, err := target.dependencyLists(project)
+	if err != nil {
+		return fmt.Errorf("patch %s.%s: %w", patch.GroupID, patch.ArtifactID, err)
+	}
+	matching := matchingDependencies
+	if patch.Operation == PatchOperationAdd {
+		// Adding a classified or test-jar variant of a declared artifact
+		// is not the same as bumping it.
+		matching = exactDependencies
+	}
+	props := coordinateProperties(project)
+	found := false
+	for _, list := range lists {
+		for _, i := range matching(*list, patch, props) {
+			dep := (*list)[i]
+			log.Infof("Patching %s.%s in %s from %s to %s", patch.GroupID, patch.ArtifactID, patch.Target, dep.Version, patch.Version)
+			patchDependency(&(*list)[i], patch, props)
+			found = true
+		}
+	}
+	if !found && patch.Version == "" {
+		log.Warnf("%s.%s not found in %s, not adding it without a version", patch.GroupID, patch.ArtifactID, patch.Target)
+	} else if !found {
+		log.Infof("Adding %s.%s:%s to %s", patch.GroupID, patch.ArtifactID, patch.Version, patch.Target)
+		scope := patch.Scope
+		// The import scope is only valid for a BOM, in dependencyManagement.
+		if scope == "import" && (target.section != targetDependencyManagement || patch.Type != "pom") {
+			scope = ""
+		}
+		dep := gopom.Dependency{
+			GroupID:    patch.GroupID,
+			ArtifactID: patch.ArtifactID,
+			Version:    patch.Version,
+			Scope:      scope,
+			Type:       patch.Type,
+			Classifier: patch.Classifier,
+		}
+		applyExclusions(&dep, patch.Exclusions)
+		*lists[0] = append(*lists[0], dep)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package pkg
+
+import (
+	"context"
+	"time"
+
+	"github.com/chainguard-dev/gopom"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Operations that are traced, and reported to the metrics callback.
+const (
+	// OperationParse is parsing a POM, local or fetched.
+	OperationParse = "parse"
+	// OperationAnalyze is analyzing a project.
+	OperationAnalyze = "analyze"
+	// OperationResolve is resolving symbolic versions (advisories,
+	// keywords) or verifying versions against a repository.
+	OperationResolve = "resolve"
+	// OperationFetch is a single request to a Maven repository or OSV, so
+	// that network latency can be told apart from the rest.
+	OperationFetch = "fetch"
+	// OperationPlan is deciding between direct and property patches.
+	OperationPlan = "plan"
+	// OperationApply is patching a project.
+	OperationApply = "apply"
+)
+
+const tracerName = "github.com/chainguard-dev/pombump/pkg"
+
+// MetricsFunc is called when an operation completes, with how long it took
+// and the error it failed with, if any.
+type MetricsFunc func(ctx context.Context, operation string, duration time.Duration, err error)
+
+// TelemetryOption configures how pombump reports what it is doing.
+type TelemetryOption func(*telemetry)
+
+type telemetry struct {
+	tracerProvider trace.TracerProvider
+	metrics        MetricsFunc
+}
+
+// WithTracerProvider makes pombump create its spans with tp rather than the
+// global OpenTelemetry tracer provider.
+func WithTracerProvider(tp trace.TracerProvider) TelemetryOption {
+	return func(t *telemetry) {
+		t.tracerProvider = tp
+	}
+}
+
+// WithMetrics makes pombump call fn every time an operation completes.
+func WithMetrics(fn MetricsFunc) TelemetryOption {
+	return func(t *telemetry) {
+		t.metrics = fn
+	}
+}
+
+type telemetryKey struct{}
+
+// WithTelemetry returns a context that makes the pkg functions called with it
+// report spans and metrics as configured by opts. Without it, spans go to
+// the global OpenTelemetry tracer provider, which does nothing unless it has
+// been set up.
+func WithTelemetry(ctx context.Context, opts ...TelemetryOption) context.Context {
+	t := &telemetry{}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return context.WithValue(ctx, telemetryKey{}, t)
+}
+
+// startSpan starts a span for operation. The returned function ends it,
+// recording err, and reports the duration to the metrics callback.
+func startSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, func(err error)) {
+	t, _ := ctx.Value(telemetryKey{}).(*telemetry)
+	var tp trace.TracerProvider
+	if t != nil && t.tracerProvider != nil {
+		tp = t.tracerProvider
+	} else {
+		tp = otel.GetTracerProvider()
+	}
+
+	start := time.Now()
+	ctx, span := tp.Tracer(tracerName).Start(ctx, "pombump."+operation, trace.WithAttributes(attrs...))
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		if t != nil && t.metrics != nil {
+			t.metrics(ctx, operation, time.Since(start), err)
+		}
+	}
+}
+
+// parsePOM is ParsePOMFile, traced.
+func parsePOM(ctx context.Context, path string) (*gopom.Project, error) {
+	_, end := startSpan(ctx, OperationParse, attribute.String("pombump.path", path))
+	project, err := ParsePOMFile(path)
+	end(err)
+	return project, err
+}
@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelemetryMetrics(t *testing.T) {
+	type call struct {
+		operation string
+		failed    bool
+	}
+	calls := []call{}
+	ctx := WithTelemetry(context.Background(), WithMetrics(func(_ context.Context, operation string, duration time.Duration, err error) {
+		assert.GreaterOrEqual(t, duration, time.Duration(0))
+		calls = append(calls, call{operation: operation, failed: err != nil})
+	}))
+
+	project := &gopom.Project{
+		Dependencies: &[]gopom.Dependency{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.94.Final"}},
+	}
+	result, err := AnalyzeProject(ctx, project)
+	require.NoError(t, err)
+	patches := []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.118.Final"}}
+	directPatches, propertyPatches := PatchStrategy(ctx, result, patches)
+	_, err = PatchProject(ctx, project, directPatches, propertyPatches)
+	require.NoError(t, err)
+
+	repo := fakeRepository(t, map[string]string{})
+	_, err = repo.Versions(ctx, "io.netty", "netty-handler")
+	require.True(t, errors.Is(err, ErrNotFound))
+
+	assert.Equal(t, []call{
+		{operation: OperationAnalyze},
+		{operation: OperationPlan},
+		{operation: OperationApply},
+		{operation: OperationFetch, failed: true},
+	}, calls)
+}
@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/ghodss/yaml"
+)
+
+// AnalysisOutput is what `pombump analyze --output-template` renders, and
+// --output json prints: the analysis of the POMs and, when patches were
+// given, what pombump recommends for them. The recommendations are empty
+// otherwise.
+type AnalysisOutput struct {
+	// POMs are the files analyzed.
+	POMs []string `json:"poms" yaml:"poms"`
+	// Analysis is the analysis of the POMs, merged if there are several.
+	Analysis *AnalysisResult `json:"analysis" yaml:"analysis"`
+	// Report summarizes the analysis.
+	Report *AggregateReport `json:"report" yaml:"report"`
+	// Patches are the direct patches, with their package URL.
+	Patches []Patch `json:"patches,omitempty" yaml:"patches,omitempty"`
+	// Properties are the property patches, sorted by property.
+	Properties []PropertyPatch `json:"properties,omitempty" yaml:"properties,omitempty"`
+	// Parent is how bumping the parent changes the project, if a patch does.
+	Parent *ParentDelta `json:"parent,omitempty" yaml:"parent,omitempty"`
+	// BOMs are the recommended bumps of imported BOMs.
+	BOMs []BOMBump `json:"boms,omitempty" yaml:"boms,omitempty"`
+	// ImportedBOMs are the BOMs the POMs import.
+	ImportedBOMs []BOMInfo `json:"importedBoms,omitempty" yaml:"importedBoms,omitempty"`
+	// Conflicts are the patches requesting different versions of what can
+	// only have one, and the version chosen.
+	Conflicts []VersionConflict `json:"conflicts,omitempty" yaml:"conflicts,omitempty"`
+	// Unfixable are the patches that can not be applied as asked.
+	Unfixable []UnfixableIssue `json:"unfixable,omitempty" yaml:"unfixable,omitempty"`
+	// Candidates record which acceptable version of each patch was picked,
+	// and why.
+	Candidates []CandidateChoice `json:"candidates,omitempty" yaml:"candidates,omitempty"`
+	// Licenses are the licenses the POM declares, and DependencyLicenses
+	// those of its dependencies, when resolved (see ResolveLicenses).
+	Licenses           []License            `json:"licenses,omitempty" yaml:"licenses,omitempty"`
+	DependencyLicenses []DependencyLicenses `json:"dependencyLicenses,omitempty" yaml:"dependencyLicenses,omitempty"`
+	// Warnings are the undefined properties, shadowed BOM versions and
+	// SNAPSHOT versions of the analysis.
+	Warnings []Warning `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	// Suppressed are the warnings, issues and patches left out of the
+	// others by --suppress and the ignore file.
+	Suppressed []SuppressedIssue `json:"suppressed,omitempty" yaml:"suppressed,omitempty"`
+	// Skipped are the patches and property updates dropped due to policy,
+	// such as --strategy-rules and --pin, or as their version is already in
+	// effect.
+	Skipped []SkippedPatch `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+}
+
+// templateFuncs are the functions available to output templates, on top of
+// the text/template builtins.
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"json": func(v any) (string, error) {
+		data, err := json.MarshalIndent(v, "", "  ")
+		return string(data), err
+	},
+	"yaml": func(v any) (string, error) {
+		data, err := yaml.Marshal(v)
+		return strings.TrimSuffix(string(data), "\n"), err
+	},
+}
+
+// LoadOutputTemplate parses a text/template file to render an AnalysisOutput
+// with. Besides the builtins, templates may call join, lower, upper, and
+// json and yaml to embed any value marshaled.
+func LoadOutputTemplate(file string) (*template.Template, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output template: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(file)).Funcs(templateFuncs).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse output template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// RenderOutput renders output through tmpl to w.
+func RenderOutput(w io.Writer, tmpl *template.Template, output AnalysisOutput) error {
+	if err := tmpl.Execute(w, output); err != nil {
+		return fmt.Errorf("failed to render output template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package pkg
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTemplate(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "output.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestRenderOutput(t *testing.T) {
+	tmpl, err := LoadOutputTemplate(writeTemplate(t, `Bumps in {{ join .POMs ", " }}:
+{{ range .Patches }}- {{ .Purl }}
+{{ end }}{{ range .Properties }}- {{ .Property }} to {{ .Value }}
+{{ end }}{{ .Report.Dependencies }} dependencies`))
+	require.NoError(t, err)
+
+	analysis := &AnalysisResult{
+		Dependencies: map[string]*DependencyInfo{
+			"io.netty:netty-codec": {GroupID: "io.netty", ArtifactID: "netty-codec", Version: "4.1.94.Final"},
+		},
+	}
+	var out bytes.Buffer
+	require.NoError(t, RenderOutput(&out, tmpl, AnalysisOutput{
+		POMs:     []string{"a/pom.xml", "b/pom.xml"},
+		Analysis: analysis,
+		Report:   analysis.AggregateReport(),
+		Patches:  WithPurls([]Patch{{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "4.1.100.Final"}}),
+		Properties: SortedPropertyPatches(map[string]string{
+			"slf4j.version":   "2.0.9",
+			"jackson.version": "2.15.3",
+		}),
+	}))
+	assert.Equal(t, `Bumps in a/pom.xml, b/pom.xml:
+- pkg:maven/io.netty/netty-codec@4.1.100.Final
+- jackson.version to 2.15.3
+- slf4j.version to 2.0.9
+1 dependencies`, out.String())
+}
+
+func TestRenderOutputJSON(t *testing.T) {
+	tmpl, err := LoadOutputTemplate(writeTemplate(t, `{{ json .Properties }}`))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, RenderOutput(&out, tmpl, AnalysisOutput{Properties: SortedPropertyPatches(map[string]string{"netty.version": "4.1.100.Final"})}))
+	assert.JSONEq(t, `[{"property": "netty.version", "value": "4.1.100.Final"}]`, out.String())
+}
+
+func TestLoadOutputTemplateErrors(t *testing.T) {
+	_, err := LoadOutputTemplate(filepath.Join(t.TempDir(), "missing.tmpl"))
+	assert.ErrorContains(t, err, "failed to read output template")
+
+	_, err = LoadOutputTemplate(writeTemplate(t, `{{ range .Patches }}`))
+	assert.ErrorContains(t, err, "failed to parse output template")
+
+	tmpl, err := LoadOutputTemplate(writeTemplate(t, `{{ .Nope }}`))
+	require.NoError(t, err)
+	assert.ErrorContains(t, RenderOutput(&bytes.Buffer{}, tmpl, AnalysisOutput{}), "failed to render output template")
+}
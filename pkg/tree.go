@@ -0,0 +1,317 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/chainguard-dev/gopom"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TreeNode is a dependency in a DependencyTree.
+type TreeNode struct {
+	GroupID    string `json:"groupId" yaml:"groupId"`
+	ArtifactID string `json:"artifactId" yaml:"artifactId"`
+	// Version is the version in effect, with properties resolved. It keeps
+	// its ${...} references if they can not be.
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	Type    string `json:"type,omitempty" yaml:"type,omitempty"`
+	Scope   string `json:"scope,omitempty" yaml:"scope,omitempty"`
+	// ControlledBy tells what sets the version, e.g. "property
+	// netty.version" or "BOM io.netty:netty-bom". It is empty for a version
+	// written as is.
+	ControlledBy string      `json:"controlledBy,omitempty" yaml:"controlledBy,omitempty"`
+	Children     []*TreeNode `json:"children,omitempty" yaml:"children,omitempty"`
+	// Error is why the dependencies of the node could not be resolved.
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// DependencyTree is the dependencies and dependencyManagement of a POM,
+// with the transitive dependencies of the former if resolved.
+type DependencyTree struct {
+	// Project is the groupId:artifactId:version of the POM.
+	Project              string      `json:"project" yaml:"project"`
+	Dependencies         []*TreeNode `json:"dependencies" yaml:"dependencies"`
+	DependencyManagement []*TreeNode `json:"dependencyManagement,omitempty" yaml:"dependencyManagement,omitempty"`
+}
+
+// DependencyTree returns the dependency tree of the analyzed POM. With a
+// repository, the transitive dependencies are resolved from it down to
+// depth levels (0 for no limit), each artifact appearing once, where it is
+// nearest to the project as Maven picks it. Their versions are the ones the
+// project manages, if it does, which is what patching changes. Resolve the
+// BOMs first (see ResolveBOMs) to know what they manage.
+func (result *AnalysisResult) DependencyTree(ctx context.Context, repo *MavenRepository, depth int) (_ *DependencyTree, err error) {
+	project := result.project
+	if project == nil {
+		return nil, fmt.Errorf("the dependency tree is only known for a single POM")
+	}
+	result.IndexDependencies(ctx)
+	tree := &DependencyTree{
+		Project:      fmt.Sprintf("%s:%s:%s", projectGroupID(project), project.ArtifactID, interpolate(projectVersion(project), result.Properties)),
+		Dependencies: []*TreeNode{},
+	}
+	props := coordinateProperties(project)
+	if project.Dependencies != nil {
+		for _, dep := range *project.Dependencies {
+			tree.Dependencies = append(tree.Dependencies, result.declaredNode(resolveCoordinates(dep, props)))
+		}
+	}
+	if dm := project.DependencyManagement; dm != nil && dm.Dependencies != nil {
+		for _, dep := range *dm.Dependencies {
+			tree.DependencyManagement = append(tree.DependencyManagement, result.declaredNode(resolveCoordinates(dep, props)))
+		}
+	}
+	if repo == nil {
+		return tree, nil
+	}
+
+	ctx, end := startSpan(ctx, OperationResolve, attribute.String("pombump.resolver", "tree"))
+	defer func() { end(err) }()
+	result.resolveTransitives(ctx, repo, tree, depth)
+	return tree, nil
+}
+
+// declaredNode returns the node of a dependency declared in the project.
+func (result *AnalysisResult) declaredNode(dep gopom.Dependency) *TreeNode {
+	node := &TreeNode{
+		GroupID:    dep.GroupID,
+		ArtifactID: dep.ArtifactID,
+		Version:    interpolate(dep.Version, result.Properties),
+		Type:       dep.Type,
+		Scope:      dep.Scope,
+	}
+	if dep.Version == "" {
+		if info := result.Dependencies[fmt.Sprintf("%s:%s", dep.GroupID, dep.ArtifactID)]; info != nil && info.ManagedBy != nil {
+			node.Version = info.ManagedBy.Version
+			node.ControlledBy = managementSource(info.ManagedBy)
+		} else if bom, ok := result.BOMForGroup(dep.GroupID); ok {
+			node.ControlledBy = fmt.Sprintf("probably BOM %s:%s", bom.GroupID, bom.ArtifactID)
+		} else {
+			node.ControlledBy = "unresolved management"
+		}
+		return node
+	}
+	if name, ok := propertyReference(dep.Version); ok {
+		chain, _, err := result.PropertyChain(name)
+		if err != nil || len(chain) < 2 {
+			node.ControlledBy = "property " + name
+		} else {
+			node.ControlledBy = fmt.Sprintf("property %s through %s", chain[len(chain)-1], strings.Join(chain[:len(chain)-1], ", "))
+		}
+		return node
+	}
+	if names := propertyReferences(dep.Version); len(names) > 0 {
+		node.ControlledBy = "embedded properties " + strings.Join(names, ", ")
+		return node
+	}
+	if dep.Scope != "import" {
+		if m, ok := result.ManagedVersion(dep.GroupID, dep.ArtifactID); ok {
+			node.ControlledBy = "pinned over " + managementSource(m)
+		}
+	}
+	return node
+}
+
+// managementSource names what manages a version, without the version.
+func managementSource(m *Management) string {
+	switch m.Source {
+	case ManagedByDependencyManagement, ManagedByEffectivePOM:
+		return m.Source
+	case ManagedByParent:
+		return fmt.Sprintf("parent %s:%s", m.GroupID, m.ArtifactID)
+	default:
+		return fmt.Sprintf("BOM %s:%s", m.GroupID, m.ArtifactID)
+	}
+}
+
+// treeEntry is a node whose dependencies remain to be resolved.
+type treeEntry struct {
+	node       *TreeNode
+	exclusions []gopom.Exclusion
+	depth      int
+}
+
+// resolveTransitives adds the transitive dependencies to the tree,
+// breadth-first so that the nearest declaration of an artifact wins.
+func (result *AnalysisResult) resolveTransitives(ctx context.Context, repo *MavenRepository, tree *DependencyTree, depth int) {
+	log := clog.FromContext(ctx)
+	seen := map[string]bool{}
+	queue := []treeEntry{}
+	for i, node := range tree.Dependencies {
+		seen[fmt.Sprintf("%s:%s", node.GroupID, node.ArtifactID)] = true
+		var exclusions []gopom.Exclusion
+		if declared := (*result.project.Dependencies)[i]; declared.Exclusions != nil {
+			exclusions = *declared.Exclusions
+		}
+		queue = append(queue, treeEntry{node: node, exclusions: exclusions, depth: 1})
+	}
+
+	for len(queue) > 0 {
+		entry := queue[0]
+		queue = queue[1:]
+		node := entry.node
+		if (depth > 0 && entry.depth > depth) || node.Version == "" || strings.Contains(node.Version, "${") || node.Scope == "system" {
+			continue
+		}
+		gav := fmt.Sprintf("%s:%s:%s", node.GroupID, node.ArtifactID, node.Version)
+		chain, err := fetchParentChain(ctx, repo, node.GroupID, node.ArtifactID, node.Version)
+		if err != nil {
+			log.Warnf("Unable to resolve the dependencies of %s: %v", gav, err)
+			node.Error = err.Error()
+			continue
+		}
+
+		props := chainProperties(chain)
+		var managed map[string]string
+		for _, dep := range chainDependencies(chain) {
+			dep.GroupID = interpolate(dep.GroupID, props)
+			dep.ArtifactID = interpolate(dep.ArtifactID, props)
+			key := fmt.Sprintf("%s:%s", dep.GroupID, dep.ArtifactID)
+			if seen[key] || dep.Optional == "true" || excluded(entry.exclusions, dep) {
+				continue
+			}
+			scope, ok := transitiveScope(node.Scope, dep.Scope)
+			if !ok {
+				continue
+			}
+			seen[key] = true
+			child := &TreeNode{GroupID: dep.GroupID, ArtifactID: dep.ArtifactID, Type: dep.Type, Scope: scope}
+			if m, ok := result.projectManagement(dep.GroupID, dep.ArtifactID); ok {
+				child.Version = m.Version
+				child.ControlledBy = managementSource(m)
+			} else if child.Version = interpolate(dep.Version, props); child.Version == "" {
+				if managed == nil {
+					if managed, err = chainManaged(ctx, repo, chain, map[string]bool{}); err != nil {
+						log.Warnf("Unable to resolve what %s manages: %v", gav, err)
+						managed = map[string]string{}
+					}
+				}
+				child.Version = managed[key]
+			}
+			node.Children = append(node.Children, child)
+
+			exclusions := entry.exclusions
+			if dep.Exclusions != nil {
+				exclusions = append(slices.Clone(exclusions), *dep.Exclusions...)
+			}
+			queue = append(queue, treeEntry{node: child, exclusions: exclusions, depth: entry.depth + 1})
+		}
+	}
+}
+
+// projectManagement returns what manages groupID:artifactID for the project,
+// which then sets its version even when it is a transitive dependency.
+func (result *AnalysisResult) projectManagement(groupID, artifactID string) (*Management, bool) {
+	if dm := result.project.DependencyManagement; dm != nil && dm.Dependencies != nil {
+		for _, managed := range *dm.Dependencies {
+			if managed.GroupID == groupID && managed.ArtifactID == artifactID && managed.Version != "" && managed.Scope != "import" {
+				return &Management{Source: ManagedByDependencyManagement, Version: interpolate(managed.Version, result.Properties)}, true
+			}
+		}
+	}
+	return result.ManagedVersion(groupID, artifactID)
+}
+
+// chainDependencies returns the dependencies of chain[0], with those it
+// inherits from its parents chain[1:], the closest declaration winning.
+func chainDependencies(chain []*gopom.Project) []gopom.Dependency {
+	deps := []gopom.Dependency{}
+	seen := map[string]bool{}
+	for _, p := range chain {
+		if p.Dependencies == nil {
+			continue
+		}
+		for _, dep := range *p.Dependencies {
+			if key := dependencyKey(dep); !seen[key] {
+				seen[key] = true
+				deps = append(deps, dep)
+			}
+		}
+	}
+	return deps
+}
+
+// excluded reports whether one of exclusions, which may use * wildcards,
+// matches dep.
+func excluded(exclusions []gopom.Exclusion, dep gopom.Dependency) bool {
+	for _, e := range exclusions {
+		if (e.GroupID == "*" || e.GroupID == dep.GroupID) && (e.ArtifactID == "*" || e.ArtifactID == dep.ArtifactID) {
+			return true
+		}
+	}
+	return false
+}
+
+// transitiveScope returns the scope of a dependency declared with scope by
+// a dependency in parent scope, as Maven mediates it, and whether it is a
+// transitive dependency at all (test and provided dependencies are not).
+func transitiveScope(parent, scope string) (string, bool) {
+	switch scope {
+	case "test", "provided", "system", "import":
+		return "", false
+	}
+	switch parent {
+	case "test", "provided":
+		return parent, true
+	case "runtime":
+		return "runtime", true
+	}
+	return scope, true
+}
+
+// String renders the tree like mvn dependency:tree, with what controls each
+// version in parentheses.
+func (tree *DependencyTree) String() string {
+	var out strings.Builder
+	out.WriteString(tree.Project + "\n")
+	writeTreeNodes(&out, tree.Dependencies, "")
+	if len(tree.DependencyManagement) > 0 {
+		out.WriteString("\ndependencyManagement\n")
+		writeTreeNodes(&out, tree.DependencyManagement, "")
+	}
+	return out.String()
+}
+
+func writeTreeNodes(out *strings.Builder, nodes []*TreeNode, indent string) {
+	for i, node := range nodes {
+		branch, nested := "+- ", "|  "
+		if i == len(nodes)-1 {
+			branch, nested = "\\- ", "   "
+		}
+		out.WriteString(indent + branch + node.coordinates())
+		var notes []string
+		if node.ControlledBy != "" {
+			notes = append(notes, node.ControlledBy)
+		}
+		if node.Error != "" {
+			notes = append(notes, "unresolved: "+node.Error)
+		}
+		if len(notes) > 0 {
+			out.WriteString(" (" + strings.Join(notes, "; ") + ")")
+		}
+		out.WriteString("\n")
+		writeTreeNodes(out, node.Children, indent+nested)
+	}
+}
+
+// coordinates returns groupId:artifactId[:type]:version[:scope], leaving
+// out the default jar type and compile scope.
+func (node *TreeNode) coordinates() string {
+	parts := []string{node.GroupID, node.ArtifactID}
+	if node.Type != "" && node.Type != "jar" {
+		parts = append(parts, node.Type)
+	}
+	version := node.Version
+	if version == "" {
+		version = "?"
+	}
+	parts = append(parts, version)
+	if node.Scope != "" && node.Scope != "compile" {
+		parts = append(parts, node.Scope)
+	}
+	return strings.Join(parts, ":")
+}
@@ -0,0 +1,207 @@
+package pkg
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const treePOM = `<project>
+  <groupId>com.example</groupId>
+  <artifactId>app</artifactId>
+  <version>1.0</version>
+  <properties>
+    <netty.version>4.1.94.Final</netty.version>
+    <lib.version>${shared.version}</lib.version>
+    <shared.version>2.3</shared.version>
+  </properties>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-buffer</artifactId>
+        <version>4.1.100.Final</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-handler</artifactId>
+      <version>${netty.version}</version>
+      <exclusions>
+        <exclusion>
+          <groupId>io.netty</groupId>
+          <artifactId>netty-transport-native-unix-common</artifactId>
+        </exclusion>
+      </exclusions>
+    </dependency>
+    <dependency>
+      <groupId>com.example</groupId>
+      <artifactId>lib</artifactId>
+      <version>${lib.version}</version>
+      <scope>test</scope>
+    </dependency>
+  </dependencies>
+</project>`
+
+func treeRepository(t *testing.T) *MavenRepository {
+	return fakeRepository(t, map[string]string{
+		"io/netty/netty-handler/4.1.94.Final/netty-handler-4.1.94.Final.pom": `<project>
+  <artifactId>netty-handler</artifactId>
+  <parent>
+    <groupId>io.netty</groupId>
+    <artifactId>netty-parent</artifactId>
+    <version>4.1.94.Final</version>
+  </parent>
+  <dependencies>
+    <dependency>
+      <groupId>${project.groupId}</groupId>
+      <artifactId>netty-buffer</artifactId>
+      <version>${project.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-transport-native-unix-common</artifactId>
+      <version>${project.version}</version>
+    </dependency>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-tcnative</artifactId>
+      <optional>true</optional>
+    </dependency>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-codec</artifactId>
+    </dependency>
+  </dependencies>
+</project>`,
+		"io/netty/netty-parent/4.1.94.Final/netty-parent-4.1.94.Final.pom": `<project>
+  <groupId>io.netty</groupId>
+  <artifactId>netty-parent</artifactId>
+  <version>4.1.94.Final</version>
+  <dependencyManagement>
+    <dependencies>
+      <dependency>
+        <groupId>io.netty</groupId>
+        <artifactId>netty-codec</artifactId>
+        <version>4.1.94.Final</version>
+      </dependency>
+    </dependencies>
+  </dependencyManagement>
+  <dependencies>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <version>4.13.2</version>
+      <scope>test</scope>
+    </dependency>
+  </dependencies>
+</project>`,
+		"io/netty/netty-buffer/4.1.100.Final/netty-buffer-4.1.100.Final.pom": `<project>
+  <groupId>io.netty</groupId>
+  <artifactId>netty-buffer</artifactId>
+  <version>4.1.100.Final</version>
+  <dependencies>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-common</artifactId>
+      <version>4.1.100.Final</version>
+    </dependency>
+    <dependency>
+      <groupId>io.netty</groupId>
+      <artifactId>netty-codec</artifactId>
+      <version>4.1.100.Final</version>
+    </dependency>
+  </dependencies>
+</project>`,
+		"io/netty/netty-common/4.1.100.Final/netty-common-4.1.100.Final.pom": `<project>
+  <groupId>io.netty</groupId>
+  <artifactId>netty-common</artifactId>
+  <version>4.1.100.Final</version>
+</project>`,
+		"io/netty/netty-codec/4.1.94.Final/netty-codec-4.1.94.Final.pom": `<project>
+  <groupId>io.netty</groupId>
+  <artifactId>netty-codec</artifactId>
+  <version>4.1.94.Final</version>
+</project>`,
+		"com/example/lib/2.3/lib-2.3.pom": `<project>
+  <groupId>com.example</groupId>
+  <artifactId>lib</artifactId>
+  <version>2.3</version>
+  <dependencies>
+    <dependency>
+      <groupId>org.slf4j</groupId>
+      <artifactId>slf4j-api</artifactId>
+      <version>2.0.9</version>
+    </dependency>
+  </dependencies>
+</project>`,
+	})
+}
+
+func TestDependencyTree(t *testing.T) {
+	project, err := StreamPOM(strings.NewReader(treePOM))
+	require.NoError(t, err)
+	analysis, err := AnalyzeProject(context.Background(), project)
+	require.NoError(t, err)
+
+	tree, err := analysis.DependencyTree(context.Background(), nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, `com.example:app:1.0
++- io.netty:netty-handler:4.1.94.Final (property netty.version)
+\- com.example:lib:2.3:test (property shared.version through lib.version)
+
+dependencyManagement
+\- io.netty:netty-buffer:4.1.100.Final
+`, tree.String())
+}
+
+func TestDependencyTreeTransitives(t *testing.T) {
+	project, err := StreamPOM(strings.NewReader(treePOM))
+	require.NoError(t, err)
+	analysis, err := AnalyzeProject(context.Background(), project)
+	require.NoError(t, err)
+
+	tree, err := analysis.DependencyTree(context.Background(), treeRepository(t), 0)
+	require.NoError(t, err)
+	// The project manages netty-buffer, netty-handler excludes
+	// netty-transport-native-unix-common, and netty-codec, declared by
+	// netty-handler with the version its parent manages, is nearer there
+	// than in netty-buffer. The repository has no slf4j-api.
+	assert.Equal(t, `com.example:app:1.0
++- io.netty:netty-handler:4.1.94.Final (property netty.version)
+|  +- io.netty:netty-buffer:4.1.100.Final (dependencyManagement)
+|  |  \- io.netty:netty-common:4.1.100.Final
+|  \- io.netty:netty-codec:4.1.94.Final
+\- com.example:lib:2.3:test (property shared.version through lib.version)
+   \- org.slf4j:slf4j-api:2.0.9:test
+`, strings.Split(tree.String(), " (unresolved")[0]+"\n")
+	assert.Contains(t, tree.Dependencies[1].Children[0].Error, "not found in repository")
+
+	tree, err = analysis.DependencyTree(context.Background(), treeRepository(t), 1)
+	require.NoError(t, err)
+	assert.Len(t, tree.Dependencies[0].Children, 2)
+	assert.Empty(t, tree.Dependencies[0].Children[0].Children)
+}
+
+func TestTransitiveScope(t *testing.T) {
+	for _, tc := range []struct {
+		parent, scope, want string
+		ok                  bool
+	}{
+		{"", "", "", true},
+		{"compile", "runtime", "runtime", true},
+		{"runtime", "", "runtime", true},
+		{"test", "compile", "test", true},
+		{"", "test", "", false},
+		{"compile", "provided", "", false},
+	} {
+		got, ok := transitiveScope(tc.parent, tc.scope)
+		assert.Equal(t, tc.want, got, "%s/%s", tc.parent, tc.scope)
+		assert.Equal(t, tc.ok, ok, "%s/%s", tc.parent, tc.scope)
+	}
+}
@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// trivyReport is the subset of the Trivy JSON output (trivy -f json) that we
+// care about.
+type trivyReport struct {
+	Results []struct {
+		Target          string               `json:"Target"`
+		Type            string               `json:"Type"`
+		Vulnerabilities []trivyVulnerability `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+type trivyVulnerability struct {
+	VulnerabilityID  string `json:"VulnerabilityID"`
+	PkgName          string `json:"PkgName"`
+	InstalledVersion string `json:"InstalledVersion"`
+	FixedVersion     string `json:"FixedVersion"`
+	PkgIdentifier    struct {
+		PURL string `json:"PURL"`
+	} `json:"PkgIdentifier"`
+}
+
+// trivyJavaTypes are the Trivy result types that contain Maven artifacts.
+var trivyJavaTypes = map[string]bool{
+	"jar":    true,
+	"pom":    true,
+	"gradle": true,
+	"sbt":    true,
+}
+
+// PatchesFromTrivy reads a Trivy JSON report and turns every fixable Java
+// vulnerability into a Patch. When multiple CVEs point at the same artifact
+// only one patch is created, using the highest fixed version so that all of
+// them get fixed.
+func PatchesFromTrivy(ctx context.Context, reportFile string) ([]Patch, error) {
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading file: %w", err)
+	}
+	var report trivyReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse trivy report: %w", err)
+	}
+
+	findings := []scanFinding{}
+	for _, result := range report.Results {
+		if !trivyJavaTypes[result.Type] {
+			continue
+		}
+		for _, v := range result.Vulnerabilities {
+			// Java packages are named groupId:artifactId
+			groupID, artifactID, found := strings.Cut(v.PkgName, ":")
+			if !found {
+				groupID, artifactID = gaFromMavenPURL(v.PkgIdentifier.PURL)
+			}
+			findings = append(findings, scanFinding{
+				ID:            v.VulnerabilityID,
+				GroupID:       groupID,
+				ArtifactID:    artifactID,
+				Installed:     v.InstalledVersion,
+				FixedVersions: splitFixedVersions(v.FixedVersion),
+			})
+		}
+	}
+	return patchesFromFindings(ctx, "Trivy", findings), nil
+}
+
+// splitFixedVersions splits Trivy's FixedVersion field, which lists one
+// version per release line separated by commas, e.g. "2.12.7.1, 2.13.4.2".
+func splitFixedVersions(fixed string) []string {
+	versions := []string{}
+	for _, v := range strings.Split(fixed, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
@@ -0,0 +1,54 @@
+package pkg
+
+import (
+	"fmt"
+)
+
+// effectiveReason is the reason a patch whose version is already in effect
+// is skipped.
+const effectiveReason = "the version is already in effect"
+
+// SkipEffectivePatches drops the version bumps whose version is already the
+// one in effect, declared or managed, which patching would only write again,
+// and returns them as skipped. Bumps with an operation or a target are kept,
+// as are those of symbolic versions not resolved yet.
+func (result *AnalysisResult) SkipEffectivePatches(patches []Patch) ([]Patch, []SkippedPatch) {
+	current := result.CurrentVersions()
+	kept := make([]Patch, 0, len(patches))
+	var skipped []SkippedPatch
+	for _, patch := range patches {
+		if patch.Operation != "" || patch.Target != "" || !changesVersion(patch) || IsAdvisoryID(patch.Version) {
+			kept = append(kept, patch)
+			continue
+		}
+		key := fmt.Sprintf("%s:%s", patch.GroupID, patch.ArtifactID)
+		version, ok := current[key]
+		if !ok {
+			if managed, found := result.ManagedVersion(patch.GroupID, patch.ArtifactID); found {
+				version = managed.Version
+			}
+		}
+		if version == "" || compareVersions(version, patch.Version) != 0 {
+			kept = append(kept, patch)
+			continue
+		}
+		skipped = append(skipped, SkippedPatch{Name: key, Current: version, Version: patch.Version, Reason: effectiveReason})
+	}
+	return kept, skipped
+}
+
+// SkipEffectiveProperties drops the property updates setting a property
+// to the value it already has, and returns them as skipped.
+func (result *AnalysisResult) SkipEffectiveProperties(propertyPatches map[string]string) (map[string]string, []SkippedPatch) {
+	kept := make(map[string]string, len(propertyPatches))
+	var skipped []SkippedPatch
+	for _, name := range sortedKeys(propertyPatches) {
+		value := propertyPatches[name]
+		if current, ok := result.Properties[name]; ok && current == value {
+			skipped = append(skipped, SkippedPatch{Name: name, Current: current, Version: value, Reason: effectiveReason})
+			continue
+		}
+		kept[name] = value
+	}
+	return kept, skipped
+}
@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkipEffectivePatches(t *testing.T) {
+	project, err := parsePOMData([]byte(analyzerPOM))
+	require.NoError(t, err)
+	analysis, err := AnalyzeProject(context.Background(), project)
+	require.NoError(t, err)
+
+	patches := []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.94.Final"},
+		{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "4.1.100.Final"},
+		{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "4.1.94.Final", Target: targetDependencyManagement},
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.94.Final", Operation: PatchOperationAdd},
+		{GroupID: "org.yaml", ArtifactID: "snakeyaml", Version: "2.2"},
+	}
+	kept, skipped := analysis.SkipEffectivePatches(patches)
+	assert.Equal(t, patches[1:], kept)
+	assert.Equal(t, []SkippedPatch{{Name: "io.netty:netty-handler", Current: "4.1.94.Final", Version: "4.1.94.Final", Reason: effectiveReason}}, skipped)
+
+	properties, skipped := analysis.SkipEffectiveProperties(map[string]string{"netty.version": "4.1.94.Final", "other.version": "1"})
+	assert.Equal(t, map[string]string{"other.version": "1"}, properties)
+	assert.Equal(t, []SkippedPatch{{Name: "netty.version", Current: "4.1.94.Final", Version: "4.1.94.Final", Reason: effectiveReason}}, skipped)
+}
+
+func TestAnalyzerForce(t *testing.T) {
+	ctx := context.Background()
+	path := writeAnalyzerPOM(t)
+	patches := []Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.94.Final"}}
+
+	analyzer := NewAnalyzer(WithoutRemoteAccess())
+	analysis, err := analyzer.Analyze(ctx, path)
+	require.NoError(t, err)
+	rec, err := analyzer.Recommend(ctx, analysis, patches)
+	require.NoError(t, err)
+	assert.Empty(t, rec.PropertyPatches)
+	require.Len(t, rec.Skipped, 1)
+	assert.Equal(t, effectiveReason, rec.Skipped[0].Reason)
+
+	rec, err = NewAnalyzer(WithoutRemoteAccess(), WithForce()).Recommend(ctx, analysis, patches)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"netty.version": "4.1.94.Final"}, rec.PropertyPatches)
+	assert.Empty(t, rec.Skipped)
+}
+
+func TestJUnitReportEffective(t *testing.T) {
+	project, err := parsePOMData([]byte(analyzerPOM))
+	require.NoError(t, err)
+	analysis, err := AnalyzeProject(context.Background(), project)
+	require.NoError(t, err)
+	_, skipped := analysis.SkipEffectivePatches([]Patch{{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.94.Final"}})
+
+	report := NewJUnitReport("pom.xml", analysis, nil, nil, skipped)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, []JUnitTestCase{{
+		Name: "io.netty:netty-handler >= 4.1.94.Final", ClassName: "pom.xml",
+		Skipped: &JUnitSkipped{Message: effectiveReason},
+	}}, report.Suites[0].Cases)
+}
@@ -0,0 +1,82 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Reasons a patch can not be applied.
+const (
+	ReasonVersionNotPublished  = "version not published"
+	ReasonArtifactNotPublished = "artifact not published"
+)
+
+// UnfixableIssue is a requested patch that can not be applied.
+type UnfixableIssue struct {
+	// Code is CodeVersionNotPublished or CodeArtifactNotPublished.
+	Code       string `json:"code" yaml:"code"`
+	GroupID    string `json:"groupId" yaml:"groupId"`
+	ArtifactID string `json:"artifactId" yaml:"artifactId"`
+	Version    string `json:"version" yaml:"version"`
+	Reason     string `json:"reason" yaml:"reason"`
+}
+
+// VerifyVersions checks that the version requested by every patch is
+// actually published in repo. Patches that pass are returned, the others
+// are turned into UnfixableIssues. Version ranges can not be checked and are
+// passed through as is.
+func VerifyVersions(ctx context.Context, repo *MavenRepository, patches []Patch) (_ []Patch, _ []UnfixableIssue, err error) {
+	ctx, end := startSpan(ctx, OperationResolve, attribute.String("pombump.resolver", "verify"))
+	defer func() { end(err) }()
+	log := clog.FromContext(ctx)
+
+	verified := []Patch{}
+	unfixable := []UnfixableIssue{}
+	// Several patches may share an artifact, only look it up once.
+	published := map[string][]string{}
+	for _, p := range patches {
+		if p.Version == "" || p.Operation == PatchOperationRemove {
+			// Only changes exclusions, or removes the dependency.
+			verified = append(verified, p)
+			continue
+		}
+		if isVersionRange(p.Version) {
+			log.Debugf("Not verifying version range %s for %s:%s", p.Version, p.GroupID, p.ArtifactID)
+			verified = append(verified, p)
+			continue
+		}
+		key := fmt.Sprintf("%s:%s", p.GroupID, p.ArtifactID)
+		versions, checked := published[key]
+		if !checked {
+			var err error
+			versions, err = repo.Versions(ctx, p.GroupID, p.ArtifactID)
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				return nil, nil, fmt.Errorf("failed to verify %s: %w", key, err)
+			}
+			published[key] = versions
+		}
+		switch {
+		case len(versions) == 0:
+			log.Warnf("%s is not published, not patching it to %s", key, p.Version)
+			unfixable = append(unfixable, UnfixableIssue{Code: CodeArtifactNotPublished, GroupID: p.GroupID, ArtifactID: p.ArtifactID, Version: p.Version, Reason: ReasonArtifactNotPublished})
+		case !slices.Contains(versions, p.Version):
+			log.Warnf("%s version %s is not published, not patching it", key, p.Version)
+			unfixable = append(unfixable, UnfixableIssue{Code: CodeVersionNotPublished, GroupID: p.GroupID, ArtifactID: p.ArtifactID, Version: p.Version, Reason: ReasonVersionNotPublished})
+		default:
+			verified = append(verified, p)
+		}
+	}
+	return verified, unfixable, nil
+}
+
+// isVersionRange reports whether version is a Maven version range, like
+// [1.4.12,2.0.0)
+func isVersionRange(version string) bool {
+	return strings.HasPrefix(version, "[") || strings.HasPrefix(version, "(")
+}
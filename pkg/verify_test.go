@@ -0,0 +1,29 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyVersions(t *testing.T) {
+	repo := fakeRepository(t, map[string]string{
+		"io/netty/netty-handler/maven-metadata.xml": nettyMetadata,
+	})
+
+	patches := []Patch{
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.100.Final"},
+		{GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.101.Final"},
+		{GroupID: "io.netty", ArtifactID: "netty-codec", Version: "4.1.100.Final"},
+		{GroupID: "ch.qos.logback", ArtifactID: "logback-core", Version: "[1.4.12,2.0.0)"},
+	}
+	verified, unfixable, err := VerifyVersions(context.Background(), repo, patches)
+	require.NoError(t, err)
+	assert.Equal(t, []Patch{patches[0], patches[3]}, verified)
+	assert.Equal(t, []UnfixableIssue{
+		{Code: CodeVersionNotPublished, GroupID: "io.netty", ArtifactID: "netty-handler", Version: "4.1.101.Final", Reason: ReasonVersionNotPublished},
+		{Code: CodeArtifactNotPublished, GroupID: "io.netty", ArtifactID: "netty-codec", Version: "4.1.100.Final", Reason: ReasonArtifactNotPublished},
+	}, unfixable)
+}
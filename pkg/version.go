@@ -0,0 +1,115 @@
+package pkg
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// qualifierOrder ranks well known Maven version qualifiers. Anything not in
+// here sorts after the release qualifiers, alphabetically.
+var qualifierOrder = map[string]int{
+	"alpha":     1,
+	"a":         1,
+	"beta":      2,
+	"b":         2,
+	"milestone": 3,
+	"m":         3,
+	"rc":        4,
+	"cr":        4,
+	"snapshot":  5,
+	"":          6,
+	"ga":        6,
+	"final":     6,
+	"release":   6,
+	"sp":        7,
+}
+
+// compareVersions compares two Maven versions, returning -1, 0 or 1.
+// This is a simplified take on Maven's ComparableVersion, it splits on '.',
+// '-' and digit/letter transitions and compares the pieces one by one.
+func compareVersions(a, b string) int {
+	pa := splitVersion(a)
+	pb := splitVersion(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y string
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if c := compareVersionPart(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// splitVersion breaks a version into its comparable pieces.
+func splitVersion(v string) []string {
+	parts := []string{}
+	var current strings.Builder
+	lastDigit := false
+	flush := func() {
+		if current.Len() > 0 {
+			parts = append(parts, strings.ToLower(current.String()))
+			current.Reset()
+		}
+	}
+	for i, r := range v {
+		if r == '.' || r == '-' || r == '_' {
+			flush()
+			continue
+		}
+		isDigit := unicode.IsDigit(r)
+		if i > 0 && current.Len() > 0 && isDigit != lastDigit {
+			flush()
+		}
+		current.WriteRune(r)
+		lastDigit = isDigit
+	}
+	flush()
+	return parts
+}
+
+// compareVersionPart compares a single piece of a version. Missing pieces
+// are treated as 0 when compared to a number, and as a release otherwise.
+func compareVersionPart(x, y string) int {
+	xn, xErr := strconv.Atoi(x)
+	yn, yErr := strconv.Atoi(y)
+	switch {
+	case xErr == nil && yErr == nil:
+		return compareInts(xn, yn)
+	case xErr == nil && y == "":
+		return compareInts(xn, 0)
+	case x == "" && yErr == nil:
+		return compareInts(0, yn)
+	case xErr == nil:
+		// Numbers sort after qualifiers, 1.0.1 > 1.0-rc1
+		return 1
+	case yErr == nil:
+		return -1
+	}
+	xo, xKnown := qualifierOrder[x]
+	yo, yKnown := qualifierOrder[y]
+	switch {
+	case xKnown && yKnown:
+		return compareInts(xo, yo)
+	case xKnown:
+		return -1
+	case yKnown:
+		return 1
+	}
+	return strings.Compare(x, y)
+}
+
+func compareInts(x, y int) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
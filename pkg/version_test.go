@@ -0,0 +1,26 @@
+package pkg
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0", "1.0.0", 0},
+		{"1.0.1", "1.0.0", 1},
+		{"4.1.94.Final", "4.1.100.Final", -1},
+		{"2.13.4.2", "2.13.4", 1},
+		{"1.0-rc1", "1.0", -1},
+		{"1.0-alpha", "1.0-beta", -1},
+		{"1.0-SNAPSHOT", "1.0", -1},
+		{"30.0-jre", "31.1-jre", -1},
+		{"9.4.53.v20231009", "9.4.51.v20230217", 1},
+	}
+	for _, tc := range testCases {
+		if got := compareVersions(tc.a, tc.b); got != tc.want {
+			t.Errorf("compareVersions(%s, %s) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// versionRestriction is one interval of a Maven version range, like
+// [1.2,2.0). An empty bound is unbounded.
+type versionRestriction struct {
+	lower, upper                   string
+	lowerInclusive, upperInclusive bool
+	// exact is a single version written [1.0].
+	exact bool
+}
+
+func (r versionRestriction) String() string {
+	if r.exact {
+		return "[" + r.lower + "]"
+	}
+	open, closing := "(", ")"
+	if r.lowerInclusive {
+		open = "["
+	}
+	if r.upperInclusive {
+		closing = "]"
+	}
+	return open + r.lower + "," + r.upper + closing
+}
+
+// parseVersionRange splits a Maven version range into its restrictions,
+// e.g. (,1.0],[1.2,) into two. It reports false if version is not a well
+// formed range.
+func parseVersionRange(version string) ([]versionRestriction, bool) {
+	version = strings.TrimSpace(version)
+	if !isVersionRange(version) {
+		return nil, false
+	}
+	restrictions := []versionRestriction{}
+	for version != "" {
+		end := strings.IndexAny(version, "])")
+		if end < 0 || (version[0] != '[' && version[0] != '(') {
+			return nil, false
+		}
+		r := versionRestriction{lowerInclusive: version[0] == '[', upperInclusive: version[end] == ']'}
+		lower, upper, isInterval := strings.Cut(version[1:end], ",")
+		r.lower, r.upper = strings.TrimSpace(lower), strings.TrimSpace(upper)
+		if !isInterval {
+			// Only [1.0] is valid without a comma.
+			if !r.lowerInclusive || !r.upperInclusive || r.lower == "" {
+				return nil, false
+			}
+			r.upper, r.exact = r.lower, true
+		}
+		restrictions = append(restrictions, r)
+		version = strings.TrimPrefix(strings.TrimSpace(version[end+1:]), ",")
+		version = strings.TrimSpace(version)
+	}
+	return restrictions, len(restrictions) > 0
+}
+
+// rewriteVersionRange returns current, a version range, changed to only
+// allow fixed or later: restrictions entirely below fixed are dropped, and
+// the lower bound of the one containing fixed is raised to it. Upper bounds
+// are kept. It reports false if no restriction allows fixed, in which case
+// fixed is returned as is and the range is lost.
+func rewriteVersionRange(current, fixed string) (string, bool) {
+	restrictions, ok := parseVersionRange(current)
+	if !ok {
+		return fixed, false
+	}
+	kept := []string{}
+	for _, r := range restrictions {
+		if r.upper != "" {
+			c := compareVersions(fixed, r.upper)
+			if c > 0 || (c == 0 && !r.upperInclusive) {
+				continue
+			}
+		}
+		if !r.exact && (r.lower == "" || compareVersions(r.lower, fixed) < 0) {
+			r.lower, r.lowerInclusive = fixed, true
+		}
+		kept = append(kept, r.String())
+	}
+	if len(kept) == 0 {
+		return fixed, false
+	}
+	return strings.Join(kept, ","), true
+}
+
+// rangeAtLeast reports whether every version version allows, a range, is
+// at least minimum.
+func rangeAtLeast(version, minimum string) bool {
+	restrictions, ok := parseVersionRange(version)
+	if !ok {
+		return false
+	}
+	for _, r := range restrictions {
+		if r.lower == "" {
+			return false
+		}
+		if compareVersions(r.lower, minimum) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// narrowVersionRange rewrites the version of patch when the dependency it
+// patches uses a version range, directly or through the property, so that
+// the range is narrowed to the fixed version instead of replaced by it.
+func narrowVersionRange(ctx context.Context, result *AnalysisResult, patch Patch, useProperty bool, propertyName string) Patch {
+	if patch.Operation != "" || patch.Version == "" || isVersionRange(patch.Version) {
+		return patch
+	}
+	dep, exists := result.Dependencies[fmt.Sprintf("%s:%s", patch.GroupID, patch.ArtifactID)]
+	if !exists || !dep.VersionRange {
+		return patch
+	}
+	current := dep.Version
+	if useProperty && propertyName != "" {
+		current = result.Properties[propertyName]
+	}
+	log := clog.FromContext(ctx)
+	version, ok := rewriteVersionRange(current, patch.Version)
+	if !ok {
+		log.Warnf("%s is outside the range %s of %s:%s, pinning it", patch.Version, current, patch.GroupID, patch.ArtifactID)
+		return patch
+	}
+	log.Infof("Narrowing the range %s of %s:%s to %s", current, patch.GroupID, patch.ArtifactID, version)
+	patch.Version = version
+	return patch
+}
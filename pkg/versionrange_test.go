@@ -0,0 +1,92 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chainguard-dev/gopom"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersionRange(t *testing.T) {
+	testCases := []struct {
+		version string
+		want    []versionRestriction
+		wantOK  bool
+	}{
+		{"1.0", nil, false},
+		{"[1.2,2.0)", []versionRestriction{{lower: "1.2", upper: "2.0", lowerInclusive: true}}, true},
+		{"[1.0]", []versionRestriction{{lower: "1.0", upper: "1.0", lowerInclusive: true, upperInclusive: true, exact: true}}, true},
+		{"(,1.0], [1.2,)", []versionRestriction{{upper: "1.0", upperInclusive: true}, {lower: "1.2", lowerInclusive: true}}, true},
+		{"(1.0)", nil, false},
+		{"[1.0,2.0", nil, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.version, func(t *testing.T) {
+			got, ok := parseVersionRange(tc.version)
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRewriteVersionRange(t *testing.T) {
+	testCases := []struct {
+		current, fixed string
+		want           string
+		wantOK         bool
+	}{
+		{"[1.2,2.0)", "1.5", "[1.5,2.0)", true},
+		{"[1.2,)", "1.5", "[1.5,)", true},
+		{"(1.6,2.0)", "1.5", "(1.6,2.0)", true},
+		{"(,1.0],[1.2,2.0)", "1.5", "[1.5,2.0)", true},
+		{"[1.0],[2.0]", "1.5", "[2.0]", true},
+		{"[1.2,2.0)", "2.0", "2.0", false},
+		{"[1.2,2.0]", "2.0", "[2.0,2.0]", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.current+" "+tc.fixed, func(t *testing.T) {
+			got, ok := rewriteVersionRange(tc.current, tc.fixed)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.wantOK, ok)
+		})
+	}
+}
+
+func TestCheckVersionRange(t *testing.T) {
+	assert.True(t, checkVersion("a", "1.5", "[1.5,2.0)").Satisfied)
+	assert.False(t, checkVersion("a", "1.5", "[1.2,2.0)").Satisfied)
+	assert.False(t, checkVersion("a", "1.5", "(,2.0)").Satisfied)
+	assert.True(t, checkVersion("a", "[1.2,2.0)", "[1.2,2.0)").Satisfied)
+}
+
+func TestPatchStrategyVersionRange(t *testing.T) {
+	ctx := context.Background()
+	project := &gopom.Project{
+		Properties: &gopom.Properties{Entries: map[string]string{"jackson.version": "[2.15,3)"}},
+		Dependencies: &[]gopom.Dependency{
+			{GroupID: "com.fasterxml.jackson.core", ArtifactID: "jackson-databind", Version: "${jackson.version}"},
+			{GroupID: "ch.qos.logback", ArtifactID: "logback-core", Version: "[1.4.0,2.0.0)"},
+			{GroupID: "org.json", ArtifactID: "json", Version: "[20230101,20231013)"},
+		},
+	}
+	analysis, err := AnalyzeProject(ctx, project)
+	require.NoError(t, err)
+	for _, key := range []string{"com.fasterxml.jackson.core:jackson-databind", "ch.qos.logback:logback-core"} {
+		assert.True(t, analysis.Dependencies[key].VersionRange, key)
+	}
+
+	patches := []Patch{
+		{GroupID: "com.fasterxml.jackson.core", ArtifactID: "jackson-databind", Version: "2.15.3"},
+		{GroupID: "ch.qos.logback", ArtifactID: "logback-core", Version: "1.4.12"},
+		{GroupID: "org.json", ArtifactID: "json", Version: "20231013"},
+	}
+	direct, props := PatchStrategy(ctx, analysis, patches)
+	assert.Equal(t, map[string]string{"jackson.version": "[2.15.3,3)"}, props)
+	assert.Equal(t, []Patch{
+		{GroupID: "ch.qos.logback", ArtifactID: "logback-core", Version: "[1.4.12,2.0.0)"},
+		// Above the range, it can only be pinned.
+		{GroupID: "org.json", ArtifactID: "json", Version: "20231013"},
+	}, direct)
+}
@@ -0,0 +1,69 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// BackupSuffix is appended to the name of a file WriteFileAtomic backs up.
+const BackupSuffix = ".pombump.bak"
+
+// WriteFileAtomic replaces the file at path with data, writing a temporary
+// file next to it and renaming it over path, so that the file is never left
+// partially written. The file keeps its permissions. With backup, the
+// original is first kept as path+BackupSuffix.
+func WriteFileAtomic(path string, data []byte, backup bool) error {
+	mode := fs.FileMode(0644)
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		mode = info.Mode().Perm()
+		if backup {
+			original, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read %s to back it up: %w", path, err)
+			}
+			if err := writeFileAtomic(path+BackupSuffix, original, mode); err != nil {
+				return fmt.Errorf("failed to back up %s: %w", path, err)
+			}
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	return writeFileAtomic(path, data, mode)
+}
+
+// writeFileAtomic writes data to a temporary file in the directory of path,
+// syncs it and renames it to path.
+func writeFileAtomic(path string, data []byte, mode fs.FileMode) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", tmp.Name(), err)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("failed to set the permissions of %s: %w", tmp.Name(), err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pom.xml")
+	require.NoError(t, os.WriteFile(path, []byte("<project/>"), 0600))
+
+	require.NoError(t, WriteFileAtomic(path, []byte("<project>patched</project>"), false))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "<project>patched</project>", string(data))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.NoFileExists(t, path+BackupSuffix)
+
+	// Nothing but the POM is left behind.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestWriteFileAtomicBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pom.xml")
+	require.NoError(t, os.WriteFile(path, []byte("<project/>"), 0644))
+
+	require.NoError(t, WriteFileAtomic(path, []byte("<project>patched</project>"), true))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "<project>patched</project>", string(data))
+	backup, err := os.ReadFile(path + BackupSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, "<project/>", string(backup))
+}
+
+func TestWriteFileAtomicNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pom.xml")
+
+	require.NoError(t, WriteFileAtomic(path, []byte("<project/>"), true))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+	assert.NoFileExists(t, path+BackupSuffix)
+}
+
+func TestWriteFileAtomicMissingDirectory(t *testing.T) {
+	err := WriteFileAtomic(filepath.Join(t.TempDir(), "missing", "pom.xml"), []byte("<project/>"), false)
+	assert.ErrorContains(t, err, "failed to create temporary file")
+}